@@ -10,14 +10,22 @@ import (
 	"github.com/microsoft/typescript-go/internal/api"
 	"github.com/microsoft/typescript-go/internal/bundled"
 	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
 )
 
 func runAPI(args []string) int {
 	flag := flag.NewFlagSet("api", flag.ContinueOnError)
 	cwd := flag.String("cwd", core.Must(os.Getwd()), "current working directory")
+	positionEncoding := flag.String("positionEncoding", string(lsproto.PositionEncodingKindUTF8), "encoding of line and character positions (utf-8 or utf-16)")
+	memoryLimit := flag.Uint64("memoryLimit", 0, "soft limit of the heap in bytes, above which the least recently used projects are closed (0 for no limit)")
+	resyncLimit := flag.Int("resyncLimit", 0, "number of bytes to skip after a malformed message to find the next request, rather than exiting (0 to exit)")
 	if err := flag.Parse(args); err != nil {
 		return 2
 	}
+	if *positionEncoding != string(lsproto.PositionEncodingKindUTF8) && *positionEncoding != string(lsproto.PositionEncodingKindUTF16) {
+		fmt.Fprintf(os.Stderr, "unsupported position encoding %q\n", *positionEncoding)
+		return 2
+	}
 
 	defaultLibraryPath := bundled.LibPath()
 
@@ -30,6 +38,9 @@ func runAPI(args []string) int {
 		Cwd:                *cwd,
 		DefaultLibraryPath: defaultLibraryPath,
 		LogEnabled:         logEnabled,
+		PositionEncoding:   lsproto.PositionEncodingKind(*positionEncoding),
+		MemoryLimit:        *memoryLimit,
+		ResyncLimit:        *resyncLimit,
 	})
 
 	if err := s.Run(); err != nil && !errors.Is(err, io.EOF) {
@@ -1,18 +1,26 @@
 package api
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"runtime"
+	"slices"
 	"sync"
+	"unsafe"
 
-	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
 	"github.com/microsoft/typescript-go/internal/api/encoder"
 	"github.com/microsoft/typescript-go/internal/ast"
 	"github.com/microsoft/typescript-go/internal/astnav"
 	"github.com/microsoft/typescript-go/internal/checker"
+	"github.com/microsoft/typescript-go/internal/collections"
 	"github.com/microsoft/typescript-go/internal/core"
 	"github.com/microsoft/typescript-go/internal/ls"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/module"
 	"github.com/microsoft/typescript-go/internal/project"
 	"github.com/microsoft/typescript-go/internal/project/logging"
 	"github.com/microsoft/typescript-go/internal/tsoptions"
@@ -32,13 +40,31 @@ type API struct {
 	logger  logging.Logger
 	session *project.Session
 
-	projects  map[Handle[project.Project]]tspath.Path
-	filesMu   sync.Mutex
-	files     handleMap[ast.SourceFile]
-	symbolsMu sync.Mutex
-	symbols   handleMap[ast.Symbol]
-	typesMu   sync.Mutex
-	types     handleMap[checker.Type]
+	projects          map[Handle[project.Project]]tspath.Path
+	diagnosticsCaches map[tspath.Path]*ls.DiagnosticsCache
+	filesMu           sync.Mutex
+	files             handleMap[ast.SourceFile]
+	symbolsMu         sync.Mutex
+	symbols           handleMap[ast.Symbol]
+	typesMu           sync.Mutex
+	types             handleMap[checker.Type]
+
+	// closedProjects maps the projects closed with CloseProject to their config file names, so
+	// that the next request on them loads them again.
+	closedProjects map[tspath.Path]string
+	// projectUses maps projects to the number of the request that last used them, to close the
+	// least recently used ones first under memory pressure.
+	projectUses map[tspath.Path]uint64
+	useCount    uint64
+
+	diagnosticsStream func(fileName string, diagnostics []ls.Diagnostic) error
+	progressStream    func(progress *Progress) error
+	codec             payloadCodec
+
+	// Options of the initialize and configure requests.
+	preferGoToSourceDefinition bool
+	maxCompletionEntries       int
+	features                   Features
 }
 
 func NewAPI(init *APIInit) *API {
@@ -48,17 +74,23 @@ func NewAPI(init *APIInit) *API {
 			FS:      init.FS,
 			Options: init.SessionOptions,
 		}),
-		projects: make(map[Handle[project.Project]]tspath.Path),
-		files:    make(handleMap[ast.SourceFile]),
-		symbols:  make(handleMap[ast.Symbol]),
-		types:    make(handleMap[checker.Type]),
+		projects:          make(map[Handle[project.Project]]tspath.Path),
+		diagnosticsCaches: make(map[tspath.Path]*ls.DiagnosticsCache),
+		closedProjects:    make(map[tspath.Path]string),
+		projectUses:       make(map[tspath.Path]uint64),
+		files:             make(handleMap[ast.SourceFile]),
+		symbols:           make(handleMap[ast.Symbol]),
+		types:             make(handleMap[checker.Type]),
+		codec:             jsonCodec{},
 	}
 
 	return api
 }
 
+// HandleRequest decodes a request payload, handles it and encodes its result. The payload is not
+// retained after HandleRequest returns.
 func (api *API) HandleRequest(ctx context.Context, method string, payload []byte) ([]byte, error) {
-	params, err := unmarshalPayload(method, payload)
+	params, err := unmarshalPayload(api.codec, method, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -72,47 +104,252 @@ func (api *API) HandleRequest(ctx context.Context, method string, payload []byte
 		}
 	case MethodGetSourceFile:
 		params := params.(*GetSourceFileParams)
-		sourceFile, err := api.GetSourceFile(params.Project, params.FileName)
+		sourceFile, err := api.GetSourceFile(ctx, params.Project, params.FileName)
+		if err != nil {
+			return nil, err
+		}
+		data, err := encoder.EncodeSourceFile(sourceFile, string(FileHandle(sourceFile)))
 		if err != nil {
 			return nil, err
 		}
-		return encoder.EncodeSourceFile(sourceFile, string(FileHandle(sourceFile)))
+		return api.codec.marshalBinary(data), nil
 	case MethodParseConfigFile:
-		return encodeJSON(api.ParseConfigFile(params.(*ParseConfigFileParams).FileName))
+		return api.encode(api.ParseConfigFile(params.(*ParseConfigFileParams).FileName))
 	case MethodLoadProject:
-		return encodeJSON(api.LoadProject(ctx, params.(*LoadProjectParams).ConfigFileName))
+		params := params.(*LoadProjectParams)
+		return api.encode(withProgress(api, ctx, params.ProgressToken, func(ctx context.Context) (*ProjectResponse, error) {
+			return api.LoadProject(ctx, params.ConfigFileName)
+		}))
 	case MethodGetSymbolAtPosition:
 		params := params.(*GetSymbolAtPositionParams)
-		return encodeJSON(api.GetSymbolAtPosition(ctx, params.Project, params.FileName, int(params.Position)))
+		return api.encode(api.GetSymbolAtPosition(ctx, params.Project, params.FileName, int(params.Position)))
 	case MethodGetSymbolsAtPositions:
 		params := params.(*GetSymbolsAtPositionsParams)
-		return encodeJSON(core.TryMap(params.Positions, func(position uint32) (any, error) {
+		return api.encode(core.TryMap(params.Positions, func(position uint32) (any, error) {
 			return api.GetSymbolAtPosition(ctx, params.Project, params.FileName, int(position))
 		}))
 	case MethodGetSymbolAtLocation:
 		params := params.(*GetSymbolAtLocationParams)
-		return encodeJSON(api.GetSymbolAtLocation(ctx, params.Project, params.Location))
+		return api.encode(api.GetSymbolAtLocation(ctx, params.Project, params.Location))
 	case MethodGetSymbolsAtLocations:
 		params := params.(*GetSymbolsAtLocationsParams)
-		return encodeJSON(core.TryMap(params.Locations, func(location Handle[ast.Node]) (any, error) {
+		return api.encode(core.TryMap(params.Locations, func(location Handle[ast.Node]) (any, error) {
 			return api.GetSymbolAtLocation(ctx, params.Project, location)
 		}))
 	case MethodGetTypeOfSymbol:
 		params := params.(*GetTypeOfSymbolParams)
-		return encodeJSON(api.GetTypeOfSymbol(ctx, params.Project, params.Symbol))
+		return api.encode(api.GetTypeOfSymbol(ctx, params.Project, params.Symbol))
 	case MethodGetTypesOfSymbols:
 		params := params.(*GetTypesOfSymbolsParams)
-		return encodeJSON(core.TryMap(params.Symbols, func(symbol Handle[ast.Symbol]) (any, error) {
+		return api.encode(core.TryMap(params.Symbols, func(symbol Handle[ast.Symbol]) (any, error) {
 			return api.GetTypeOfSymbol(ctx, params.Project, symbol)
 		}))
 	case MethodGetDiagnostics:
 		params := params.(*GetDiagnosticsParams)
-		return encodeJSON((api.GetDiagnostics(ctx, params.Project)))
+		return api.encode(withProgress(api, ctx, params.ProgressToken, func(ctx context.Context) ([]ls.Diagnostic, error) {
+			return api.GetDiagnostics(ctx, params.Project)
+		}))
+	case MethodGetDiagnosticsByFile:
+		params := params.(*GetDiagnosticsParams)
+		return api.encode(api.GetDiagnosticsByFile(ctx, params.Project))
+	case MethodGetCodeFixes:
+		params := params.(*GetCodeFixesParams)
+		return api.encode(api.GetCodeFixes(ctx, params.Project, params.FileName, core.NewTextRange(int(params.Start), int(params.End)), params.ErrorCodes))
+	case MethodGetFileText:
+		params := params.(*GetFileTextParams)
+		text, version, err := api.GetFileText(ctx, params.Project, params.FileName)
+		return api.encode(&FileTextResponse{Text: text, Version: version}, err)
+	case MethodGetCompletions:
+		params := params.(*GetCompletionsParams)
+		return api.encode(api.GetCompletions(ctx, params.Project, params.FileName, int(params.Position), params.FilterByPrefix, params.IncludeCompletionsForModuleExports))
+	case MethodGetCompletionEntryDetails:
+		params := params.(*GetCompletionEntryDetailsParams)
+		return api.encode(api.GetCompletionEntryDetails(ctx, params.Project, params.FileName, int(params.Position), params.EntryName, params.Source))
+	case MethodGetModuleExports:
+		params := params.(*GetModuleExportsParams)
+		return api.encode(api.GetModuleExports(ctx, params.Project, params.FileName))
+	case MethodGetImplementation:
+		params := params.(*GetImplementationParams)
+		return api.encode(api.GetImplementation(ctx, params.Project, params.FileName, int(params.Position)))
+	case MethodGetSyntacticDiagnostics:
+		params := params.(*GetSyntacticDiagnosticsParams)
+		return api.encode(api.GetSyntacticDiagnostics(ctx, params.Project, params.FileName))
+	case MethodGetEditsForFileRename:
+		params := params.(*GetEditsForFileRenameParams)
+		return api.encode(api.GetEditsForFileRename(ctx, params.Project, params.OldFileName, params.NewFileName))
+	case MethodGetTouchingToken:
+		params := params.(*GetTouchingTokenParams)
+		return api.encode(api.GetTouchingToken(ctx, params.Project, params.FileName, int(params.Position), params.PreferLeft))
+	case MethodPrepareTypeHierarchy:
+		params := params.(*TypeHierarchyParams)
+		return api.encode(api.PrepareTypeHierarchy(ctx, params.Project, params.FileName, int(params.Position)))
+	case MethodGetSupertypes:
+		params := params.(*TypeHierarchyParams)
+		return api.encode(api.GetSupertypes(ctx, params.Project, params.FileName, int(params.Position)))
+	case MethodGetSubtypes:
+		params := params.(*TypeHierarchyParams)
+		return api.encode(api.GetSubtypes(ctx, params.Project, params.FileName, int(params.Position)))
+	case MethodWarmup:
+		params := params.(*WarmupParams)
+		_, err := withProgress(api, ctx, params.ProgressToken, func(ctx context.Context) (any, error) {
+			return nil, api.Warmup(ctx, params.Project)
+		})
+		return nil, err
+	case MethodGetGlobalSymbols:
+		params := params.(*GetGlobalSymbolsParams)
+		return api.encode(api.GetGlobalSymbols(ctx, params.Project, params.Query))
+	case MethodGetRefactors:
+		params := params.(*GetRefactorsParams)
+		return api.encode(api.GetRefactors(ctx, params.Project, params.FileName, core.NewTextRange(int(params.Start), int(params.End))))
+	case MethodGetRefactorEdits:
+		params := params.(*GetRefactorEditsParams)
+		return api.encode(api.GetRefactorEdits(ctx, params.Project, params.FileName, core.NewTextRange(int(params.Start), int(params.End)), params.RefactorName, params.ActionName))
+	case MethodGetMatchingBrackets:
+		params := params.(*GetMatchingBracketsParams)
+		return api.encode(api.GetMatchingBrackets(ctx, params.Project, params.FileName, int(params.Position)))
+	case MethodGetNavigateTo:
+		params := params.(*GetNavigateToParams)
+		return api.encode(api.GetNavigateTo(ctx, params.Project, params.Query, params.MaxResults))
+	case MethodGetProgramFiles:
+		params := params.(*GetProgramFilesParams)
+		return api.encode(api.GetProgramFiles(ctx, params.Project))
+	case MethodGetFileIncludeReasons:
+		params := params.(*GetFileIncludeReasonsParams)
+		return api.encode(api.GetFileIncludeReasons(ctx, params.Project, params.FileName))
+	case MethodGetDiagnosticsChunked:
+		params := params.(*GetDiagnosticsChunkedParams)
+		return api.encode(api.GetDiagnosticsChunked(ctx, params.Project, params.ChunkSize))
+	case MethodGetOutliningSpans:
+		params := params.(*GetOutliningSpansParams)
+		return api.encode(api.GetOutliningSpans(ctx, params.Project, params.FileName))
+	case MethodFindReferencesInScope:
+		params := params.(*FindReferencesInScopeParams)
+		return api.encode(api.FindReferencesInScope(ctx, params.Project, params.FileName, int(params.Position), params.Scope))
+	case MethodGetCombinedCodeFix:
+		params := params.(*GetCombinedCodeFixParams)
+		return api.encode(api.GetCombinedCodeFix(ctx, params.Project, params.FileName, params.FixId))
+	case MethodGetEnclosingComment:
+		params := params.(*GetEnclosingCommentParams)
+		return api.encode(api.GetEnclosingComment(ctx, params.Project, params.FileName, int(params.Position)))
+	case MethodGetUnusedExports:
+		params := params.(*GetUnusedExportsParams)
+		return api.encode(api.GetUnusedExports(ctx, params.Project, params.EntryPoints))
+	case MethodGetDefinition:
+		params := params.(*GetDefinitionParams)
+		return api.encode(api.GetDefinition(ctx, params.Project, params.FileName, int(params.Position)))
+	case MethodGetDiagnosticsForContent:
+		params := params.(*GetDiagnosticsForContentParams)
+		return api.encode(api.GetDiagnosticsForContent(ctx, params.Project, params.FileName, params.Content))
+	case MethodGetNavigationBarItems:
+		params := params.(*GetNavigationBarItemsParams)
+		return api.encode(api.GetNavigationBarItems(ctx, params.Project, params.FileName))
+	case MethodGetDiagnosticsPage:
+		params := params.(*GetDiagnosticsPageParams)
+		return api.encode(api.GetDiagnosticsPage(ctx, params.Project, params.MaxResults, params.ContinuationToken))
+	case MethodGetMoveToFileEdits:
+		params := params.(*GetMoveToFileEditsParams)
+		return api.encode(api.GetMoveToFileEdits(ctx, params.Project, params.FileName, core.NewTextRange(int(params.Start), int(params.End)), params.TargetFileName))
+	case MethodGetQuickInfo:
+		params := params.(*GetQuickInfoParams)
+		return api.encode(api.GetQuickInfo(ctx, params.Project, params.FileName, int(params.Position)))
+	case MethodGetSelectionRanges:
+		params := params.(*GetSelectionRangesParams)
+		return api.encode(api.GetSelectionRanges(ctx, params.Project, params.FileName, int(params.Position)))
+	case MethodGetEmitOutput:
+		params := params.(*GetEmitOutputParams)
+		return api.encode(api.GetEmitOutput(ctx, params.Project, params.FileName))
+	case MethodGetAutoImportEdit:
+		params := params.(*GetAutoImportEditParams)
+		return api.encode(api.GetAutoImportEdit(ctx, params.Project, params.FileName, params.SymbolName, params.FromModule))
+	case MethodGetEncodedSemanticClassifications:
+		params := params.(*GetEncodedSemanticClassificationsParams)
+		return api.encode(api.GetEncodedSemanticClassifications(ctx, params.Project, params.FileName, core.NewTextRange(int(params.Start), int(params.End))))
+	case MethodCloseProject:
+		return nil, api.CloseProject(ctx, params.(*CloseProjectParams).Project)
+	case MethodGetMemoryStats:
+		return api.encode(api.GetMemoryStats(ctx, params.(*GetMemoryStatsParams).ForceGC))
+	case MethodSetCompilerOptionsOverride:
+		return nil, api.SetCompilerOptionsOverride(ctx, params.(*SetCompilerOptionsOverrideParams).Options)
+	case MethodGetNodes:
+		params := params.(*GetNodesParams)
+		return api.encode(api.GetNodes(ctx, params.Project, params.FileName, params.Kinds, params.MaxResults))
+	case MethodGetFixAllMissingImports:
+		params := params.(*GetFixAllMissingImportsParams)
+		return api.encode(api.GetFixAllMissingImports(ctx, params.Project, params.FileName))
+	case MethodGetJsxClosingTag:
+		params := params.(*GetJsxClosingTagParams)
+		return api.encode(api.GetJsxClosingTag(ctx, params.Project, params.FileName, int(params.Position)))
+	case MethodGetBreakpointSpan:
+		params := params.(*GetBreakpointSpanParams)
+		return api.encode(api.GetBreakpointSpan(ctx, params.Project, params.FileName, int(params.Position)))
+	case MethodResolveModule:
+		params := params.(*ResolveModuleParams)
+		return api.encode(api.ResolveModule(ctx, params.Project, params.FromFile, params.Specifier))
+	case MethodGetWatchGlobs:
+		params := params.(*GetWatchGlobsParams)
+		return api.encode(api.GetWatchGlobs(ctx, params.Project))
+	case MethodGetTodoComments:
+		params := params.(*GetTodoCommentsParams)
+		return api.encode(api.GetTodoComments(ctx, params.Project, params.FileName, params.Tokens))
+	case MethodGetAliasedSymbol:
+		params := params.(*GetAliasedSymbolParams)
+		return api.encode(api.GetAliasedSymbol(ctx, params.Project, params.FileName, int(params.Position)))
+	case MethodGetDiagnosticsDelta:
+		params := params.(*GetDiagnosticsDeltaParams)
+		return api.encode(api.GetDiagnosticsDelta(ctx, params.Project, params.FileName, params.PreviousResultId))
+	case MethodGetNameOrDottedNameSpan:
+		params := params.(*GetNameOrDottedNameSpanParams)
+		return api.encode(api.GetNameOrDottedNameSpan(ctx, params.Project, params.FileName, int(params.Start), int(params.End)))
+	case MethodGetEffectiveCompilerOptions:
+		return api.encode(api.GetEffectiveCompilerOptions(ctx, params.(*GetEffectiveCompilerOptionsParams).FileName))
+	case MethodGetConfigFileDiagnostics:
+		return api.encode(api.GetConfigFileDiagnostics(ctx, params.(*GetConfigFileDiagnosticsParams).FileName))
+	case MethodPrepareRename:
+		params := params.(*PrepareRenameParams)
+		return api.encode(api.PrepareRename(ctx, params.Project, params.FileName, int(params.Position)))
+	case MethodIsTypeAssignableTo:
+		params := params.(*IsTypeAssignableToParams)
+		return api.encode(api.IsTypeAssignableTo(ctx, params.Project, params.SourceFile, int(params.SourcePosition), params.TargetFile, int(params.TargetPosition)))
 	default:
 		return nil, fmt.Errorf("unhandled API method %q", method)
 	}
 }
 
+// SetDiagnosticsStream makes GetDiagnostics pass each file's diagnostics to fn as soon as that
+// file has been checked, rather than returning them all at once. A nil fn disables streaming.
+func (api *API) SetDiagnosticsStream(fn func(fileName string, diagnostics []ls.Diagnostic) error) {
+	api.diagnosticsStream = fn
+}
+
+// SetProgressStream makes requests with a progress token pass the progress of the request to fn.
+// An error from fn cancels the request. A nil fn disables progress reporting.
+func (api *API) SetProgressStream(fn func(progress *Progress) error) {
+	api.progressStream = fn
+}
+
+// withProgress calls fn with a context that reports the progress of the operation against token,
+// if there is one. The operation is cancelled when a report fails, in which case the error of the
+// report is returned.
+func withProgress[T any](api *API, ctx context.Context, token string, fn func(ctx context.Context) (T, error)) (T, error) {
+	if token == "" || api.progressStream == nil {
+		return fn(ctx)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	result, err := fn(ls.WithProgressReporter(ctx, func(percentage int, message string) {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := api.progressStream(&Progress{Token: token, Percentage: percentage, Message: message}); err != nil {
+			cancel(fmt.Errorf("progress %q cancelled: %w", token, err))
+		}
+	}))
+	if ctx.Err() != nil {
+		return result, context.Cause(ctx)
+	}
+	return result, err
+}
+
 func (api *API) Close() {
 	api.session.Close()
 }
@@ -123,38 +360,273 @@ func (api *API) ParseConfigFile(configFileName string) (*ConfigFileResponse, err
 	if !ok {
 		return nil, fmt.Errorf("could not read file %q", configFileName)
 	}
-	configDir := tspath.GetDirectoryPath(configFileName)
+	parsedCommandLine := api.parseConfigFileContent(configFileName, configFileContent)
+	return &ConfigFileResponse{
+		FileNames: parsedCommandLine.FileNames(),
+		Options:   parsedCommandLine.CompilerOptions(),
+	}, nil
+}
+
+// GetConfigFileDiagnostics parses a config file as ParseConfigFile does and returns the diagnostics
+// of parsing it, such as syntax errors and unknown compiler options, for editors to show in the
+// config file. It does not need a loaded project, so it also reports errors that keep a project
+// from loading.
+func (api *API) GetConfigFileDiagnostics(ctx context.Context, configFileName string) ([]*ls.Diagnostic, error) {
+	configFileName = api.toAbsoluteFileName(configFileName)
+	snapshot, release := api.session.Snapshot()
+	defer release()
+	// The content is read through the snapshot, which also provides the line maps of the ranges.
+	configFileContent, ok := snapshot.ReadFile(configFileName)
+	if !ok {
+		return nil, fmt.Errorf("could not read file %q", configFileName)
+	}
+	parsedCommandLine := api.parseConfigFileContent(configFileName, configFileContent)
+	languageService := ls.NewBindOnlyLanguageService(parsedCommandLine.ConfigFile.SourceFile, snapshot)
+	return languageService.GetConfigFileDiagnostics(ctx, parsedCommandLine)
+}
+
+func (api *API) parseConfigFileContent(configFileName string, configFileContent string) *tsoptions.ParsedCommandLine {
 	tsConfigSourceFile := tsoptions.NewTsconfigSourceFileFromFilePath(configFileName, api.toPath(configFileName), configFileContent)
-	parsedCommandLine := tsoptions.ParseJsonSourceFileConfigFileContent(
+	return tsoptions.ParseJsonSourceFileConfigFileContent(
 		tsConfigSourceFile,
 		api.session,
-		configDir,
+		tspath.GetDirectoryPath(configFileName),
 		nil, /*existingOptions*/
 		configFileName,
 		nil, /*resolutionStack*/
 		nil, /*extraFileExtensions*/
 		nil, /*extendedConfigCache*/
 	)
-	return &ConfigFileResponse{
-		FileNames: parsedCommandLine.FileNames(),
-		Options:   parsedCommandLine.CompilerOptions(),
-	}, nil
+}
+
+// GetEffectiveCompilerOptions returns the config file governing a file and the compiler options
+// that apply to it, after `extends` resolution. A file of a loaded project's program is governed
+// by the project's config file, unless it is a source of a project the loaded project references,
+// whose config file then governs it. For other files, it falls back to the nearest config file the
+// session has parsed for the file, if any.
+func (api *API) GetEffectiveCompilerOptions(ctx context.Context, fileName string) (*EffectiveOptions, error) {
+	fileName = api.toAbsoluteFileName(fileName)
+	file := ast.NewHasFileName(fileName, api.toPath(fileName))
+	var projectPaths []tspath.Path
+	for _, projectPath := range api.projects {
+		if _, closed := api.closedProjects[projectPath]; !closed && !slices.Contains(projectPaths, projectPath) {
+			projectPaths = append(projectPaths, projectPath)
+		}
+	}
+	slices.Sort(projectPaths)
+
+	snapshot, release := api.session.Snapshot()
+	defer release()
+	var redirected *EffectiveOptions
+	for _, projectPath := range projectPaths {
+		p := snapshot.ProjectCollection.GetProjectByPath(projectPath)
+		if p == nil || p.GetProgram() == nil {
+			continue
+		}
+		program := p.GetProgram()
+		if redirect := program.GetRedirectForResolution(file); redirect != nil {
+			if redirected == nil {
+				redirected = &EffectiveOptions{
+					ConfigFileName: redirect.ConfigName(),
+					Options:        module.GetCompilerOptionsWithRedirect(program.Options(), redirect),
+				}
+			}
+			continue
+		}
+		if program.GetSourceFileByPath(file.Path()) != nil {
+			api.useProject(projectPath)
+			return &EffectiveOptions{
+				ConfigFileName: p.ConfigFileName(),
+				Options:        program.Options(),
+			}, nil
+		}
+	}
+	if redirected != nil {
+		return redirected, nil
+	}
+	if configFileName := snapshot.ConfigFileRegistry.GetConfigFileName(file.Path()); configFileName != "" {
+		if config := snapshot.ConfigFileRegistry.GetConfig(api.toPath(configFileName)); config != nil {
+			return &EffectiveOptions{ConfigFileName: configFileName, Options: config.CompilerOptions()}, nil
+		}
+	}
+	return nil, fmt.Errorf("file %q is not part of a loaded project", fileName)
 }
 
 func (api *API) LoadProject(ctx context.Context, configFileName string) (*ProjectResponse, error) {
-	project, err := api.session.OpenProject(ctx, api.toAbsoluteFileName(configFileName))
+	configFileName = api.toAbsoluteFileName(configFileName)
+	ls.ReportProgress(ctx, 0, "Loading project "+configFileName)
+	project, err := api.session.OpenProject(ctx, configFileName)
 	if err != nil {
 		return nil, err
 	}
+	ls.ReportProgress(ctx, 100, "Loaded project "+configFileName)
 	data := NewProjectResponse(project)
 	api.projects[data.Id] = project.ConfigFilePath()
+	delete(api.closedProjects, project.ConfigFilePath())
+	api.useProject(project.ConfigFilePath())
 	return data, nil
 }
 
-func (api *API) GetSymbolAtPosition(ctx context.Context, projectId Handle[project.Project], fileName string, position int) (*SymbolResponse, error) {
+// CloseProject releases the program of a project, its cached diagnostics and the handles of its
+// files and of the symbols declared in them, unless another loaded project shares them. The handle
+// of the project stays valid: the next request on it loads the project again from scratch.
+func (api *API) CloseProject(ctx context.Context, projectId Handle[project.Project]) error {
 	projectPath, ok := api.projects[projectId]
 	if !ok {
-		return nil, errors.New("project ID not found")
+		return errors.New("project ID not found")
+	}
+	if _, closed := api.closedProjects[projectPath]; closed {
+		return nil
+	}
+	snapshot, release := api.session.Snapshot()
+	p := snapshot.ProjectCollection.GetProjectByPath(projectPath)
+	if p == nil {
+		release()
+		return errors.New("project not found")
+	}
+	configFileName := p.ConfigFileName()
+	api.releaseProjectHandles(snapshot, p)
+	release()
+
+	api.session.CloseProject(ctx, projectPath)
+	api.closedProjects[projectPath] = configFileName
+	delete(api.diagnosticsCaches, projectPath)
+	return nil
+}
+
+// discardProjects closes the projects whose programs were built from incomplete module resolution,
+// so that the next request on them loads them again from scratch.
+func (api *API) discardProjects(ctx context.Context, projectPaths []tspath.Path) {
+	for _, projectPath := range projectPaths {
+		closed := false
+		for id, path := range api.projects {
+			if path == projectPath {
+				// The project is found, so closing it cannot fail.
+				_ = api.CloseProject(ctx, id)
+				closed = true
+			}
+		}
+		if !closed {
+			api.session.CloseProject(ctx, projectPath)
+		}
+	}
+}
+
+// closeLeastRecentlyUsedProjects closes loaded projects, least recently used first, as long as
+// overLimit reports that memory is over its limit. Closing a project drops its program, with the
+// module resolver holding its module resolution and package.json caches, and the files only it
+// has read. The handles of the projects stay valid, and the next request on each of them loads it
+// again. It returns the config file names of the closed projects.
+func (api *API) closeLeastRecentlyUsedProjects(ctx context.Context, overLimit func() bool) []string {
+	var ids []Handle[project.Project]
+	var seen collections.Set[tspath.Path]
+	for id, projectPath := range api.projects {
+		if _, closed := api.closedProjects[projectPath]; !closed && seen.AddIfAbsent(projectPath) {
+			ids = append(ids, id)
+		}
+	}
+	slices.SortFunc(ids, func(a, b Handle[project.Project]) int {
+		return cmp.Compare(api.projectUses[api.projects[a]], api.projectUses[api.projects[b]])
+	})
+	var closed []string
+	for _, id := range ids {
+		if !overLimit() {
+			break
+		}
+		projectPath := api.projects[id]
+		if err := api.CloseProject(ctx, id); err != nil {
+			continue
+		}
+		closed = append(closed, api.closedProjects[projectPath])
+	}
+	return closed
+}
+
+// restartFrom makes api, whose session is new, take over the projects of previous as closed
+// projects: their handles stay valid, and the next request on each of them loads it again from
+// scratch. The handles of files, symbols and types and the cached diagnostics are not carried over,
+// while the options of the configure request are.
+func (api *API) restartFrom(previous *API) {
+	snapshot, release := previous.session.Snapshot()
+	defer release()
+	for id, projectPath := range previous.projects {
+		configFileName, closed := previous.closedProjects[projectPath]
+		if !closed {
+			p := snapshot.ProjectCollection.GetProjectByPath(projectPath)
+			if p == nil {
+				continue
+			}
+			configFileName = p.ConfigFileName()
+		}
+		api.projects[id] = projectPath
+		api.closedProjects[projectPath] = configFileName
+	}
+	api.diagnosticsStream = previous.diagnosticsStream
+	api.preferGoToSourceDefinition = previous.preferGoToSourceDefinition
+	api.maxCompletionEntries = previous.maxCompletionEntries
+	api.features = previous.features
+}
+
+// GetMemoryStats returns the heap usage of the server and the approximate size of the program of
+// each loaded project, after a garbage collection if forceGC is set.
+func (api *API) GetMemoryStats(ctx context.Context, forceGC bool) (*MemoryStats, error) {
+	if forceGC {
+		runtime.GC()
+	}
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	stats := &MemoryStats{
+		HeapAlloc: memStats.HeapAlloc,
+		HeapInuse: memStats.HeapInuse,
+		Sys:       memStats.Sys,
+		NumGC:     memStats.NumGC,
+		Projects:  []*ProjectMemoryStats{},
+	}
+	snapshot, release := api.session.Snapshot()
+	defer release()
+	for _, projectId := range slices.Sorted(maps.Keys(api.projects)) {
+		projectPath := api.projects[projectId]
+		if _, closed := api.closedProjects[projectPath]; closed {
+			continue
+		}
+		p := snapshot.ProjectCollection.GetProjectByPath(projectPath)
+		if p == nil || p.GetProgram() == nil {
+			continue
+		}
+		projectStats := &ProjectMemoryStats{Id: projectId, ConfigFileName: p.ConfigFileName()}
+		for _, file := range p.GetProgram().GetSourceFiles() {
+			projectStats.FileCount++
+			projectStats.NodeCount += file.NodeCount
+			projectStats.ApproximateSize += uint64(len(file.Text())) +
+				uint64(file.NodeCount)*uint64(unsafe.Sizeof(ast.Node{})) +
+				uint64(file.SymbolCount)*uint64(unsafe.Sizeof(ast.Symbol{}))
+		}
+		stats.Projects = append(stats.Projects, projectStats)
+	}
+	return stats, ctx.Err()
+}
+
+// SetCompilerOptionsOverride merges the given compiler options, as in the compilerOptions property
+// of a tsconfig.json, over the options of every configured project for subsequent requests,
+// replacing any previous override. The programs of loaded projects are rebuilt, resolving their
+// modules again. Null or empty options clear the override.
+func (api *API) SetCompilerOptionsOverride(ctx context.Context, options jsontext.Value) error {
+	var override *core.CompilerOptions
+	if len(options) > 0 && options.Kind() != 'n' {
+		var diagnostics []*ast.Diagnostic
+		override, diagnostics = tsoptions.ParseCompilerOptionsFromJsonText(string(options), api.session.GetCurrentDirectory())
+		if len(diagnostics) > 0 {
+			return fmt.Errorf("%w: %s", ErrInvalidRequest, diagnostics[0].Message())
+		}
+	}
+	api.session.SetCompilerOptionsOverride(ctx, override)
+	return nil
+}
+
+func (api *API) GetSymbolAtPosition(ctx context.Context, projectId Handle[project.Project], fileName string, position int) (*SymbolResponse, error) {
+	projectPath, err := api.projectPath(ctx, projectId)
+	if err != nil {
+		return nil, err
 	}
 	snapshot, release := api.session.Snapshot()
 	defer release()
@@ -164,7 +636,29 @@ func (api *API) GetSymbolAtPosition(ctx context.Context, projectId Handle[projec
 	}
 
 	languageService := ls.NewLanguageService(project.GetProgram(), snapshot)
-	symbol, err := languageService.GetSymbolAtPosition(ctx, fileName, position)
+	symbol, details, err := languageService.GetSymbolDetailsAtPosition(ctx, fileName, position)
+	if err != nil || symbol == nil {
+		return nil, err
+	}
+	data := NewSymbolResponse(symbol)
+	data.Declarations = details.Declarations
+	data.Documentation = details.Documentation
+	data.Type = details.Type
+	api.symbolsMu.Lock()
+	defer api.symbolsMu.Unlock()
+	api.symbols[data.Id] = symbol
+	return data, nil
+}
+
+// GetAliasedSymbol is GetSymbolAtPosition with aliases, such as imports and re-exports, resolved to
+// the symbol they refer to.
+func (api *API) GetAliasedSymbol(ctx context.Context, projectId Handle[project.Project], fileName string, position int) (*SymbolResponse, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	symbol, err := languageService.GetAliasedSymbol(ctx, fileName, position)
 	if err != nil || symbol == nil {
 		return nil, err
 	}
@@ -176,9 +670,9 @@ func (api *API) GetSymbolAtPosition(ctx context.Context, projectId Handle[projec
 }
 
 func (api *API) GetSymbolAtLocation(ctx context.Context, projectId Handle[project.Project], location Handle[ast.Node]) (*SymbolResponse, error) {
-	projectPath, ok := api.projects[projectId]
-	if !ok {
-		return nil, errors.New("project ID not found")
+	projectPath, err := api.projectPath(ctx, projectId)
+	if err != nil {
+		return nil, err
 	}
 	snapshot, release := api.session.Snapshot()
 	defer release()
@@ -218,9 +712,9 @@ func (api *API) GetSymbolAtLocation(ctx context.Context, projectId Handle[projec
 }
 
 func (api *API) GetTypeOfSymbol(ctx context.Context, projectId Handle[project.Project], symbolHandle Handle[ast.Symbol]) (*TypeResponse, error) {
-	projectPath, ok := api.projects[projectId]
-	if !ok {
-		return nil, errors.New("project ID not found")
+	projectPath, err := api.projectPath(ctx, projectId)
+	if err != nil {
+		return nil, err
 	}
 	snapshot, release := api.session.Snapshot()
 	defer release()
@@ -243,10 +737,10 @@ func (api *API) GetTypeOfSymbol(ctx context.Context, projectId Handle[project.Pr
 	return NewTypeData(t), nil
 }
 
-func (api *API) GetSourceFile(projectId Handle[project.Project], fileName string) (*ast.SourceFile, error) {
-	projectPath, ok := api.projects[projectId]
-	if !ok {
-		return nil, errors.New("project ID not found")
+func (api *API) GetSourceFile(ctx context.Context, projectId Handle[project.Project], fileName string) (*ast.SourceFile, error) {
+	projectPath, err := api.projectPath(ctx, projectId)
+	if err != nil {
+		return nil, err
 	}
 	snapshot, release := api.session.Snapshot()
 	defer release()
@@ -265,10 +759,39 @@ func (api *API) GetSourceFile(projectId Handle[project.Project], fileName string
 	return sourceFile, nil
 }
 
+// GetFileText returns the text of a file as the project's program sees it, along with the version of
+// that text in the session, so clients can check that their view of the file has not diverged.
+func (api *API) GetFileText(ctx context.Context, projectId Handle[project.Project], fileName string) (string, int, error) {
+	projectPath, err := api.projectPath(ctx, projectId)
+	if err != nil {
+		return "", 0, err
+	}
+	snapshot, release := api.session.Snapshot()
+	defer release()
+	project := snapshot.ProjectCollection.GetProjectByPath(projectPath)
+	if project == nil {
+		return "", 0, errors.New("project not found")
+	}
+
+	// A project that has not built a program yet has no source files.
+	var sourceFile *ast.SourceFile
+	if program := project.GetProgram(); program != nil {
+		sourceFile = program.GetSourceFile(fileName)
+	}
+	if sourceFile == nil {
+		return "", 0, fmt.Errorf("%w: %s", ls.ErrNoSourceFile, fileName)
+	}
+	var version int
+	if file := snapshot.GetFile(sourceFile.FileName()); file != nil {
+		version = int(file.Version())
+	}
+	return sourceFile.Text(), version, nil
+}
+
 func (api *API) GetDiagnostics(ctx context.Context, projectId Handle[project.Project]) ([]ls.Diagnostic, error) {
-	projectPath, ok := api.projects[projectId]
-	if !ok {
-		return nil, errors.New("project ID not found")
+	projectPath, err := api.projectPath(ctx, projectId)
+	if err != nil {
+		return nil, err
 	}
 	snapshot, release := api.session.Snapshot()
 	defer release()
@@ -278,22 +801,616 @@ func (api *API) GetDiagnostics(ctx context.Context, projectId Handle[project.Pro
 	}
 
 	languageService := ls.NewLanguageService(project.GetProgram(), snapshot)
+	languageService.SetDiagnosticsCache(api.diagnosticsCache(projectPath))
+	if api.diagnosticsStream != nil {
+		// Everything has been sent by the time streaming completes.
+		if err := languageService.StreamDiagnostics(ctx, api.diagnosticsStream); err != nil {
+			return nil, err
+		}
+		return []ls.Diagnostic{}, nil
+	}
 	diagnostics := languageService.GetDiagnostics(ctx)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	api.symbolsMu.Lock()
 	defer api.symbolsMu.Unlock()
 	return diagnostics, nil
 }
 
+func (api *API) GetDiagnosticsByFile(ctx context.Context, projectId Handle[project.Project]) (map[string][]*ls.Diagnostic, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetDiagnosticsByFile(ctx)
+}
+
+func (api *API) GetCodeFixes(ctx context.Context, projectId Handle[project.Project], fileName string, textRange core.TextRange, errorCodes []int32) ([]*ls.CodeFixAction, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetCodeFixes(ctx, fileName, textRange, errorCodes)
+}
+
+func (api *API) GetCompletions(ctx context.Context, projectId Handle[project.Project], fileName string, position int, filterByPrefix bool, includeCompletionsForModuleExports *bool) (*ls.CompletionInfo, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if includeCompletionsForModuleExports != nil {
+		preferences := api.userPreferences()
+		preferences.IncludeCompletionsForModuleExports = core.BoolToTristate(*includeCompletionsForModuleExports)
+		languageService.SetUserPreferences(preferences)
+	}
+	info, err := languageService.GetCompletions(ctx, fileName, position, filterByPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if api.maxCompletionEntries > 0 && len(info.Entries) > api.maxCompletionEntries {
+		info.Entries = info.Entries[:api.maxCompletionEntries]
+		info.IsIncomplete = true
+	}
+	return info, nil
+}
+
+func (api *API) GetCompletionEntryDetails(ctx context.Context, projectId Handle[project.Project], fileName string, position int, entryName string, source string) (*ls.CompletionEntryDetails, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetCompletionEntryDetails(ctx, fileName, position, entryName, source)
+}
+
+func (api *API) GetModuleExports(ctx context.Context, projectId Handle[project.Project], fileName string) ([]*ls.ExportedSymbolInfo, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetModuleExports(ctx, fileName)
+}
+
+func (api *API) GetImplementation(ctx context.Context, projectId Handle[project.Project], fileName string, position int) ([]ls.DefinitionLocation, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetImplementation(ctx, fileName, position)
+}
+
+func (api *API) GetSyntacticDiagnostics(ctx context.Context, projectId Handle[project.Project], fileName string) ([]*ls.Diagnostic, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetSyntacticDiagnostics(ctx, fileName)
+}
+
+func (api *API) GetEditsForFileRename(ctx context.Context, projectId Handle[project.Project], oldFileName string, newFileName string) (*lsproto.WorkspaceEdit, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetEditsForFileRename(ctx, oldFileName, newFileName)
+}
+
+func (api *API) GetTouchingToken(ctx context.Context, projectId Handle[project.Project], fileName string, position int, preferLeft bool) (*ls.NodeInfo, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetTouchingToken(ctx, fileName, position, preferLeft)
+}
+
+// GetNodes returns the nodes of the given kinds in a file, or all its nodes if kinds is empty, in the
+// order of a depth-first walk of the syntax tree. At most maxResults nodes are returned if it is positive.
+func (api *API) GetNodes(ctx context.Context, projectId Handle[project.Project], fileName string, kinds []ast.Kind, maxResults int) ([]ls.NodeInfo, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	nodes := []ls.NodeInfo{}
+	err = languageService.ForEachNode(ctx, fileName, kinds, func(node ls.NodeInfo) bool {
+		nodes = append(nodes, node)
+		return maxResults <= 0 || len(nodes) < maxResults
+	})
+	return nodes, err
+}
+
+func (api *API) PrepareTypeHierarchy(ctx context.Context, projectId Handle[project.Project], fileName string, position int) ([]*ls.TypeHierarchyItem, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.PrepareTypeHierarchy(ctx, fileName, position)
+}
+
+func (api *API) GetSupertypes(ctx context.Context, projectId Handle[project.Project], fileName string, position int) ([]*ls.TypeHierarchyItem, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetSupertypes(ctx, fileName, position)
+}
+
+func (api *API) GetSubtypes(ctx context.Context, projectId Handle[project.Project], fileName string, position int) ([]*ls.TypeHierarchyItem, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetSubtypes(ctx, fileName, position)
+}
+
+func (api *API) GetGlobalSymbols(ctx context.Context, projectId Handle[project.Project], query string) ([]*ls.SymbolInformation, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetGlobalSymbols(ctx, query)
+}
+
+func (api *API) GetRefactors(ctx context.Context, projectId Handle[project.Project], fileName string, textRange core.TextRange) ([]*ls.ApplicableRefactor, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetRefactors(ctx, fileName, textRange)
+}
+
+func (api *API) GetRefactorEdits(ctx context.Context, projectId Handle[project.Project], fileName string, textRange core.TextRange, refactorName string, actionName string) (*ls.RefactorEditInfo, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetRefactorEdits(ctx, fileName, textRange, refactorName, actionName)
+}
+
+func (api *API) GetMatchingBrackets(ctx context.Context, projectId Handle[project.Project], fileName string, position int) ([]ls.TextRange, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetMatchingBrackets(ctx, fileName, position)
+}
+
+func (api *API) GetNavigateTo(ctx context.Context, projectId Handle[project.Project], query string, maxResults int) ([]ls.NavigateToItem, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetNavigateTo(ctx, query, maxResults)
+}
+
+func (api *API) GetProgramFiles(ctx context.Context, projectId Handle[project.Project]) ([]ls.ProgramFileInfo, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetProgramFiles(ctx)
+}
+
+func (api *API) GetFileIncludeReasons(ctx context.Context, projectId Handle[project.Project], fileName string) ([]ls.FileIncludeReason, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetFileIncludeReasons(ctx, fileName)
+}
+
+func (api *API) GetDiagnosticsChunked(ctx context.Context, projectId Handle[project.Project], chunkSize int) (*ls.ChunkedDiagnostics, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetDiagnosticsChunked(ctx, chunkSize), nil
+}
+
+func (api *API) GetOutliningSpans(ctx context.Context, projectId Handle[project.Project], fileName string) ([]ls.OutliningSpan, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetOutliningSpans(ctx, fileName)
+}
+
+func (api *API) FindReferencesInScope(ctx context.Context, projectId Handle[project.Project], fileName string, position int, scope ls.ReferenceScope) ([]ls.DefinitionLocation, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.FindReferencesInScope(ctx, fileName, position, scope)
+}
+
+func (api *API) GetCombinedCodeFix(ctx context.Context, projectId Handle[project.Project], fileName string, fixId string) (*lsproto.WorkspaceEdit, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetCombinedCodeFix(ctx, fileName, fixId)
+}
+
+func (api *API) GetFixAllMissingImports(ctx context.Context, projectId Handle[project.Project], fileName string) (*ls.MissingImportsFix, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetFixAllMissingImports(ctx, fileName)
+}
+
+func (api *API) GetEnclosingComment(ctx context.Context, projectId Handle[project.Project], fileName string, position int) (*ls.CommentRange, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetEnclosingComment(ctx, fileName, position)
+}
+
+func (api *API) GetUnusedExports(ctx context.Context, projectId Handle[project.Project], entryPoints []string) ([]ls.UnusedExportInfo, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetUnusedExports(ctx, entryPoints)
+}
+
+func (api *API) GetDefinition(ctx context.Context, projectId Handle[project.Project], fileName string, position int) ([]ls.DefinitionLocation, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetDefinition(ctx, fileName, position)
+}
+
+// GetDiagnosticsForContent returns the diagnostics of a file of the given project as if it had the
+// given content, for checking unsaved buffers. The content is only seen by a speculative copy of
+// the current snapshot, which is discarded afterwards, so it neither changes the file for other
+// requests nor requires a document change to be sent first.
+func (api *API) GetDiagnosticsForContent(ctx context.Context, projectId Handle[project.Project], fileName string, content string) ([]*ls.Diagnostic, error) {
+	projectPath, err := api.projectPath(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, release, err := api.session.SpeculativeSnapshot(ctx, projectPath, fileName, content)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	project := snapshot.ProjectCollection.GetProjectByPath(projectPath)
+	if project == nil {
+		return nil, errors.New("project not found")
+	}
+	languageService := ls.NewLanguageService(project.GetProgram(), snapshot)
+	languageService.SetUserPreferences(api.userPreferences())
+	return languageService.GetFileDiagnostics(ctx, fileName)
+}
+
+func (api *API) GetNavigationBarItems(ctx context.Context, projectId Handle[project.Project], fileName string) ([]ls.NavigationBarItem, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetNavigationBarItems(ctx, fileName)
+}
+
+func (api *API) GetDiagnosticsPage(ctx context.Context, projectId Handle[project.Project], maxResults int, continuationToken string) (*ls.DiagnosticsPage, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetDiagnosticsPage(ctx, maxResults, continuationToken)
+}
+
+func (api *API) GetDiagnosticsDelta(ctx context.Context, projectId Handle[project.Project], fileName string, previousResultId string) (*ls.DiagnosticDelta, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetDiagnosticsDelta(ctx, fileName, previousResultId)
+}
+
+func (api *API) GetMoveToFileEdits(ctx context.Context, projectId Handle[project.Project], fileName string, textRange core.TextRange, targetFileName string) (*lsproto.WorkspaceEdit, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetMoveToFileEdits(ctx, fileName, textRange, targetFileName)
+}
+
+func (api *API) GetQuickInfo(ctx context.Context, projectId Handle[project.Project], fileName string, position int) (*ls.QuickInfo, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetQuickInfo(ctx, fileName, position)
+}
+
+func (api *API) GetSelectionRanges(ctx context.Context, projectId Handle[project.Project], fileName string, position int) ([]ls.TextRange, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetSelectionRanges(ctx, fileName, position)
+}
+
+func (api *API) GetEmitOutput(ctx context.Context, projectId Handle[project.Project], fileName string) (*ls.EmitOutput, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetEmitOutput(ctx, fileName)
+}
+
+func (api *API) GetAutoImportEdit(ctx context.Context, projectId Handle[project.Project], fileName string, symbolName string, fromModule string) (*lsproto.TextEdit, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetAutoImportEdit(ctx, fileName, symbolName, fromModule)
+}
+
+func (api *API) GetEncodedSemanticClassifications(ctx context.Context, projectId Handle[project.Project], fileName string, span core.TextRange) (*ls.Classifications, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetEncodedSemanticClassifications(ctx, fileName, span)
+}
+
+func (api *API) GetJsxClosingTag(ctx context.Context, projectId Handle[project.Project], fileName string, position int) (*ls.JsxClosingTagInfo, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetJsxClosingTag(ctx, fileName, position)
+}
+
+func (api *API) GetBreakpointSpan(ctx context.Context, projectId Handle[project.Project], fileName string, position int) (*ls.TextRange, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetBreakpointSpan(ctx, fileName, position)
+}
+
+func (api *API) GetNameOrDottedNameSpan(ctx context.Context, projectId Handle[project.Project], fileName string, start int, end int) (*ls.TextRange, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetNameOrDottedNameSpan(ctx, fileName, start, end)
+}
+
+func (api *API) PrepareRename(ctx context.Context, projectId Handle[project.Project], fileName string, position int) (*ls.PrepareRenameResult, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.PrepareRename(ctx, fileName, position)
+}
+
+func (api *API) ResolveModule(ctx context.Context, projectId Handle[project.Project], fromFile string, specifier string) (*ls.ModuleResolutionResult, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.ResolveModule(ctx, fromFile, specifier)
+}
+
+// GetWatchGlobs returns the files and glob patterns the program of a project depends on, for
+// clients that watch the file system themselves. It reflects the current program, so clients
+// request it again after changes and diff the result to update their watchers.
+func (api *API) GetWatchGlobs(ctx context.Context, projectId Handle[project.Project]) (*WatchGlobsResponse, error) {
+	projectPath, err := api.projectPath(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, release := api.session.Snapshot()
+	defer release()
+	p := snapshot.ProjectCollection.GetProjectByPath(projectPath)
+	if p == nil {
+		return nil, errors.New("project not found")
+	}
+	files, globs := p.WatchHints()
+	return &WatchGlobsResponse{Files: files, Globs: globs}, nil
+}
+
+func (api *API) GetTodoComments(ctx context.Context, projectId Handle[project.Project], fileName string, tokens []ls.TodoCommentToken) ([]ls.TodoComment, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return languageService.GetTodoComments(ctx, fileName, tokens)
+}
+
+// Warmup binds the files and creates a checker for the program of the given project, or of every
+// loaded project if projectId is empty, so that the first request on it is not slowed down.
+func (api *API) Warmup(ctx context.Context, projectId Handle[project.Project]) error {
+	projectIds := []Handle[project.Project]{projectId}
+	if projectId == "" {
+		projectIds = slices.Sorted(maps.Keys(api.projects))
+	}
+	for _, projectId := range projectIds {
+		languageService, release, err := api.languageService(ctx, projectId)
+		if err != nil {
+			return err
+		}
+		err = languageService.Warmup(ctx)
+		release()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (api *API) IsTypeAssignableTo(ctx context.Context, projectId Handle[project.Project], sourceFile string, sourcePos int, targetFile string, targetPos int) (*TypeAssignabilityResponse, error) {
+	languageService, release, err := api.languageService(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	assignable, diagnostics, err := languageService.IsTypeAssignableTo(ctx, sourceFile, sourcePos, targetFile, targetPos)
+	if err != nil {
+		return nil, err
+	}
+	return &TypeAssignabilityResponse{
+		Assignable:  assignable,
+		Diagnostics: diagnostics,
+	}, nil
+}
+
+// projectPath returns the config file path of a project, loading the project again if it was
+// closed with CloseProject.
+func (api *API) projectPath(ctx context.Context, projectId Handle[project.Project]) (tspath.Path, error) {
+	projectPath, ok := api.projects[projectId]
+	if !ok {
+		return "", errors.New("project ID not found")
+	}
+	if configFileName, closed := api.closedProjects[projectPath]; closed {
+		if _, err := api.session.OpenProject(ctx, configFileName); err != nil {
+			return "", err
+		}
+		delete(api.closedProjects, projectPath)
+	}
+	api.useProject(projectPath)
+	return projectPath, nil
+}
+
+func (api *API) useProject(projectPath tspath.Path) {
+	api.useCount++
+	api.projectUses[projectPath] = api.useCount
+}
+
+// releaseProjectHandles releases the handles of the files of a project, and of the symbols
+// declared in them, that are not in the program of another loaded project.
+func (api *API) releaseProjectHandles(snapshot *project.Snapshot, p *project.Project) {
+	program := p.GetProgram()
+	if program == nil {
+		return
+	}
+	isShared := func(file *ast.SourceFile) bool {
+		for _, otherPath := range api.projects {
+			if _, closed := api.closedProjects[otherPath]; closed || otherPath == p.ConfigFilePath() {
+				continue
+			}
+			if other := snapshot.ProjectCollection.GetProjectByPath(otherPath); other != nil && other.GetProgram() != nil && other.GetProgram().GetSourceFileByPath(file.Path()) == file {
+				return true
+			}
+		}
+		return false
+	}
+	isReleased := func(file *ast.SourceFile) bool {
+		return file != nil && program.GetSourceFileByPath(file.Path()) == file && !isShared(file)
+	}
+
+	api.filesMu.Lock()
+	maps.DeleteFunc(api.files, func(_ Handle[ast.SourceFile], file *ast.SourceFile) bool {
+		return isReleased(file)
+	})
+	api.filesMu.Unlock()
+	api.symbolsMu.Lock()
+	maps.DeleteFunc(api.symbols, func(_ Handle[ast.Symbol], symbol *ast.Symbol) bool {
+		return len(symbol.Declarations) != 0 && isReleased(ast.GetSourceFileOfNode(symbol.Declarations[0]))
+	})
+	api.symbolsMu.Unlock()
+}
+
+// languageService returns a language service for the given project in the current snapshot.
+// The returned function releases the snapshot and must be called once the language service is no longer needed.
+func (api *API) languageService(ctx context.Context, projectId Handle[project.Project]) (*ls.LanguageService, func(), error) {
+	projectPath, err := api.projectPath(ctx, projectId)
+	if err != nil {
+		return nil, nil, err
+	}
+	snapshot, release := api.session.Snapshot()
+	project := snapshot.ProjectCollection.GetProjectByPath(projectPath)
+	if project == nil {
+		release()
+		return nil, nil, errors.New("project not found")
+	}
+	languageService := ls.NewLanguageService(project.GetProgram(), snapshot)
+	languageService.SetDiagnosticsCache(api.diagnosticsCache(projectPath))
+	languageService.SetUserPreferences(api.userPreferences())
+	return languageService, release, nil
+}
+
+func (api *API) userPreferences() *ls.UserPreferences {
+	preferences := &ls.UserPreferences{
+		PreferGoToSourceDefinition: api.preferGoToSourceDefinition,
+	}
+	if api.features&FeatureCompletionsForModuleExports != 0 {
+		preferences.IncludeCompletionsForModuleExports = core.TSTrue
+	}
+	if api.features&FeatureCompletionsForImportStatements != 0 {
+		preferences.IncludeCompletionsForImportStatements = core.TSTrue
+	}
+	return preferences
+}
+
+func (api *API) diagnosticsCache(projectPath tspath.Path) *ls.DiagnosticsCache {
+	cache, ok := api.diagnosticsCaches[projectPath]
+	if !ok {
+		cache = ls.NewDiagnosticsCache()
+		api.diagnosticsCaches[projectPath] = cache
+	}
+	return cache
+}
+
 func (api *API) releaseHandle(handle string) error {
 	switch handle[0] {
 	case handlePrefixProject:
 		projectId := Handle[project.Project](handle)
-		_, ok := api.projects[projectId]
+		projectPath, ok := api.projects[projectId]
 		if !ok {
 			return fmt.Errorf("project %q not found", handle)
 		}
 		delete(api.projects, projectId)
+		delete(api.diagnosticsCaches, projectPath)
 	case handlePrefixFile:
 		fileId := Handle[ast.SourceFile](handle)
 		api.filesMu.Lock()
@@ -335,11 +1452,12 @@ func (api *API) toPath(fileName string) tspath.Path {
 	return tspath.ToPath(fileName, api.session.GetCurrentDirectory(), api.session.FS().UseCaseSensitiveFileNames())
 }
 
-func encodeJSON(v any, err error) ([]byte, error) {
+// encode encodes a request's result in the negotiated payload format.
+func (api *API) encode(v any, err error) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	b, err := json.Marshal(v)
+	b, err := api.codec.marshal(v)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,62 @@
+package api_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/microsoft/typescript-go/internal/api"
+	"github.com/microsoft/typescript-go/internal/bundled"
+	"github.com/microsoft/typescript-go/internal/ls"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/project"
+	"github.com/microsoft/typescript-go/internal/vfs/vfstest"
+	"gotest.tools/v3/assert"
+)
+
+func TestAPIGetFileText(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	files := map[string]any{
+		"/src/tsconfig.json": `{"compilerOptions": {"noLib": true}, "files": ["a.ts"]}`,
+		"/src/a.ts":          "export const a = 1;",
+		"/src/other.ts":      "export const other = 1;",
+	}
+	a := api.NewAPI(&api.APIInit{
+		Logger: api.NoLogger{},
+		FS:     bundled.WrapFS(vfstest.FromMap(files, false /*useCaseSensitiveFileNames*/)),
+		SessionOptions: &project.SessionOptions{
+			CurrentDirectory:   "/src",
+			DefaultLibraryPath: bundled.LibPath(),
+			PositionEncoding:   lsproto.PositionEncodingKindUTF8,
+		},
+	})
+	ctx := context.Background()
+	loaded, err := a.LoadProject(ctx, "/src/tsconfig.json")
+	assert.NilError(t, err)
+
+	text, version, err := a.GetFileText(ctx, loaded.Id, "/src/a.ts")
+	assert.NilError(t, err)
+	assert.Equal(t, text, "export const a = 1;")
+	assert.Equal(t, version, 0)
+
+	// Once the session has applied an edit of the file, its text is returned with the version of
+	// the edit.
+	session := a.Session()
+	session.DidOpenFile(ctx, "file:///src/a.ts", 1, "export const a = 1;", lsproto.LanguageKindTypeScript)
+	session.DidChangeFile(ctx, "file:///src/a.ts", 2, []lsproto.TextDocumentContentChangePartialOrWholeDocument{
+		{WholeDocument: &lsproto.TextDocumentContentChangeWholeDocument{Text: "export const a = 2;"}},
+	})
+	_, err = session.GetLanguageService(ctx, "file:///src/a.ts")
+	assert.NilError(t, err)
+	text, version, err = a.GetFileText(ctx, loaded.Id, "/src/a.ts")
+	assert.NilError(t, err)
+	assert.Equal(t, text, "export const a = 2;")
+	assert.Equal(t, version, 2)
+
+	// Files outside of the program have no text to return, even if they exist.
+	_, _, err = a.GetFileText(ctx, loaded.Id, "/src/other.ts")
+	assert.ErrorIs(t, err, ls.ErrNoSourceFile)
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/go-json-experiment/json"
+	"github.com/microsoft/typescript-go/internal/api/msgpack"
+)
+
+// PayloadFormat is the wire format of the payload element of request and response messages.
+type PayloadFormat string
+
+const (
+	// PayloadFormatJSON sends payloads as JSON inside a bin element. This is the default.
+	PayloadFormatJSON PayloadFormat = "json"
+	// PayloadFormatMessagePack sends payloads as native MessagePack values, avoiding a second
+	// encoding layer. Binary results, such as encoded source files, are sent as bin values.
+	PayloadFormatMessagePack PayloadFormat = "msgpack"
+)
+
+// payloadCodec reads, writes, decodes and encodes request and response payloads, so that
+// request handlers do not depend on the payload format negotiated with the client.
+type payloadCodec interface {
+	// readPayload reads a payload element, appending it to buf, which may be nil.
+	readPayload(r *bufio.Reader, buf []byte) ([]byte, error)
+	writePayload(w *bufio.Writer, payload []byte) error
+	unmarshal(data []byte, v any) error
+	marshal(v any) ([]byte, error)
+	// marshalBinary encodes a result that is already binary.
+	marshalBinary(data []byte) []byte
+}
+
+func newPayloadCodec(format PayloadFormat) (payloadCodec, error) {
+	switch format {
+	case PayloadFormatJSON:
+		return jsonCodec{}, nil
+	case PayloadFormatMessagePack:
+		return msgpackCodec{}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown payload format %q", ErrInvalidRequest, format)
+	}
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) readPayload(r *bufio.Reader, buf []byte) ([]byte, error) {
+	return readBin(r, buf)
+}
+
+func (jsonCodec) writePayload(w *bufio.Writer, payload []byte) error {
+	return writeBin(w, payload)
+}
+
+func (jsonCodec) unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) marshalBinary(data []byte) []byte {
+	return data
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) readPayload(r *bufio.Reader, buf []byte) ([]byte, error) {
+	return msgpack.AppendValue(buf, r)
+}
+
+func (msgpackCodec) writePayload(w *bufio.Writer, payload []byte) error {
+	if len(payload) == 0 {
+		// Requests without a result respond with nil.
+		return w.WriteByte(0xc0)
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+func (msgpackCodec) unmarshal(data []byte, v any) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+func (msgpackCodec) marshal(v any) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (msgpackCodec) marshalBinary(data []byte) []byte {
+	return msgpack.AppendBin(nil, data)
+}
+
+// maxPooledBufferSize is the largest buffer returned to payloadBufferPool, so that a single large
+// request does not keep its memory alive for the lifetime of the server.
+const maxPooledBufferSize = 1 << 20
+
+// payloadBufferPool holds the buffers that request payloads are read into. A buffer is owned by
+// the request read into it until the request has been handled, after which it is put back.
+var payloadBufferPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, 0, 512)
+		return &buf
+	},
+}
+
+func getPayloadBuffer() *[]byte {
+	return payloadBufferPool.Get().(*[]byte)
+}
+
+// putPayloadBuffer returns buf to the pool, keeping payload's storage when it has grown.
+// Neither buf nor payload may be used afterwards.
+func putPayloadBuffer(buf *[]byte, payload []byte) {
+	if cap(payload) > maxPooledBufferSize {
+		return
+	}
+	*buf = payload[:0]
+	payloadBufferPool.Put(buf)
+}
+
+func isBinHeader(t byte) bool {
+	switch MessagePackType(t) {
+	case MessagePackTypeBin8, MessagePackTypeBin16, MessagePackTypeBin32:
+		return true
+	}
+	return false
+}
+
+// readBin reads a bin element into buf, which is grown as needed and may be nil.
+func readBin(r *bufio.Reader, buf []byte) ([]byte, error) {
+	// https://github.com/msgpack/msgpack/blob/master/spec.md#bin-format-family
+	t, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	var sizeLength int
+	switch MessagePackType(t) {
+	case MessagePackTypeBin8:
+		sizeLength = 1
+	case MessagePackTypeBin16:
+		sizeLength = 2
+	case MessagePackTypeBin32:
+		sizeLength = 4
+	default:
+		return nil, fmt.Errorf("%w: expected binary data length (0xc4-0xc6), received: 0x%2x", ErrInvalidRequest, t)
+	}
+	// The length is read byte by byte, since binary.Read allocates for every value it reads.
+	var size uint
+	for range sizeLength {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		size = size<<8 | uint(b)
+	}
+	payload := slices.Grow(buf[:0], int(size))[:size]
+	bytesRead, err := io.ReadFull(r, payload)
+	if err != nil {
+		return nil, err
+	}
+	if bytesRead != int(size) {
+		return nil, fmt.Errorf("%w: expected %d bytes, read %d", ErrInvalidRequest, size, bytesRead)
+	}
+	return payload, nil
+}
+
+func writeBin(w *bufio.Writer, payload []byte) error {
+	if err := writeBinHeader(w, len(payload)); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// writeBinString writes s as a bin element without converting it to a byte slice first.
+func writeBinString(w *bufio.Writer, s string) error {
+	if err := writeBinHeader(w, len(s)); err != nil {
+		return err
+	}
+	_, err := w.WriteString(s)
+	return err
+}
+
+func writeBinHeader(w *bufio.Writer, length int) error {
+	if length < 256 {
+		if err := w.WriteByte(byte(MessagePackTypeBin8)); err != nil {
+			return err
+		}
+		if err := w.WriteByte(byte(length)); err != nil {
+			return err
+		}
+	} else if length < 1<<16 {
+		if err := w.WriteByte(byte(MessagePackTypeBin16)); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, uint16(length)); err != nil {
+			return err
+		}
+	} else {
+		if err := w.WriteByte(byte(MessagePackTypeBin32)); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, uint32(length)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package api
+
+import "github.com/microsoft/typescript-go/internal/project"
+
+var ParseAccessibleEntries = parseAccessibleEntries
+
+// Session returns the session of api, for tests to edit files as an editor would.
+func (api *API) Session() *project.Session {
+	return api.session
+}
+
+// EnableCallback enables a callback as the configure request would.
+func (s *Server) EnableCallback(callback string) error {
+	return s.enableCallbacks([]string{callback})
+}
+
+// SetCallbackBypassPrefixes sets the callback bypass prefixes as the configure request would.
+func (s *Server) SetCallbackBypassPrefixes(prefixes []string) error {
+	return s.setCallbackBypassPrefixes(prefixes)
+}
@@ -0,0 +1,361 @@
+package msgpack
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+
+	"github.com/go-json-experiment/json"
+)
+
+// Unmarshal decodes the MessagePack value in data into the value pointed to by v.
+// A nil value sets the target to its zero value, and map entries that do not match
+// a struct field are ignored.
+func Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("msgpack: Unmarshal requires a non-nil pointer, got %T", v)
+	}
+	d := &decoder{data: data}
+	if err := d.decode(rv.Elem()); err != nil {
+		return err
+	}
+	if d.pos != len(d.data) {
+		return errors.New("msgpack: unexpected data after top-level value")
+	}
+	return nil
+}
+
+func (d *decoder) decode(v reflect.Value) error {
+	t := v.Type()
+	if t.Kind() != reflect.Pointer && t.Kind() != reflect.Interface && unmarshalsViaJSON(reflect.PointerTo(t)) {
+		return d.decodeViaJSON(v)
+	}
+
+	if next, err := d.peek(); err != nil {
+		return err
+	} else if next == typeNil {
+		d.pos++
+		v.SetZero()
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(t.Elem()))
+		}
+		return d.decode(v.Elem())
+	case reflect.Interface:
+		if t.NumMethod() != 0 {
+			return fmt.Errorf("msgpack: cannot decode into non-empty interface %s", t)
+		}
+		value, err := d.decodeAny()
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(value))
+		return nil
+	case reflect.Struct:
+		if getStructFields(t).viaJSON {
+			return d.decodeViaJSON(v)
+		}
+	}
+
+	tok, err := d.next()
+	if err != nil {
+		return err
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		if tok.kind == kindBool {
+			v.SetBool(tok.bool)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, ok := tok.asInt(); ok && !v.OverflowInt(n) {
+			v.SetInt(n)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n, ok := tok.asUint(); ok && !v.OverflowUint(n) {
+			v.SetUint(n)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, ok := tok.asFloat(); ok {
+			v.SetFloat(f)
+			return nil
+		}
+	case reflect.String:
+		if tok.kind == kindStr || tok.kind == kindBin {
+			v.SetString(string(tok.bytes))
+			return nil
+		}
+	case reflect.Slice:
+		if isByteSlice(t) && (tok.kind == kindBin || tok.kind == kindStr) {
+			v.SetBytes(append([]byte{}, tok.bytes...))
+			return nil
+		}
+		if tok.kind == kindArray {
+			v.Set(reflect.MakeSlice(t, tok.len, tok.len))
+			for i := range tok.len {
+				if err := d.decode(v.Index(i)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case reflect.Array:
+		if tok.kind == kindArray {
+			v.SetZero()
+			for i := range tok.len {
+				if i >= v.Len() {
+					if _, err := d.skip(); err != nil {
+						return err
+					}
+					continue
+				}
+				if err := d.decode(v.Index(i)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case reflect.Map:
+		if tok.kind == kindMap {
+			return d.decodeMap(v, tok.len)
+		}
+	case reflect.Struct:
+		if tok.kind == kindMap {
+			return d.decodeStruct(v, tok.len)
+		}
+	}
+	return fmt.Errorf("msgpack: cannot decode %s into %s", tok.kind, t)
+}
+
+func (d *decoder) decodeMap(v reflect.Value, length int) error {
+	t := v.Type()
+	if v.IsNil() {
+		v.Set(reflect.MakeMapWithSize(t, length))
+	}
+	for range length {
+		keyToken, err := d.next()
+		if err != nil {
+			return err
+		}
+		key := reflect.New(t.Key()).Elem()
+		if err := setMapKey(key, keyToken); err != nil {
+			return err
+		}
+		value := reflect.New(t.Elem()).Elem()
+		if err := d.decode(value); err != nil {
+			return err
+		}
+		v.SetMapIndex(key, value)
+	}
+	return nil
+}
+
+func setMapKey(key reflect.Value, tok token) error {
+	switch key.Kind() {
+	case reflect.String:
+		s, err := tok.asKey()
+		if err != nil {
+			return err
+		}
+		key.SetString(s)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := tok.asInt()
+		if tok.kind == kindStr {
+			var err error
+			n, err = strconv.ParseInt(string(tok.bytes), 10, 64)
+			ok = err == nil
+		}
+		if ok && !key.OverflowInt(n) {
+			key.SetInt(n)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, ok := tok.asUint()
+		if tok.kind == kindStr {
+			var err error
+			n, err = strconv.ParseUint(string(tok.bytes), 10, 64)
+			ok = err == nil
+		}
+		if ok && !key.OverflowUint(n) {
+			key.SetUint(n)
+			return nil
+		}
+	}
+	return fmt.Errorf("msgpack: cannot decode %s into map key of type %s", tok.kind, key.Type())
+}
+
+func (d *decoder) decodeStruct(v reflect.Value, length int) error {
+	fields := getStructFields(v.Type())
+	for range length {
+		keyToken, err := d.next()
+		if err != nil {
+			return err
+		}
+		if keyToken.kind != kindStr {
+			return fmt.Errorf("msgpack: cannot decode %s into struct field name", keyToken.kind)
+		}
+		f, ok := fields.byName[string(keyToken.bytes)]
+		if !ok {
+			if _, err := d.skip(); err != nil {
+				return err
+			}
+			continue
+		}
+		fv := v
+		for i, x := range f.index {
+			if i > 0 && fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					fv.Set(reflect.New(fv.Type().Elem()))
+				}
+				fv = fv.Elem()
+			}
+			fv = fv.Field(x)
+		}
+		if err := d.decode(fv); err != nil {
+			return fmt.Errorf("%w (field %q)", err, f.name)
+		}
+	}
+	return nil
+}
+
+// decodeAny decodes a value into the types the json package uses for an empty interface,
+// except that bin values become byte slices.
+func (d *decoder) decodeAny() (any, error) {
+	tok, err := d.next()
+	if err != nil {
+		return nil, err
+	}
+	switch tok.kind {
+	case kindNil:
+		return nil, nil
+	case kindBool:
+		return tok.bool, nil
+	case kindInt, kindUint, kindFloat:
+		f, _ := tok.asFloat()
+		return f, nil
+	case kindStr:
+		return string(tok.bytes), nil
+	case kindBin:
+		return append([]byte{}, tok.bytes...), nil
+	case kindArray:
+		values := make([]any, tok.len)
+		for i := range tok.len {
+			if values[i], err = d.decodeAny(); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	case kindMap:
+		values := make(map[string]any, tok.len)
+		for range tok.len {
+			keyToken, err := d.next()
+			if err != nil {
+				return nil, err
+			}
+			key, err := keyToken.asKey()
+			if err != nil {
+				return nil, err
+			}
+			if values[key], err = d.decodeAny(); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("msgpack: cannot decode %s", tok.kind)
+}
+
+func (d *decoder) decodeViaJSON(v reflect.Value) error {
+	raw, err := d.skip()
+	if err != nil {
+		return err
+	}
+	data, err := ToJSON(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v.Addr().Interface())
+}
+
+// asKey returns a map key as a string, formatting integer keys in decimal as the json package does.
+func (tok token) asKey() (string, error) {
+	switch tok.kind {
+	case kindStr:
+		return string(tok.bytes), nil
+	case kindInt:
+		return strconv.FormatInt(tok.int, 10), nil
+	case kindUint:
+		return strconv.FormatUint(tok.uint, 10), nil
+	}
+	return "", fmt.Errorf("msgpack: cannot use %s as a map key", tok.kind)
+}
+
+func (tok token) asInt() (int64, bool) {
+	switch tok.kind {
+	case kindInt:
+		return tok.int, true
+	case kindUint:
+		return int64(tok.uint), tok.uint <= math.MaxInt64
+	case kindFloat:
+		return int64(tok.float), tok.float == math.Trunc(tok.float) && tok.float >= math.MinInt64 && tok.float < math.MaxInt64
+	}
+	return 0, false
+}
+
+func (tok token) asUint() (uint64, bool) {
+	switch tok.kind {
+	case kindInt:
+		return uint64(tok.int), tok.int >= 0
+	case kindUint:
+		return tok.uint, true
+	case kindFloat:
+		return uint64(tok.float), tok.float == math.Trunc(tok.float) && tok.float >= 0 && tok.float < math.MaxUint64
+	}
+	return 0, false
+}
+
+func (tok token) asFloat() (float64, bool) {
+	switch tok.kind {
+	case kindInt:
+		return float64(tok.int), true
+	case kindUint:
+		return float64(tok.uint), true
+	case kindFloat:
+		return tok.float, true
+	}
+	return 0, false
+}
+
+func (k kind) String() string {
+	switch k {
+	case kindNil:
+		return "nil"
+	case kindBool:
+		return "bool"
+	case kindInt, kindUint:
+		return "integer"
+	case kindFloat:
+		return "float"
+	case kindStr:
+		return "str"
+	case kindBin:
+		return "bin"
+	case kindArray:
+		return "array"
+	case kindMap:
+		return "map"
+	case kindExt:
+		return "ext"
+	}
+	return "unknown"
+}
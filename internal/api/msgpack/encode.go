@@ -0,0 +1,174 @@
+package msgpack
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"github.com/go-json-experiment/json"
+)
+
+// Marshal returns the MessagePack encoding of v.
+func Marshal(v any) ([]byte, error) {
+	return appendValue(nil, reflect.ValueOf(v))
+}
+
+func appendValue(b []byte, v reflect.Value) ([]byte, error) {
+	if !v.IsValid() {
+		return appendNil(b), nil
+	}
+	t := v.Type()
+	if t.Kind() != reflect.Pointer && t.Kind() != reflect.Interface && marshalsViaJSON(reflect.PointerTo(t)) {
+		// Like the json package, call methods with pointer receivers even on values that are not addressable.
+		if !v.CanAddr() {
+			addressable := reflect.New(t).Elem()
+			addressable.Set(v)
+			v = addressable
+		}
+		return appendViaJSON(b, v.Addr().Interface())
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return appendBool(b, v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return appendInt(b, v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return appendUint(b, v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return appendFloat(b, v.Float()), nil
+	case reflect.String:
+		return appendString(b, v.String()), nil
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return appendNil(b), nil
+		}
+		return appendValue(b, v.Elem())
+	case reflect.Slice:
+		if isByteSlice(t) {
+			return AppendBin(b, v.Bytes()), nil
+		}
+		fallthrough
+	case reflect.Array:
+		var err error
+		b = appendArrayHeader(b, v.Len())
+		for i := range v.Len() {
+			if b, err = appendValue(b, v.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case reflect.Map:
+		return appendMap(b, v)
+	case reflect.Struct:
+		return appendStruct(b, v)
+	}
+	return nil, fmt.Errorf("msgpack: unsupported type %s", t)
+}
+
+func appendMap(b []byte, v reflect.Value) ([]byte, error) {
+	type entry struct {
+		key   string
+		value reflect.Value
+	}
+	entries := make([]entry, 0, v.Len())
+	for iter := v.MapRange(); iter.Next(); {
+		key, err := mapKeyString(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry{key, iter.Value()})
+	}
+	slices.SortFunc(entries, func(a, b entry) int { return cmp.Compare(a.key, b.key) })
+
+	var err error
+	b = appendMapHeader(b, len(entries))
+	for _, entry := range entries {
+		b = appendString(b, entry.key)
+		if b, err = appendValue(b, entry.value); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func mapKeyString(key reflect.Value) (string, error) {
+	switch key.Kind() {
+	case reflect.String:
+		return key.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("msgpack: unsupported map key type %s", key.Type())
+}
+
+func appendStruct(b []byte, v reflect.Value) ([]byte, error) {
+	fields := getStructFields(v.Type())
+	if fields.viaJSON {
+		return appendViaJSON(b, v.Interface())
+	}
+
+	b, at := reserveContainer(b, typeMap32)
+	count := 0
+	for i := range fields.fields {
+		f := &fields.fields[i]
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok {
+			continue
+		}
+		if f.omitZero && isZero(fv) {
+			continue
+		}
+		start := len(b)
+		b = appendString(b, f.name)
+		valueStart := len(b)
+		var err error
+		if b, err = appendValue(b, fv); err != nil {
+			return nil, err
+		}
+		if f.omitEmpty && isEmpty(b[valueStart:]) {
+			b = b[:start]
+			continue
+		}
+		count++
+	}
+	patchContainer(b, at, count)
+	return b, nil
+}
+
+// fieldByIndex returns the field at the given index path. It returns false if the path goes
+// through a nil embedded pointer, in which case the field is omitted.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func isZero(v reflect.Value) bool {
+	if v.Type().Implements(isZeroerType) {
+		if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+			return true
+		}
+		return v.Interface().(interface{ IsZero() bool }).IsZero()
+	}
+	return v.IsZero()
+}
+
+func appendViaJSON(b []byte, v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return appendJSON(b, data)
+}
@@ -0,0 +1,179 @@
+package msgpack
+
+import (
+	"encoding"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-json-experiment/json"
+	"github.com/microsoft/typescript-go/internal/core"
+)
+
+type field struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	omitZero  bool
+}
+
+type structFields struct {
+	fields []field
+	byName map[string]*field
+	// viaJSON is set for structs using field options that are only supported by the json package.
+	viaJSON bool
+}
+
+var structFieldsCache sync.Map // map[reflect.Type]*structFields
+
+var (
+	jsonMarshalerType   = reflect.TypeFor[json.Marshaler]()
+	jsonMarshalerToType = reflect.TypeFor[json.MarshalerTo]()
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	jsonUnmarshalerFrom = reflect.TypeFor[json.UnmarshalerFrom]()
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	isZeroerType        = reflect.TypeFor[interface{ IsZero() bool }]()
+)
+
+func marshalsViaJSON(t reflect.Type) bool {
+	return t.Implements(jsonMarshalerType) || t.Implements(jsonMarshalerToType) || t.Implements(textMarshalerType)
+}
+
+func unmarshalsViaJSON(t reflect.Type) bool {
+	return t.Implements(jsonUnmarshalerType) || t.Implements(jsonUnmarshalerFrom) || t.Implements(textUnmarshalerType)
+}
+
+func isByteSlice(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+func getStructFields(t reflect.Type) *structFields {
+	if cached, ok := structFieldsCache.Load(t); ok {
+		return cached.(*structFields)
+	}
+	fields := computeStructFields(t)
+	cached, _ := structFieldsCache.LoadOrStore(t, fields)
+	return cached.(*structFields)
+}
+
+type candidate struct {
+	field
+	depth  int
+	tagged bool
+}
+
+// computeStructFields lists the fields of a struct in breadth-first order of inlined structs,
+// resolving name conflicts the way the json package does: the shallowest field wins, then the
+// only explicitly named field at that depth; otherwise all conflicting fields are dropped.
+func computeStructFields(t reflect.Type) *structFields {
+	result := &structFields{byName: make(map[string]*field)}
+	var candidates []candidate
+	type queued struct {
+		typ   reflect.Type
+		index []int
+	}
+	queue := []queued{{typ: t}}
+	visited := map[reflect.Type]bool{t: true}
+	for depth := 0; len(queue) > 0; depth++ {
+		level := queue
+		queue = nil
+		for _, q := range level {
+			for i := range q.typ.NumField() {
+				sf := q.typ.Field(i)
+				tag, hasTag := sf.Tag.Lookup("json")
+				if tag == "-" {
+					continue
+				}
+				name, options, _ := strings.Cut(tag, ",")
+				index := append(slices.Clip(q.index), i)
+				inline := false
+				omitEmpty, omitZero := false, false
+				for option := range strings.SplitSeq(options, ",") {
+					switch option {
+					case "":
+					case "omitempty":
+						omitEmpty = true
+					case "omitzero":
+						omitZero = true
+					case "inline":
+						inline = true
+					default:
+						result.viaJSON = true
+					}
+				}
+				if strings.HasPrefix(name, "'") {
+					result.viaJSON = true
+				}
+				if sf.Anonymous && name == "" {
+					inline = true
+				}
+				if inline {
+					ft := sf.Type
+					if ft.Kind() == reflect.Pointer && ft.Name() == "" {
+						ft = ft.Elem()
+					}
+					if ft.Kind() != reflect.Struct || marshalsViaJSON(ft) || marshalsViaJSON(reflect.PointerTo(ft)) {
+						if sf.Anonymous && !hasTag && !sf.IsExported() {
+							continue
+						}
+						result.viaJSON = true
+						continue
+					}
+					if !visited[ft] {
+						visited[ft] = true
+						queue = append(queue, queued{typ: ft, index: index})
+					}
+					continue
+				}
+				if !sf.IsExported() {
+					continue
+				}
+				tagged := name != ""
+				if !tagged {
+					name = sf.Name
+				}
+				candidates = append(candidates, candidate{
+					field:  field{name: name, index: index, omitEmpty: omitEmpty, omitZero: omitZero},
+					depth:  depth,
+					tagged: tagged,
+				})
+			}
+		}
+	}
+
+	byName := make(map[string][]candidate)
+	var order []string
+	for _, c := range candidates {
+		if _, ok := byName[c.name]; !ok {
+			order = append(order, c.name)
+		}
+		byName[c.name] = append(byName[c.name], c)
+	}
+	for _, name := range order {
+		conflicting := byName[name]
+		shallowest := conflicting[0].depth
+		var atShallowest []candidate
+		for _, c := range conflicting {
+			if c.depth == shallowest {
+				atShallowest = append(atShallowest, c)
+			}
+		}
+		if len(atShallowest) > 1 {
+			tagged := core.Filter(atShallowest, func(c candidate) bool { return c.tagged })
+			if len(tagged) != 1 {
+				continue
+			}
+			atShallowest = tagged
+		}
+		result.fields = append(result.fields, atShallowest[0].field)
+	}
+	slices.SortStableFunc(result.fields, func(a, b field) int {
+		return slices.Compare(a.index, b.index)
+	})
+	for i := range result.fields {
+		result.byName[result.fields[i].name] = &result.fields[i]
+	}
+	return result
+}
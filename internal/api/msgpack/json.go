@@ -0,0 +1,151 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+// ToJSON converts a MessagePack value to JSON. Bin values become base64 strings,
+// matching how the json package encodes byte slices.
+func ToJSON(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := jsontext.NewEncoder(&buf)
+	d := &decoder{data: data}
+	if err := d.writeJSON(enc); err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, errors.New("msgpack: unexpected data after top-level value")
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func (d *decoder) writeJSON(enc *jsontext.Encoder) error {
+	tok, err := d.next()
+	if err != nil {
+		return err
+	}
+	switch tok.kind {
+	case kindNil:
+		return enc.WriteToken(jsontext.Null)
+	case kindBool:
+		return enc.WriteToken(jsontext.Bool(tok.bool))
+	case kindInt:
+		return enc.WriteToken(jsontext.Int(tok.int))
+	case kindUint:
+		return enc.WriteToken(jsontext.Uint(tok.uint))
+	case kindFloat:
+		return enc.WriteToken(jsontext.Float(tok.float))
+	case kindStr:
+		return enc.WriteToken(jsontext.String(string(tok.bytes)))
+	case kindBin:
+		return enc.WriteToken(jsontext.String(base64.StdEncoding.EncodeToString(tok.bytes)))
+	case kindArray:
+		if err := enc.WriteToken(jsontext.BeginArray); err != nil {
+			return err
+		}
+		for range tok.len {
+			if err := d.writeJSON(enc); err != nil {
+				return err
+			}
+		}
+		return enc.WriteToken(jsontext.EndArray)
+	case kindMap:
+		if err := enc.WriteToken(jsontext.BeginObject); err != nil {
+			return err
+		}
+		for range tok.len {
+			keyToken, err := d.next()
+			if err != nil {
+				return err
+			}
+			key, err := keyToken.asKey()
+			if err != nil {
+				return err
+			}
+			if err := enc.WriteToken(jsontext.String(key)); err != nil {
+				return err
+			}
+			if err := d.writeJSON(enc); err != nil {
+				return err
+			}
+		}
+		return enc.WriteToken(jsontext.EndObject)
+	}
+	return fmt.Errorf("msgpack: cannot convert %s to JSON", tok.kind)
+}
+
+// FromJSON converts a JSON value to MessagePack.
+func FromJSON(data []byte) ([]byte, error) {
+	return appendJSON(nil, data)
+}
+
+func appendJSON(b []byte, data []byte) ([]byte, error) {
+	dec := jsontext.NewDecoder(bytes.NewReader(data))
+	// Containers are written with 32-bit headers whose lengths are patched once they are closed.
+	type container struct {
+		at    int
+		count int
+	}
+	var stack []container
+	for {
+		tok, err := dec.ReadToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(stack) > 0 && tok.Kind() != ']' && tok.Kind() != '}' {
+			stack[len(stack)-1].count++
+		}
+		switch tok.Kind() {
+		case 'n':
+			b = appendNil(b)
+		case 'f', 't':
+			b = appendBool(b, tok.Bool())
+		case '"':
+			b = appendString(b, tok.String())
+		case '0':
+			b = appendJSONNumber(b, tok.String())
+		case '[':
+			var at int
+			b, at = reserveContainer(b, typeArray32)
+			stack = append(stack, container{at: at})
+		case '{':
+			var at int
+			b, at = reserveContainer(b, typeMap32)
+			stack = append(stack, container{at: at})
+		case ']', '}':
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			count := top.count
+			if tok.Kind() == '}' {
+				// Names and values were both counted.
+				count /= 2
+			}
+			patchContainer(b, top.at, count)
+		}
+	}
+	return b, nil
+}
+
+func appendJSONNumber(b []byte, number string) []byte {
+	if !strings.ContainsAny(number, ".eE") {
+		if n, err := strconv.ParseInt(number, 10, 64); err == nil {
+			return appendInt(b, n)
+		}
+		if n, err := strconv.ParseUint(number, 10, 64); err == nil {
+			return appendUint(b, n)
+		}
+	}
+	f, _ := strconv.ParseFloat(number, 64)
+	return appendFloat(b, f)
+}
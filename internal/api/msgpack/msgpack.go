@@ -0,0 +1,425 @@
+// Package msgpack encodes and decodes MessagePack values.
+//
+// Go values are mapped to MessagePack the same way the json package maps them to JSON:
+// structs become maps keyed by their JSON field names, with the same omitempty and omitzero
+// rules, and nil slices and maps become empty arrays and maps. Byte slices become bin values.
+// Types with JSON marshaling methods, and structs using field options other than a name,
+// omitempty, omitzero and inline, are encoded through JSON and transcoded, so that their
+// representation matches what they would produce as JSON.
+//
+// See https://github.com/msgpack/msgpack/blob/master/spec.md.
+package msgpack
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	typeNil      = 0xc0
+	typeFalse    = 0xc2
+	typeTrue     = 0xc3
+	typeBin8     = 0xc4
+	typeBin16    = 0xc5
+	typeBin32    = 0xc6
+	typeExt8     = 0xc7
+	typeExt16    = 0xc8
+	typeExt32    = 0xc9
+	typeFloat32  = 0xca
+	typeFloat64  = 0xcb
+	typeUint8    = 0xcc
+	typeUint16   = 0xcd
+	typeUint32   = 0xce
+	typeUint64   = 0xcf
+	typeInt8     = 0xd0
+	typeInt16    = 0xd1
+	typeInt32    = 0xd2
+	typeInt64    = 0xd3
+	typeFixExt1  = 0xd4
+	typeFixExt16 = 0xd8
+	typeStr8     = 0xd9
+	typeStr16    = 0xda
+	typeStr32    = 0xdb
+	typeArray16  = 0xdc
+	typeArray32  = 0xdd
+	typeMap16    = 0xde
+	typeMap32    = 0xdf
+)
+
+// header describes the leading bytes of a value: the number of bytes following the type byte
+// that encode a length or a number, and for containers whether elements follow.
+type header struct {
+	// size is the number of bytes after the type byte holding the length or the value itself.
+	size int
+	// length is the fixed length for fixstr, fixarray and fixmap values.
+	length int
+	kind   kind
+}
+
+type kind uint8
+
+const (
+	kindNil kind = iota
+	kindBool
+	kindInt
+	kindUint
+	kindFloat
+	kindStr
+	kindBin
+	kindArray
+	kindMap
+	kindExt
+)
+
+func headerOf(t byte) (header, error) {
+	switch {
+	case t <= 0x7f:
+		return header{kind: kindUint}, nil
+	case t <= 0x8f:
+		return header{kind: kindMap, length: int(t & 0x0f)}, nil
+	case t <= 0x9f:
+		return header{kind: kindArray, length: int(t & 0x0f)}, nil
+	case t <= 0xbf:
+		return header{kind: kindStr, length: int(t & 0x1f)}, nil
+	case t >= 0xe0:
+		return header{kind: kindInt}, nil
+	}
+	switch t {
+	case typeNil:
+		return header{kind: kindNil}, nil
+	case typeFalse, typeTrue:
+		return header{kind: kindBool}, nil
+	case typeBin8, typeBin16, typeBin32:
+		return header{kind: kindBin, size: 1 << (t - typeBin8)}, nil
+	case typeExt8, typeExt16, typeExt32:
+		return header{kind: kindExt, size: 1 << (t - typeExt8)}, nil
+	case typeFloat32:
+		return header{kind: kindFloat, size: 4}, nil
+	case typeFloat64:
+		return header{kind: kindFloat, size: 8}, nil
+	case typeUint8, typeUint16, typeUint32, typeUint64:
+		return header{kind: kindUint, size: 1 << (t - typeUint8)}, nil
+	case typeInt8, typeInt16, typeInt32, typeInt64:
+		return header{kind: kindInt, size: 1 << (t - typeInt8)}, nil
+	case typeStr8, typeStr16, typeStr32:
+		return header{kind: kindStr, size: 1 << (t - typeStr8)}, nil
+	case typeArray16, typeArray32:
+		return header{kind: kindArray, size: 2 << (t - typeArray16)}, nil
+	case typeMap16, typeMap32:
+		return header{kind: kindMap, size: 2 << (t - typeMap16)}, nil
+	}
+	if t >= typeFixExt1 && t <= typeFixExt16 {
+		return header{kind: kindExt, length: 1 << (t - typeFixExt1)}, nil
+	}
+	return header{}, fmt.Errorf("msgpack: invalid type byte 0x%02x", t)
+}
+
+func readUint(b []byte) uint64 {
+	switch len(b) {
+	case 1:
+		return uint64(b[0])
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b))
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b))
+	default:
+		return binary.BigEndian.Uint64(b)
+	}
+}
+
+// ReadValue reads a single complete value from r and returns its encoded bytes.
+func ReadValue(r *bufio.Reader) ([]byte, error) {
+	return AppendValue(nil, r)
+}
+
+// AppendValue reads a single complete value from r and appends its encoded bytes to b.
+func AppendValue(b []byte, r *bufio.Reader) ([]byte, error) {
+	value := b
+	for remaining := 1; remaining > 0; remaining-- {
+		t, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		value = append(value, t)
+		h, err := headerOf(t)
+		if err != nil {
+			return nil, err
+		}
+		start := len(value)
+		if value, err = readN(r, value, h.size); err != nil {
+			return nil, err
+		}
+		length := h.length
+		if h.size > 0 && h.kind != kindInt && h.kind != kindUint && h.kind != kindFloat {
+			length = int(readUint(value[start:]))
+		}
+		switch h.kind {
+		case kindStr, kindBin:
+			value, err = readN(r, value, length)
+		case kindExt:
+			// The extension type byte precedes the data.
+			value, err = readN(r, value, length+1)
+		case kindArray:
+			remaining += length
+		case kindMap:
+			remaining += 2 * length
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+func readN(r *bufio.Reader, value []byte, n int) ([]byte, error) {
+	if n == 0 {
+		return value, nil
+	}
+	start := len(value)
+	value = append(value, make([]byte, n)...)
+	if _, err := io.ReadFull(r, value[start:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return value, nil
+}
+
+// token is a single decoded value header: a scalar, or the start of a container of Len elements.
+type token struct {
+	kind  kind
+	bool  bool
+	int   int64
+	uint  uint64
+	float float64
+	// bytes holds the contents of str and bin values. It aliases the input.
+	bytes []byte
+	// len is the number of elements of an array or entries of a map.
+	len int
+}
+
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) peek() (byte, error) {
+	if d.pos >= len(d.data) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return d.data[d.pos], nil
+}
+
+func (d *decoder) take(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.pos < n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *decoder) next() (token, error) {
+	t, err := d.peek()
+	if err != nil {
+		return token{}, err
+	}
+	d.pos++
+	h, err := headerOf(t)
+	if err != nil {
+		return token{}, err
+	}
+	b, err := d.take(h.size)
+	if err != nil {
+		return token{}, err
+	}
+	tok := token{kind: h.kind}
+	switch h.kind {
+	case kindBool:
+		tok.bool = t == typeTrue
+	case kindUint:
+		if h.size == 0 {
+			tok.uint = uint64(t)
+		} else {
+			tok.uint = readUint(b)
+		}
+	case kindInt:
+		switch h.size {
+		case 0:
+			tok.int = int64(int8(t))
+		case 1:
+			tok.int = int64(int8(b[0]))
+		case 2:
+			tok.int = int64(int16(binary.BigEndian.Uint16(b)))
+		case 4:
+			tok.int = int64(int32(binary.BigEndian.Uint32(b)))
+		default:
+			tok.int = int64(binary.BigEndian.Uint64(b))
+		}
+	case kindFloat:
+		if h.size == 4 {
+			tok.float = float64(math.Float32frombits(binary.BigEndian.Uint32(b)))
+		} else {
+			tok.float = math.Float64frombits(binary.BigEndian.Uint64(b))
+		}
+	case kindStr, kindBin, kindExt:
+		length := h.length
+		if h.size > 0 {
+			length = int(readUint(b))
+		}
+		if h.kind == kindExt {
+			length++
+		}
+		if tok.bytes, err = d.take(length); err != nil {
+			return token{}, err
+		}
+	case kindArray, kindMap:
+		tok.len = h.length
+		if h.size > 0 {
+			tok.len = int(readUint(b))
+		}
+	}
+	return tok, nil
+}
+
+// skip advances past a single complete value and returns its encoded bytes.
+func (d *decoder) skip() ([]byte, error) {
+	start := d.pos
+	for remaining := 1; remaining > 0; remaining-- {
+		tok, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		switch tok.kind {
+		case kindArray:
+			remaining += tok.len
+		case kindMap:
+			remaining += 2 * tok.len
+		}
+	}
+	return d.data[start:d.pos], nil
+}
+
+func appendNil(b []byte) []byte {
+	return append(b, typeNil)
+}
+
+func appendBool(b []byte, v bool) []byte {
+	if v {
+		return append(b, typeTrue)
+	}
+	return append(b, typeFalse)
+}
+
+func appendInt(b []byte, v int64) []byte {
+	switch {
+	case v >= 0:
+		return appendUint(b, uint64(v))
+	case v >= -32:
+		return append(b, byte(v))
+	case v >= math.MinInt8:
+		return append(b, typeInt8, byte(v))
+	case v >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, typeInt16), uint16(v))
+	case v >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, typeInt32), uint32(v))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, typeInt64), uint64(v))
+	}
+}
+
+func appendUint(b []byte, v uint64) []byte {
+	switch {
+	case v <= 0x7f:
+		return append(b, byte(v))
+	case v <= math.MaxUint8:
+		return append(b, typeUint8, byte(v))
+	case v <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, typeUint16), uint16(v))
+	case v <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, typeUint32), uint32(v))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, typeUint64), v)
+	}
+}
+
+func appendFloat(b []byte, v float64) []byte {
+	return binary.BigEndian.AppendUint64(append(b, typeFloat64), math.Float64bits(v))
+}
+
+func appendString(b []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n <= 31:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, typeStr8, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, typeStr16), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, typeStr32), uint32(n))
+	}
+	return append(b, s...)
+}
+
+// AppendBin appends data to b as a bin value.
+func AppendBin(b []byte, data []byte) []byte {
+	n := len(data)
+	switch {
+	case n <= math.MaxUint8:
+		b = append(b, typeBin8, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, typeBin16), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, typeBin32), uint32(n))
+	}
+	return append(b, data...)
+}
+
+func appendArrayHeader(b []byte, n int) []byte {
+	switch {
+	case n <= 15:
+		return append(b, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, typeArray16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, typeArray32), uint32(n))
+	}
+}
+
+func appendMapHeader(b []byte, n int) []byte {
+	switch {
+	case n <= 15:
+		return append(b, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, typeMap16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, typeMap32), uint32(n))
+	}
+}
+
+// reserveContainer appends a 32-bit container header whose length is filled in by patchContainer,
+// for containers whose number of elements is not known until they have been encoded.
+func reserveContainer(b []byte, t byte) ([]byte, int) {
+	return append(b, t, 0, 0, 0, 0), len(b)
+}
+
+func patchContainer(b []byte, at int, n int) {
+	binary.BigEndian.PutUint32(b[at+1:], uint32(n))
+}
+
+// isEmpty reports whether an encoded value is nil, an empty string, an empty array or an empty map,
+// i.e. whether it is omitted by omitempty.
+func isEmpty(value []byte) bool {
+	switch len(value) {
+	case 1:
+		return value[0] == typeNil || value[0] == 0xa0 || value[0] == 0x90 || value[0] == 0x80
+	case 5:
+		return (value[0] == typeArray32 || value[0] == typeMap32) && binary.BigEndian.Uint32(value[1:]) == 0
+	}
+	return false
+}
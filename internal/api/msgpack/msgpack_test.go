@@ -0,0 +1,135 @@
+package msgpack_test
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/go-json-experiment/json"
+	"github.com/microsoft/typescript-go/internal/api/msgpack"
+	"github.com/microsoft/typescript-go/internal/core"
+	"gotest.tools/v3/assert"
+)
+
+type Inner struct {
+	Value int `json:"value"`
+}
+
+type Embedded struct {
+	Shared string `json:"shared"`
+}
+
+type Outer struct {
+	Embedded
+	Name     string         `json:"name"`
+	Count    uint32         `json:"count"`
+	Ratio    float64        `json:"ratio"`
+	Negative int64          `json:"negative"`
+	Flag     bool           `json:"flag,omitempty"`
+	Optional *Inner         `json:"optional,omitempty"`
+	Inner    *Inner         `json:"inner"`
+	Items    []Inner        `json:"items"`
+	Nil      []string       `json:"nil"`
+	Empty    []string       `json:"empty,omitempty"`
+	Zero     Inner          `json:"zero,omitzero"`
+	Map      map[string]int `json:"map"`
+	IntKeys  map[int]string `json:"intKeys"`
+	Any      any            `json:"any"`
+	Tristate core.Tristate  `json:"tristate"`
+	Ignored  string         `json:"-"`
+	Untagged string
+	Nested   map[string][]bool `json:"nested"`
+}
+
+func testValue() *Outer {
+	return &Outer{
+		Embedded: Embedded{Shared: "shared"},
+		Name:     strings.Repeat("long name ", 10),
+		Count:    70000,
+		Ratio:    0.5,
+		Negative: -1 << 40,
+		Inner:    &Inner{Value: -3},
+		Items:    []Inner{{Value: 1}, {Value: 200}},
+		Map:      map[string]int{"b": 2, "a": 1},
+		IntKeys:  map[int]string{1: "one"},
+		Any:      map[string]any{"x": []any{1.0, "y", nil}},
+		Tristate: core.TSTrue,
+		Ignored:  "ignored",
+		Untagged: "untagged",
+		Nested:   map[string][]bool{"n": {true, false}},
+	}
+}
+
+func TestMarshalMatchesJSON(t *testing.T) {
+	t.Parallel()
+	value := testValue()
+
+	encoded, err := msgpack.Marshal(value)
+	assert.NilError(t, err)
+	converted, err := msgpack.ToJSON(encoded)
+	assert.NilError(t, err)
+	expected, err := json.Marshal(value)
+	assert.NilError(t, err)
+
+	var actualValue, expectedValue any
+	assert.NilError(t, json.Unmarshal(converted, &actualValue))
+	assert.NilError(t, json.Unmarshal(expected, &expectedValue))
+	assert.DeepEqual(t, actualValue, expectedValue)
+}
+
+func TestUnmarshalRoundTrip(t *testing.T) {
+	t.Parallel()
+	value := testValue()
+
+	encoded, err := msgpack.Marshal(value)
+	assert.NilError(t, err)
+	var decoded Outer
+	assert.NilError(t, msgpack.Unmarshal(encoded, &decoded))
+
+	value.Ignored = ""
+	value.Nil = []string{}
+	assert.DeepEqual(t, &decoded, value)
+}
+
+func TestUnmarshalFromJSON(t *testing.T) {
+	t.Parallel()
+	encoded, err := msgpack.FromJSON([]byte(`{"name":"a","count":1,"unknown":{"deep":[1,2,{"x":null}]},"inner":null,"items":[{"value":-5}],"ratio":1e3}`))
+	assert.NilError(t, err)
+
+	var decoded Outer
+	assert.NilError(t, msgpack.Unmarshal(encoded, &decoded))
+	assert.Equal(t, decoded.Name, "a")
+	assert.Equal(t, decoded.Count, uint32(1))
+	assert.Assert(t, decoded.Inner == nil)
+	assert.DeepEqual(t, decoded.Items, []Inner{{Value: -5}})
+	assert.Equal(t, decoded.Ratio, 1000.0)
+
+	var count uint8
+	encoded, err = msgpack.Marshal(300)
+	assert.NilError(t, err)
+	assert.ErrorContains(t, msgpack.Unmarshal(encoded, &count), "cannot decode")
+}
+
+func TestReadValue(t *testing.T) {
+	t.Parallel()
+	var stream []byte
+	values := []any{testValue(), "text", []byte{1, 2, 3}, nil, -7, []any{map[string]any{}}}
+	for _, value := range values {
+		encoded, err := msgpack.Marshal(value)
+		assert.NilError(t, err)
+		stream = append(stream, encoded...)
+	}
+
+	r := bufio.NewReader(bytes.NewReader(stream))
+	var read []byte
+	for range values {
+		value, err := msgpack.ReadValue(r)
+		assert.NilError(t, err)
+		read = append(read, value...)
+	}
+	assert.DeepEqual(t, read, stream)
+	_, err := msgpack.ReadValue(r)
+	assert.ErrorIs(t, err, io.EOF)
+}
@@ -11,12 +11,17 @@ import (
 	"github.com/microsoft/typescript-go/internal/ast"
 	"github.com/microsoft/typescript-go/internal/checker"
 	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/ls"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
 	"github.com/microsoft/typescript-go/internal/project"
 )
 
 var (
 	ErrInvalidRequest = errors.New("api: invalid request")
 	ErrClientError    = errors.New("api: client error")
+	// ErrCallbackUnsupported is matched by a ClientError whose code is ENOTSUP, reporting that the
+	// client does not support the operation of the callback.
+	ErrCallbackUnsupported = errors.New("api: callback unsupported")
 )
 
 type Method string
@@ -36,7 +41,7 @@ func ProjectHandle(p *project.Project) Handle[project.Project] {
 }
 
 func SymbolHandle(symbol *ast.Symbol) Handle[ast.Symbol] {
-	return createHandle[ast.Symbol](handlePrefixSymbol, ast.GetSymbolId(symbol))
+	return createHandle[ast.Symbol](handlePrefixSymbol, symbol.Id())
 }
 
 func TypeHandle(t *checker.Type) Handle[checker.Type] {
@@ -75,38 +80,242 @@ func createHandle[T any](prefix rune, id any) Handle[T] {
 }
 
 const (
-	MethodConfigure Method = "configure"
-	MethodRelease   Method = "release"
-
-	MethodParseConfigFile       Method = "parseConfigFile"
-	MethodLoadProject           Method = "loadProject"
-	MethodGetSymbolAtPosition   Method = "getSymbolAtPosition"
-	MethodGetSymbolsAtPositions Method = "getSymbolsAtPositions"
-	MethodGetSymbolAtLocation   Method = "getSymbolAtLocation"
-	MethodGetSymbolsAtLocations Method = "getSymbolsAtLocations"
-	MethodGetTypeOfSymbol       Method = "getTypeOfSymbol"
-	MethodGetTypesOfSymbols     Method = "getTypesOfSymbols"
-	MethodGetSourceFile         Method = "getSourceFile"
-	MethodGetDiagnostics        Method = "getDiagnostics"
+	MethodInitialize   Method = "initialize"
+	MethodConfigure    Method = "configure"
+	MethodRelease      Method = "release"
+	MethodGetStats     Method = "getStats"
+	MethodGetCallbacks Method = "getCallbacks"
+	MethodSetCallbacks Method = "setCallbacks"
+
+	MethodParseConfigFile                   Method = "parseConfigFile"
+	MethodLoadProject                       Method = "loadProject"
+	MethodGetSymbolAtPosition               Method = "getSymbolAtPosition"
+	MethodGetSymbolsAtPositions             Method = "getSymbolsAtPositions"
+	MethodGetSymbolAtLocation               Method = "getSymbolAtLocation"
+	MethodGetSymbolsAtLocations             Method = "getSymbolsAtLocations"
+	MethodGetTypeOfSymbol                   Method = "getTypeOfSymbol"
+	MethodGetTypesOfSymbols                 Method = "getTypesOfSymbols"
+	MethodGetSourceFile                     Method = "getSourceFile"
+	MethodGetDiagnostics                    Method = "getDiagnostics"
+	MethodGetDiagnosticsByFile              Method = "getDiagnosticsByFile"
+	MethodGetCodeFixes                      Method = "getCodeFixes"
+	MethodIsTypeAssignableTo                Method = "isTypeAssignableTo"
+	MethodGetFileText                       Method = "getFileText"
+	MethodGetCompletions                    Method = "getCompletions"
+	MethodGetCompletionEntryDetails         Method = "getCompletionEntryDetails"
+	MethodGetModuleExports                  Method = "getModuleExports"
+	MethodGetImplementation                 Method = "getImplementation"
+	MethodGetSyntacticDiagnostics           Method = "getSyntacticDiagnostics"
+	MethodGetEditsForFileRename             Method = "getEditsForFileRename"
+	MethodGetTouchingToken                  Method = "getTouchingToken"
+	MethodPrepareTypeHierarchy              Method = "prepareTypeHierarchy"
+	MethodGetSupertypes                     Method = "getSupertypes"
+	MethodGetSubtypes                       Method = "getSubtypes"
+	MethodWarmup                            Method = "warmup"
+	MethodGetGlobalSymbols                  Method = "getGlobalSymbols"
+	MethodGetRefactors                      Method = "getRefactors"
+	MethodGetRefactorEdits                  Method = "getRefactorEdits"
+	MethodGetMatchingBrackets               Method = "getMatchingBrackets"
+	MethodGetNavigateTo                     Method = "getNavigateTo"
+	MethodGetProgramFiles                   Method = "getProgramFiles"
+	MethodGetFileIncludeReasons             Method = "getFileIncludeReasons"
+	MethodGetDiagnosticsChunked             Method = "getDiagnosticsChunked"
+	MethodGetOutliningSpans                 Method = "getOutliningSpans"
+	MethodFindReferencesInScope             Method = "findReferencesInScope"
+	MethodGetCombinedCodeFix                Method = "getCombinedCodeFix"
+	MethodGetEnclosingComment               Method = "getEnclosingComment"
+	MethodGetUnusedExports                  Method = "getUnusedExports"
+	MethodGetDefinition                     Method = "getDefinition"
+	MethodGetDiagnosticsForContent          Method = "getDiagnosticsForContent"
+	MethodGetNavigationBarItems             Method = "getNavigationBarItems"
+	MethodGetDiagnosticsPage                Method = "getDiagnosticsPage"
+	MethodGetMoveToFileEdits                Method = "getMoveToFileEdits"
+	MethodGetQuickInfo                      Method = "getQuickInfo"
+	MethodGetSelectionRanges                Method = "getSelectionRanges"
+	MethodGetEmitOutput                     Method = "getEmitOutput"
+	MethodGetAutoImportEdit                 Method = "getAutoImportEdit"
+	MethodGetEncodedSemanticClassifications Method = "getEncodedSemanticClassifications"
+	MethodCloseProject                      Method = "closeProject"
+	MethodGetMemoryStats                    Method = "getMemoryStats"
+	MethodSetCompilerOptionsOverride        Method = "setCompilerOptionsOverride"
+	MethodGetNodes                          Method = "getNodes"
+	MethodGetFixAllMissingImports           Method = "getFixAllMissingImports"
+	MethodGetJsxClosingTag                  Method = "getJsxClosingTag"
+	MethodGetBreakpointSpan                 Method = "getBreakpointSpan"
+	MethodResolveModule                     Method = "resolveModule"
+	MethodGetWatchGlobs                     Method = "getWatchGlobs"
+	MethodGetTodoComments                   Method = "getTodoComments"
+	MethodGetAliasedSymbol                  Method = "getAliasedSymbol"
+	MethodGetDiagnosticsDelta               Method = "getDiagnosticsDelta"
+	MethodGetNameOrDottedNameSpan           Method = "getNameOrDottedNameSpan"
+	MethodGetEffectiveCompilerOptions       Method = "getEffectiveCompilerOptions"
+	MethodGetConfigFileDiagnostics          Method = "getConfigFileDiagnostics"
+	MethodPrepareRename                     Method = "prepareRename"
 )
 
-var unmarshalers = map[Method]func([]byte) (any, error){
-	MethodRelease:               unmarshallerFor[string],
-	MethodParseConfigFile:       unmarshallerFor[ParseConfigFileParams],
-	MethodLoadProject:           unmarshallerFor[LoadProjectParams],
-	MethodGetSourceFile:         unmarshallerFor[GetSourceFileParams],
-	MethodGetSymbolAtPosition:   unmarshallerFor[GetSymbolAtPositionParams],
-	MethodGetSymbolsAtPositions: unmarshallerFor[GetSymbolsAtPositionsParams],
-	MethodGetSymbolAtLocation:   unmarshallerFor[GetSymbolAtLocationParams],
-	MethodGetSymbolsAtLocations: unmarshallerFor[GetSymbolsAtLocationsParams],
-	MethodGetTypeOfSymbol:       unmarshallerFor[GetTypeOfSymbolParams],
-	MethodGetTypesOfSymbols:     unmarshallerFor[GetTypesOfSymbolsParams],
-	MethodGetDiagnostics:        unmarshallerFor[GetDiagnosticsParams],
+var unmarshalers = map[Method]func(payloadCodec, []byte) (any, error){
+	MethodRelease:                           unmarshallerFor[string],
+	MethodParseConfigFile:                   unmarshallerFor[ParseConfigFileParams],
+	MethodLoadProject:                       unmarshallerFor[LoadProjectParams],
+	MethodGetSourceFile:                     unmarshallerFor[GetSourceFileParams],
+	MethodGetSymbolAtPosition:               unmarshallerFor[GetSymbolAtPositionParams],
+	MethodGetSymbolsAtPositions:             unmarshallerFor[GetSymbolsAtPositionsParams],
+	MethodGetSymbolAtLocation:               unmarshallerFor[GetSymbolAtLocationParams],
+	MethodGetSymbolsAtLocations:             unmarshallerFor[GetSymbolsAtLocationsParams],
+	MethodGetTypeOfSymbol:                   unmarshallerFor[GetTypeOfSymbolParams],
+	MethodGetTypesOfSymbols:                 unmarshallerFor[GetTypesOfSymbolsParams],
+	MethodGetDiagnostics:                    unmarshallerFor[GetDiagnosticsParams],
+	MethodGetDiagnosticsByFile:              unmarshallerFor[GetDiagnosticsParams],
+	MethodGetCodeFixes:                      unmarshallerFor[GetCodeFixesParams],
+	MethodIsTypeAssignableTo:                unmarshallerFor[IsTypeAssignableToParams],
+	MethodGetFileText:                       unmarshallerFor[GetFileTextParams],
+	MethodGetCompletions:                    unmarshallerFor[GetCompletionsParams],
+	MethodGetCompletionEntryDetails:         unmarshallerFor[GetCompletionEntryDetailsParams],
+	MethodGetModuleExports:                  unmarshallerFor[GetModuleExportsParams],
+	MethodGetImplementation:                 unmarshallerFor[GetImplementationParams],
+	MethodGetSyntacticDiagnostics:           unmarshallerFor[GetSyntacticDiagnosticsParams],
+	MethodGetEditsForFileRename:             unmarshallerFor[GetEditsForFileRenameParams],
+	MethodGetTouchingToken:                  unmarshallerFor[GetTouchingTokenParams],
+	MethodPrepareTypeHierarchy:              unmarshallerFor[TypeHierarchyParams],
+	MethodGetSupertypes:                     unmarshallerFor[TypeHierarchyParams],
+	MethodGetSubtypes:                       unmarshallerFor[TypeHierarchyParams],
+	MethodWarmup:                            unmarshallerFor[WarmupParams],
+	MethodGetGlobalSymbols:                  unmarshallerFor[GetGlobalSymbolsParams],
+	MethodGetRefactors:                      unmarshallerFor[GetRefactorsParams],
+	MethodGetRefactorEdits:                  unmarshallerFor[GetRefactorEditsParams],
+	MethodGetMatchingBrackets:               unmarshallerFor[GetMatchingBracketsParams],
+	MethodGetNavigateTo:                     unmarshallerFor[GetNavigateToParams],
+	MethodGetProgramFiles:                   unmarshallerFor[GetProgramFilesParams],
+	MethodGetFileIncludeReasons:             unmarshallerFor[GetFileIncludeReasonsParams],
+	MethodGetDiagnosticsChunked:             unmarshallerFor[GetDiagnosticsChunkedParams],
+	MethodGetOutliningSpans:                 unmarshallerFor[GetOutliningSpansParams],
+	MethodFindReferencesInScope:             unmarshallerFor[FindReferencesInScopeParams],
+	MethodGetCombinedCodeFix:                unmarshallerFor[GetCombinedCodeFixParams],
+	MethodGetEnclosingComment:               unmarshallerFor[GetEnclosingCommentParams],
+	MethodGetUnusedExports:                  unmarshallerFor[GetUnusedExportsParams],
+	MethodGetDefinition:                     unmarshallerFor[GetDefinitionParams],
+	MethodGetDiagnosticsForContent:          unmarshallerFor[GetDiagnosticsForContentParams],
+	MethodGetNavigationBarItems:             unmarshallerFor[GetNavigationBarItemsParams],
+	MethodGetDiagnosticsPage:                unmarshallerFor[GetDiagnosticsPageParams],
+	MethodGetMoveToFileEdits:                unmarshallerFor[GetMoveToFileEditsParams],
+	MethodGetQuickInfo:                      unmarshallerFor[GetQuickInfoParams],
+	MethodGetSelectionRanges:                unmarshallerFor[GetSelectionRangesParams],
+	MethodGetEmitOutput:                     unmarshallerFor[GetEmitOutputParams],
+	MethodGetAutoImportEdit:                 unmarshallerFor[GetAutoImportEditParams],
+	MethodGetEncodedSemanticClassifications: unmarshallerFor[GetEncodedSemanticClassificationsParams],
+	MethodCloseProject:                      unmarshallerFor[CloseProjectParams],
+	MethodGetMemoryStats:                    unmarshallerFor[GetMemoryStatsParams],
+	MethodSetCompilerOptionsOverride:        unmarshallerFor[SetCompilerOptionsOverrideParams],
+	MethodGetNodes:                          unmarshallerFor[GetNodesParams],
+	MethodGetFixAllMissingImports:           unmarshallerFor[GetFixAllMissingImportsParams],
+	MethodGetJsxClosingTag:                  unmarshallerFor[GetJsxClosingTagParams],
+	MethodGetBreakpointSpan:                 unmarshallerFor[GetBreakpointSpanParams],
+	MethodResolveModule:                     unmarshallerFor[ResolveModuleParams],
+	MethodGetWatchGlobs:                     unmarshallerFor[GetWatchGlobsParams],
+	MethodGetTodoComments:                   unmarshallerFor[GetTodoCommentsParams],
+	MethodGetAliasedSymbol:                  unmarshallerFor[GetAliasedSymbolParams],
+	MethodGetDiagnosticsDelta:               unmarshallerFor[GetDiagnosticsDeltaParams],
+	MethodGetNameOrDottedNameSpan:           unmarshallerFor[GetNameOrDottedNameSpanParams],
+	MethodGetEffectiveCompilerOptions:       unmarshallerFor[GetEffectiveCompilerOptionsParams],
+	MethodGetConfigFileDiagnostics:          unmarshallerFor[GetConfigFileDiagnosticsParams],
+	MethodPrepareRename:                     unmarshallerFor[PrepareRenameParams],
+}
+
+// InitializeParams are the parameters of the initialize request, which negotiates the protocol of
+// the connection. It is optional, but when sent it must be the first request.
+type InitializeParams struct {
+	// PayloadFormat is the format of request and response payloads the client asks for, which
+	// applies from the response to the initialize request onward. Callback payloads are always
+	// JSON. Defaults to "json".
+	PayloadFormat PayloadFormat `json:"payloadFormat"`
+	// PositionEncodings are the encodings of the character offsets of line and character positions
+	// the client supports, in order of preference. The server picks the first one it supports, and
+	// fails the request if there is none. Omitting it keeps the encoding the server was started
+	// with.
+	PositionEncodings []lsproto.PositionEncodingKind `json:"positionEncodings"`
+	// ResponseRequestIds appends the id of the request to each response and error message, as a
+	// fourth element of the message tuple, so that clients with several requests in flight on the
+	// connection can correlate responses with requests. Requests are numbered from 1 in the order
+	// the server receives them, the initialize request included, and ids apply from the response
+	// to the initialize request onward. Clients that do not ask for it keep receiving 3-element
+	// messages.
+	ResponseRequestIds bool `json:"responseRequestIds"`
+	// Features are the optional behaviors of the language service the client asks for. Those the
+	// server does not support are left out of the result.
+	Features Features `json:"features"`
+}
+
+// InitializeResult is the protocol of the connection chosen by the server.
+type InitializeResult struct {
+	PayloadFormat      PayloadFormat                `json:"payloadFormat"`
+	PositionEncoding   lsproto.PositionEncodingKind `json:"positionEncoding"`
+	ResponseRequestIds bool                         `json:"responseRequestIds"`
+	// Features are the features enabled for the connection, those asked for that the server supports.
+	Features Features `json:"features"`
+	// SupportedFeatures are all the features the server supports.
+	SupportedFeatures Features `json:"supportedFeatures"`
 }
 
 type ConfigureParams struct {
 	Callbacks []string `json:"callbacks"`
 	LogFile   string   `json:"logFile"`
+	// StreamDiagnostics enables or disables sending the diagnostics of each file as a diagnostics
+	// call while getDiagnostics checks the program. Omitting it keeps the current setting.
+	StreamDiagnostics *bool `json:"streamDiagnostics"`
+	// CallbackBypassPrefixes replaces the path prefixes of files that the server reads from its own
+	// file system instead of through the file system callbacks, like "bundled://" files. Each prefix
+	// is a scheme such as "node_modules://" or an absolute path. Omitting it keeps the current list.
+	CallbackBypassPrefixes []string `json:"callbackBypassPrefixes"`
+	// RequestTiming enables or disables logging the duration of each request and collecting the
+	// stats returned by getStats. Omitting it keeps the current setting and stats.
+	RequestTiming *bool `json:"requestTiming"`
+	// UseCaseSensitiveFileNames sets whether file names are case sensitive, which they are by
+	// default whatever the host file system. It can only be changed before any project is loaded.
+	UseCaseSensitiveFileNames *bool `json:"useCaseSensitiveFileNames"`
+	// PreferGoToSourceDefinition makes definitions in declaration files of project references
+	// resolve to the source files they were built from. Omitting it keeps the current setting.
+	PreferGoToSourceDefinition *bool `json:"preferGoToSourceDefinition"`
+	// MaxCompletionEntries limits the number of entries returned by getCompletions, which marks
+	// truncated lists as incomplete. Zero means no limit. Omitting it keeps the current limit.
+	MaxCompletionEntries *int `json:"maxCompletionEntries"`
+	// PositionEncoding and Features are negotiated by the initialize request, since changing them
+	// after projects are loaded or completions are requested would mix encodings and behaviors.
+	// Sending either fails the request, rather than ignoring it.
+	PositionEncoding lsproto.PositionEncodingKind `json:"positionEncoding"`
+	Features         *Features                    `json:"features"`
+}
+
+// SetCallbacksParams are the parameters of the setCallbacks request, which replaces the enabled
+// callbacks, unlike configure, which can only enable more of them.
+type SetCallbacksParams struct {
+	// Callbacks are the names of the callbacks to enable, as in ConfigureParams.Callbacks. The
+	// others are disabled.
+	Callbacks []string `json:"callbacks"`
+}
+
+// Features is a set of optional language service behaviors negotiated in the initialize request.
+type Features uint32
+
+const (
+	// FeatureCompletionsForModuleExports includes the exports of modules that are not imported yet
+	// in completion lists, as auto-import entries.
+	FeatureCompletionsForModuleExports Features = 1 << iota
+	// FeatureCompletionsForImportStatements completes partially typed import statements, e.g.
+	// `import write` to `import { writeFile } from "fs"`.
+	FeatureCompletionsForImportStatements
+
+	// SupportedFeatures are the features this server implements.
+	SupportedFeatures = FeatureCompletionsForModuleExports | FeatureCompletionsForImportStatements
+)
+
+// RequestStats is the aggregate timing of one method, returned by getStats. Percentiles
+// are in milliseconds and cover the most recent requests.
+type RequestStats struct {
+	Method string  `json:"method"`
+	Count  int     `json:"count"`
+	Errors int     `json:"errors"`
+	P50    float64 `json:"p50"`
+	P95    float64 `json:"p95"`
 }
 
 type ParseConfigFileParams struct {
@@ -118,8 +327,19 @@ type ConfigFileResponse struct {
 	Options   *core.CompilerOptions `json:"options"`
 }
 
+// EffectiveOptions are the compiler options that apply to a file, returned by
+// getEffectiveCompilerOptions.
+type EffectiveOptions struct {
+	// ConfigFileName is the config file governing the file: that of the loaded project whose program
+	// contains it, or that of the referenced project it is a source of.
+	ConfigFileName string                `json:"configFileName"`
+	Options        *core.CompilerOptions `json:"options"`
+}
+
 type LoadProjectParams struct {
 	ConfigFileName string `json:"configFileName"`
+	// ProgressToken, if set, makes the server report the progress of the request against it.
+	ProgressToken string `json:"progressToken,omitempty"`
 }
 
 type ProjectResponse struct {
@@ -165,6 +385,11 @@ type SymbolResponse struct {
 	Name       string             `json:"name"`
 	Flags      uint32             `json:"flags"`
 	CheckFlags uint32             `json:"checkFlags"`
+	// Declarations, Documentation and Type are only set by getSymbolAtPosition, so that a single
+	// request tells what a name is. See ls.SymbolDetails.
+	Declarations  []ls.DefinitionLocation `json:"declarations,omitempty"`
+	Documentation string                  `json:"documentation,omitempty"`
+	Type          string                  `json:"type,omitempty"`
 }
 
 func NewSymbolResponse(symbol *ast.Symbol) *SymbolResponse {
@@ -178,6 +403,160 @@ func NewSymbolResponse(symbol *ast.Symbol) *SymbolResponse {
 
 type GetDiagnosticsParams struct {
 	Project Handle[project.Project] `json:"project"`
+	// ProgressToken, if set, makes the server report the progress of the request against it.
+	ProgressToken string `json:"progressToken,omitempty"`
+}
+
+// FileDiagnostics is the payload of a "diagnostics" call, sent for each file while
+// diagnostics are being streamed.
+type FileDiagnostics struct {
+	FileName    string          `json:"fileName"`
+	Diagnostics []ls.Diagnostic `json:"diagnostics"`
+}
+
+// Progress is the payload of a "progress" call, sent while a request with a progress token is in
+// progress. The client acknowledges each report with a call-response; a call-error cancels the
+// request.
+type Progress struct {
+	// Token is the progress token of the request, as created by the client.
+	Token string `json:"token"`
+	// Percentage is the percentage of the request done, from 0 to 100.
+	Percentage int    `json:"percentage"`
+	Message    string `json:"message"`
+}
+
+// ReadFileRangeParams is the payload of a "readFileRange" call. Start and Length are in bytes of the UTF-8 contents.
+type ReadFileRangeParams struct {
+	Path   string `json:"path"`
+	Start  int    `json:"start"`
+	Length int    `json:"length"`
+}
+
+// ChtimesParams is the payload of a "chtimes" call. Times are in milliseconds since the Unix epoch.
+type ChtimesParams struct {
+	Path  string `json:"path"`
+	ATime int64  `json:"atime"`
+	MTime int64  `json:"mtime"`
+}
+
+// ClientError is the error of a callback the client answered with a call-error. The payload of a
+// call-error is either an object with a code and a message, or the message alone, in which case a
+// leading errno-style code such as "ENOTSUP: " is taken as the code, as in Node.js error messages.
+type ClientError struct {
+	Method  string
+	Code    string
+	Message string
+}
+
+func newClientError(method string, payload []byte) *ClientError {
+	var structured struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(payload, &structured); err == nil && structured.Code != "" {
+		return &ClientError{Method: method, Code: structured.Code, Message: structured.Message}
+	}
+	message := string(payload)
+	code, _, _ := strings.Cut(message, ":")
+	if !isErrnoCode(code) {
+		code = ""
+	}
+	return &ClientError{Method: method, Code: code, Message: message}
+}
+
+func isErrnoCode(code string) bool {
+	if len(code) < 2 || code[0] != 'E' {
+		return false
+	}
+	for _, c := range code {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *ClientError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrClientError, e.Message)
+}
+
+// Is reports ErrClientError for every client error, and ErrCallbackUnsupported for those with
+// an ENOTSUP code.
+func (e *ClientError) Is(target error) bool {
+	switch target {
+	case ErrClientError:
+		return true
+	case ErrCallbackUnsupported:
+		return e.Code == "ENOTSUP"
+	}
+	return false
+}
+
+type GetCodeFixesParams struct {
+	Project    Handle[project.Project] `json:"project"`
+	FileName   string                  `json:"fileName"`
+	Start      uint32                  `json:"start"`
+	End        uint32                  `json:"end"`
+	ErrorCodes []int32                 `json:"errorCodes"`
+}
+
+type GetFileTextParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+}
+
+type FileTextResponse struct {
+	Text    string `json:"text"`
+	Version int    `json:"version"`
+}
+
+type GetCompletionsParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+	Position uint32                  `json:"position"`
+	// FilterByPrefix returns only the entries starting with the part of the identifier at the
+	// position that is before it, rather than leaving filtering to the client.
+	FilterByPrefix bool `json:"filterByPrefix,omitempty"`
+	// IncludeCompletionsForModuleExports, if set, overrides FeatureCompletionsForModuleExports of the
+	// configure request for this request.
+	IncludeCompletionsForModuleExports *bool `json:"includeCompletionsForModuleExports,omitempty"`
+}
+
+type GetCompletionEntryDetailsParams struct {
+	Project   Handle[project.Project] `json:"project"`
+	FileName  string                  `json:"fileName"`
+	Position  uint32                  `json:"position"`
+	EntryName string                  `json:"entryName"`
+	Source    string                  `json:"source"`
+}
+
+type GetModuleExportsParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+}
+
+type GetImplementationParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+	Position uint32                  `json:"position"`
+}
+
+type GetSyntacticDiagnosticsParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+}
+
+type IsTypeAssignableToParams struct {
+	Project        Handle[project.Project] `json:"project"`
+	SourceFile     string                  `json:"sourceFile"`
+	SourcePosition uint32                  `json:"sourcePosition"`
+	TargetFile     string                  `json:"targetFile"`
+	TargetPosition uint32                  `json:"targetPosition"`
+}
+
+type TypeAssignabilityResponse struct {
+	Assignable  bool            `json:"assignable"`
+	Diagnostics []ls.Diagnostic `json:"diagnostics"`
 }
 
 type GetTypeOfSymbolParams struct {
@@ -207,17 +586,316 @@ type GetSourceFileParams struct {
 	FileName string                  `json:"fileName"`
 }
 
-func unmarshalPayload(method string, payload jsontext.Value) (any, error) {
+type GetEditsForFileRenameParams struct {
+	Project     Handle[project.Project] `json:"project"`
+	OldFileName string                  `json:"oldFileName"`
+	NewFileName string                  `json:"newFileName"`
+}
+
+type GetTouchingTokenParams struct {
+	Project    Handle[project.Project] `json:"project"`
+	FileName   string                  `json:"fileName"`
+	Position   uint32                  `json:"position"`
+	PreferLeft bool                    `json:"preferLeft"`
+}
+
+type GetNodesParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+	// Kinds are the kinds of the nodes to return. All nodes are returned if it is empty.
+	Kinds []ast.Kind `json:"kinds"`
+	// MaxResults limits the number of returned nodes if it is positive.
+	MaxResults int `json:"maxResults"`
+}
+
+type GetFixAllMissingImportsParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+}
+
+// TypeHierarchyParams are the params of prepareTypeHierarchy, getSupertypes and getSubtypes.
+type TypeHierarchyParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+	Position uint32                  `json:"position"`
+}
+
+type WarmupParams struct {
+	// Project is the project to warm up. If empty, all loaded projects are warmed up.
+	Project Handle[project.Project] `json:"project"`
+	// ProgressToken, if set, makes the server report the progress of the request against it.
+	ProgressToken string `json:"progressToken,omitempty"`
+}
+
+type GetGlobalSymbolsParams struct {
+	Project Handle[project.Project] `json:"project"`
+	Query   string                  `json:"query"`
+}
+
+type GetRefactorsParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+	Start    uint32                  `json:"start"`
+	End      uint32                  `json:"end"`
+}
+
+type GetRefactorEditsParams struct {
+	Project      Handle[project.Project] `json:"project"`
+	FileName     string                  `json:"fileName"`
+	Start        uint32                  `json:"start"`
+	End          uint32                  `json:"end"`
+	RefactorName string                  `json:"refactorName"`
+	ActionName   string                  `json:"actionName"`
+}
+
+type GetMatchingBracketsParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+	Position uint32                  `json:"position"`
+}
+
+type GetNavigateToParams struct {
+	Project    Handle[project.Project] `json:"project"`
+	Query      string                  `json:"query"`
+	MaxResults int                     `json:"maxResults"`
+}
+
+type GetProgramFilesParams struct {
+	Project Handle[project.Project] `json:"project"`
+}
+
+type GetFileIncludeReasonsParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+}
+
+type GetDiagnosticsChunkedParams struct {
+	Project Handle[project.Project] `json:"project"`
+	// ChunkSize is the number of files checked between cancellation checks. If zero, ls.DefaultDiagnosticsChunkSize is used.
+	ChunkSize int `json:"chunkSize"`
+}
+
+type GetOutliningSpansParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+}
+
+type FindReferencesInScopeParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+	Position uint32                  `json:"position"`
+	Scope    ls.ReferenceScope       `json:"scope"`
+}
+
+type GetCombinedCodeFixParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+	FixId    string                  `json:"fixId"`
+}
+
+type GetEnclosingCommentParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+	Position uint32                  `json:"position"`
+}
+
+type GetUnusedExportsParams struct {
+	Project Handle[project.Project] `json:"project"`
+	// EntryPoints are the files whose exports are used by definition, such as the main module of a package.
+	EntryPoints []string `json:"entryPoints"`
+}
+
+type GetDefinitionParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+	Position uint32                  `json:"position"`
+}
+
+type GetDiagnosticsForContentParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+	Content  string                  `json:"content"`
+}
+
+type GetNavigationBarItemsParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+}
+
+type GetDiagnosticsPageParams struct {
+	Project Handle[project.Project] `json:"project"`
+	// MaxResults is the largest number of diagnostics in the page, not counting the message chain
+	// and related information entries they reference. If zero, every remaining diagnostic is returned.
+	MaxResults int `json:"maxResults"`
+	// ContinuationToken is the token of the previous page, or empty for the first page.
+	ContinuationToken string `json:"continuationToken"`
+}
+
+type GetMoveToFileEditsParams struct {
+	Project        Handle[project.Project] `json:"project"`
+	FileName       string                  `json:"fileName"`
+	Start          uint32                  `json:"start"`
+	End            uint32                  `json:"end"`
+	TargetFileName string                  `json:"targetFileName"`
+}
+
+type GetQuickInfoParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+	Position uint32                  `json:"position"`
+}
+
+type GetSelectionRangesParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+	Position uint32                  `json:"position"`
+}
+
+type GetEmitOutputParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+}
+
+type GetAutoImportEditParams struct {
+	Project    Handle[project.Project] `json:"project"`
+	FileName   string                  `json:"fileName"`
+	SymbolName string                  `json:"symbolName"`
+	FromModule string                  `json:"fromModule"`
+}
+
+type GetEncodedSemanticClassificationsParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+	Start    uint32                  `json:"start"`
+	End      uint32                  `json:"end"`
+}
+
+type CloseProjectParams struct {
+	Project Handle[project.Project] `json:"project"`
+}
+
+type GetMemoryStatsParams struct {
+	// ForceGC runs a garbage collection before reading the heap usage, so that it does not include
+	// memory that is no longer reachable, such as the programs of closed projects.
+	ForceGC bool `json:"forceGC,omitempty"`
+}
+
+type SetCompilerOptionsOverrideParams struct {
+	// Options are compiler options as written in a tsconfig.json. Null or absent options clear
+	// the override.
+	Options jsontext.Value `json:"options"`
+}
+
+// MemoryStats is the memory usage of the server, returned by getMemoryStats. Sizes are in bytes.
+type MemoryStats struct {
+	// HeapAlloc is the size of the allocated heap objects, including unreachable ones that have
+	// not been collected yet.
+	HeapAlloc uint64 `json:"heapAlloc"`
+	// HeapInuse is the size of the heap spans that hold at least one object.
+	HeapInuse uint64 `json:"heapInuse"`
+	// Sys is the memory obtained from the operating system.
+	Sys      uint64                `json:"sys"`
+	NumGC    uint32                `json:"numGC"`
+	Projects []*ProjectMemoryStats `json:"projects"`
+}
+
+// ProjectMemoryStats describes the program of a loaded project.
+type ProjectMemoryStats struct {
+	Id             Handle[project.Project] `json:"id"`
+	ConfigFileName string                  `json:"configFileName"`
+	FileCount      int                     `json:"fileCount"`
+	NodeCount      int                     `json:"nodeCount"`
+	// ApproximateSize estimates the memory retained by the files of the program from the size of
+	// their text, nodes and symbols. It does not include the memory of the type checkers, and files
+	// shared with other projects are counted for each of them.
+	ApproximateSize uint64 `json:"approximateSize"`
+}
+
+type GetJsxClosingTagParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+	Position uint32                  `json:"position"`
+}
+
+type GetBreakpointSpanParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+	Position uint32                  `json:"position"`
+}
+
+type ResolveModuleParams struct {
+	Project   Handle[project.Project] `json:"project"`
+	FromFile  string                  `json:"fromFile"`
+	Specifier string                  `json:"specifier"`
+}
+
+type GetWatchGlobsParams struct {
+	Project Handle[project.Project] `json:"project"`
+}
+
+// WatchGlobsResponse lists what a client watching the file system itself should watch for the
+// program of a project to stay up to date. Both lists are sorted.
+type WatchGlobsResponse struct {
+	// Files are the config files, the files of the program and the files affecting module
+	// resolution, such as package.json files.
+	Files []string `json:"files"`
+	// Globs match the files in the directories of the include patterns of the config file and in
+	// the directories of failed module lookups.
+	Globs []string `json:"globs"`
+}
+
+type GetTodoCommentsParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+	// Tokens are the markers to look for, such as TODO, FIXME and HACK, with their priorities.
+	Tokens []ls.TodoCommentToken `json:"tokens"`
+}
+
+type GetAliasedSymbolParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+	Position uint32                  `json:"position"`
+}
+
+type GetDiagnosticsDeltaParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+	// PreviousResultId is the result id of the previous request for the file, or empty for the first.
+	PreviousResultId string `json:"previousResultId"`
+}
+
+type GetNameOrDottedNameSpanParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+	Start    uint32                  `json:"start"`
+	End      uint32                  `json:"end"`
+}
+
+type GetEffectiveCompilerOptionsParams struct {
+	FileName string `json:"fileName"`
+}
+
+type GetConfigFileDiagnosticsParams struct {
+	FileName string `json:"fileName"`
+}
+
+type PrepareRenameParams struct {
+	Project  Handle[project.Project] `json:"project"`
+	FileName string                  `json:"fileName"`
+	Position uint32                  `json:"position"`
+}
+
+func unmarshalPayload(codec payloadCodec, method string, payload []byte) (any, error) {
 	unmarshaler, ok := unmarshalers[Method(method)]
 	if !ok {
 		return nil, fmt.Errorf("unknown API method %q", method)
 	}
-	return unmarshaler(payload)
+	return unmarshaler(codec, payload)
 }
 
-func unmarshallerFor[T any](data []byte) (any, error) {
+func unmarshallerFor[T any](codec payloadCodec, data []byte) (any, error) {
 	var v T
-	if err := json.Unmarshal(data, &v); err != nil {
+	if err := codec.unmarshal(data, &v); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal %T: %w", (*T)(nil), err)
 	}
 	return &v, nil
@@ -2,11 +2,15 @@ package api
 
 import (
 	"bufio"
+	"cmp"
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
+	"runtime"
 	"runtime/debug"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -17,6 +21,7 @@ import (
 	"github.com/microsoft/typescript-go/internal/bundled"
 	"github.com/microsoft/typescript-go/internal/collections"
 	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/ls"
 	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
 	"github.com/microsoft/typescript-go/internal/module"
 	"github.com/microsoft/typescript-go/internal/packagejson"
@@ -51,10 +56,12 @@ type MessagePackType uint8
 
 const (
 	MessagePackTypeFixedArray3 MessagePackType = 0x93
+	MessagePackTypeFixedArray4 MessagePackType = 0x94
 	MessagePackTypeBin8        MessagePackType = 0xC4
 	MessagePackTypeBin16       MessagePackType = 0xC5
 	MessagePackTypeBin32       MessagePackType = 0xC6
 	MessagePackTypeU8          MessagePackType = 0xCC
+	MessagePackTypeU32         MessagePackType = 0xCE
 )
 
 type Callback int
@@ -71,6 +78,9 @@ const (
 	CallbackGetPackageScopeForPath
 	CallbackGetImpliedNodeFormatForFile
 	CallbackIsNodeSourceFile
+	CallbackRemove
+	CallbackChtimes
+	CallbackReadFileRange
 )
 
 type ServerOptions struct {
@@ -80,31 +90,101 @@ type ServerOptions struct {
 	Cwd                string
 	DefaultLibraryPath string
 	LogEnabled         bool
+	// PositionEncoding is the encoding of the line and character positions reported by the API,
+	// either UTF-8 or UTF-16. Byte offsets are always UTF-8. Defaults to UTF-8.
+	PositionEncoding lsproto.PositionEncodingKind
+	// UseCaseSensitiveFileNames sets whether file names are case sensitive, for clients that
+	// emulate a file system with different case sensitivity than the host. When unset, file names
+	// are case sensitive whatever the file system.
+	UseCaseSensitiveFileNames *bool
+	// NewLine is the line ending, "\n" or "\r\n", of emitted files and of the text of edits in
+	// projects whose compiler options do not set newLine. Defaults to the line ending of the OS.
+	NewLine string
+	// FS is the file system that files are read from, wrapped to serve the bundled library files.
+	// Defaults to the file system of the OS.
+	FS vfs.FS
+	// MemoryLimit is a soft limit, in bytes, of the heap of the server. While the heap exceeds it
+	// after a garbage collection, the least recently used projects are closed, to be loaded again
+	// by the next request on them. Zero disables the limit.
+	MemoryLimit uint64
+	// MemoryCheckInterval is how often the heap is compared to MemoryLimit. Defaults to
+	// DefaultMemoryCheckInterval.
+	MemoryCheckInterval time.Duration
+	// ResyncLimit, if non-zero, makes the server skip a malformed message rather than stop: the
+	// error is logged and the bytes up to the start of the next request are discarded, as long as
+	// there are at most ResyncLimit of them. Zero stops the server at the first malformed message.
+	// It must not be negative.
+	ResyncLimit int
 }
 
-var _ vfs.FS = (*Server)(nil)
+// DefaultMemoryCheckInterval is how often the heap is compared to the memory limit of the server
+// when no interval is given.
+const DefaultMemoryCheckInterval = 10 * time.Second
+
+var (
+	_ vfs.FS          = (*Server)(nil)
+	_ vfs.RangeReader = (*Server)(nil)
+)
 
 type Server struct {
 	r      *bufio.Reader
 	w      *bufio.Writer
 	stderr io.Writer
+	// methodBuffer is reused to read the method names of messages, which are converted to strings.
+	methodBuffer []byte
 
 	cwd                string
 	newLine            string
 	fs                 vfs.FS
 	defaultLibraryPath string
 
+	// useCaseSensitiveFileNames sets whether file names are case sensitive when non-nil. They
+	// are otherwise case sensitive, as they were before the option existed.
+	useCaseSensitiveFileNames *bool
+
+	codec payloadCodec
+	// callbackMu serializes the callbacks to the client and guards enabledCallbacks, which the
+	// goroutines building programs read.
 	callbackMu       sync.Mutex
 	enabledCallbacks Callback
-	logger           logging.Logger
-	api              *API
-
+	// callbackBypassPrefixes are the path prefixes, in addition to "bundled://", of files the
+	// server reads from its own file system even when file system callbacks are enabled.
+	callbackBypassPrefixes []string
+	logger                 logging.Logger
+	logEnabled             bool
+	api                    *API
+	sessionOptions         *project.SessionOptions
+	// stats is non-nil when request timing is enabled in the configure request.
+	stats *requestStats
+
+	memoryLimit         uint64
+	memoryCheckInterval time.Duration
+	resyncLimit         int
+	// handleMu is held while a request is handled, and while projects are closed under memory
+	// pressure, so that no request sees a project closed under it.
+	handleMu sync.Mutex
+
+	// requestId numbers the requests of the connection from 1, in the order they are received.
 	requestId int
+	// responseRequestIds appends the id of the request to each response and error message, as
+	// negotiated in the initialize request.
+	responseRequestIds bool
+
+	requestMu sync.Mutex
+	// requestCtx is the context of the API request being handled, or context.Background() between
+	// requests. It is cancelled when the client fails a progress report of the request.
+	requestCtx    context.Context
+	cancelRequest context.CancelCauseFunc
+	// cancelledProjects are the config files of the projects whose module resolution was cut short
+	// by the cancellation of the request being handled.
+	cancelledProjects collections.SyncSet[tspath.Path]
 }
 
 type hostWrapper struct {
 	inner  project.ProjectHost
 	server *Server
+	// projectPath is the config file of the project, or empty for an inferred project.
+	projectPath tspath.Path
 }
 
 // CompilerFS implements project.ProjectHost.
@@ -144,7 +224,7 @@ func (h *hostWrapper) GetSourceFile(opts ast.SourceFileParseOptions) *ast.Source
 
 // MakeResolver implements project.ProjectHost.
 func (h *hostWrapper) MakeResolver(host module.ResolutionHost, options *core.CompilerOptions, typingsLocation string, projectName string) module.ResolverInterface {
-	return newResolverWrapper(h.inner.MakeResolver(host, options, typingsLocation, projectName), h.server)
+	return newResolverWrapper(h.inner.MakeResolver(host, options, typingsLocation, projectName), h.server, h.projectPath)
 }
 
 // SeenFiles implements project.ProjectHost.
@@ -192,24 +272,44 @@ func (h *hostWrapper) IsNodeSourceFile(path tspath.Path) bool {
 
 func newProjectHostWrapper(currentDirectory string, proj *project.Project, builder *project.ProjectCollectionBuilder, logger *logging.LogTree, server *Server) *hostWrapper {
 	inner := project.NewProjectHost(currentDirectory, proj, builder, logger)
+	var projectPath tspath.Path
+	if proj.Kind == project.KindConfigured {
+		projectPath = proj.ConfigFilePath()
+	}
 	return &hostWrapper{
-		inner:  inner,
-		server: server,
+		inner:       inner,
+		server:      server,
+		projectPath: projectPath,
 	}
 }
 
 type resolverWrapper struct {
-	inner  module.ResolverInterface
-	server *Server
+	inner       module.ResolverInterface
+	server      *Server
+	projectPath tspath.Path
 }
 
-func newResolverWrapper(inner module.ResolverInterface, server *Server) *resolverWrapper {
+func newResolverWrapper(inner module.ResolverInterface, server *Server, projectPath tspath.Path) *resolverWrapper {
 	return &resolverWrapper{
-		inner:  inner,
-		server: server,
+		inner:       inner,
+		server:      server,
+		projectPath: projectPath,
 	}
 }
 
+// cancelled reports whether the request being handled has been cancelled. The resolver then stops
+// calling the client and leaves the remaining names unresolved, and the project is closed once the
+// request has been answered, so that its incomplete program is not used by later requests.
+func (r *resolverWrapper) cancelled() bool {
+	if r.server.requestContext().Err() == nil {
+		return false
+	}
+	if r.projectPath != "" {
+		r.server.cancelledProjects.Add(r.projectPath)
+	}
+	return true
+}
+
 type PackageJsonIfApplicable struct {
 	PackageDirectory string `json:"packageDirectory"`
 	DirectoryExists  bool   `json:"directoryExists"`
@@ -219,6 +319,9 @@ type PackageJsonIfApplicable struct {
 // GetPackageJsonScopeIfApplicable implements module.ResolverInterface.
 func (r *resolverWrapper) GetPackageJsonScopeIfApplicable(path string) *packagejson.InfoCacheEntry {
 	if r.server.CallbackEnabled(CallbackGetPackageJsonScopeIfApplicable) {
+		if r.cancelled() {
+			return nil
+		}
 		result, err := r.server.call("getPackageJsonScopeIfApplicable", path)
 		if err != nil {
 			panic(err)
@@ -252,6 +355,9 @@ func (r *resolverWrapper) GetPackageJsonScopeIfApplicable(path string) *packagej
 // GetPackageScopeForPath implements module.ResolverInterface.
 func (r *resolverWrapper) GetPackageScopeForPath(directory string) *packagejson.InfoCacheEntry {
 	if r.server.CallbackEnabled(CallbackGetPackageScopeForPath) {
+		if r.cancelled() {
+			return nil
+		}
 		result, err := r.server.call("getPackageScopeForPath", directory)
 		if err != nil {
 			panic(err)
@@ -283,6 +389,9 @@ func (r *resolverWrapper) GetPackageScopeForPath(directory string) *packagejson.
 // ResolveModuleName implements module.ResolverInterface.
 func (r *resolverWrapper) ResolveModuleName(moduleName string, containingFile string, resolutionMode core.ResolutionMode, redirectedReference module.ResolvedProjectReference) (*module.ResolvedModule, []string) {
 	if r.server.CallbackEnabled(CallbackResolveModuleName) {
+		if r.cancelled() {
+			return &module.ResolvedModule{}, nil
+		}
 		result, err := r.server.call("resolveModuleName", map[string]any{
 			"moduleName":          moduleName,
 			"containingFile":      containingFile,
@@ -306,6 +415,9 @@ func (r *resolverWrapper) ResolveModuleName(moduleName string, containingFile st
 // ResolveTypeReferenceDirective implements module.ResolverInterface.
 func (r *resolverWrapper) ResolveTypeReferenceDirective(typeReferenceDirectiveName string, containingFile string, resolutionMode core.ResolutionMode, redirectedReference module.ResolvedProjectReference) (*module.ResolvedTypeReferenceDirective, []string) {
 	if r.server.CallbackEnabled(CallbackResolveTypeReferenceDirective) {
+		if r.cancelled() {
+			return &module.ResolvedTypeReferenceDirective{}, nil
+		}
 		result, err := r.server.call("resolveTypeReferenceDirective", map[string]any{
 			"typeReferenceDirectiveName": typeReferenceDirectiveName,
 			"containingFile":             containingFile,
@@ -327,7 +439,8 @@ func (r *resolverWrapper) ResolveTypeReferenceDirective(typeReferenceDirectiveNa
 }
 
 func (r *resolverWrapper) GetImpliedNodeFormatForFile(path string, packageJsonType string) core.ModuleKind {
-	if r.server.CallbackEnabled(CallbackGetImpliedNodeFormatForFile) {
+	// The format is computed without calling the client once the request is cancelled.
+	if r.server.CallbackEnabled(CallbackGetImpliedNodeFormatForFile) && !r.cancelled() {
 		result, err := r.server.call("getImpliedNodeFormatForFile", map[string]any{
 			"fileName":        path,
 			"packageJsonType": packageJsonType,
@@ -352,14 +465,45 @@ func NewServer(options *ServerOptions) *Server {
 	if options.Cwd == "" {
 		panic("Cwd is required")
 	}
+	positionEncoding := options.PositionEncoding
+	switch positionEncoding {
+	case "":
+		positionEncoding = lsproto.PositionEncodingKindUTF8
+	case lsproto.PositionEncodingKindUTF8, lsproto.PositionEncodingKindUTF16:
+	default:
+		panic(fmt.Sprintf("unsupported position encoding %q", positionEncoding))
+	}
+	newLine := options.NewLine
+	switch newLine {
+	case "":
+		newLine = core.IfElse(runtime.GOOS == "windows", "\r\n", "\n")
+	case "\n", "\r\n":
+	default:
+		panic(fmt.Sprintf("unsupported new line %q", newLine))
+	}
+
+	if options.ResyncLimit < 0 {
+		panic(fmt.Sprintf("negative resync limit %d", options.ResyncLimit))
+	}
+
+	fs := options.FS
+	if fs == nil {
+		fs = osvfs.FS()
+	}
 
 	server := &Server{
-		r:                  bufio.NewReader(options.In),
-		w:                  bufio.NewWriter(options.Out),
-		stderr:             options.Err,
-		cwd:                options.Cwd,
-		fs:                 bundled.WrapFS(osvfs.FS()),
-		defaultLibraryPath: options.DefaultLibraryPath,
+		r:                   bufio.NewReader(options.In),
+		w:                   bufio.NewWriter(options.Out),
+		stderr:              options.Err,
+		cwd:                 options.Cwd,
+		newLine:             newLine,
+		fs:                  bundled.WrapFS(fs),
+		defaultLibraryPath:  options.DefaultLibraryPath,
+		codec:               jsonCodec{},
+		requestCtx:          context.Background(),
+		memoryLimit:         options.MemoryLimit,
+		memoryCheckInterval: cmp.Or(options.MemoryCheckInterval, DefaultMemoryCheckInterval),
+		resyncLimit:         options.ResyncLimit,
 	}
 
 	var logger logging.Logger
@@ -369,22 +513,33 @@ func NewServer(options *ServerOptions) *Server {
 		logger = NoLogger{}
 	}
 	server.logger = logger
-	server.api = NewAPI(&APIInit{
-		Logger: logger,
-		FS:     server,
-		SessionOptions: &project.SessionOptions{
-			CurrentDirectory:   options.Cwd,
-			DefaultLibraryPath: options.DefaultLibraryPath,
-			PositionEncoding:   lsproto.PositionEncodingKindUTF8,
-			LoggingEnabled:     true,
-			MakeHost: func(currentDirectory string, proj *project.Project, builder *project.ProjectCollectionBuilder, logger *logging.LogTree) project.ProjectHost {
-				return newProjectHostWrapper(currentDirectory, proj, builder, logger, server)
-			},
+	server.logEnabled = options.LogEnabled
+	server.useCaseSensitiveFileNames = options.UseCaseSensitiveFileNames
+	server.sessionOptions = &project.SessionOptions{
+		CurrentDirectory:   options.Cwd,
+		DefaultLibraryPath: options.DefaultLibraryPath,
+		PositionEncoding:   positionEncoding,
+		NewLine:            core.GetNewLineKind(newLine),
+		LoggingEnabled:     true,
+		MakeHost: func(currentDirectory string, proj *project.Project, builder *project.ProjectCollectionBuilder, logger *logging.LogTree) project.ProjectHost {
+			return newProjectHostWrapper(currentDirectory, proj, builder, logger, server)
 		},
-	})
+	}
+	server.api = server.newAPI()
 	return server
 }
 
+func (s *Server) newAPI() *API {
+	api := NewAPI(&APIInit{
+		Logger:         s.logger,
+		FS:             s,
+		SessionOptions: s.sessionOptions,
+	})
+	api.codec = s.codec
+	api.SetProgressStream(s.sendProgress)
+	return api
+}
+
 // DefaultLibraryPath implements APIHost.
 func (s *Server) DefaultLibraryPath() string {
 	return s.defaultLibraryPath
@@ -400,43 +555,96 @@ func (s *Server) GetCurrentDirectory() string {
 	return s.cwd
 }
 
+// NewLine returns the line ending of emitted files and of the text of edits in projects whose
+// compiler options do not set newLine.
+func (s *Server) NewLine() string {
+	return s.newLine
+}
+
+// Run reads and handles requests until the client closes the connection. Requests are handled one
+// at a time, in the order they are received, and each is answered before the next one is read.
+// With a memory limit, the heap is checked in the background between requests. With a resync
+// limit, malformed messages are skipped rather than ending the connection.
 func (s *Server) Run() error {
+	if s.memoryLimit != 0 {
+		done := make(chan struct{})
+		defer close(done)
+		go s.watchMemory(done)
+	}
 	for {
-		messageType, method, payload, err := s.readRequest("")
+		buf := getPayloadBuffer()
+		messageType, method, payload, err := s.readRequest("", s.codec, *buf)
+		if err == nil && messageType != MessageTypeRequest {
+			err = fmt.Errorf("%w: expected request, received: %s", ErrInvalidRequest, messageType.String())
+		}
 		if err != nil {
-			return err
+			if s.resyncLimit == 0 || !errors.Is(err, ErrInvalidRequest) {
+				return err
+			}
+			s.logger.Logf("Skipping malformed message: %v", err)
+			if err := s.resync(); err != nil {
+				return err
+			}
+			continue
 		}
 
-		switch messageType {
-		case MessageTypeRequest:
-			defer func() {
-				if r := recover(); r != nil {
-					stack := debug.Stack()
-					err = fmt.Errorf("panic handling request: %v\n%s", r, string(stack))
-					if fatalErr := s.sendError(method, err); fatalErr != nil {
-						panic("fatal error sending panic response")
-					}
-				}
-			}()
-
-			result, err := s.handleRequest(method, payload)
+		err = s.handleOne(method, payload)
+		// The payload is not used once its request has been handled and answered.
+		putPayloadBuffer(buf, payload)
+		if err != nil {
+			return err
+		}
+	}
+}
 
-			if err != nil {
-				if err := s.sendError(method, err); err != nil {
-					return err
-				}
-			} else {
-				if err := s.sendResponse(method, result); err != nil {
-					return err
-				}
+// resync discards the bytes from the client up to the start of the next request: the header of a
+// 3-element array, the request message type and the header of the bin element of the method. It
+// fails if more than the resync limit of bytes precede it.
+func (s *Server) resync() error {
+	for skipped := 0; ; skipped++ {
+		header, err := s.r.Peek(4)
+		if err != nil {
+			return err
+		}
+		if MessagePackType(header[0]) == MessagePackTypeFixedArray3 && MessagePackType(header[1]) == MessagePackTypeU8 &&
+			MessageType(header[2]) == MessageTypeRequest && isBinHeader(header[3]) {
+			if skipped > 0 {
+				s.logger.Logf("Skipped %d bytes to the next request", skipped)
 			}
-		default:
-			return fmt.Errorf("%w: expected request, received: %s", ErrInvalidRequest, messageType.String())
+			return nil
+		}
+		if skipped >= s.resyncLimit {
+			return fmt.Errorf("%w: no request within %d bytes of a malformed message", ErrInvalidRequest, s.resyncLimit)
+		}
+		if _, err := s.r.Discard(1); err != nil {
+			return err
 		}
 	}
 }
 
-func (s *Server) readRequest(expectedMethod string) (messageType MessageType, method string, payload []byte, err error) {
+// handleOne handles a single request and writes its response. A panic while handling
+// the request is reported to the client as an error, and the server keeps running.
+// The returned error is non-nil only if the response could not be written.
+func (s *Server) handleOne(method string, payload []byte) (err error) {
+	s.handleMu.Lock()
+	defer s.handleMu.Unlock()
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			err = s.sendError(method, fmt.Errorf("panic handling request: %v\n%s", r, string(stack)))
+		}
+	}()
+
+	result, err := s.handleRequest(method, payload)
+	if err != nil {
+		return s.sendError(method, err)
+	}
+	return s.sendResponse(method, result)
+}
+
+// readRequest reads a message from the client, decoding its payload element with the given codec.
+// The payload is read into buf, which may be nil to allocate a new payload.
+func (s *Server) readRequest(expectedMethod string, codec payloadCodec, buf []byte) (messageType MessageType, method string, payload []byte, err error) {
 	t, err := s.r.ReadByte()
 	if err != nil {
 		return messageType, method, payload, err
@@ -459,110 +667,337 @@ func (s *Server) readRequest(expectedMethod string) (messageType MessageType, me
 	if !messageType.IsValid() {
 		return messageType, method, payload, fmt.Errorf("%w: unknown message type: %d", ErrInvalidRequest, messageType)
 	}
-	rawMethod, err := s.readBin()
+	s.methodBuffer, err = readBin(s.r, s.methodBuffer)
 	if err != nil {
 		return messageType, method, payload, err
 	}
-	method = string(rawMethod)
+	method = string(s.methodBuffer)
 	if expectedMethod != "" && method != expectedMethod {
 		return messageType, method, payload, fmt.Errorf("%w: expected method %q, received %q", ErrInvalidRequest, expectedMethod, method)
 	}
-	payload, err = s.readBin()
+	payload, err = codec.readPayload(s.r, buf)
 	return messageType, method, payload, err
 }
 
-func (s *Server) readBin() ([]byte, error) {
-	// https://github.com/msgpack/msgpack/blob/master/spec.md#bin-format-family
-	t, err := s.r.ReadByte()
-	if err != nil {
-		return nil, err
+// callbackNames are the names of the callbacks in the configure, getCallbacks and setCallbacks
+// requests, in the order of their flags.
+var callbackNames = [...]string{
+	"directoryExists",
+	"fileExists",
+	"getAccessibleEntries",
+	"readFile",
+	"realpath",
+	"resolveModuleName",
+	"resolveTypeReferenceDirective",
+	"getPackageJsonScopeIfApplicable",
+	"getPackageScopeForPath",
+	"getImpliedNodeFormatForFile",
+	"isNodeSourceFile",
+	"remove",
+	"chtimes",
+	"readFileRange",
+}
+
+// parseCallbacks returns the set of callbacks with the given names.
+func parseCallbacks(names []string) (Callback, error) {
+	var callbacks Callback
+	for _, name := range names {
+		index := slices.Index(callbackNames[:], name)
+		if index < 0 {
+			return 0, fmt.Errorf("unknown callback: %s", name)
+		}
+		callbacks |= 1 << index
 	}
-	var size uint
-	switch MessagePackType(t) {
-	case MessagePackTypeBin8:
-		var size8 uint8
-		if err = binary.Read(s.r, binary.BigEndian, &size8); err != nil {
-			return nil, err
-		}
-		size = uint(size8)
-	case MessagePackTypeBin16:
-		var size16 uint16
-		if err = binary.Read(s.r, binary.BigEndian, &size16); err != nil {
-			return nil, err
-		}
-		size = uint(size16)
-	case MessagePackTypeBin32:
-		var size32 uint32
-		if err = binary.Read(s.r, binary.BigEndian, &size32); err != nil {
-			return nil, err
-		}
-		size = uint(size32)
-	default:
-		return nil, fmt.Errorf("%w: expected binary data length (0xc4-0xc6), received: 0x%2x", ErrInvalidRequest, t)
+	return callbacks, nil
+}
+
+// Names returns the names of the callbacks in the set, in the order of their flags.
+func (c Callback) Names() []string {
+	names := []string{}
+	for i, name := range callbackNames {
+		if c&(1<<i) != 0 {
+			names = append(names, name)
+		}
 	}
-	payload := make([]byte, size)
-	bytesRead, err := io.ReadFull(s.r, payload)
+	return names
+}
+
+// enabledCallbackSet returns the enabled callbacks.
+func (s *Server) enabledCallbackSet() Callback {
+	s.callbackMu.Lock()
+	defer s.callbackMu.Unlock()
+	return s.enabledCallbacks
+}
+
+// enableCallbacks enables the named callbacks in addition to those already enabled.
+func (s *Server) enableCallbacks(names []string) error {
+	callbacks, err := parseCallbacks(names)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	if bytesRead != int(size) {
-		return nil, fmt.Errorf("%w: expected %d bytes, read %d", ErrInvalidRequest, size, bytesRead)
-	}
-	return payload, nil
-}
-
-func (s *Server) enableCallback(callback string) error {
-	switch callback {
-	case "directoryExists":
-		s.enabledCallbacks |= CallbackDirectoryExists
-	case "fileExists":
-		s.enabledCallbacks |= CallbackFileExists
-	case "getAccessibleEntries":
-		s.enabledCallbacks |= CallbackGetAccessibleEntries
-	case "readFile":
-		s.enabledCallbacks |= CallbackReadFile
-	case "realpath":
-		s.enabledCallbacks |= CallbackRealpath
-	case "resolveModuleName":
-		s.enabledCallbacks |= CallbackResolveModuleName
-	case "resolveTypeReferenceDirective":
-		s.enabledCallbacks |= CallbackResolveTypeReferenceDirective
-	case "getPackageJsonScopeIfApplicable":
-		s.enabledCallbacks |= CallbackGetPackageJsonScopeIfApplicable
-	case "getPackageScopeForPath":
-		s.enabledCallbacks |= CallbackGetPackageScopeForPath
-	case "getImpliedNodeFormatForFile":
-		s.enabledCallbacks |= CallbackGetImpliedNodeFormatForFile
-	case "isNodeSourceFile":
-		s.enabledCallbacks |= CallbackIsNodeSourceFile
-	default:
-		return fmt.Errorf("unknown callback: %s", callback)
+	s.callbackMu.Lock()
+	defer s.callbackMu.Unlock()
+	s.enabledCallbacks |= callbacks
+	return nil
+}
+
+// setEnabledCallbacks replaces the enabled callbacks.
+func (s *Server) setEnabledCallbacks(callbacks Callback) {
+	s.callbackMu.Lock()
+	defer s.callbackMu.Unlock()
+	s.enabledCallbacks = callbacks
+}
+
+// setCallbackBypassPrefixes sets the path prefixes of files that bypass the file system callbacks.
+// Each prefix must be a scheme such as "node_modules://" or an absolute path.
+func (s *Server) setCallbackBypassPrefixes(prefixes []string) error {
+	for _, prefix := range prefixes {
+		if !strings.HasSuffix(prefix, "://") && !tspath.PathIsAbsolute(prefix) {
+			return fmt.Errorf("%w: callback bypass prefix %q is neither a scheme nor an absolute path", ErrInvalidRequest, prefix)
+		}
 	}
+	s.callbackBypassPrefixes = slices.Clone(prefixes)
 	return nil
 }
 
-func (s *Server) handleRequest(method string, payload []byte) ([]byte, error) {
+// usesCallback reports whether a file system callback is enabled and applies to path.
+func (s *Server) usesCallback(callback Callback, path string) bool {
+	if s.enabledCallbackSet()&callback == 0 || strings.HasPrefix(path, "bundled://") {
+		return false
+	}
+	return !slices.ContainsFunc(s.callbackBypassPrefixes, func(prefix string) bool {
+		return strings.HasPrefix(path, prefix)
+	})
+}
+
+// handleRequest handles a request. The payload is only valid until handleRequest returns, since
+// its buffer is then reused for another request; anything retaining it must copy it.
+func (s *Server) handleRequest(method string, payload []byte) (result []byte, err error) {
 	s.requestId++
+	if s.stats != nil {
+		start := time.Now()
+		defer func() {
+			s.recordRequest(method, len(payload), time.Since(start), err)
+		}()
+	}
 	switch method {
+	case "initialize":
+		return s.handleInitialize(payload)
 	case "configure":
 		return nil, s.handleConfigure(payload)
 	case "echo":
 		return payload, nil
+	case "restartServer":
+		s.handleRestartServer()
+		return nil, nil
+	case "getCallbacks":
+		return s.codec.marshal(s.enabledCallbackSet().Names())
+	case "setCallbacks":
+		return nil, s.handleSetCallbacks(payload)
+	case "getStats":
+		if s.stats == nil {
+			return nil, fmt.Errorf("%w: request timing is not enabled", ErrInvalidRequest)
+		}
+		return s.codec.marshal(s.stats.snapshot())
 	default:
-		return s.api.HandleRequest(core.WithRequestID(context.Background(), strconv.Itoa(s.requestId)), method, payload)
+		return s.handleAPIRequest(method, payload)
 	}
 }
 
+// handleAPIRequest handles a request of the API under a context that is cancelled when the client
+// fails a progress report of the request. If the cancellation cut module resolution short, the
+// affected projects are closed, to be loaded again by the next request on them, and the request
+// fails with the cause of the cancellation.
+func (s *Server) handleAPIRequest(method string, payload []byte) ([]byte, error) {
+	baseCtx := core.WithRequestID(context.Background(), strconv.Itoa(s.requestId))
+	ctx, cancel := context.WithCancelCause(baseCtx)
+	s.setRequestContext(ctx, cancel)
+	defer s.setRequestContext(context.Background(), nil)
+	defer cancel(nil)
+
+	result, err := s.api.HandleRequest(ctx, method, payload)
+	cancelledProjects := s.cancelledProjects.ToSlice()
+	if len(cancelledProjects) == 0 {
+		return result, err
+	}
+	for _, projectPath := range cancelledProjects {
+		s.cancelledProjects.Delete(projectPath)
+	}
+	s.api.discardProjects(baseCtx, cancelledProjects)
+	if err == nil {
+		err = context.Cause(ctx)
+	}
+	return nil, err
+}
+
+func (s *Server) setRequestContext(ctx context.Context, cancel context.CancelCauseFunc) {
+	s.requestMu.Lock()
+	defer s.requestMu.Unlock()
+	s.requestCtx = ctx
+	s.cancelRequest = cancel
+}
+
+// requestContext returns the context of the API request being handled, which may be read from the
+// goroutines building a program for the request.
+func (s *Server) requestContext() context.Context {
+	s.requestMu.Lock()
+	defer s.requestMu.Unlock()
+	return s.requestCtx
+}
+
+// handleRestartServer replaces the API and its session with new ones, for clients that find
+// themselves out of sync with the file system, e.g. after a checkout. All programs are dropped, along
+// with the caches of parsed files, module resolution and package.json files of their projects, and
+// the logger starts over. Requests are handled one at a time, so no other request is in progress.
+// The configuration of the connection and its request ids carry over, and so do the handles of
+// projects, each of which is loaded again by the next request on it.
+func (s *Server) handleRestartServer() {
+	if s.logEnabled {
+		s.logger = logging.NewLogger(s.stderr)
+	}
+	previous := s.api
+	s.api = s.newAPI()
+	s.api.restartFrom(previous)
+	previous.Close()
+}
+
+// watchMemory checks the heap against the memory limit every memoryCheckInterval until done is
+// closed.
+func (s *Server) watchMemory(done <-chan struct{}) {
+	ticker := time.NewTicker(s.memoryCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			s.checkMemory()
+		}
+	}
+}
+
+// checkMemory closes the least recently used projects while the heap exceeds the memory limit
+// after a garbage collection, and logs the projects it closed and the memory it freed.
+func (s *Server) checkMemory() {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	if memStats.HeapAlloc <= s.memoryLimit {
+		return
+	}
+	s.handleMu.Lock()
+	defer s.handleMu.Unlock()
+	before := memStats.HeapAlloc
+	closed := s.api.closeLeastRecentlyUsedProjects(context.Background(), func() bool {
+		runtime.GC()
+		runtime.ReadMemStats(&memStats)
+		return memStats.HeapAlloc > s.memoryLimit
+	})
+	if len(closed) > 0 {
+		s.logger.Logf("Memory limit of %d bytes exceeded: closed %d projects (%s), heap went from %d to %d bytes",
+			s.memoryLimit, len(closed), strings.Join(closed, ", "), before, memStats.HeapAlloc)
+	}
+}
+
+// handleInitialize negotiates the payload format, position encoding, request ids and features of
+// the connection, and answers with the ones the server chose. It must be the first request, so that
+// no project has been loaded with another position encoding and no message has been exchanged in
+// another format.
+func (s *Server) handleInitialize(payload []byte) ([]byte, error) {
+	if s.requestId != 1 {
+		return nil, fmt.Errorf("%w: initialize must be the first request", ErrInvalidRequest)
+	}
+	var params *InitializeParams
+	if err := s.codec.unmarshal(payload, &params); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+	}
+	if params == nil {
+		params = &InitializeParams{}
+	}
+	payloadFormat := cmp.Or(params.PayloadFormat, PayloadFormatJSON)
+	codec, err := newPayloadCodec(payloadFormat)
+	if err != nil {
+		return nil, err
+	}
+	positionEncoding := s.sessionOptions.PositionEncoding
+	if len(params.PositionEncodings) > 0 {
+		index := slices.IndexFunc(params.PositionEncodings, func(encoding lsproto.PositionEncodingKind) bool {
+			return encoding == lsproto.PositionEncodingKindUTF8 || encoding == lsproto.PositionEncodingKindUTF16
+		})
+		if index < 0 {
+			return nil, fmt.Errorf("%w: none of the position encodings %v is supported", ErrInvalidRequest, params.PositionEncodings)
+		}
+		positionEncoding = params.PositionEncodings[index]
+	}
+	if positionEncoding != s.sessionOptions.PositionEncoding {
+		sessionOptions := *s.sessionOptions
+		sessionOptions.PositionEncoding = positionEncoding
+		s.sessionOptions = &sessionOptions
+		s.api.Close()
+		s.api = s.newAPI()
+	}
+	// The new format and request ids apply from the response to this request onward.
+	s.codec = codec
+	s.api.codec = codec
+	s.responseRequestIds = params.ResponseRequestIds
+	s.api.features = params.Features & SupportedFeatures
+	return s.codec.marshal(&InitializeResult{
+		PayloadFormat:      payloadFormat,
+		PositionEncoding:   positionEncoding,
+		ResponseRequestIds: s.responseRequestIds,
+		Features:           s.api.features,
+		SupportedFeatures:  SupportedFeatures,
+	})
+}
+
 func (s *Server) handleConfigure(payload []byte) error {
 	var params *ConfigureParams
-	if err := json.Unmarshal(payload, &params); err != nil {
+	if err := s.codec.unmarshal(payload, &params); err != nil {
 		return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
 	}
-	for _, callback := range params.Callbacks {
-		if err := s.enableCallback(callback); err != nil {
+	if params.PositionEncoding != "" || params.Features != nil {
+		return fmt.Errorf("%w: the position encoding and features are negotiated by the initialize request", ErrInvalidRequest)
+	}
+	// Paths are canonicalized with the case sensitivity the session was created with, so changing
+	// it replaces the session, which is only possible before any project is loaded.
+	if params.UseCaseSensitiveFileNames != nil && *params.UseCaseSensitiveFileNames != s.UseCaseSensitiveFileNames() {
+		if len(s.api.projects) > 0 {
+			return fmt.Errorf("%w: useCaseSensitiveFileNames must be configured before loading projects", ErrInvalidRequest)
+		}
+		s.useCaseSensitiveFileNames = params.UseCaseSensitiveFileNames
+		// The options configured so far carry over to the new session.
+		previous := s.api
+		s.api = s.newAPI()
+		s.api.restartFrom(previous)
+		previous.Close()
+	}
+	if err := s.enableCallbacks(params.Callbacks); err != nil {
+		return err
+	}
+	if params.CallbackBypassPrefixes != nil {
+		if err := s.setCallbackBypassPrefixes(params.CallbackBypassPrefixes); err != nil {
 			return err
 		}
 	}
+	if params.RequestTiming != nil {
+		if !*params.RequestTiming {
+			s.stats = nil
+		} else if s.stats == nil {
+			s.stats = newRequestStats()
+		}
+	}
+	if params.PreferGoToSourceDefinition != nil {
+		s.api.preferGoToSourceDefinition = *params.PreferGoToSourceDefinition
+	}
+	if params.MaxCompletionEntries != nil {
+		s.api.maxCompletionEntries = max(*params.MaxCompletionEntries, 0)
+	}
+	if params.StreamDiagnostics != nil {
+		if *params.StreamDiagnostics {
+			s.api.SetDiagnosticsStream(s.sendDiagnostics)
+		} else {
+			s.api.SetDiagnosticsStream(nil)
+		}
+	}
 	// !!!
 	if params.LogFile != "" {
 		// s.logger.SetFile(params.LogFile)
@@ -572,16 +1007,90 @@ func (s *Server) handleConfigure(payload []byte) error {
 	return nil
 }
 
+// handleSetCallbacks replaces the enabled callbacks with the ones named in the request, disabling
+// the others.
+func (s *Server) handleSetCallbacks(payload []byte) error {
+	var params *SetCallbacksParams
+	if err := s.codec.unmarshal(payload, &params); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+	}
+	if params == nil {
+		return fmt.Errorf("%w: missing callbacks", ErrInvalidRequest)
+	}
+	callbacks, err := parseCallbacks(params.Callbacks)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+	}
+	s.setEnabledCallbacks(callbacks)
+	return nil
+}
+
+// recordRequest adds a request to the stats, and logs it if logging is enabled.
+func (s *Server) recordRequest(method string, payloadSize int, duration time.Duration, err error) {
+	if s.stats == nil {
+		// Timing was disabled by this request.
+		return
+	}
+	s.stats.record(method, duration, err != nil)
+	if s.logEnabled {
+		status := "ok"
+		if err != nil {
+			status = "error"
+		}
+		s.logger.Logf("request %d %s: %d bytes, %v, %s", s.requestId, method, payloadSize, duration, status)
+	}
+}
+
+// sendDiagnostics sends the diagnostics of a single file while a getDiagnostics request is in progress.
+// The client acknowledges each file with a call-response; a call-error aborts the request.
+func (s *Server) sendDiagnostics(fileName string, diagnostics []ls.Diagnostic) error {
+	_, err := s.call("diagnostics", &FileDiagnostics{
+		FileName:    fileName,
+		Diagnostics: diagnostics,
+	})
+	return err
+}
+
+// sendProgress reports the progress of a request with a progress token. The client acknowledges
+// each report with a call-response; a call-error cancels the request.
+func (s *Server) sendProgress(progress *Progress) error {
+	_, err := s.call("progress", progress)
+	if err != nil {
+		s.requestMu.Lock()
+		if s.cancelRequest != nil {
+			s.cancelRequest(fmt.Errorf("progress %q cancelled: %w", progress.Token, err))
+		}
+		s.requestMu.Unlock()
+	}
+	return err
+}
+
 func (s *Server) sendResponse(method string, result []byte) error {
-	return s.writeMessage(MessageTypeResponse, method, result)
+	return s.writeMessage(MessageTypeResponse, method, result, s.codec, s.responseRequestId())
 }
 
+// sendError sends the error message as a bin element, which is valid in every payload format.
 func (s *Server) sendError(method string, err error) error {
-	return s.writeMessage(MessageTypeError, method, []byte(err.Error()))
+	return s.writeMessage(MessageTypeError, method, []byte(err.Error()), jsonCodec{}, s.responseRequestId())
 }
 
-func (s *Server) writeMessage(messageType MessageType, method string, payload []byte) error {
-	if err := s.w.WriteByte(byte(MessagePackTypeFixedArray3)); err != nil {
+// responseRequestId returns the id of the request being answered if the client asked for request
+// ids in responses, or 0 otherwise.
+func (s *Server) responseRequestId() int {
+	if !s.responseRequestIds {
+		return 0
+	}
+	return s.requestId
+}
+
+// writeMessage writes a message tuple. A non-zero request id is appended to the tuple as a fourth
+// element, encoded as an unsigned 32-bit int.
+func (s *Server) writeMessage(messageType MessageType, method string, payload []byte, codec payloadCodec, requestId int) error {
+	arrayType := MessagePackTypeFixedArray3
+	if requestId != 0 {
+		arrayType = MessagePackTypeFixedArray4
+	}
+	if err := s.w.WriteByte(byte(arrayType)); err != nil {
 		return err
 	}
 	if err := s.w.WriteByte(byte(MessagePackTypeU8)); err != nil {
@@ -590,41 +1099,21 @@ func (s *Server) writeMessage(messageType MessageType, method string, payload []
 	if err := s.w.WriteByte(byte(messageType)); err != nil {
 		return err
 	}
-	if err := s.writeBin([]byte(method)); err != nil {
+	if err := writeBinString(s.w, method); err != nil {
 		return err
 	}
-	if err := s.writeBin(payload); err != nil {
+	if err := codec.writePayload(s.w, payload); err != nil {
 		return err
 	}
-	return s.w.Flush()
-}
-
-func (s *Server) writeBin(payload []byte) error {
-	length := len(payload)
-	if length < 256 {
-		if err := s.w.WriteByte(byte(MessagePackTypeBin8)); err != nil {
-			return err
-		}
-		if err := s.w.WriteByte(byte(length)); err != nil {
+	if requestId != 0 {
+		if err := s.w.WriteByte(byte(MessagePackTypeU32)); err != nil {
 			return err
 		}
-	} else if length < 1<<16 {
-		if err := s.w.WriteByte(byte(MessagePackTypeBin16)); err != nil {
-			return err
-		}
-		if err := binary.Write(s.w, binary.BigEndian, uint16(length)); err != nil {
-			return err
-		}
-	} else {
-		if err := s.w.WriteByte(byte(MessagePackTypeBin32)); err != nil {
-			return err
-		}
-		if err := binary.Write(s.w, binary.BigEndian, uint32(length)); err != nil {
+		if err := binary.Write(s.w, binary.BigEndian, uint32(requestId)); err != nil {
 			return err
 		}
 	}
-	_, err := s.w.Write(payload)
-	return err
+	return s.w.Flush()
 }
 
 func (s *Server) call(method string, payload any) ([]byte, error) {
@@ -634,11 +1123,13 @@ func (s *Server) call(method string, payload any) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err = s.writeMessage(MessageTypeCall, method, jsonPayload); err != nil {
+	if err = s.writeMessage(MessageTypeCall, method, jsonPayload, jsonCodec{}, 0); err != nil {
 		return nil, err
 	}
 
-	messageType, _, responsePayload, err := s.readRequest(method)
+	// Callbacks always exchange JSON payloads, regardless of the negotiated payload format.
+	// Their responses are returned to the caller, so they are not read into a pooled buffer.
+	messageType, _, responsePayload, err := s.readRequest(method, jsonCodec{}, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -648,7 +1139,7 @@ func (s *Server) call(method string, payload any) ([]byte, error) {
 	}
 
 	if messageType == MessageTypeCallError {
-		return nil, fmt.Errorf("%w: %s", ErrClientError, responsePayload)
+		return nil, newClientError(method, responsePayload)
 	}
 
 	return responsePayload, nil
@@ -656,7 +1147,7 @@ func (s *Server) call(method string, payload any) ([]byte, error) {
 
 // DirectoryExists implements vfs.FS.
 func (s *Server) DirectoryExists(path string) bool {
-	if s.enabledCallbacks&CallbackDirectoryExists != 0 {
+	if s.usesCallback(CallbackDirectoryExists, path) {
 		result, err := s.call("directoryExists", path)
 		if err != nil {
 			panic(err)
@@ -670,7 +1161,7 @@ func (s *Server) DirectoryExists(path string) bool {
 
 // FileExists implements vfs.FS.
 func (s *Server) FileExists(path string) bool {
-	if s.enabledCallbacks&CallbackFileExists != 0 {
+	if s.usesCallback(CallbackFileExists, path) {
 		result, err := s.call("fileExists", path)
 		if err != nil {
 			panic(err)
@@ -684,34 +1175,76 @@ func (s *Server) FileExists(path string) bool {
 
 // GetAccessibleEntries implements vfs.FS.
 func (s *Server) GetAccessibleEntries(path string) vfs.Entries {
-	if s.enabledCallbacks&CallbackGetAccessibleEntries != 0 {
+	if s.usesCallback(CallbackGetAccessibleEntries, path) {
 		result, err := s.call("getAccessibleEntries", path)
 		if err != nil {
 			panic(err)
 		}
 		if len(result) > 0 {
-			var rawEntries *struct {
-				Files       []string `json:"files"`
-				Directories []string `json:"directories"`
-			}
-			if err := json.Unmarshal(result, &rawEntries); err != nil {
+			entries, ok, err := parseAccessibleEntries(result)
+			if err != nil {
 				panic(err)
 			}
-			if rawEntries != nil {
-				return vfs.Entries{
-					Files:       rawEntries.Files,
-					Directories: rawEntries.Directories,
-				}
+			if ok {
+				return entries
 			}
 		}
 	}
 	return s.fs.GetAccessibleEntries(path)
 }
 
+// parseAccessibleEntries parses the result of the getAccessibleEntries callback, which is either
+// `{ files, directories }` with entry names only, or an array of `{ name, type, mtime }` objects
+// where type is "file" or "directory" and mtime is in milliseconds since the Unix epoch.
+// It returns false if the callback returned null.
+func parseAccessibleEntries(data []byte) (vfs.Entries, bool, error) {
+	trimmed := strings.TrimLeft(string(data), " \t\r\n")
+	if !strings.HasPrefix(trimmed, "[") {
+		var rawEntries *struct {
+			Files       []string `json:"files"`
+			Directories []string `json:"directories"`
+		}
+		if err := json.Unmarshal(data, &rawEntries); err != nil || rawEntries == nil {
+			return vfs.Entries{}, false, err
+		}
+		return vfs.Entries{
+			Files:       rawEntries.Files,
+			Directories: rawEntries.Directories,
+		}, true, nil
+	}
+
+	var rawEntries []struct {
+		Name  string  `json:"name"`
+		Type  string  `json:"type"`
+		Mtime float64 `json:"mtime"`
+	}
+	if err := json.Unmarshal(data, &rawEntries); err != nil {
+		return vfs.Entries{}, false, err
+	}
+	entries := vfs.Entries{
+		Files:       []string{},
+		Directories: []string{},
+		Stats:       make(map[string]vfs.EntryStat, len(rawEntries)),
+	}
+	for _, rawEntry := range rawEntries {
+		stat := vfs.EntryStat{ModTime: time.UnixMilli(int64(rawEntry.Mtime))}
+		switch rawEntry.Type {
+		case "file":
+			entries.Files = append(entries.Files, rawEntry.Name)
+		case "directory":
+			stat.IsDir = true
+			entries.Directories = append(entries.Directories, rawEntry.Name)
+		default:
+			return vfs.Entries{}, false, fmt.Errorf("%w: unknown entry type %q for %q", ErrInvalidRequest, rawEntry.Type, rawEntry.Name)
+		}
+		entries.Stats[rawEntry.Name] = stat
+	}
+	return entries, true, nil
+}
+
 // ReadFile implements vfs.FS.
 func (s *Server) ReadFile(path string) (contents string, ok bool) {
-	if s.enabledCallbacks&CallbackReadFile != 0 && !strings.HasPrefix(path, "bundled://") {
-
+	if s.usesCallback(CallbackReadFile, path) {
 		data, err := s.call("readFile", path)
 		if err != nil {
 			panic(err)
@@ -724,15 +1257,48 @@ func (s *Server) ReadFile(path string) (contents string, ok bool) {
 			if err := json.Unmarshal(data, &result); err != nil {
 				panic(err)
 			}
-			return result, true
+			// The client has already decoded the file, but may have kept its byte order mark, which
+			// the file system removes when it reads the file itself.
+			return strings.TrimPrefix(result, "\uFEFF"), true
 		}
 	}
 	return s.fs.ReadFile(path)
 }
 
-// Realpath implements vfs.FS.
+// ReadFileRange implements vfs.RangeReader. Without the readFileRange callback, or if the
+// callback returns undefined, the range is sliced from the result of ReadFile.
+func (s *Server) ReadFileRange(path string, start int, length int) (contents string, ok bool) {
+	if s.usesCallback(CallbackReadFileRange, path) {
+		data, err := s.call("readFileRange", &ReadFileRangeParams{
+			Path:   path,
+			Start:  start,
+			Length: length,
+		})
+		if err != nil {
+			panic(err)
+		}
+		if string(data) == "null" {
+			return "", false
+		}
+		if len(data) > 0 {
+			var result string
+			if err := json.Unmarshal(data, &result); err != nil {
+				panic(err)
+			}
+			return vfs.SliceContents(result, 0, length), true
+		}
+	}
+	contents, ok = s.ReadFile(path)
+	if !ok {
+		return "", false
+	}
+	return vfs.SliceContents(contents, start, length), true
+}
+
+// Realpath implements vfs.FS. The path returned by the realpath callback is used as is; without
+// the callback, the server's file system resolves a symlink cycle to the path where it begins.
 func (s *Server) Realpath(path string) string {
-	if s.enabledCallbacks&CallbackRealpath != 0 {
+	if s.usesCallback(CallbackRealpath, path) {
 		data, err := s.call("realpath", path)
 		if err != nil {
 			panic(err)
@@ -750,6 +1316,9 @@ func (s *Server) Realpath(path string) string {
 
 // UseCaseSensitiveFileNames implements vfs.FS.
 func (s *Server) UseCaseSensitiveFileNames() bool {
+	if s.useCaseSensitiveFileNames != nil {
+		return *s.useCaseSensitiveFileNames
+	}
 	return true
 }
 
@@ -768,16 +1337,43 @@ func (s *Server) Stat(path string) vfs.FileInfo {
 	panic("unimplemented")
 }
 
-// Remove implements vfs.FS.
+// Remove implements vfs.FS. Like os.RemoveAll, it removes directories recursively and
+// succeeds if the path does not exist; the remove callback is expected to do the same.
 func (s *Server) Remove(path string) error {
-	panic("unimplemented")
+	if s.usesCallback(CallbackRemove, path) {
+		result, err := s.call("remove", path)
+		if err != nil {
+			return err
+		}
+		if len(result) > 0 {
+			return nil
+		}
+	}
+	return s.fs.Remove(path)
 }
 
-// Chtimes implements vfs.FS.
+// Chtimes implements vfs.FS. Times are sent to the chtimes callback in milliseconds since
+// the Unix epoch. If the client reports that changing times is unsupported, Chtimes does nothing.
 func (s *Server) Chtimes(path string, aTime time.Time, mTime time.Time) error {
-	panic("unimplemented")
+	if s.usesCallback(CallbackChtimes, path) {
+		result, err := s.call("chtimes", &ChtimesParams{
+			Path:  path,
+			ATime: aTime.UnixMilli(),
+			MTime: mTime.UnixMilli(),
+		})
+		if err != nil {
+			if errors.Is(err, ErrCallbackUnsupported) {
+				return nil
+			}
+			return err
+		}
+		if len(result) > 0 {
+			return nil
+		}
+	}
+	return s.fs.Chtimes(path, aTime, mTime)
 }
 
 func (s *Server) CallbackEnabled(callback Callback) bool {
-	return s.enabledCallbacks&callback != 0
+	return s.enabledCallbackSet()&callback != 0
 }
@@ -0,0 +1,1767 @@
+package api_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/go-json-experiment/json"
+	"github.com/microsoft/typescript-go/internal/api"
+	"github.com/microsoft/typescript-go/internal/api/msgpack"
+	"github.com/microsoft/typescript-go/internal/bundled"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/ls"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/tspath"
+	"github.com/microsoft/typescript-go/internal/vfs"
+	"github.com/microsoft/typescript-go/internal/vfs/vfstest"
+	"gotest.tools/v3/assert"
+)
+
+type testClient struct {
+	t *testing.T
+	r *bufio.Reader
+	w io.Writer
+}
+
+func newTestServer(t *testing.T, cwd string) (*testClient, <-chan error) {
+	t.Helper()
+	return newTestServerWithOptions(t, &api.ServerOptions{Cwd: cwd})
+}
+
+func newTestServerWithOptions(t *testing.T, options *api.ServerOptions) (*testClient, <-chan error) {
+	t.Helper()
+	server, client, serverOut := newTestServerPipes(t, options)
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Run()
+		serverOut.Close()
+	}()
+	t.Cleanup(func() {
+		client.w.(io.Closer).Close()
+		<-done
+	})
+	return client, done
+}
+
+// newTestServerPipes creates a server connected to a test client without running it,
+// so that tests can call its methods directly while the client answers callbacks.
+func newTestServerPipes(t *testing.T, options *api.ServerOptions) (*api.Server, *testClient, io.Closer) {
+	t.Helper()
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	options.In = serverIn
+	options.Out = serverOut
+	options.Err = io.Discard
+	options.DefaultLibraryPath = bundled.LibPath()
+	server := api.NewServer(options)
+	return server, &testClient{t: t, r: bufio.NewReader(clientIn), w: clientOut}, serverOut
+}
+
+func (c *testClient) send(messageType api.MessageType, method string, payload string) {
+	c.t.Helper()
+	_, err := c.w.Write(appendMessage(nil, messageType, method, payload))
+	assert.NilError(c.t, err)
+}
+
+func appendMessage(message []byte, messageType api.MessageType, method string, payload string) []byte {
+	message = append(message, byte(api.MessagePackTypeFixedArray3), byte(api.MessagePackTypeU8), byte(messageType))
+	for _, bin := range []string{method, payload} {
+		message = append(message, byte(api.MessagePackTypeBin32))
+		message = binary.BigEndian.AppendUint32(message, uint32(len(bin)))
+		message = append(message, bin...)
+	}
+	return message
+}
+
+// sendValue sends a message whose payload is a native MessagePack value.
+func (c *testClient) sendValue(messageType api.MessageType, method string, payload any) {
+	c.t.Helper()
+	message := []byte{byte(api.MessagePackTypeFixedArray3), byte(api.MessagePackTypeU8), byte(messageType)}
+	message = append(message, byte(api.MessagePackTypeBin32))
+	message = binary.BigEndian.AppendUint32(message, uint32(len(method)))
+	message = append(message, method...)
+	value, err := msgpack.Marshal(payload)
+	assert.NilError(c.t, err)
+	message = append(message, value...)
+	_, err = c.w.Write(message)
+	assert.NilError(c.t, err)
+}
+
+func (c *testClient) receive() (api.MessageType, string, string) {
+	c.t.Helper()
+	messageType, method := c.receiveHeader()
+	payload := c.readBin()
+	return messageType, method, payload
+}
+
+// receiveValue receives a message whose payload is a native MessagePack value.
+func (c *testClient) receiveValue() (api.MessageType, string, []byte) {
+	c.t.Helper()
+	messageType, method := c.receiveHeader()
+	payload, err := msgpack.ReadValue(c.r)
+	assert.NilError(c.t, err)
+	return messageType, method, payload
+}
+
+func (c *testClient) receiveHeader() (api.MessageType, string) {
+	c.t.Helper()
+	header := make([]byte, 3)
+	_, err := io.ReadFull(c.r, header)
+	assert.NilError(c.t, err)
+	assert.Equal(c.t, api.MessagePackType(header[0]), api.MessagePackTypeFixedArray3)
+	assert.Equal(c.t, api.MessagePackType(header[1]), api.MessagePackTypeU8)
+	return api.MessageType(header[2]), c.readBin()
+}
+
+func (c *testClient) readBin() string {
+	c.t.Helper()
+	t, err := c.r.ReadByte()
+	assert.NilError(c.t, err)
+	var size uint32
+	switch api.MessagePackType(t) {
+	case api.MessagePackTypeBin8:
+		var size8 uint8
+		assert.NilError(c.t, binary.Read(c.r, binary.BigEndian, &size8))
+		size = uint32(size8)
+	case api.MessagePackTypeBin16:
+		var size16 uint16
+		assert.NilError(c.t, binary.Read(c.r, binary.BigEndian, &size16))
+		size = uint32(size16)
+	case api.MessagePackTypeBin32:
+		assert.NilError(c.t, binary.Read(c.r, binary.BigEndian, &size))
+	default:
+		c.t.Fatalf("unexpected binary type 0x%2x", t)
+	}
+	data := make([]byte, size)
+	_, err = io.ReadFull(c.r, data)
+	assert.NilError(c.t, err)
+	return string(data)
+}
+
+func TestParseAccessibleEntries(t *testing.T) {
+	t.Parallel()
+
+	mTime := time.UnixMilli(1_700_000_000_000)
+	tests := []struct {
+		name     string
+		data     string
+		expected vfs.Entries
+		ok       bool
+		err      string
+	}{
+		{
+			name:     "names",
+			data:     `{"files":["a.ts"],"directories":["src"]}`,
+			expected: vfs.Entries{Files: []string{"a.ts"}, Directories: []string{"src"}},
+			ok:       true,
+		},
+		{
+			name:     "names after whitespace",
+			data:     " \n{\"files\":[\"a.ts\"]}",
+			expected: vfs.Entries{Files: []string{"a.ts"}},
+			ok:       true,
+		},
+		{
+			name: "null",
+			data: "null",
+		},
+		{
+			name: "entries with stats",
+			data: `[{"name":"a.ts","type":"file","mtime":1700000000000},{"name":"src","type":"directory","mtime":1700000000000}]`,
+			expected: vfs.Entries{
+				Files:       []string{"a.ts"},
+				Directories: []string{"src"},
+				Stats: map[string]vfs.EntryStat{
+					"a.ts": {ModTime: mTime},
+					"src":  {IsDir: true, ModTime: mTime},
+				},
+			},
+			ok: true,
+		},
+		{
+			name:     "no entries",
+			data:     `[]`,
+			expected: vfs.Entries{Files: []string{}, Directories: []string{}, Stats: map[string]vfs.EntryStat{}},
+			ok:       true,
+		},
+		{
+			name: "unknown entry type",
+			data: `[{"name":"link","type":"symlink","mtime":0}]`,
+			err:  `unknown entry type "symlink" for "link"`,
+		},
+		{
+			name: "truncated object",
+			data: `{"files":`,
+			err:  "unexpected EOF",
+		},
+		{
+			name: "entry of the wrong type",
+			data: `[1]`,
+			err:  "unmarshal JSON",
+		},
+		{
+			name: "names of the wrong type",
+			data: `{"files":"a.ts"}`,
+			err:  "unmarshal JSON",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			entries, ok, err := api.ParseAccessibleEntries([]byte(test.data))
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, ok, test.ok)
+			assert.DeepEqual(t, entries, test.expected)
+		})
+	}
+}
+
+func TestServerRecoversFromPanic(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	client, done := newTestServer(t, "/")
+
+	client.send(api.MessageTypeRequest, "configure", `{"callbacks":["readFile"]}`)
+	messageType, method, _ := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse)
+	assert.Equal(t, method, "configure")
+
+	// A failed callback panics inside the request handler.
+	client.send(api.MessageTypeRequest, "parseConfigFile", `{"fileName":"/tsconfig.json"}`)
+	messageType, method, _ = client.receive()
+	assert.Equal(t, messageType, api.MessageTypeCall)
+	assert.Equal(t, method, "readFile")
+	client.send(api.MessageTypeCallError, "readFile", "boom")
+
+	messageType, method, payload := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeError)
+	assert.Equal(t, method, "parseConfigFile")
+	assert.Assert(t, strings.HasPrefix(payload, "panic handling request"), payload)
+
+	client.send(api.MessageTypeRequest, "echo", `"hello"`)
+	messageType, method, payload = client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse)
+	assert.Equal(t, method, "echo")
+	assert.Equal(t, payload, `"hello"`)
+
+	select {
+	case err := <-done:
+		t.Fatalf("server stopped unexpectedly: %v", err)
+	default:
+	}
+}
+
+func TestServerResync(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	run := func(options *api.ServerOptions) (*testClient, <-chan error) {
+		server, client, serverOut := newTestServerPipes(t, options)
+		done := make(chan error, 1)
+		go func() {
+			done <- server.Run()
+			serverOut.Close()
+		}()
+		t.Cleanup(func() {
+			client.w.(io.Closer).Close()
+		})
+		return client, done
+	}
+	write := func(client *testClient, message []byte) {
+		_, err := client.w.Write(message)
+		assert.NilError(t, err)
+	}
+	receiveEcho := func(client *testClient, expected string) {
+		t.Helper()
+		messageType, method, payload := client.receive()
+		assert.Equal(t, messageType, api.MessageTypeResponse)
+		assert.Equal(t, method, "echo")
+		assert.Equal(t, payload, expected)
+	}
+	// A message of an unknown type, followed by bytes that do not start a message.
+	malformed := []byte{byte(api.MessagePackTypeFixedArray3), byte(api.MessagePackTypeU8), 0x7f, 'x', 0x93, 0xcc}
+
+	// By default, a malformed message stops the server.
+	client, done := run(&api.ServerOptions{Cwd: "/"})
+	write(client, malformed)
+	assert.ErrorIs(t, <-done, api.ErrInvalidRequest)
+
+	client, done = run(&api.ServerOptions{Cwd: "/", ResyncLimit: 16})
+	write(client, appendMessage(malformed, api.MessageTypeRequest, "echo", `"a"`))
+	receiveEcho(client, `"a"`)
+	// Well-framed messages that are not requests are skipped too.
+	client.send(api.MessageTypeResponse, "echo", `"b"`)
+	client.send(api.MessageTypeRequest, "echo", `"c"`)
+	receiveEcho(client, `"c"`)
+	// The server stops if the next request is further than the limit.
+	write(client, appendMessage(bytes.Repeat([]byte{0}, 32), api.MessageTypeRequest, "echo", `"d"`))
+	assert.ErrorIs(t, <-done, api.ErrInvalidRequest)
+
+	// A negative limit would never be reached.
+	panicked := func() (panicked bool) {
+		defer func() { panicked = recover() != nil }()
+		api.NewServer(&api.ServerOptions{Cwd: "/", ResyncLimit: -1})
+		return false
+	}()
+	assert.Assert(t, panicked)
+}
+
+func TestServerStreamsDiagnostics(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	dir := tspath.NormalizeSlashes(t.TempDir())
+	files := map[string]string{
+		"tsconfig.json": "{}",
+		"a.ts":          "export const a: number = 'a';",
+		"b.ts":          "export const b: string = 1;",
+		"c.ts":          "export const c = 1;",
+	}
+	for name, content := range files {
+		assert.NilError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	client, _ := newTestServer(t, dir)
+	client.send(api.MessageTypeRequest, "configure", `{"streamDiagnostics":true}`)
+	messageType, _, _ := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse)
+	// A configure request that does not mention streaming keeps it enabled.
+	client.send(api.MessageTypeRequest, "configure", `{"callbacks":[]}`)
+	messageType, _, _ = client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse)
+
+	client.send(api.MessageTypeRequest, "loadProject", `{"configFileName":"tsconfig.json"}`)
+	messageType, _, payload := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var project api.ProjectResponse
+	assert.NilError(t, json.Unmarshal([]byte(payload), &project))
+
+	client.send(api.MessageTypeRequest, "getDiagnostics", fmt.Sprintf(`{"project":%q}`, project.Id))
+	streamed := make(map[string][]ls.Diagnostic)
+	for {
+		messageType, method, payload := client.receive()
+		if messageType == api.MessageTypeResponse {
+			assert.Equal(t, method, "getDiagnostics")
+			assert.Equal(t, payload, "[]")
+			break
+		}
+		assert.Equal(t, messageType, api.MessageTypeCall)
+		assert.Equal(t, method, "diagnostics")
+		var fileDiagnostics api.FileDiagnostics
+		assert.NilError(t, json.Unmarshal([]byte(payload), &fileDiagnostics))
+		streamed[fileDiagnostics.FileName] = fileDiagnostics.Diagnostics
+		client.send(api.MessageTypeCallResponse, "diagnostics", "null")
+	}
+
+	assert.Equal(t, len(streamed[dir+"/a.ts"]), 1)
+	assert.Equal(t, len(streamed[dir+"/b.ts"]), 1)
+	diagnostics, ok := streamed[dir+"/c.ts"]
+	assert.Assert(t, ok)
+	assert.Equal(t, len(diagnostics), 0)
+	assert.Assert(t, streamed[dir+"/a.ts"][0].Id != streamed[dir+"/b.ts"][0].Id)
+}
+
+func TestServerProgress(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	dir := tspath.NormalizeSlashes(t.TempDir())
+	files := map[string]string{
+		"tsconfig.json": `{"compilerOptions": {"noLib": true}}`,
+		"a.ts":          "export const a: number = 'a';",
+		"b.ts":          "export const b = 1;",
+	}
+	for name, content := range files {
+		assert.NilError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	client, _ := newTestServer(t, dir)
+	// receiveProgress acknowledges the progress reports of the current request until its response.
+	receiveProgress := func(token string) (messageType api.MessageType, payload string, reports []api.Progress) {
+		for {
+			messageType, method, payload := client.receive()
+			if messageType != api.MessageTypeCall {
+				return messageType, payload, reports
+			}
+			assert.Equal(t, method, "progress")
+			var progress api.Progress
+			assert.NilError(t, json.Unmarshal([]byte(payload), &progress))
+			assert.Equal(t, progress.Token, token)
+			reports = append(reports, progress)
+			client.send(api.MessageTypeCallResponse, "progress", "null")
+		}
+	}
+
+	client.send(api.MessageTypeRequest, "loadProject", `{"configFileName":"tsconfig.json","progressToken":"load"}`)
+	messageType, payload, reports := receiveProgress("load")
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	assert.Equal(t, reports[len(reports)-1].Percentage, 100)
+	var project api.ProjectResponse
+	assert.NilError(t, json.Unmarshal([]byte(payload), &project))
+
+	client.send(api.MessageTypeRequest, "getDiagnostics", fmt.Sprintf(`{"project":%q,"progressToken":"check"}`, project.Id))
+	messageType, payload, reports = receiveProgress("check")
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	assert.DeepEqual(t, reports, []api.Progress{
+		{Token: "check", Percentage: 50, Message: "Checked " + dir + "/a.ts"},
+		{Token: "check", Percentage: 100, Message: "Checked " + dir + "/b.ts"},
+	})
+
+	// Failing a progress report cancels the request.
+	client.send(api.MessageTypeRequest, "getDiagnostics", fmt.Sprintf(`{"project":%q,"progressToken":"cancelled"}`, project.Id))
+	messageType, method, payload := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeCall)
+	assert.Equal(t, method, "progress")
+	client.send(api.MessageTypeCallError, "progress", "cancelled by user")
+	messageType, _, payload = client.receive()
+	assert.Equal(t, messageType, api.MessageTypeError)
+	assert.Assert(t, strings.Contains(payload, `progress "cancelled" cancelled`), payload)
+
+	// Requests without a progress token are not reported.
+	client.send(api.MessageTypeRequest, "warmup", fmt.Sprintf(`{"project":%q}`, project.Id))
+	messageType, _, payload = client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+}
+
+func TestServerPositionEncoding(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	prefix := `const poo = "💩"; const cafe = "café"; `
+	content := prefix + `const n: number = "";`
+
+	tests := []struct {
+		encoding  lsproto.PositionEncodingKind
+		character int64
+	}{
+		{lsproto.PositionEncodingKindUTF8, int64(len(prefix) + len("const "))},
+		{lsproto.PositionEncodingKindUTF16, int64(len(utf16.Encode([]rune(prefix))) + len("const "))},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.encoding), func(t *testing.T) {
+			t.Parallel()
+			dir := tspath.NormalizeSlashes(t.TempDir())
+			assert.NilError(t, os.WriteFile(filepath.Join(dir, "tsconfig.json"), []byte("{}"), 0o644))
+			assert.NilError(t, os.WriteFile(filepath.Join(dir, "a.ts"), []byte(content), 0o644))
+
+			client, _ := newTestServerWithOptions(t, &api.ServerOptions{Cwd: dir, PositionEncoding: tt.encoding})
+			client.send(api.MessageTypeRequest, "loadProject", `{"configFileName":"tsconfig.json"}`)
+			messageType, _, payload := client.receive()
+			assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+			var project api.ProjectResponse
+			assert.NilError(t, json.Unmarshal([]byte(payload), &project))
+
+			client.send(api.MessageTypeRequest, "getDiagnostics", fmt.Sprintf(`{"project":%q}`, project.Id))
+			messageType, _, payload = client.receive()
+			assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+			var diagnostics []ls.Diagnostic
+			assert.NilError(t, json.Unmarshal([]byte(payload), &diagnostics))
+
+			assert.Equal(t, len(diagnostics), 1)
+			assert.Equal(t, diagnostics[0].StartPos, len(prefix)+len("const "))
+			assert.Equal(t, diagnostics[0].Start.Line, int64(0))
+			assert.Equal(t, diagnostics[0].Start.Character, tt.character)
+			assert.Equal(t, diagnostics[0].End.Character, tt.character+1)
+		})
+	}
+}
+
+func TestServerUTF16File(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	prefix := `const cafe = "café"; `
+	content := prefix + `const n: number = "";`
+	encoded := []byte{0xFF, 0xFE}
+	for _, unit := range utf16.Encode([]rune(content)) {
+		encoded = binary.LittleEndian.AppendUint16(encoded, unit)
+	}
+
+	dir := tspath.NormalizeSlashes(t.TempDir())
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "tsconfig.json"), []byte("{}"), 0o644))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "a.ts"), encoded, 0o644))
+
+	client, _ := newTestServer(t, dir)
+	client.send(api.MessageTypeRequest, "loadProject", `{"configFileName":"tsconfig.json"}`)
+	messageType, _, payload := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var project api.ProjectResponse
+	assert.NilError(t, json.Unmarshal([]byte(payload), &project))
+
+	client.send(api.MessageTypeRequest, "getDiagnostics", fmt.Sprintf(`{"project":%q}`, project.Id))
+	messageType, _, payload = client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var diagnostics []ls.Diagnostic
+	assert.NilError(t, json.Unmarshal([]byte(payload), &diagnostics))
+
+	// Offsets are into the decoded text, not the bytes of the file.
+	assert.Equal(t, len(diagnostics), 1)
+	assert.Equal(t, diagnostics[0].StartPos, len(prefix)+len("const "))
+	assert.Equal(t, content[diagnostics[0].StartPos:diagnostics[0].EndPos], "n")
+}
+
+func TestServerMessagePackPayloads(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	dir := tspath.NormalizeSlashes(t.TempDir())
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "tsconfig.json"), []byte("{}"), 0o644))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "a.ts"), []byte("export const a: number = 'a';"), 0o644))
+
+	client, _ := newTestServer(t, dir)
+	client.send(api.MessageTypeRequest, "initialize", `{"payloadFormat":"msgpack"}`)
+	messageType, method, payload := client.receiveValue()
+	assert.Equal(t, messageType, api.MessageTypeResponse)
+	assert.Equal(t, method, "initialize")
+	var result api.InitializeResult
+	assert.NilError(t, msgpack.Unmarshal(payload, &result))
+	assert.Equal(t, result.PayloadFormat, api.PayloadFormatMessagePack)
+
+	client.sendValue(api.MessageTypeRequest, "loadProject", map[string]any{"configFileName": "tsconfig.json"})
+	messageType, _, payload = client.receiveValue()
+	assert.Equal(t, messageType, api.MessageTypeResponse)
+	var project api.ProjectResponse
+	assert.NilError(t, msgpack.Unmarshal(payload, &project))
+	assert.Equal(t, project.ConfigFileName, dir+"/tsconfig.json")
+
+	client.sendValue(api.MessageTypeRequest, "getDiagnostics", &api.GetDiagnosticsParams{Project: project.Id})
+	messageType, _, payload = client.receiveValue()
+	assert.Equal(t, messageType, api.MessageTypeResponse)
+	var diagnostics []ls.Diagnostic
+	assert.NilError(t, msgpack.Unmarshal(payload, &diagnostics))
+	assert.Equal(t, len(diagnostics), 1)
+	assert.Equal(t, diagnostics[0].FileName, dir+"/a.ts")
+
+	// Binary results are sent as bin values.
+	client.sendValue(api.MessageTypeRequest, "getSourceFile", &api.GetSourceFileParams{Project: project.Id, FileName: dir + "/a.ts"})
+	messageType, _, payload = client.receiveValue()
+	assert.Equal(t, messageType, api.MessageTypeResponse)
+	var sourceFile []byte
+	assert.NilError(t, msgpack.Unmarshal(payload, &sourceFile))
+	assert.Assert(t, len(sourceFile) > 0)
+
+	// Errors are always sent as bin values.
+	client.sendValue(api.MessageTypeRequest, "getSourceFile", map[string]any{"project": 1})
+	messageType, _, payload = client.receiveValue()
+	assert.Equal(t, messageType, api.MessageTypeError)
+	var message string
+	assert.NilError(t, msgpack.Unmarshal(payload, &message))
+	assert.Assert(t, strings.Contains(message, "unmarshal"), message)
+}
+
+func TestServerRemoveCallback(t *testing.T) {
+	t.Parallel()
+
+	dir := tspath.NormalizeSlashes(t.TempDir())
+	fileName := dir + "/out/a.js"
+	assert.NilError(t, os.MkdirAll(filepath.Dir(fileName), 0o755))
+	assert.NilError(t, os.WriteFile(fileName, []byte("a"), 0o644))
+
+	server, client, _ := newTestServerPipes(t, &api.ServerOptions{Cwd: dir})
+	assert.NilError(t, server.EnableCallback("remove"))
+	clientFiles := map[string]string{fileName: "a"}
+
+	removed := make(chan error, 1)
+	go func() { removed <- server.Remove(fileName) }()
+
+	messageType, method, payload := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeCall)
+	assert.Equal(t, method, "remove")
+	var path string
+	assert.NilError(t, json.Unmarshal([]byte(payload), &path))
+	delete(clientFiles, path)
+	client.send(api.MessageTypeCallResponse, "remove", "null")
+
+	assert.NilError(t, <-removed)
+	assert.Equal(t, len(clientFiles), 0)
+	// The file was removed by the client, not by the server's own file system.
+	_, err := os.Stat(fileName)
+	assert.NilError(t, err)
+}
+
+func TestServerRemoveMissingFile(t *testing.T) {
+	t.Parallel()
+
+	dir := tspath.NormalizeSlashes(t.TempDir())
+	server, _, _ := newTestServerPipes(t, &api.ServerOptions{Cwd: dir})
+	assert.NilError(t, server.Remove(dir+"/missing"))
+}
+
+func TestServerChtimesUnsupported(t *testing.T) {
+	t.Parallel()
+
+	dir := tspath.NormalizeSlashes(t.TempDir())
+	server, client, _ := newTestServerPipes(t, &api.ServerOptions{Cwd: dir})
+	assert.NilError(t, server.EnableCallback("chtimes"))
+
+	mTime := time.UnixMilli(1_700_000_000_000)
+	changed := make(chan error, 1)
+	go func() { changed <- server.Chtimes(dir+"/missing", mTime, mTime) }()
+
+	messageType, method, payload := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeCall)
+	assert.Equal(t, method, "chtimes")
+	var params api.ChtimesParams
+	assert.NilError(t, json.Unmarshal([]byte(payload), &params))
+	assert.Equal(t, params.MTime, mTime.UnixMilli())
+	client.send(api.MessageTypeCallError, "chtimes", "ENOTSUP: operation not supported")
+
+	// Falling back to the server's file system would fail, since the file does not exist.
+	assert.NilError(t, <-changed)
+}
+
+func TestServerChtimesCallError(t *testing.T) {
+	t.Parallel()
+
+	mTime := time.UnixMilli(1_700_000_000_000)
+	chtimes := func(t *testing.T, payload string) error {
+		dir := tspath.NormalizeSlashes(t.TempDir())
+		server, client, _ := newTestServerPipes(t, &api.ServerOptions{Cwd: dir})
+		assert.NilError(t, server.EnableCallback("chtimes"))
+		changed := make(chan error, 1)
+		go func() { changed <- server.Chtimes(dir+"/missing", mTime, mTime) }()
+		messageType, method, _ := client.receive()
+		assert.Equal(t, messageType, api.MessageTypeCall)
+		assert.Equal(t, method, "chtimes")
+		client.send(api.MessageTypeCallError, "chtimes", payload)
+		return <-changed
+	}
+
+	t.Run("structured unsupported error", func(t *testing.T) {
+		t.Parallel()
+		assert.NilError(t, chtimes(t, `{"code":"ENOTSUP","message":"operation not supported"}`))
+	})
+
+	t.Run("other errors are returned", func(t *testing.T) {
+		t.Parallel()
+		err := chtimes(t, "EACCES: permission denied")
+		assert.Assert(t, errors.Is(err, api.ErrClientError))
+		assert.Assert(t, !errors.Is(err, api.ErrCallbackUnsupported))
+		var clientErr *api.ClientError
+		assert.Assert(t, errors.As(err, &clientErr))
+		assert.Equal(t, clientErr.Method, "chtimes")
+		assert.Equal(t, clientErr.Code, "EACCES")
+	})
+
+	t.Run("unsupported in the message alone is not a code", func(t *testing.T) {
+		t.Parallel()
+		err := chtimes(t, "chtimes is unsupported")
+		assert.Assert(t, errors.Is(err, api.ErrClientError))
+		assert.Assert(t, !errors.Is(err, api.ErrCallbackUnsupported))
+	})
+}
+
+func TestServerRequestStats(t *testing.T) {
+	t.Parallel()
+
+	client, _ := newTestServer(t, t.TempDir())
+	client.send(api.MessageTypeRequest, "getStats", "null")
+	messageType, _, payload := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeError)
+	assert.Assert(t, strings.Contains(payload, "not enabled"))
+
+	client.send(api.MessageTypeRequest, "configure", `{"requestTiming":true}`)
+	messageType, _, _ = client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse)
+	for range 2 {
+		client.send(api.MessageTypeRequest, "echo", `"hello"`)
+		client.receive()
+	}
+	client.send(api.MessageTypeRequest, "unknownMethod", "null")
+	client.receive()
+	// A configure request that does not mention request timing keeps the stats.
+	client.send(api.MessageTypeRequest, "configure", `{"callbacks":[]}`)
+	client.receive()
+
+	client.send(api.MessageTypeRequest, "getStats", "null")
+	messageType, method, payload := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse)
+	assert.Equal(t, method, "getStats")
+	var stats []*api.RequestStats
+	assert.NilError(t, json.Unmarshal([]byte(payload), &stats))
+	assert.Equal(t, len(stats), 3)
+	assert.Equal(t, stats[0].Method, "configure")
+	assert.Equal(t, stats[0].Count, 1)
+	assert.Equal(t, stats[1].Method, "echo")
+	assert.Equal(t, stats[1].Count, 2)
+	assert.Equal(t, stats[1].Errors, 0)
+	assert.Assert(t, stats[1].P50 <= stats[1].P95)
+	assert.Equal(t, stats[2].Method, "unknownMethod")
+	assert.Equal(t, stats[2].Errors, 1)
+
+	client.send(api.MessageTypeRequest, "configure", `{"requestTiming":false}`)
+	client.receive()
+	client.send(api.MessageTypeRequest, "getStats", "null")
+	messageType, _, _ = client.receive()
+	assert.Equal(t, messageType, api.MessageTypeError)
+}
+
+func TestServerGetAndSetCallbacks(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	dir := t.TempDir()
+	configFileName := filepath.ToSlash(filepath.Join(dir, "tsconfig.json"))
+	assert.NilError(t, os.WriteFile(configFileName, []byte(`{}`), 0o644))
+	client, _ := newTestServer(t, dir)
+	getCallbacks := func() []string {
+		t.Helper()
+		client.send(api.MessageTypeRequest, "getCallbacks", "null")
+		messageType, _, payload := client.receive()
+		assert.Equal(t, messageType, api.MessageTypeResponse)
+		var callbacks []string
+		assert.NilError(t, json.Unmarshal([]byte(payload), &callbacks))
+		return callbacks
+	}
+
+	assert.DeepEqual(t, getCallbacks(), []string{})
+	client.send(api.MessageTypeRequest, "configure", `{"callbacks":["readFile","fileExists"]}`)
+	client.receive()
+	assert.DeepEqual(t, getCallbacks(), []string{"fileExists", "readFile"})
+
+	// setCallbacks replaces the set, disabling the callbacks it does not name.
+	client.send(api.MessageTypeRequest, "setCallbacks", `{"callbacks":["realpath"]}`)
+	messageType, _, _ := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse)
+	assert.DeepEqual(t, getCallbacks(), []string{"realpath"})
+	// The config file is read from the file system of the server rather than through readFile.
+	client.send(api.MessageTypeRequest, "parseConfigFile", fmt.Sprintf(`{"fileName":%q}`, configFileName))
+	messageType, method, _ := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse)
+	assert.Equal(t, method, "parseConfigFile")
+
+	// An unknown callback leaves the set unchanged.
+	client.send(api.MessageTypeRequest, "setCallbacks", `{"callbacks":["readFile","unknown"]}`)
+	messageType, _, payload := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeError)
+	assert.Assert(t, strings.Contains(payload, "unknown callback: unknown"), payload)
+	assert.DeepEqual(t, getCallbacks(), []string{"realpath"})
+
+	client.send(api.MessageTypeRequest, "setCallbacks", `{"callbacks":[]}`)
+	client.receive()
+	assert.DeepEqual(t, getCallbacks(), []string{})
+}
+
+func TestServerResponseRequestIds(t *testing.T) {
+	t.Parallel()
+
+	client, _ := newTestServer(t, t.TempDir())
+	for i, expected := range []struct {
+		messageType api.MessageType
+		method      string
+		payload     string
+	}{
+		{api.MessageTypeResponse, "initialize", `{"responseRequestIds":true}`},
+		{api.MessageTypeResponse, "echo", `"hello"`},
+		{api.MessageTypeError, "unknownMethod", "null"},
+	} {
+		client.send(api.MessageTypeRequest, expected.method, expected.payload)
+		header := make([]byte, 3)
+		_, err := io.ReadFull(client.r, header)
+		assert.NilError(t, err)
+		assert.Equal(t, api.MessagePackType(header[0]), api.MessagePackTypeFixedArray4)
+		assert.Equal(t, api.MessageType(header[2]), expected.messageType)
+		assert.Equal(t, client.readBin(), expected.method)
+		client.readBin()
+		idType, err := client.r.ReadByte()
+		assert.NilError(t, err)
+		assert.Equal(t, api.MessagePackType(idType), api.MessagePackTypeU32)
+		var id uint32
+		assert.NilError(t, binary.Read(client.r, binary.BigEndian, &id))
+		assert.Equal(t, id, uint32(i+1))
+	}
+}
+
+func TestServerWithoutResponseRequestIds(t *testing.T) {
+	t.Parallel()
+
+	client, _ := newTestServer(t, t.TempDir())
+	client.send(api.MessageTypeRequest, "initialize", `{}`)
+	messageType, _, payload := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	client.send(api.MessageTypeRequest, "echo", `"hello"`)
+	messageType, _, payload = client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+}
+
+func TestServerCaseSensitivityDefault(t *testing.T) {
+	t.Parallel()
+
+	// File names are case sensitive unless the client says otherwise, even on a case-insensitive
+	// file system.
+	fs := vfstest.FromMap(map[string]string{"/a.ts": ""}, false /*useCaseSensitiveFileNames*/)
+	server := api.NewServer(&api.ServerOptions{Cwd: "/", FS: fs})
+	assert.Assert(t, server.UseCaseSensitiveFileNames())
+
+	useCaseSensitiveFileNames := false
+	server = api.NewServer(&api.ServerOptions{Cwd: "/", FS: fs, UseCaseSensitiveFileNames: &useCaseSensitiveFileNames})
+	assert.Assert(t, !server.UseCaseSensitiveFileNames())
+}
+
+func TestServerCaseSensitivityOverride(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	dir := tspath.NormalizeSlashes(t.TempDir())
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "tsconfig.json"), []byte("{}"), 0o644))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "a.ts"), []byte("export {};"), 0o644))
+
+	client, _ := newTestServer(t, dir)
+	client.send(api.MessageTypeRequest, "configure", `{"useCaseSensitiveFileNames":false}`)
+	messageType, _, payload := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+
+	client.send(api.MessageTypeRequest, "loadProject", `{"configFileName":"tsconfig.json"}`)
+	messageType, _, payload = client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var project api.ProjectResponse
+	assert.NilError(t, json.Unmarshal([]byte(payload), &project))
+
+	// The file is found through a path that differs only by case.
+	client.send(api.MessageTypeRequest, "getFileText", fmt.Sprintf(`{"project":%q,"fileName":%q}`, project.Id, dir+"/A.TS"))
+	messageType, _, payload = client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	assert.Assert(t, strings.Contains(payload, "export {};"))
+
+	client.send(api.MessageTypeRequest, "configure", `{"useCaseSensitiveFileNames":true}`)
+	messageType, _, payload = client.receive()
+	assert.Equal(t, messageType, api.MessageTypeError)
+	assert.Assert(t, strings.Contains(payload, "before loading projects"))
+}
+
+func TestServerConfigureOptions(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	dir := tspath.NormalizeSlashes(t.TempDir())
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "tsconfig.json"), []byte("{}"), 0o644))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "a.ts"), []byte("const x = { a: 1, b: 2, c: 3 };\nx."), 0o644))
+
+	client, _ := newTestServer(t, dir)
+	// Unknown fields are ignored.
+	client.send(api.MessageTypeRequest, "configure", `{"maxCompletionEntries":2,"futureOption":{"x":[1]}}`)
+	messageType, _, payload := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+
+	client.send(api.MessageTypeRequest, "loadProject", `{"configFileName":"tsconfig.json"}`)
+	messageType, _, payload = client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var project api.ProjectResponse
+	assert.NilError(t, json.Unmarshal([]byte(payload), &project))
+
+	client.send(api.MessageTypeRequest, "getCompletions", fmt.Sprintf(`{"project":%q,"fileName":%q,"position":%d}`, project.Id, dir+"/a.ts", len("const x = { a: 1, b: 2, c: 3 };\nx.")))
+	messageType, _, payload = client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var completions ls.CompletionInfo
+	assert.NilError(t, json.Unmarshal([]byte(payload), &completions))
+	assert.Equal(t, len(completions.Entries), 2)
+	assert.Assert(t, completions.IsIncomplete)
+
+	// A configure request that omits the limit keeps it.
+	client.send(api.MessageTypeRequest, "configure", `{"callbacks":[]}`)
+	messageType, _, payload = client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	client.send(api.MessageTypeRequest, "getCompletions", fmt.Sprintf(`{"project":%q,"fileName":%q,"position":%d}`, project.Id, dir+"/a.ts", len("const x = { a: 1, b: 2, c: 3 };\nx.")))
+	messageType, _, payload = client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	completions = ls.CompletionInfo{}
+	assert.NilError(t, json.Unmarshal([]byte(payload), &completions))
+	assert.Equal(t, len(completions.Entries), 2)
+}
+
+func TestServerInitialize(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	dir := tspath.NormalizeSlashes(t.TempDir())
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "tsconfig.json"), []byte("{}"), 0o644))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "a.ts"), []byte("export {};"), 0o644))
+
+	t.Run("picks the first supported position encoding", func(t *testing.T) {
+		t.Parallel()
+		client, _ := newTestServer(t, dir)
+		client.send(api.MessageTypeRequest, "initialize", `{"positionEncodings":["utf-32","utf-16","utf-8"]}`)
+		messageType, _, payload := client.receive()
+		assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+		var result api.InitializeResult
+		assert.NilError(t, json.Unmarshal([]byte(payload), &result))
+		assert.DeepEqual(t, result, api.InitializeResult{
+			PayloadFormat:     api.PayloadFormatJSON,
+			PositionEncoding:  lsproto.PositionEncodingKindUTF16,
+			SupportedFeatures: api.SupportedFeatures,
+		})
+	})
+
+	t.Run("enables the supported features asked for", func(t *testing.T) {
+		t.Parallel()
+		client, _ := newTestServer(t, dir)
+		client.send(api.MessageTypeRequest, "initialize", fmt.Sprintf(`{"features":%d}`, api.FeatureCompletionsForModuleExports|1<<31))
+		messageType, _, payload := client.receive()
+		assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+		var result api.InitializeResult
+		assert.NilError(t, json.Unmarshal([]byte(payload), &result))
+		assert.Equal(t, result.Features, api.FeatureCompletionsForModuleExports)
+		assert.Equal(t, result.SupportedFeatures, api.SupportedFeatures)
+	})
+
+	t.Run("keeps the position encoding of the server without a requested one", func(t *testing.T) {
+		t.Parallel()
+		client, _ := newTestServerWithOptions(t, &api.ServerOptions{Cwd: dir, PositionEncoding: lsproto.PositionEncodingKindUTF16})
+		client.send(api.MessageTypeRequest, "initialize", `{}`)
+		messageType, _, payload := client.receive()
+		assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+		var result api.InitializeResult
+		assert.NilError(t, json.Unmarshal([]byte(payload), &result))
+		assert.Equal(t, result.PositionEncoding, lsproto.PositionEncodingKindUTF16)
+	})
+
+	t.Run("rejects position encodings that are not supported", func(t *testing.T) {
+		t.Parallel()
+		client, _ := newTestServer(t, dir)
+		client.send(api.MessageTypeRequest, "initialize", `{"positionEncodings":["utf-32"]}`)
+		messageType, _, payload := client.receive()
+		assert.Equal(t, messageType, api.MessageTypeError)
+		assert.Assert(t, strings.Contains(payload, "utf-32"), payload)
+	})
+
+	t.Run("rejects a position encoding sent to configure", func(t *testing.T) {
+		t.Parallel()
+		client, _ := newTestServer(t, dir)
+		client.send(api.MessageTypeRequest, "configure", `{"positionEncoding":"utf-8"}`)
+		messageType, _, payload := client.receive()
+		assert.Equal(t, messageType, api.MessageTypeError)
+		assert.Assert(t, strings.Contains(payload, "initialize"), payload)
+	})
+
+	t.Run("rejects an unknown payload format", func(t *testing.T) {
+		t.Parallel()
+		client, _ := newTestServer(t, dir)
+		client.send(api.MessageTypeRequest, "initialize", `{"payloadFormat":"xml"}`)
+		messageType, _, payload := client.receive()
+		assert.Equal(t, messageType, api.MessageTypeError)
+		assert.Assert(t, strings.Contains(payload, "unknown payload format"), payload)
+	})
+
+	t.Run("must be the first request", func(t *testing.T) {
+		t.Parallel()
+		client, _ := newTestServer(t, dir)
+		client.send(api.MessageTypeRequest, "loadProject", `{"configFileName":"tsconfig.json"}`)
+		messageType, _, payload := client.receive()
+		assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+		client.send(api.MessageTypeRequest, "initialize", `{"positionEncodings":["utf-16"]}`)
+		messageType, _, payload = client.receive()
+		assert.Equal(t, messageType, api.MessageTypeError)
+		assert.Assert(t, strings.Contains(payload, "first request"), payload)
+	})
+}
+
+func TestServerWarmup(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	dir := tspath.NormalizeSlashes(t.TempDir())
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "tsconfig.json"), []byte("{}"), 0o644))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "a.ts"), []byte("export const a = [1].map(String);"), 0o644))
+
+	client, _ := newTestServer(t, dir)
+	client.send(api.MessageTypeRequest, "loadProject", `{"configFileName":"tsconfig.json"}`)
+	messageType, _, payload := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var project api.ProjectResponse
+	assert.NilError(t, json.Unmarshal([]byte(payload), &project))
+
+	// Warming up is idempotent, for a single project or for all of them.
+	for _, params := range []string{fmt.Sprintf(`{"project":%q}`, project.Id), `{}`} {
+		client.send(api.MessageTypeRequest, "warmup", params)
+		messageType, _, payload = client.receive()
+		assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	}
+
+	client.send(api.MessageTypeRequest, "warmup", `{"project":"p0000000000000000"}`)
+	messageType, _, _ = client.receive()
+	assert.Equal(t, messageType, api.MessageTypeError)
+}
+
+func TestServerReadFileRange(t *testing.T) {
+	t.Parallel()
+
+	dir := tspath.NormalizeSlashes(t.TempDir())
+	fileName := dir + "/big.ts"
+	assert.NilError(t, os.WriteFile(fileName, []byte("// @ts-nocheck\nexport {};"), 0o644))
+
+	// Without the callback, the range is sliced from the file contents.
+	server, client, _ := newTestServerPipes(t, &api.ServerOptions{Cwd: dir})
+	contents, ok := server.ReadFileRange(fileName, 3, 11)
+	assert.Assert(t, ok)
+	assert.Equal(t, contents, "@ts-nocheck")
+	contents, ok = server.ReadFileRange(fileName, 23, 100)
+	assert.Assert(t, ok)
+	assert.Equal(t, contents, "};")
+
+	assert.NilError(t, server.EnableCallback("readFileRange"))
+	type result struct {
+		contents string
+		ok       bool
+	}
+	read := make(chan result, 1)
+	go func() {
+		contents, ok := server.ReadFileRange("/virtual/huge.ts", 0, 4)
+		read <- result{contents, ok}
+	}()
+
+	messageType, method, payload := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeCall)
+	assert.Equal(t, method, "readFileRange")
+	var params api.ReadFileRangeParams
+	assert.NilError(t, json.Unmarshal([]byte(payload), &params))
+	assert.DeepEqual(t, params, api.ReadFileRangeParams{Path: "/virtual/huge.ts", Start: 0, Length: 4})
+	client.send(api.MessageTypeCallResponse, "readFileRange", `"\ufeff//"`)
+
+	r := <-read
+	assert.Assert(t, r.ok)
+	assert.Equal(t, r.contents, "\ufeff/")
+}
+
+func TestServerCallbackBypassPrefixes(t *testing.T) {
+	t.Parallel()
+
+	dir := tspath.NormalizeSlashes(t.TempDir())
+	fileName := dir + "/served/a.ts"
+	assert.NilError(t, os.MkdirAll(filepath.Dir(fileName), 0o755))
+	assert.NilError(t, os.WriteFile(fileName, []byte("export {};"), 0o644))
+
+	server, client, _ := newTestServerPipes(t, &api.ServerOptions{Cwd: dir})
+	for _, callback := range []string{"readFile", "fileExists", "directoryExists", "realpath"} {
+		assert.NilError(t, server.EnableCallback(callback))
+	}
+	assert.ErrorContains(t, server.SetCallbackBypassPrefixes([]string{"served/"}), "neither a scheme nor an absolute path")
+	assert.NilError(t, server.SetCallbackBypassPrefixes([]string{"node_modules://", dir + "/served/"}))
+
+	// Files under a bypassed prefix are read from the server's file system without calling the client.
+	contents, ok := server.ReadFile(fileName)
+	assert.Assert(t, ok)
+	assert.Equal(t, contents, "export {};")
+	assert.Assert(t, server.FileExists(fileName))
+	assert.Assert(t, server.DirectoryExists(dir+"/served/"))
+	assert.Assert(t, server.Realpath(fileName) != "")
+
+	// Other files still go through the callbacks.
+	exists := make(chan bool, 1)
+	go func() { exists <- server.FileExists(dir + "/other.ts") }()
+	messageType, method, _ := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeCall)
+	assert.Equal(t, method, "fileExists")
+	client.send(api.MessageTypeCallResponse, "fileExists", "true")
+	assert.Assert(t, <-exists)
+}
+
+func TestServerGetDiagnosticsForContent(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	dir := tspath.NormalizeSlashes(t.TempDir())
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "tsconfig.json"), []byte("{}"), 0o644))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "a.ts"), []byte("export const a: number = 1;"), 0o644))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "b.ts"), []byte(`import { a } from "./a"; export const b: number = a;`), 0o644))
+
+	client, _ := newTestServer(t, dir)
+	client.send(api.MessageTypeRequest, "loadProject", `{"configFileName":"tsconfig.json"}`)
+	messageType, _, payload := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var project api.ProjectResponse
+	assert.NilError(t, json.Unmarshal([]byte(payload), &project))
+
+	// The content is checked with the rest of the program, so the error comes from the import.
+	client.send(api.MessageTypeRequest, "getDiagnosticsForContent", fmt.Sprintf(`{"project":%q,"fileName":%q,"content":%q}`, project.Id, dir+"/b.ts", `import { a } from "./a"; export const b: string = a;`))
+	messageType, _, payload = client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var diagnostics []ls.Diagnostic
+	assert.NilError(t, json.Unmarshal([]byte(payload), &diagnostics))
+	assert.Equal(t, len(diagnostics), 1, payload)
+	assert.Equal(t, diagnostics[0].Code, int32(2322))
+	assert.Equal(t, diagnostics[0].FileName, dir+"/b.ts")
+
+	// The project still sees the file on disk.
+	client.send(api.MessageTypeRequest, "getFileText", fmt.Sprintf(`{"project":%q,"fileName":%q}`, project.Id, dir+"/b.ts"))
+	messageType, _, payload = client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var text api.FileTextResponse
+	assert.NilError(t, json.Unmarshal([]byte(payload), &text))
+	assert.Equal(t, text.Text, `import { a } from "./a"; export const b: number = a;`)
+
+	client.send(api.MessageTypeRequest, "getDiagnostics", fmt.Sprintf(`{"project":%q}`, project.Id))
+	messageType, _, payload = client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	assert.NilError(t, json.Unmarshal([]byte(payload), &diagnostics))
+	assert.Equal(t, len(diagnostics), 0, payload)
+}
+
+func TestServerNewLine(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	dir := tspath.NormalizeSlashes(t.TempDir())
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "tsconfig.json"), []byte(`{"compilerOptions": {"declaration": true}}`), 0o644))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "a.ts"), []byte("export function f(a: number) {\n    return a;\n}\n"), 0o644))
+
+	client, _ := newTestServerWithOptions(t, &api.ServerOptions{Cwd: dir, NewLine: "\r\n"})
+	client.send(api.MessageTypeRequest, "loadProject", `{"configFileName":"tsconfig.json"}`)
+	messageType, _, payload := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var project api.ProjectResponse
+	assert.NilError(t, json.Unmarshal([]byte(payload), &project))
+
+	client.send(api.MessageTypeRequest, "getEmitOutput", fmt.Sprintf(`{"project":%q,"fileName":%q}`, project.Id, dir+"/a.ts"))
+	messageType, _, payload = client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var output ls.EmitOutput
+	assert.NilError(t, json.Unmarshal([]byte(payload), &output))
+	assert.Equal(t, len(output.OutputFiles), 2, payload)
+	for _, file := range output.OutputFiles {
+		assert.Assert(t, strings.Contains(file.Text, "\r\n"), file.Name)
+		assert.Equal(t, strings.Count(file.Text, "\n"), strings.Count(file.Text, "\r\n"), file.Name)
+	}
+}
+
+func BenchmarkServerSmallRequests(b *testing.B) {
+	const requestCount = 10_000
+	var input []byte
+	for range requestCount {
+		input = appendMessage(input, api.MessageTypeRequest, "echo", `{"id":"p1","position":42}`)
+	}
+	dir := b.TempDir()
+	b.ReportAllocs()
+	for b.Loop() {
+		b.StopTimer()
+		server := api.NewServer(&api.ServerOptions{
+			In:  bytes.NewReader(input),
+			Out: io.Discard,
+			Err: io.Discard,
+			Cwd: dir,
+		})
+		b.StartTimer()
+		if err := server.Run(); err != io.EOF {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestServerCloseProject(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	dir := tspath.NormalizeSlashes(t.TempDir())
+	files := map[string]string{
+		"tsconfig.json": `{"compilerOptions": {"noLib": true}}`,
+		"a.ts":          "export const a = 1;",
+	}
+	for name, content := range files {
+		assert.NilError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	client, _ := newTestServer(t, dir)
+	request := func(method string, params string) (api.MessageType, string) {
+		client.send(api.MessageTypeRequest, method, params)
+		messageType, _, payload := client.receive()
+		return messageType, payload
+	}
+	getMemoryStats := func() api.MemoryStats {
+		messageType, payload := request("getMemoryStats", `{"forceGC":true}`)
+		assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+		var stats api.MemoryStats
+		assert.NilError(t, json.Unmarshal([]byte(payload), &stats))
+		assert.Assert(t, stats.HeapAlloc > 0)
+		return stats
+	}
+
+	messageType, payload := request("loadProject", `{"configFileName":"tsconfig.json"}`)
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var project api.ProjectResponse
+	assert.NilError(t, json.Unmarshal([]byte(payload), &project))
+	messageType, payload = request("getSymbolAtPosition", fmt.Sprintf(`{"project":%q,"fileName":"a.ts","position":13}`, project.Id))
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var symbol api.SymbolResponse
+	assert.NilError(t, json.Unmarshal([]byte(payload), &symbol))
+
+	stats := getMemoryStats()
+	assert.Equal(t, len(stats.Projects), 1)
+	assert.Equal(t, stats.Projects[0].Id, project.Id)
+	assert.Equal(t, stats.Projects[0].FileCount, 1)
+	assert.Assert(t, stats.Projects[0].ApproximateSize > uint64(len(files["a.ts"])))
+
+	messageType, payload = request("closeProject", fmt.Sprintf(`{"project":%q}`, project.Id))
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	assert.Equal(t, len(getMemoryStats().Projects), 0)
+
+	// The next request on the project loads it again, with the current content of its files, and
+	// the handles of the symbols of the closed project are released.
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "a.ts"), []byte("export const b = 2;"), 0o644))
+	messageType, payload = request("getTypeOfSymbol", fmt.Sprintf(`{"project":%q,"symbol":%q}`, project.Id, symbol.Id))
+	assert.Equal(t, messageType, api.MessageTypeError)
+	assert.Assert(t, strings.Contains(payload, "not found"), payload)
+	messageType, payload = request("getFileText", fmt.Sprintf(`{"project":%q,"fileName":"a.ts"}`, project.Id))
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var fileText api.FileTextResponse
+	assert.NilError(t, json.Unmarshal([]byte(payload), &fileText))
+	assert.Equal(t, fileText.Text, "export const b = 2;")
+	assert.Equal(t, len(getMemoryStats().Projects), 1)
+}
+
+func TestServerMemoryLimit(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	dir := tspath.NormalizeSlashes(t.TempDir())
+	files := map[string]string{
+		"p1/tsconfig.json": `{"compilerOptions": {"noLib": true}}`,
+		"p1/a.ts":          "export const a = 1;",
+		"p2/tsconfig.json": `{"compilerOptions": {"noLib": true}}`,
+		"p2/b.ts":          "export const b = 2;",
+	}
+	for name, content := range files {
+		assert.NilError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0o755))
+		assert.NilError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	// The heap always exceeds the limit, so projects are closed as soon as requests leave them be.
+	client, _ := newTestServerWithOptions(t, &api.ServerOptions{Cwd: dir, MemoryLimit: 1, MemoryCheckInterval: time.Millisecond})
+	request := func(method string, params string) (api.MessageType, string) {
+		client.send(api.MessageTypeRequest, method, params)
+		messageType, _, payload := client.receive()
+		return messageType, payload
+	}
+	loadProject := func(configFileName string) api.ProjectResponse {
+		messageType, payload := request("loadProject", fmt.Sprintf(`{"configFileName":%q}`, configFileName))
+		assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+		var project api.ProjectResponse
+		assert.NilError(t, json.Unmarshal([]byte(payload), &project))
+		return project
+	}
+	getSymbolName := func(project api.ProjectResponse, fileName string) string {
+		messageType, payload := request("getSymbolAtPosition", fmt.Sprintf(`{"project":%q,"fileName":%q,"position":13}`, project.Id, fileName))
+		assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+		var symbol api.SymbolResponse
+		assert.NilError(t, json.Unmarshal([]byte(payload), &symbol))
+		return symbol.Name
+	}
+
+	p1 := loadProject("p1/tsconfig.json")
+	p2 := loadProject("p2/tsconfig.json")
+	// Projects closed between requests are loaded again by the next request on them.
+	for range 20 {
+		assert.Equal(t, getSymbolName(p1, dir+"/p1/a.ts"), "a")
+		assert.Equal(t, getSymbolName(p2, dir+"/p2/b.ts"), "b")
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		messageType, payload := request("getMemoryStats", `{}`)
+		assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+		var stats api.MemoryStats
+		assert.NilError(t, json.Unmarshal([]byte(payload), &stats))
+		if len(stats.Projects) == 0 {
+			break
+		}
+		assert.Assert(t, time.Now().Before(deadline), "projects were not closed under memory pressure")
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, getSymbolName(p2, dir+"/p2/b.ts"), "b")
+}
+
+func TestServerRestart(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	dir := tspath.NormalizeSlashes(t.TempDir())
+	files := map[string]string{
+		"tsconfig.json": `{"compilerOptions": {"noLib": true}}`,
+		"a.ts":          "export const a = 1;",
+	}
+	for name, content := range files {
+		assert.NilError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	client, _ := newTestServer(t, dir)
+	request := func(method string, params string) (api.MessageType, string) {
+		client.send(api.MessageTypeRequest, method, params)
+		messageType, _, payload := client.receive()
+		return messageType, payload
+	}
+
+	messageType, payload := request("configure", `{"requestTiming":true}`)
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	messageType, payload = request("loadProject", `{"configFileName":"tsconfig.json"}`)
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var project api.ProjectResponse
+	assert.NilError(t, json.Unmarshal([]byte(payload), &project))
+	messageType, payload = request("getSymbolAtPosition", fmt.Sprintf(`{"project":%q,"fileName":"a.ts","position":13}`, project.Id))
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var symbol api.SymbolResponse
+	assert.NilError(t, json.Unmarshal([]byte(payload), &symbol))
+
+	// Without a restart, the server keeps the file it has read.
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "a.ts"), []byte("export const b = 2;"), 0o644))
+	getFileText := func() string {
+		messageType, payload := request("getFileText", fmt.Sprintf(`{"project":%q,"fileName":"a.ts"}`, project.Id))
+		assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+		var fileText api.FileTextResponse
+		assert.NilError(t, json.Unmarshal([]byte(payload), &fileText))
+		return fileText.Text
+	}
+	assert.Equal(t, getFileText(), "export const a = 1;")
+
+	messageType, payload = request("restartServer", "null")
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+
+	// The handle of the project stays valid, and the project is loaded again with the current
+	// content of its files, while the handles of its symbols are released.
+	assert.Equal(t, getFileText(), "export const b = 2;")
+	messageType, payload = request("getTypeOfSymbol", fmt.Sprintf(`{"project":%q,"symbol":%q}`, project.Id, symbol.Id))
+	assert.Equal(t, messageType, api.MessageTypeError)
+	assert.Assert(t, strings.Contains(payload, "not found"), payload)
+
+	// The configuration of the connection carries over.
+	messageType, payload = request("getStats", "null")
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	assert.Assert(t, strings.Contains(payload, "restartServer"), payload)
+}
+
+func TestServerSetCompilerOptionsOverride(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	dir := tspath.NormalizeSlashes(t.TempDir())
+	files := map[string]string{
+		"tsconfig.json": `{"compilerOptions": {"noLib": true}, "files": ["a.ts"]}`,
+		"a.ts":          `import { b } from "b";`,
+		"lib/b.ts":      "export const b = 1;",
+	}
+	for name, content := range files {
+		assert.NilError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		assert.NilError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	client, _ := newTestServer(t, dir)
+	request := func(method string, params string) (api.MessageType, string) {
+		client.send(api.MessageTypeRequest, method, params)
+		messageType, _, payload := client.receive()
+		return messageType, payload
+	}
+
+	messageType, payload := request("loadProject", `{"configFileName":"tsconfig.json"}`)
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var project api.ProjectResponse
+	assert.NilError(t, json.Unmarshal([]byte(payload), &project))
+	getProgramFiles := func() []string {
+		messageType, payload := request("getProgramFiles", fmt.Sprintf(`{"project":%q}`, project.Id))
+		assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+		var programFiles []ls.ProgramFileInfo
+		assert.NilError(t, json.Unmarshal([]byte(payload), &programFiles))
+		var fileNames []string
+		for _, file := range programFiles {
+			fileNames = append(fileNames, tspath.GetRelativePathFromDirectory(dir, file.FileName, tspath.ComparePathsOptions{}))
+		}
+		return fileNames
+	}
+	assert.DeepEqual(t, getProgramFiles(), []string{"a.ts"})
+
+	// An override of the module resolution options resolves the imports of the program again.
+	messageType, payload = request("setCompilerOptionsOverride", `{"options":{"paths":{"b":["./lib/b.ts"]}}}`)
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	assert.DeepEqual(t, getProgramFiles(), []string{"lib/b.ts", "a.ts"})
+
+	messageType, payload = request("setCompilerOptionsOverride", `{"options":{"notAnOption":true}}`)
+	assert.Equal(t, messageType, api.MessageTypeError)
+	assert.Assert(t, strings.Contains(payload, "notAnOption"), payload)
+	assert.DeepEqual(t, getProgramFiles(), []string{"lib/b.ts", "a.ts"})
+
+	messageType, payload = request("setCompilerOptionsOverride", `{"options":null}`)
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	assert.DeepEqual(t, getProgramFiles(), []string{"a.ts"})
+}
+
+func TestServerFS(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	fs := vfstest.FromMap(map[string]string{
+		"/home/src/project/tsconfig.json": `{"compilerOptions": {"lib": ["es5"]}}`,
+		"/home/src/project/a.ts":          "export const a: string = 1;",
+	}, true /*useCaseSensitiveFileNames*/)
+	client, _ := newTestServerWithOptions(t, &api.ServerOptions{Cwd: "/home/src/project", FS: fs})
+	request := func(method string, params string) (api.MessageType, string) {
+		client.send(api.MessageTypeRequest, method, params)
+		messageType, _, payload := client.receive()
+		return messageType, payload
+	}
+
+	messageType, payload := request("loadProject", `{"configFileName":"tsconfig.json"}`)
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var project api.ProjectResponse
+	assert.NilError(t, json.Unmarshal([]byte(payload), &project))
+
+	// The files of the project are read from the in-memory file system, and the library files
+	// from the bundled ones.
+	messageType, payload = request("getProgramFiles", fmt.Sprintf(`{"project":%q}`, project.Id))
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var programFiles []ls.ProgramFileInfo
+	assert.NilError(t, json.Unmarshal([]byte(payload), &programFiles))
+	assert.Assert(t, len(programFiles) > 1)
+	assert.Equal(t, programFiles[len(programFiles)-1].FileName, "/home/src/project/a.ts")
+	assert.Assert(t, programFiles[0].IsDefaultLibrary)
+
+	messageType, payload = request("getDiagnostics", fmt.Sprintf(`{"project":%q}`, project.Id))
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var diagnostics []ls.Diagnostic
+	assert.NilError(t, json.Unmarshal([]byte(payload), &diagnostics))
+	assert.Equal(t, len(diagnostics), 1)
+	assert.Equal(t, diagnostics[0].Code, int32(2322))
+}
+
+func TestServerRealpathSymlinkCycle(t *testing.T) {
+	t.Parallel()
+
+	fs := vfstest.FromMap(map[string]any{
+		"/project/node_modules/a":   vfstest.Symlink("/project/node_modules/b"),
+		"/project/node_modules/b":   vfstest.Symlink("/project/node_modules/a"),
+		"/project/node_modules/pkg": vfstest.Symlink("/project/node_modules/a"),
+	}, true /*useCaseSensitiveFileNames*/)
+	server, _, _ := newTestServerPipes(t, &api.ServerOptions{Cwd: "/project", FS: fs})
+
+	// Without a realpath callback, the server resolves the cycle to where it begins.
+	assert.Equal(t, server.Realpath("/project/node_modules/pkg/index.d.ts"), "/project/node_modules/a/index.d.ts")
+}
+
+func TestServerResolveModule(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	fs := vfstest.FromMap(map[string]string{
+		"/home/src/project/tsconfig.json":                 `{"compilerOptions": {"module": "nodenext", "noLib": true}}`,
+		"/home/src/project/package.json":                  `{"type": "module"}`,
+		"/home/src/project/a.ts":                          `import { p } from "pkg";`,
+		"/home/src/project/node_modules/pkg/package.json": `{"name": "pkg", "types": "index.d.ts"}`,
+		"/home/src/project/node_modules/pkg/index.d.ts":   "export const p: number;",
+	}, true /*useCaseSensitiveFileNames*/)
+	client, _ := newTestServerWithOptions(t, &api.ServerOptions{Cwd: "/home/src/project", FS: fs})
+	request := func(method string, params string) (api.MessageType, string) {
+		client.send(api.MessageTypeRequest, method, params)
+		messageType, _, payload := client.receive()
+		return messageType, payload
+	}
+
+	messageType, payload := request("loadProject", `{"configFileName":"tsconfig.json"}`)
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var project api.ProjectResponse
+	assert.NilError(t, json.Unmarshal([]byte(payload), &project))
+	resolveModule := func(specifier string) *ls.ModuleResolutionResult {
+		messageType, payload := request("resolveModule", fmt.Sprintf(`{"project":%q,"fromFile":"/home/src/project/a.ts","specifier":%q}`, project.Id, specifier))
+		assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+		var result *ls.ModuleResolutionResult
+		assert.NilError(t, json.Unmarshal([]byte(payload), &result))
+		return result
+	}
+
+	result := resolveModule("pkg")
+	assert.Equal(t, result.ResolvedFileName, "/home/src/project/node_modules/pkg/index.d.ts")
+	assert.Equal(t, result.ResolutionMode, "import")
+	assert.Assert(t, result.IsExternalLibraryImport)
+	assert.Equal(t, result.PackageJsonScope, "/home/src/project")
+	assert.Equal(t, result.Candidates[len(result.Candidates)-1], result.ResolvedFileName)
+	assert.Assert(t, len(result.Trace) > 0)
+
+	// The lookups of an unresolved module are still reported.
+	result = resolveModule("./missing.js")
+	assert.Equal(t, result.ResolvedFileName, "")
+	assert.Assert(t, slices.Contains(result.Candidates, "/home/src/project/missing.ts"), "%v", result.Candidates)
+	assert.Assert(t, strings.Contains(result.Trace[len(result.Trace)-1], "was not resolved"), "%v", result.Trace)
+}
+
+func TestServerGetWatchGlobs(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	fs := vfstest.FromMap(map[string]string{
+		"/home/src/project/tsconfig.base.json":            `{"compilerOptions": {"module": "nodenext", "noLib": true}}`,
+		"/home/src/project/tsconfig.json":                 `{"extends": "./tsconfig.base.json", "include": ["src"]}`,
+		"/home/src/project/src/a.ts":                      `import { p } from "pkg"; import { m } from "./missing.js";`,
+		"/home/src/project/node_modules/pkg/package.json": `{"name": "pkg", "types": "index.d.ts"}`,
+		"/home/src/project/node_modules/pkg/index.d.ts":   "export const p: number;",
+	}, true /*useCaseSensitiveFileNames*/)
+	client, _ := newTestServerWithOptions(t, &api.ServerOptions{Cwd: "/home/src/project", FS: fs})
+	client.send(api.MessageTypeRequest, "loadProject", `{"configFileName":"tsconfig.json"}`)
+	messageType, _, payload := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var project api.ProjectResponse
+	assert.NilError(t, json.Unmarshal([]byte(payload), &project))
+
+	client.send(api.MessageTypeRequest, "getWatchGlobs", fmt.Sprintf(`{"project":%q}`, project.Id))
+	messageType, _, payload = client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var result api.WatchGlobsResponse
+	assert.NilError(t, json.Unmarshal([]byte(payload), &result))
+	assert.DeepEqual(t, result.Files, []string{
+		"/home/src/project/node_modules/pkg/index.d.ts",
+		"/home/src/project/node_modules/pkg/package.json",
+		"/home/src/project/src/a.ts",
+		"/home/src/project/tsconfig.base.json",
+		"/home/src/project/tsconfig.json",
+	})
+	// The include pattern and the failed lookups of ./missing.js are in the same directory.
+	assert.Assert(t, slices.Contains(result.Globs, "/home/src/project/src/**/*.{js,jsx,mjs,cjs,ts,tsx,mts,cts,json}"), "%v", result.Globs)
+}
+
+func TestServerGetEffectiveCompilerOptions(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	fs := vfstest.FromMap(map[string]string{
+		"/home/src/project/tsconfig.base.json":  `{"compilerOptions": {"noLib": true, "composite": true}}`,
+		"/home/src/project/tsconfig.json":       `{"files": [], "references": [{"path": "./lib"}, {"path": "./app"}]}`,
+		"/home/src/project/lib/tsconfig.json":   `{"extends": "../tsconfig.base.json", "compilerOptions": {"strict": true}}`,
+		"/home/src/project/lib/a.ts":            "export const a = 1;",
+		"/home/src/project/app/tsconfig.json":   `{"extends": "../tsconfig.base.json", "references": [{"path": "../lib"}]}`,
+		"/home/src/project/app/b.ts":            `import { a } from "../lib/a";`,
+		"/home/src/project/other/tsconfig.json": `{}`,
+		"/home/src/project/other/c.ts":          "export {};",
+	}, true /*useCaseSensitiveFileNames*/)
+	client, _ := newTestServerWithOptions(t, &api.ServerOptions{Cwd: "/home/src/project", FS: fs})
+	request := func(method string, params string) (api.MessageType, string) {
+		client.send(api.MessageTypeRequest, method, params)
+		messageType, _, payload := client.receive()
+		return messageType, payload
+	}
+	getEffectiveCompilerOptions := func(fileName string) api.EffectiveOptions {
+		t.Helper()
+		messageType, payload := request("getEffectiveCompilerOptions", fmt.Sprintf(`{"fileName":%q}`, fileName))
+		assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+		var result api.EffectiveOptions
+		assert.NilError(t, json.Unmarshal([]byte(payload), &result))
+		return result
+	}
+
+	messageType, payload := request("loadProject", `{"configFileName":"tsconfig.json"}`)
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+
+	// The sources of referenced projects are governed by their config files, with the options they
+	// extend.
+	result := getEffectiveCompilerOptions("lib/a.ts")
+	assert.Equal(t, result.ConfigFileName, "/home/src/project/lib/tsconfig.json")
+	assert.Equal(t, result.Options.Strict, core.TSTrue)
+	assert.Equal(t, result.Options.NoLib, core.TSTrue)
+	result = getEffectiveCompilerOptions("app/b.ts")
+	assert.Equal(t, result.ConfigFileName, "/home/src/project/app/tsconfig.json")
+	assert.Equal(t, result.Options.Strict, core.TSUnknown)
+
+	// Files of a loaded project's program are governed by its config file.
+	messageType, payload = request("loadProject", `{"configFileName":"other/tsconfig.json"}`)
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	assert.Equal(t, getEffectiveCompilerOptions("other/c.ts").ConfigFileName, "/home/src/project/other/tsconfig.json")
+
+	messageType, _ = request("getEffectiveCompilerOptions", `{"fileName":"missing.ts"}`)
+	assert.Equal(t, messageType, api.MessageTypeError)
+}
+
+func TestServerGetSymbolAtPositionDetails(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	a := "/** The answer. */\nexport const answer = 42;\nexport interface I {}\n"
+	b := "import { answer, I } from \"./a\";\nanswer;\nlet i: I;\n"
+	fs := vfstest.FromMap(map[string]string{
+		"/home/src/project/tsconfig.json": `{"compilerOptions": {"noLib": true}}`,
+		"/home/src/project/a.ts":          a,
+		"/home/src/project/b.ts":          b,
+	}, true /*useCaseSensitiveFileNames*/)
+	client, _ := newTestServerWithOptions(t, &api.ServerOptions{Cwd: "/home/src/project", FS: fs})
+	request := func(method string, params string) (api.MessageType, string) {
+		client.send(api.MessageTypeRequest, method, params)
+		messageType, _, payload := client.receive()
+		return messageType, payload
+	}
+	messageType, payload := request("loadProject", `{"configFileName":"tsconfig.json"}`)
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	var project api.ProjectResponse
+	assert.NilError(t, json.Unmarshal([]byte(payload), &project))
+	getSymbolAtPosition := func(fileName string, position int) api.SymbolResponse {
+		t.Helper()
+		messageType, payload := request("getSymbolAtPosition", fmt.Sprintf(`{"project":%q,"fileName":%q,"position":%d}`, project.Id, fileName, position))
+		assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+		var symbol api.SymbolResponse
+		assert.NilError(t, json.Unmarshal([]byte(payload), &symbol))
+		return symbol
+	}
+
+	symbol := getSymbolAtPosition("a.ts", strings.Index(a, "answer ="))
+	assert.Equal(t, symbol.Name, "answer")
+	assert.Equal(t, symbol.Documentation, "The answer.")
+	assert.Equal(t, symbol.Type, "42")
+	assert.Equal(t, len(symbol.Declarations), 1)
+	assert.Equal(t, symbol.Declarations[0].FileName, "/home/src/project/a.ts")
+	assert.Equal(t, symbol.Declarations[0].Start, ls.Position{Line: 1, Character: 13})
+
+	// An import is described by its own declaration, and by the type and documentation of the
+	// symbol it refers to.
+	symbol = getSymbolAtPosition("b.ts", strings.Index(b, "answer;"))
+	assert.Equal(t, symbol.Declarations[0].FileName, "/home/src/project/b.ts")
+	assert.Equal(t, symbol.Documentation, "The answer.")
+	assert.Equal(t, symbol.Type, "42")
+
+	// Symbols without a value have no type.
+	symbol = getSymbolAtPosition("a.ts", strings.Index(a, "I {"))
+	assert.Equal(t, symbol.Name, "I")
+	assert.Equal(t, symbol.Type, "")
+}
+
+func TestServerGetConfigFileDiagnostics(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	fs := vfstest.FromMap(map[string]string{
+		"/home/src/project/tsconfig.json": "{\n  // Comments are allowed.\n  \"compilerOptions\": {\n    \"strictt\": true,\n    \"target\": \"es1999\",\n  }\n  \"files\": [\"a.ts\"]\n}\n",
+		"/home/src/project/a.ts":          "export {};",
+		"/home/src/empty/tsconfig.json":   `{}`,
+	}, true /*useCaseSensitiveFileNames*/)
+	client, _ := newTestServerWithOptions(t, &api.ServerOptions{Cwd: "/home/src/project", FS: fs})
+	getConfigFileDiagnostics := func(fileName string) []*ls.Diagnostic {
+		t.Helper()
+		client.send(api.MessageTypeRequest, "getConfigFileDiagnostics", fmt.Sprintf(`{"fileName":%q}`, fileName))
+		messageType, _, payload := client.receive()
+		assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+		var result []*ls.Diagnostic
+		assert.NilError(t, json.Unmarshal([]byte(payload), &result))
+		return result
+	}
+
+	var actual []string
+	for _, diagnostic := range getConfigFileDiagnostics("tsconfig.json") {
+		actual = append(actual, fmt.Sprintf("%d:%d %s", diagnostic.Start.Line, diagnostic.Start.Character, diagnostic.CodeString))
+	}
+	assert.DeepEqual(t, actual, []string{
+		"3:4 TS5023",  // Unknown compiler option 'strictt'.
+		"4:14 TS6046", // Argument for '--target' option must be one of ...
+		"6:2 TS1005",  // ',' expected.
+	})
+
+	// Diagnostics without a location are reported at the start of the config file.
+	diagnostics := getConfigFileDiagnostics("/home/src/empty/tsconfig.json")
+	assert.Equal(t, len(diagnostics), 1)
+	assert.Equal(t, diagnostics[0].FileName, "/home/src/empty/tsconfig.json")
+	assert.Equal(t, diagnostics[0].CodeString, "TS18003") // No inputs were found in config file ...
+
+	client.send(api.MessageTypeRequest, "getConfigFileDiagnostics", `{"fileName":"missing.json"}`)
+	messageType, _, _ := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeError)
+}
+
+func TestServerCancelledModuleResolution(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	dir := tspath.NormalizeSlashes(t.TempDir())
+	files := map[string]string{
+		"tsconfig.json": `{"compilerOptions": {"noLib": true}}`,
+		"a.ts":          `import { b } from "./b"; export const a = b;`,
+		"b.ts":          "export const b = 1;",
+	}
+	for name, content := range files {
+		assert.NilError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	client, _ := newTestServer(t, dir)
+	client.send(api.MessageTypeRequest, "configure", `{"callbacks":["resolveModuleName"]}`)
+	messageType, _, payload := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+
+	// Once the request is cancelled, the program is built without calling the client to resolve modules.
+	client.send(api.MessageTypeRequest, "loadProject", `{"configFileName":"tsconfig.json","progressToken":"load"}`)
+	messageType, method, payload := client.receive()
+	assert.Equal(t, messageType, api.MessageTypeCall)
+	assert.Equal(t, method, "progress")
+	client.send(api.MessageTypeCallError, "progress", "cancelled by user")
+	messageType, method, payload = client.receive()
+	assert.Equal(t, messageType, api.MessageTypeError, method)
+	assert.Assert(t, strings.Contains(payload, `progress "load" cancelled`), payload)
+
+	// The incomplete program is not kept: loading the project again resolves its modules.
+	client.send(api.MessageTypeRequest, "loadProject", `{"configFileName":"tsconfig.json"}`)
+	resolved := 0
+	for {
+		messageType, method, payload = client.receive()
+		if messageType != api.MessageTypeCall {
+			break
+		}
+		assert.Equal(t, method, "resolveModuleName")
+		resolved++
+		client.send(api.MessageTypeCallResponse, method, "null")
+	}
+	assert.Equal(t, messageType, api.MessageTypeResponse, payload)
+	assert.Equal(t, resolved, 1)
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
+// statsSampleCount is the number of recent durations kept per method for computing percentiles.
+const statsSampleCount = 256
+
+// requestStats aggregates the duration of requests by method. Recording a request
+// allocates only the first time a method is seen.
+type requestStats struct {
+	methods map[string]*methodStats
+}
+
+type methodStats struct {
+	count   int
+	errors  int
+	samples [statsSampleCount]time.Duration
+}
+
+func newRequestStats() *requestStats {
+	return &requestStats{methods: make(map[string]*methodStats)}
+}
+
+func (s *requestStats) record(method string, duration time.Duration, failed bool) {
+	stats, ok := s.methods[method]
+	if !ok {
+		stats = &methodStats{}
+		s.methods[method] = stats
+	}
+	stats.samples[stats.count%statsSampleCount] = duration
+	stats.count++
+	if failed {
+		stats.errors++
+	}
+}
+
+// snapshot returns the stats of each method sorted by method name. Percentiles
+// are computed over the most recent requests of each method.
+func (s *requestStats) snapshot() []*RequestStats {
+	methods := slices.Sorted(maps.Keys(s.methods))
+	result := make([]*RequestStats, 0, len(methods))
+	for _, method := range methods {
+		stats := s.methods[method]
+		samples := slices.Clone(stats.samples[:min(stats.count, statsSampleCount)])
+		slices.Sort(samples)
+		result = append(result, &RequestStats{
+			Method: method,
+			Count:  stats.count,
+			Errors: stats.errors,
+			P50:    percentile(samples, 50),
+			P95:    percentile(samples, 95),
+		})
+	}
+	return result
+}
+
+// percentile returns the nearest-rank percentile of sorted durations in milliseconds.
+func percentile(sorted []time.Duration, p int) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	return float64(sorted[max(rank, 1)-1]) / float64(time.Millisecond)
+}
@@ -22,6 +22,8 @@ type Diagnostic struct {
 	reportsUnnecessary bool
 	reportsDeprecated  bool
 	skippedOnNoEmit    bool
+	// The message the diagnostic was created from, if known.
+	template *diagnostics.Message
 }
 
 func (d *Diagnostic) File() *SourceFile                 { return d.file }
@@ -38,6 +40,10 @@ func (d *Diagnostic) ReportsUnnecessary() bool          { return d.reportsUnnece
 func (d *Diagnostic) ReportsDeprecated() bool           { return d.reportsDeprecated }
 func (d *Diagnostic) SkippedOnNoEmit() bool             { return d.skippedOnNoEmit }
 
+// MessageTemplate returns the message the diagnostic was created from, before its arguments were
+// substituted, or nil for diagnostics created from message text, such as those read from build info.
+func (d *Diagnostic) MessageTemplate() *diagnostics.Message { return d.template }
+
 func (d *Diagnostic) SetFile(file *SourceFile)                  { d.file = file }
 func (d *Diagnostic) SetLocation(loc core.TextRange)            { d.loc = loc }
 func (d *Diagnostic) SetCategory(category diagnostics.Category) { d.category = category }
@@ -107,6 +113,7 @@ func NewDiagnostic(file *SourceFile, loc core.TextRange, message *diagnostics.Me
 		message:            message.Format(args...),
 		reportsUnnecessary: message.ReportsUnnecessary(),
 		reportsDeprecated:  message.ReportsDeprecated(),
+		template:           message,
 	}
 }
 
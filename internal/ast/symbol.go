@@ -27,6 +27,13 @@ type Symbol struct {
 	GlobalExports                SymbolTable            // Conditional global UMD exports
 }
 
+// Id returns the identity of the symbol, assigning it on first use. The id is the same for the
+// lifetime of the symbol and unique among the symbols of the process, so it can key data cached per
+// symbol. Ids are not stable across processes or program rebuilds, which create new symbols.
+func (s *Symbol) Id() uint64 {
+	return uint64(GetSymbolId(s))
+}
+
 // SymbolTable
 
 // type SymbolTable map[string]*Symbol
@@ -42,6 +49,9 @@ type SymbolTable interface {
 	Iter() iter.Seq2[string, *Symbol]
 	Len() int
 	Clone() SymbolTable
+	// CloneWithExtraCapacity is Clone for callers that add entries to the clone, with room for
+	// extra more entries before it grows.
+	CloneWithExtraCapacity(extra int) SymbolTable
 	Find(predicate func(*Symbol) bool) *Symbol
 }
 
@@ -62,6 +72,12 @@ func (m *SymbolMap) Clone() SymbolTable {
 	return &SymbolMap{m: maps.Clone(m.m)}
 }
 
+func (m *SymbolMap) CloneWithExtraCapacity(extra int) SymbolTable {
+	clone := make(map[string]*Symbol, len(m.m)+max(extra, 0))
+	maps.Copy(clone, m.m)
+	return &SymbolMap{m: clone}
+}
+
 func (m *SymbolMap) Len() int {
 	return len(m.m)
 }
@@ -131,6 +147,179 @@ func NewSymbolTableFromMap(m map[string]*Symbol) SymbolTable {
 	return &SymbolMap{m: m}
 }
 
+// OverlaySymbolTable layers additions and deletions over a base symbol table without modifying it,
+// so that speculative changes can be discarded by dropping the overlay instead of cloning the base.
+// The base must not be modified while the overlay is in use.
+type OverlaySymbolTable struct {
+	base    SymbolTable
+	added   map[string]*Symbol
+	deleted collections.Set[string] // Tombstones for names deleted from the base
+}
+
+var _ SymbolTable = (*OverlaySymbolTable)(nil)
+
+func NewOverlaySymbolTable(base SymbolTable) *OverlaySymbolTable {
+	if base == nil {
+		base = NewSymbolTable()
+	}
+	return &OverlaySymbolTable{base: base, added: make(map[string]*Symbol)}
+}
+
+func (o *OverlaySymbolTable) Get(name string) *Symbol {
+	symbol, _ := o.Get2(name)
+	return symbol
+}
+
+func (o *OverlaySymbolTable) Get2(name string) (*Symbol, bool) {
+	if symbol, ok := o.added[name]; ok {
+		return symbol, true
+	}
+	if o.deleted.Has(name) {
+		return nil, false
+	}
+	return o.base.Get2(name)
+}
+
+func (o *OverlaySymbolTable) Set(name string, symbol *Symbol) {
+	o.added[name] = symbol
+	o.deleted.Delete(name)
+}
+
+func (o *OverlaySymbolTable) Delete(name string) {
+	delete(o.added, name)
+	if _, ok := o.base.Get2(name); ok {
+		o.deleted.Add(name)
+	}
+}
+
+func (o *OverlaySymbolTable) Keys() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for name := range o.Iter() {
+			if !yield(name) {
+				return
+			}
+		}
+	}
+}
+
+func (o *OverlaySymbolTable) Values() iter.Seq[*Symbol] {
+	return func(yield func(*Symbol) bool) {
+		for _, symbol := range o.Iter() {
+			if !yield(symbol) {
+				return
+			}
+		}
+	}
+}
+
+func (o *OverlaySymbolTable) Each(fn func(name string, symbol *Symbol)) {
+	for name, symbol := range o.Iter() {
+		fn(name, symbol)
+	}
+}
+
+// Iter yields the overlay's additions, then the entries of the base that are neither
+// replaced nor deleted by the overlay.
+func (o *OverlaySymbolTable) Iter() iter.Seq2[string, *Symbol] {
+	return func(yield func(string, *Symbol) bool) {
+		for name, symbol := range o.added {
+			if !yield(name, symbol) {
+				return
+			}
+		}
+		for name, symbol := range o.base.Iter() {
+			if _, ok := o.added[name]; ok || o.deleted.Has(name) {
+				continue
+			}
+			if !yield(name, symbol) {
+				return
+			}
+		}
+	}
+}
+
+func (o *OverlaySymbolTable) Len() int {
+	n := o.base.Len() - o.deleted.Len()
+	for name := range o.added {
+		if _, ok := o.base.Get2(name); !ok {
+			n++
+		}
+	}
+	return n
+}
+
+// Clone returns an overlay over the same base with a copy of this overlay's changes.
+func (o *OverlaySymbolTable) Clone() SymbolTable {
+	return &OverlaySymbolTable{base: o.base, added: maps.Clone(o.added), deleted: *o.deleted.Clone()}
+}
+
+// CloneWithExtraCapacity is Clone with room for extra more additions in the overlay.
+func (o *OverlaySymbolTable) CloneWithExtraCapacity(extra int) SymbolTable {
+	added := make(map[string]*Symbol, len(o.added)+max(extra, 0))
+	maps.Copy(added, o.added)
+	return &OverlaySymbolTable{base: o.base, added: added, deleted: *o.deleted.Clone()}
+}
+
+func (o *OverlaySymbolTable) Find(predicate func(*Symbol) bool) *Symbol {
+	for symbol := range o.Values() {
+		if predicate(symbol) {
+			return symbol
+		}
+	}
+	return nil
+}
+
+// SymbolTablesEqual reports whether a and b have the same set of names and symbolEqual holds for
+// the symbols stored under each name. A nil table is equal to an empty one. If symbolEqual is nil,
+// SymbolsEqual is used.
+func SymbolTablesEqual(a, b SymbolTable, symbolEqual func(x, y *Symbol) bool) bool {
+	if symbolEqual == nil {
+		symbolEqual = SymbolsEqual
+	}
+	aLen, bLen := 0, 0
+	if a != nil {
+		aLen = a.Len()
+	}
+	if b != nil {
+		bLen = b.Len()
+	}
+	if aLen != bLen {
+		return false
+	}
+	if aLen == 0 {
+		return true
+	}
+	for name, x := range a.Iter() {
+		y, ok := b.Get2(name)
+		if !ok || !symbolEqual(x, y) {
+			return false
+		}
+	}
+	return true
+}
+
+// SymbolsEqual reports whether x and y have the same name and flags, and are declared at the same
+// positions in the same files. It does not compare members or exports.
+func SymbolsEqual(x, y *Symbol) bool {
+	if x == y {
+		return true
+	}
+	if x == nil || y == nil || x.Name != y.Name || x.Flags != y.Flags || len(x.Declarations) != len(y.Declarations) {
+		return false
+	}
+	for i, xDeclaration := range x.Declarations {
+		yDeclaration := y.Declarations[i]
+		if xDeclaration.Pos() != yDeclaration.Pos() || xDeclaration.End() != yDeclaration.End() {
+			return false
+		}
+		xFile, yFile := GetSourceFileOfNode(xDeclaration), GetSourceFileOfNode(yDeclaration)
+		if (xFile == nil) != (yFile == nil) || xFile != nil && xFile.FileName() != yFile.FileName() {
+			return false
+		}
+	}
+	return true
+}
+
 const InternalSymbolNamePrefix = "\xFE" // Invalid UTF8 sequence, will never occur as IdentifierName
 
 const (
@@ -176,6 +365,15 @@ func (c *CombinedSymbolTable) Clone() SymbolTable {
 	}
 }
 
+// CloneWithExtraCapacity implements SymbolTable. The extra capacity goes to the first table,
+// which Set adds to.
+func (c *CombinedSymbolTable) CloneWithExtraCapacity(extra int) SymbolTable {
+	return &CombinedSymbolTable{
+		firstTable:  c.firstTable.CloneWithExtraCapacity(extra),
+		secondTable: c.secondTable.Clone(),
+	}
+}
+
 // Delete implements SymbolTable.
 func (c *CombinedSymbolTable) Delete(name string) {
 	if c.firstTable.Get(name) != nil {
@@ -0,0 +1,154 @@
+package ast_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/binder"
+	"github.com/microsoft/typescript-go/internal/testutil/parsetestutil"
+	"gotest.tools/v3/assert"
+)
+
+func TestSymbolTablesEqual(t *testing.T) {
+	t.Parallel()
+
+	exportsOf := func(text string) ast.SymbolTable {
+		file := parsetestutil.ParseTypeScript(text, false /*jsx*/)
+		binder.BindSourceFile(file)
+		return file.Symbol.Exports
+	}
+
+	base := exportsOf("export const a = 1;\nexport function f() {}\n")
+	data := []struct {
+		title string
+		input string
+		equal bool
+	}{
+		{title: "Identical", input: "export const a = 1;\nexport function f() {}\n", equal: true},
+		{title: "BodyChangeOnly", input: "export const a = 2;\nexport function f() {}\n", equal: true},
+		{title: "AddedExport", input: "export const a = 1;\nexport function f() {}\nexport type T = string;\n", equal: false},
+		{title: "RemovedExport", input: "export const a = 1;\n", equal: false},
+		{title: "ChangedFlags", input: "export let a = 1;\nexport class f {}\n", equal: false},
+		{title: "MovedDeclaration", input: "export function f() {}\nexport const a = 1;\n", equal: false},
+	}
+	for _, rec := range data {
+		t.Run(rec.title, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, ast.SymbolTablesEqual(base, exportsOf(rec.input), nil), rec.equal)
+		})
+	}
+
+	byName := func(x, y *ast.Symbol) bool { return x.Name == y.Name }
+	assert.Assert(t, ast.SymbolTablesEqual(base, exportsOf("export function f() {}\nexport let a = 1;\n"), byName))
+	assert.Assert(t, ast.SymbolTablesEqual(nil, ast.NewSymbolTable(), nil))
+}
+
+func TestOverlaySymbolTable(t *testing.T) {
+	t.Parallel()
+
+	a, b, c := &ast.Symbol{Name: "a"}, &ast.Symbol{Name: "b"}, &ast.Symbol{Name: "c"}
+	base := ast.NewSymbolTable()
+	base.Set("a", a)
+	base.Set("b", b)
+
+	overlay := ast.NewOverlaySymbolTable(base)
+	overlay.Set("c", c)
+	overlay.Set("a", b)
+	overlay.Delete("b")
+	overlay.Delete("missing")
+
+	assert.Equal(t, overlay.Len(), 2)
+	assert.Equal(t, overlay.Get("a"), b)
+	assert.Equal(t, overlay.Get("c"), c)
+	_, ok := overlay.Get2("b")
+	assert.Assert(t, !ok)
+	entries := make(map[string]*ast.Symbol)
+	for name, symbol := range overlay.Iter() {
+		entries[name] = symbol
+	}
+	assert.Equal(t, len(entries), 2)
+	assert.Equal(t, entries["a"], b)
+	assert.Equal(t, entries["c"], c)
+
+	// Restoring a deleted name clears its tombstone, and clones are independent.
+	clone := overlay.Clone()
+	overlay.Set("b", a)
+	assert.Equal(t, overlay.Get("b"), a)
+	assert.Equal(t, overlay.Len(), 3)
+	assert.Equal(t, clone.Len(), 2)
+	assert.Assert(t, clone.Get("b") == nil)
+
+	// The base is left untouched.
+	assert.Equal(t, base.Len(), 2)
+	assert.Equal(t, base.Get("a"), a)
+	assert.Equal(t, base.Get("b"), b)
+}
+
+func TestSymbolId(t *testing.T) {
+	t.Parallel()
+
+	file := parsetestutil.ParseTypeScript("export const a = 1;\nexport const b = 2;\n", false /*jsx*/)
+	binder.BindSourceFile(file)
+	a := file.Symbol.Exports.Get("a")
+	b := file.Symbol.Exports.Get("b")
+
+	ids := make(chan uint64, 8)
+	for range cap(ids) {
+		go func() { ids <- a.Id() }()
+	}
+	id := a.Id()
+	assert.Assert(t, id != 0)
+	for range cap(ids) {
+		assert.Equal(t, <-ids, id)
+	}
+	assert.Equal(t, uint64(ast.GetSymbolId(a)), id)
+	assert.Assert(t, b.Id() != id)
+}
+
+func TestSymbolTableCloneWithExtraCapacity(t *testing.T) {
+	t.Parallel()
+
+	a, b := &ast.Symbol{Name: "a"}, &ast.Symbol{Name: "b"}
+	tables := []ast.SymbolTable{ast.NewSymbolTable(), ast.NewOverlaySymbolTable(ast.NewSymbolTable())}
+	for _, table := range tables {
+		table.Set("a", a)
+		clone := table.CloneWithExtraCapacity(1)
+		clone.Set("b", b)
+		assert.Equal(t, clone.Len(), 2)
+		assert.Equal(t, clone.Get("a"), a)
+		assert.Equal(t, table.Len(), 1)
+		assert.Assert(t, table.Get("b") == nil)
+	}
+}
+
+func BenchmarkSymbolTableCloneThenInsert(b *testing.B) {
+	base := ast.NewSymbolTable()
+	for i := range 64 {
+		base.Set(fmt.Sprintf("base%d", i), &ast.Symbol{})
+	}
+	added := make([]string, 256)
+	for i := range added {
+		added[i] = fmt.Sprintf("added%d", i)
+	}
+	symbol := &ast.Symbol{}
+
+	b.Run("Clone", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			clone := base.Clone()
+			for _, name := range added {
+				clone.Set(name, symbol)
+			}
+		}
+	})
+	b.Run("CloneWithExtraCapacity", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			clone := base.CloneWithExtraCapacity(len(added))
+			for _, name := range added {
+				clone.Set(name, symbol)
+			}
+		}
+	})
+}
@@ -36,6 +36,22 @@ func GetTouchingToken(sourceFile *ast.SourceFile, position int) *ast.Node {
 	return getTokenAtPosition(sourceFile, position, false /*allowPositionInLeadingTrivia*/, nil)
 }
 
+// GetTouchingTokenPreferLeft is like GetTouchingToken, except that at a position where one token
+// ends and another begins, it returns the token that ends at the position.
+func GetTouchingTokenPreferLeft(sourceFile *ast.SourceFile, position int) *ast.Node {
+	token := getTokenAtPosition(sourceFile, position, false /*allowPositionInLeadingTrivia*/, func(node *ast.Node) bool {
+		return true
+	})
+	// Only nodes of the tree ending at the position are found above, so punctuation ending there,
+	// as in `a.b` before `b`, is looked for separately.
+	if token != nil && position > 0 && GetStartOfNode(token, sourceFile, false /*includeJSDoc*/) == position {
+		if preceding := FindPrecedingToken(sourceFile, position); preceding != nil && preceding.End() == position {
+			return preceding
+		}
+	}
+	return token
+}
+
 func GetTokenAtPosition(sourceFile *ast.SourceFile, position int) *ast.Node {
 	return getTokenAtPosition(sourceFile, position, true /*allowPositionInLeadingTrivia*/, nil)
 }
@@ -14969,7 +14969,7 @@ func (c *Checker) tryFindAmbientModule(moduleName string, withAugmentations bool
 }
 
 func (c *Checker) GetAmbientModules(sourceFile *ast.SourceFile) []*ast.Symbol {
-	isNode := c.denoForkContext.HasNodeSourceFile(&sourceFile.Node)
+	isNode := sourceFile != nil && c.denoForkContext.HasNodeSourceFile(&sourceFile.Node)
 	if isNode {
 		c.nodeAmbientModulesOnce.Do(func() {
 			for sym, global := range c.denoForkContext.CombinedGlobals().Iter() {
@@ -19947,8 +19947,9 @@ func (c *Checker) resolveAnonymousTypeMembers(t *Type) {
 		classType := c.getDeclaredTypeOfClassOrInterface(symbol)
 		baseConstructorType := c.getBaseConstructorTypeOfClass(classType)
 		if baseConstructorType.flags&(TypeFlagsObject|TypeFlagsIntersection|TypeFlagsTypeVariable) != 0 {
-			members = members.Clone()
-			c.addInheritedMembers(members, c.getPropertiesOfType(baseConstructorType))
+			baseProperties := c.getPropertiesOfType(baseConstructorType)
+			members = members.CloneWithExtraCapacity(len(baseProperties))
+			c.addInheritedMembers(members, baseProperties)
 			c.setStructuredTypeMembers(t, members, nil, nil, nil)
 		} else if baseConstructorType == c.anyType {
 			baseConstructorIndexInfo = c.anyBaseTypeIndexInfo
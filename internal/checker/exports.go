@@ -22,6 +22,12 @@ func (c *Checker) GetGlobalSymbol(name string, meaning ast.SymbolFlags, diagnost
 	return c.getGlobalSymbol(name, meaning, diagnostic)
 }
 
+// GetGlobals returns the global symbol table seen by files that are not Node source files. It
+// includes the globals of lib files, `declare global` augmentations and UMD global exports.
+func (c *Checker) GetGlobals() ast.SymbolTable {
+	return c.denoGlobals
+}
+
 func (c *Checker) GetMergedSymbol(symbol *ast.Symbol) *ast.Symbol {
 	return c.getMergedSymbol(symbol)
 }
@@ -62,6 +68,12 @@ func (c *Checker) GetPropertyOfType(t *Type, name string) *ast.Symbol {
 	return c.getPropertyOfType(t, name)
 }
 
+// Checks whether source is assignable to target. When it is not, diagnostics elaborating the
+// failure are reported on errorNode and appended to diagnosticOutput.
+func (c *Checker) CheckTypeAssignableTo(source *Type, target *Type, errorNode *ast.Node, diagnosticOutput *[]*ast.Diagnostic) bool {
+	return c.checkTypeAssignableToEx(source, target, errorNode, nil /*headMessage*/, diagnosticOutput)
+}
+
 func (c *Checker) TypeHasCallOrConstructSignatures(t *Type) bool {
 	return c.typeHasCallOrConstructSignatures(t)
 }
@@ -98,6 +110,33 @@ func (c *Checker) GetDeclaredTypeOfSymbol(symbol *ast.Symbol) *Type {
 	return c.getDeclaredTypeOfSymbol(symbol)
 }
 
+// GetBaseTypes returns the types a class or interface type extends.
+func (c *Checker) GetBaseTypes(t *Type) []*Type {
+	return c.getBaseTypes(t)
+}
+
+// GetBaseConstructorTypeOfClass returns the type of the expression a class type extends, which
+// declares the static members the class inherits. It is undefined if the class extends nothing.
+func (c *Checker) GetBaseConstructorTypeOfClass(t *Type) *Type {
+	return c.getBaseConstructorTypeOfClass(t)
+}
+
+// GetInstantiatedConstructorsForTypeArguments returns the construct signatures of t that accept
+// typeArgumentNodes, instantiated with them, as for the base class of a class extending t.
+func (c *Checker) GetInstantiatedConstructorsForTypeArguments(t *Type, typeArgumentNodes []*ast.Node, location *ast.Node) []*Signature {
+	return c.getInstantiatedConstructorsForTypeArguments(t, typeArgumentNodes, location)
+}
+
+func (c *Checker) GetMinArgumentCount(signature *Signature) int {
+	return c.getMinArgumentCount(signature)
+}
+
+// ResolveName returns the symbol name would refer to with the given meaning if it were written at
+// location, or nil if there is none.
+func (c *Checker) ResolveName(name string, location *ast.Node, meaning ast.SymbolFlags, excludeGlobals bool) *ast.Symbol {
+	return c.resolveName(location, name, meaning, nil /*nameNotFoundMessage*/, false /*isUse*/, excludeGlobals)
+}
+
 func (c *Checker) GetTypeOfSymbol(symbol *ast.Symbol) *Type {
 	return c.getTypeOfSymbol(symbol)
 }
@@ -146,6 +185,14 @@ func (c *Checker) TypePredicateToString(t *TypePredicate) string {
 	return c.typePredicateToString(t)
 }
 
+func (c *Checker) TypePredicateToStringEx(t *TypePredicate, enclosingDeclaration *ast.Node, flags TypeFormatFlags) string {
+	return c.typePredicateToStringEx(t, enclosingDeclaration, flags)
+}
+
+func (c *Checker) GetSignatureFromDeclaration(declaration *ast.Node) *Signature {
+	return c.getSignatureFromDeclaration(declaration)
+}
+
 func (c *Checker) GetExpandedParameters(signature *Signature, skipUnionExpanding bool) [][]*ast.Symbol {
 	return c.getExpandedParameters(signature, skipUnionExpanding)
 }
@@ -170,3 +217,7 @@ func (c *Checker) GetIndexSignaturesAtLocation(node *ast.Node) []*ast.Node {
 func (c *Checker) GetResolvedSymbol(node *ast.Node) *ast.Symbol {
 	return c.getResolvedSymbol(node)
 }
+
+func (c *Checker) GetBaseTypeOfLiteralType(t *Type) *Type {
+	return c.getBaseTypeOfLiteralType(t)
+}
@@ -339,3 +339,73 @@ func (r *fileIncludeReason) computeReferenceFileRelatedInfo(program *Program) *a
 		panic(fmt.Sprintf("unknown reason: %v", r.kind))
 	}
 }
+
+// IncludeReasonKind is the kind of an IncludeReason.
+type IncludeReasonKind string
+
+const (
+	IncludeReasonKindRootFile               IncludeReasonKind = "RootFile"
+	IncludeReasonKindImport                 IncludeReasonKind = "Import"
+	IncludeReasonKindReferenceDirective     IncludeReasonKind = "ReferenceDirective"
+	IncludeReasonKindLibFile                IncludeReasonKind = "LibFile"
+	IncludeReasonKindAutomaticTypeDirective IncludeReasonKind = "AutomaticTypeDirective"
+	IncludeReasonKindProjectReference       IncludeReasonKind = "ProjectReference"
+)
+
+// IncludeReason is a reason a file is part of a program.
+type IncludeReason struct {
+	Kind IncludeReasonKind
+	// For imports and reference directives, the file containing the reference, the module specifier
+	// or referenced file name, and the range of the specifier or directive in the file. The range is
+	// undefined for imports synthesized by the compiler, such as those of importHelpers.
+	ReferencingFile *ast.SourceFile
+	Specifier       string
+	Range           core.TextRange
+	// The explanation of the reason printed by --explainFiles.
+	Message string
+}
+
+// GetFileIncludeReasons returns the reasons the file at path is part of the program, in the order
+// they were found while loading the program. The first reason is the one through which the file
+// was first reached.
+func (p *Program) GetFileIncludeReasons(path tspath.Path) []*IncludeReason {
+	reasons := p.includeProcessor.fileIncludeReasons[path]
+	result := make([]*IncludeReason, 0, len(reasons))
+	for _, reason := range reasons {
+		result = append(result, reason.toIncludeReason(p))
+	}
+	return result
+}
+
+func (r *fileIncludeReason) toIncludeReason(program *Program) *IncludeReason {
+	result := &IncludeReason{
+		Message: r.toDiagnostic(program, false).Message(),
+		Range:   core.UndefinedTextRange(),
+	}
+	switch r.kind {
+	case fileIncludeKindRootFile:
+		result.Kind = IncludeReasonKindRootFile
+	case fileIncludeKindLibFile:
+		result.Kind = IncludeReasonKindLibFile
+	case fileIncludeKindAutomaticTypeDirectiveFile:
+		result.Kind = IncludeReasonKindAutomaticTypeDirective
+		result.Specifier = r.asAutomaticTypeDirectiveFileData().typeReference
+	case fileIncludeKindSourceFromProjectReference, fileIncludeKindOutputFromProjectReference:
+		result.Kind = IncludeReasonKindProjectReference
+	default:
+		location := program.includeProcessor.getReferenceLocation(r, program)
+		result.ReferencingFile = location.file
+		if location.node != nil {
+			result.Kind = IncludeReasonKindImport
+			result.Specifier = location.node.Text()
+			if !location.isSynthetic {
+				result.Range = core.NewTextRange(scanner.SkipTrivia(location.file.Text(), location.node.Pos()), location.node.End())
+			}
+		} else {
+			result.Kind = IncludeReasonKindReferenceDirective
+			result.Specifier = location.ref.FileName
+			result.Range = location.ref.TextRange
+		}
+	}
+	return result
+}
@@ -390,6 +390,17 @@ func (p *Program) GetResolvedModule(file ast.HasFileName, moduleReference string
 	return nil
 }
 
+// ResolveModuleNameWithTrace resolves a module name imported by a file of the program as the
+// program does, with a new resolver from the host of the program that traces each step as
+// --traceResolution does. The resolutions of the program are not changed.
+func (p *Program) ResolveModuleNameWithTrace(moduleName string, file ast.HasFileName, mode core.ResolutionMode) (*module.ResolvedModule, []string) {
+	redirect, fileName := p.projectReferenceFileMapper.getRedirectForResolution(file)
+	options := p.opts.Config.CompilerOptions().Clone()
+	options.TraceResolution = core.TSTrue
+	resolver := p.opts.Host.MakeResolver(p.opts.Host, options, p.opts.TypingsLocation, p.opts.ProjectName)
+	return resolver.ResolveModuleName(moduleName, fileName, mode, redirect)
+}
+
 func (p *Program) GetResolvedModuleFromModuleSpecifier(file ast.HasFileName, moduleSpecifier *ast.StringLiteralLike) *module.ResolvedModule {
 	if !ast.IsStringLiteralLike(moduleSpecifier) {
 		panic("moduleSpecifier must be a StringLiteralLike")
@@ -0,0 +1,72 @@
+package fourslash_test
+
+import (
+	"testing"
+
+	"github.com/microsoft/typescript-go/internal/fourslash"
+	. "github.com/microsoft/typescript-go/internal/fourslash/tests/util"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/testutil"
+)
+
+func TestSignatureHelpTypeArgumentList(t *testing.T) {
+	t.Parallel()
+	defer testutil.RecoverAndFail(t, "Panic on fourslash test")
+	const content = `
+interface Box<T extends object, U = string> {}
+class C<T> {
+    constructor(x: T) {}
+}
+
+let b: Box</*1*/
+let b2: Box<{}, /*2*/
+new C</*3*/
+`
+	typeParameterLabels := func(labels ...string) *[]*lsproto.ParameterInformation {
+		parameters := make([]*lsproto.ParameterInformation, len(labels))
+		for i, label := range labels {
+			parameters[i] = &lsproto.ParameterInformation{Label: lsproto.StringOrTuple{String: PtrTo(label)}}
+		}
+		return &parameters
+	}
+	typed := func(character string) *lsproto.SignatureHelpContext {
+		return &lsproto.SignatureHelpContext{
+			IsRetrigger:      false,
+			TriggerCharacter: PtrTo(character),
+			TriggerKind:      lsproto.SignatureHelpTriggerKindTriggerCharacter,
+		}
+	}
+	box := func(activeParameter uint32) *lsproto.SignatureHelp {
+		return &lsproto.SignatureHelp{
+			Signatures: []*lsproto.SignatureInformation{{
+				Label:      "Box<T extends object, U = string>",
+				Parameters: typeParameterLabels("T extends object", "U = string"),
+			}},
+			ActiveSignature: PtrTo(uint32(0)),
+			ActiveParameter: &lsproto.UintegerOrNull{Uinteger: PtrTo(activeParameter)},
+		}
+	}
+	f := fourslash.NewFourslash(t, nil /*capabilities*/, content)
+	f.VerifySignatureHelp(t, &fourslash.SignatureHelpCase{
+		MarkerInput: "1",
+		Expected:    box(0),
+		Context:     typed("<"),
+	})
+	f.VerifySignatureHelp(t, &fourslash.SignatureHelpCase{
+		MarkerInput: "2",
+		Expected:    box(1),
+		Context:     typed(","),
+	})
+	f.VerifySignatureHelp(t, &fourslash.SignatureHelpCase{
+		MarkerInput: "3",
+		Expected: &lsproto.SignatureHelp{
+			Signatures: []*lsproto.SignatureInformation{{
+				Label:      "C<T>",
+				Parameters: typeParameterLabels("T"),
+			}},
+			ActiveSignature: PtrTo(uint32(0)),
+			ActiveParameter: &lsproto.UintegerOrNull{Uinteger: PtrTo(uint32(0))},
+		},
+		Context: typed("<"),
+	})
+}
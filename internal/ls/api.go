@@ -4,19 +4,61 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"slices"
 
 	"github.com/microsoft/typescript-go/internal/ast"
 	"github.com/microsoft/typescript-go/internal/astnav"
 	"github.com/microsoft/typescript-go/internal/checker"
+	"github.com/microsoft/typescript-go/internal/collections"
 	"github.com/microsoft/typescript-go/internal/compiler"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/diagnostics"
+	"github.com/microsoft/typescript-go/internal/tsoptions"
+	"github.com/zeebo/xxh3"
 )
 
 var (
 	ErrNoSourceFile      = errors.New("source file not found")
 	ErrNoTokenAtPosition = errors.New("no token found at position")
+	// ErrUnknownRefactor is returned for a refactor name that GetRefactors never returns.
+	ErrUnknownRefactor = errors.New("unknown refactor")
+	// ErrRefactorNotApplicable is returned when a refactor action does not apply to the requested range.
+	ErrRefactorNotApplicable = errors.New("refactor is not applicable")
+	// ErrUnknownFixId is returned for a fix id that no code fix belongs to.
+	ErrUnknownFixId = errors.New("unknown fix id")
+	// ErrFileAlreadyExists is returned when a refactor would create a file that already exists.
+	ErrFileAlreadyExists = errors.New("file already exists")
+	// ErrInvalidContinuationToken is returned for a continuation token that GetDiagnosticsPage did not
+	// return for the current diagnostics of the program, such as one from before the program changed.
+	ErrInvalidContinuationToken = errors.New("invalid or stale continuation token")
+	// ErrExportNotFound is returned when a module has no export with the requested name.
+	ErrExportNotFound = errors.New("export not found")
+	// ErrCannotRename is returned when the name at a position refers to something that cannot be renamed.
+	ErrCannotRename = errors.New("cannot rename")
 )
 
+// Warmup binds every file of the program, including the default library files, and creates a type
+// checker, so that later requests on the same program do not pay for it. It does nothing for work
+// that has already been done.
+func (l *LanguageService) Warmup(ctx context.Context) error {
+	program := l.GetProgram()
+	ReportProgress(ctx, 0, "Binding files")
+	program.BindSourceFiles()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	// Creating a checker merges the globals of all files.
+	ReportProgress(ctx, 50, "Creating type checker")
+	_, done := program.GetTypeChecker(ctx)
+	done()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ReportProgress(ctx, 100, "Warmed up")
+	return nil
+}
+
 func (l *LanguageService) GetSymbolAtPosition(ctx context.Context, fileName string, position int) (*ast.Symbol, error) {
 	program, file := l.tryGetProgramAndFile(fileName)
 	if file == nil {
@@ -31,6 +73,155 @@ func (l *LanguageService) GetSymbolAtPosition(ctx context.Context, fileName stri
 	return checker.GetSymbolAtLocation(node), nil
 }
 
+// SymbolDetails describes a symbol for clients that only see its name and flags: where it is
+// declared, its documentation and its type.
+type SymbolDetails struct {
+	// Declarations are the locations of the names of the declarations of the symbol.
+	Declarations  []DefinitionLocation `json:"declarations"`
+	Documentation string               `json:"documentation"`
+	// Type is the type of the symbol, or of the symbol an alias refers to, as written in quick
+	// info. It is empty for symbols without a value, such as interfaces.
+	Type string `json:"type"`
+}
+
+// GetSymbolDetailsAtPosition returns the symbol at a position, as GetSymbolAtPosition does, with
+// its details, or nil if there is no symbol there.
+func (l *LanguageService) GetSymbolDetailsAtPosition(ctx context.Context, fileName string, position int) (*ast.Symbol, *SymbolDetails, error) {
+	program, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	node := astnav.GetTokenAtPosition(file, position)
+	if node == nil {
+		return nil, nil, fmt.Errorf("%w: %s:%d", ErrNoTokenAtPosition, fileName, position)
+	}
+	c, done := program.GetTypeCheckerForFile(ctx, file)
+	defer done()
+	symbol := c.GetSymbolAtLocation(node)
+	if symbol == nil {
+		return nil, nil, nil
+	}
+	details := &SymbolDetails{Declarations: []DefinitionLocation{}}
+	for _, declaration := range symbol.Declarations {
+		details.Declarations = append(details.Declarations, l.newDefinitionLocation(declaration))
+	}
+	_, details.Documentation = getQuickInfoAndDocumentationForSymbol(c, symbol, getNodeForQuickInfo(node))
+	target := symbol
+	if symbol.Flags&ast.SymbolFlagsAlias != 0 {
+		target = c.GetAliasedSymbol(symbol)
+	}
+	if target.Flags&ast.SymbolFlagsValue != 0 {
+		details.Type = c.TypeToString(c.GetTypeOfSymbol(target))
+	}
+	return symbol, details, nil
+}
+
+// GetAliasedSymbol returns the symbol at a position with aliases, such as imports, resolved to the
+// symbol they refer to through any chain of re-exports. A symbol that is not an alias is returned as
+// is. If the chain cannot be resolved or is circular, the last alias of the chain is returned.
+func (l *LanguageService) GetAliasedSymbol(ctx context.Context, fileName string, position int) (*ast.Symbol, error) {
+	program, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	node := astnav.GetTokenAtPosition(file, position)
+	if node == nil {
+		return nil, fmt.Errorf("%w: %s:%d", ErrNoTokenAtPosition, fileName, position)
+	}
+	checker, done := program.GetTypeCheckerForFile(ctx, file)
+	defer done()
+	return resolveAliasChain(checker, checker.GetSymbolAtLocation(node)), nil
+}
+
+// resolveAliasChain follows the immediate targets of an alias one at a time, stopping at the first
+// symbol that is not an alias, or at an alias that cannot be resolved or was already visited.
+func resolveAliasChain(c *checker.Checker, symbol *ast.Symbol) *ast.Symbol {
+	var visited collections.Set[*ast.Symbol]
+	for symbol != nil && symbol.Flags&ast.SymbolFlagsAlias != 0 && visited.AddIfAbsent(symbol) {
+		target := c.GetImmediateAliasedSymbol(symbol)
+		if target == nil || c.IsUnknownSymbol(target) {
+			break
+		}
+		symbol = target
+	}
+	return symbol
+}
+
+// NodeInfo describes a single node or token of a source file. Its range and text exclude leading trivia.
+type NodeInfo struct {
+	Kind       ast.Kind `json:"kind"`
+	ParentKind ast.Kind `json:"parentKind"`
+	Text       string   `json:"text"`
+	FileName   string   `json:"fileName"`
+	Start      Position `json:"start"`
+	End        Position `json:"end"`
+	StartPos   int      `json:"startPos"`
+	EndPos     int      `json:"endPos"`
+}
+
+func (l *LanguageService) newNodeInfo(file *ast.SourceFile, node *ast.Node) NodeInfo {
+	textRange := createRangeFromNode(node, file)
+	var parentKind ast.Kind
+	if node.Parent != nil {
+		parentKind = node.Parent.Kind
+	}
+	return NodeInfo{
+		Kind:       node.Kind,
+		ParentKind: parentKind,
+		Text:       file.Text()[textRange.Pos():textRange.End()],
+		FileName:   file.FileName(),
+		Start:      getPosition(file, textRange.Pos(), l),
+		End:        getPosition(file, textRange.End(), l),
+		StartPos:   textRange.Pos(),
+		EndPos:     textRange.End(),
+	}
+}
+
+// GetTouchingToken returns the token touching position, ignoring leading trivia. At a position
+// where one token ends and another begins, preferLeft selects the token ending at the position,
+// such as the end of an identifier followed by whitespace or punctuation.
+func (l *LanguageService) GetTouchingToken(ctx context.Context, fileName string, position int, preferLeft bool) (*NodeInfo, error) {
+	_, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	var token *ast.Node
+	if preferLeft {
+		token = astnav.GetTouchingTokenPreferLeft(file, position)
+	} else {
+		token = astnav.GetTouchingToken(file, position)
+	}
+	// When no token touches the position, astnav returns the innermost node containing it.
+	if token == nil || !ast.IsTokenKind(token.Kind) || token.Kind == ast.KindEndOfFile {
+		return nil, fmt.Errorf("%w: %s:%d", ErrNoTokenAtPosition, fileName, position)
+	}
+	info := l.newNodeInfo(file, token)
+	return &info, nil
+}
+
+// ForEachNode calls visit with each node of the given kinds in a file, or with every node if kinds is
+// empty, in a depth-first walk of the syntax tree that visits a node before its children. Tokens that
+// are not stored in the tree, such as punctuation, are not visited. The walk stops when visit returns
+// false or the context is cancelled, in which case the error of the context is returned.
+func (l *LanguageService) ForEachNode(ctx context.Context, fileName string, kinds []ast.Kind, visit func(NodeInfo) bool) error {
+	_, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	var visitNode func(node *ast.Node) bool
+	visitNode = func(node *ast.Node) bool {
+		if ctx.Err() != nil {
+			return true
+		}
+		if (len(kinds) == 0 || slices.Contains(kinds, node.Kind)) && !visit(l.newNodeInfo(file, node)) {
+			return true
+		}
+		return node.ForEachChild(visitNode)
+	}
+	file.AsNode().ForEachChild(visitNode)
+	return ctx.Err()
+}
+
 func (l *LanguageService) GetSymbolAtLocation(ctx context.Context, node *ast.Node) *ast.Symbol {
 	program := l.GetProgram()
 	checker, done := program.GetTypeCheckerForFile(ctx, ast.GetSourceFileOfNode(node))
@@ -58,6 +249,32 @@ func getPosition(file *ast.SourceFile, position int, ls *LanguageService) Positi
 	}
 }
 
+type DefinitionLocation struct {
+	FileName string   `json:"fileName"`
+	Start    Position `json:"start"`
+	End      Position `json:"end"`
+	StartPos int      `json:"startPos"`
+	EndPos   int      `json:"endPos"`
+}
+
+// newDefinitionLocation returns the location of a declaration's name, or of the whole declaration if it has no name.
+func (l *LanguageService) newDefinitionLocation(declaration *ast.Node) DefinitionLocation {
+	return l.newNodeLocation(core.OrElse(ast.GetNameOfDeclaration(declaration), declaration))
+}
+
+// newNodeLocation returns the location of a node, excluding its leading trivia.
+func (l *LanguageService) newNodeLocation(node *ast.Node) DefinitionLocation {
+	file := ast.GetSourceFileOfNode(node)
+	textRange := createRangeFromNode(node, file)
+	return DefinitionLocation{
+		FileName: file.FileName(),
+		Start:    getPosition(file, textRange.Pos(), l),
+		End:      getPosition(file, textRange.End(), l),
+		StartPos: textRange.Pos(),
+		EndPos:   textRange.End(),
+	}
+}
+
 type DiagnosticId uint32
 
 type Diagnostic struct {
@@ -76,6 +293,29 @@ type Diagnostic struct {
 	ReportsDeprecated  bool           `json:"reportsDeprecated"`
 	SkippedOnNoEmit    bool           `json:"skippedOnNoEmit"`
 	SourceLine         string         `json:"sourceLine"`
+	// IsDirectiveRelated is set for diagnostics about comment directives rather than code,
+	// such as an unused `@ts-expect-error` comment.
+	IsDirectiveRelated bool `json:"isDirectiveRelated"`
+	// MessageTemplate is the message before its arguments were substituted, such as
+	// "Cannot find name '{0}'.", for clients that localize messages. It is empty when unknown.
+	MessageTemplate string `json:"messageTemplate,omitempty"`
+	// CodeString is the code in the form used by tsc output, such as "TS2304".
+	CodeString string `json:"codeString"`
+	// Label is a short description of how a related information entry relates to the diagnostic
+	// referencing it, such as "Declared here". It is empty for other diagnostics.
+	Label string `json:"label,omitempty"`
+}
+
+// relatedInformationLabels maps the codes of related information messages to their labels.
+var relatedInformationLabels = map[int32]string{
+	diagnostics.X_0_is_declared_here.Code():                          "Declared here",
+	diagnostics.X_0_was_also_declared_here.Code():                    "Also declared here",
+	diagnostics.X_and_here.Code():                                    "Also declared here",
+	diagnostics.Property_0_was_also_declared_here.Code():             "Also declared here",
+	diagnostics.The_implementation_signature_is_declared_here.Code(): "Implementation declared here",
+	diagnostics.The_last_overload_is_declared_here.Code():            "Last overload declared here",
+	diagnostics.The_first_export_default_is_here.Code():              "First default export here",
+	diagnostics.Another_export_default_is_here.Code():                "Another default export here",
 }
 
 type diagnosticMaps struct {
@@ -87,7 +327,7 @@ func (d *diagnosticMaps) addDiagnostic(diagnostic *ast.Diagnostic, ls *LanguageS
 	if i, ok := d.diagnosticReverseMap[diagnostic]; ok {
 		return i
 	}
-	id := DiagnosticId(len(d.diagnosticMapById) + 1)
+	id := d.nextId()
 
 	startPos := diagnostic.Loc().Pos()
 	startPosLineCol := getPosition(diagnostic.File(), startPos, ls)
@@ -114,6 +354,12 @@ func (d *diagnosticMaps) addDiagnostic(diagnostic *ast.Diagnostic, ls *LanguageS
 		Message:            diagnostic.Message(),
 		MessageChain:       make([]DiagnosticId, 0, len(diagnostic.MessageChain())),
 		RelatedInformation: make([]DiagnosticId, 0, len(diagnostic.RelatedInformation())),
+		IsDirectiveRelated: diagnostic.Code() == diagnostics.Unused_ts_expect_error_directive.Code(),
+		CodeString:         fmt.Sprintf("TS%d", diagnostic.Code()),
+		Label:              relatedInformationLabels[diagnostic.Code()],
+	}
+	if template := diagnostic.MessageTemplate(); template != nil {
+		diag.MessageTemplate = template.Message()
 	}
 
 	d.diagnosticReverseMap[diagnostic] = id
@@ -130,6 +376,19 @@ func (d *diagnosticMaps) addDiagnostic(diagnostic *ast.Diagnostic, ls *LanguageS
 	return id
 }
 
+func (d *diagnosticMaps) nextId() DiagnosticId {
+	return DiagnosticId(len(d.diagnosticReverseMap) + 1)
+}
+
+// getDiagnosticsFrom returns the diagnostics added since firstId was the next id, in id order.
+func (d *diagnosticMaps) getDiagnosticsFrom(firstId DiagnosticId) []Diagnostic {
+	diagnostics := make([]Diagnostic, 0, int(d.nextId()-firstId))
+	for id := firstId; id < d.nextId(); id++ {
+		diagnostics = append(diagnostics, d.diagnosticMapById[id])
+	}
+	return diagnostics
+}
+
 func (d *diagnosticMaps) getDiagnostics() []Diagnostic {
 	diagnostics := make([]Diagnostic, 0, len(d.diagnosticMapById))
 	for _, diagnostic := range d.diagnosticMapById {
@@ -142,21 +401,342 @@ func (d *diagnosticMaps) getDiagnostics() []Diagnostic {
 	return diagnostics
 }
 
+// getDiagnosticsByFile groups the collected diagnostics by file name. Ids are shared with
+// the flat list, so message chain and related information references remain valid across files.
+func (d *diagnosticMaps) getDiagnosticsByFile() map[string][]*Diagnostic {
+	diagnosticsByFile := make(map[string][]*Diagnostic)
+	for _, diagnostic := range d.getDiagnostics() {
+		diagnosticsByFile[diagnostic.FileName] = append(diagnosticsByFile[diagnostic.FileName], &diagnostic)
+	}
+	return diagnosticsByFile
+}
+
 func (l *LanguageService) GetDiagnostics(ctx context.Context) []Diagnostic {
+	return l.collectDiagnostics(ctx).getDiagnostics()
+}
+
+// DefaultDiagnosticsChunkSize is the number of files GetDiagnosticsChunked checks at a time when
+// no chunk size is given.
+const DefaultDiagnosticsChunkSize = 16
+
+type ChunkedDiagnostics struct {
+	Diagnostics []Diagnostic `json:"diagnostics"`
+	// Whether checking was cancelled before every file was checked, in which case Diagnostics only
+	// holds the diagnostics of the files checked before.
+	Partial bool `json:"partial"`
+}
+
+// GetDiagnosticsChunked is GetDiagnostics for callers that may cancel. It passes the files of the
+// program to Program.CheckSourceFiles chunkSize at a time, and stops between chunks once ctx is
+// cancelled, returning the diagnostics of the chunks already checked rather than nothing.
+// The files of a chunk interrupted by the cancellation are left out. Unlike GetDiagnostics, it
+// does not use the diagnostics cache.
+func (l *LanguageService) GetDiagnosticsChunked(ctx context.Context, chunkSize int) *ChunkedDiagnostics {
+	if chunkSize <= 0 {
+		chunkSize = DefaultDiagnosticsChunkSize
+	}
 	program := l.GetProgram()
-	sourceFiles := program.GetSourceFiles()
-	diagnosticMaps := &diagnosticMaps{
+	program.BindSourceFiles()
+	var diagnostics []*ast.Diagnostic
+	partial := false
+	for chunk := range slices.Chunk(program.GetSourceFiles(), chunkSize) {
+		if ctx.Err() == nil {
+			program.CheckSourceFiles(ctx, chunk)
+		}
+		if ctx.Err() != nil {
+			partial = true
+			break
+		}
+		for _, sourceFile := range chunk {
+			diagnostics = append(diagnostics, collectFileDiagnostics(ctx, program, sourceFile)...)
+		}
+	}
+	diagnosticMaps := newDiagnosticMaps()
+	for _, diagnostic := range compiler.SortAndDeduplicateDiagnostics(diagnostics) {
+		diagnosticMaps.addDiagnostic(diagnostic, l)
+	}
+	return &ChunkedDiagnostics{Diagnostics: diagnosticMaps.getDiagnostics(), Partial: partial}
+}
+
+type DiagnosticsPage struct {
+	// The diagnostics of the page, followed by the message chain and related information entries
+	// they reference, in id order.
+	Diagnostics []Diagnostic `json:"diagnostics"`
+	// The number of diagnostics of the program across all pages, not counting message chain and
+	// related information entries.
+	TotalCount int `json:"totalCount"`
+	// The token to pass to get the next page, or empty for the last page.
+	ContinuationToken string `json:"continuationToken,omitempty"`
+}
+
+// GetDiagnosticsPage returns at most maxResults of the diagnostics of GetDiagnostics, starting
+// after the page that returned continuationToken, or from the first one if it is empty. If
+// maxResults is not positive, every remaining diagnostic is returned. Ids are those of
+// GetDiagnostics, and every entry referenced by the message chain or related information of a
+// diagnostic is in the same page, so pages can be used on their own. A token only continues the
+// diagnostics it was returned with: once they change, it is rejected with
+// ErrInvalidContinuationToken and paging must restart.
+func (l *LanguageService) GetDiagnosticsPage(ctx context.Context, maxResults int, continuationToken string) (*DiagnosticsPage, error) {
+	diagnosticMaps, ids := l.collectTopLevelDiagnostics(ctx)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	fingerprint := xxh3.New()
+	for _, id := range ids {
+		diagnostic := diagnosticMaps.diagnosticMapById[id]
+		_, _ = fmt.Fprintf(fingerprint, "%s:%d:%d:%d:%s\n", diagnostic.FileName, diagnostic.StartPos, diagnostic.EndPos, diagnostic.Code, diagnostic.Message)
+	}
+
+	offset := 0
+	if continuationToken != "" {
+		var tokenFingerprint uint64
+		if _, err := fmt.Sscanf(continuationToken, "%d-%x", &offset, &tokenFingerprint); err != nil || tokenFingerprint != fingerprint.Sum64() || offset < 0 || offset > len(ids) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidContinuationToken, continuationToken)
+		}
+	}
+	end := len(ids)
+	if maxResults > 0 {
+		end = min(offset+maxResults, end)
+	}
+
+	var pageIds collections.Set[DiagnosticId]
+	var addIds func(ids []DiagnosticId)
+	addIds = func(ids []DiagnosticId) {
+		for _, id := range ids {
+			if pageIds.AddIfAbsent(id) {
+				diagnostic := diagnosticMaps.diagnosticMapById[id]
+				addIds(diagnostic.MessageChain)
+				addIds(diagnostic.RelatedInformation)
+			}
+		}
+	}
+	addIds(ids[offset:end])
+	page := &DiagnosticsPage{
+		Diagnostics: make([]Diagnostic, 0, pageIds.Len()),
+		TotalCount:  len(ids),
+	}
+	for _, id := range slices.Sorted(maps.Keys(pageIds.Keys())) {
+		page.Diagnostics = append(page.Diagnostics, diagnosticMaps.diagnosticMapById[id])
+	}
+	if end < len(ids) {
+		page.ContinuationToken = fmt.Sprintf("%d-%x", end, fingerprint.Sum64())
+	}
+	return page, nil
+}
+
+// GetSyntacticDiagnostics returns the parse diagnostics of a single file. It neither binds nor checks
+// the program, so it stays fast enough to run on every edit.
+func (l *LanguageService) GetSyntacticDiagnostics(ctx context.Context, fileName string) ([]*Diagnostic, error) {
+	program, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	diagnostics := program.GetSyntacticDiagnostics(ctx, file)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return l.toDiagnostics(diagnostics), nil
+}
+
+// GetConfigFileDiagnostics returns the diagnostics of parsing a config file: syntax errors in its
+// JSON, and unknown compiler options and invalid option values, located in the config file or in a
+// config file it extends. Diagnostics that are not about a location, such as the one reporting
+// that no input files were found, are located at the start of the config file.
+func (l *LanguageService) GetConfigFileDiagnostics(ctx context.Context, config *tsoptions.ParsedCommandLine) ([]*Diagnostic, error) {
+	if config.ConfigFile == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, config.ConfigName())
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	diagnostics := config.GetConfigFileParsingDiagnostics()
+	for i, diagnostic := range diagnostics {
+		if diagnostic.File() == nil {
+			diagnostics[i] = diagnostic.Clone()
+			diagnostics[i].SetFile(config.ConfigFile.SourceFile)
+			diagnostics[i].SetLocation(core.NewTextRange(0, 0))
+		}
+	}
+	return l.toDiagnostics(diagnostics), nil
+}
+
+// GetFileDiagnostics returns the syntactic and semantic diagnostics of a single file. Only the
+// file is checked, and the diagnostics cache is not used.
+func (l *LanguageService) GetFileDiagnostics(ctx context.Context, fileName string) ([]*Diagnostic, error) {
+	program, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	diagnostics := collectFileDiagnostics(ctx, program, file)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return l.toDiagnostics(diagnostics), nil
+}
+
+type DiagnosticDelta struct {
+	// ResultId identifies the diagnostics of the file, to be passed as the previous result id of
+	// the next request.
+	ResultId string `json:"resultId"`
+	// Unchanged is set when the diagnostics are those identified by the previous result id, in which
+	// case Diagnostics is nil.
+	Unchanged   bool          `json:"unchanged"`
+	Diagnostics []*Diagnostic `json:"diagnostics"`
+}
+
+// GetDiagnosticsDelta is GetFileDiagnostics for clients that keep the diagnostics of the previous
+// request, as with LSP pull diagnostics. The result id is a hash of the diagnostics, so it does not
+// depend on the language service that computed them: when it equals previousResultId, only the
+// id is returned and the client keeps the diagnostics it has.
+func (l *LanguageService) GetDiagnosticsDelta(ctx context.Context, fileName string, previousResultId string) (*DiagnosticDelta, error) {
+	diagnostics, err := l.GetFileDiagnostics(ctx, fileName)
+	if err != nil {
+		return nil, err
+	}
+	hasher := xxh3.New()
+	_, _ = hasher.WriteString(fileName)
+	for _, diagnostic := range diagnostics {
+		_, _ = fmt.Fprintf(hasher, "\n%+v", *diagnostic)
+	}
+	resultId := fmt.Sprintf("%x", hasher.Sum64())
+	if resultId == previousResultId {
+		return &DiagnosticDelta{ResultId: resultId, Unchanged: true}, nil
+	}
+	return &DiagnosticDelta{ResultId: resultId, Diagnostics: diagnostics}, nil
+}
+
+// GetDiagnosticsByFile is GetDiagnostics grouped by file name. The diagnostics that message chains
+// and related information refer to are grouped with the file they are in, and files without any
+// diagnostics are left out.
+func (l *LanguageService) GetDiagnosticsByFile(ctx context.Context) (map[string][]*Diagnostic, error) {
+	diagnosticMaps := l.collectDiagnostics(ctx)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return diagnosticMaps.getDiagnosticsByFile(), nil
+}
+
+// toDiagnostics sorts and deduplicates diagnostics and converts them to API diagnostics, including
+// the diagnostics their message chains and related information refer to by id.
+func (l *LanguageService) toDiagnostics(diagnostics []*ast.Diagnostic) []*Diagnostic {
+	diagnosticMaps := newDiagnosticMaps()
+	for _, diagnostic := range compiler.SortAndDeduplicateDiagnostics(diagnostics) {
+		diagnosticMaps.addDiagnostic(diagnostic, l)
+	}
+	result := []*Diagnostic{}
+	for _, diagnostic := range diagnosticMaps.getDiagnostics() {
+		result = append(result, &diagnostic)
+	}
+	return result
+}
+
+func newDiagnosticMaps() *diagnosticMaps {
+	return &diagnosticMaps{
 		diagnosticMapById:    make(map[DiagnosticId]Diagnostic),
 		diagnosticReverseMap: make(map[*ast.Diagnostic]DiagnosticId),
 	}
-	diagnostics := make([]*ast.Diagnostic, 0, len(sourceFiles))
-	for _, sourceFile := range sourceFiles {
-		diagnostics = append(diagnostics, program.GetSyntacticDiagnostics(ctx, sourceFile)...)
-		diagnostics = append(diagnostics, program.GetSemanticDiagnostics(ctx, sourceFile)...)
-	}
+}
+
+func (l *LanguageService) collectDiagnostics(ctx context.Context) *diagnosticMaps {
+	diagnosticMaps, _ := l.collectTopLevelDiagnostics(ctx)
+	return diagnosticMaps
+}
+
+// collectTopLevelDiagnostics is collectDiagnostics that also returns the ids of the diagnostics
+// of the program, in order, as opposed to those of their message chains and related information.
+func (l *LanguageService) collectTopLevelDiagnostics(ctx context.Context) (*diagnosticMaps, []DiagnosticId) {
+	diagnosticMaps := newDiagnosticMaps()
+	var diagnostics []*ast.Diagnostic
+	_ = l.forEachFileDiagnostics(ctx, func(_ *ast.SourceFile, fileDiagnostics []*ast.Diagnostic) error {
+		diagnostics = append(diagnostics, fileDiagnostics...)
+		return nil
+	})
 	diagnostics = compiler.SortAndDeduplicateDiagnostics(diagnostics)
+	ids := make([]DiagnosticId, 0, len(diagnostics))
+	for _, diagnostic := range diagnostics {
+		ids = append(ids, diagnosticMaps.addDiagnostic(diagnostic, l))
+	}
+	return diagnosticMaps, ids
+}
+
+// StreamDiagnostics checks the program one file at a time, passing each file's diagnostics to fn
+// as soon as that file has been checked. Ids are assigned across the whole stream: the diagnostics
+// passed for a file include any message chain and related information entries first reached
+// through it, and may also reference entries passed for an earlier file.
+func (l *LanguageService) StreamDiagnostics(ctx context.Context, fn func(fileName string, diagnostics []Diagnostic) error) error {
+	diagnosticMaps := newDiagnosticMaps()
+	return l.forEachFileDiagnostics(ctx, func(sourceFile *ast.SourceFile, diagnostics []*ast.Diagnostic) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		firstId := diagnosticMaps.nextId()
+		for _, diagnostic := range compiler.SortAndDeduplicateDiagnostics(diagnostics) {
+			diagnosticMaps.addDiagnostic(diagnostic, l)
+		}
+		return fn(sourceFile.FileName(), diagnosticMaps.getDiagnosticsFrom(firstId))
+	})
+}
+
+// forEachFileDiagnostics calls fn with the diagnostics of each file of the program, in order,
+// reporting the progress to the reporter of ctx after each file.
+func (l *LanguageService) forEachFileDiagnostics(ctx context.Context, fn func(sourceFile *ast.SourceFile, diagnostics []*ast.Diagnostic) error) error {
+	program := l.GetProgram()
+	total := len(program.GetSourceFiles())
+	checked := 0
+	inner := fn
+	fn = func(sourceFile *ast.SourceFile, diagnostics []*ast.Diagnostic) error {
+		if err := inner(sourceFile, diagnostics); err != nil {
+			return err
+		}
+		checked++
+		ReportProgress(ctx, checked*100/total, "Checked "+sourceFile.FileName())
+		return nil
+	}
+	if l.diagnosticsCache != nil {
+		return l.diagnosticsCache.forEachFileDiagnostics(ctx, program, fn)
+	}
+	for _, sourceFile := range program.GetSourceFiles() {
+		if err := fn(sourceFile, collectFileDiagnostics(ctx, program, sourceFile)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsTypeAssignableTo reports whether the type at sourcePos in sourceFile is assignable to the
+// type at targetPos in targetFile. When it is not, the returned diagnostics explain why: the
+// first diagnostic is the head of the elaboration, and its message chain and related information
+// reference the remaining diagnostics by id, as in GetDiagnostics.
+func (l *LanguageService) IsTypeAssignableTo(ctx context.Context, sourceFile string, sourcePos int, targetFile string, targetPos int) (bool, []Diagnostic, error) {
+	program, source := l.tryGetProgramAndFile(sourceFile)
+	if source == nil {
+		return false, nil, fmt.Errorf("%w: %s", ErrNoSourceFile, sourceFile)
+	}
+	target := program.GetSourceFile(targetFile)
+	if target == nil {
+		return false, nil, fmt.Errorf("%w: %s", ErrNoSourceFile, targetFile)
+	}
+	sourceNode := astnav.GetTokenAtPosition(source, sourcePos)
+	if sourceNode == nil {
+		return false, nil, fmt.Errorf("%w: %s:%d", ErrNoTokenAtPosition, sourceFile, sourcePos)
+	}
+	targetNode := astnav.GetTokenAtPosition(target, targetPos)
+	if targetNode == nil {
+		return false, nil, fmt.Errorf("%w: %s:%d", ErrNoTokenAtPosition, targetFile, targetPos)
+	}
+
+	checker, done := program.GetTypeCheckerForFile(ctx, source)
+	defer done()
+	sourceType := checker.GetTypeAtLocation(sourceNode)
+	targetType := checker.GetTypeAtLocation(targetNode)
+	var diagnostics []*ast.Diagnostic
+	if checker.CheckTypeAssignableTo(sourceType, targetType, sourceNode, &diagnostics) {
+		return true, nil, nil
+	}
+
+	diagnosticMaps := newDiagnosticMaps()
 	for _, diagnostic := range diagnostics {
 		diagnosticMaps.addDiagnostic(diagnostic, l)
 	}
-	return diagnosticMaps.getDiagnostics()
+	return false, diagnosticMaps.getDiagnostics(), nil
 }
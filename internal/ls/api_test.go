@@ -0,0 +1,2798 @@
+package ls_test
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/bundled"
+	"github.com/microsoft/typescript-go/internal/compiler"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/ls"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/project"
+	"github.com/microsoft/typescript-go/internal/testutil/projecttestutil"
+	"github.com/microsoft/typescript-go/internal/tspath"
+	"gotest.tools/v3/assert"
+)
+
+func TestGetDiagnosticsByFile(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	files := map[string]any{
+		"/src/tsconfig.json": "{}",
+		"/src/a.ts":          "export const a: number = 'a';\nexport const b: string = 1;\n",
+		"/src/b.ts":          "import { Point } from \"./c\";\nexport const p: Point = { x: 1 };\n",
+		"/src/c.ts":          "export interface Point {\n    x: number;\n    y: number;\n}\n",
+		"/src/d.ts":          "export const d = 1;\n",
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	diagnosticsByFile, err := languageService.GetDiagnosticsByFile(ctx)
+	assert.NilError(t, err)
+	describe := make(map[string][]string)
+	for fileName, diagnostics := range diagnosticsByFile {
+		for _, diagnostic := range diagnostics {
+			assert.Equal(t, diagnostic.FileName, fileName)
+			describe[fileName] = append(describe[fileName], fmt.Sprintf("%d %d %s", diagnostic.Id, diagnostic.Code, diagnostic.Message))
+		}
+	}
+	// Related information is grouped with the file it is in, and files without diagnostics are left out.
+	assert.DeepEqual(t, describe, map[string][]string{
+		"/src/a.ts": {
+			"1 2322 Type 'string' is not assignable to type 'number'.",
+			"2 2322 Type 'number' is not assignable to type 'string'.",
+		},
+		"/src/b.ts": {"3 2741 Property 'y' is missing in type '{ x: number; }' but required in type 'Point'."},
+		"/src/c.ts": {"4 2728 'y' is declared here."},
+	})
+	assert.DeepEqual(t, diagnosticsByFile["/src/b.ts"][0].RelatedInformation, []ls.DiagnosticId{diagnosticsByFile["/src/c.ts"][0].Id})
+
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = languageService.GetDiagnosticsByFile(cancelledCtx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestIsTypeAssignableTo(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	a := `interface Point {
+    x: number;
+    y: number;
+}
+declare const point: Point;
+declare const onlyX: { x: number };
+declare const stringX: { x: string; y: number };
+declare const num: number;
+declare const str: string;
+`
+	b := "declare const point3: { x: number; y: number; z: number };\n"
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/a.ts":          a,
+		"/src/b.ts":          b,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	isAssignable := func(sourceFile string, source string, targetFile string, target string) (bool, []ls.Diagnostic) {
+		sourcePos := strings.Index(files[sourceFile].(string), source)
+		targetPos := strings.Index(files[targetFile].(string), target)
+		assignable, diagnostics, err := languageService.IsTypeAssignableTo(ctx, sourceFile, sourcePos, targetFile, targetPos)
+		assert.NilError(t, err)
+		return assignable, diagnostics
+	}
+
+	// describe returns the code and message of each diagnostic with the ids it references.
+	describe := func(diagnostics []ls.Diagnostic) []string {
+		var result []string
+		for _, diagnostic := range diagnostics {
+			result = append(result, fmt.Sprintf("%d %d %s %v %v", diagnostic.Id, diagnostic.Code, diagnostic.Message, diagnostic.MessageChain, diagnostic.RelatedInformation))
+		}
+		return result
+	}
+
+	assignable, diagnostics := isAssignable("/src/a.ts", "point:", "/src/a.ts", "onlyX")
+	assert.Assert(t, assignable)
+	assert.Equal(t, len(diagnostics), 0)
+	assignable, diagnostics = isAssignable("/src/b.ts", "point3", "/src/a.ts", "point:")
+	assert.Assert(t, assignable)
+	assert.Equal(t, len(diagnostics), 0)
+
+	assignable, diagnostics = isAssignable("/src/a.ts", "num:", "/src/a.ts", "str:")
+	assert.Assert(t, !assignable)
+	assert.DeepEqual(t, describe(diagnostics), []string{"1 2322 Type 'number' is not assignable to type 'string'. [] []"})
+	assert.Equal(t, a[diagnostics[0].StartPos:diagnostics[0].EndPos], "num")
+
+	// A missing property is reported with the declaration of the property as related information.
+	assignable, diagnostics = isAssignable("/src/a.ts", "onlyX", "/src/a.ts", "point:")
+	assert.Assert(t, !assignable)
+	assert.DeepEqual(t, describe(diagnostics), []string{
+		"1 2741 Property 'y' is missing in type '{ x: number; }' but required in type 'Point'. [] [2]",
+		"2 2728 'y' is declared here. [] []",
+	})
+	assert.Equal(t, a[diagnostics[1].StartPos:diagnostics[1].EndPos], "y")
+
+	// An incompatible property is explained by the message chain.
+	assignable, diagnostics = isAssignable("/src/a.ts", "stringX", "/src/a.ts", "point:")
+	assert.Assert(t, !assignable)
+	assert.DeepEqual(t, describe(diagnostics), []string{
+		"1 2322 Type '{ x: string; y: number; }' is not assignable to type 'Point'. [2] []",
+		"2 2326 Types of property 'x' are incompatible. [3] []",
+		"3 2322 Type 'string' is not assignable to type 'number'. [] []",
+	})
+
+	_, _, err := languageService.IsTypeAssignableTo(ctx, "/src/missing.ts", 0, "/src/a.ts", 0)
+	assert.ErrorIs(t, err, ls.ErrNoSourceFile)
+	_, _, err = languageService.IsTypeAssignableTo(ctx, "/src/a.ts", 0, "/src/missing.ts", 0)
+	assert.ErrorIs(t, err, ls.ErrNoSourceFile)
+}
+
+func TestGetTouchingToken(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := "foo.bar(baz);\nlet  x = 1;\n"
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/a.ts":          content,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	tests := []struct {
+		name       string
+		position   int
+		preferLeft bool
+		expected   string
+	}{
+		{name: "inside a name", position: 1, expected: "foo"},
+		{name: "inside a name preferring left", position: 1, preferLeft: true, expected: "foo"},
+		{name: "name then punctuation", position: 3, expected: "."},
+		{name: "name then punctuation preferring left", position: 3, preferLeft: true, expected: "foo"},
+		{name: "punctuation then name", position: 4, expected: "bar"},
+		{name: "punctuation then name preferring left", position: 4, preferLeft: true, expected: "."},
+		{name: "punctuation then punctuation", position: 12, expected: ";"},
+		{name: "punctuation then punctuation preferring left", position: 12, preferLeft: true, expected: ")"},
+		{name: "name then whitespace", position: 17},
+		{name: "name then whitespace preferring left", position: 17, preferLeft: true, expected: "let"},
+		{name: "whitespace", position: 18, preferLeft: true},
+	}
+	for _, test := range tests {
+		info, err := languageService.GetTouchingToken(ctx, "/src/a.ts", test.position, test.preferLeft)
+		if test.expected == "" {
+			assert.ErrorIs(t, err, ls.ErrNoTokenAtPosition, test.name)
+			continue
+		}
+		assert.NilError(t, err, test.name)
+		assert.Equal(t, info.Text, test.expected, test.name)
+		assert.Equal(t, content[info.StartPos:info.EndPos], test.expected, test.name)
+	}
+}
+
+func TestGetSyntacticDiagnostics(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	files := map[string]any{
+		"/src/tsconfig.json": "{}",
+		"/src/a.ts":          "let x: number = 'a';\nlet y = ;\n",
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	// The type error on `x` is only reported by the checker.
+	diagnostics, err := languageService.GetSyntacticDiagnostics(ctx, "/src/a.ts")
+	assert.NilError(t, err)
+	assert.Equal(t, len(diagnostics), 1)
+	assert.Equal(t, diagnostics[0].Code, int32(1109))
+	assert.Equal(t, diagnostics[0].Start.Line, int64(1))
+
+	_, err = languageService.GetSyntacticDiagnostics(ctx, "/src/missing.ts")
+	assert.ErrorIs(t, err, ls.ErrNoSourceFile)
+}
+
+func TestGetDiagnosticsUnusedExpectError(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := "// @ts-expect-error\nlet x: number = 1;\n// @ts-expect-error\nlet y: number = 'y';\n"
+	files := map[string]any{
+		"/src/tsconfig.json": "{}",
+		"/src/a.ts":          content,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	// Only the first directive is unused; the second suppresses the error on `y`.
+	var diagnostics []ls.Diagnostic
+	for _, diagnostic := range languageService.GetDiagnostics(ctx) {
+		if diagnostic.FileName == "/src/a.ts" {
+			diagnostics = append(diagnostics, diagnostic)
+		}
+	}
+	assert.Equal(t, len(diagnostics), 1)
+	assert.Equal(t, diagnostics[0].Code, int32(2578))
+	assert.Assert(t, diagnostics[0].IsDirectiveRelated)
+	assert.Equal(t, diagnostics[0].CodeString, "TS2578")
+	assert.Equal(t, diagnostics[0].MessageTemplate, "Unused '@ts-expect-error' directive.")
+	assert.Equal(t, diagnostics[0].StartPos, 0)
+	assert.Equal(t, diagnostics[0].EndPos, len("// @ts-expect-error"))
+	assert.Equal(t, diagnostics[0].Start.Line, int64(0))
+}
+
+func TestGetGlobalSymbols(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	files := map[string]any{
+		"/src/tsconfig.json": "{}",
+		"/src/a.ts":          "export {};\ndeclare global { var myGlobalVar: number; }\n",
+		"/src/umd.d.ts":      "export declare function parse(): void;\nexport as namespace myUmdLib;\n",
+		"/src/script.ts":     "function myScriptFunction() {}\n",
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	symbols, err := languageService.GetGlobalSymbols(ctx, "my")
+	assert.NilError(t, err)
+	assert.Equal(t, len(symbols), 3)
+	assert.DeepEqual(t, *symbols[0], ls.SymbolInformation{Name: "myGlobalVar", Kind: ls.ScriptElementKindVariableElement, FileName: "/src/a.ts"})
+	assert.DeepEqual(t, *symbols[1], ls.SymbolInformation{Name: "myScriptFunction", Kind: ls.ScriptElementKindFunctionElement, FileName: "/src/script.ts"})
+	assert.DeepEqual(t, *symbols[2], ls.SymbolInformation{Name: "myUmdLib", Kind: ls.ScriptElementKindModuleElement, FileName: "/src/umd.d.ts", IsUMDGlobal: true})
+
+	symbols, err = languageService.GetGlobalSymbols(ctx, "Array")
+	assert.NilError(t, err)
+	assert.Assert(t, len(symbols) > 0)
+	assert.Equal(t, symbols[0].Name, "Array")
+	assert.Assert(t, strings.HasPrefix(symbols[0].FileName, "bundled:///libs/"))
+}
+
+func TestGetAliasedSymbol(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := `import { value, renamed } from "./mid";
+import { loop } from "./loop1";
+value + renamed;
+loop;
+const local = 1;
+local;
+`
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/a.ts":          content,
+		"/src/real.ts":       "export const value = 1;\n",
+		"/src/mid.ts":        "export { value } from \"./real\";\nexport { value as renamed } from \"./real\";\n",
+		"/src/loop1.ts":      "export { loop } from \"./loop2\";\n",
+		"/src/loop2.ts":      "export { loop } from \"./loop1\";\n",
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	getAliasedSymbol := func(text string) *ast.Symbol {
+		t.Helper()
+		symbol, err := languageService.GetAliasedSymbol(ctx, "/src/a.ts", strings.Index(content, text))
+		assert.NilError(t, err)
+		assert.Assert(t, symbol != nil)
+		return symbol
+	}
+
+	// Both the import and the re-export are resolved.
+	for _, text := range []string{"value +", "renamed;"} {
+		symbol := getAliasedSymbol(text)
+		assert.Equal(t, symbol.Name, "value")
+		assert.Equal(t, symbol.Flags&ast.SymbolFlagsAlias, ast.SymbolFlagsNone)
+		assert.Equal(t, ast.GetSourceFileOfNode(symbol.ValueDeclaration).FileName(), "/src/real.ts")
+	}
+	// A circular chain of re-exports stops at an alias.
+	symbol := getAliasedSymbol("loop;")
+	assert.Assert(t, symbol.Flags&ast.SymbolFlagsAlias != 0)
+	// A symbol that is not an alias is returned as is.
+	symbol = getAliasedSymbol("local;")
+	assert.Equal(t, symbol.Name, "local")
+	assert.Equal(t, symbol.Flags&ast.SymbolFlagsAlias, ast.SymbolFlagsNone)
+}
+
+func TestGetRefactorsAddOrRemoveBraces(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := "const f = (x: number) => x + 1;\nconst g = (x: number) => ({ a: x });\nconst h = (x: number) => {\n    // Doubles x.\n    return x * 2;\n};\nconst i = (x: number) => { x; return x; };\n"
+	files := map[string]any{
+		"/src/tsconfig.json": "{}",
+		"/src/a.ts":          content,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	getEdit := func(name string, actionName string) string {
+		position := strings.Index(content, name+" = ") + len(name+" = ")
+		textRange := core.NewTextRange(position, position)
+		refactors, err := languageService.GetRefactors(ctx, "/src/a.ts", textRange)
+		assert.NilError(t, err)
+		refactor := findRefactor(refactors, "Add or remove braces in an arrow function")
+		assert.Assert(t, refactor != nil)
+		assert.Equal(t, len(refactor.Actions), 1)
+		assert.Equal(t, refactor.Actions[0].Name, actionName)
+		info, err := languageService.GetRefactorEdits(ctx, "/src/a.ts", textRange, refactor.Name, actionName)
+		assert.NilError(t, err)
+		edits := (*info.Edits.Changes)["file:///src/a.ts"]
+		assert.Equal(t, len(edits), 1)
+		return edits[0].NewText
+	}
+
+	assert.Equal(t, getEdit("f", "Add braces to arrow function"), "{\n    return x + 1;\n}")
+	assert.Equal(t, getEdit("g", "Add braces to arrow function"), "{\n    return { a: x };\n}")
+	assert.Equal(t, getEdit("h", "Remove braces from arrow function"), "/* Doubles x. */ x * 2")
+
+	// A body with more than a return statement cannot lose its braces.
+	position := strings.Index(content, "i = ") + len("i = ")
+	refactors, err := languageService.GetRefactors(ctx, "/src/a.ts", core.NewTextRange(position, position))
+	assert.NilError(t, err)
+	assert.Assert(t, findRefactor(refactors, "Add or remove braces in an arrow function") == nil)
+	_, err = languageService.GetRefactorEdits(ctx, "/src/a.ts", core.NewTextRange(0, 0), "Add or remove braces in an arrow function", "Remove braces from arrow function")
+	assert.ErrorIs(t, err, ls.ErrRefactorNotApplicable)
+}
+
+func TestGetRefactorsConvertModuleSyntax(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := "const a = require(\"m\"), { b, c: d } = require(\"n\");\nimport * as e from \"o\";\n"
+	files := map[string]any{
+		"/src/tsconfig.json": "{}",
+		"/src/a.ts":          content,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	getEdit := func(position int, actionName string) string {
+		textRange := core.NewTextRange(position, position)
+		refactors, err := languageService.GetRefactors(ctx, "/src/a.ts", textRange)
+		assert.NilError(t, err)
+		refactor := findRefactor(refactors, "Convert module syntax")
+		assert.Assert(t, refactor != nil)
+		assert.Equal(t, len(refactor.Actions), 1)
+		assert.Equal(t, refactor.Actions[0].Name, actionName)
+		info, err := languageService.GetRefactorEdits(ctx, "/src/a.ts", textRange, refactor.Name, actionName)
+		assert.NilError(t, err)
+		edits := (*info.Edits.Changes)["file:///src/a.ts"]
+		assert.Equal(t, len(edits), 1)
+		return edits[0].NewText
+	}
+
+	assert.Equal(t, getEdit(0, "Convert to ES module syntax"), "import a from \"m\";\nimport { b, c as d } from \"n\";")
+	assert.Equal(t, getEdit(strings.Index(content, "import"), "Convert to CommonJS syntax"), "const e = require(\"o\");")
+
+	_, err := languageService.GetRefactorEdits(ctx, "/src/a.ts", core.NewTextRange(0, 0), "Convert module syntax", "Convert to CommonJS syntax")
+	assert.ErrorIs(t, err, ls.ErrRefactorNotApplicable)
+}
+
+func TestGetRefactorsConvertExport(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/a.ts":          "export default class Foo {}\n",
+		"/src/b.ts":          "import Foo from \"./a\";\nexport { default as Bar } from \"./a\";\nnew Foo();\n",
+		"/src/c.ts":          "export function foo() {}\n",
+		"/src/d.ts":          "import { foo } from \"./c\";\nexport { foo } from \"./c\";\nfoo();\n",
+		"/src/get-value.ts":  "export default function () { return 1; }\n",
+		"/src/f.ts":          "import g, * as ns from \"./get-value\";\ng();\nns.default();\n",
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	getEdits := func(fileName string, actionName string) map[string]string {
+		textRange := core.NewTextRange(len("export "), len("export "))
+		refactors, err := languageService.GetRefactors(ctx, fileName, textRange)
+		assert.NilError(t, err)
+		refactor := findRefactor(refactors, "Convert export")
+		assert.Assert(t, refactor != nil)
+		assert.Equal(t, len(refactor.Actions), 1)
+		assert.Equal(t, refactor.Actions[0].Name, actionName)
+		info, err := languageService.GetRefactorEdits(ctx, fileName, textRange, refactor.Name, actionName)
+		assert.NilError(t, err)
+		result := map[string]string{}
+		for uri, edits := range *info.Edits.Changes {
+			fileName := strings.TrimPrefix(string(uri), "file://")
+			result[fileName] = applyTextEdits(files[fileName].(string), edits)
+		}
+		return result
+	}
+
+	assert.DeepEqual(t, getEdits("/src/a.ts", "Convert default export to named export"), map[string]string{
+		"/src/a.ts": "export class Foo {}\n",
+		"/src/b.ts": "import { Foo } from \"./a\";\nexport { Foo as Bar } from \"./a\";\nnew Foo();\n",
+	})
+	assert.DeepEqual(t, getEdits("/src/c.ts", "Convert named export to default export"), map[string]string{
+		"/src/c.ts": "export default function foo() {}\n",
+		"/src/d.ts": "import foo from \"./c\";\nexport { default as foo } from \"./c\";\nfoo();\n",
+	})
+	// An anonymous default export is named after its file.
+	assert.DeepEqual(t, getEdits("/src/get-value.ts", "Convert default export to named export"), map[string]string{
+		"/src/get-value.ts": "export function getValue() { return 1; }\n",
+		"/src/f.ts":         "import * as ns from \"./get-value\";\nimport { getValue as g } from \"./get-value\";\ng();\nns.getValue();\n",
+	})
+}
+
+func TestGetMatchingBrackets(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := "let a: Array<Array<number>> = [];\nif (a < b && c > d) { f(\"(\"); }\nconst x = <div>{a}</div>;\n"
+	files := map[string]any{
+		"/src/tsconfig.json": `{"compilerOptions": {"jsx": "preserve"}}`,
+		"/src/a.tsx":         content,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.tsx")
+
+	getMatches := func(position int) []string {
+		ranges, err := languageService.GetMatchingBrackets(ctx, "/src/a.tsx", position)
+		assert.NilError(t, err)
+		var result []string
+		for _, textRange := range ranges {
+			result = append(result, fmt.Sprintf("%s@%d", content[textRange.StartPos:textRange.EndPos], textRange.StartPos))
+		}
+		return result
+	}
+
+	assert.DeepEqual(t, getMatches(strings.Index(content, "<")), []string{"<@12", ">@26"})
+	assert.DeepEqual(t, getMatches(strings.Index(content, ">")), []string{"<@18", ">@25"})
+	assert.DeepEqual(t, getMatches(strings.Index(content, "<number>>")+len("<number>>")), []string{"<@12", ">@26"})
+	assert.Equal(t, len(getMatches(strings.Index(content, "a < b")+2)), 0)
+	assert.Equal(t, len(getMatches(strings.Index(content, "c > d")+2)), 0)
+	assert.DeepEqual(t, getMatches(strings.Index(content, "{ f")), []string{"{@54", "}@64"})
+	assert.DeepEqual(t, getMatches(strings.Index(content, "(\"(\")")), []string{"(@57", ")@61"})
+	assert.DeepEqual(t, getMatches(strings.Index(content, "(\"(\")")+1), []string{"(@57", ")@61"})
+	assert.Equal(t, len(getMatches(strings.Index(content, "\"(\"")+1)), 0)
+	assert.DeepEqual(t, getMatches(strings.Index(content, "div")+1), []string{"div@77", "div@86"})
+}
+
+func TestGetBreakpointSpan(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := `interface I { a: number }
+function f(a: number, b = 1) {
+    const x = a; let y;
+    if (a > 0) {
+        return x;
+    }
+    for (let i = 0; i < 2; i++) g();
+    if (a > 1) {
+        g(1);
+    } else {
+        g(2);
+    }
+    for (const z of [a]) g(z);
+    try {
+        g(3);
+    } catch {
+        g(4);
+    }
+}
+class C {
+    m() { return 1; }
+}
+declare function h(): void;
+`
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/a.ts":          content,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	tests := []struct {
+		at       string
+		expected string
+	}{
+		// Declarations without code have no breakpoint.
+		{at: "interface"},
+		{at: "declare"},
+		// The signature of a function resolves to the first statement of its body.
+		{at: "function f", expected: "const x = a"},
+		{at: "b = 1", expected: "b = 1"},
+		// Each statement of a line has its own span.
+		{at: "x = a", expected: "const x = a"},
+		{at: "let y"},
+		{at: "if (a", expected: "if (a > 0)"},
+		{at: "{\n        return", expected: "if (a > 0)"},
+		{at: "return x", expected: "return x"},
+		{at: "i < 2", expected: "i < 2"},
+		{at: "g()", expected: "g()"},
+		// The closing brace of a function is a breakpoint of its own.
+		{at: "}\nclass", expected: "}"},
+		{at: "m()", expected: "m() { return 1; }"},
+		{at: "return 1", expected: "return 1"},
+		// Keywords between the parts of a statement, and the whitespace before them, resolve to
+		// the part that follows.
+		{at: "else", expected: "g(2)"},
+		{at: " else", expected: "g(2)"},
+		{at: "of [a]", expected: "[a]"},
+		{at: " of [a]", expected: "[a]"},
+		{at: "catch", expected: "g(4)"},
+		{at: " catch", expected: "g(4)"},
+	}
+	for _, test := range tests {
+		position := strings.Index(content, test.at)
+		span, err := languageService.GetBreakpointSpan(ctx, "/src/a.ts", position)
+		assert.NilError(t, err)
+		if test.expected == "" {
+			assert.Assert(t, span == nil, test.at)
+		} else {
+			assert.Assert(t, span != nil, test.at)
+			assert.Equal(t, content[span.StartPos:span.EndPos], test.expected, test.at)
+		}
+	}
+}
+
+func TestGetNameOrDottedNameSpan(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := `declare const a: any;
+declare const i: number;
+a.b.c;
+a.bb().cc;
+a["key"].d;
+a[i].e;
+this.f;
+namespace N1.N2 {}
+let t: N1.N2;
+1 + 2;
+`
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/a.ts":          content,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	tests := []struct {
+		at       string
+		expected string
+	}{
+		// The span ends with the name at the position.
+		{at: "a.b.c", expected: "a"},
+		{at: "b.c", expected: "a.b"},
+		{at: "c;", expected: "a.b.c"},
+		// Calls end the dotted name.
+		{at: "bb()", expected: "a.bb"},
+		{at: "cc;", expected: "cc"},
+		// Element accesses are only part of it with a literal argument.
+		{at: `"key"`, expected: `a["key"]`},
+		{at: "d;", expected: `a["key"].d`},
+		{at: "i].e", expected: "i"},
+		{at: "e;", expected: "e"},
+		{at: "f;", expected: "this.f"},
+		{at: "N2 {}", expected: "N1.N2"},
+		{at: "N2;", expected: "N1.N2"},
+		{at: "1 + 2"},
+	}
+	for _, test := range tests {
+		position := strings.Index(content, test.at)
+		span, err := languageService.GetNameOrDottedNameSpan(ctx, "/src/a.ts", position, position)
+		assert.NilError(t, err)
+		if test.expected == "" {
+			assert.Assert(t, span == nil, test.at)
+		} else {
+			assert.Assert(t, span != nil, test.at)
+			assert.Equal(t, content[span.StartPos:span.EndPos], test.expected, test.at)
+		}
+	}
+}
+
+func TestGetJsxClosingTag(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{content: "const x = <div>|", expected: "</div>"},
+		{content: "const x = <div>text|", expected: "</div>"},
+		{content: "const x = <A.B>|", expected: "</A.B>"},
+		{content: "const x = <div>|</div>;"},
+		// The inner element is left open: the closing tag belongs to the outer one.
+		{content: "const x = <div><div>|</div>;", expected: "</div>"},
+		{content: "const x = <div><span></span></|", expected: "div>"},
+		{content: "const x = <>|", expected: "</>"},
+		{content: "const x = <>|</>;"},
+		{content: "const x = <><>|</>;", expected: "</>"},
+		{content: "const x = <></|", expected: ">"},
+		{content: "const x = <div></div>|"},
+	}
+	for _, test := range tests {
+		position := strings.Index(test.content, "|")
+		content := strings.Replace(test.content, "|", "", 1)
+		files := map[string]any{
+			"/src/tsconfig.json": `{"compilerOptions": {"jsx": "preserve"}}`,
+			"/src/a.tsx":         content,
+		}
+		ctx, languageService := newTestLanguageService(t, files, "/src/a.tsx")
+
+		info, err := languageService.GetJsxClosingTag(ctx, "/src/a.tsx", position)
+		assert.NilError(t, err)
+		if test.expected == "" {
+			assert.Assert(t, info == nil, test.content)
+		} else {
+			assert.Assert(t, info != nil, test.content)
+			assert.Equal(t, info.NewText, test.expected, test.content)
+		}
+	}
+}
+
+func TestPrepareRename(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := `import def, { default as other, helper } from "./b";
+import { pkgFn } from "pkg";
+const value = { "quoted": 1 };
+value.quoted;
+console.log(value, def, other, helper, pkgFn);
+const s: string = "text";
+outer: for (;;) { break outer; }
+`
+	files := map[string]any{
+		"/src/tsconfig.json":                 `{"compilerOptions": {"module": "esnext", "moduleResolution": "bundler"}}`,
+		"/src/a.ts":                          content,
+		"/src/b.ts":                          "export default function f() {}\nexport function helper() {}\n",
+		"/src/node_modules/pkg/package.json": `{"name": "pkg", "types": "index.d.ts"}`,
+		"/src/node_modules/pkg/index.d.ts":   "export declare function pkgFn(): void;\n",
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	tests := []struct {
+		marker      string
+		placeholder string
+		canRename   bool
+		err         bool
+	}{
+		{marker: "value = {", placeholder: "value", canRename: true},
+		{marker: "helper }", placeholder: "helper", canRename: true},
+		{marker: "quoted\": 1", placeholder: "quoted", canRename: true},
+		{marker: "outer;", placeholder: "outer", canRename: true},
+		// Keywords and punctuation have no name to rename.
+		{marker: "const value"},
+		{marker: "{ \"quoted"},
+		// A string literal that refers to nothing.
+		{marker: "text\""},
+		{marker: "log(", err: true},
+		{marker: "console", err: true},
+		{marker: "default as", err: true},
+		{marker: "./b", err: true},
+		{marker: "pkgFn }", err: true},
+	}
+	for _, test := range tests {
+		position := strings.Index(content, test.marker)
+		assert.Assert(t, position >= 0, test.marker)
+		result, err := languageService.PrepareRename(ctx, "/src/a.ts", position)
+		switch {
+		case test.err:
+			assert.ErrorIs(t, err, ls.ErrCannotRename, test.marker)
+		case test.canRename:
+			assert.NilError(t, err, test.marker)
+			assert.Assert(t, result != nil, test.marker)
+			assert.Equal(t, result.Placeholder, test.placeholder, test.marker)
+			assert.Equal(t, content[result.Range.StartPos:result.Range.EndPos], test.placeholder, test.marker)
+		default:
+			assert.NilError(t, err, test.marker)
+			assert.Assert(t, result == nil, test.marker)
+		}
+	}
+}
+
+func TestGetNavigateTo(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/a.ts":          "export function getDocumentSymbols() {}\nexport class DocumentStore {\n  getDocument() {}\n}\n",
+		"/src/b.ts":          "export const get_document_id = 1;\nexport interface Doc {}\n",
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	getItems := func(query string, maxResults int) []string {
+		items, err := languageService.GetNavigateTo(ctx, query, maxResults)
+		assert.NilError(t, err)
+		var result []string
+		for _, item := range items {
+			result = append(result, fmt.Sprintf("%s:%s:%s:%s", item.Name, item.Kind, item.MatchKind, item.ContainerName))
+		}
+		return result
+	}
+
+	assert.DeepEqual(t, getItems("Doc", 0), []string{
+		"Doc:interface:exact:",
+		"DocumentStore:class:prefix:",
+		"getDocument:method:substring:DocumentStore",
+		"getDocumentSymbols:function:substring:",
+		"get_document_id:const:camelCase:",
+	})
+	assert.DeepEqual(t, getItems("gDS", 0), []string{"getDocumentSymbols:function:camelCase:"})
+	assert.DeepEqual(t, getItems("DocumentStore.getDoc", 0), []string{"getDocument:method:prefix:DocumentStore"})
+	assert.DeepEqual(t, getItems("Doc", 2), []string{"Doc:interface:exact:", "DocumentStore:class:prefix:"})
+	assert.Equal(t, len(getItems("", 0)), 0)
+}
+
+func TestGetProgramFiles(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	files := map[string]any{
+		"/src/tsconfig.json": `{"files": ["a.ts"]}`,
+		"/src/a.ts":          "import { b } from \"./b\";\n",
+		"/src/b.ts":          "/// <reference path=\"c.ts\" />\nexport const b = 1;\n",
+		"/src/c.ts":          "declare const c: number;\n",
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	programFiles, err := languageService.GetProgramFiles(ctx)
+	assert.NilError(t, err)
+	byName := make(map[string]ls.ProgramFileInfo)
+	var hasDefaultLibrary bool
+	for _, file := range programFiles {
+		byName[file.FileName] = file
+		// Other library files are referenced by the default library file.
+		if file.IsDefaultLibrary && file.Reason.Kind == compiler.IncludeReasonKindLibFile {
+			hasDefaultLibrary = true
+		}
+	}
+	assert.Assert(t, hasDefaultLibrary)
+
+	a := byName["/src/a.ts"]
+	assert.Equal(t, a.Reason.Kind, compiler.IncludeReasonKindRootFile)
+	assert.Equal(t, a.Reason.Message, "Part of 'files' list in tsconfig.json")
+	assert.Assert(t, !a.IsDefaultLibrary)
+
+	b := byName["/src/b.ts"]
+	assert.Equal(t, b.Reason.Kind, compiler.IncludeReasonKindImport)
+	assert.Equal(t, b.Reason.ReferencingFile, "/src/a.ts")
+	assert.Equal(t, b.Reason.Specifier, "./b")
+	assert.Equal(t, b.Reason.Range.StartPos, strings.Index(files["/src/a.ts"].(string), "\"./b\""))
+	assert.Equal(t, b.Reason.Message, "Imported via \"./b\" from file '/src/a.ts'")
+
+	c := byName["/src/c.ts"]
+	assert.Equal(t, c.Reason.Kind, compiler.IncludeReasonKindReferenceDirective)
+	assert.Equal(t, c.Reason.ReferencingFile, "/src/b.ts")
+	assert.Equal(t, c.Reason.Specifier, "c.ts")
+}
+
+func TestGetFileIncludeReasons(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	files := map[string]any{
+		"/src/tsconfig.json": `{"files": ["a.ts"]}`,
+		"/src/a.ts":          "import { b } from \"./b\";\n",
+		"/src/b.ts":          "/// <reference path=\"c.ts\" />\nexport const b = 1;\n",
+		"/src/c.ts":          "declare const c: number;\n",
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	reasons, err := languageService.GetFileIncludeReasons(ctx, "/src/c.ts")
+	assert.NilError(t, err)
+	var chain []string
+	for _, reason := range reasons {
+		chain = append(chain, fmt.Sprintf("%s:%s:%s", reason.Kind, reason.ReferencingFile, reason.Specifier))
+	}
+	assert.DeepEqual(t, chain, []string{
+		"RootFile::",
+		"Import:/src/a.ts:./b",
+		"ReferenceDirective:/src/b.ts:c.ts",
+	})
+	directive := reasons[2].Range
+	assert.Equal(t, files["/src/b.ts"].(string)[directive.StartPos:directive.EndPos], "c.ts")
+
+	_, err = languageService.GetFileIncludeReasons(ctx, "/src/missing.ts")
+	assert.ErrorIs(t, err, ls.ErrNoSourceFile)
+}
+
+func TestGetDiagnosticsChunked(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	files := map[string]any{
+		"/src/tsconfig.json": "{}",
+		"/src/a.ts":          "export const a: number = 'a';\n",
+		"/src/b.ts":          "export const b: string = 1;\n",
+		"/src/c.ts":          "export const c = ;\n",
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	result := languageService.GetDiagnosticsChunked(ctx, 1)
+	assert.Assert(t, !result.Partial)
+	assert.DeepEqual(t, result.Diagnostics, languageService.GetDiagnostics(ctx))
+	assert.Equal(t, len(result.Diagnostics), 3)
+
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	result = languageService.GetDiagnosticsChunked(cancelledCtx, 1)
+	assert.Assert(t, result.Partial)
+	assert.Equal(t, len(result.Diagnostics), 0)
+}
+
+func TestGetOutliningSpans(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := `/*
+ * Copyright notice.
+ */
+import { x } from "./x";
+
+// First line of a banner.
+// Second line of a banner.
+function f() {
+    /** Docs spanning
+     * two lines. */
+    const s = ` + "`a\nb${`c\nd`}`" + `;
+    // A single comment.
+    return "one line";
+}
+`
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/a.ts":          content,
+		"/src/x.ts":          "export const x = 1;\n",
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	spans, err := languageService.GetOutliningSpans(ctx, "/src/a.ts")
+	assert.NilError(t, err)
+	var actual []string
+	for _, span := range spans {
+		actual = append(actual, fmt.Sprintf("%s %v %q %q", span.Kind, span.AutoCollapse,
+			content[span.TextSpan.StartPos:span.TextSpan.EndPos], content[span.HintSpan.StartPos:span.HintSpan.EndPos]))
+	}
+	assert.DeepEqual(t, actual, []string{
+		"comment true \"/*\\n * Copyright notice.\\n */\" \"/*\"",
+		"comment false \"// First line of a banner.\\n// Second line of a banner.\" \"// First line of a banner.\"",
+		"comment false \"/** Docs spanning\\n     * two lines. */\" \"/** Docs spanning\"",
+		"code false \"`a\\nb${`c\\nd`}`\" \"`a\"",
+	})
+
+	_, err = languageService.GetOutliningSpans(ctx, "/src/missing.ts")
+	assert.ErrorIs(t, err, ls.ErrNoSourceFile)
+}
+
+func TestFindReferencesInScope(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	files := map[string]any{
+		"/src/tsconfig.json":  `{}`,
+		"/src/a.ts":           "export const value = 1;\nvalue;\n",
+		"/src/pkg/b.ts":       "import { value } from \"../a\";\nvalue;\n",
+		"/src/pkg/sub/c.ts":   "import { value } from \"../../a\";\nvalue;\n",
+		"/src/other/d.ts":     "import { value } from \"../a\";\nvalue;\n",
+		"/src/other/empty.ts": "export {};\n",
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	position := strings.Index(files["/src/a.ts"].(string), "value")
+	referencedFiles := func(scope ls.ReferenceScope) []string {
+		locations, err := languageService.FindReferencesInScope(ctx, "/src/a.ts", position, scope)
+		assert.NilError(t, err)
+		var fileNames []string
+		for _, location := range locations {
+			assert.Equal(t, files[location.FileName].(string)[location.StartPos:location.EndPos], "value")
+			fileNames = append(fileNames, location.FileName)
+		}
+		slices.Sort(fileNames)
+		return slices.Compact(fileNames)
+	}
+
+	assert.DeepEqual(t, referencedFiles(ls.ReferenceScope{Kind: ls.ReferenceScopeProject}), []string{"/src/a.ts", "/src/other/d.ts", "/src/pkg/b.ts", "/src/pkg/sub/c.ts"})
+	assert.DeepEqual(t, referencedFiles(ls.ReferenceScope{Kind: ls.ReferenceScopeFile}), []string{"/src/a.ts"})
+	assert.DeepEqual(t, referencedFiles(ls.ReferenceScope{Kind: ls.ReferenceScopeDirectory, Directory: "/src/pkg"}), []string{"/src/pkg/b.ts", "/src/pkg/sub/c.ts"})
+
+	_, err := languageService.FindReferencesInScope(ctx, "/src/a.ts", position, ls.ReferenceScope{Kind: "workspace"})
+	assert.ErrorContains(t, err, "unknown reference scope")
+}
+
+func TestGetCombinedCodeFix(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := "import { a, b, c } from \"./x\";\nimport { d } from \"./x\";\nfunction f(p: number, q: number) {\n    const unused = 1;\n    return b;\n}\nf(1, 2);\n"
+	files := map[string]any{
+		"/src/tsconfig.json": `{"compilerOptions": {"noUnusedLocals": true, "noUnusedParameters": true}}`,
+		"/src/a.ts":          content,
+		"/src/x.ts":          "export const a = 1, b = 2, c = 3, d = 4;\n",
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	edit, err := languageService.GetCombinedCodeFix(ctx, "/src/a.ts", "unusedIdentifier_delete")
+	assert.NilError(t, err)
+	assert.Equal(t, len(*edit.Changes), 1)
+	assert.Equal(t, applyTextEdits(content, (*edit.Changes)["file:///src/a.ts"]), "import { b } from \"./x\";\nfunction f(p: number) {\n    return b;\n}\nf(1, 2);\n")
+
+	edit, err = languageService.GetCombinedCodeFix(ctx, "/src/a.ts", "unusedIdentifier_prefix")
+	assert.NilError(t, err)
+	assert.Equal(t, applyTextEdits(content, (*edit.Changes)["file:///src/a.ts"]), strings.Replace(strings.Replace(content, "p:", "_p:", 1), "q:", "_q:", 1))
+
+	_, err = languageService.GetCombinedCodeFix(ctx, "/src/a.ts", "unknownFix")
+	assert.ErrorIs(t, err, ls.ErrUnknownFixId)
+}
+
+func TestGetCodeFixesAddOverrideModifier(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := `class Base {
+    m() {}
+    get a() { return 1; }
+    p = 1;
+    static s() {}
+    n() {}
+}
+class Derived extends Base {
+    constructor(public p: number) { super(); }
+    public m() {}
+    get a() { return 2; }
+    static s() {}
+    get n() { return () => {}; }
+}
+`
+	files := map[string]any{
+		"/src/tsconfig.json": `{"compilerOptions": {"noImplicitOverride": true}}`,
+		"/src/a.ts":          content,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	position := strings.Index(content, "public m") + len("public ")
+	fixes, err := languageService.GetCodeFixes(ctx, "/src/a.ts", core.NewTextRange(position, position), nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(fixes), 2)
+	assert.Equal(t, fixes[0].Description, "Add 'override' modifier")
+	assert.Equal(t, fixes[0].FixId, "fixAddOverrideModifier")
+	assert.Equal(t, applyTextEdits(content, (*fixes[0].Changes.Changes)["file:///src/a.ts"]), strings.Replace(content, "public m", "public override m", 1))
+	expected := strings.NewReplacer(
+		"public p", "public override p",
+		"public m", "public override m",
+		"get a() { return 2", "override get a() { return 2",
+		"static s() {}\n    get n", "static override s() {}\n    get n",
+	).Replace(content)
+	assert.Equal(t, fixes[1].Description, "Add all missing 'override' modifiers")
+	assert.Equal(t, applyTextEdits(content, (*fixes[1].Changes.Changes)["file:///src/a.ts"]), expected)
+
+	// The getter does not override the method of the same name.
+	position = strings.Index(content, "get n") + len("get ")
+	fixes, err = languageService.GetCodeFixes(ctx, "/src/a.ts", core.NewTextRange(position, position), nil)
+	assert.NilError(t, err)
+	for _, fix := range fixes {
+		assert.Assert(t, fix.FixName != "fixAddOverrideModifier")
+	}
+
+	edit, err := languageService.GetCombinedCodeFix(ctx, "/src/a.ts", "fixAddOverrideModifier")
+	assert.NilError(t, err)
+	assert.Equal(t, applyTextEdits(content, (*edit.Changes)["file:///src/a.ts"]), expected)
+}
+
+// findRefactor returns the refactor with the given name, or nil if it is not in refactors.
+func findRefactor(refactors []*ls.ApplicableRefactor, name string) *ls.ApplicableRefactor {
+	for _, refactor := range refactors {
+		if refactor.Name == name {
+			return refactor
+		}
+	}
+	return nil
+}
+
+// newTestSession sets up a project with files and opens the files named by openFileNames, with
+// their content in files.
+func newTestSession(t *testing.T, files map[string]any, openFileNames ...string) (context.Context, *project.Session) {
+	t.Helper()
+	session, _ := projecttestutil.Setup(files)
+	ctx := projecttestutil.WithRequestID(context.Background())
+	for _, fileName := range openFileNames {
+		session.DidOpenFile(ctx, lsproto.DocumentUri("file://"+fileName), 1, files[fileName].(string), getLanguageKind(fileName))
+	}
+	return ctx, session
+}
+
+// newTestLanguageService sets up a project with files, opens the files named by openFileNames and
+// returns the language service of the project of the first of them.
+func newTestLanguageService(t *testing.T, files map[string]any, openFileNames ...string) (context.Context, *ls.LanguageService) {
+	t.Helper()
+	ctx, session := newTestSession(t, files, openFileNames...)
+	languageService, err := session.GetLanguageService(ctx, lsproto.DocumentUri("file://"+openFileNames[0]))
+	assert.NilError(t, err)
+	return ctx, languageService
+}
+
+func getLanguageKind(fileName string) lsproto.LanguageKind {
+	switch {
+	case tspath.FileExtensionIs(fileName, tspath.ExtensionTsx):
+		return lsproto.LanguageKindTypeScriptReact
+	case tspath.FileExtensionIs(fileName, tspath.ExtensionJsx):
+		return lsproto.LanguageKindJavaScriptReact
+	case tspath.FileExtensionIsOneOf(fileName, []string{tspath.ExtensionJs, tspath.ExtensionMjs, tspath.ExtensionCjs}):
+		return lsproto.LanguageKindJavaScript
+	}
+	return lsproto.LanguageKindTypeScript
+}
+
+// applyTextEdits applies edits to text, which must be ASCII, one after the other as a client does,
+// which requires them to be in reverse document order.
+func applyTextEdits(text string, edits []*lsproto.TextEdit) string {
+	for _, edit := range edits {
+		lineStarts := []int{0}
+		for i, ch := range text {
+			if ch == '\n' {
+				lineStarts = append(lineStarts, i+1)
+			}
+		}
+		offset := func(position lsproto.Position) int {
+			return lineStarts[position.Line] + int(position.Character)
+		}
+		text = text[:offset(edit.Range.Start)] + edit.NewText + text[offset(edit.Range.End):]
+	}
+	return text
+}
+
+func TestGetEnclosingComment(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := "// line comment\nconst url = \"http://example.com\"; /* block // with slashes */\n/** Docs. */\nfunction f() {}\nconst re = /\\/\\/x/;\n/* unterminated"
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/a.ts":          content,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	tests := []struct {
+		name     string
+		position int
+		kind     ls.CommentKind
+		text     string
+	}{
+		{"line comment", strings.Index(content, "comment"), ls.CommentKindLine, "// line comment"},
+		{"end of line comment", strings.Index(content, "\nconst url"), ls.CommentKindLine, "// line comment"},
+		{"slashes in string", strings.Index(content, "//example"), "", ""},
+		{"slashes in block comment", strings.Index(content, "// with"), ls.CommentKindBlock, "/* block // with slashes */"},
+		{"after block comment", strings.Index(content, "\n/** Docs"), "", ""},
+		{"jsdoc", strings.Index(content, "Docs"), ls.CommentKindJSDoc, "/** Docs. */"},
+		{"slashes in regular expression", strings.Index(content, "\\/\\/x") + 1, "", ""},
+		{"code", strings.Index(content, "function"), "", ""},
+		{"end of unterminated comment", len(content), ls.CommentKindBlock, "/* unterminated"},
+	}
+	for _, tt := range tests {
+		comment, err := languageService.GetEnclosingComment(ctx, "/src/a.ts", tt.position)
+		assert.NilError(t, err)
+		if tt.kind == "" {
+			assert.Assert(t, comment == nil, tt.name)
+			continue
+		}
+		assert.Assert(t, comment != nil, tt.name)
+		assert.Equal(t, comment.Kind, tt.kind, tt.name)
+		assert.Equal(t, content[comment.Range.StartPos:comment.Range.EndPos], tt.text, tt.name)
+	}
+}
+
+func TestGetTodoComments(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := `// TODO: first
+const s = "// TODO: not a comment";
+/*
+ * FIXME handle errors
+ * HACK */
+// TODOLIST is not a marker
+let x; // todo lowercase
+/* note: TODO not at the start */
+`
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/a.ts":          content,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	todo := ls.TodoCommentToken{Text: "TODO", Priority: 0}
+	fixme := ls.TodoCommentToken{Text: "FIXME", Priority: 1}
+	hack := ls.TodoCommentToken{Text: "HACK", Priority: 2}
+	comments, err := languageService.GetTodoComments(ctx, "/src/a.ts", []ls.TodoCommentToken{todo, fixme, hack})
+	assert.NilError(t, err)
+	var messages []string
+	var tokens []ls.TodoCommentToken
+	for _, comment := range comments {
+		assert.Equal(t, content[comment.Range.StartPos:comment.Range.EndPos], comment.Message)
+		messages = append(messages, comment.Message)
+		tokens = append(tokens, comment.Token)
+	}
+	assert.DeepEqual(t, messages, []string{"TODO: first", "FIXME handle errors", "HACK", "todo lowercase"})
+	assert.DeepEqual(t, tokens, []ls.TodoCommentToken{todo, fixme, hack, todo})
+}
+
+func TestGetUnusedExports(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/main.ts":       "import { used, type UsedType } from \"./a\";\nimport def from \"./b\";\nimport * as ns from \"./c\";\nimport { reexported } from \"./reexport\";\nexport const entry = used;\n",
+		"/src/a.ts":          "export const used = 1;\nexport const unused = 2;\nexport interface UsedType {}\nexport function unusedFunction() {}\n",
+		"/src/b.ts":          "export default 1;\nexport class UnusedClass {}\n",
+		"/src/c.ts":          "export const viaNamespace = 1;\n",
+		"/src/reexport.ts":   "export * from \"./d\";\nexport { d2 as renamed } from \"./d\";\n",
+		"/src/d.ts":          "export const reexported = 1;\nexport const d2 = 2;\nexport const d3 = 3;\n",
+		"/src/lazy.ts":       "export const loaded = import(\"./e\");\n",
+		"/src/e.ts":          "export const dynamic = 1;\n",
+		"/src/types.d.ts":    "export declare const declared: number;\n",
+		"/src/cjs.ts":        "const x = 1;\nexport = x;\n",
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/main.ts")
+
+	unusedExports := func(entryPoints ...string) []string {
+		exports, err := languageService.GetUnusedExports(ctx, entryPoints)
+		assert.NilError(t, err)
+		var names []string
+		for _, export := range exports {
+			names = append(names, export.FileName+":"+export.Name)
+		}
+		return names
+	}
+	assert.DeepEqual(t, unusedExports(), []string{
+		"/src/a.ts:unused",
+		"/src/a.ts:unusedFunction",
+		"/src/b.ts:UnusedClass",
+		"/src/d.ts:d3",
+		"/src/lazy.ts:loaded",
+		"/src/reexport.ts:renamed",
+		"/src/main.ts:entry",
+	})
+	assert.DeepEqual(t, unusedExports("/src/main.ts", "/src/reexport.ts", "/src/lazy.ts"), []string{
+		"/src/a.ts:unused",
+		"/src/a.ts:unusedFunction",
+		"/src/b.ts:UnusedClass",
+	})
+}
+
+func TestGetDefinitionPreferSourceDefinition(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	files := map[string]any{
+		"/lib/tsconfig.json":       `{"compilerOptions": {"composite": true, "declarationMap": true, "outDir": "dist"}}`,
+		"/lib/index.ts":            "export function greet(name: string) {\n    return name;\n}\n",
+		"/lib/dist/index.d.ts":     "export declare function greet(name: string): string;\n//# sourceMappingURL=index.d.ts.map",
+		"/lib/dist/index.d.ts.map": `{"version":3,"file":"index.d.ts","sourceRoot":"","sources":["../index.ts"],"names":[],"mappings":"AAAA,wBAAgB,KAAK"}`,
+		"/app/tsconfig.json":       `{"compilerOptions": {"disableSourceOfProjectReferenceRedirect": true}, "references": [{"path": "../lib"}]}`,
+		"/app/main.ts":             "import { greet } from \"../lib/index\";\ngreet(\"world\");\n",
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/app/main.ts")
+
+	position := strings.LastIndex(files["/app/main.ts"].(string), "greet")
+	definitions, err := languageService.GetDefinition(ctx, "/app/main.ts", position)
+	assert.NilError(t, err)
+	assert.Equal(t, len(definitions), 1)
+	assert.Equal(t, definitions[0].FileName, "/lib/dist/index.d.ts")
+
+	languageService.SetUserPreferences(&ls.UserPreferences{PreferGoToSourceDefinition: true})
+	definitions, err = languageService.GetDefinition(ctx, "/app/main.ts", position)
+	assert.NilError(t, err)
+	assert.Equal(t, len(definitions), 1)
+	assert.Equal(t, definitions[0].FileName, "/lib/index.ts")
+	assert.Equal(t, files["/lib/index.ts"].(string)[definitions[0].StartPos:definitions[0].EndPos], "greet")
+	assert.Equal(t, definitions[0].Start, ls.Position{Line: 0, Character: 16})
+}
+
+func TestGetRefactorsExtractSymbol(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := "const scale = 2;\nfunction f(a: number, b: number) {\n    const sum = a + b;\n    return sum * scale;\n}\nclass C {\n    x = 1;\n    m() {\n        return this.x + 1;\n    }\n}\nclass D extends C {\n    constructor() { super(); }\n}\n"
+	files := map[string]any{
+		"/src/tsconfig.json": "{}",
+		"/src/a.ts":          content,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	rangeOf := func(text string) core.TextRange {
+		start := strings.Index(content, text)
+		return core.NewTextRange(start, start+len(text))
+	}
+	getActions := func(text string) map[string]string {
+		refactors, err := languageService.GetRefactors(ctx, "/src/a.ts", rangeOf(text))
+		assert.NilError(t, err)
+		actions := map[string]string{}
+		for _, refactor := range refactors {
+			if refactor.Name == "Extract Symbol" {
+				for _, action := range refactor.Actions {
+					actions[action.Name] = action.NotApplicableReason
+				}
+			}
+		}
+		return actions
+	}
+	applyAction := func(text string, actionName string) string {
+		info, err := languageService.GetRefactorEdits(ctx, "/src/a.ts", rangeOf(text), "Extract Symbol", actionName)
+		assert.NilError(t, err)
+		return applyTextEdits(content, (*info.Edits.Changes)["file:///src/a.ts"])
+	}
+
+	assert.DeepEqual(t, getActions("a + b"), map[string]string{"Extract to function": "", "Extract to constant": ""})
+	assert.Equal(t, applyAction("a + b", "Extract to constant"), strings.Replace(content, "const sum = a + b;", "const newLocal = a + b;\n    const sum = newLocal;", 1))
+	assert.Equal(t, applyAction("sum * scale", "Extract to function"), strings.Replace(content,
+		"return sum * scale;\n}\n",
+		"return newFunction(sum);\n}\n\nfunction newFunction(sum: number) {\n    return sum * scale;\n}\n", 1))
+
+	// Statements declaring a local used after them are not extracted.
+	actions := getActions("const sum = a + b;")
+	assert.Equal(t, len(actions), 1)
+	assert.Assert(t, strings.Contains(actions["Extract to function"], "'sum'"))
+
+	// `this` cannot be passed to a function declared at the top level.
+	actions = getActions("this.x + 1")
+	assert.Assert(t, strings.Contains(actions["Extract to function"], "'this'"))
+	assert.Equal(t, actions["Extract to constant"], "")
+	_, err := languageService.GetRefactorEdits(ctx, "/src/a.ts", rangeOf("this.x + 1"), "Extract Symbol", "Extract to function")
+	assert.ErrorIs(t, err, ls.ErrRefactorNotApplicable)
+	assert.Equal(t, applyAction("this.x + 1", "Extract to constant"), strings.Replace(content,
+		"return this.x + 1;",
+		"const newLocal = this.x + 1;\n        return newLocal;", 1))
+
+	// A span starting in the whitespace before a block is not extracted, and does not fail the
+	// other refactors.
+	assert.DeepEqual(t, getActions(" { super(); }"), map[string]string{})
+}
+
+func TestGetRefactorsInferReturnType(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := `function f(x: number) { return x > 0 ? "a" : 1; }
+const g = <T,>(x: T) => ({ value: x, count: 1 });
+const h = x => x;
+function o(x: string): string;
+function o(x: number): number;
+function o(x: any) { return x; }
+function l() { interface L { a: number } return {} as L; }
+`
+	files := map[string]any{
+		"/src/tsconfig.json": `{ "compilerOptions": { "strict": true, "noImplicitAny": false } }`,
+		"/src/a.ts":          content,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	const refactorName = "Infer function return type"
+	getAction := func(text string) *ls.RefactorAction {
+		position := strings.Index(content, text)
+		refactors, err := languageService.GetRefactors(ctx, "/src/a.ts", core.NewTextRange(position, position))
+		assert.NilError(t, err)
+		refactor := findRefactor(refactors, refactorName)
+		if refactor == nil {
+			return nil
+		}
+		assert.Equal(t, len(refactor.Actions), 1)
+		return refactor.Actions[0]
+	}
+	applyAction := func(text string) string {
+		position := strings.Index(content, text)
+		info, err := languageService.GetRefactorEdits(ctx, "/src/a.ts", core.NewTextRange(position, position), refactorName, refactorName)
+		assert.NilError(t, err)
+		return applyTextEdits(content, (*info.Edits.Changes)["file:///src/a.ts"])
+	}
+
+	assert.Equal(t, getAction("f(").NotApplicableReason, "")
+	assert.Equal(t, applyAction("f("), strings.Replace(content, "f(x: number)", `f(x: number): "a" | 1`, 1))
+	assert.Equal(t, applyAction("(x: T)"), strings.Replace(content, "(x: T)", "(x: T): { value: T; count: number; }", 1))
+	assert.Equal(t, applyAction("x => x"), strings.Replace(content, "x => x", "(x): any => x", 1))
+	assert.Equal(t, applyAction("o(x: any)"), strings.Replace(content, "o(x: any)", "o(x: any): string | number", 1))
+
+	// Positions in the body are not part of the function's signature.
+	assert.Assert(t, getAction("return x >") == nil)
+	// A type declared in the function cannot be named outside of it.
+	assert.Assert(t, getAction("l()").NotApplicableReason != "")
+	_, err := languageService.GetRefactorEdits(ctx, "/src/a.ts", core.NewTextRange(strings.Index(content, "l()"), strings.Index(content, "l()")), refactorName, refactorName)
+	assert.ErrorIs(t, err, ls.ErrRefactorNotApplicable)
+}
+
+func TestGetRefactorsConvertParametersToDestructuredObject(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := `export function f(a: number, b = "x", ...rest: boolean[]) { return a; }
+f(1);
+f(1, "y", true, false);
+const a = 2;
+f(a, undefined);
+export const g = (x: number, y?: string) => x;
+g(1, ...[]);
+class C {
+    constructor(p: number, q: number) {}
+    m(s: string) { return s; }
+}
+new C(1, 2).m("s");
+function h(n: number) { return n; }
+[1].map(h);
+`
+	importingContent := `import { f } from "./a";
+f(3);
+`
+	files := map[string]any{
+		"/src/tsconfig.json": `{ "compilerOptions": { "strict": true } }`,
+		"/src/a.ts":          content,
+		"/src/b.ts":          importingContent,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	const refactorName = "Convert parameters to destructured object"
+	getRefactor := func(text string) *ls.ApplicableRefactor {
+		position := strings.Index(content, text)
+		refactors, err := languageService.GetRefactors(ctx, "/src/a.ts", core.NewTextRange(position, position))
+		assert.NilError(t, err)
+		return findRefactor(refactors, refactorName)
+	}
+	getEdits := func(text string) (map[lsproto.DocumentUri][]*lsproto.TextEdit, error) {
+		position := strings.Index(content, text)
+		info, err := languageService.GetRefactorEdits(ctx, "/src/a.ts", core.NewTextRange(position, position), refactorName, refactorName)
+		if err != nil {
+			return nil, err
+		}
+		return *info.Edits.Changes, nil
+	}
+
+	assert.Equal(t, getRefactor("f(a: number").Actions[0].NotApplicableReason, "")
+	changes, err := getEdits("f(a: number")
+	assert.NilError(t, err)
+	expected := strings.NewReplacer(
+		`f(a: number, b = "x", ...rest: boolean[])`, `f({ a, b = "x", rest = [] }: { a: number; b?: string; rest?: boolean[]; })`,
+		"f(1);", "f({ a: 1 });",
+		`f(1, "y", true, false)`, `f({ a: 1, b: "y", rest: [true, false] })`,
+		"f(a, undefined)", "f({ a, b: undefined })",
+	).Replace(content)
+	assert.Equal(t, applyTextEdits(content, changes["file:///src/a.ts"]), expected)
+	assert.Equal(t, applyTextEdits(importingContent, changes["file:///src/b.ts"]), strings.Replace(importingContent, "f(3)", "f({ a: 3 })", 1))
+
+	changes, err = getEdits("constructor")
+	assert.NilError(t, err)
+	expected = strings.NewReplacer(
+		"constructor(p: number, q: number)", "constructor({ p, q }: { p: number; q: number; })",
+		"new C(1, 2)", "new C({ p: 1, q: 2 })",
+	).Replace(content)
+	assert.Equal(t, applyTextEdits(content, changes["file:///src/a.ts"]), expected)
+
+	changes, err = getEdits("m(s")
+	assert.NilError(t, err)
+	expected = strings.NewReplacer(
+		"m(s: string)", "m({ s }: { s: string; })",
+		`m("s")`, `m({ s: "s" })`,
+	).Replace(content)
+	assert.Equal(t, applyTextEdits(content, changes["file:///src/a.ts"]), expected)
+
+	// Positions in the body are not part of the function's signature.
+	assert.Assert(t, getRefactor("return a") == nil)
+	// Calls with spread arguments cannot be converted.
+	assert.Assert(t, getRefactor("(x: number").Actions[0].NotApplicableReason != "")
+	_, err = getEdits("(x: number")
+	assert.ErrorIs(t, err, ls.ErrRefactorNotApplicable)
+	// Nor can a function passed as a value.
+	assert.Assert(t, getRefactor("h(n").Actions[0].NotApplicableReason != "")
+	_, err = getEdits("h(n")
+	assert.ErrorIs(t, err, ls.ErrRefactorNotApplicable)
+}
+
+func TestGetRefactorsConvertStringOrTemplateLiteral(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := "declare const name: string;\n" +
+		"declare const count: number;\n" +
+		"const greeting = \"Hello \" + name + \"!\";\n" +
+		"const size = 1 + count + \"px\";\n" +
+		"const quoted = 'it\\'s `${name}` ' + name;\n" +
+		"const nested = \"a\" + (name + \"b\") + (count + 1);\n" +
+		"const dollar = \"$\" + \"{x}\";\n" +
+		"const sum = count + 1;\n" +
+		"const template = `Hi ${name}, ${count + 1} items`;\n" +
+		"const counted = `${count} items`;\n"
+	files := map[string]any{
+		"/src/tsconfig.json": `{ "compilerOptions": { "strict": true } }`,
+		"/src/a.ts":          content,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	const refactorName = "Convert string or template literal"
+	getRefactor := func(text string) *ls.ApplicableRefactor {
+		position := strings.Index(content, text)
+		refactors, err := languageService.GetRefactors(ctx, "/src/a.ts", core.NewTextRange(position, position))
+		assert.NilError(t, err)
+		return findRefactor(refactors, refactorName)
+	}
+	applyAction := func(text string, actionName string) string {
+		position := strings.Index(content, text)
+		info, err := languageService.GetRefactorEdits(ctx, "/src/a.ts", core.NewTextRange(position, position), refactorName, actionName)
+		assert.NilError(t, err)
+		return applyTextEdits(content, (*info.Edits.Changes)["file:///src/a.ts"])
+	}
+	toTemplate := func(text string, expected string) {
+		t.Helper()
+		assert.Equal(t, applyAction(text, "Convert to template string"), strings.Replace(content, text, expected, 1))
+	}
+	toConcatenation := func(text string, expected string) {
+		t.Helper()
+		assert.Equal(t, applyAction(text, "Convert to string concatenation"), strings.Replace(content, text, expected, 1))
+	}
+
+	toTemplate(`"Hello " + name + "!"`, "`Hello ${name}!`")
+	// The numeric addition is substituted as a whole.
+	toTemplate(`1 + count + "px"`, "`${1 + count}px`")
+	toTemplate("'it\\'s `${name}` ' + name", "`it's \\`\\${name}\\` ${name}`")
+	toTemplate(`"a" + (name + "b") + (count + 1)`, "`a${name}b${count + 1}`")
+	toTemplate(`"$" + "{x}"`, "`\\${x}`")
+	assert.Assert(t, getRefactor("count + 1;") == nil)
+
+	toConcatenation("`Hi ${name}, ${count + 1} items`", `"Hi " + name + ", " + (count + 1) + " items"`)
+	// The empty head is kept so that the number is concatenated to a string.
+	toConcatenation("`${count} items`", `"" + count + " items"`)
+}
+
+func TestGetRefactorsConvertTypeOnlyImport(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := "import D, { T, C } from \"./m\";\n" +
+		"import { T as T2, v } from \"./m\";\n" +
+		"import * as ns from \"./m\";\n" +
+		"import { T as T3 } from \"./m\";\n" +
+		"import type { C as C2, T as T4 } from \"./m\";\n" +
+		"import { type C as C3, v as v2 } from \"./m\";\n" +
+		"import { v as v3 } from \"./m\";\n" +
+		"const d: D = new C();\n" +
+		"const t: T | T2 | T3 | T4 | ns.T = { a: v };\n" +
+		"new C2();\n" +
+		"new C3(v2);\n" +
+		"export { v3 };\n"
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/m.ts":          "export interface T { a: number }\nexport class C {}\nexport default class D {}\nexport const v = 1;\n",
+		"/src/a.ts":          content,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	const refactorName = "Convert type-only import"
+	getActions := func(text string) []string {
+		position := strings.Index(content, text)
+		refactors, err := languageService.GetRefactors(ctx, "/src/a.ts", core.NewTextRange(position, position))
+		assert.NilError(t, err)
+		var names []string
+		if refactor := findRefactor(refactors, refactorName); refactor != nil {
+			for _, action := range refactor.Actions {
+				names = append(names, action.Name)
+			}
+		}
+		return names
+	}
+	applyAction := func(text string, actionName string, expected string) {
+		t.Helper()
+		assert.DeepEqual(t, getActions(text), []string{actionName})
+		position := strings.Index(content, text)
+		info, err := languageService.GetRefactorEdits(ctx, "/src/a.ts", core.NewTextRange(position, position), refactorName, actionName)
+		assert.NilError(t, err)
+		assert.Equal(t, applyTextEdits(content, (*info.Edits.Changes)["file:///src/a.ts"]), strings.Replace(content, text, expected, 1))
+	}
+	toTypeOnly := func(text string, expected string) {
+		t.Helper()
+		applyAction(text, "Convert to type-only import", expected)
+	}
+	toRegular := func(text string, expected string) {
+		t.Helper()
+		applyAction(text, "Convert to regular import", expected)
+	}
+
+	// Mixed imports are split into type-only imports and an import of the values.
+	toTypeOnly("import D, { T, C } from \"./m\";", "import type D from \"./m\";\nimport type { T } from \"./m\";\nimport { C } from \"./m\";")
+	toTypeOnly("import { T as T2, v } from \"./m\";", "import type { T as T2 } from \"./m\";\nimport { v } from \"./m\";")
+	toTypeOnly("import * as ns from \"./m\";", "import type * as ns from \"./m\";")
+	toTypeOnly("import { T as T3 } from \"./m\";", "import type { T as T3 } from \"./m\";")
+	// Only the bindings used as values stop being type-only.
+	toRegular("import type { C as C2, T as T4 } from \"./m\";", "import { C as C2, type T as T4 } from \"./m\";")
+	toRegular("import { type C as C3, v as v2 } from \"./m\";", "import { C as C3, v as v2 } from \"./m\";")
+	// Exports count as value usages.
+	assert.Assert(t, getActions("import { v as v3 }") == nil)
+}
+
+func TestGetRefactorsSurroundWithStatement(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := `declare function work(n: number): number;
+export function f() {
+    let total = 0;
+    total += work(1);
+    total += work(2);
+    const scoped = work(3);
+    return total + scoped;
+}
+export const g = 1;
+`
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/a.ts":          content,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	const refactorName = "Surround with statement"
+	selection := func(text string) core.TextRange {
+		start := strings.Index(content, text)
+		return core.NewTextRange(start, start+len(text))
+	}
+	getRefactor := func(text string) *ls.ApplicableRefactor {
+		refactors, err := languageService.GetRefactors(ctx, "/src/a.ts", selection(text))
+		assert.NilError(t, err)
+		return findRefactor(refactors, refactorName)
+	}
+
+	statements := "total += work(1);\n    total += work(2);"
+	refactor := getRefactor(statements)
+	assert.Assert(t, refactor != nil)
+	assert.Equal(t, len(refactor.Actions), 3)
+	assert.Equal(t, refactor.Actions[0].NotApplicableReason, "")
+
+	info, err := languageService.GetRefactorEdits(ctx, "/src/a.ts", selection(statements), refactorName, "Surround with try/catch")
+	assert.NilError(t, err)
+	assert.Equal(t, applyTextEdits(content, (*info.Edits.Changes)["file:///src/a.ts"]), strings.Replace(content, statements,
+		"try {\n        total += work(1);\n        total += work(2);\n    } catch (error) {\n    }", 1))
+	assert.DeepEqual(t, info.CursorPosition, &lsproto.Position{Line: 6, Character: 21})
+
+	info, err = languageService.GetRefactorEdits(ctx, "/src/a.ts", selection(statements), refactorName, "Surround with if statement")
+	assert.NilError(t, err)
+	assert.Equal(t, applyTextEdits(content, (*info.Edits.Changes)["file:///src/a.ts"]), strings.Replace(content, statements,
+		"if () {\n        total += work(1);\n        total += work(2);\n    }", 1))
+	assert.DeepEqual(t, info.CursorPosition, &lsproto.Position{Line: 3, Character: 8})
+
+	info, err = languageService.GetRefactorEdits(ctx, "/src/a.ts", selection(statements), refactorName, "Surround with for loop")
+	assert.NilError(t, err)
+	assert.Equal(t, applyTextEdits(content, (*info.Edits.Changes)["file:///src/a.ts"]), strings.Replace(content, statements,
+		"for (let i = 0; i < ; i++) {\n        total += work(1);\n        total += work(2);\n    }", 1))
+	assert.DeepEqual(t, info.CursorPosition, &lsproto.Position{Line: 3, Character: 24})
+
+	// Partial expressions are not statements.
+	assert.Assert(t, getRefactor("work(1)") == nil)
+	// A declaration used after the selection would go out of scope.
+	refactor = getRefactor("const scoped = work(3);")
+	assert.Assert(t, refactor != nil)
+	assert.Equal(t, refactor.Actions[0].NotApplicableReason, "Cannot surround the declaration of 'scoped', which is used outside the selection.")
+	_, err = languageService.GetRefactorEdits(ctx, "/src/a.ts", selection("const scoped = work(3);"), refactorName, "Surround with if statement")
+	assert.ErrorIs(t, err, ls.ErrRefactorNotApplicable)
+	refactor = getRefactor("export const g = 1;")
+	assert.Assert(t, refactor != nil)
+	assert.Equal(t, refactor.Actions[0].NotApplicableReason, "Cannot surround import or export declarations.")
+}
+
+func TestGetRefactorsSplitOrMergeVariableDeclarations(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := "export const a = 1, /* b */ b = 2;\n" +
+		"function f() {\n" +
+		"    let c = 1, // c\n" +
+		"        // d\n" +
+		"        d = 2\n" +
+		"    for (let i = 0, j = 1; i < j; i++) {}\n" +
+		"}\n" +
+		"let e = 1; // e\n" +
+		"// f\n" +
+		"let f2 = 2;\n" +
+		"let g = 3, h = 4;\n" +
+		"const k = 5;\n"
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/a.ts":          content,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	const refactorName = "Split or merge variable declarations"
+	selection := func(text string) core.TextRange {
+		start := strings.Index(content, text)
+		return core.NewTextRange(start, start+len(text))
+	}
+	getActions := func(text string) map[string]string {
+		refactors, err := languageService.GetRefactors(ctx, "/src/a.ts", selection(text))
+		assert.NilError(t, err)
+		actions := map[string]string{}
+		if refactor := findRefactor(refactors, refactorName); refactor != nil {
+			for _, action := range refactor.Actions {
+				actions[action.Name] = action.NotApplicableReason
+			}
+		}
+		return actions
+	}
+	applyAction := func(text string, actionName string, replaced string, expected string) {
+		t.Helper()
+		info, err := languageService.GetRefactorEdits(ctx, "/src/a.ts", selection(text), refactorName, actionName)
+		assert.NilError(t, err)
+		assert.Equal(t, applyTextEdits(content, (*info.Edits.Changes)["file:///src/a.ts"]), strings.Replace(content, replaced, expected, 1))
+	}
+
+	// Comments before a declarator move with it.
+	assert.DeepEqual(t, getActions("a = 1"), map[string]string{"Split into separate declarations": ""})
+	applyAction("a = 1", "Split into separate declarations", "export const a = 1, /* b */ b = 2;",
+		"export const a = 1;\nexport const /* b */ b = 2;")
+	// Comments on the line of a comma stay on the line of the previous statement.
+	applyAction("c = 1", "Split into separate declarations", "let c = 1, // c\n        // d\n        d = 2",
+		"let c = 1 // c\n    // d\n    let d = 2")
+	assert.DeepEqual(t, getActions("j = 1"), map[string]string{"Split into separate declarations": "Cannot split the declarations of a for loop initializer."})
+	_, err := languageService.GetRefactorEdits(ctx, "/src/a.ts", selection("j = 1"), refactorName, "Split into separate declarations")
+	assert.ErrorIs(t, err, ls.ErrRefactorNotApplicable)
+
+	// A statement is merged with the one following it, keeping the comments between them.
+	assert.DeepEqual(t, getActions("e = 1"), map[string]string{"Merge into one declaration": ""})
+	applyAction("e = 1", "Merge into one declaration", "let e = 1; // e\n// f\nlet f2 = 2;",
+		"let e = 1, // e\n    // f\n    f2 = 2;")
+	// Selected statements are merged together.
+	applyAction("let f2 = 2;\nlet g = 3, h = 4;", "Merge into one declaration", "let f2 = 2;\nlet g = 3, h = 4;",
+		"let f2 = 2, g = 3, h = 4;")
+	assert.DeepEqual(t, getActions("g = 3"), map[string]string{"Split into separate declarations": ""})
+	// Declarations of different kinds cannot be merged.
+	assert.DeepEqual(t, getActions("let g = 3, h = 4;\nconst k = 5;"), map[string]string{})
+	assert.DeepEqual(t, getActions("k = 5"), map[string]string{})
+}
+
+func TestGetRefactorsGenerateAccessors(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := `class C {
+    x = 1;
+    _y: string = "";
+    protected z?: boolean;
+    static s = 0;
+    readonly r: number;
+    get a() { return 1; }
+    constructor() {
+        this.r = 1;
+    }
+}
+abstract class D {
+    abstract n: number;
+}
+`
+	files := map[string]any{
+		"/src/tsconfig.json": `{ "compilerOptions": { "strict": false } }`,
+		"/src/a.ts":          content,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	const refactorName = "Generate 'get' and 'set' accessors"
+	getAction := func(text string) *ls.RefactorAction {
+		position := strings.Index(content, text)
+		refactors, err := languageService.GetRefactors(ctx, "/src/a.ts", core.NewTextRange(position, position))
+		assert.NilError(t, err)
+		refactor := findRefactor(refactors, refactorName)
+		if refactor == nil {
+			return nil
+		}
+		assert.Equal(t, len(refactor.Actions), 1)
+		return refactor.Actions[0]
+	}
+	applyAction := func(text string) string {
+		position := strings.Index(content, text)
+		info, err := languageService.GetRefactorEdits(ctx, "/src/a.ts", core.NewTextRange(position, position), refactorName, refactorName)
+		assert.NilError(t, err)
+		return applyTextEdits(content, (*info.Edits.Changes)["file:///src/a.ts"])
+	}
+
+	assert.Equal(t, getAction("x = 1").NotApplicableReason, "")
+	assert.Equal(t, applyAction("x = 1"), strings.Replace(content, "    x = 1;\n", `    private _x = 1;
+    public get x(): number {
+        return this._x;
+    }
+    public set x(value: number) {
+        this._x = value;
+    }
+`, 1))
+	assert.Equal(t, applyAction("_y"), strings.Replace(content, "    _y: string = \"\";\n", `    private _y: string = "";
+    public get y(): string {
+        return this._y;
+    }
+    public set y(value: string) {
+        this._y = value;
+    }
+`, 1))
+	assert.Equal(t, applyAction("protected z"), strings.Replace(content, "    protected z?: boolean;\n", `    protected _z?: boolean;
+    protected get z(): boolean {
+        return this._z;
+    }
+    protected set z(value: boolean) {
+        this._z = value;
+    }
+`, 1))
+	assert.Equal(t, applyAction("static s"), strings.Replace(content, "    static s = 0;\n", `    private static _s = 0;
+    public static get s(): number {
+        return C._s;
+    }
+    public static set s(value: number) {
+        C._s = value;
+    }
+`, 1))
+	expected := strings.Replace(content, "    readonly r: number;\n", `    private _r: number;
+    public get r(): number {
+        return this._r;
+    }
+`, 1)
+	assert.Equal(t, applyAction("readonly r"), strings.Replace(expected, "this.r = 1", "this._r = 1", 1))
+
+	// Positions after the name are not part of the declaration.
+	assert.Assert(t, getAction("1;\n    _y") == nil)
+	// Accessors and abstract properties cannot have accessors generated.
+	assert.Equal(t, getAction("get a").NotApplicableReason, "The member is already an accessor.")
+	assert.Assert(t, getAction("abstract n").NotApplicableReason != "")
+	_, err := languageService.GetRefactorEdits(ctx, "/src/a.ts", core.NewTextRange(strings.Index(content, "abstract n"), strings.Index(content, "abstract n")), refactorName, refactorName)
+	assert.ErrorIs(t, err, ls.ErrRefactorNotApplicable)
+}
+
+func TestGetRefactorsInline(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/globals.d.ts":  "declare const a: number, b: number, c: number;\ndeclare function f(): number;\ndeclare function use(...args: unknown[]): void;\n",
+		"/src/precedence.ts": "const x = a + b;\nuse(x * 2, x.toFixed(), -x, 1 - x, x - 1, { x });\nexport {};\n",
+		"/src/unary.ts":      "const n = -a;\nuse(-n, n ** 2, 2 ** n);\nconst l = a || b;\nuse(l ?? c);\nexport {};\n",
+		"/src/effects.ts":    "const v = f();\nuse(v);\nconst w = f();\nuse(w, w);\nconst o = { p: 1 };\no.p;\nconst g = f();\nfor (;;) use(g);\nexport {};\n",
+		"/src/shadow.ts":     "const s = a;\nfunction h(a: string) { return s; }\nexport {};\n",
+		"/src/functions.ts": "function mul(p: number, q: number) { return p * q; }\nuse(mul(a + b, c), mul(1, 2).toFixed());\n" +
+			"function twice(p: number) { return p + p; }\ntwice(f());\n" +
+			"function log() { use(); return 1; }\nlog();\nexport {};\n",
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/precedence.ts")
+
+	const refactorName = "Inline"
+	position := func(fileName string, text string) core.TextRange {
+		start := strings.Index(files[fileName].(string), text)
+		return core.NewTextRange(start, start)
+	}
+	getAction := func(fileName string, text string, actionName string) *ls.RefactorAction {
+		t.Helper()
+		refactors, err := languageService.GetRefactors(ctx, fileName, position(fileName, text))
+		assert.NilError(t, err)
+		if refactor := findRefactor(refactors, refactorName); refactor != nil {
+			for _, action := range refactor.Actions {
+				if action.Name == actionName {
+					return action
+				}
+			}
+		}
+		return nil
+	}
+	applyAction := func(fileName string, text string, actionName string) string {
+		t.Helper()
+		info, err := languageService.GetRefactorEdits(ctx, fileName, position(fileName, text), refactorName, actionName)
+		assert.NilError(t, err)
+		return applyTextEdits(files[fileName].(string), (*info.Edits.Changes)[lsproto.DocumentUri("file://"+fileName)])
+	}
+
+	// The initializer is parenthesized where its operator would not bind as the variable did.
+	assert.Equal(t, applyAction("/src/precedence.ts", "x = a", "Inline variable"),
+		"use((a + b) * 2, (a + b).toFixed(), -(a + b), 1 - (a + b), a + b - 1, { x: a + b });\nexport {};\n")
+	// The action is available from a reference as well.
+	assert.Equal(t, applyAction("/src/unary.ts", "n ** 2", "Inline variable"),
+		"use(-(-a), (-a) ** 2, 2 ** -a);\nconst l = a || b;\nuse(l ?? c);\nexport {};\n")
+	assert.Equal(t, applyAction("/src/unary.ts", "l = a", "Inline variable"),
+		"const n = -a;\nuse(-n, n ** 2, 2 ** n);\nuse((a || b) ?? c);\nexport {};\n")
+
+	// An initializer with side effects can only replace a single reference evaluated once.
+	assert.Equal(t, applyAction("/src/effects.ts", "v = f", "Inline variable"), strings.Replace(files["/src/effects.ts"].(string), "const v = f();\nuse(v);", "use(f());", 1))
+	assert.Equal(t, getAction("/src/effects.ts", "w = f", "Inline variable").NotApplicableReason, "Cannot inline an expression with side effects that is used more than once.")
+	assert.Equal(t, getAction("/src/effects.ts", "g = f", "Inline variable").NotApplicableReason, "Cannot inline an expression with side effects into a function or loop.")
+	// An object literal starting a statement is parenthesized.
+	assert.Equal(t, applyAction("/src/effects.ts", "o = {", "Inline variable"), strings.Replace(files["/src/effects.ts"].(string), "const o = { p: 1 };\no.p;", "({ p: 1 }).p;", 1))
+	assert.Equal(t, getAction("/src/shadow.ts", "s = a", "Inline variable").NotApplicableReason, "Cannot inline an expression whose names refer to other declarations at a reference.")
+
+	// Calls are replaced with the returned expression, with the arguments in place of the parameters.
+	assert.Equal(t, applyAction("/src/functions.ts", "mul(p", "Inline function"), strings.Replace(files["/src/functions.ts"].(string),
+		"function mul(p: number, q: number) { return p * q; }\nuse(mul(a + b, c), mul(1, 2).toFixed());", "use((a + b) * c, (1 * 2).toFixed());", 1))
+	assert.Equal(t, getAction("/src/functions.ts", "twice(f", "Inline function").NotApplicableReason, "Cannot inline a call with an argument with side effects that is not used exactly once.")
+	assert.Equal(t, getAction("/src/functions.ts", "log() {", "Inline function").NotApplicableReason, "Only a function whose body is a single return statement can be inlined.")
+	assert.Assert(t, getAction("/src/functions.ts", "use(mul", "Inline variable") == nil)
+}
+
+func TestGetRefactorsConvertArrowFunctionOrFunctionExpression(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := "declare const f: any;\ndeclare function use(...args: unknown[]): void;\n" +
+		"export const add = (a: number, b: number) => a + b;\n" +
+		"const inc = async x => x + 1;\n" +
+		"class C {\n    m() {\n        return () => this;\n    }\n}\n" +
+		"const g = function* () { yield 1; };\n" +
+		"const h = function fact(n: number): number { return n ? n * fact(n - 1) : 1; };\n" +
+		"use(f || function (x) { return x; });\n" +
+		"const t = function () { return this; };\n"
+	tsxContent := "const id = function <T>(x: T) { return x; };\n"
+	files := map[string]any{
+		"/src/tsconfig.json": `{ "compilerOptions": { "strict": false, "jsx": "preserve" } }`,
+		"/src/a.ts":          content,
+		"/src/b.tsx":         tsxContent,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts", "/src/b.tsx")
+
+	const refactorName = "Convert arrow function or function expression"
+	position := func(text string) core.TextRange {
+		start := strings.Index(content, text)
+		return core.NewTextRange(start, start)
+	}
+	getActions := func(text string) map[string]string {
+		t.Helper()
+		refactors, err := languageService.GetRefactors(ctx, "/src/a.ts", position(text))
+		assert.NilError(t, err)
+		actions := map[string]string{}
+		if refactor := findRefactor(refactors, refactorName); refactor != nil {
+			for _, action := range refactor.Actions {
+				actions[action.Name] = action.NotApplicableReason
+			}
+		}
+		return actions
+	}
+	applyAction := func(text string, actionName string, replaced string, expected string) {
+		t.Helper()
+		info, err := languageService.GetRefactorEdits(ctx, "/src/a.ts", position(text), refactorName, actionName)
+		assert.NilError(t, err)
+		assert.Equal(t, applyTextEdits(content, (*info.Edits.Changes)["file:///src/a.ts"]), strings.Replace(content, replaced, expected, 1))
+	}
+
+	assert.DeepEqual(t, getActions("add ="), map[string]string{"Convert to anonymous function": "", "Convert to named function": ""})
+	applyAction("add =", "Convert to named function", "export const add = (a: number, b: number) => a + b;",
+		"export function add(a: number, b: number) {\n    return a + b;\n}")
+	applyAction("(a: number", "Convert to anonymous function", "(a: number, b: number) => a + b",
+		"function (a: number, b: number) {\n    return a + b;\n}")
+	// The parameter of an arrow function is parenthesized.
+	applyAction("x =>", "Convert to anonymous function", "async x => x + 1", "async function (x) {\n    return x + 1;\n}")
+	// Positions in the body are not part of the function.
+	assert.DeepEqual(t, getActions("x + 1"), map[string]string{})
+
+	// An arrow function uses `this` of the function enclosing it.
+	assert.DeepEqual(t, getActions("() => this"), map[string]string{
+		"Convert to anonymous function": "Cannot convert an arrow function that uses 'this', 'super', 'arguments' or 'new.target' of its enclosing function.",
+	})
+	assert.DeepEqual(t, getActions("function () { return this"), map[string]string{
+		"Convert to arrow function": "Cannot convert a function that uses 'this', 'super', 'arguments' or 'new.target' to an arrow function.",
+		"Convert to named function": "",
+	})
+
+	assert.DeepEqual(t, getActions("function* ()"), map[string]string{
+		"Convert to arrow function": "Cannot convert a generator to an arrow function.",
+		"Convert to named function": "",
+	})
+	applyAction("g =", "Convert to named function", "const g = function* () { yield 1; };", "function* g() { yield 1; }")
+	assert.DeepEqual(t, getActions("function fact"), map[string]string{
+		"Convert to arrow function": "Cannot convert a function expression that refers to its own name to an arrow function.",
+		"Convert to named function": "Cannot convert a function expression that refers to its own name to a function of another name.",
+	})
+
+	// An arrow function is parenthesized where it would not bind as tightly.
+	applyAction("function (x)", "Convert to arrow function", "function (x) { return x; }", "((x) => { return x; })")
+
+	// A type parameter is followed by a comma in TSX files, where `<T>` would start an element.
+	info, err := languageService.GetRefactorEdits(ctx, "/src/b.tsx", core.NewTextRange(strings.Index(tsxContent, "function"), strings.Index(tsxContent, "function")), refactorName, "Convert to arrow function")
+	assert.NilError(t, err)
+	assert.Equal(t, applyTextEdits(tsxContent, (*info.Edits.Changes)["file:///src/b.tsx"]), "const id = <T,>(x: T) => { return x; };\n")
+}
+
+func TestGetNavigationBarItems(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := `import { readFile } from "fs";
+function overloaded(x: string): void;
+function overloaded(x: number): void;
+function overloaded(x: any) {}
+namespace N {
+    export const a = 1;
+}
+namespace N {
+    export function b() {}
+}
+class C {
+    static m() {}
+    m() {}
+    constructor(private p: number) {}
+}
+const handler = () => {
+    function inner() {}
+};
+`
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/a.ts":          content,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	items, err := languageService.GetNavigationBarItems(ctx, "/src/a.ts")
+	assert.NilError(t, err)
+	var lines []string
+	for _, item := range items {
+		var children []string
+		for _, child := range item.ChildItems {
+			children = append(children, fmt.Sprintf("%s(%s,%d)", child.Text, child.Kind, len(child.Spans)))
+		}
+		lines = append(lines, fmt.Sprintf("%d %s %s %d: %s", item.Indent, item.Text, item.Kind, len(item.Spans), strings.Join(children, " ")))
+	}
+	assert.DeepEqual(t, lines, []string{
+		`0 "a" module 1: C(class,1) handler(const,1) N(module,2) overloaded(function,3) readFile(alias,1)`,
+		"1 C class 1: constructor(constructor,1) m(method,1) m(method,1) p(property,1)",
+		"1 handler const 1: inner(function,1)",
+		"1 N module 2: a(const,1) b(function,1)",
+	})
+
+	_, err = languageService.GetNavigationBarItems(ctx, "/src/missing.ts")
+	assert.ErrorIs(t, err, ls.ErrNoSourceFile)
+}
+
+func TestGetBoundSourceFile(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := `/**
+ * Docs.
+ */
+function f() {
+    return 1;
+}
+class C {
+    m() {}
+}
+`
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/a.ts":          content,
+		"/other/b.ts":        content,
+	}
+	ctx, session := newTestSession(t, files, "/src/a.ts")
+
+	for _, fileName := range []string{"/src/a.ts", "/other/b.ts"} {
+		languageService, err := session.GetBindOnlyLanguageService(ctx, lsproto.DocumentUri("file://"+fileName))
+		assert.NilError(t, err)
+
+		file, err := languageService.GetBoundSourceFile(ctx, fileName)
+		assert.NilError(t, err)
+		locals := slices.Sorted(file.Locals.Keys())
+		assert.DeepEqual(t, locals, []string{"C", "f"})
+
+		symbols, err := languageService.ProvideDocumentSymbols(ctx, lsproto.DocumentUri("file://"+fileName))
+		assert.NilError(t, err)
+		assert.Assert(t, symbols.DocumentSymbols != nil)
+		var names []string
+		for _, symbol := range *symbols.DocumentSymbols {
+			names = append(names, symbol.Name)
+		}
+		assert.DeepEqual(t, names, []string{"f", "C"})
+
+		spans, err := languageService.GetOutliningSpans(ctx, fileName)
+		assert.NilError(t, err)
+		assert.Equal(t, len(spans), 1)
+	}
+
+	// The file of an unloaded project is parsed on its own, without a program.
+	languageService, err := session.GetBindOnlyLanguageService(ctx, "file:///other/b.ts")
+	assert.NilError(t, err)
+	assert.Assert(t, languageService.GetProgram() == nil)
+	_, err = languageService.GetBoundSourceFile(ctx, "/src/a.ts")
+	assert.ErrorIs(t, err, ls.ErrNoSourceFile)
+}
+
+func TestGetDiagnosticsPage(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := "const a: string = 1;\nconst b: { p: { q: string } } = { p: { q: 1 } };\nc;\nd;\ne;\n"
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/a.ts":          content,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	all := languageService.GetDiagnostics(ctx)
+	// The error of b has related information.
+	assert.Assert(t, len(all) > 5)
+	var pages []*ls.DiagnosticsPage
+	var paged []ls.Diagnostic
+	token := ""
+	for {
+		page, err := languageService.GetDiagnosticsPage(ctx, 2, token)
+		assert.NilError(t, err)
+		assert.Equal(t, page.TotalCount, 5)
+		pages = append(pages, page)
+		// Every entry referenced from a page is in the page.
+		ids := map[ls.DiagnosticId]bool{}
+		for _, diagnostic := range page.Diagnostics {
+			ids[diagnostic.Id] = true
+		}
+		for _, diagnostic := range page.Diagnostics {
+			for _, id := range slices.Concat(diagnostic.MessageChain, diagnostic.RelatedInformation) {
+				assert.Assert(t, ids[id], "entry %d referenced by %d is not in the page", id, diagnostic.Id)
+			}
+		}
+		paged = append(paged, page.Diagnostics...)
+		if page.ContinuationToken == "" {
+			break
+		}
+		token = page.ContinuationToken
+	}
+	assert.Equal(t, len(pages), 3)
+	// Pages use the ids of GetDiagnostics.
+	assert.DeepEqual(t, paged, all)
+
+	page, err := languageService.GetDiagnosticsPage(ctx, 0, "")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, page.Diagnostics, all)
+	assert.Equal(t, page.ContinuationToken, "")
+
+	_, err = languageService.GetDiagnosticsPage(ctx, 2, "2-0")
+	assert.ErrorIs(t, err, ls.ErrInvalidContinuationToken)
+}
+
+func TestGetDiagnosticsDelta(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := "const a: string = 1;\n"
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/a.ts":          content,
+	}
+	ctx, session := newTestSession(t, files, "/src/a.ts")
+	languageService, err := session.GetLanguageService(ctx, "file:///src/a.ts")
+	assert.NilError(t, err)
+
+	first, err := languageService.GetDiagnosticsDelta(ctx, "/src/a.ts", "")
+	assert.NilError(t, err)
+	assert.Assert(t, !first.Unchanged)
+	assert.Equal(t, len(first.Diagnostics), 1)
+
+	unchanged, err := languageService.GetDiagnosticsDelta(ctx, "/src/a.ts", first.ResultId)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, unchanged, &ls.DiagnosticDelta{ResultId: first.ResultId, Unchanged: true})
+
+	// An edit after the diagnostics leaves them unchanged, unlike one that adds a diagnostic.
+	edit := func(version int32, text string) *ls.LanguageService {
+		session.DidChangeFile(ctx, "file:///src/a.ts", version, []lsproto.TextDocumentContentChangePartialOrWholeDocument{{
+			Partial: &lsproto.TextDocumentContentChangePartial{Text: text, Range: lsproto.Range{Start: lsproto.Position{Line: 1, Character: 0}, End: lsproto.Position{Line: 1, Character: 0}}},
+		}})
+		languageService, err := session.GetLanguageService(ctx, "file:///src/a.ts")
+		assert.NilError(t, err)
+		return languageService
+	}
+	languageService = edit(2, "// comment\n")
+	delta, err := languageService.GetDiagnosticsDelta(ctx, "/src/a.ts", first.ResultId)
+	assert.NilError(t, err)
+	assert.Assert(t, delta.Unchanged)
+	languageService = edit(3, "b;\n")
+	delta, err = languageService.GetDiagnosticsDelta(ctx, "/src/a.ts", first.ResultId)
+	assert.NilError(t, err)
+	assert.Assert(t, !delta.Unchanged)
+	assert.Assert(t, delta.ResultId != first.ResultId)
+	assert.Equal(t, len(delta.Diagnostics), 2)
+}
+
+func TestGetDiagnosticsRelatedInformation(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	// The scripts share the global scope, so x is declared twice.
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/a.ts":          "let x = 1;\ny;\nlet y = 0;\n",
+		"/src/b.ts":          "\nlet x = 2;\n",
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	diagnostics := map[ls.DiagnosticId]ls.Diagnostic{}
+	for _, diagnostic := range languageService.GetDiagnostics(ctx) {
+		diagnostics[diagnostic.Id] = diagnostic
+	}
+	// related returns the related information of the diagnostic with the given code in the given
+	// file.
+	related := func(code int32, fileName string) []ls.Diagnostic {
+		for _, diagnostic := range diagnostics {
+			if diagnostic.Code == code && diagnostic.FileName == fileName {
+				var result []ls.Diagnostic
+				for _, id := range diagnostic.RelatedInformation {
+					result = append(result, diagnostics[id])
+				}
+				return result
+			}
+		}
+		t.Fatalf("no diagnostic %d in %s", code, fileName)
+		return nil
+	}
+
+	// Each declaration of x points at the other.
+	duplicate := related(2451, "/src/a.ts")
+	assert.Equal(t, len(duplicate), 1)
+	assert.Equal(t, duplicate[0].FileName, "/src/b.ts")
+	assert.Equal(t, duplicate[0].StartPos, 5)
+	assert.Equal(t, duplicate[0].EndPos, 6)
+	assert.Equal(t, duplicate[0].Start, ls.Position{Line: 1, Character: 4})
+	assert.Equal(t, duplicate[0].Label, "Also declared here")
+	duplicate = related(2451, "/src/b.ts")
+	assert.Equal(t, len(duplicate), 1)
+	assert.Equal(t, duplicate[0].FileName, "/src/a.ts")
+	assert.Equal(t, duplicate[0].StartPos, 4)
+
+	// A use before the declaration points at the declaration.
+	usedBefore := related(2448, "/src/a.ts")
+	assert.Equal(t, len(usedBefore), 1)
+	assert.Equal(t, usedBefore[0].FileName, "/src/a.ts")
+	assert.Equal(t, usedBefore[0].Start, ls.Position{Line: 2, Character: 4})
+	assert.Equal(t, usedBefore[0].Label, "Declared here")
+}
+func TestGetMoveToFileEdits(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := `import { helper } from "./util";
+
+const base = 1;
+// Doubles x.
+function double(x: number) { return helper(x) * 2 + base; }
+export function triple(x: number) { return x * 3; }
+export const result = double(2);
+`
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/a.ts":          content,
+		"/src/util.ts":       "export function helper(x: number) { return x; }\n",
+		"/src/main.ts":       "import { triple, result } from \"./a\";\nconsole.log(triple(1), result);\n",
+		"/src/other.ts":      "import { triple } from \"./a\";\ntriple(2);\n",
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	start := strings.Index(content, "function double")
+	end := strings.Index(content, "export const result")
+	edit, err := languageService.GetMoveToFileEdits(ctx, "/src/a.ts", core.NewTextRange(start, end-1), "/src/moved.ts")
+	assert.NilError(t, err)
+	changes := *edit.Changes
+	assert.Equal(t, len(changes), 4)
+	assert.Equal(t, applyTextEdits("", changes["file:///src/moved.ts"]), `import { helper } from "./util";
+import { base } from "./a";
+
+// Doubles x.
+export function double(x: number) { return helper(x) * 2 + base; }
+export function triple(x: number) { return x * 3; }
+`)
+	assert.Equal(t, applyTextEdits(content, changes["file:///src/a.ts"]), `import { helper } from "./util";
+import { double } from "./moved";
+
+export const base = 1;
+export const result = double(2);
+`)
+	assert.Equal(t, applyTextEdits(files["/src/main.ts"].(string), changes["file:///src/main.ts"]), "import { result } from \"./a\";\nimport { triple } from \"./moved\";\nconsole.log(triple(1), result);\n")
+	assert.Equal(t, applyTextEdits(files["/src/other.ts"].(string), changes["file:///src/other.ts"]), "import { triple } from \"./moved\";\ntriple(2);\n")
+
+	// The refactor names the new file after the first moved declaration.
+	refactors, err := languageService.GetRefactors(ctx, "/src/a.ts", core.NewTextRange(start, end-1))
+	assert.NilError(t, err)
+	assert.Assert(t, slices.ContainsFunc(refactors, func(refactor *ls.ApplicableRefactor) bool { return refactor.Name == "Move to a new file" }))
+	info, err := languageService.GetRefactorEdits(ctx, "/src/a.ts", core.NewTextRange(start, end-1), "Move to a new file", "Move to a new file")
+	assert.NilError(t, err)
+	assert.Assert(t, (*info.Edits.Changes)["file:///src/double.ts"] != nil)
+
+	_, err = languageService.GetMoveToFileEdits(ctx, "/src/a.ts", core.NewTextRange(0, end), "/src/moved.ts")
+	assert.ErrorIs(t, err, ls.ErrRefactorNotApplicable)
+	_, err = languageService.GetMoveToFileEdits(ctx, "/src/a.ts", core.NewTextRange(start, start), "/src/util.ts")
+	assert.ErrorIs(t, err, ls.ErrFileAlreadyExists)
+}
+
+func TestJsxAttributeFeatures(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := `declare namespace JSX { interface Element {} }
+interface Props {
+    /** The title of the card. */
+    title: string;
+}
+function Card(props: Props) { return props.title as any; }
+const a = <Card title="a">text</Card>;
+const b = <Card title="b" />;
+`
+	files := map[string]any{
+		"/src/tsconfig.json": `{"compilerOptions": {"jsx": "preserve"}}`,
+		"/src/a.tsx":         content,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.tsx")
+
+	position := strings.Index(content, `title="a"`) + 1
+	quickInfo, err := languageService.GetQuickInfo(ctx, "/src/a.tsx", position)
+	assert.NilError(t, err)
+	assert.Equal(t, quickInfo.Text, "(property) Props.title: string")
+	assert.Equal(t, quickInfo.Documentation, "The title of the card.")
+
+	ranges, err := languageService.GetSelectionRanges(ctx, "/src/a.tsx", position)
+	assert.NilError(t, err)
+	var selections []string
+	for _, textRange := range ranges[:4] {
+		selections = append(selections, content[textRange.StartPos:textRange.EndPos])
+	}
+	assert.DeepEqual(t, selections, []string{`title`, `title="a"`, `<Card title="a">`, `<Card title="a">text</Card>`})
+
+	line := strings.Count(content[:position], "\n")
+	highlights, err := languageService.ProvideDocumentHighlights(ctx, "file:///src/a.tsx", lsproto.Position{
+		Line:      uint32(line),
+		Character: uint32(position - strings.LastIndex(content[:position], "\n") - 1),
+	})
+	assert.NilError(t, err)
+	var highlighted []int
+	for _, highlight := range *highlights.DocumentHighlights {
+		highlighted = append(highlighted, int(highlight.Range.Start.Line))
+	}
+	// The declaration of the prop, its use in Card and both attributes.
+	assert.DeepEqual(t, highlighted, []int{3, 5, 6, 7})
+}
+
+func TestGetAutoImportEdit(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	files := map[string]any{
+		"/src/tsconfig.json": `{"compilerOptions": {"module": "esnext", "moduleResolution": "bundler"}}`,
+		"/src/b.ts":          "export const b1 = 1;\nexport const b2 = 2;\nexport interface B3 {}\n",
+		"/src/c.ts":          "export const c1 = 1;\n",
+		"/src/z.ts":          "export const z1 = 1;\n",
+	}
+	tests := []struct {
+		content    string
+		symbolName string
+		fromModule string
+		expected   string
+	}{
+		{
+			content:    "import { b1 } from \"./b\";\nimport { z1 } from \"./z\";\n",
+			symbolName: "c1",
+			fromModule: "./c",
+			expected:   "import { b1 } from \"./b\";\nimport { c1 } from \"./c\";\nimport { z1 } from \"./z\";\n",
+		},
+		{
+			content:    "import { b1 } from \"./b.js\";\n",
+			symbolName: "c1",
+			fromModule: "/src/c.ts",
+			expected:   "import { b1 } from \"./b.js\";\nimport { c1 } from \"./c.js\";\n",
+		},
+		{
+			content:    "import { b1 } from \"./b\";\n",
+			symbolName: "b2",
+			fromModule: "./b",
+			expected:   "import { b1, b2 } from \"./b\";\n",
+		},
+		{
+			content:    "import { type b2, b1 } from \"./b\";\n",
+			symbolName: "b2",
+			fromModule: "./b",
+			expected:   "import { b2, b1 } from \"./b\";\n",
+		},
+		{
+			content:    "import type { b1, B3 } from \"./b\";\n",
+			symbolName: "b1",
+			fromModule: "./b",
+			expected:   "import { b1, type B3 } from \"./b\";\n",
+		},
+	}
+	// The importing files are on disk, so that they belong to the configured project with c.ts.
+	for i, test := range tests {
+		files[fmt.Sprintf("/src/a%d.ts", i)] = test.content
+	}
+	content := "import { b1 } from \"./b\";\n"
+	files["/src/imported.ts"] = content
+	ctx, session := newTestSession(t, files)
+
+	for i, test := range tests {
+		uri := lsproto.DocumentUri(fmt.Sprintf("file:///src/a%d.ts", i))
+		fileName := fmt.Sprintf("/src/a%d.ts", i)
+		session.DidOpenFile(ctx, uri, 1, test.content, lsproto.LanguageKindTypeScript)
+		languageService, err := session.GetLanguageService(ctx, uri)
+		assert.NilError(t, err)
+		edit, err := languageService.GetAutoImportEdit(ctx, fileName, test.symbolName, test.fromModule)
+		assert.NilError(t, err)
+		assert.Assert(t, edit != nil, test.content)
+		assert.Equal(t, applyTextEdits(test.content, []*lsproto.TextEdit{edit}), test.expected)
+	}
+
+	session.DidOpenFile(ctx, "file:///src/imported.ts", 1, content, lsproto.LanguageKindTypeScript)
+	languageService, err := session.GetLanguageService(ctx, "file:///src/imported.ts")
+	assert.NilError(t, err)
+	edit, err := languageService.GetAutoImportEdit(ctx, "/src/imported.ts", "b1", "./b")
+	assert.NilError(t, err)
+	assert.Assert(t, edit == nil)
+	_, err = languageService.GetAutoImportEdit(ctx, "/src/imported.ts", "missing", "./b")
+	assert.ErrorIs(t, err, ls.ErrExportNotFound)
+}
+
+func TestGetEncodedSemanticClassifications(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := `class C<T> { value: T; }
+interface I {}
+type A = I;
+enum E { One }
+namespace N { export const x = 1; }
+namespace Empty { export type T = number; }
+import Alias = N;
+const c: C<I> = new C();
+N.x;
+`
+	files := map[string]any{
+		"/src/tsconfig.json": "{}",
+		"/src/a.ts":          content,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	classifications, err := languageService.GetEncodedSemanticClassifications(ctx, "/src/a.ts", core.NewTextRange(0, len(content)))
+	assert.NilError(t, err)
+	assert.Equal(t, len(classifications.Spans)%3, 0)
+	var classified []string
+	for i := 0; i < len(classifications.Spans); i += 3 {
+		start, length := classifications.Spans[i], classifications.Spans[i+1]
+		classified = append(classified, fmt.Sprintf("%s:%d", content[start:start+length], classifications.Spans[i+2]))
+	}
+	assert.DeepEqual(t, classified, []string{
+		"C:11", "T:15", "T:15", "I:13", "A:16", "I:13", "E:12", "N:14", "Empty:14", "T:16", "Alias:14", "N:14",
+		"C:11", "I:13", "C:11", "N:14",
+	})
+
+	line := strings.Index(content, "const c")
+	classifications, err = languageService.GetEncodedSemanticClassifications(ctx, "/src/a.ts", core.NewTextRange(line, line+len("const c")))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, classifications.Spans, []int{})
+}
+
+func TestGetCompletionsReplacementSpan(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	declarations := "declare const fooBar: number;\ndeclare const fooBaz: number;\ndeclare const x: { ab: number; \"a-b\": number };\n"
+	tests := []struct {
+		fileName        string
+		content         string
+		filterByPrefix  bool
+		replacementSpan string
+		names           []string
+		// entryReplacementSpans are the texts replaced by the entries with their own replacement span.
+		entryReplacementSpans map[string]string
+	}{
+		// Mid-identifier completions replace the whole identifier, not only the part before the position.
+		{content: "fo|oBar;", replacementSpan: "fooBar"},
+		{content: "fo|oBar;", filterByPrefix: true, replacementSpan: "fooBar", names: []string{"fooBar", "fooBaz"}},
+		{content: "fooBar|", replacementSpan: "fooBar"},
+		{content: "fooBar|", filterByPrefix: true, replacementSpan: "fooBar", names: []string{"fooBar"}},
+		// After a dot there is no identifier to replace, but inserting an element access replaces the dot.
+		{content: "x.|", names: []string{"a-b", "ab"}, entryReplacementSpans: map[string]string{"a-b": "."}},
+		{content: "x.a|b;", filterByPrefix: true, replacementSpan: "ab", names: []string{"a-b", "ab"}, entryReplacementSpans: map[string]string{"a-b": "."}},
+		// Right after the `<` of a closing tag, the replacement span starts after the position.
+		{fileName: "/src/a.tsx", content: "const e = <div><|/div>;", filterByPrefix: true, replacementSpan: "div", names: []string{"div"}},
+	}
+	for _, test := range tests {
+		fileName := cmp.Or(test.fileName, "/src/a.ts")
+		position := len(declarations) + strings.Index(test.content, "|")
+		content := declarations + strings.Replace(test.content, "|", "", 1)
+		files := map[string]any{
+			"/src/tsconfig.json": `{ "compilerOptions": { "jsx": "preserve" } }`,
+			fileName:             content,
+		}
+		ctx, languageService := newTestLanguageService(t, files, fileName)
+
+		info, err := languageService.GetCompletions(ctx, fileName, position, test.filterByPrefix)
+		assert.NilError(t, err)
+		if test.replacementSpan == "" {
+			assert.Assert(t, info.OptionalReplacementSpan == nil, test.content)
+		} else {
+			assert.Assert(t, info.OptionalReplacementSpan != nil, test.content)
+			assert.Equal(t, content[info.OptionalReplacementSpan.StartPos:info.OptionalReplacementSpan.EndPos], test.replacementSpan, test.content)
+		}
+		var names []string
+		entryReplacementSpans := map[string]string{}
+		for _, entry := range info.Entries {
+			if test.names == nil || slices.Contains(test.names, entry.Name) {
+				names = append(names, entry.Name)
+			}
+			if entry.ReplacementSpan != nil {
+				entryReplacementSpans[entry.Name] = content[entry.ReplacementSpan.StartPos:entry.ReplacementSpan.EndPos]
+			}
+		}
+		if test.names != nil {
+			assert.DeepEqual(t, names, test.names)
+		}
+		if test.entryReplacementSpans == nil {
+			test.entryReplacementSpans = map[string]string{}
+		}
+		assert.DeepEqual(t, entryReplacementSpans, test.entryReplacementSpans)
+	}
+}
+
+func TestGetCompletionsJSDoc(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	tests := []struct {
+		content        string
+		filterByPrefix bool
+		contains       []string
+		names          []string
+	}{
+		// Tag names are completed after '@', and filtered by the name being typed.
+		{content: "/**\n * @|\n */\nfunction f() {}", contains: []string{"param", "returns", "deprecated"}},
+		{content: "/** @ret| */\nfunction f() {}", filterByPrefix: true, names: []string{"returns"}},
+		// Parameter names are completed after '@param'.
+		{content: "/**\n * @param |\n */\nfunction f(alpha: number, beta: string) {}", names: []string{"alpha", "beta"}},
+		// Type names are completed in type expressions, whether or not they are closed.
+		{content: "interface StringBox {}\n/** @param {Stri|} a */\nfunction f(a) {}", contains: []string{"String", "StringBox"}},
+		{content: "interface StringBox {}\n/** @param {Str| */\nfunction f(a) {}", contains: []string{"String", "StringBox"}},
+	}
+	for _, test := range tests {
+		position := strings.Index(test.content, "|")
+		content := strings.Replace(test.content, "|", "", 1)
+		files := map[string]any{
+			"/src/tsconfig.json": `{}`,
+			"/src/a.ts":          content,
+		}
+		ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+		info, err := languageService.GetCompletions(ctx, "/src/a.ts", position, test.filterByPrefix)
+		assert.NilError(t, err)
+		var names []string
+		for _, entry := range info.Entries {
+			names = append(names, entry.Name)
+		}
+		for _, name := range test.contains {
+			assert.Assert(t, slices.Contains(names, name), "%s: missing %s", test.content, name)
+		}
+		if test.names != nil {
+			assert.DeepEqual(t, names, test.names)
+		}
+	}
+}
+
+func TestGetCompletionsForModuleExports(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := "const shared = 1;\nconst localValue = 2;\n"
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/a.ts":          content,
+		"/src/m.ts":          "export const exportedValue = 1;\nexport const shared = 2;\n",
+		"/src/ambient.d.ts":  "declare module \"ambient\" {\n    export const ambientValue: number;\n}\n",
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	getEntries := func() map[string][]*ls.CompletionEntry {
+		info, err := languageService.GetCompletions(ctx, "/src/a.ts", len(content), false /*filterByPrefix*/)
+		assert.NilError(t, err)
+		entries := map[string][]*ls.CompletionEntry{}
+		for _, entry := range info.Entries {
+			entries[entry.Name] = append(entries[entry.Name], entry)
+		}
+		return entries
+	}
+
+	entries := getEntries()
+	assert.Assert(t, entries["exportedValue"] == nil)
+	assert.Equal(t, len(entries["localValue"]), 1)
+
+	languageService.SetUserPreferences(&ls.UserPreferences{IncludeCompletionsForModuleExports: core.TSTrue})
+	entries = getEntries()
+	assert.Equal(t, len(entries["exportedValue"]), 1)
+	exported := entries["exportedValue"][0]
+	assert.Equal(t, exported.Source, "/src/m")
+	assert.Assert(t, exported.HasAction)
+	assert.Equal(t, len(entries["ambientValue"]), 1)
+	assert.Equal(t, entries["ambientValue"][0].Source, "ambient")
+	// Symbols in scope rank above the exports of other modules, and shadow exports of the same name.
+	local := entries["localValue"][0]
+	assert.Assert(t, !local.HasAction)
+	assert.Assert(t, local.SortText < exported.SortText, "%s %s", local.SortText, exported.SortText)
+	assert.Equal(t, len(entries["shared"]), 1)
+	assert.Equal(t, entries["shared"][0].Source, "")
+
+	details, err := languageService.GetCompletionEntryDetails(ctx, "/src/a.ts", len(content), "exportedValue", exported.Source)
+	assert.NilError(t, err)
+	assert.Equal(t, len(details.AdditionalTextEdits), 1)
+	assert.Equal(t, details.AdditionalTextEdits[0].NewText, "import { exportedValue } from \"./m\";\n")
+}
+
+func TestForEachNode(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := "function f(a: number) {\n    return a;\n}\nconst b = f(1);\n"
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/a.ts":          content,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	var identifiers []string
+	err := languageService.ForEachNode(ctx, "/src/a.ts", []ast.Kind{ast.KindIdentifier}, func(node ls.NodeInfo) bool {
+		assert.Equal(t, content[node.StartPos:node.EndPos], node.Text)
+		identifiers = append(identifiers, fmt.Sprintf("%s %v", node.Text, node.ParentKind))
+		return true
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, identifiers, []string{
+		"f KindFunctionDeclaration",
+		"a KindParameter",
+		"a KindReturnStatement",
+		"b KindVariableDeclaration",
+		"f KindCallExpression",
+	})
+
+	var visited []ast.Kind
+	err = languageService.ForEachNode(ctx, "/src/a.ts", nil, func(node ls.NodeInfo) bool {
+		visited = append(visited, node.Kind)
+		return node.Kind != ast.KindParameter
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, visited, []ast.Kind{ast.KindFunctionDeclaration, ast.KindIdentifier, ast.KindParameter})
+
+	err = languageService.ForEachNode(ctx, "/src/missing.ts", nil, func(ls.NodeInfo) bool { return true })
+	assert.ErrorIs(t, err, ls.ErrNoSourceFile)
+}
+
+func TestGetFixAllMissingImports(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := "import { b1 } from \"./b\";\n\nc2(c1, b1, b2, b1, dup);\nconst x: B3 = c1;\n"
+	files := map[string]any{
+		"/src/tsconfig.json": `{"compilerOptions": {"module": "esnext", "moduleResolution": "bundler"}}`,
+		"/src/a.ts":          content,
+		"/src/b.ts":          "export const b1 = 1;\nexport const b2 = 2;\nexport interface B3 {}\n",
+		"/src/c.ts":          "export const c1 = 1;\nexport function c2(...args: unknown[]) {}\n",
+		"/src/d.ts":          "export const dup = 1;\n",
+		"/src/e.ts":          "export const dup = 2;\n",
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	fix, err := languageService.GetFixAllMissingImports(ctx, "/src/a.ts")
+	assert.NilError(t, err)
+	assert.Equal(t, len(*fix.Edit.Changes), 1)
+	assert.Equal(t, applyTextEdits(content, (*fix.Edit.Changes)["file:///src/a.ts"]), "import { b1, b2, B3 } from \"./b\";\nimport { c1, c2 } from \"./c\";\n\nc2(c1, b1, b2, b1, dup);\nconst x: B3 = c1;\n")
+	assert.DeepEqual(t, fix.Ambiguous, []*ls.AmbiguousImport{{Name: "dup", Modules: []string{"/src/d.ts", "/src/e.ts"}}})
+
+	_, err = languageService.GetFixAllMissingImports(ctx, "/src/missing.ts")
+	assert.ErrorIs(t, err, ls.ErrNoSourceFile)
+}
+
+func TestGetRefactorsGenerateConstructor(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	files := map[string]any{
+		"/src/tsconfig.json": `{ "compilerOptions": { "strict": false } }`,
+		"/src/point.ts": `class Point {
+    x: number;
+    #y: string;
+    z?: boolean;
+    w = 1;
+    static s: number;
+    m() { return 1; }
+}
+`,
+		"/src/derived.ts": `class Base<T> {
+    constructor(a: T, b?: number) {}
+}
+class Derived extends Base<string> {
+    a: boolean;
+}
+`,
+		"/src/existing.ts": `class Existing extends Base<string> {
+    x: number;
+    y: string;
+    constructor(x: number) {
+        super("", x);
+        this.x = x;
+    }
+}
+class Empty {
+    n: number;
+    constructor() {}
+}
+`,
+		"/src/props.ts": `class Props {
+    private readonly id: number;
+    protected name: string;
+    count: number;
+    other: number;
+    constructor(id, name: string, count: number, other: number) {
+        this.id = id;
+        this.name = name;
+        this.count = count;
+        this.other = other + 1;
+    }
+}
+`,
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/point.ts")
+
+	const refactorName = "Generate constructor"
+	getActions := func(fileName string, text string) map[string]string {
+		position := strings.Index(files[fileName].(string), text)
+		refactors, err := languageService.GetRefactors(ctx, fileName, core.NewTextRange(position, position))
+		assert.NilError(t, err)
+		refactor := findRefactor(refactors, refactorName)
+		if refactor == nil {
+			return nil
+		}
+		reasons := map[string]string{}
+		for _, action := range refactor.Actions {
+			reasons[action.Name] = action.NotApplicableReason
+		}
+		return reasons
+	}
+	applyAction := func(fileName string, text string, actionName string) string {
+		content := files[fileName].(string)
+		position := strings.Index(content, text)
+		info, err := languageService.GetRefactorEdits(ctx, fileName, core.NewTextRange(position, position), refactorName, actionName)
+		assert.NilError(t, err)
+		return applyTextEdits(content, (*info.Edits.Changes)[lsproto.DocumentUri("file://"+fileName)])
+	}
+
+	assert.DeepEqual(t, getActions("/src/point.ts", "Point"), map[string]string{"Generate constructor": ""})
+	assert.Assert(t, getActions("/src/point.ts", "return 1") == nil)
+	assert.Equal(t, applyAction("/src/point.ts", "x: number", "Generate constructor"), `class Point {
+    x: number;
+    #y: string;
+    z?: boolean;
+    w = 1;
+    static s: number;
+    constructor(x: number, y: string) {
+        this.x = x;
+        this.#y = y;
+    }
+    m() { return 1; }
+}
+`)
+
+	// The parameters of the base constructor come after those of the fields.
+	assert.Equal(t, applyAction("/src/derived.ts", "Derived", "Generate constructor"), `class Base<T> {
+    constructor(a: T, b?: number) {}
+}
+class Derived extends Base<string> {
+    a: boolean;
+    constructor(a: boolean, a1: string, b?: number) {
+        super(a1, b);
+        this.a = a;
+    }
+}
+`)
+	assert.DeepEqual(t, getActions("/src/derived.ts", "class Base"), map[string]string{
+		"Initialize fields in constructor": "The class has no uninitialized fields.",
+		"Convert to parameter properties":  "The constructor has no parameters assigned to fields.",
+	})
+
+	// An existing constructor gets parameters for the fields it does not assign.
+	assert.Equal(t, applyAction("/src/existing.ts", "class Existing", "Initialize fields in constructor"), strings.Replace(files["/src/existing.ts"].(string), `    constructor(x: number) {
+        super("", x);
+`, `    constructor(x: number, y: string) {
+        super("", x);
+        this.y = y;
+`, 1))
+	assert.Equal(t, applyAction("/src/existing.ts", "n: number", "Initialize fields in constructor"), strings.Replace(files["/src/existing.ts"].(string), `    constructor() {}
+`, `    constructor(n: number) {
+        this.n = n;
+    }
+`, 1))
+
+	assert.Equal(t, getActions("/src/props.ts", "constructor")["Convert to parameter properties"], "")
+	assert.Equal(t, applyAction("/src/props.ts", "constructor", "Convert to parameter properties"), `class Props {
+    other: number;
+    constructor(private readonly id: number, protected name: string, public count: number, other: number) {
+        this.other = other + 1;
+    }
+}
+`)
+}
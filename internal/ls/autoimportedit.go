@@ -0,0 +1,177 @@
+package ls
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/checker"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/module"
+	"github.com/microsoft/typescript-go/internal/stringutil"
+	"github.com/microsoft/typescript-go/internal/tspath"
+)
+
+// GetAutoImportEdit returns the edit to the imports of a file that brings the export symbolName of
+// fromModule into scope as a value, or nil if the file already imports it as one. fromModule is
+// either a module specifier as it would be written in the file, such as "./utils" or "lodash", or
+// the file name of the module. The edit adds the name to an existing import of the module, adds a
+// new import in the order of the existing ones, or removes the type keyword from an existing
+// type-only import of the name. New module specifiers follow the style of the existing imports of
+// the file, such as their use of relative paths and file extensions.
+func (l *LanguageService) GetAutoImportEdit(ctx context.Context, fileName string, symbolName string, fromModule string) (*lsproto.TextEdit, error) {
+	program, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	c, done := program.GetTypeCheckerForFile(ctx, file)
+	defer done()
+
+	preferences := l.getUserPreferences()
+	var exportInfos []*SymbolExportInfo
+	l.searchExportInfosForCompletions(
+		ctx,
+		c,
+		file,
+		preferences,
+		false, /*isForImportStatementCompletion*/
+		false, /*isRightOfOpenTag*/
+		false, /*isTypeOnlyLocation*/
+		strings.ToLower(symbolName),
+		func(infos []*SymbolExportInfo, name string, _ bool, _ ExportInfoMapKey) []*SymbolExportInfo {
+			if name == symbolName {
+				for _, info := range infos {
+					if l.isExportInfoFromModule(info, file, fromModule) {
+						exportInfos = append(exportInfos, info)
+					}
+				}
+			}
+			return nil
+		},
+	)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(exportInfos) == 0 {
+		return nil, fmt.Errorf("%w: %s in %s", ErrExportNotFound, symbolName, fromModule)
+	}
+
+	var fix *ImportFix
+	if existing := findImportOfExport(c, file, symbolName, exportInfos); existing != nil {
+		if !ast.IsTypeOnlyImportDeclaration(existing) {
+			return nil, nil
+		}
+		fix = getNewPromoteTypeOnlyImport(existing)
+	} else {
+		// Without a usage position, the name is never qualified with an existing namespace import.
+		useRequire := getShouldUseRequire(file, program)
+		_, fixes := l.getImportFixes(c, exportInfos, nil /*usagePosition*/, ptrTo(false), &useRequire, file, *preferences, false /*fromCacheOnly*/)
+		fix = l.getBestFix(fixes, file, l.createPackageJsonImportFilter(file, *preferences).allowsImportingSpecifier, *preferences)
+		if fix == nil {
+			return nil, fmt.Errorf("%w: %s in %s", ErrExportNotFound, symbolName, fromModule)
+		}
+	}
+	ct := l.newChangeTracker(ctx)
+	l.codeActionForFixWorker(ct, file, symbolName, fix, true /*includeSymbolNameInDescription*/, preferences)
+	return l.mergeTextEdits(file, ct.getChanges()[file.FileName()]), nil
+}
+
+// isExportInfoFromModule reports whether info is an export of the module named by fromModule, a
+// module specifier as written in file or the file name of the module.
+func (l *LanguageService) isExportInfoFromModule(info *SymbolExportInfo, file *ast.SourceFile, fromModule string) bool {
+	if info.moduleFileName == "" {
+		return stringutil.StripQuotes(info.moduleSymbol.Name) == fromModule
+	}
+	program := l.GetProgram()
+	options := tspath.ComparePathsOptions{
+		UseCaseSensitiveFileNames: program.UseCaseSensitiveFileNames(),
+		CurrentDirectory:          program.GetCurrentDirectory(),
+	}
+	moduleFileName := tspath.RemoveFileExtension(info.moduleFileName)
+	if tspath.IsExternalModuleNameRelative(fromModule) {
+		modulePath := tspath.RemoveFileExtension(tspath.GetNormalizedAbsolutePath(fromModule, tspath.GetDirectoryPath(file.FileName())))
+		return tspath.ComparePaths(moduleFileName, modulePath, options) == 0 ||
+			tspath.ComparePaths(moduleFileName, tspath.CombinePaths(modulePath, "index"), options) == 0
+	}
+	return strings.Contains(moduleFileName, "/node_modules/"+fromModule+"/") ||
+		strings.Contains(moduleFileName, "/node_modules/"+module.GetTypesPackageName(fromModule)+"/")
+}
+
+// findImportOfExport returns the import clause, import specifier or namespace import of file that
+// imports one of exportInfos as symbolName, if any.
+func findImportOfExport(c *checker.Checker, file *ast.SourceFile, symbolName string, exportInfos []*SymbolExportInfo) *ast.Node {
+	for _, statement := range file.Statements.Nodes {
+		if !ast.IsImportDeclaration(statement) || statement.ImportClause() == nil {
+			continue
+		}
+		importClause := statement.ImportClause()
+		declarations := []*ast.Node{importClause}
+		if namedBindings := importClause.AsImportClause().NamedBindings; namedBindings != nil {
+			if ast.IsNamedImports(namedBindings) {
+				declarations = append(declarations, namedBindings.Elements()...)
+			} else {
+				declarations = append(declarations, namedBindings)
+			}
+		}
+		for _, declaration := range declarations {
+			name := declaration.Name()
+			if name == nil || name.Text() != symbolName {
+				continue
+			}
+			symbol := c.GetSymbolAtLocation(name)
+			if symbol == nil {
+				continue
+			}
+			target := c.GetMergedSymbol(c.SkipAlias(symbol))
+			if core.Some(exportInfos, func(info *SymbolExportInfo) bool {
+				return c.GetMergedSymbol(c.SkipAlias(info.symbol)) == target
+			}) {
+				return declaration
+			}
+		}
+	}
+	return nil
+}
+
+// mergeTextEdits combines the non-overlapping edits of a file into a single edit spanning all of
+// them, or returns nil if there are none.
+func (l *LanguageService) mergeTextEdits(file *ast.SourceFile, edits []*lsproto.TextEdit) *lsproto.TextEdit {
+	if len(edits) == 0 {
+		return nil
+	}
+	if len(edits) == 1 {
+		return edits[0]
+	}
+	type offsetEdit struct {
+		start, end int
+		text       string
+	}
+	offsetEdits := core.Map(edits, func(edit *lsproto.TextEdit) offsetEdit {
+		return offsetEdit{
+			start: int(l.converters.LineAndCharacterToPosition(file, edit.Range.Start)),
+			end:   int(l.converters.LineAndCharacterToPosition(file, edit.Range.End)),
+			text:  edit.NewText,
+		}
+	})
+	slices.SortStableFunc(offsetEdits, func(a, b offsetEdit) int {
+		return a.start - b.start
+	})
+	text := file.Text()
+	start := offsetEdits[0].start
+	end := start
+	var b strings.Builder
+	for _, edit := range offsetEdits {
+		if edit.start > end {
+			b.WriteString(text[end:edit.start])
+		}
+		b.WriteString(edit.text)
+		end = max(end, edit.end)
+	}
+	return &lsproto.TextEdit{
+		Range:   *l.createLspRangeFromBounds(start, end, file),
+		NewText: b.String(),
+	}
+}
@@ -1,6 +1,8 @@
 package ls
 
 import (
+	"slices"
+
 	"github.com/microsoft/typescript-go/internal/ast"
 	"github.com/microsoft/typescript-go/internal/astnav"
 	"github.com/microsoft/typescript-go/internal/core"
@@ -151,24 +153,57 @@ func (ct *changeTracker) doAddExistingFix(
 		}
 
 		if promoteFromTypeOnly {
-			// !!! promote type-only imports not implemented
-
-			// ct.delete(sourceFile, getTypeKeywordOfTypeOnlyImport(clause, sourceFile));
-			// if (existingSpecifiers) {
-			//     // We used to convert existing specifiers to type-only only if compiler options indicated that
-			//     // would be meaningful (see the `importNameElisionDisabled` utility function), but user
-			//     // feedback indicated a preference for preserving the type-onlyness of existing specifiers
-			//     // regardless of whether it would make a difference in emit.
-			//     for _, specifier := range existingSpecifiers {
-			//         ct.insertModifierBefore(sourceFile, SyntaxKind.TypeKeyword, specifier);
-			//     }
-			// }
+			ct.promoteImportClause(sourceFile, clause, nil /*promotedSpecifier*/)
 		}
 	default:
 		panic("Unsupported clause kind: " + clause.Kind.String() + "for doAddExistingFix")
 	}
 }
 
+// promoteFromTypeOnly removes the type keyword from the type-only import of an alias, so that the
+// alias can be used as a value, and returns the import specifier or import clause it was removed from.
+func (ct *changeTracker) promoteFromTypeOnly(sourceFile *ast.SourceFile, aliasDeclaration *ast.Node) *ast.Node {
+	switch aliasDeclaration.Kind {
+	case ast.KindImportSpecifier:
+		if aliasDeclaration.IsTypeOnly() {
+			ct.deleteTypeKeyword(sourceFile, aliasDeclaration, aliasDeclaration.PropertyNameOrName())
+			return aliasDeclaration
+		}
+		ct.promoteImportClause(sourceFile, aliasDeclaration.Parent.Parent, aliasDeclaration)
+		return aliasDeclaration.Parent.Parent
+	case ast.KindImportClause:
+		ct.promoteImportClause(sourceFile, aliasDeclaration, nil /*promotedSpecifier*/)
+		return aliasDeclaration
+	case ast.KindNamespaceImport:
+		ct.promoteImportClause(sourceFile, aliasDeclaration.Parent, nil /*promotedSpecifier*/)
+		return aliasDeclaration.Parent
+	}
+	return nil
+}
+
+// promoteImportClause removes the type keyword of a type-only import clause. The named imports of
+// the clause other than promotedSpecifier keep their type-onlyness with a type modifier of their own.
+func (ct *changeTracker) promoteImportClause(sourceFile *ast.SourceFile, importClause *ast.Node, promotedSpecifier *ast.Node) {
+	namedBindings := importClause.AsImportClause().NamedBindings
+	next := importClause.Name()
+	if next == nil {
+		next = namedBindings
+	}
+	ct.deleteTypeKeyword(sourceFile, importClause, next)
+	if namedBindings != nil && ast.IsNamedImports(namedBindings) {
+		for _, specifier := range namedBindings.Elements() {
+			if specifier != promotedSpecifier {
+				ct.insertText(sourceFile, ct.ls.createLspPosition(astnav.GetStartOfNode(specifier, sourceFile, false), sourceFile), "type ")
+			}
+		}
+	}
+}
+
+// deleteTypeKeyword deletes the type keyword that starts node, up to the start of next.
+func (ct *changeTracker) deleteTypeKeyword(sourceFile *ast.SourceFile, node *ast.Node, next *ast.Node) {
+	ct.deleteRange(sourceFile, core.NewTextRange(astnav.GetStartOfNode(node, sourceFile, false), astnav.GetStartOfNode(next, sourceFile, false)))
+}
+
 func (ct *changeTracker) addElementToBindingPattern(sourceFile *ast.SourceFile, bindingPattern *ast.Node, name string, propertyName *string) {
 	element := ct.newBindingElementFromNameAndPropertyName(name, propertyName)
 	if len(bindingPattern.Elements()) > 0 {
@@ -202,10 +237,10 @@ func (ct *changeTracker) insertImports(sourceFile *ast.SourceFile, imports []*as
 	} else {
 		existingImportStatements = core.Filter(sourceFile.Statements.Nodes, ast.IsAnyImportSyntax)
 	}
-	// !!! OrganizeImports
-	//  { comparer, isSorted } := OrganizeImports.getOrganizeImportsStringComparerWithDetection(existingImportStatements, preferences);
-	//  sortedNewImports := isArray(imports) ? toSorted(imports, (a, b) => OrganizeImports.compareImportsOrRequireStatements(a, b, comparer)) : [imports];
-	sortedNewImports := imports
+	comparer, isSorted := getModuleSpecifierStringComparerWithDetection(existingImportStatements)
+	sortedNewImports := slices.SortedStableFunc(slices.Values(imports), func(a, b *ast.Statement) int {
+		return compareImportsOrRequireStatements(a, b, comparer)
+	})
 	// !!! FutureSourceFile
 	// if !isFullSourceFile(sourceFile) {
 	//     for _, newImport := range sortedNewImports {
@@ -216,20 +251,22 @@ func (ct *changeTracker) insertImports(sourceFile *ast.SourceFile, imports []*as
 	// return;
 	// }
 
-	// if len(existingImportStatements) > 0 && isSorted {
-	//     for _, newImport := range sortedNewImports {
-	//         insertionIndex := OrganizeImports.getImportDeclarationInsertionIndex(existingImportStatements, newImport, comparer)
-	//         if insertionIndex == 0 {
-	//             // If the first import is top-of-file, insert after the leading comment which is likely the header.
-	//             options := existingImportStatements[0] == sourceFile.statements[0] ? { leadingTriviaOption: textchanges.LeadingTriviaOption.Exclude } : {};
-	//             ct.insertNodeBefore(sourceFile, existingImportStatements[0], newImport, /*blankLineBetween*/ false, options);
-	//         } else {
-	//             prevImport := existingImportStatements[insertionIndex - 1]
-	//             ct.insertNodeAfter(sourceFile, prevImport, newImport);
-	//         }
-	//     }
-	// 	return
-	// }
+	if len(existingImportStatements) > 0 && isSorted {
+		for _, newImport := range sortedNewImports {
+			insertionIndex := getImportDeclarationInsertIndex(existingImportStatements, newImport, func(a, b *ast.Statement) int {
+				return compareImportsOrRequireStatements(a, b, comparer)
+			})
+			if insertionIndex == 0 {
+				// If the first import is top-of-file, insert after the leading comment which is likely the header.
+				leadingOption := core.IfElse(existingImportStatements[0] == sourceFile.Statements.Nodes[0], leadingTriviaOptionExclude, leadingTriviaOptionNone)
+				pos := ct.getAdjustedStartPosition(sourceFile, existingImportStatements[0], leadingOption, false /*hasTrailingComment*/)
+				ct.insertNodeAt(sourceFile, core.TextPos(pos), newImport, changeNodeOptions{suffix: ct.newLine})
+			} else {
+				ct.insertNodeAfter(sourceFile, existingImportStatements[insertionIndex-1], newImport)
+			}
+		}
+		return
+	}
 
 	if len(existingImportStatements) > 0 {
 		ct.insertNodesAfter(sourceFile, existingImportStatements[len(existingImportStatements)-1], sortedNewImports)
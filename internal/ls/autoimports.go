@@ -1462,12 +1462,15 @@ func (l *LanguageService) codeActionForFixWorker(
 		}
 		return diagnostics.FormatMessage(diagnostics.Add_import_from_0, fix.moduleSpecifier)
 	case ImportFixKindPromoteTypeOnly:
-		// !!! type only
-		// promotedDeclaration := promoteFromTypeOnly(changes, fix.typeOnlyAliasDeclaration, program, sourceFile, preferences);
-		// if promotedDeclaration.Kind == ast.KindImportSpecifier {
-		// return diagnostics.FormatMessage(diagnostics.Remove_type_from_import_of_0_from_1, symbolName, getModuleSpecifierText(promotedDeclaration.parent.parent))
-		// }
-		// return diagnostics.FormatMessage(diagnostics.Remove_type_from_import_declaration_from_0, getModuleSpecifierText(promotedDeclaration));
+		promotedDeclaration := changeTracker.promoteFromTypeOnly(sourceFile, fix.typeOnlyAliasDeclaration)
+		if promotedDeclaration == nil {
+			return nil
+		}
+		moduleSpecifier := ast.FindAncestor(promotedDeclaration, ast.IsImportDeclaration).ModuleSpecifier().Text()
+		if promotedDeclaration.Kind == ast.KindImportSpecifier {
+			return diagnostics.FormatMessage(diagnostics.Remove_type_from_import_of_0_from_1, symbolName, moduleSpecifier)
+		}
+		return diagnostics.FormatMessage(diagnostics.Remove_type_from_import_declaration_from_0, moduleSpecifier)
 	default:
 		panic(fmt.Sprintf(`Unexpected fix kind %v`, fix.kind))
 	}
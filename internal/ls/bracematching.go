@@ -0,0 +1,106 @@
+package ls
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+	"github.com/microsoft/typescript-go/internal/core"
+)
+
+// TextRange is a range of a file, given both as offsets and as line and character positions.
+type TextRange struct {
+	Start    Position `json:"start"`
+	End      Position `json:"end"`
+	StartPos int      `json:"startPos"`
+	EndPos   int      `json:"endPos"`
+}
+
+func (l *LanguageService) newTextRange(file *ast.SourceFile, textRange core.TextRange) TextRange {
+	return TextRange{
+		Start:    getPosition(file, textRange.Pos(), l),
+		End:      getPosition(file, textRange.End(), l),
+		StartPos: textRange.Pos(),
+		EndPos:   textRange.End(),
+	}
+}
+
+var matchingBracketKinds = map[ast.Kind]ast.Kind{
+	ast.KindOpenBraceToken:    ast.KindCloseBraceToken,
+	ast.KindCloseBraceToken:   ast.KindOpenBraceToken,
+	ast.KindOpenParenToken:    ast.KindCloseParenToken,
+	ast.KindCloseParenToken:   ast.KindOpenParenToken,
+	ast.KindOpenBracketToken:  ast.KindCloseBracketToken,
+	ast.KindCloseBracketToken: ast.KindOpenBracketToken,
+	ast.KindLessThanToken:     ast.KindGreaterThanToken,
+	ast.KindGreaterThanToken:  ast.KindLessThanToken,
+}
+
+// GetMatchingBrackets returns the ranges of the bracket starting or ending at position and of its
+// match, in document order. Brackets are matched using the syntax tree, so brackets in strings,
+// comments and template literals are never matched, and `<` and `>` are only matched when they
+// enclose type parameters, type arguments or a JSX tag rather than being comparison operators.
+// At a position in the tag name of a JSX element, the ranges of the opening and closing tag names
+// are returned. An empty result means there is nothing to match at position.
+func (l *LanguageService) GetMatchingBrackets(ctx context.Context, fileName string, position int) ([]TextRange, error) {
+	_, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	// A bracket starting at position takes precedence over one ending there, as in `)(`.
+	if token := astnav.GetTouchingToken(file, position); token != nil && createRangeFromNode(token, file).Pos() == position {
+		if ranges := l.getMatchingBracketRanges(file, token); ranges != nil {
+			return ranges, nil
+		}
+	}
+	if token := astnav.GetTouchingTokenPreferLeft(file, position); token != nil && token.End() == position {
+		if ranges := l.getMatchingBracketRanges(file, token); ranges != nil {
+			return ranges, nil
+		}
+	}
+	if ranges := l.getMatchingJsxTagNameRanges(file, position); ranges != nil {
+		return ranges, nil
+	}
+	return []TextRange{}, nil
+}
+
+func (l *LanguageService) getMatchingBracketRanges(file *ast.SourceFile, token *ast.Node) []TextRange {
+	matchKind, ok := matchingBracketKinds[token.Kind]
+	if !ok || token.Parent == nil {
+		return nil
+	}
+	if (token.Kind == ast.KindLessThanToken || token.Kind == ast.KindGreaterThanToken) && ast.IsBinaryExpression(token.Parent) {
+		return nil
+	}
+	match := findChildOfKind(token.Parent, matchKind, file)
+	if match == nil {
+		return nil
+	}
+	tokenRange := createRangeFromNode(token, file)
+	matchRange := createRangeFromNode(match, file)
+	if matchRange.Pos() < tokenRange.Pos() {
+		tokenRange, matchRange = matchRange, tokenRange
+	}
+	return []TextRange{l.newTextRange(file, tokenRange), l.newTextRange(file, matchRange)}
+}
+
+func (l *LanguageService) getMatchingJsxTagNameRanges(file *ast.SourceFile, position int) []TextRange {
+	node := astnav.GetTouchingPropertyName(file, position)
+	// Tag names are identifiers, `this`, namespaced names or property accesses such as `a.b`.
+	tag := ast.FindAncestor(node, func(n *ast.Node) bool {
+		switch n.Kind {
+		case ast.KindIdentifier, ast.KindThisKeyword, ast.KindJsxNamespacedName, ast.KindPropertyAccessExpression:
+			return false
+		}
+		return true
+	})
+	if tag == nil || !ast.IsJsxOpeningElement(tag) && !ast.IsJsxClosingElement(tag) || !ast.IsJsxElement(tag.Parent) || node.Pos() < tag.TagName().Pos() {
+		return nil
+	}
+	element := tag.Parent.AsJsxElement()
+	return []TextRange{
+		l.newTextRange(file, createRangeFromNode(element.OpeningElement.TagName(), file)),
+		l.newTextRange(file, createRangeFromNode(element.ClosingElement.TagName(), file)),
+	}
+}
@@ -0,0 +1,569 @@
+package ls
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/lsutil"
+	"github.com/microsoft/typescript-go/internal/scanner"
+)
+
+// GetBreakpointSpan returns the span of the statement or expression a debugger breakpoint at
+// position would stop at, or nil if no code is executed on the line of position, such as in an
+// interface, a type alias or an ambient declaration. A position on a brace or a function signature
+// resolves to the first statement of the body, and a position on a line with several statements
+// resolves to the statement under it.
+func (l *LanguageService) GetBreakpointSpan(ctx context.Context, fileName string, position int) (*TextRange, error) {
+	_, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	if file.IsDeclarationFile {
+		return nil, nil
+	}
+	b := &breakpointResolver{file: file, line: getLineOfPosition(file, position)}
+	token := astnav.GetTokenAtPosition(file, position)
+	if getLineOfPosition(file, scanner.GetTokenPosOfNode(token, file, false /*includeJSDoc*/)) > b.line {
+		// The token starts on a later line: use the token ending the line of position instead.
+		preceding := astnav.FindPrecedingToken(file, token.Pos())
+		if preceding == nil || getLineOfPosition(file, preceding.End()) != b.line {
+			return nil, nil
+		}
+		token = preceding
+	}
+	// No breakpoints in ambient declarations. Tokens do not carry the flag of their parent.
+	if ast.FindAncestor(token, func(node *ast.Node) bool { return node.Flags&ast.NodeFlagsAmbient != 0 }) != nil {
+		return nil, nil
+	}
+	span, ok := b.spanInNode(token)
+	if !ok {
+		return nil, nil
+	}
+	textRange := l.newTextRange(file, span)
+	return &textRange, nil
+}
+
+// breakpointResolver finds the breakpoint span of a token on a line, following the emit of the
+// nodes containing it.
+type breakpointResolver struct {
+	file *ast.SourceFile
+	line int
+}
+
+var noBreakpointSpan = core.UndefinedTextRange()
+
+// textSpan returns the span from the start of startNode, after its decorators, to the end of
+// endNode, or of startNode if endNode is nil.
+func (b *breakpointResolver) textSpan(startNode *ast.Node, endNode *ast.Node) (core.TextRange, bool) {
+	start := scanner.GetTokenPosOfNode(startNode, b.file, false /*includeJSDoc*/)
+	if decorators := core.Filter(startNode.ModifierNodes(), ast.IsDecorator); len(decorators) > 0 {
+		start = scanner.SkipTrivia(b.file.Text(), decorators[len(decorators)-1].End())
+	}
+	if endNode == nil {
+		endNode = startNode
+	}
+	return core.NewTextRange(start, endNode.End()), true
+}
+
+// keywordSpan returns the span from the start of a statement starting with keyword to the end of
+// endNode, or of the keyword if endNode is nil.
+func (b *breakpointResolver) keywordSpan(statement *ast.Node, keyword string, endNode *ast.Node) (core.TextRange, bool) {
+	if endNode != nil {
+		return b.textSpan(statement, endNode)
+	}
+	start := scanner.GetTokenPosOfNode(statement, b.file, false /*includeJSDoc*/)
+	return core.NewTextRange(start, start+len(keyword)), true
+}
+
+// textSpanEndingAtNextToken returns the span from the start of startNode to the end of the token
+// following previousToken, such as the close paren after the condition of an if statement.
+func (b *breakpointResolver) textSpanEndingAtNextToken(startNode *ast.Node, previousToken *ast.Node) (core.TextRange, bool) {
+	return b.textSpan(startNode, b.nextToken(previousToken))
+}
+
+// nextToken returns the token following node, found by scanning past the trivia after it, or nil
+// at the end of the file.
+func (b *breakpointResolver) nextToken(node *ast.Node) *ast.Node {
+	position := scanner.SkipTrivia(b.file.Text(), node.End())
+	if position >= len(b.file.Text()) {
+		return nil
+	}
+	return astnav.GetTokenAtPosition(b.file, position)
+}
+
+func (b *breakpointResolver) spanInNodeIfStartsOnSameLine(node *ast.Node, otherwise *ast.Node) (core.TextRange, bool) {
+	if node != nil && getLineOfPosition(b.file, scanner.GetTokenPosOfNode(node, b.file, false /*includeJSDoc*/)) == b.line {
+		return b.spanInNode(node)
+	}
+	return b.spanInNode(otherwise)
+}
+
+func (b *breakpointResolver) spanInPreviousNode(node *ast.Node) (core.TextRange, bool) {
+	return b.spanInNode(astnav.FindPrecedingToken(b.file, node.Pos()))
+}
+
+func (b *breakpointResolver) spanInNextNode(node *ast.Node) (core.TextRange, bool) {
+	return b.spanInNode(b.nextToken(node))
+}
+
+func (b *breakpointResolver) spanInNode(node *ast.Node) (core.TextRange, bool) {
+	if node == nil {
+		return noBreakpointSpan, false
+	}
+	parent := node.Parent
+	switch node.Kind {
+	case ast.KindVariableStatement:
+		return b.spanInVariableDeclaration(node.AsVariableStatement().DeclarationList.AsVariableDeclarationList().Declarations.Nodes[0])
+	case ast.KindVariableDeclaration, ast.KindPropertyDeclaration, ast.KindPropertySignature:
+		return b.spanInVariableDeclaration(node)
+	case ast.KindParameter:
+		return b.spanInParameterDeclaration(node)
+	case ast.KindFunctionDeclaration, ast.KindMethodDeclaration, ast.KindMethodSignature, ast.KindGetAccessor,
+		ast.KindSetAccessor, ast.KindConstructor, ast.KindFunctionExpression, ast.KindArrowFunction:
+		return b.spanInFunctionDeclaration(node)
+	case ast.KindBlock:
+		if ast.IsFunctionBlock(node) {
+			return b.spanInFunctionBlock(node)
+		}
+		return b.spanInBlock(node)
+	case ast.KindModuleBlock:
+		return b.spanInBlock(node)
+	case ast.KindCatchClause:
+		return b.spanInBlock(node.AsCatchClause().Block)
+	case ast.KindExpressionStatement:
+		return b.textSpan(node.Expression(), nil)
+	case ast.KindReturnStatement:
+		return b.keywordSpan(node, "return", node.Expression())
+	case ast.KindWhileStatement, ast.KindIfStatement, ast.KindForInStatement, ast.KindSwitchStatement:
+		// The span ends at the close paren after the expression.
+		return b.textSpanEndingAtNextToken(node, node.Expression())
+	case ast.KindDoStatement:
+		return b.spanInNode(node.Statement())
+	case ast.KindLabeledStatement:
+		return b.spanInNode(node.AsLabeledStatement().Statement)
+	case ast.KindWithStatement:
+		return b.spanInNode(node.AsWithStatement().Statement)
+	case ast.KindDebuggerStatement:
+		return b.keywordSpan(node, "debugger", nil)
+	case ast.KindBreakStatement:
+		return b.keywordSpan(node, "break", node.Label())
+	case ast.KindContinueStatement:
+		return b.keywordSpan(node, "continue", node.Label())
+	case ast.KindForStatement:
+		return b.spanInForStatement(node)
+	case ast.KindForOfStatement:
+		return b.spanInInitializerOfForLike(node)
+	case ast.KindCaseClause, ast.KindDefaultClause:
+		return b.spanInNode(core.FirstOrNil(node.AsCaseOrDefaultClause().Statements.Nodes))
+	case ast.KindTryStatement:
+		return b.spanInBlock(node.AsTryStatement().TryBlock)
+	case ast.KindThrowStatement, ast.KindExportAssignment:
+		return b.textSpan(node, node.Expression())
+	case ast.KindImportEqualsDeclaration:
+		// The span excludes the semicolon.
+		return b.textSpan(node, node.AsImportEqualsDeclaration().ModuleReference)
+	case ast.KindImportDeclaration:
+		return b.textSpan(node, node.AsImportDeclaration().ModuleSpecifier)
+	case ast.KindExportDeclaration:
+		return b.textSpan(node, node.AsExportDeclaration().ModuleSpecifier)
+	case ast.KindModuleDeclaration:
+		if ast.GetModuleInstanceState(node) != ast.ModuleInstanceStateInstantiated {
+			return noBreakpointSpan, false
+		}
+		return b.textSpan(node, nil)
+	case ast.KindClassDeclaration, ast.KindEnumDeclaration, ast.KindEnumMember, ast.KindBindingElement:
+		return b.textSpan(node, nil)
+	case ast.KindDecorator:
+		return b.spanInDecorators(node)
+	case ast.KindObjectBindingPattern, ast.KindArrayBindingPattern:
+		return b.spanInBindingPattern(node)
+	case ast.KindInterfaceDeclaration, ast.KindTypeAliasDeclaration:
+		return noBreakpointSpan, false
+	case ast.KindSemicolonToken, ast.KindEndOfFile:
+		return b.spanInNodeIfStartsOnSameLine(astnav.FindPrecedingToken(b.file, node.Pos()), nil)
+	case ast.KindCommaToken:
+		return b.spanInPreviousNode(node)
+	case ast.KindOpenBraceToken:
+		return b.spanInOpenBraceToken(node)
+	case ast.KindCloseBraceToken:
+		return b.spanInCloseBraceToken(node)
+	case ast.KindCloseBracketToken:
+		return b.spanInCloseBracketToken(node)
+	case ast.KindOpenParenToken:
+		return b.spanInOpenParenToken(node)
+	case ast.KindCloseParenToken:
+		return b.spanInCloseParenToken(node)
+	case ast.KindColonToken:
+		if ast.IsFunctionLike(parent) || ast.IsPropertyAssignment(parent) || ast.IsParameter(parent) {
+			// The colon of a type annotation or of a property assignment.
+			return b.spanInPreviousNode(node)
+		}
+		return b.spanInNode(parent)
+	case ast.KindGreaterThanToken, ast.KindLessThanToken:
+		if parent.Kind == ast.KindTypeAssertionExpression {
+			return b.spanInNextNode(node)
+		}
+		return b.spanInNode(parent)
+	case ast.KindWhileKeyword:
+		if parent.Kind == ast.KindDoStatement {
+			return b.textSpanEndingAtNextToken(node, parent.Expression())
+		}
+		return b.spanInNode(parent)
+	case ast.KindElseKeyword, ast.KindCatchKeyword, ast.KindFinallyKeyword:
+		return b.spanInNextNode(node)
+	case ast.KindOfKeyword:
+		if ast.IsForOfStatement(parent) {
+			return b.spanInNextNode(node)
+		}
+		return b.spanInNode(parent)
+	}
+	return b.spanInOtherNode(node)
+}
+
+// spanInOtherNode returns the span of an expression or other node without a statement of its own,
+// which is usually the span of its parent.
+func (b *breakpointResolver) spanInOtherNode(node *ast.Node) (core.TextRange, bool) {
+	parent := node.Parent
+	if isDestructuringAssignmentPattern(node) {
+		return b.spanInDestructuringAssignmentPattern(node)
+	}
+	// An element of a destructuring assignment pattern, such as `a`, `...c` or `d: x` in
+	// `[a, ...c] = e` or `{ d: x } = e`.
+	switch node.Kind {
+	case ast.KindIdentifier, ast.KindSpreadElement, ast.KindPropertyAssignment, ast.KindShorthandPropertyAssignment:
+		if isDestructuringAssignmentPattern(parent) {
+			return b.textSpan(node, nil)
+		}
+	}
+	if ast.IsBinaryExpression(node) {
+		binary := node.AsBinaryExpression()
+		if isDestructuringAssignmentPattern(binary.Left) {
+			return b.spanInDestructuringAssignmentPattern(binary.Left)
+		}
+		if binary.OperatorToken.Kind == ast.KindEqualsToken && isDestructuringAssignmentPattern(parent) {
+			// An element with a default value, such as `a = 1` in `[a = 1] = e`.
+			return b.textSpan(node, nil)
+		}
+		if binary.OperatorToken.Kind == ast.KindCommaToken {
+			return b.spanInNode(binary.Left)
+		}
+	}
+	if ast.IsExpressionNode(node) {
+		switch parent.Kind {
+		case ast.KindDoStatement:
+			// The condition of a do statement is part of the span of its while keyword.
+			return b.spanInPreviousNode(node)
+		case ast.KindDecorator:
+			return b.spanInNode(parent)
+		case ast.KindForStatement, ast.KindForOfStatement:
+			return b.textSpan(node, nil)
+		case ast.KindBinaryExpression:
+			if parent.AsBinaryExpression().OperatorToken.Kind == ast.KindCommaToken {
+				return b.textSpan(node, nil)
+			}
+		case ast.KindArrowFunction:
+			if parent.Body() == node {
+				return b.textSpan(node, nil)
+			}
+		}
+	}
+	switch parent.Kind {
+	case ast.KindPropertyAssignment:
+		// The name of a property assignment resolves to its initializer.
+		if parent.Name() == node && !isDestructuringAssignmentPattern(parent.Parent) {
+			return b.spanInNode(parent.Initializer())
+		}
+	case ast.KindTypeAssertionExpression:
+		if parent.Type() == node {
+			return b.spanInNextNode(node)
+		}
+	case ast.KindVariableDeclaration, ast.KindParameter:
+		// The initializer or type of a declaration resolves to the declaration.
+		if parent.Initializer() == node || parent.Type() == node || ast.IsAssignmentOperator(node.Kind) {
+			return b.spanInPreviousNode(node)
+		}
+	case ast.KindBinaryExpression:
+		// The value of a destructuring assignment resolves to the pattern.
+		if left := parent.AsBinaryExpression().Left; isDestructuringAssignmentPattern(left) && node != left {
+			return b.spanInPreviousNode(node)
+		}
+	default:
+		// The return type of a function resolves to its signature.
+		if ast.IsFunctionLike(parent) && parent.Type() == node {
+			return b.spanInPreviousNode(node)
+		}
+	}
+	return b.spanInNode(parent)
+}
+
+// spanInVariableDeclaration returns the span of a variable or property declaration, which has one
+// only if it has an initializer, is exported or declares the variable of a for-of statement. The
+// span of the first declaration of a list includes the `let`, `const` or `var` keyword.
+func (b *breakpointResolver) spanInVariableDeclaration(declaration *ast.Node) (core.TextRange, bool) {
+	parent := declaration.Parent
+	if parent.Parent != nil && ast.IsForInStatement(parent.Parent) {
+		return b.spanInNode(parent.Parent)
+	}
+	if name := declaration.Name(); name != nil && ast.IsBindingPattern(name) {
+		return b.spanInBindingPattern(name)
+	}
+	if declaration.Initializer() != nil || ast.HasSyntacticModifier(declaration, ast.ModifierFlagsExport) ||
+		parent.Parent != nil && ast.IsForOfStatement(parent.Parent) {
+		return b.textSpanFromVariableDeclaration(declaration)
+	}
+	if ast.IsVariableDeclarationList(parent) && parent.AsVariableDeclarationList().Declarations.Nodes[0] != declaration {
+		// A declaration without a span resolves to the previous one.
+		return b.spanInNode(astnav.FindPrecedingTokenEx(b.file, declaration.Pos(), parent, false /*excludeJSDoc*/))
+	}
+	return noBreakpointSpan, false
+}
+
+func (b *breakpointResolver) textSpanFromVariableDeclaration(declaration *ast.Node) (core.TextRange, bool) {
+	if parent := declaration.Parent; ast.IsVariableDeclarationList(parent) && parent.AsVariableDeclarationList().Declarations.Nodes[0] == declaration {
+		keyword := astnav.FindPrecedingTokenEx(b.file, declaration.Pos(), parent, false /*excludeJSDoc*/)
+		return b.textSpan(keyword, declaration)
+	}
+	return b.textSpan(declaration, nil)
+}
+
+// spanInParameterDeclaration returns the span of a parameter, which has one only if it has an
+// initializer, is a rest parameter or is a parameter property. Other parameters resolve to the
+// previous parameter, and the first one to the body of the function.
+func (b *breakpointResolver) spanInParameterDeclaration(parameter *ast.Node) (core.TextRange, bool) {
+	if name := parameter.Name(); name != nil && ast.IsBindingPattern(name) {
+		return b.spanInBindingPattern(name)
+	}
+	if parameter.Initializer() != nil || parameter.AsParameterDeclaration().DotDotDotToken != nil ||
+		ast.HasSyntacticModifier(parameter, ast.ModifierFlagsPublic|ast.ModifierFlagsPrivate) {
+		return b.textSpan(parameter, nil)
+	}
+	function := parameter.Parent
+	parameters := function.Parameters()
+	if index := core.FindIndex(parameters, func(p *ast.Node) bool { return p == parameter }); index > 0 {
+		return b.spanInParameterDeclaration(parameters[index-1])
+	}
+	return b.spanInNode(function.Body())
+}
+
+// canFunctionHaveSpanInWholeDeclaration reports whether the declaration of a function emits code,
+// as an exported function or a class method does.
+func canFunctionHaveSpanInWholeDeclaration(function *ast.Node) bool {
+	return ast.HasSyntacticModifier(function, ast.ModifierFlagsExport) ||
+		ast.IsClassDeclaration(function.Parent) && !ast.IsConstructorDeclaration(function)
+}
+
+func (b *breakpointResolver) spanInFunctionDeclaration(function *ast.Node) (core.TextRange, bool) {
+	// No breakpoints in a signature without a body.
+	if function.Body() == nil {
+		return noBreakpointSpan, false
+	}
+	if canFunctionHaveSpanInWholeDeclaration(function) {
+		return b.textSpan(function, nil)
+	}
+	return b.spanInNode(function.Body())
+}
+
+func (b *breakpointResolver) spanInFunctionBlock(block *ast.Node) (core.TextRange, bool) {
+	nodeForSpanInBlock := core.FirstOrNil(block.Statements())
+	if nodeForSpanInBlock == nil {
+		nodeForSpanInBlock = lsutil.GetLastToken(block, b.file)
+	}
+	if canFunctionHaveSpanInWholeDeclaration(block.Parent) {
+		return b.spanInNodeIfStartsOnSameLine(block.Parent, nodeForSpanInBlock)
+	}
+	return b.spanInNode(nodeForSpanInBlock)
+}
+
+// spanInBlock returns the span of the statement owning a block if it starts on the same line, or
+// of the first statement of the block otherwise.
+func (b *breakpointResolver) spanInBlock(block *ast.Node) (core.TextRange, bool) {
+	firstStatement := core.FirstOrNil(block.Statements())
+	switch block.Parent.Kind {
+	case ast.KindModuleDeclaration:
+		if ast.GetModuleInstanceState(block.Parent) != ast.ModuleInstanceStateInstantiated {
+			return noBreakpointSpan, false
+		}
+		return b.spanInNodeIfStartsOnSameLine(block.Parent, firstStatement)
+	case ast.KindWhileStatement, ast.KindIfStatement, ast.KindForInStatement:
+		return b.spanInNodeIfStartsOnSameLine(block.Parent, firstStatement)
+	case ast.KindForStatement, ast.KindForOfStatement:
+		return b.spanInNodeIfStartsOnSameLine(astnav.FindPrecedingTokenEx(b.file, block.Pos(), block.Parent, false /*excludeJSDoc*/), firstStatement)
+	}
+	return b.spanInNode(firstStatement)
+}
+
+func (b *breakpointResolver) spanInInitializerOfForLike(statement *ast.Node) (core.TextRange, bool) {
+	initializer := statement.Initializer()
+	if ast.IsVariableDeclarationList(initializer) {
+		return b.spanInNode(core.FirstOrNil(initializer.AsVariableDeclarationList().Declarations.Nodes))
+	}
+	return b.spanInNode(initializer)
+}
+
+func (b *breakpointResolver) spanInForStatement(statement *ast.Node) (core.TextRange, bool) {
+	forStatement := statement.AsForStatement()
+	switch {
+	case forStatement.Initializer != nil:
+		return b.spanInInitializerOfForLike(statement)
+	case forStatement.Condition != nil:
+		return b.textSpan(forStatement.Condition, nil)
+	case forStatement.Incrementor != nil:
+		return b.textSpan(forStatement.Incrementor, nil)
+	}
+	return noBreakpointSpan, false
+}
+
+// spanInDecorators returns the span of a run of consecutive decorators.
+func (b *breakpointResolver) spanInDecorators(decorator *ast.Node) (core.TextRange, bool) {
+	modifiers := decorator.Parent.ModifierNodes()
+	index := core.FindIndex(modifiers, func(m *ast.Node) bool { return m == decorator })
+	if index < 0 {
+		return b.textSpan(decorator, nil)
+	}
+	start, end := index, index+1
+	for start > 0 && ast.IsDecorator(modifiers[start-1]) {
+		start--
+	}
+	for end < len(modifiers) && ast.IsDecorator(modifiers[end]) {
+		end++
+	}
+	return core.NewTextRange(scanner.SkipTrivia(b.file.Text(), modifiers[start].Pos()), modifiers[end-1].End()), true
+}
+
+// spanInBindingPattern returns the span of the first element of a binding pattern, or of the
+// declaration of an empty pattern.
+func (b *breakpointResolver) spanInBindingPattern(pattern *ast.Node) (core.TextRange, bool) {
+	for _, element := range pattern.Elements() {
+		if !ast.IsOmittedExpression(element) {
+			return b.spanInNode(element)
+		}
+	}
+	if ast.IsBindingElement(pattern.Parent) {
+		return b.textSpan(pattern.Parent, nil)
+	}
+	return b.textSpanFromVariableDeclaration(pattern.Parent)
+}
+
+// spanInDestructuringAssignmentPattern returns the span of the first element of an array or object
+// literal assigned to, or of the assignment of an empty pattern.
+func (b *breakpointResolver) spanInDestructuringAssignmentPattern(pattern *ast.Node) (core.TextRange, bool) {
+	for _, element := range getDestructuringAssignmentElements(pattern) {
+		if !ast.IsOmittedExpression(element) {
+			return b.spanInNode(element)
+		}
+	}
+	if ast.IsBinaryExpression(pattern.Parent) {
+		return b.textSpan(pattern.Parent, nil)
+	}
+	return b.textSpan(pattern, nil)
+}
+
+func (b *breakpointResolver) spanInOpenBraceToken(node *ast.Node) (core.TextRange, bool) {
+	parent := node.Parent
+	switch parent.Kind {
+	case ast.KindEnumDeclaration, ast.KindClassDeclaration:
+		first := core.FirstOrNil(parent.Members())
+		if first == nil {
+			first = lsutil.GetLastToken(parent, b.file)
+		}
+		return b.spanInNodeIfStartsOnSameLine(astnav.FindPrecedingTokenEx(b.file, node.Pos(), parent, false /*excludeJSDoc*/), first)
+	case ast.KindCaseBlock:
+		return b.spanInNodeIfStartsOnSameLine(parent.Parent, core.FirstOrNil(parent.AsCaseBlock().Clauses.Nodes))
+	}
+	return b.spanInNode(parent)
+}
+
+func (b *breakpointResolver) spanInCloseBraceToken(node *ast.Node) (core.TextRange, bool) {
+	parent := node.Parent
+	switch parent.Kind {
+	case ast.KindModuleBlock:
+		if ast.GetModuleInstanceState(parent.Parent) != ast.ModuleInstanceStateInstantiated {
+			return noBreakpointSpan, false
+		}
+		return b.textSpan(node, nil)
+	case ast.KindEnumDeclaration, ast.KindClassDeclaration:
+		return b.textSpan(node, nil)
+	case ast.KindBlock:
+		if ast.IsFunctionBlock(parent) {
+			return b.textSpan(node, nil)
+		}
+		return b.spanInNode(core.LastOrNil(parent.Statements()))
+	case ast.KindCatchClause:
+		return b.spanInNode(core.LastOrNil(parent.AsCatchClause().Block.Statements()))
+	case ast.KindCaseBlock:
+		// The last statement of the last clause.
+		if lastClause := core.LastOrNil(parent.AsCaseBlock().Clauses.Nodes); lastClause != nil {
+			return b.spanInNode(core.LastOrNil(lastClause.AsCaseOrDefaultClause().Statements.Nodes))
+		}
+		return noBreakpointSpan, false
+	case ast.KindObjectBindingPattern:
+		if last := core.LastOrNil(parent.Elements()); last != nil {
+			return b.spanInNode(last)
+		}
+		return b.spanInNode(parent)
+	}
+	if isDestructuringAssignmentPattern(parent) {
+		return b.textSpan(core.OrElse(core.LastOrNil(parent.Properties()), parent), nil)
+	}
+	return b.spanInNode(parent)
+}
+
+func (b *breakpointResolver) spanInCloseBracketToken(node *ast.Node) (core.TextRange, bool) {
+	parent := node.Parent
+	if ast.IsArrayBindingPattern(parent) {
+		return b.textSpan(core.OrElse(core.LastOrNil(parent.Elements()), parent), nil)
+	}
+	if isDestructuringAssignmentPattern(parent) {
+		return b.textSpan(core.OrElse(core.LastOrNil(getDestructuringAssignmentElements(parent)), parent), nil)
+	}
+	return b.spanInNode(parent)
+}
+
+func (b *breakpointResolver) spanInOpenParenToken(node *ast.Node) (core.TextRange, bool) {
+	switch node.Parent.Kind {
+	case ast.KindDoStatement, ast.KindCallExpression, ast.KindNewExpression:
+		return b.spanInPreviousNode(node)
+	case ast.KindParenthesizedExpression:
+		return b.spanInNextNode(node)
+	}
+	return b.spanInNode(node.Parent)
+}
+
+func (b *breakpointResolver) spanInCloseParenToken(node *ast.Node) (core.TextRange, bool) {
+	switch node.Parent.Kind {
+	case ast.KindFunctionExpression, ast.KindFunctionDeclaration, ast.KindArrowFunction, ast.KindMethodDeclaration,
+		ast.KindMethodSignature, ast.KindGetAccessor, ast.KindSetAccessor, ast.KindConstructor, ast.KindWhileStatement,
+		ast.KindDoStatement, ast.KindForStatement, ast.KindForOfStatement, ast.KindCallExpression, ast.KindNewExpression,
+		ast.KindParenthesizedExpression:
+		return b.spanInPreviousNode(node)
+	}
+	return b.spanInNode(node.Parent)
+}
+
+// isDestructuringAssignmentPattern reports whether node is an array or object literal assigned to,
+// as in `[a, b] = e`, `for ({ a } of e)` or a nested pattern of those.
+func isDestructuringAssignmentPattern(node *ast.Node) bool {
+	if !ast.IsArrayLiteralExpression(node) && !ast.IsObjectLiteralExpression(node) {
+		return false
+	}
+	parent := node.Parent
+	switch {
+	case ast.IsBinaryExpression(parent) && parent.AsBinaryExpression().Left == node:
+		return true
+	case ast.IsForOfStatement(parent) && parent.Initializer() == node:
+		return true
+	case ast.IsPropertyAssignment(parent):
+		return isDestructuringAssignmentPattern(parent.Parent)
+	}
+	return isDestructuringAssignmentPattern(parent)
+}
+
+func getDestructuringAssignmentElements(pattern *ast.Node) []*ast.Node {
+	if ast.IsArrayLiteralExpression(pattern) {
+		return pattern.AsArrayLiteralExpression().Elements.Nodes
+	}
+	return pattern.Properties()
+}
@@ -114,6 +114,43 @@ func (ct *changeTracker) getChanges() map[string][]*lsproto.TextEdit {
 	return changes
 }
 
+func (ct *changeTracker) getWorkspaceEdit() *lsproto.WorkspaceEdit {
+	changes := make(map[lsproto.DocumentUri][]*lsproto.TextEdit)
+	for fileName, edits := range ct.getChanges() {
+		changes[FileNameToDocumentURI(fileName)] = edits
+	}
+	return newWorkspaceEdit(changes)
+}
+
+func (ct *changeTracker) deleteRange(sourceFile *ast.SourceFile, textRange core.TextRange) {
+	ct.changes.Add(sourceFile, &trackerEdit{kind: trackerEditKindRemove, Range: *ct.ls.createLspRangeFromBounds(textRange.Pos(), textRange.End(), sourceFile)})
+}
+
+func (ct *changeTracker) deleteNode(sourceFile *ast.SourceFile, node *ast.Node, leadingOption leadingTriviaOption, trailingOption trailingTriviaOption) {
+	ct.changes.Add(sourceFile, &trackerEdit{kind: trackerEditKindRemove, Range: ct.getAdjustedRange(sourceFile, node, node, leadingOption, trailingOption)})
+}
+
+// Deletes an element of a comma-separated list together with the separator joining it to its neighbour.
+func (ct *changeTracker) deleteNodeInList(sourceFile *ast.SourceFile, node *ast.Node) {
+	containingList := findContainingList(node, sourceFile)
+	if containingList == nil {
+		ct.deleteNode(sourceFile, node, leadingTriviaOptionExclude, trailingTriviaOptionExclude)
+		return
+	}
+	index := slices.Index(containingList.Nodes, node)
+	start := astnav.GetStartOfNode(node, sourceFile, false)
+	switch {
+	case index < 0 || len(containingList.Nodes) == 1:
+		ct.deleteRange(sourceFile, core.NewTextRange(start, node.End()))
+	case index < len(containingList.Nodes)-1:
+		// a, |b, |c
+		ct.deleteRange(sourceFile, core.NewTextRange(start, astnav.GetStartOfNode(containingList.Nodes[index+1], sourceFile, false)))
+	default:
+		// a, b|, c|
+		ct.deleteRange(sourceFile, core.NewTextRange(containingList.Nodes[index-1].End(), node.End()))
+	}
+}
+
 func (ct *changeTracker) replaceNode(sourceFile *ast.SourceFile, oldNode *ast.Node, newNode *ast.Node, options *changeNodeOptions) {
 	if options == nil {
 		// defaults to `useNonAdjustedPositions`
@@ -125,6 +162,13 @@ func (ct *changeTracker) replaceNode(sourceFile *ast.SourceFile, oldNode *ast.No
 	ct.replaceRange(sourceFile, ct.getAdjustedRange(sourceFile, oldNode, oldNode, options.leadingTriviaOption, options.trailingTriviaOption), newNode, *options)
 }
 
+// replaceNodeWithNodes replaces oldNode with newNodes joined by new lines. Unlike replaceNode, the
+// text of the last node never ends with a new line.
+func (ct *changeTracker) replaceNodeWithNodes(sourceFile *ast.SourceFile, oldNode *ast.Node, newNodes []*ast.Node) {
+	lsprotoRange := ct.getAdjustedRange(sourceFile, oldNode, oldNode, leadingTriviaOptionExclude, trailingTriviaOptionExclude)
+	ct.changes.Add(sourceFile, &trackerEdit{kind: trackerEditKindReplaceWithMultipleNodes, Range: lsprotoRange, nodes: newNodes})
+}
+
 func (ct *changeTracker) replaceRange(sourceFile *ast.SourceFile, lsprotoRange lsproto.Range, newNode *ast.Node, options changeNodeOptions) {
 	ct.changes.Add(sourceFile, &trackerEdit{kind: trackerEditKindReplaceWithSingleNode, Range: lsprotoRange, options: options, Node: newNode})
 }
@@ -251,7 +295,7 @@ func (ct *changeTracker) insertNodeInListAfter(sourceFile *ast.SourceFile, after
 	separatorString := scanner.TokenToString(separator)
 	end := ct.ls.converters.PositionToLineAndCharacter(sourceFile, core.TextPos(after.End()))
 	if !multilineList {
-		ct.replaceRange(sourceFile, lsproto.Range{Start: end, End: end}, newNode, changeNodeOptions{prefix: separatorString})
+		ct.replaceRange(sourceFile, lsproto.Range{Start: end, End: end}, newNode, changeNodeOptions{prefix: separatorString + " "})
 		return
 	}
 
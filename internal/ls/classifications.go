@@ -0,0 +1,125 @@
+package ls
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/checker"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/scanner"
+)
+
+// ClassificationType is the type of a classified span, with the values of the ClassificationType
+// enum of TypeScript.
+type ClassificationType int
+
+const (
+	ClassificationTypeComment                        ClassificationType = 1
+	ClassificationTypeIdentifier                     ClassificationType = 2
+	ClassificationTypeKeyword                        ClassificationType = 3
+	ClassificationTypeNumericLiteral                 ClassificationType = 4
+	ClassificationTypeOperator                       ClassificationType = 5
+	ClassificationTypeStringLiteral                  ClassificationType = 6
+	ClassificationTypeRegularExpressionLiteral       ClassificationType = 7
+	ClassificationTypeWhiteSpace                     ClassificationType = 8
+	ClassificationTypeText                           ClassificationType = 9
+	ClassificationTypePunctuation                    ClassificationType = 10
+	ClassificationTypeClassName                      ClassificationType = 11
+	ClassificationTypeEnumName                       ClassificationType = 12
+	ClassificationTypeInterfaceName                  ClassificationType = 13
+	ClassificationTypeModuleName                     ClassificationType = 14
+	ClassificationTypeTypeParameterName              ClassificationType = 15
+	ClassificationTypeTypeAliasName                  ClassificationType = 16
+	ClassificationTypeParameterName                  ClassificationType = 17
+	ClassificationTypeDocCommentTagName              ClassificationType = 18
+	ClassificationTypeJsxOpenTagName                 ClassificationType = 19
+	ClassificationTypeJsxCloseTagName                ClassificationType = 20
+	ClassificationTypeJsxSelfClosingTagName          ClassificationType = 21
+	ClassificationTypeJsxAttribute                   ClassificationType = 22
+	ClassificationTypeJsxText                        ClassificationType = 23
+	ClassificationTypeJsxAttributeStringLiteralValue ClassificationType = 24
+	ClassificationTypeBigintLiteral                  ClassificationType = 25
+)
+
+type Classifications struct {
+	// Spans holds a triple of numbers per classified span: its start offset, its length and its
+	// ClassificationType.
+	Spans []int `json:"spans"`
+	// EndOfLineState is the state of the scanner at the end of the span, which is always 0 (none)
+	// for semantic classifications.
+	EndOfLineState int `json:"endOfLineState"`
+}
+
+// GetEncodedSemanticClassifications returns the semantic classifications of the identifiers of a
+// file within span, in the encoding of the getEncodedSemanticClassifications of tsserver, for
+// clients that do not use semantic tokens. Only identifiers that name classes, enums, interfaces,
+// type aliases, type parameters and namespaces, directly or through an alias, are classified.
+func (l *LanguageService) GetEncodedSemanticClassifications(ctx context.Context, fileName string, span core.TextRange) (*Classifications, error) {
+	program, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	c, done := program.GetTypeCheckerForFile(ctx, file)
+	defer done()
+
+	spans := []int{}
+	var visit func(node *ast.Node) bool
+	visit = func(node *ast.Node) bool {
+		if node.Pos() > span.End() || node.End() < span.Pos() || ctx.Err() != nil {
+			return false
+		}
+		if ast.IsIdentifier(node) && !ast.NodeIsMissing(node) && file.ClassifiableNames.Has(node.Text()) {
+			if symbol := c.GetSymbolAtLocation(node); symbol != nil {
+				if classification := classifySymbol(c, symbol, getMeaningFromLocation(node)); classification != 0 {
+					start := scanner.GetTokenPosOfNode(node, file, false /*includeJSDoc*/)
+					spans = append(spans, start, node.End()-start, int(classification))
+				}
+			}
+		}
+		node.ForEachChild(visit)
+		return false
+	}
+	file.AsNode().ForEachChild(visit)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &Classifications{Spans: spans}, nil
+}
+
+// classifySymbol returns the classification of a reference to symbol with the given meaning, or
+// 0 if references to it are not classified.
+func classifySymbol(c *checker.Checker, symbol *ast.Symbol, meaning ast.SemanticMeaning) ClassificationType {
+	flags := symbol.Flags
+	switch {
+	case flags&ast.SymbolFlagsClassifiable == 0:
+		return 0
+	case flags&ast.SymbolFlagsClass != 0:
+		return ClassificationTypeClassName
+	case flags&ast.SymbolFlagsEnum != 0:
+		return ClassificationTypeEnumName
+	case flags&ast.SymbolFlagsTypeAlias != 0:
+		return ClassificationTypeTypeAliasName
+	case flags&ast.SymbolFlagsModule != 0:
+		if meaning&ast.SemanticMeaningNamespace != 0 || meaning&ast.SemanticMeaningValue != 0 && hasValueSideModule(symbol) {
+			return ClassificationTypeModuleName
+		}
+		return 0
+	case flags&ast.SymbolFlagsAlias != 0:
+		return classifySymbol(c, c.GetAliasedSymbol(symbol), meaning)
+	case meaning&ast.SemanticMeaningType != 0:
+		if flags&ast.SymbolFlagsInterface != 0 {
+			return ClassificationTypeInterfaceName
+		}
+		if flags&ast.SymbolFlagsTypeParameter != 0 {
+			return ClassificationTypeTypeParameterName
+		}
+	}
+	return 0
+}
+
+func hasValueSideModule(symbol *ast.Symbol) bool {
+	return core.Some(symbol.Declarations, func(declaration *ast.Node) bool {
+		return ast.IsModuleDeclaration(declaration) && ast.GetModuleInstanceState(declaration) == ast.ModuleInstanceStateInstantiated
+	})
+}
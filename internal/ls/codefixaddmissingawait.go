@@ -0,0 +1,110 @@
+package ls
+
+import (
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/diagnostics"
+)
+
+const (
+	fixNameAddMissingAwait = "addMissingAwait"
+	fixIdAddMissingAwait   = "addMissingAwait"
+)
+
+var addMissingAwaitFixProvider = &codeFixProvider{
+	fixName: fixNameAddMissingAwait,
+	errorCodes: []int32{
+		diagnostics.An_arithmetic_operand_must_be_of_type_any_number_bigint_or_an_enum_type.Code(),
+		diagnostics.The_left_hand_side_of_an_arithmetic_operation_must_be_of_type_any_number_bigint_or_an_enum_type.Code(),
+		diagnostics.The_right_hand_side_of_an_arithmetic_operation_must_be_of_type_any_number_bigint_or_an_enum_type.Code(),
+		diagnostics.Operator_0_cannot_be_applied_to_types_1_and_2.Code(),
+		diagnostics.This_comparison_appears_to_be_unintentional_because_the_types_0_and_1_have_no_overlap.Code(),
+		diagnostics.This_condition_will_always_return_true_since_this_0_is_always_defined.Code(),
+		diagnostics.Type_0_is_not_an_array_type.Code(),
+		diagnostics.Type_0_must_have_a_Symbol_iterator_method_that_returns_an_iterator.Code(),
+		diagnostics.Argument_of_type_0_is_not_assignable_to_parameter_of_type_1.Code(),
+		diagnostics.Property_0_does_not_exist_on_type_1.Code(),
+		diagnostics.This_expression_is_not_callable.Code(),
+		diagnostics.This_expression_is_not_constructable.Code(),
+	},
+	fixIds:         []string{fixIdAddMissingAwait},
+	getCodeActions: getAddMissingAwaitCodeActions,
+}
+
+func getAddMissingAwaitCodeActions(c *codeFixContext) []*CodeFixAction {
+	expressions := getAwaitableExpressions(c)
+	if len(expressions) == 0 {
+		return nil
+	}
+	ct := c.ls.newChangeTracker(c.ctx)
+	for _, expression := range expressions {
+		ct.insertAwait(c.sourceFile, expression)
+	}
+	return []*CodeFixAction{newCodeFixAction(ct, fixNameAddMissingAwait, diagnostics.FormatMessage(diagnostics.Add_await).Message(), fixIdAddMissingAwait)}
+}
+
+// Finds the expressions the diagnostic was reported on that are promises and may be awaited at their location.
+// For binary expressions, each promise-typed operand is returned.
+func getAwaitableExpressions(c *codeFixContext) []*ast.Node {
+	span := c.span()
+	token := astnav.GetTokenAtPosition(c.sourceFile, span.Pos())
+	var expression *ast.Node
+	for node := token; node != nil && node.End() <= span.End(); node = node.Parent {
+		if astnav.GetStartOfNode(node, c.sourceFile, false) == span.Pos() && node.End() == span.End() {
+			expression = node
+			break
+		}
+	}
+	if expression == nil {
+		return nil
+	}
+	if c.diagnostic.Code() == diagnostics.Property_0_does_not_exist_on_type_1.Code() {
+		if !ast.IsPropertyAccessExpression(expression.Parent) || expression.Parent.Name() != expression {
+			return nil
+		}
+		expression = expression.Parent.Expression()
+	}
+	if !isInAwaitableContext(c.sourceFile, expression) {
+		return nil
+	}
+	candidates := []*ast.Node{expression}
+	if ast.IsBinaryExpression(expression) {
+		binary := expression.AsBinaryExpression()
+		candidates = []*ast.Node{binary.Left, binary.Right}
+	}
+	return core.Filter(candidates, func(candidate *ast.Node) bool {
+		return ast.IsExpressionNode(candidate) && !ast.IsAwaitExpression(candidate) &&
+			c.checker.GetPromisedTypeOfPromise(c.checker.GetTypeAtLocation(candidate)) != nil
+	})
+}
+
+func isInAwaitableContext(sourceFile *ast.SourceFile, node *ast.Node) bool {
+	container := ast.FindAncestor(node.Parent, ast.IsFunctionLikeOrClassStaticBlockDeclaration)
+	if container == nil {
+		return ast.IsExternalModule(sourceFile)
+	}
+	return ast.IsFunctionLikeDeclaration(container) && ast.HasSyntacticModifier(container, ast.ModifierFlagsAsync)
+}
+
+func (ct *changeTracker) insertAwait(sourceFile *ast.SourceFile, expression *ast.Node) {
+	start := ct.ls.createLspPosition(astnav.GetStartOfNode(expression, sourceFile, false), sourceFile)
+	if !awaitNeedsParentheses(expression) {
+		ct.insertText(sourceFile, start, "await ")
+		return
+	}
+	ct.insertText(sourceFile, start, "(await ")
+	ct.insertText(sourceFile, ct.ls.createLspPosition(expression.End(), sourceFile), ")")
+}
+
+func awaitNeedsParentheses(expression *ast.Node) bool {
+	parent := expression.Parent
+	switch parent.Kind {
+	case ast.KindPropertyAccessExpression, ast.KindElementAccessExpression, ast.KindCallExpression,
+		ast.KindNewExpression, ast.KindNonNullExpression:
+		return parent.Expression() == expression
+	case ast.KindTaggedTemplateExpression:
+		return parent.AsTaggedTemplateExpression().Tag == expression
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package ls
+
+import (
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+	"github.com/microsoft/typescript-go/internal/checker"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/diagnostics"
+	"github.com/microsoft/typescript-go/internal/scanner"
+)
+
+const (
+	fixNameAddOverrideModifier = "fixAddOverrideModifier"
+	fixIdAddOverrideModifier   = "fixAddOverrideModifier"
+)
+
+var addOverrideModifierFixProvider = &codeFixProvider{
+	fixName: fixNameAddOverrideModifier,
+	errorCodes: []int32{
+		diagnostics.This_member_must_have_an_override_modifier_because_it_overrides_a_member_in_the_base_class_0.Code(),
+		diagnostics.This_parameter_property_must_have_an_override_modifier_because_it_overrides_a_member_in_base_class_0.Code(),
+		diagnostics.This_member_must_have_an_override_modifier_because_it_overrides_an_abstract_method_that_is_declared_in_the_base_class_0.Code(),
+	},
+	fixIds:         []string{fixIdAddOverrideModifier},
+	getCodeActions: getAddOverrideModifierCodeActions,
+}
+
+// getAddOverrideModifierCodeActions offers to add `override` to the member the diagnostic was
+// reported on and, if other members of its class miss it as well, to all of them.
+func getAddOverrideModifierCodeActions(c *codeFixContext) []*CodeFixAction {
+	token := astnav.GetTokenAtPosition(c.sourceFile, c.span().Pos())
+	member := ast.FindAncestor(token, func(node *ast.Node) bool {
+		return ast.IsClassElement(node) || ast.IsParameterPropertyDeclaration(node, node.Parent)
+	})
+	if member == nil {
+		return nil
+	}
+	classNode := getClassOfMember(member)
+	if classNode == nil || !ast.IsClassLike(classNode) {
+		return nil
+	}
+	members := getMembersMissingOverrideModifier(c.checker, classNode)
+	if !core.Some(members, func(m *ast.Node) bool { return m == member }) {
+		return nil
+	}
+
+	ct := c.ls.newChangeTracker(c.ctx)
+	ct.insertOverrideModifier(c.sourceFile, member)
+	actions := []*CodeFixAction{newCodeFixAction(ct, fixNameAddOverrideModifier, diagnostics.FormatMessage(diagnostics.Add_override_modifier).Message(), fixIdAddOverrideModifier)}
+	if len(members) > 1 {
+		ct := c.ls.newChangeTracker(c.ctx)
+		for _, m := range members {
+			ct.insertOverrideModifier(c.sourceFile, m)
+		}
+		actions = append(actions, newCodeFixAction(ct, fixNameAddOverrideModifier, diagnostics.FormatMessage(diagnostics.Add_all_missing_override_modifiers).Message(), ""))
+	}
+	return actions
+}
+
+// getClassOfMember returns the class declaring member, which is the parent of the constructor for
+// a parameter property.
+func getClassOfMember(member *ast.Node) *ast.Node {
+	if ast.IsParameter(member) {
+		return member.Parent.Parent
+	}
+	return member.Parent
+}
+
+// getMembersMissingOverrideModifier returns the members and parameter properties of a class that
+// must be marked `override` because they override a member of the base class, in the same way
+// the checker requires it under noImplicitOverride: a member overriding an abstract member only
+// needs the modifier if it is abstract itself. A member only overrides a base member of the same
+// name and of the same kind, so a method never overrides an accessor or a property.
+func getMembersMissingOverrideModifier(ch *checker.Checker, classNode *ast.Node) []*ast.Node {
+	if classNode.Flags&ast.NodeFlagsAmbient != 0 || ast.IsInJSFile(classNode) || ast.GetExtendsHeritageClauseElement(classNode) == nil {
+		return nil
+	}
+	classSymbol := classNode.Symbol()
+	if classSymbol == nil {
+		return nil
+	}
+	classType := ch.GetDeclaredTypeOfSymbol(ch.GetMergedSymbol(classSymbol))
+	baseTypes := ch.GetBaseTypes(classType)
+	if len(baseTypes) == 0 {
+		return nil
+	}
+	baseType := baseTypes[0]
+	baseStaticType := ch.GetBaseConstructorTypeOfClass(classType)
+
+	var members []*ast.Node
+	check := func(member *ast.Node) {
+		if ast.HasSyntacticModifier(member, ast.ModifierFlagsOverride|ast.ModifierFlagsAmbient) {
+			return
+		}
+		symbol := member.Symbol()
+		if symbol == nil || member.Name() == nil || ast.IsPrivateIdentifier(member.Name()) {
+			return
+		}
+		baseMember := ch.GetPropertyOfType(core.IfElse(ast.IsStatic(member), baseStaticType, baseType), symbol.Name)
+		if baseMember == nil || len(baseMember.Declarations) == 0 || getOverridableMemberKind(baseMember) != getOverridableMemberKind(symbol) {
+			return
+		}
+		if core.Some(baseMember.Declarations, isAbstractDeclaration) && !isAbstractDeclaration(member) {
+			return
+		}
+		members = append(members, member)
+	}
+	for _, member := range classNode.Members() {
+		if ast.IsConstructorDeclaration(member) {
+			for _, parameter := range member.Parameters() {
+				if ast.IsParameterPropertyDeclaration(parameter, member) {
+					check(parameter)
+				}
+			}
+		} else if ast.IsMethodDeclaration(member) || ast.IsPropertyDeclaration(member) || ast.IsAccessor(member) {
+			check(member)
+		}
+	}
+	return members
+}
+
+// getOverridableMemberKind returns whether a class member is a method, an accessor or a property,
+// as only members of the same kind override each other.
+func getOverridableMemberKind(symbol *ast.Symbol) ast.SymbolFlags {
+	switch {
+	case symbol.Flags&ast.SymbolFlagsMethod != 0:
+		return ast.SymbolFlagsMethod
+	case symbol.Flags&ast.SymbolFlagsAccessor != 0:
+		return ast.SymbolFlagsAccessor
+	case symbol.Flags&ast.SymbolFlagsProperty != 0:
+		return ast.SymbolFlagsProperty
+	}
+	return ast.SymbolFlagsNone
+}
+
+func isAbstractDeclaration(declaration *ast.Node) bool {
+	return ast.HasSyntacticModifier(declaration, ast.ModifierFlagsAbstract)
+}
+
+// insertOverrideModifier adds `override` after the accessibility, `static` or `abstract` modifier
+// of a member, or before the rest of it if it has none.
+func (ct *changeTracker) insertOverrideModifier(sourceFile *ast.SourceFile, member *ast.Node) {
+	var lastDecorator, lastModifier *ast.Node
+	if modifiers := member.Modifiers(); modifiers != nil {
+		for _, modifier := range modifiers.Nodes {
+			switch {
+			case ast.IsDecorator(modifier):
+				lastDecorator = modifier
+			case ast.ModifierToFlag(modifier.Kind)&(ast.ModifierFlagsAccessibilityModifier|ast.ModifierFlagsStatic|ast.ModifierFlagsAbstract) != 0:
+				lastModifier = modifier
+			}
+		}
+	}
+	switch {
+	case lastModifier != nil:
+		ct.insertText(sourceFile, ct.ls.createLspPosition(lastModifier.End(), sourceFile), " override")
+	case lastDecorator != nil:
+		ct.insertText(sourceFile, ct.ls.createLspPosition(scanner.SkipTrivia(sourceFile.Text(), lastDecorator.End()), sourceFile), "override ")
+	default:
+		ct.insertText(sourceFile, ct.ls.createLspPosition(scanner.GetTokenPosOfNode(member, sourceFile, false /*includeJSDoc*/), sourceFile), "override ")
+	}
+}
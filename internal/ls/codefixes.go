@@ -0,0 +1,205 @@
+package ls
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/checker"
+	"github.com/microsoft/typescript-go/internal/compiler"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+)
+
+// CodeFixAction is a single fix offered for a diagnostic.
+type CodeFixAction struct {
+	// Short name identifying the kind of fix, e.g. "import" or "unusedIdentifier".
+	FixName string `json:"fixName"`
+	// Human-readable description of the fix.
+	Description string `json:"description"`
+	// Text edits to apply, keyed by document, each in reverse document order.
+	Changes *lsproto.WorkspaceEdit `json:"changes"`
+	// Identifier of the fix-all group this fix belongs to, if any.
+	FixId string `json:"fixId,omitempty"`
+}
+
+type codeFixContext struct {
+	ctx        context.Context
+	ls         *LanguageService
+	program    *compiler.Program
+	checker    *checker.Checker
+	sourceFile *ast.SourceFile
+	diagnostic *ast.Diagnostic
+}
+
+func (c *codeFixContext) span() core.TextRange {
+	return c.diagnostic.Loc()
+}
+
+type codeFixProvider struct {
+	fixName        string
+	errorCodes     []int32
+	fixIds         []string
+	getCodeActions func(c *codeFixContext) []*CodeFixAction
+}
+
+var codeFixProviders = []*codeFixProvider{
+	importFixProvider,
+	unusedIdentifierFixProvider,
+	addMissingAwaitFixProvider,
+	implementInterfaceFixProvider,
+	addOverrideModifierFixProvider,
+}
+
+func newCodeFixAction(ct *changeTracker, fixName string, description string, fixId string) *CodeFixAction {
+	return &CodeFixAction{
+		FixName:     fixName,
+		Description: description,
+		Changes:     ct.getWorkspaceEdit(),
+		FixId:       fixId,
+	}
+}
+
+// GetCodeFixes returns the fixes available for the diagnostics overlapping the given range.
+// If errorCodes is non-empty, only diagnostics with one of those codes are considered.
+func (l *LanguageService) GetCodeFixes(ctx context.Context, fileName string, textRange core.TextRange, errorCodes []int32) ([]*CodeFixAction, error) {
+	program, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	checker, done := program.GetTypeCheckerForFile(ctx, file)
+	defer done()
+
+	var fixes []*CodeFixAction
+	for _, diagnostic := range l.getCodeFixDiagnostics(ctx, program, file) {
+		if len(errorCodes) > 0 && !slices.Contains(errorCodes, diagnostic.Code()) {
+			continue
+		}
+		if !rangesOverlapInclusive(diagnostic.Loc(), textRange) {
+			continue
+		}
+		c := &codeFixContext{
+			ctx:        ctx,
+			ls:         l,
+			program:    program,
+			checker:    checker,
+			sourceFile: file,
+			diagnostic: diagnostic,
+		}
+		for _, provider := range codeFixProviders {
+			if slices.Contains(provider.errorCodes, diagnostic.Code()) {
+				fixes = append(fixes, provider.getCodeActions(c)...)
+			}
+		}
+	}
+	return fixes, nil
+}
+
+// GetCombinedCodeFix returns the edits applying every fix with the given fix id, such as
+// "unusedIdentifier_delete" or "addMissingAwait", to the diagnostics of a file, as a single edit.
+// Identical edits from several fixes are applied once and overlapping deletions are merged. Any
+// other edit overlapping an edit of an earlier fix is dropped, so the fix it belongs to is only
+// partly applied and a later request finds the diagnostic again.
+func (l *LanguageService) GetCombinedCodeFix(ctx context.Context, fileName string, fixId string) (*lsproto.WorkspaceEdit, error) {
+	program, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	provider := core.Find(codeFixProviders, func(provider *codeFixProvider) bool {
+		return slices.Contains(provider.fixIds, fixId)
+	})
+	if provider == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFixId, fixId)
+	}
+	checker, done := program.GetTypeCheckerForFile(ctx, file)
+	defer done()
+
+	editsByDocument := make(map[lsproto.DocumentUri][]*lsproto.TextEdit)
+	for _, diagnostic := range l.getCodeFixDiagnostics(ctx, program, file) {
+		if !slices.Contains(provider.errorCodes, diagnostic.Code()) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		c := &codeFixContext{
+			ctx:        ctx,
+			ls:         l,
+			program:    program,
+			checker:    checker,
+			sourceFile: file,
+			diagnostic: diagnostic,
+		}
+		for _, action := range provider.getCodeActions(c) {
+			if action.FixId != fixId || action.Changes == nil || action.Changes.Changes == nil {
+				continue
+			}
+			for uri, edits := range *action.Changes.Changes {
+				editsByDocument[uri] = append(editsByDocument[uri], edits...)
+			}
+		}
+	}
+	changes := make(map[lsproto.DocumentUri][]*lsproto.TextEdit, len(editsByDocument))
+	for uri, edits := range editsByDocument {
+		changes[uri] = combineTextEdits(edits)
+	}
+	return newWorkspaceEdit(changes), nil
+}
+
+// combineTextEdits sorts the edits of a document and removes conflicts between them, keeping one of
+// identical edits, merging overlapping deletions into one, and dropping other edits that overlap
+// an edit already kept. Insertions at the same position are all kept, in the order they were made.
+func combineTextEdits(edits []*lsproto.TextEdit) []*lsproto.TextEdit {
+	slices.SortStableFunc(edits, func(a, b *lsproto.TextEdit) int {
+		return CompareRanges(&a.Range, &b.Range)
+	})
+	var result []*lsproto.TextEdit
+	for _, edit := range edits {
+		if isDuplicateTextEdit(result, edit) {
+			continue
+		}
+		if len(result) == 0 {
+			result = append(result, edit)
+			continue
+		}
+		last := result[len(result)-1]
+		switch {
+		case ComparePositions(edit.Range.Start, last.Range.End) >= 0 && last.Range != edit.Range,
+			isEmptyRange(last.Range) && isEmptyRange(edit.Range):
+			result = append(result, edit)
+		case last.NewText == "" && edit.NewText == "":
+			if ComparePositions(edit.Range.End, last.Range.End) > 0 {
+				result[len(result)-1] = &lsproto.TextEdit{Range: lsproto.Range{Start: last.Range.Start, End: edit.Range.End}}
+			}
+		}
+	}
+	return result
+}
+
+// isDuplicateTextEdit reports whether edit is among the last edits of sorted with the same range.
+func isDuplicateTextEdit(sorted []*lsproto.TextEdit, edit *lsproto.TextEdit) bool {
+	for i := len(sorted) - 1; i >= 0 && sorted[i].Range == edit.Range; i-- {
+		if sorted[i].NewText == edit.NewText {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmptyRange(r lsproto.Range) bool {
+	return r.Start == r.End
+}
+
+func (l *LanguageService) getCodeFixDiagnostics(ctx context.Context, program *compiler.Program, file *ast.SourceFile) []*ast.Diagnostic {
+	diagnostics := slices.Concat(
+		program.GetSyntacticDiagnostics(ctx, file),
+		program.GetSemanticDiagnostics(ctx, file),
+		program.GetSuggestionDiagnostics(ctx, file),
+	)
+	return compiler.SortAndDeduplicateDiagnostics(diagnostics)
+}
+
+func rangesOverlapInclusive(a core.TextRange, b core.TextRange) bool {
+	return a.Pos() <= b.End() && b.Pos() <= a.End()
+}
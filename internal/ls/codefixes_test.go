@@ -0,0 +1,156 @@
+package ls_test
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/microsoft/typescript-go/internal/bundled"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/ls"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"gotest.tools/v3/assert"
+)
+
+func TestGetCodeFixes(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	tests := []struct {
+		name        string
+		options     string
+		content     string
+		other       string
+		at          string
+		description string
+		expected    string
+		// code is the diagnostic reported where the fix does not apply.
+		code int32
+	}{
+		{
+			name:        "import",
+			content:     "foo();\n",
+			other:       "export function foo() {}\n",
+			at:          "foo",
+			description: `Import 'foo' from "./b"`,
+			expected:    "import { foo } from \"./b\";\nfoo();\n",
+		},
+		{
+			name:    "import of a name nothing exports",
+			content: "bar();\n",
+			other:   "export function foo() {}\n",
+			at:      "bar",
+			code:    2304,
+		},
+		{
+			name:        "unused identifier",
+			options:     `"noUnusedLocals": true`,
+			content:     "export function f() {\n    const unused = 1;\n}\n",
+			at:          "unused",
+			description: "Remove unused declaration for: 'unused'",
+			expected:    "export function f() {\n}\n",
+		},
+		{
+			name:    "unused loop variable",
+			options: `"noUnusedLocals": true`,
+			content: "export function f() {\n    for (const x of [1]) {}\n}\n",
+			at:      "x of",
+			code:    6133,
+		},
+		{
+			name:        "missing await",
+			content:     "export async function f(p: Promise<number>) {\n    return p * 2;\n}\n",
+			at:          "p * 2",
+			description: "Add 'await'",
+			expected:    "export async function f(p: Promise<number>) {\n    return await p * 2;\n}\n",
+		},
+		{
+			name:    "missing await outside of an async function",
+			content: "export function f(p: Promise<number>) {\n    return p * 2;\n}\n",
+			at:      "p * 2",
+			code:    2362,
+		},
+		{
+			name:        "implement interface",
+			content:     "interface I {\n    x: number;\n}\nexport class C implements I {\n}\n",
+			at:          "C implements",
+			description: "Implement interface 'I'",
+			expected:    "interface I {\n    x: number;\n}\nexport class C implements I {\n    x: number;\n}\n",
+		},
+		{
+			name:    "implement interface with no missing members",
+			content: "interface I {\n    x: number;\n}\nexport class C implements I {\n    private x = 1;\n}\n",
+			at:      "C implements",
+			code:    2420,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			files := map[string]any{
+				"/src/tsconfig.json": fmt.Sprintf(`{"compilerOptions": {%s}}`, test.options),
+				"/src/a.ts":          test.content,
+			}
+			if test.other != "" {
+				files["/src/b.ts"] = test.other
+			}
+			ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+			position := strings.Index(test.content, test.at)
+			fixes, err := languageService.GetCodeFixes(ctx, "/src/a.ts", core.NewTextRange(position, position), nil)
+			assert.NilError(t, err)
+			if test.description == "" {
+				diagnostics, err := languageService.GetFileDiagnostics(ctx, "/src/a.ts")
+				assert.NilError(t, err)
+				assert.Assert(t, slices.ContainsFunc(diagnostics, func(d *ls.Diagnostic) bool { return d.Code == test.code }))
+				assert.Equal(t, len(fixes), 0)
+				return
+			}
+			assert.Assert(t, len(fixes) > 0)
+			assert.Equal(t, fixes[0].Description, test.description)
+			assert.Equal(t, applyTextEdits(test.content, (*fixes[0].Changes.Changes)["file:///src/a.ts"]), test.expected)
+		})
+	}
+}
+
+func TestCombineTextEdits(t *testing.T) {
+	t.Parallel()
+
+	// Edits on the first line of "0123456789", written as start, end and new text.
+	type edit struct {
+		start, end uint32
+		newText    string
+	}
+	tests := []struct {
+		name     string
+		edits    []edit
+		expected []edit
+	}{
+		{"disjoint edits are sorted", []edit{{5, 6, "x"}, {1, 2, ""}}, []edit{{1, 2, ""}, {5, 6, "x"}}},
+		{"identical edits are applied once", []edit{{1, 2, "x"}, {1, 2, "x"}}, []edit{{1, 2, "x"}}},
+		{"overlapping deletions are merged", []edit{{3, 6, ""}, {1, 4, ""}, {2, 3, ""}}, []edit{{1, 6, ""}}},
+		{"adjacent deletions are kept apart", []edit{{1, 2, ""}, {2, 3, ""}}, []edit{{1, 2, ""}, {2, 3, ""}}},
+		{"insertions at one position are kept in order", []edit{{2, 2, "a"}, {2, 2, "b"}, {2, 2, "a"}}, []edit{{2, 2, "a"}, {2, 2, "b"}}},
+		{"insertion before a deletion is kept", []edit{{2, 4, ""}, {2, 2, "a"}}, []edit{{2, 2, "a"}, {2, 4, ""}}},
+		{"overlapping replacement is dropped", []edit{{1, 4, ""}, {3, 5, "x"}, {2, 2, "y"}}, []edit{{1, 4, ""}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			toTextEdits := func(edits []edit) []*lsproto.TextEdit {
+				result := make([]*lsproto.TextEdit, len(edits))
+				for i, e := range edits {
+					result[i] = &lsproto.TextEdit{
+						Range:   lsproto.Range{Start: lsproto.Position{Character: e.start}, End: lsproto.Position{Character: e.end}},
+						NewText: e.newText,
+					}
+				}
+				return result
+			}
+			assert.DeepEqual(t, ls.CombineTextEdits(toTextEdits(tt.edits)), toTextEdits(tt.expected))
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package ls
+
+import (
+	"strings"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+	"github.com/microsoft/typescript-go/internal/checker"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/diagnostics"
+	"github.com/microsoft/typescript-go/internal/format"
+	"github.com/microsoft/typescript-go/internal/scanner"
+)
+
+const (
+	fixNameImplementInterface = "fixClassIncorrectlyImplementsInterface"
+	fixIdImplementInterface   = "fixClassIncorrectlyImplementsInterface"
+)
+
+var implementInterfaceFixProvider = &codeFixProvider{
+	fixName: fixNameImplementInterface,
+	errorCodes: []int32{
+		diagnostics.Class_0_incorrectly_implements_interface_1.Code(),
+	},
+	fixIds:         []string{fixIdImplementInterface},
+	getCodeActions: getImplementInterfaceCodeActions,
+}
+
+func getImplementInterfaceCodeActions(c *codeFixContext) []*CodeFixAction {
+	token := astnav.GetTokenAtPosition(c.sourceFile, c.span().Pos())
+	classNode := ast.FindAncestor(token, ast.IsClassLike)
+	if classNode == nil {
+		return nil
+	}
+	var actions []*CodeFixAction
+	for _, implementedTypeNode := range ast.GetImplementsHeritageClauseElements(classNode) {
+		ct := c.ls.newChangeTracker(c.ctx)
+		if !ct.addMissingInterfaceMembers(c.sourceFile, c.checker, classNode, implementedTypeNode) {
+			continue
+		}
+		description := diagnostics.FormatMessage(diagnostics.Implement_interface_0, scanner.GetTextOfNode(implementedTypeNode))
+		actions = append(actions, newCodeFixAction(ct, fixNameImplementInterface, description.Message(), fixIdImplementInterface))
+	}
+	return actions
+}
+
+// Adds stubs for the members of the implemented type that the class does not declare.
+// Returns false if there is nothing to add.
+func (ct *changeTracker) addMissingInterfaceMembers(sourceFile *ast.SourceFile, ch *checker.Checker, classNode *ast.Node, implementedTypeNode *ast.Node) bool {
+	classSymbol := ch.GetSymbolAtLocation(core.OrElse(classNode.Name(), classNode))
+	implementedType := ch.GetTypeAtLocation(implementedTypeNode)
+	if implementedType == nil {
+		return false
+	}
+	var classType *checker.Type
+	if classSymbol != nil {
+		classType = ch.GetDeclaredTypeOfSymbol(classSymbol)
+	}
+
+	classIndentation := getLineIndentation(sourceFile, astnav.GetStartOfNode(classNode, sourceFile, false))
+	memberIndentation := classIndentation + ct.indentationUnit()
+
+	var text strings.Builder
+	for _, property := range ch.GetPropertiesOfType(implementedType) {
+		if classType != nil && ch.GetPropertyOfType(classType, property.Name) != nil {
+			continue
+		}
+		member := ct.getMemberStubText(sourceFile, ch, classNode, property, memberIndentation)
+		if member == "" {
+			continue
+		}
+		text.WriteString(ct.newLine)
+		text.WriteString(memberIndentation)
+		text.WriteString(member)
+	}
+	if text.Len() == 0 {
+		return false
+	}
+
+	members := classNode.MemberList()
+	insertPos := members.Pos()
+	if len(members.Nodes) > 0 {
+		insertPos = members.Nodes[len(members.Nodes)-1].End()
+	} else if closeBrace := classNode.End() - 1; !strings.ContainsAny(sourceFile.Text()[insertPos:closeBrace], "\r\n") {
+		// class C implements I {} -> put the closing brace on its own line
+		text.WriteString(ct.newLine)
+		text.WriteString(classIndentation)
+		if insertPos < closeBrace {
+			ct.deleteRange(sourceFile, core.NewTextRange(insertPos, closeBrace))
+		}
+	}
+	ct.insertText(sourceFile, ct.ls.createLspPosition(insertPos, sourceFile), text.String())
+	return true
+}
+
+func (ct *changeTracker) getMemberStubText(sourceFile *ast.SourceFile, ch *checker.Checker, enclosingDeclaration *ast.Node, property *ast.Symbol, indentation string) string {
+	name := property.Name
+	if !scanner.IsIdentifierText(name, sourceFile.LanguageVariant) {
+		if strings.HasPrefix(name, ast.InternalSymbolNamePrefix) {
+			return ""
+		}
+		name = quote(sourceFile, nil, name)
+	}
+	if property.Flags&ast.SymbolFlagsOptional != 0 {
+		name += "?"
+	}
+	propertyType := ch.GetTypeOfSymbol(property)
+	if property.Flags&ast.SymbolFlagsMethod != 0 {
+		signatures := ch.GetSignaturesOfType(propertyType, checker.SignatureKindCall)
+		if len(signatures) == 0 {
+			return ""
+		}
+		signature := ch.SignatureToStringEx(signatures[0], enclosingDeclaration, checker.TypeFormatFlagsNoTruncation|checker.TypeFormatFlagsWriteCallStyleSignature)
+		return name + signature + " {" + ct.newLine +
+			indentation + ct.indentationUnit() + `throw new Error("Method not implemented.");` + ct.newLine +
+			indentation + "}"
+	}
+	return name + ": " + ch.TypeToStringEx(propertyType, enclosingDeclaration, checker.TypeFormatFlagsNoTruncation) + ";"
+}
+
+func (ct *changeTracker) indentationUnit() string {
+	if ct.formatSettings.ConvertTabsToSpaces {
+		return strings.Repeat(" ", ct.formatSettings.IndentSize)
+	}
+	return "\t"
+}
+
+// Returns the whitespace preceding the first non-whitespace character of the line containing pos.
+func getLineIndentation(sourceFile *ast.SourceFile, pos int) string {
+	text := sourceFile.Text()
+	lineStart := format.GetLineStartPositionForPosition(pos, sourceFile)
+	end := lineStart
+	for end < len(text) && (text[end] == ' ' || text[end] == '\t') {
+		end++
+	}
+	return text[lineStart:end]
+}
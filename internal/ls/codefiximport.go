@@ -0,0 +1,74 @@
+package ls
+
+import (
+	"strings"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+	"github.com/microsoft/typescript-go/internal/diagnostics"
+)
+
+const (
+	fixNameImport      = "import"
+	fixIdMissingImport = "fixMissingImport"
+)
+
+var importFixProvider = &codeFixProvider{
+	fixName: fixNameImport,
+	errorCodes: []int32{
+		diagnostics.Cannot_find_name_0.Code(),
+		diagnostics.Cannot_find_name_0_Did_you_mean_1.Code(),
+	},
+	fixIds:         []string{fixIdMissingImport},
+	getCodeActions: getMissingImportCodeActions,
+}
+
+func getMissingImportCodeActions(c *codeFixContext) []*CodeFixAction {
+	token := astnav.GetTokenAtPosition(c.sourceFile, c.span().Pos())
+	if token == nil || !ast.IsIdentifier(token) {
+		return nil
+	}
+	preferences := &UserPreferences{}
+	fix := c.ls.getMissingImportFix(c, token, preferences)
+	if fix == nil {
+		return nil
+	}
+	ct := c.ls.newChangeTracker(c.ctx)
+	description := c.ls.codeActionForFixWorker(ct, c.sourceFile, token.Text(), fix, true /*includeSymbolNameInDescription*/, preferences)
+	if description == nil {
+		return nil
+	}
+	return []*CodeFixAction{newCodeFixAction(ct, fixNameImport, description.Message(), fixIdMissingImport)}
+}
+
+// Finds the best way to import an exported symbol whose name exactly matches the unresolved identifier.
+func (l *LanguageService) getMissingImportFix(c *codeFixContext, token *ast.Node, preferences *UserPreferences) *ImportFix {
+	exportInfos := l.getMissingImportExportInfos(c, token)
+	if len(exportInfos) == 0 {
+		return nil
+	}
+	return l.getImportFixForSymbol(c.checker, c.sourceFile, exportInfos, c.span().Pos(), nil /*isValidTypeOnlySite*/, preferences)
+}
+
+// Returns the exports whose name exactly matches the unresolved identifier.
+func (l *LanguageService) getMissingImportExportInfos(c *codeFixContext, token *ast.Node) []*SymbolExportInfo {
+	symbolName := token.Text()
+	var exportInfos []*SymbolExportInfo
+	l.searchExportInfosForCompletions(
+		c.ctx,
+		c.checker,
+		c.sourceFile,
+		nil,   /*preferences*/
+		false, /*isForImportStatementCompletion*/
+		false, /*isRightOfOpenTag*/
+		ast.IsPartOfTypeNode(token),
+		strings.ToLower(symbolName),
+		func(infos []*SymbolExportInfo, name string, _ bool, _ ExportInfoMapKey) []*SymbolExportInfo {
+			if name == symbolName {
+				exportInfos = append(exportInfos, infos...)
+			}
+			return nil
+		},
+	)
+	return exportInfos
+}
@@ -0,0 +1,174 @@
+package ls
+
+import (
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/diagnostics"
+)
+
+const (
+	fixNameUnusedIdentifier     = "unusedIdentifier"
+	fixIdUnusedIdentifierDelete = "unusedIdentifier_delete"
+	fixIdUnusedIdentifierPrefix = "unusedIdentifier_prefix"
+)
+
+var unusedIdentifierFixProvider = &codeFixProvider{
+	fixName: fixNameUnusedIdentifier,
+	errorCodes: []int32{
+		diagnostics.X_0_is_declared_but_its_value_is_never_read.Code(),
+		diagnostics.X_0_is_declared_but_never_used.Code(),
+		diagnostics.Property_0_is_declared_but_its_value_is_never_read.Code(),
+		diagnostics.All_imports_in_import_declaration_are_unused.Code(),
+		diagnostics.All_destructured_elements_are_unused.Code(),
+		diagnostics.All_variables_are_unused.Code(),
+		diagnostics.All_type_parameters_are_unused.Code(),
+	},
+	fixIds:         []string{fixIdUnusedIdentifierDelete, fixIdUnusedIdentifierPrefix},
+	getCodeActions: getUnusedIdentifierCodeActions,
+}
+
+func getUnusedIdentifierCodeActions(c *codeFixContext) []*CodeFixAction {
+	token := astnav.GetTokenAtPosition(c.sourceFile, c.span().Pos())
+	if token == nil {
+		return nil
+	}
+
+	deleteAction := func(description *diagnostics.Message, del func(ct *changeTracker)) []*CodeFixAction {
+		ct := c.ls.newChangeTracker(c.ctx)
+		del(ct)
+		return []*CodeFixAction{newCodeFixAction(ct, fixNameUnusedIdentifier, description.Message(), fixIdUnusedIdentifierDelete)}
+	}
+
+	switch c.diagnostic.Code() {
+	case diagnostics.All_type_parameters_are_unused.Code():
+		return deleteAction(diagnostics.FormatMessage(diagnostics.Remove_type_parameters), func(ct *changeTracker) {
+			ct.deleteRange(c.sourceFile, c.span())
+		})
+	case diagnostics.All_imports_in_import_declaration_are_unused.Code():
+		importDecl := ast.FindAncestorKind(token, ast.KindImportDeclaration)
+		if importDecl == nil {
+			return nil
+		}
+		return deleteAction(diagnostics.FormatMessage(diagnostics.Remove_import_from_0, importDecl.ModuleSpecifier().Text()), func(ct *changeTracker) {
+			ct.deleteStatement(c.sourceFile, importDecl)
+		})
+	case diagnostics.All_variables_are_unused.Code():
+		statement := ast.FindAncestorKind(token, ast.KindVariableStatement)
+		if statement == nil {
+			return nil
+		}
+		return deleteAction(diagnostics.FormatMessage(diagnostics.Remove_variable_statement), func(ct *changeTracker) {
+			ct.deleteStatement(c.sourceFile, statement)
+		})
+	case diagnostics.All_destructured_elements_are_unused.Code():
+		pattern := token.Parent
+		if pattern == nil || !ast.IsBindingPattern(pattern) || !ast.IsVariableDeclaration(pattern.Parent) {
+			return nil
+		}
+		return deleteAction(diagnostics.FormatMessage(diagnostics.Remove_unused_destructuring_declaration), func(ct *changeTracker) {
+			ct.deleteVariableDeclaration(c.sourceFile, pattern.Parent)
+		})
+	}
+
+	if !ast.IsIdentifier(token) && !ast.IsPrivateIdentifier(token) {
+		return nil
+	}
+	name := token.Text()
+	declaration := token.Parent
+	if ast.IsParameter(declaration) {
+		return getUnusedParameterCodeActions(c, token, declaration)
+	}
+	if !canDeleteUnusedDeclaration(declaration, token) {
+		return nil
+	}
+	return deleteAction(diagnostics.FormatMessage(diagnostics.Remove_unused_declaration_for_Colon_0, name), func(ct *changeTracker) {
+		ct.deleteUnusedDeclaration(c.sourceFile, declaration)
+	})
+}
+
+func getUnusedParameterCodeActions(c *codeFixContext, name *ast.Node, parameter *ast.Node) []*CodeFixAction {
+	var actions []*CodeFixAction
+	parameters := parameter.Parent.Parameters()
+	if len(parameters) > 0 && parameters[len(parameters)-1] == parameter && !ast.IsSetAccessorDeclaration(parameter.Parent) {
+		ct := c.ls.newChangeTracker(c.ctx)
+		ct.deleteNodeInList(c.sourceFile, parameter)
+		actions = append(actions, newCodeFixAction(ct, fixNameUnusedIdentifier, diagnostics.FormatMessage(diagnostics.Remove_unused_declaration_for_Colon_0, name.Text()).Message(), fixIdUnusedIdentifierDelete))
+	}
+	if ast.IsIdentifier(name) && name.Text()[0] != '_' {
+		ct := c.ls.newChangeTracker(c.ctx)
+		ct.insertText(c.sourceFile, c.ls.createLspPosition(astnav.GetStartOfNode(name, c.sourceFile, false), c.sourceFile), "_")
+		actions = append(actions, newCodeFixAction(ct, fixNameUnusedIdentifier, diagnostics.FormatMessage(diagnostics.Prefix_0_with_an_underscore, name.Text()).Message(), fixIdUnusedIdentifierPrefix))
+	}
+	return actions
+}
+
+func canDeleteUnusedDeclaration(declaration *ast.Node, name *ast.Node) bool {
+	if declaration == nil || declaration.Name() != name {
+		return false
+	}
+	switch declaration.Kind {
+	case ast.KindImportSpecifier, ast.KindImportClause, ast.KindNamespaceImport, ast.KindImportEqualsDeclaration,
+		ast.KindFunctionDeclaration, ast.KindClassDeclaration, ast.KindInterfaceDeclaration, ast.KindTypeAliasDeclaration,
+		ast.KindEnumDeclaration, ast.KindModuleDeclaration, ast.KindTypeParameter,
+		ast.KindPropertyDeclaration, ast.KindMethodDeclaration, ast.KindGetAccessor, ast.KindSetAccessor:
+		return true
+	case ast.KindVariableDeclaration:
+		return ast.IsVariableDeclarationList(declaration.Parent) && !ast.IsForInOrOfStatement(declaration.Parent.Parent)
+	case ast.KindBindingElement:
+		return declaration.Parent.Kind == ast.KindObjectBindingPattern
+	}
+	return false
+}
+
+func (ct *changeTracker) deleteUnusedDeclaration(sourceFile *ast.SourceFile, declaration *ast.Node) {
+	switch declaration.Kind {
+	case ast.KindImportSpecifier:
+		namedImports := declaration.Parent
+		importClause := namedImports.Parent
+		if len(namedImports.Elements()) > 1 {
+			ct.deleteNodeInList(sourceFile, declaration)
+		} else if importClause.Name() != nil {
+			// import a, { b } from "c" -> import a from "c"
+			ct.deleteRange(sourceFile, core.NewTextRange(importClause.Name().End(), namedImports.End()))
+		} else {
+			ct.deleteStatement(sourceFile, importClause.Parent)
+		}
+	case ast.KindImportClause:
+		namedBindings := declaration.AsImportClause().NamedBindings
+		if namedBindings == nil {
+			ct.deleteStatement(sourceFile, declaration.Parent)
+		} else {
+			// import a, { b } from "c" -> import { b } from "c"
+			ct.deleteRange(sourceFile, core.NewTextRange(astnav.GetStartOfNode(declaration.Name(), sourceFile, false), astnav.GetStartOfNode(namedBindings, sourceFile, false)))
+		}
+	case ast.KindNamespaceImport:
+		importClause := declaration.Parent
+		if importClause.Name() != nil {
+			// import a, * as b from "c" -> import a from "c"
+			ct.deleteRange(sourceFile, core.NewTextRange(importClause.Name().End(), declaration.End()))
+		} else {
+			ct.deleteStatement(sourceFile, importClause.Parent)
+		}
+	case ast.KindVariableDeclaration:
+		ct.deleteVariableDeclaration(sourceFile, declaration)
+	case ast.KindTypeParameter, ast.KindBindingElement:
+		ct.deleteNodeInList(sourceFile, declaration)
+	default:
+		ct.deleteStatement(sourceFile, declaration)
+	}
+}
+
+func (ct *changeTracker) deleteVariableDeclaration(sourceFile *ast.SourceFile, declaration *ast.Node) {
+	declarationList := declaration.Parent
+	if len(declarationList.AsVariableDeclarationList().Declarations.Nodes) > 1 {
+		ct.deleteNodeInList(sourceFile, declaration)
+	} else if ast.IsVariableStatement(declarationList.Parent) {
+		ct.deleteStatement(sourceFile, declarationList.Parent)
+	}
+}
+
+// Deletes a statement or member along with its leading comments and the rest of its last line.
+func (ct *changeTracker) deleteStatement(sourceFile *ast.SourceFile, node *ast.Node) {
+	ct.deleteNode(sourceFile, node, leadingTriviaOptionIncludeAll, trailingTriviaOptionInclude)
+}
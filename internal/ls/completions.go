@@ -514,7 +514,7 @@ func (l *LanguageService) getCompletionData(
 		// Completion should work inside certain JSDoc tags. For example:
 		//     /** @type {number | string} */
 		// Completion should work in the brackets
-		if tag := getJSDocTagAtPosition(currentToken, position); tag != nil {
+		if tag := getJSDocTagAtPosition(file, currentToken, position); tag != nil {
 			if tag.TagName().Pos() <= position && position <= tag.TagName().End() {
 				return &completionDataJSDocTagName{}
 			}
@@ -4522,6 +4522,7 @@ func (l *LanguageService) createLSPCompletionItem(
 		Source:     source,
 		Name:       name,
 		AutoImport: autoImportEntryData,
+		HasAction:  hasAction,
 	}
 
 	// Text edit
@@ -4954,6 +4955,9 @@ type itemData struct {
 	Source     string               `json:"source,omitempty"`
 	Name       string               `json:"name,omitempty"`
 	AutoImport *completionEntryData `json:"autoImport,omitempty"`
+	// HasAction is set if the entry needs additional edits when selected, such as the import of
+	// an auto-import entry.
+	HasAction bool `json:"hasAction,omitempty"`
 }
 
 type completionEntryData struct {
@@ -5553,8 +5557,8 @@ func hasDocComment(file *ast.SourceFile, position int) bool {
 }
 
 // Get the corresponding JSDocTag node if the position is in a JSDoc comment
-func getJSDocTagAtPosition(node *ast.Node, position int) *ast.JSDocTag {
-	return ast.FindAncestorOrQuit(node, func(n *ast.Node) ast.FindAncestorResult {
+func getJSDocTagAtPosition(file *ast.SourceFile, node *ast.Node, position int) *ast.JSDocTag {
+	if tag := ast.FindAncestorOrQuit(node, func(n *ast.Node) ast.FindAncestorResult {
 		if ast.IsJSDocTag(n) && n.Loc.ContainsInclusive(position) {
 			return ast.FindAncestorTrue
 		}
@@ -5562,7 +5566,18 @@ func getJSDocTagAtPosition(node *ast.Node, position int) *ast.JSDocTag {
 			return ast.FindAncestorQuit
 		}
 		return ast.FindAncestorFalse
-	})
+	}); tag != nil || !node.IsJSDoc() || node.AsJSDoc().Tags == nil {
+		return tag
+	}
+	// A tag whose type expression is not closed yet, as in `@param {Str`, ends where the type is being
+	// typed, so the token at the position is the comment itself.
+	for _, tag := range node.AsJSDoc().Tags.Nodes {
+		if typeExpression := tryGetTypeExpressionFromTag(tag); typeExpression != nil && typeExpression.Kind == ast.KindJSDocTypeExpression &&
+			typeExpression.End() == position && file.Text()[position-1] != '}' {
+			return tag
+		}
+	}
+	return nil
 }
 
 func tryGetTypeExpressionFromTag(tag *ast.JSDocTag) *ast.Node {
@@ -0,0 +1,200 @@
+package ls
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/scanner"
+)
+
+type CompletionInfo struct {
+	Entries      []*CompletionEntry `json:"entries"`
+	IsIncomplete bool               `json:"isIncomplete"`
+	// OptionalReplacementSpan is the span of the identifier at the position, which entries without
+	// their own replacement span replace, including the part of it after the position.
+	OptionalReplacementSpan *TextRange `json:"optionalReplacementSpan,omitempty"`
+}
+
+type CompletionEntry struct {
+	Name       string                     `json:"name"`
+	Kind       lsproto.CompletionItemKind `json:"kind"`
+	SortText   string                     `json:"sortText"`
+	InsertText string                     `json:"insertText,omitempty"`
+	// Source disambiguates entries with the same name, e.g. exports of the same name from different modules.
+	Source string `json:"source,omitempty"`
+	// HasAction is set if selecting the entry needs the additional edits returned by
+	// GetCompletionEntryDetails, such as the import of an entry for an export of a module that is
+	// not imported yet.
+	HasAction bool `json:"hasAction,omitempty"`
+	// ReplacementSpan is the span replaced by the entry when it differs from the optional replacement
+	// span of the list, e.g. the dot of a property access for an entry inserting an element access.
+	ReplacementSpan *TextRange `json:"replacementSpan,omitempty"`
+}
+
+type CompletionEntryDetails struct {
+	Name          string `json:"name"`
+	Source        string `json:"source,omitempty"`
+	Detail        string `json:"detail"`
+	Documentation string `json:"documentation"`
+	// Edits to apply along with the completion, such as the import statement for an auto-import entry.
+	AdditionalTextEdits []*lsproto.TextEdit `json:"additionalTextEdits,omitempty"`
+}
+
+// Client options for completions requested through the API, which has no snippet or label details support.
+// The edit range item default makes the list carry the replacement span of its entries.
+var apiCompletionClientOptions = &lsproto.CompletionClientCapabilities{
+	CompletionItem: &lsproto.ClientCompletionItemOptions{},
+	CompletionList: &lsproto.CompletionListCapabilities{
+		ItemDefaults: &[]string{"editRange"},
+	},
+}
+
+// GetCompletions returns the completion entries at a position. If filterByPrefix is set, only the
+// entries starting with the part of the identifier at the position before it are returned, ignoring
+// case; otherwise filtering is left to the client. With the IncludeCompletionsForModuleExports
+// preference, the entries include the exports of the modules of the program and of ambient modules
+// that are not in scope, ranked after the symbols in scope.
+func (l *LanguageService) GetCompletions(ctx context.Context, fileName string, position int, filterByPrefix bool) (*CompletionInfo, error) {
+	_, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	list := l.getCompletionsAtPosition(ctx, file, position, nil /*triggerCharacter*/, l.getUserPreferences(), apiCompletionClientOptions)
+	list = ensureItemData(file.FileName(), position, list)
+	info := &CompletionInfo{Entries: []*CompletionEntry{}}
+	if list == nil {
+		return info, nil
+	}
+	info.IsIncomplete = list.IsIncomplete
+	var defaultReplacementSpan *lsproto.Range
+	if list.ItemDefaults != nil && list.ItemDefaults.EditRange != nil && list.ItemDefaults.EditRange.EditRangeWithInsertReplace != nil {
+		defaultReplacementSpan = &list.ItemDefaults.EditRange.EditRangeWithInsertReplace.Replace
+		replacementSpan := l.newTextRange(file, l.converters.FromLSPRange(file, *defaultReplacementSpan))
+		info.OptionalReplacementSpan = &replacementSpan
+	} else if hasDocComment(file, position) {
+		// The names of JSDoc tags and of the parameters they document are not identifiers of the
+		// tree, so their span is taken from the text.
+		replacementSpan := l.newTextRange(file, getJSDocNameRangeAtPosition(file, position))
+		info.OptionalReplacementSpan = &replacementSpan
+	}
+	var prefix string
+	// The replacement span can start after the position, e.g. right after the `<` of a closing
+	// JSX tag, which leaves no prefix to filter by.
+	if filterByPrefix && info.OptionalReplacementSpan != nil && info.OptionalReplacementSpan.StartPos <= position {
+		prefix = strings.ToLower(file.Text()[info.OptionalReplacementSpan.StartPos:position])
+	}
+	for _, item := range list.Items {
+		if prefix != "" && !strings.HasPrefix(strings.ToLower(item.Label), prefix) {
+			continue
+		}
+		entry := &CompletionEntry{
+			Name:      item.Label,
+			Source:    completionItemSource(item),
+			HasAction: completionItemHasAction(item),
+		}
+		if item.Kind != nil {
+			entry.Kind = *item.Kind
+		}
+		if item.SortText != nil {
+			entry.SortText = *item.SortText
+		}
+		if item.InsertText != nil {
+			entry.InsertText = *item.InsertText
+		}
+		if item.TextEdit != nil {
+			var newText string
+			var replacementSpan lsproto.Range
+			if item.TextEdit.TextEdit != nil {
+				newText, replacementSpan = item.TextEdit.TextEdit.NewText, item.TextEdit.TextEdit.Range
+			} else {
+				newText, replacementSpan = item.TextEdit.InsertReplaceEdit.NewText, item.TextEdit.InsertReplaceEdit.Replace
+			}
+			if newText != item.Label {
+				entry.InsertText = newText
+			}
+			if defaultReplacementSpan == nil || replacementSpan != *defaultReplacementSpan {
+				entryReplacementSpan := l.newTextRange(file, l.converters.FromLSPRange(file, replacementSpan))
+				entry.ReplacementSpan = &entryReplacementSpan
+			}
+		}
+		info.Entries = append(info.Entries, entry)
+	}
+	return info, nil
+}
+
+// GetCompletionEntryDetails resolves the details of a single entry returned by GetCompletions at the same
+// position: its documentation, its signature or type, and for auto-import entries the edit inserting the import.
+func (l *LanguageService) GetCompletionEntryDetails(ctx context.Context, fileName string, position int, entryName string, source string) (*CompletionEntryDetails, error) {
+	program, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	preferences := &UserPreferences{IncludeCompletionsForModuleExports: core.TSTrue}
+	list := l.getCompletionsAtPosition(ctx, file, position, nil /*triggerCharacter*/, preferences, apiCompletionClientOptions)
+	list = ensureItemData(file.FileName(), position, list)
+	if list == nil {
+		return nil, nil
+	}
+	for _, item := range list.Items {
+		if item.Label != entryName || completionItemSource(item) != source {
+			continue
+		}
+		data, err := GetCompletionItemData(item)
+		if err != nil {
+			return nil, err
+		}
+		item = l.getCompletionItemDetails(ctx, program, position, file, item, data, apiCompletionClientOptions, preferences)
+		details := &CompletionEntryDetails{
+			Name:   entryName,
+			Source: source,
+		}
+		if item.Detail != nil {
+			details.Detail = *item.Detail
+		}
+		if item.Documentation != nil && item.Documentation.MarkupContent != nil {
+			details.Documentation = item.Documentation.MarkupContent.Value
+		}
+		if item.AdditionalTextEdits != nil {
+			details.AdditionalTextEdits = *item.AdditionalTextEdits
+		}
+		return details, nil
+	}
+	return nil, nil
+}
+
+func completionItemSource(item *lsproto.CompletionItem) string {
+	if item.Data == nil {
+		return ""
+	}
+	if data, ok := (*item.Data).(*itemData); ok {
+		return data.Source
+	}
+	return ""
+}
+
+func completionItemHasAction(item *lsproto.CompletionItem) bool {
+	if item.Data == nil {
+		return false
+	}
+	if data, ok := (*item.Data).(*itemData); ok {
+		return data.HasAction
+	}
+	return false
+}
+
+// getJSDocNameRangeAtPosition returns the range of the identifier characters around position.
+func getJSDocNameRangeAtPosition(file *ast.SourceFile, position int) core.TextRange {
+	text := file.Text()
+	start, end := position, position
+	for start > 0 && scanner.IsIdentifierPart(rune(text[start-1])) {
+		start--
+	}
+	for end < len(text) && scanner.IsIdentifierPart(rune(text[end])) {
+		end++
+	}
+	return core.NewTextRange(start, end)
+}
@@ -2,11 +2,13 @@ package ls
 
 import (
 	"context"
+	"fmt"
 	"slices"
 
 	"github.com/microsoft/typescript-go/internal/ast"
 	"github.com/microsoft/typescript-go/internal/astnav"
 	"github.com/microsoft/typescript-go/internal/checker"
+	"github.com/microsoft/typescript-go/internal/collections"
 	"github.com/microsoft/typescript-go/internal/core"
 	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
 	"github.com/microsoft/typescript-go/internal/scanner"
@@ -47,6 +49,36 @@ func (l *LanguageService) ProvideDefinition(ctx context.Context, documentURI lsp
 		}
 	}
 
+	return l.createLocationsFromDeclarations(getDefinitionDeclarations(c, node)), nil
+}
+
+// GetDefinition returns the locations of the declarations of the symbol at the given position. With
+// the PreferGoToSourceDefinition preference, declarations in the output declaration files of project
+// references are mapped to the source files they were built from.
+func (l *LanguageService) GetDefinition(ctx context.Context, fileName string, position int) ([]DefinitionLocation, error) {
+	program, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	result := []DefinitionLocation{}
+	node := astnav.GetTouchingPropertyName(file, position)
+	if node.Kind == ast.KindSourceFile {
+		return result, nil
+	}
+
+	c, done := program.GetTypeCheckerForFile(ctx, file)
+	defer done()
+
+	var seen collections.Set[*ast.Node]
+	for _, declaration := range getDefinitionDeclarations(c, node) {
+		if seen.AddIfAbsent(declaration) {
+			result = append(result, l.newDefinitionLocation(declaration))
+		}
+	}
+	return l.mapDefinitionLocations(result), nil
+}
+
+func getDefinitionDeclarations(c *checker.Checker, node *ast.Node) []*ast.Node {
 	declarations := getDeclarationsFromLocation(c, node)
 	calledDeclaration := tryGetSignatureDeclaration(c, node)
 	if calledDeclaration != nil {
@@ -54,7 +86,7 @@ func (l *LanguageService) ProvideDefinition(ctx context.Context, documentURI lsp
 		nonFunctionDeclarations := core.Filter(slices.Clip(declarations), func(node *ast.Node) bool { return !ast.IsFunctionLike(node) })
 		declarations = append(nonFunctionDeclarations, calledDeclaration)
 	}
-	return l.createLocationsFromDeclarations(declarations), nil
+	return declarations
 }
 
 func (l *LanguageService) ProvideTypeDefinition(ctx context.Context, documentURI lsproto.DocumentUri, position lsproto.Position) (lsproto.DefinitionResponse, error) {
@@ -0,0 +1,216 @@
+package ls
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/collections"
+	"github.com/microsoft/typescript-go/internal/compiler"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/module"
+	"github.com/microsoft/typescript-go/internal/tspath"
+	"github.com/zeebo/xxh3"
+)
+
+// DiagnosticsCache retains per-file diagnostics across language service instances, so that
+// repeated diagnostics requests only recheck files that changed since the previous request.
+//
+// Entries are keyed by a version computed from the file's content and the content of every
+// file it can observe: the files it transitively imports, all global (non-module) files, such as
+// lib files, and all modules that augment the global scope or other modules. The version also
+// covers the module and type reference resolutions of the files it imports, failed ones
+// included, and the type reference resolutions of the program, such as those of type roots, so
+// that an import that starts resolving, or resolves elsewhere, invalidates the entry.
+// A file whose version is unchanged returns its cached diagnostics without being checked.
+type DiagnosticsCache struct {
+	mu              sync.Mutex
+	compilerOptions *core.CompilerOptions
+	entries         map[tspath.Path]*diagnosticsCacheEntry
+}
+
+type diagnosticsCacheEntry struct {
+	version     xxh3.Uint128
+	diagnostics []*ast.Diagnostic
+}
+
+func NewDiagnosticsCache() *DiagnosticsCache {
+	return &DiagnosticsCache{
+		entries: make(map[tspath.Path]*diagnosticsCacheEntry),
+	}
+}
+
+// SetDiagnosticsCache makes the language service reuse diagnostics from the given cache.
+func (l *LanguageService) SetDiagnosticsCache(cache *DiagnosticsCache) {
+	l.diagnosticsCache = cache
+}
+
+// forEachFileDiagnostics invokes fn with the syntactic and semantic diagnostics of each file in the
+// program, collecting them only for files whose version changed since they were last cached.
+// Iteration stops at the first error returned by fn.
+func (c *DiagnosticsCache) forEachFileDiagnostics(ctx context.Context, program *compiler.Program, fn func(sourceFile *ast.SourceFile, diagnostics []*ast.Diagnostic) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.compilerOptions != program.Options() {
+		c.compilerOptions = program.Options()
+		clear(c.entries)
+	}
+
+	versioner := newDiagnosticsVersioner(program)
+	sourceFiles := program.GetSourceFiles()
+	seen := make(map[tspath.Path]struct{}, len(sourceFiles))
+	for _, sourceFile := range sourceFiles {
+		seen[sourceFile.Path()] = struct{}{}
+		version := versioner.version(sourceFile)
+		entry, ok := c.entries[sourceFile.Path()]
+		if !ok || entry.version != version {
+			entry = &diagnosticsCacheEntry{version: version, diagnostics: collectFileDiagnostics(ctx, program, sourceFile)}
+			if ctx.Err() == nil {
+				c.entries[sourceFile.Path()] = entry
+			}
+		}
+		if err := fn(sourceFile, entry.diagnostics); err != nil {
+			return err
+		}
+	}
+	for path := range c.entries {
+		if _, ok := seen[path]; !ok {
+			delete(c.entries, path)
+		}
+	}
+	return nil
+}
+
+func collectFileDiagnostics(ctx context.Context, program *compiler.Program, sourceFile *ast.SourceFile) []*ast.Diagnostic {
+	var diagnostics []*ast.Diagnostic
+	diagnostics = append(diagnostics, program.GetSyntacticDiagnostics(ctx, sourceFile)...)
+	diagnostics = append(diagnostics, program.GetSemanticDiagnostics(ctx, sourceFile)...)
+	return diagnostics
+}
+
+type diagnosticsVersioner struct {
+	program      *compiler.Program
+	contentHash  map[*ast.SourceFile]xxh3.Uint128
+	globalFiles  []*ast.SourceFile
+	dependencies map[*ast.SourceFile][]*ast.SourceFile
+	// typeReferencesHash is the hash of every type reference resolution of the program.
+	typeReferencesHash xxh3.Uint128
+}
+
+func newDiagnosticsVersioner(program *compiler.Program) *diagnosticsVersioner {
+	v := &diagnosticsVersioner{
+		program:      program,
+		contentHash:  make(map[*ast.SourceFile]xxh3.Uint128),
+		dependencies: make(map[*ast.SourceFile][]*ast.SourceFile),
+	}
+	for _, sourceFile := range program.GetSourceFiles() {
+		// `declare global` and `declare module "x"` in a module are visible from files that do
+		// not import it.
+		if !ast.IsExternalOrCommonJSModule(sourceFile) || len(sourceFile.ModuleAugmentations) > 0 {
+			v.globalFiles = append(v.globalFiles, sourceFile)
+		}
+	}
+	hasher := xxh3.New()
+	typeReferences := program.GetResolvedTypeReferenceDirectives()
+	for _, path := range slices.Sorted(maps.Keys(typeReferences)) {
+		_, _ = hasher.WriteString(string(path))
+		writeResolutions(hasher, typeReferences[path], resolvedTypeReferenceFileName)
+	}
+	v.typeReferencesHash = hasher.Sum128()
+	return v
+}
+
+func resolvedModuleFileName(resolved *module.ResolvedModule) string {
+	if !resolved.IsResolved() {
+		return ""
+	}
+	return resolved.ResolvedFileName
+}
+
+func resolvedTypeReferenceFileName(resolved *module.ResolvedTypeReferenceDirective) string {
+	if resolved == nil {
+		return ""
+	}
+	return resolved.ResolvedFileName
+}
+
+// writeResolutions writes the names looked up in a file, with their modes and the file each
+// resolved to, or an empty name if it failed to resolve.
+func writeResolutions[T any](hasher *xxh3.Hasher, resolutions module.ModeAwareCache[T], resolvedFileName func(T) string) {
+	keys := slices.SortedFunc(maps.Keys(resolutions), func(a, b module.ModeAwareCacheKey) int {
+		return cmp.Or(strings.Compare(a.Name, b.Name), cmp.Compare(a.Mode, b.Mode))
+	})
+	for _, key := range keys {
+		_, _ = hasher.WriteString(key.Name)
+		_, _ = hasher.Write([]byte{byte(key.Mode), 0})
+		_, _ = hasher.WriteString(resolvedFileName(resolutions[key]))
+		_, _ = hasher.Write([]byte{0})
+	}
+}
+
+func (v *diagnosticsVersioner) hash(sourceFile *ast.SourceFile) xxh3.Uint128 {
+	if h, ok := v.contentHash[sourceFile]; ok {
+		return h
+	}
+	h := xxh3.HashString128(sourceFile.Text())
+	v.contentHash[sourceFile] = h
+	return h
+}
+
+func (v *diagnosticsVersioner) imports(sourceFile *ast.SourceFile) []*ast.SourceFile {
+	if imports, ok := v.dependencies[sourceFile]; ok {
+		return imports
+	}
+	var imports []*ast.SourceFile
+	for _, resolved := range v.program.GetResolvedModules()[sourceFile.Path()] {
+		if resolved == nil || !resolved.IsResolved() {
+			continue
+		}
+		if file := v.program.GetSourceFile(resolved.ResolvedFileName); file != nil {
+			imports = append(imports, file)
+		}
+	}
+	v.dependencies[sourceFile] = imports
+	return imports
+}
+
+func (v *diagnosticsVersioner) version(sourceFile *ast.SourceFile) xxh3.Uint128 {
+	hasher := xxh3.New()
+	write := func(file *ast.SourceFile) {
+		h := v.hash(file).Bytes()
+		_, _ = hasher.WriteString(file.FileName())
+		_, _ = hasher.Write(h[:])
+	}
+	resolvedModules := v.program.GetResolvedModules()
+	writeWithResolutions := func(file *ast.SourceFile) {
+		write(file)
+		writeResolutions(hasher, resolvedModules[file.Path()], resolvedModuleFileName)
+	}
+
+	var visited collections.Set[*ast.SourceFile]
+	queue := []*ast.SourceFile{sourceFile}
+	visited.Add(sourceFile)
+	for len(queue) > 0 {
+		file := queue[0]
+		queue = queue[1:]
+		writeWithResolutions(file)
+		for _, dependency := range v.imports(file) {
+			if visited.AddIfAbsent(dependency) {
+				queue = append(queue, dependency)
+			}
+		}
+	}
+	for _, file := range v.globalFiles {
+		if !visited.Has(file) {
+			write(file)
+		}
+	}
+	typeReferencesHash := v.typeReferencesHash.Bytes()
+	_, _ = hasher.Write(typeReferencesHash[:])
+	return hasher.Sum128()
+}
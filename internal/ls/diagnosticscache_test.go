@@ -0,0 +1,131 @@
+package ls_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/microsoft/typescript-go/internal/bundled"
+	"github.com/microsoft/typescript-go/internal/ls"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/testutil/projecttestutil"
+	"github.com/microsoft/typescript-go/internal/tspath"
+	"gotest.tools/v3/assert"
+)
+
+func TestDiagnosticsCache(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	files := map[string]any{
+		"/src/tsconfig.json": "{}",
+		"/src/a.ts":          "export const a: number = 'a';",
+		"/src/b.ts":          "export const b: string = 1;",
+	}
+	session, _ := projecttestutil.Setup(files)
+	ctx := projecttestutil.WithRequestID(context.Background())
+	session.DidOpenFile(ctx, "file:///src/a.ts", 1, files["/src/a.ts"].(string), lsproto.LanguageKindTypeScript)
+	session.DidOpenFile(ctx, "file:///src/b.ts", 1, files["/src/b.ts"].(string), lsproto.LanguageKindTypeScript)
+
+	cache := ls.NewDiagnosticsCache()
+	getDiagnostics := func() []ls.Diagnostic {
+		languageService, err := session.GetLanguageService(ctx, "file:///src/b.ts")
+		assert.NilError(t, err)
+		languageService.SetDiagnosticsCache(cache)
+		return languageService.GetDiagnostics(ctx)
+	}
+
+	diagnostics := getDiagnostics()
+	assert.Equal(t, len(diagnostics), 2)
+	aBefore, ok := cache.CachedDiagnostics(tspath.Path("/src/a.ts"))
+	assert.Assert(t, ok)
+	bBefore, ok := cache.CachedDiagnostics(tspath.Path("/src/b.ts"))
+	assert.Assert(t, ok)
+
+	session.DidChangeFile(ctx, "file:///src/b.ts", 2, []lsproto.TextDocumentContentChangePartialOrWholeDocument{{
+		WholeDocument: &lsproto.TextDocumentContentChangeWholeDocument{Text: "export const b: string = 'b';"},
+	}})
+
+	diagnostics = getDiagnostics()
+	assert.Equal(t, len(diagnostics), 1)
+	assert.Equal(t, diagnostics[0].FileName, "/src/a.ts")
+
+	aAfter, ok := cache.CachedDiagnostics(tspath.Path("/src/a.ts"))
+	assert.Assert(t, ok)
+	bAfter, ok := cache.CachedDiagnostics(tspath.Path("/src/b.ts"))
+	assert.Assert(t, ok)
+	assert.Assert(t, &aBefore[0] == &aAfter[0], "untouched file should not be re-collected")
+	assert.Equal(t, len(bBefore), 1)
+	assert.Equal(t, len(bAfter), 0)
+}
+
+func TestDiagnosticsCacheGlobalAugmentation(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	files := map[string]any{
+		"/src/tsconfig.json": "{}",
+		"/src/a.ts":          "export {};\ndeclare global { var augmented: number; }",
+		"/src/b.ts":          "export const b: number = augmented;",
+	}
+	session, _ := projecttestutil.Setup(files)
+	ctx := projecttestutil.WithRequestID(context.Background())
+	session.DidOpenFile(ctx, "file:///src/a.ts", 1, files["/src/a.ts"].(string), lsproto.LanguageKindTypeScript)
+	session.DidOpenFile(ctx, "file:///src/b.ts", 1, files["/src/b.ts"].(string), lsproto.LanguageKindTypeScript)
+
+	cache := ls.NewDiagnosticsCache()
+	getDiagnostics := func() []ls.Diagnostic {
+		languageService, err := session.GetLanguageService(ctx, "file:///src/b.ts")
+		assert.NilError(t, err)
+		languageService.SetDiagnosticsCache(cache)
+		return languageService.GetDiagnostics(ctx)
+	}
+
+	assert.Equal(t, len(getDiagnostics()), 0)
+
+	// b.ts does not import a.ts, but sees its augmentation of the global scope.
+	session.DidChangeFile(ctx, "file:///src/a.ts", 2, []lsproto.TextDocumentContentChangePartialOrWholeDocument{{
+		WholeDocument: &lsproto.TextDocumentContentChangeWholeDocument{Text: "export {};\ndeclare global { var augmented: string; }"},
+	}})
+
+	diagnostics := getDiagnostics()
+	assert.Equal(t, len(diagnostics), 1)
+	assert.Equal(t, diagnostics[0].FileName, "/src/b.ts")
+}
+
+func TestDiagnosticsCacheImportStartsResolving(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	files := map[string]any{
+		"/src/tsconfig.json": "{}",
+		"/src/a.ts":          "import c from \"./c\";\nexport const a = c;",
+	}
+	session, utils := projecttestutil.Setup(files)
+	ctx := projecttestutil.WithRequestID(context.Background())
+	session.DidOpenFile(ctx, "file:///src/a.ts", 1, files["/src/a.ts"].(string), lsproto.LanguageKindTypeScript)
+
+	cache := ls.NewDiagnosticsCache()
+	getDiagnostics := func() []ls.Diagnostic {
+		languageService, err := session.GetLanguageService(ctx, "file:///src/a.ts")
+		assert.NilError(t, err)
+		languageService.SetDiagnosticsCache(cache)
+		return languageService.GetDiagnostics(ctx)
+	}
+
+	diagnostics := getDiagnostics()
+	assert.Equal(t, len(diagnostics), 1)
+	assert.Equal(t, diagnostics[0].Code, int32(2307))
+
+	// a.ts is unchanged, but the module it failed to find now resolves to a JavaScript file,
+	// which is not part of the program.
+	assert.NilError(t, utils.FS().WriteFile("/src/c.js", "module.exports = 1;", false))
+	session.DidChangeWatchedFiles(ctx, []*lsproto.FileEvent{{Type: lsproto.FileChangeTypeCreated, Uri: "file:///src/c.js"}})
+
+	assert.Equal(t, len(getDiagnostics()), 0)
+}
@@ -0,0 +1,86 @@
+package ls
+
+import (
+	"context"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/compiler"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/modulespecifiers"
+	"github.com/microsoft/typescript-go/internal/scanner"
+	"github.com/microsoft/typescript-go/internal/tspath"
+)
+
+// GetEditsForFileRename returns the edits that update module specifiers after oldFileName is
+// moved to newFileName: imports of the file from the rest of the program, and relative imports
+// in the moved file itself. A specifier keeps its style, so an import through a `paths` mapping
+// stays mapped if the new location is covered by one.
+func (l *LanguageService) GetEditsForFileRename(ctx context.Context, oldFileName string, newFileName string) (*lsproto.WorkspaceEdit, error) {
+	program := l.GetProgram()
+	oldPath := toPath(program, oldFileName)
+	changes := make(map[lsproto.DocumentUri][]*lsproto.TextEdit)
+	for _, file := range program.GetSourceFiles() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if program.IsSourceFileDefaultLibrary(file.Path()) || program.IsSourceFileFromExternalLibrary(file) {
+			continue
+		}
+		isMovedFile := file.Path() == oldPath
+		importingFileName := file.FileName()
+		if isMovedFile {
+			importingFileName = newFileName
+		}
+		for _, specifier := range file.Imports() {
+			newSpecifier := l.getUpdatedModuleSpecifier(program, file, importingFileName, specifier, oldPath, newFileName, isMovedFile)
+			if newSpecifier == "" || newSpecifier == specifier.Text() {
+				continue
+			}
+			// Replace the contents of the string literal, keeping its quotes.
+			start := scanner.GetTokenPosOfNode(specifier, file, false /*includeJSDoc*/) + 1
+			uri := FileNameToDocumentURI(file.FileName())
+			changes[uri] = append(changes[uri], &lsproto.TextEdit{
+				Range:   *l.createLspRangeFromBounds(start, specifier.End()-1, file),
+				NewText: newSpecifier,
+			})
+		}
+	}
+	return newWorkspaceEdit(changes), nil
+}
+
+// getUpdatedModuleSpecifier returns the specifier that should replace specifier once the
+// file at oldPath is moved, or "" if it does not need to change.
+func (l *LanguageService) getUpdatedModuleSpecifier(
+	program *compiler.Program,
+	file *ast.SourceFile,
+	importingFileName string,
+	specifier *ast.StringLiteralLike,
+	oldPath tspath.Path,
+	newFileName string,
+	isMovedFile bool,
+) string {
+	resolved := program.GetResolvedModuleFromModuleSpecifier(file, specifier)
+	if resolved == nil || !resolved.IsResolved() || resolved.IsExternalLibraryImport {
+		return ""
+	}
+	targetFileName := resolved.ResolvedFileName
+	if toPath(program, targetFileName) == oldPath {
+		targetFileName = newFileName
+	} else if !isMovedFile || !tspath.PathIsRelative(specifier.Text()) {
+		// Non-relative imports of other files resolve the same way from the new location.
+		return ""
+	}
+	return modulespecifiers.GetModuleSpecifier(
+		program.Options(),
+		program,
+		file,
+		importingFileName,
+		specifier.Text(),
+		targetFileName,
+		modulespecifiers.ModuleSpecifierOptions{},
+	)
+}
+
+func toPath(program *compiler.Program, fileName string) tspath.Path {
+	return tspath.ToPath(fileName, program.GetCurrentDirectory(), program.UseCaseSensitiveFileNames())
+}
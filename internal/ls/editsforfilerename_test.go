@@ -0,0 +1,57 @@
+package ls_test
+
+import (
+	"testing"
+
+	"github.com/microsoft/typescript-go/internal/bundled"
+	"gotest.tools/v3/assert"
+)
+
+func TestGetEditsForFileRename(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	files := map[string]any{
+		"/src/tsconfig.json":    `{}`,
+		"/src/a.ts":             "import { util } from \"./lib/util\";\nimport { helper } from \"./lib/helper\";\nimport * as lib from \"./lib\";\n",
+		"/src/b.ts":             "import { util } from './lib/util.js';\n",
+		"/src/lib/index.ts":     "export * from \"./util\";\n",
+		"/src/lib/util.ts":      "import { helper } from \"./helper\";\nexport const util = helper;\n",
+		"/src/lib/helper.ts":    "export const helper = 1;\n",
+		"/src/lib/unrelated.ts": "import { helper } from \"./helper\";\n",
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	// renamed returns the text of each file the edits of renaming oldFileName to newFileName change.
+	renamed := func(oldFileName string, newFileName string) map[string]string {
+		edit, err := languageService.GetEditsForFileRename(ctx, oldFileName, newFileName)
+		assert.NilError(t, err)
+		result := make(map[string]string)
+		for uri, edits := range *edit.Changes {
+			fileName := uri.FileName()
+			result[fileName] = applyTextEdits(files[fileName].(string), edits)
+		}
+		return result
+	}
+
+	// Moving a file into a directory of its own updates its importers to the directory, and its
+	// own relative imports to the new location. Non-relative and unaffected imports are kept.
+	assert.DeepEqual(t, renamed("/src/lib/util.ts", "/src/shared/util/index.ts"), map[string]string{
+		"/src/a.ts":         "import { util } from \"./shared/util\";\nimport { helper } from \"./lib/helper\";\nimport * as lib from \"./lib\";\n",
+		"/src/b.ts":         "import { util } from './shared/util/index.js';\n",
+		"/src/lib/index.ts": "export * from \"../shared/util\";\n",
+		"/src/lib/util.ts":  "import { helper } from \"../../lib/helper\";\nexport const util = helper;\n",
+	})
+
+	// Renaming an index file changes the imports of its directory.
+	assert.DeepEqual(t, renamed("/src/lib/index.ts", "/src/lib/main.ts"), map[string]string{
+		"/src/a.ts": "import { util } from \"./lib/util\";\nimport { helper } from \"./lib/helper\";\nimport * as lib from \"./lib/main\";\n",
+	})
+
+	// Moving a file nothing imports only updates its own relative imports.
+	assert.DeepEqual(t, renamed("/src/lib/unrelated.ts", "/src/unrelated.ts"), map[string]string{
+		"/src/lib/unrelated.ts": "import { helper } from \"./lib/helper\";\n",
+	})
+}
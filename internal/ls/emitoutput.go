@@ -0,0 +1,46 @@
+package ls
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/microsoft/typescript-go/internal/compiler"
+)
+
+type OutputFile struct {
+	Name               string `json:"name"`
+	Text               string `json:"text"`
+	WriteByteOrderMark bool   `json:"writeByteOrderMark"`
+}
+
+type EmitOutput struct {
+	OutputFiles []OutputFile `json:"outputFiles"`
+	EmitSkipped bool         `json:"emitSkipped"`
+}
+
+// GetEmitOutput returns the files that emitting the given file would write, without writing them.
+// Their line endings are those of the newLine compiler option, which defaults to the line ending
+// of the session.
+func (l *LanguageService) GetEmitOutput(ctx context.Context, fileName string) (*EmitOutput, error) {
+	program, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	output := &EmitOutput{OutputFiles: []OutputFile{}}
+	var mu sync.Mutex
+	result := program.Emit(ctx, compiler.EmitOptions{
+		TargetSourceFile: file,
+		WriteFile: func(fileName string, text string, writeByteOrderMark bool, data *compiler.WriteFileData) error {
+			mu.Lock()
+			defer mu.Unlock()
+			output.OutputFiles = append(output.OutputFiles, OutputFile{Name: fileName, Text: text, WriteByteOrderMark: writeByteOrderMark})
+			return nil
+		},
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	output.EmitSkipped = result.EmitSkipped
+	return output, nil
+}
@@ -0,0 +1,48 @@
+package ls
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+)
+
+type CommentKind string
+
+const (
+	CommentKindLine  CommentKind = "line"
+	CommentKindBlock CommentKind = "block"
+	CommentKindJSDoc CommentKind = "jsdoc"
+)
+
+// CommentRange is the range of a comment, including its delimiters.
+type CommentRange struct {
+	Kind  CommentKind `json:"kind"`
+	Range TextRange   `json:"range"`
+}
+
+// GetEnclosingComment returns the comment containing position, or nil if position is not in a
+// comment. Comments are found from the trivia around the tokens of the file, so comment delimiters
+// in strings, template literals and regular expressions, or `//` inside a block comment, are not
+// mistaken for comments. A position at the end of a line comment, or of an unterminated block
+// comment, is in the comment; a position just after the `*/` of a block comment is not.
+func (l *LanguageService) GetEnclosingComment(ctx context.Context, fileName string, position int) (*CommentRange, error) {
+	_, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	comment := isInComment(file, position, astnav.GetTokenAtPosition(file, position))
+	if comment == nil {
+		return nil, nil
+	}
+	kind := CommentKindLine
+	if comment.Kind == ast.KindMultiLineCommentTrivia {
+		kind = CommentKindBlock
+		if text := file.Text()[comment.Pos():comment.End()]; strings.HasPrefix(text, "/**") && !strings.HasPrefix(text, "/**/") {
+			kind = CommentKindJSDoc
+		}
+	}
+	return &CommentRange{Kind: kind, Range: l.newTextRange(file, comment.TextRange)}, nil
+}
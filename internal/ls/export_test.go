@@ -0,0 +1,35 @@
+package ls
+
+import (
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/tspath"
+)
+
+// CachedDiagnostics returns the cached diagnostics of a file and whether it has an entry.
+// Identity of the returned slice changes only when the file is re-collected.
+func (c *DiagnosticsCache) CachedDiagnostics(path tspath.Path) ([]*ast.Diagnostic, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[path]
+	if !ok {
+		return nil, false
+	}
+	return entry.diagnostics, true
+}
+
+// MatchPattern matches candidate against the pattern of the navigate-to matcher and returns the
+// kind of the match, or false if it does not match.
+func MatchPattern(pattern string, candidate string) (kind string, isCaseSensitive bool, ok bool) {
+	matcher := newPatternMatcher(pattern)
+	if matcher == nil {
+		return "", false, false
+	}
+	match := matcher.getFullMatch(nil, candidate)
+	if match == nil {
+		return "", false, false
+	}
+	return match.kind.String(), match.isCaseSensitive, true
+}
+
+var CombineTextEdits = combineTextEdits
+var NormalizeTextEdits = normalizeTextEdits
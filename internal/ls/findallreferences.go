@@ -474,9 +474,7 @@ func (l *LanguageService) ProvideRename(ctx context.Context, params *lsproto.Ren
 		changes[uri] = append(changes[uri], textEdit)
 	}
 	return lsproto.WorkspaceEditOrNull{
-		WorkspaceEdit: &lsproto.WorkspaceEdit{
-			Changes: &changes,
-		},
+		WorkspaceEdit: newWorkspaceEdit(changes),
 	}, nil
 }
 
@@ -0,0 +1,80 @@
+package ls
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/checker"
+	"github.com/microsoft/typescript-go/internal/collections"
+)
+
+type SymbolInformation struct {
+	Name string            `json:"name"`
+	Kind ScriptElementKind `json:"kind"`
+	// The file declaring the symbol, or "" for symbols synthesized by the checker such as `globalThis`.
+	FileName string `json:"fileName,omitempty"`
+	// Whether the symbol is a UMD global, declared with `export as namespace`.
+	IsUMDGlobal bool `json:"isUMDGlobal,omitempty"`
+}
+
+// GetGlobalSymbols returns the symbols of the program's global scope whose names start with query,
+// sorted by name. This includes the globals declared by lib files, script files and `declare global`
+// blocks, as well as the UMD globals of modules declared with `export as namespace`.
+func (l *LanguageService) GetGlobalSymbols(ctx context.Context, query string) ([]*SymbolInformation, error) {
+	program := l.GetProgram()
+	checker, done := program.GetTypeChecker(ctx)
+	defer done()
+
+	symbols := make(map[string]*ast.Symbol)
+	var umdGlobals collections.Set[*ast.Symbol]
+	for name, symbol := range checker.GetGlobals().Iter() {
+		if strings.HasPrefix(name, query) && !strings.HasPrefix(name, ast.InternalSymbolNamePrefix) {
+			symbols[name] = symbol
+		}
+	}
+	for _, file := range program.GetSourceFiles() {
+		if file.Symbol == nil || file.Symbol.GlobalExports == nil {
+			continue
+		}
+		for name, symbol := range file.Symbol.GlobalExports.Iter() {
+			if !strings.HasPrefix(name, query) {
+				continue
+			}
+			// The checker merges UMD globals into the global scope unless a global of the same name
+			// exists already, so the first declaration wins here as well.
+			if existing, ok := symbols[name]; !ok || existing == symbol || checker.GetMergedSymbol(symbol) == existing {
+				symbols[name] = symbol
+				umdGlobals.Add(symbol)
+			}
+		}
+	}
+
+	result := make([]*SymbolInformation, 0, len(symbols))
+	for _, name := range slices.Sorted(maps.Keys(symbols)) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		symbol := symbols[name]
+		result = append(result, newGlobalSymbolInformation(checker, name, symbol, umdGlobals.Has(symbol)))
+	}
+	return result, nil
+}
+
+func newGlobalSymbolInformation(ch *checker.Checker, name string, symbol *ast.Symbol, isUMDGlobal bool) *SymbolInformation {
+	info := &SymbolInformation{Name: name, IsUMDGlobal: isUMDGlobal}
+	target := ch.SkipAlias(symbol)
+	if len(target.Declarations) == 0 {
+		info.Kind = ScriptElementKindUnknown
+		if target.Flags&ast.SymbolFlagsModule != 0 {
+			info.Kind = ScriptElementKindModuleElement
+		}
+		return info
+	}
+	location := target.Declarations[0]
+	info.Kind = getSymbolKind(ch, target, location)
+	info.FileName = ast.GetSourceFileOfNode(location).FileName()
+	return info
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/microsoft/typescript-go/internal/checker"
 	"github.com/microsoft/typescript-go/internal/core"
 	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/scanner"
 )
 
 const (
@@ -43,8 +44,56 @@ func (l *LanguageService) ProvideHover(ctx context.Context, documentURI lsproto.
 	}, nil
 }
 
+type QuickInfo struct {
+	// The description of the symbol, as shown in the code block of a hover.
+	Text          string `json:"text"`
+	Documentation string `json:"documentation"`
+	// The range of the node the quick info describes.
+	Span TextRange `json:"span"`
+}
+
+// GetQuickInfo returns the description and documentation of the symbol at the given position, as
+// shown in hovers, or nil if there is no symbol there. The names of object literal properties and
+// JSX attributes describe the property of their contextual type, so an attribute of a component
+// shows the type and documentation of the prop it sets.
+func (l *LanguageService) GetQuickInfo(ctx context.Context, fileName string, position int) (*QuickInfo, error) {
+	program, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	node := astnav.GetTouchingPropertyName(file, position)
+	if node.Kind == ast.KindSourceFile {
+		return nil, nil
+	}
+	c, done := program.GetTypeCheckerForFile(ctx, file)
+	defer done()
+	quickInfo, documentation := getQuickInfoAndDocumentation(c, node)
+	if quickInfo == "" {
+		return nil, nil
+	}
+	return &QuickInfo{
+		Text:          quickInfo,
+		Documentation: documentation,
+		Span:          l.newTextRange(file, core.NewTextRange(scanner.GetTokenPosOfNode(node, file, false /*includeJSDoc*/), node.End())),
+	}, nil
+}
+
 func getQuickInfoAndDocumentation(c *checker.Checker, node *ast.Node) (string, string) {
-	return getQuickInfoAndDocumentationForSymbol(c, c.GetSymbolAtLocation(node), getNodeForQuickInfo(node))
+	return getQuickInfoAndDocumentationForSymbol(c, getSymbolAtLocationForQuickInfo(c, node), getNodeForQuickInfo(node))
+}
+
+// getSymbolAtLocationForQuickInfo returns the symbol at node. The name of an object literal property
+// or of a JSX attribute resolves to the property of the contextual type it sets, such as the prop
+// of a component, when there is exactly one.
+func getSymbolAtLocationForQuickInfo(c *checker.Checker, node *ast.Node) *ast.Symbol {
+	if element := getContainingObjectLiteralElement(node); element != nil {
+		if contextualType := c.GetContextualType(element.Parent, checker.ContextFlagsNone); contextualType != nil {
+			if properties := c.GetPropertySymbolsFromContextualType(element, contextualType, false /*unionSymbolOk*/); len(properties) == 1 {
+				return properties[0]
+			}
+		}
+	}
+	return c.GetSymbolAtLocation(node)
 }
 
 func getQuickInfoAndDocumentationForSymbol(c *checker.Checker, symbol *ast.Symbol, node *ast.Node) (string, string) {
@@ -0,0 +1,162 @@
+package ls
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+	"github.com/microsoft/typescript-go/internal/checker"
+	"github.com/microsoft/typescript-go/internal/collections"
+	"github.com/microsoft/typescript-go/internal/core"
+)
+
+// GetImplementation returns the implementations of the symbol at the given position. For an interface or
+// abstract class these are the classes extending or implementing it, directly or through other heritage
+// clauses; for a member of an interface or abstract class they are the same-named concrete members of those
+// classes. For any other symbol the result is the same as go-to-definition.
+func (l *LanguageService) GetImplementation(ctx context.Context, fileName string, position int) ([]DefinitionLocation, error) {
+	program, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	result := []DefinitionLocation{}
+	node := astnav.GetTouchingPropertyName(file, position)
+	if node.Kind == ast.KindSourceFile {
+		return result, nil
+	}
+
+	c, done := program.GetTypeChecker(ctx)
+	defer done()
+
+	var declarations []*ast.Node
+	if symbol := c.GetSymbolAtLocation(getDeclarationNameForKeyword(node)); symbol != nil {
+		symbol = c.SkipAlias(symbol)
+		switch {
+		case isAbstractTypeSymbol(symbol):
+			declarations = l.findDerivedClasses(ctx, c, symbol)
+		case symbol.Parent != nil && isAbstractTypeSymbol(symbol.Parent) && isAbstractMemberSymbol(symbol):
+			for _, class := range l.findDerivedClasses(ctx, c, symbol.Parent) {
+				declarations = append(declarations, getConcreteMemberDeclarations(c, class, symbol.Name)...)
+			}
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+	if declarations == nil {
+		declarations = getDefinitionDeclarations(c, node)
+	}
+
+	var seen collections.Set[*ast.Node]
+	for _, declaration := range declarations {
+		if seen.AddIfAbsent(declaration) {
+			result = append(result, l.newDefinitionLocation(declaration))
+		}
+	}
+	return result, nil
+}
+
+// isAbstractTypeSymbol reports whether the symbol is an interface or an abstract class, i.e. a type whose
+// members are implemented by other classes.
+func isAbstractTypeSymbol(symbol *ast.Symbol) bool {
+	if symbol.Flags&ast.SymbolFlagsInterface != 0 {
+		return true
+	}
+	if symbol.Flags&ast.SymbolFlagsClass != 0 {
+		return core.Some(symbol.Declarations, func(d *ast.Node) bool {
+			return ast.IsClassLike(d) && ast.HasSyntacticModifier(d, ast.ModifierFlagsAbstract)
+		})
+	}
+	return false
+}
+
+// isAbstractMemberSymbol reports whether the symbol is an interface member or an abstract class member.
+func isAbstractMemberSymbol(symbol *ast.Symbol) bool {
+	if symbol.Flags&(ast.SymbolFlagsMethod|ast.SymbolFlagsProperty|ast.SymbolFlagsAccessor) == 0 {
+		return false
+	}
+	return core.Some(symbol.Declarations, func(d *ast.Node) bool {
+		return d.Parent != nil && ast.IsInterfaceDeclaration(d.Parent) || ast.HasSyntacticModifier(d, ast.ModifierFlagsAbstract)
+	})
+}
+
+// findDerivedClasses scans the program's non-library files for classes whose heritage clauses reference
+// the given interface or class, either directly or through intermediate interfaces and classes.
+func (l *LanguageService) findDerivedClasses(ctx context.Context, c *checker.Checker, target *ast.Symbol) []*ast.Node {
+	program := l.GetProgram()
+	derives := make(map[*ast.Symbol]bool)
+	var derivesFromTarget func(symbol *ast.Symbol) bool
+	derivesFromTarget = func(symbol *ast.Symbol) bool {
+		if result, ok := derives[symbol]; ok {
+			return result
+		}
+		// Assume no relationship while visiting to guard against circular heritage clauses.
+		derives[symbol] = false
+		result := false
+		for _, declaration := range symbol.Declarations {
+			for _, base := range getHeritageSymbols(c, declaration) {
+				if base == target || derivesFromTarget(base) {
+					result = true
+					break
+				}
+			}
+		}
+		derives[symbol] = result
+		return result
+	}
+
+	var classes []*ast.Node
+	var visit ast.Visitor
+	visit = func(node *ast.Node) bool {
+		if ast.IsClassLike(node) {
+			if symbol := node.Symbol(); symbol != nil && derivesFromTarget(c.GetMergedSymbol(symbol)) {
+				classes = append(classes, node)
+			}
+		}
+		node.ForEachChild(visit)
+		return false
+	}
+	for _, sourceFile := range program.GetSourceFiles() {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if program.IsSourceFileDefaultLibrary(sourceFile.Path()) || program.IsSourceFileFromExternalLibrary(sourceFile) {
+			continue
+		}
+		sourceFile.AsNode().ForEachChild(visit)
+	}
+	return classes
+}
+
+func getHeritageSymbols(c *checker.Checker, declaration *ast.Node) []*ast.Symbol {
+	if !ast.IsClassLike(declaration) && !ast.IsInterfaceDeclaration(declaration) {
+		return nil
+	}
+	var symbols []*ast.Symbol
+	elements := core.Concatenate(ast.GetExtendsHeritageClauseElements(declaration), ast.GetImplementsHeritageClauseElements(declaration))
+	for _, element := range elements {
+		if symbol := c.GetSymbolAtLocation(element.Expression()); symbol != nil {
+			symbols = append(symbols, c.SkipAlias(symbol))
+		}
+	}
+	return symbols
+}
+
+func getConcreteMemberDeclarations(c *checker.Checker, class *ast.Node, name string) []*ast.Node {
+	symbol := class.Symbol()
+	if symbol == nil {
+		return nil
+	}
+	symbol = c.GetMergedSymbol(symbol)
+	if symbol.Members == nil {
+		return nil
+	}
+	member := symbol.Members.Get(name)
+	if member == nil {
+		return nil
+	}
+	return core.Filter(member.Declarations, func(d *ast.Node) bool {
+		return d.Parent == class && !ast.HasSyntacticModifier(d, ast.ModifierFlagsAbstract)
+	})
+}
@@ -0,0 +1,87 @@
+package ls_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/microsoft/typescript-go/internal/bundled"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/testutil/projecttestutil"
+	"gotest.tools/v3/assert"
+)
+
+func TestGetImplementation(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	content := `interface Base {
+    m(): void;
+}
+interface Middle extends Base {}
+class Impl implements Middle {
+    m() {}
+}
+class Sub extends Impl {}
+interface Loop1 extends Loop2 {}
+interface Loop2 extends Loop1 {}
+class LoopImpl implements Loop1 {}
+abstract class Shape {
+    abstract area(): number;
+}
+class Square extends Shape {
+    area() { return 1; }
+}
+`
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/a.ts":          content,
+	}
+	session, _ := projecttestutil.Setup(files)
+	ctx := projecttestutil.WithRequestID(context.Background())
+	session.DidOpenFile(ctx, "file:///src/a.ts", 1, files["/src/a.ts"].(string), lsproto.LanguageKindTypeScript)
+	languageService, err := session.GetLanguageService(ctx, "file:///src/a.ts")
+	assert.NilError(t, err)
+
+	// implementations returns the start of each implementation found at the position of at.
+	implementations := func(ctx context.Context, at string) ([]int, error) {
+		locations, err := languageService.GetImplementation(ctx, "/src/a.ts", strings.Index(content, at))
+		var starts []int
+		for _, location := range locations {
+			starts = append(starts, location.StartPos)
+		}
+		return starts, err
+	}
+
+	tests := []struct {
+		at       string
+		expected []string
+	}{
+		// Classes implementing Base through Middle, and extending such a class, implement it too.
+		{at: "Base {", expected: []string{"Impl implements", "Sub extends"}},
+		{at: "m(): void", expected: []string{"m() {}"}},
+		// Circular heritage clauses end the search rather than recursing forever.
+		{at: "Loop1 extends", expected: []string{"LoopImpl"}},
+		{at: "Shape {", expected: []string{"Square"}},
+		// Abstract members resolve to their concrete overrides.
+		{at: "area(): number", expected: []string{"area() {"}},
+		// Anything else resolves to its definition.
+		{at: "Impl {}", expected: []string{"Impl implements"}},
+	}
+	for _, test := range tests {
+		starts, err := implementations(ctx, test.at)
+		assert.NilError(t, err)
+		var expected []int
+		for _, at := range test.expected {
+			expected = append(expected, strings.Index(content, at))
+		}
+		assert.DeepEqual(t, starts, expected)
+	}
+
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = implementations(cancelledCtx, "Base {")
+	assert.ErrorIs(t, err, context.Canceled)
+}
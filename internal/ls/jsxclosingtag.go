@@ -0,0 +1,90 @@
+package ls
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+	"github.com/microsoft/typescript-go/internal/scanner"
+)
+
+type JsxClosingTagInfo struct {
+	// NewText is the text to insert at the position to close the element, such as `</div>` after
+	// an opening tag, `div>` after a `</` or `</>` after an opening fragment.
+	NewText string `json:"newText"`
+}
+
+// GetJsxClosingTag returns the closing tag to insert at the given position, just after the opening
+// tag of a JSX element or fragment, in its children or after the `</` of its closing tag, if the
+// element or fragment is not closed. Nested elements with the same tag name are matched to closing
+// tags as the parser nests them, so that an inner element left open is closed rather than the
+// outer one. It returns nil if there is no unclosed element at the position.
+func (l *LanguageService) GetJsxClosingTag(ctx context.Context, fileName string, position int) (*JsxClosingTagInfo, error) {
+	_, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	token := astnav.FindPrecedingToken(file, position)
+	if token == nil {
+		return nil, nil
+	}
+	parent := token.Parent
+
+	// <div>| or <div>text|
+	var element *ast.Node
+	switch {
+	case token.Kind == ast.KindGreaterThanToken && ast.IsJsxOpeningElement(parent):
+		element = parent.Parent
+	case ast.IsJsxText(token) && ast.IsJsxElement(parent):
+		element = parent
+	}
+	if element != nil && isUnclosedJsxElement(element) {
+		return &JsxClosingTagInfo{NewText: "</" + scanner.GetTextOfNode(element.AsJsxElement().OpeningElement.TagName()) + ">"}, nil
+	}
+
+	// <>| or <>text|
+	var fragment *ast.Node
+	switch {
+	case token.Kind == ast.KindGreaterThanToken && ast.IsJsxOpeningFragment(parent):
+		fragment = parent.Parent
+	case ast.IsJsxText(token) && ast.IsJsxFragment(parent):
+		fragment = parent
+	}
+	if fragment != nil && isUnclosedJsxFragment(fragment) {
+		return &JsxClosingTagInfo{NewText: "</>"}, nil
+	}
+
+	// <div></| or <></|
+	if token.Kind == ast.KindLessThanSlashToken && token.End() == position {
+		switch {
+		case ast.IsJsxClosingElement(parent) && isUnclosedJsxElement(parent.Parent):
+			return &JsxClosingTagInfo{NewText: scanner.GetTextOfNode(parent.Parent.AsJsxElement().OpeningElement.TagName()) + ">"}, nil
+		case parent.Kind == ast.KindJsxClosingFragment && isUnclosedJsxFragment(parent.Parent):
+			return &JsxClosingTagInfo{NewText: ">"}, nil
+		}
+	}
+	return nil, nil
+}
+
+// isUnclosedJsxElement reports whether the closing tag the parser matched to a JSX element does
+// not close it, either because its tag name is different or because it closes an enclosing
+// element with the same tag name that is itself unclosed.
+func isUnclosedJsxElement(element *ast.Node) bool {
+	jsxElement := element.AsJsxElement()
+	tagName := jsxElement.OpeningElement.TagName()
+	if !ast.TagNamesAreEquivalent(tagName, jsxElement.ClosingElement.TagName()) {
+		return true
+	}
+	parent := element.Parent
+	return ast.IsJsxElement(parent) && ast.TagNamesAreEquivalent(tagName, parent.AsJsxElement().OpeningElement.TagName()) && isUnclosedJsxElement(parent)
+}
+
+// isUnclosedJsxFragment reports whether a JSX fragment, or an enclosing fragment, is missing its
+// closing fragment.
+func isUnclosedJsxFragment(fragment *ast.Node) bool {
+	if fragment.AsJsxFragment().ClosingFragment.Flags&ast.NodeFlagsThisNodeHasError != 0 {
+		return true
+	}
+	return ast.IsJsxFragment(fragment.Parent) && isUnclosedJsxFragment(fragment.Parent)
+}
@@ -1,10 +1,15 @@
 package ls
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/binder"
 	"github.com/microsoft/typescript-go/internal/compiler"
 	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
 	"github.com/microsoft/typescript-go/internal/sourcemap"
+	"github.com/microsoft/typescript-go/internal/tspath"
 )
 
 type LanguageService struct {
@@ -12,6 +17,11 @@ type LanguageService struct {
 	program                 *compiler.Program
 	converters              *Converters
 	documentPositionMappers map[string]*sourcemap.DocumentPositionMapper
+	diagnosticsCache        *DiagnosticsCache
+	userPreferences         *UserPreferences
+	// boundFile is the only file of a language service created by NewBindOnlyLanguageService,
+	// which has no program.
+	boundFile *ast.SourceFile
 }
 
 func NewLanguageService(
@@ -26,6 +36,35 @@ func NewLanguageService(
 	}
 }
 
+// NewBindOnlyLanguageService returns a language service for the structural features of a single
+// file, such as document symbols and outlining spans, which only need the file to be parsed and
+// bound. It has no program, so it can be created without loading the project of the file, but only
+// the features going through GetBoundSourceFile can be used on it.
+func NewBindOnlyLanguageService(file *ast.SourceFile, host Host) *LanguageService {
+	return &LanguageService{
+		host:                    host,
+		converters:              host.Converters(),
+		documentPositionMappers: map[string]*sourcemap.DocumentPositionMapper{},
+		boundFile:               file,
+	}
+}
+
+// GetBoundSourceFile returns a file parsed and bound, with the symbols it declares in its locals,
+// without type checking it or any other file of the program.
+func (l *LanguageService) GetBoundSourceFile(ctx context.Context, fileName string) (*ast.SourceFile, error) {
+	var file *ast.SourceFile
+	if l.program != nil {
+		file = l.program.GetSourceFile(fileName)
+	} else if l.boundFile != nil && l.boundFile.Path() == tspath.ToPath(fileName, "", l.UseCaseSensitiveFileNames()) {
+		file = l.boundFile
+	}
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	binder.BindSourceFile(file)
+	return file, nil
+}
+
 func (l *LanguageService) GetProgram() *compiler.Program {
 	return l.program
 }
@@ -0,0 +1,198 @@
+package ls
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/stringutil"
+)
+
+// MissingImportsFix is the result of GetFixAllMissingImports.
+type MissingImportsFix struct {
+	Edit *lsproto.WorkspaceEdit `json:"edit"`
+	// Ambiguous lists the names that exports of different symbols could provide. The edit does not
+	// import them, so that the editor can let the user pick a module.
+	Ambiguous []*AmbiguousImport `json:"ambiguous,omitempty"`
+}
+
+// AmbiguousImport is a name used by a file that several modules export as different symbols.
+type AmbiguousImport struct {
+	Name string `json:"name"`
+	// Modules are the file names of the modules, or the names of ambient modules, in the form
+	// accepted by GetAutoImportEdit.
+	Modules []string `json:"modules"`
+}
+
+// newModuleImports are the imports to add to a single import declaration.
+type newModuleImports struct {
+	moduleSpecifier     string
+	defaultImport       *Import
+	namedImports        []*Import
+	namespaceLikeImport *Import
+}
+
+// GetFixAllMissingImports returns a single edit importing every name that a "Cannot find name"
+// diagnostic of the file reports and that an export of another module provides. Names imported
+// from the same module share one import declaration, and names from a module the file already
+// imports are added to the existing declaration.
+func (l *LanguageService) GetFixAllMissingImports(ctx context.Context, fileName string) (*MissingImportsFix, error) {
+	program, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	checker, done := program.GetTypeCheckerForFile(ctx, file)
+	defer done()
+
+	preferences := &UserPreferences{}
+	result := &MissingImportsFix{}
+	ct := l.newChangeTracker(ctx)
+	var newImports []*newModuleImports
+	existingImports := make(map[*ast.Node]*newModuleImports)
+	var existingClauses []*ast.Node
+	promoted := make(map[*ast.Node]bool)
+	seen := make(map[string]bool)
+	for _, diagnostic := range l.getCodeFixDiagnostics(ctx, program, file) {
+		if !slices.Contains(importFixProvider.errorCodes, diagnostic.Code()) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		token := astnav.GetTokenAtPosition(file, diagnostic.Pos())
+		if token == nil || !ast.IsIdentifier(token) || seen[token.Text()] {
+			continue
+		}
+		symbolName := token.Text()
+		c := &codeFixContext{
+			ctx:        ctx,
+			ls:         l,
+			program:    program,
+			checker:    checker,
+			sourceFile: file,
+			diagnostic: diagnostic,
+		}
+		exportInfos := l.getMissingImportExportInfos(c, token)
+		if len(exportInfos) == 0 {
+			continue
+		}
+		if modules := l.getAmbiguousExportModules(c, exportInfos); modules != nil {
+			seen[symbolName] = true
+			result.Ambiguous = append(result.Ambiguous, &AmbiguousImport{Name: symbolName, Modules: modules})
+			continue
+		}
+		fix := l.getImportFixForSymbol(checker, file, exportInfos, diagnostic.Pos(), nil /*isValidTypeOnlySite*/, preferences)
+		if fix == nil {
+			continue
+		}
+		// A qualification only applies to the usage of the name at the position of the diagnostic,
+		// so the other usages are fixed separately.
+		var qualification *Qualification
+		if fix.kind == ImportFixKindAddNew || fix.kind == ImportFixKindUseNamespace {
+			qualification = fix.qualification()
+		}
+		if qualification != nil {
+			ct.addNamespaceQualifier(file, qualification)
+		} else {
+			seen[symbolName] = true
+		}
+		imp := &Import{name: symbolName, addAsTypeOnly: fix.addAsTypeOnly}
+		switch fix.kind {
+		case ImportFixKindAddToExisting:
+			imports := existingImports[fix.importClauseOrBindingPattern]
+			if imports == nil {
+				imports = &newModuleImports{}
+				existingImports[fix.importClauseOrBindingPattern] = imports
+				existingClauses = append(existingClauses, fix.importClauseOrBindingPattern)
+			}
+			imports.add(fix.importKind, imp)
+		case ImportFixKindAddNew:
+			if fix.useRequire {
+				// !!! require
+				continue
+			}
+			if fix.importKind == ImportKindNamespace || fix.importKind == ImportKindCommonJS {
+				imp.kind = fix.importKind
+				if qualification != nil {
+					imp.name = qualification.namespacePrefix
+				}
+				if !slices.ContainsFunc(newImports, func(imports *newModuleImports) bool {
+					return imports.namespaceLikeImport != nil && imports.namespaceLikeImport.name == imp.name
+				}) {
+					newImports = append(newImports, &newModuleImports{moduleSpecifier: fix.moduleSpecifier, namespaceLikeImport: imp})
+				}
+				continue
+			}
+			index := slices.IndexFunc(newImports, func(imports *newModuleImports) bool {
+				return imports.moduleSpecifier == fix.moduleSpecifier && imports.namespaceLikeImport == nil
+			})
+			if index < 0 {
+				index = len(newImports)
+				newImports = append(newImports, &newModuleImports{moduleSpecifier: fix.moduleSpecifier})
+			}
+			newImports[index].add(fix.importKind, imp)
+		case ImportFixKindPromoteTypeOnly:
+			if !promoted[fix.typeOnlyAliasDeclaration] {
+				promoted[fix.typeOnlyAliasDeclaration] = true
+				ct.promoteFromTypeOnly(file, fix.typeOnlyAliasDeclaration)
+			}
+		}
+	}
+
+	for _, clause := range existingClauses {
+		imports := existingImports[clause]
+		ct.doAddExistingFix(file, clause, imports.defaultImport, imports.namedImports, preferences)
+	}
+	var declarations []*ast.Statement
+	for _, imports := range newImports {
+		declarations = append(declarations, ct.getNewImports(imports.moduleSpecifier, imports.defaultImport, imports.namedImports, imports.namespaceLikeImport, program.Options(), preferences)...)
+	}
+	if len(declarations) > 0 {
+		ct.insertImports(file, declarations, true /*blankLineBetween*/, preferences)
+	}
+
+	changes := make(map[lsproto.DocumentUri][]*lsproto.TextEdit)
+	if edits := ct.getChanges()[file.FileName()]; len(edits) > 0 {
+		changes[FileNameToDocumentURI(file.FileName())] = combineTextEdits(edits)
+	}
+	result.Edit = newWorkspaceEdit(changes)
+	return result, nil
+}
+
+func (imports *newModuleImports) add(kind ImportKind, imp *Import) {
+	if kind == ImportKindDefault {
+		imports.defaultImport = imp
+		return
+	}
+	index, _ := slices.BinarySearchFunc(imports.namedImports, imp, func(a, b *Import) int {
+		return stringutil.CompareStringsCaseInsensitiveThenSensitive(a.name, b.name)
+	})
+	imports.namedImports = slices.Insert(imports.namedImports, index, imp)
+}
+
+// getAmbiguousExportModules returns the modules of exportInfos if they export more than one
+// distinct symbol, and nil otherwise. Re-exports of the same symbol are not ambiguous.
+func (l *LanguageService) getAmbiguousExportModules(c *codeFixContext, exportInfos []*SymbolExportInfo) []string {
+	target := c.checker.GetMergedSymbol(c.checker.SkipAlias(exportInfos[0].symbol))
+	if core.Every(exportInfos[1:], func(info *SymbolExportInfo) bool {
+		return c.checker.GetMergedSymbol(c.checker.SkipAlias(info.symbol)) == target
+	}) {
+		return nil
+	}
+	var modules []string
+	for _, info := range exportInfos {
+		module := info.moduleFileName
+		if module == "" {
+			module = stringutil.StripQuotes(info.moduleSymbol.Name)
+		}
+		if !slices.Contains(modules, module) {
+			modules = append(modules, module)
+		}
+	}
+	slices.Sort(modules)
+	return modules
+}
@@ -0,0 +1,115 @@
+package ls
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/binder"
+	"github.com/microsoft/typescript-go/internal/checker"
+	"github.com/microsoft/typescript-go/internal/collections"
+)
+
+type ExportedSymbolInfo struct {
+	// The name the symbol is exported as.
+	Name string `json:"name"`
+	// The name of the symbol in the module declaring the export, e.g. `x` in `export { x as y }`.
+	LocalName string            `json:"localName"`
+	Kind      ScriptElementKind `json:"kind"`
+	// Whether the export can only be used as a type, either because it is exported with `export type`
+	// or because the symbol has no value meaning.
+	IsTypeOnly bool `json:"isTypeOnly"`
+	// The module declaring the export. This differs from the requested module for names re-exported with `export *`.
+	FileName string `json:"fileName"`
+}
+
+// GetModuleExports returns the exports of a module, including names re-exported from other modules with `export *`.
+// A name declared by the module itself takes precedence over the same name re-exported from another module.
+// Exports are sorted by name, with the module's own exports first.
+func (l *LanguageService) GetModuleExports(ctx context.Context, fileName string) ([]*ExportedSymbolInfo, error) {
+	program, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	result := []*ExportedSymbolInfo{}
+	if file.Symbol == nil {
+		return result, nil
+	}
+	checker, done := program.GetTypeCheckerForFile(ctx, file)
+	defer done()
+
+	var seenNames collections.Set[string]
+	var visitedModules collections.Set[*ast.Symbol]
+	var collect func(moduleSymbol *ast.Symbol, moduleFile *ast.SourceFile, isStarExport bool)
+	collect = func(moduleSymbol *ast.Symbol, moduleFile *ast.SourceFile, isStarExport bool) {
+		if !visitedModules.AddIfAbsent(moduleSymbol) || moduleSymbol.Exports == nil {
+			return
+		}
+		var starExports *ast.Symbol
+		for _, name := range slices.Sorted(moduleSymbol.Exports.Keys()) {
+			symbol := moduleSymbol.Exports.Get(name)
+			if name == ast.InternalSymbolNameExportStar {
+				starExports = symbol
+				continue
+			}
+			// `export *` does not re-export the default export.
+			if isStarExport && name == ast.InternalSymbolNameDefault || !seenNames.AddIfAbsent(name) {
+				continue
+			}
+			result = append(result, newExportedSymbolInfo(checker, moduleFile, name, symbol))
+		}
+		if starExports == nil {
+			return
+		}
+		for _, declaration := range starExports.Declarations {
+			if !ast.IsExportDeclaration(declaration) || declaration.ModuleSpecifier() == nil {
+				continue
+			}
+			target := checker.ResolveExternalModuleName(declaration.ModuleSpecifier())
+			if target == nil {
+				continue
+			}
+			targetFile := moduleFile
+			if target.ValueDeclaration != nil && ast.IsSourceFile(target.ValueDeclaration) {
+				targetFile = target.ValueDeclaration.AsSourceFile()
+			}
+			collect(target, targetFile, true /*isStarExport*/)
+		}
+	}
+	collect(file.Symbol, file, false /*isStarExport*/)
+	return result, nil
+}
+
+func newExportedSymbolInfo(ch *checker.Checker, file *ast.SourceFile, name string, symbol *ast.Symbol) *ExportedSymbolInfo {
+	target := ch.SkipAlias(symbol)
+	location := file.AsNode()
+	if len(target.Declarations) > 0 {
+		location = target.Declarations[0]
+	}
+	return &ExportedSymbolInfo{
+		Name:       name,
+		LocalName:  getExportLocalName(name, symbol),
+		Kind:       getSymbolKind(ch, target, location),
+		IsTypeOnly: ch.GetTypeOnlyAliasDeclaration(symbol) != nil || target.Flags&ast.SymbolFlagsValue == 0,
+		FileName:   file.FileName(),
+	}
+}
+
+func getExportLocalName(name string, symbol *ast.Symbol) string {
+	if localSymbol := binder.GetLocalSymbolForExportDefault(symbol); localSymbol != nil {
+		return localSymbol.Name
+	}
+	for _, declaration := range symbol.Declarations {
+		switch {
+		case ast.IsExportSpecifier(declaration):
+			if propertyName := declaration.PropertyName(); propertyName != nil {
+				return propertyName.Text()
+			}
+			return declaration.Name().Text()
+		case ast.IsExportAssignment(declaration) && ast.IsIdentifier(declaration.Expression()):
+			return declaration.Expression().Text()
+		}
+	}
+	return name
+}
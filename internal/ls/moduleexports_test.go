@@ -0,0 +1,66 @@
+package ls_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/microsoft/typescript-go/internal/bundled"
+	"github.com/microsoft/typescript-go/internal/ls"
+	"gotest.tools/v3/assert"
+)
+
+func TestGetModuleExports(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	files := map[string]any{
+		"/src/tsconfig.json": `{"compilerOptions": {"allowJs": true}}`,
+		"/src/a.ts": `export const own = 1;
+export { renamed as alias } from "./b";
+export type { Shape } from "./b";
+export * from "./c";
+export default function main() {}
+`,
+		"/src/b.ts": "export const renamed = 1;\nexport interface Shape {}\n",
+		"/src/c.ts": "export const fromC = 1;\nexport const own = 2;\nexport default 3;\nexport * from \"./a\";\n",
+		"/src/d.js": "exports.f = function () {};\nmodule.exports.g = 1;\n",
+	}
+	ctx, languageService := newTestLanguageService(t, files, "/src/a.ts")
+
+	describe := func(fileName string) []string {
+		exports, err := languageService.GetModuleExports(ctx, fileName)
+		assert.NilError(t, err)
+		var result []string
+		for _, export := range exports {
+			result = append(result, fmt.Sprintf("%s %s %s %v %s", export.Name, export.LocalName, export.Kind, export.IsTypeOnly, export.FileName))
+		}
+		return result
+	}
+
+	// The module's own exports come first, then those re-exported with `export *`, except for the
+	// default export and names the module exports itself.
+	assert.DeepEqual(t, describe("/src/a.ts"), []string{
+		"Shape Shape interface true /src/a.ts",
+		"alias renamed const false /src/a.ts",
+		"default main function false /src/a.ts",
+		"own own const false /src/a.ts",
+		"fromC fromC const false /src/c.ts",
+	})
+	// Modules re-exporting each other with `export *` are each visited once.
+	assert.DeepEqual(t, describe("/src/c.ts"), []string{
+		"default default property false /src/c.ts",
+		"fromC fromC const false /src/c.ts",
+		"own own const false /src/c.ts",
+		"Shape Shape interface true /src/a.ts",
+		"alias renamed const false /src/a.ts",
+	})
+	assert.DeepEqual(t, describe("/src/d.js"), []string{
+		"f f var false /src/d.js",
+		"g g var false /src/d.js",
+	})
+
+	_, err := languageService.GetModuleExports(ctx, "/src/missing.ts")
+	assert.ErrorIs(t, err, ls.ErrNoSourceFile)
+}
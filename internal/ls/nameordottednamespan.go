@@ -0,0 +1,78 @@
+package ls
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/scanner"
+)
+
+// GetNameOrDottedNameSpan returns the span of the name at start together with the names it is a
+// property of, such as `a.b` for a position in `b` of `a.b.c`, for debuggers to evaluate. Element
+// accesses with literal arguments are part of the dotted name, but the span stops at a call or at
+// another expression whose evaluation could have side effects: a position in `c` of `a.b().c` only
+// yields `c`. It returns nil if there is no name at start. end is ignored.
+func (l *LanguageService) GetNameOrDottedNameSpan(ctx context.Context, fileName string, start int, end int) (*TextRange, error) {
+	_, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	node := astnav.GetTouchingPropertyName(file, start)
+	if node.Flags&ast.NodeFlagsReparsed != 0 {
+		// Reparsed nodes, such as the implicit export modifier of B in `namespace A.B {}`, are
+		// empty and touch the name that follows them.
+		node = astnav.GetTokenAtPosition(file, start)
+	}
+	switch node.Kind {
+	case ast.KindPropertyAccessExpression, ast.KindQualifiedName, ast.KindStringLiteral, ast.KindFalseKeyword, ast.KindTrueKeyword,
+		ast.KindNullKeyword, ast.KindSuperKeyword, ast.KindThisKeyword, ast.KindThisType, ast.KindIdentifier:
+	case ast.KindNumericLiteral:
+		if !isLiteralArgumentOfElementAccess(node) {
+			return nil, nil
+		}
+	default:
+		return nil, nil
+	}
+
+	nodeForStart := node
+	spanEnd := node.End()
+	for {
+		parent := nodeForStart.Parent
+		if isRightSideOfPropertyAccess(nodeForStart) && isDottedNameExpression(parent.Expression()) ||
+			ast.IsQualifiedName(parent) && parent.AsQualifiedName().Right == nodeForStart {
+			nodeForStart = parent
+		} else if isLiteralArgumentOfElementAccess(nodeForStart) && isDottedNameExpression(parent.Expression()) {
+			nodeForStart = parent
+			spanEnd = parent.End()
+		} else if isNameOfModuleDeclaration(nodeForStart) && ast.IsModuleDeclaration(parent.Parent) && parent.Parent.Body() == parent {
+			// The name of B in `namespace A.B {}` is dotted with the name of A.
+			nodeForStart = parent.Parent.Name()
+		} else {
+			break
+		}
+	}
+	textRange := l.newTextRange(file, core.NewTextRange(scanner.GetTokenPosOfNode(nodeForStart, file, false /*includeJSDoc*/), spanEnd))
+	return &textRange, nil
+}
+
+func isLiteralArgumentOfElementAccess(node *ast.Node) bool {
+	return ast.IsElementAccessExpression(node.Parent) && node.Parent.AsElementAccessExpression().ArgumentExpression == node &&
+		(ast.IsStringLiteral(node) || ast.IsNumericLiteral(node) || node.Kind == ast.KindNoSubstitutionTemplateLiteral)
+}
+
+// isDottedNameExpression returns whether expression is a name, `this` or `super`, or property
+// accesses and element accesses with literal arguments of one.
+func isDottedNameExpression(expression *ast.Node) bool {
+	switch expression.Kind {
+	case ast.KindIdentifier, ast.KindThisKeyword, ast.KindSuperKeyword:
+		return true
+	case ast.KindPropertyAccessExpression:
+		return isDottedNameExpression(expression.Expression())
+	case ast.KindElementAccessExpression:
+		return isLiteralArgumentOfElementAccess(expression.AsElementAccessExpression().ArgumentExpression) && isDottedNameExpression(expression.Expression())
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package ls
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/stringutil"
+)
+
+type NavigateToItem struct {
+	Name string            `json:"name"`
+	Kind ScriptElementKind `json:"kind"`
+	// How the name matched the query: "exact", "prefix", "substring" or "camelCase".
+	MatchKind string `json:"matchKind"`
+	// Whether the name matched without ignoring case.
+	IsCaseSensitive bool `json:"isCaseSensitive"`
+	// The name and kind of the declaration containing this one, if any.
+	ContainerName string             `json:"containerName,omitempty"`
+	ContainerKind ScriptElementKind  `json:"containerKind,omitempty"`
+	Location      DefinitionLocation `json:"location"`
+}
+
+type navigateToMatch struct {
+	name        string
+	declaration *ast.Node
+	match       patternMatch
+}
+
+// GetNavigateTo searches the names of the declarations of the program, except those of default
+// library files and packages, with the pattern matcher of the "go to symbol" palette. See
+// patternMatcher for the forms of query that match. Items are ranked by how well they match, from
+// exact matches to camel case matches, and then by name. At most maxResults items are returned,
+// or all of them if maxResults is not positive.
+func (l *LanguageService) GetNavigateTo(ctx context.Context, query string, maxResults int) ([]NavigateToItem, error) {
+	program := l.GetProgram()
+	matcher := newPatternMatcher(query)
+	if matcher == nil {
+		return []NavigateToItem{}, nil
+	}
+	var matches []navigateToMatch
+	for _, file := range program.GetSourceFiles() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if program.IsSourceFileDefaultLibrary(file.Path()) || program.IsSourceFileFromExternalLibrary(file) {
+			continue
+		}
+		for name, declarations := range file.GetDeclarationMap() {
+			// Names that cannot match are rejected before computing the containers of each declaration.
+			if matcher.matchSegment(name, matcher.segments[len(matcher.segments)-1]) == nil {
+				continue
+			}
+			for _, declaration := range declarations {
+				if match := matcher.getFullMatch(getNavigateToContainerNames(declaration), name); match != nil {
+					matches = append(matches, navigateToMatch{name: name, declaration: declaration, match: *match})
+				}
+			}
+		}
+	}
+	slices.SortFunc(matches, compareNavigateToMatches)
+	if maxResults > 0 && len(matches) > maxResults {
+		matches = matches[:maxResults]
+	}
+
+	items := make([]NavigateToItem, 0, len(matches))
+	for _, match := range matches {
+		item := NavigateToItem{
+			Name:            match.name,
+			Kind:            getNodeKind(match.declaration),
+			MatchKind:       match.match.kind.String(),
+			IsCaseSensitive: match.match.isCaseSensitive,
+			Location:        l.newDefinitionLocation(match.declaration),
+		}
+		if container := getContainerNode(match.declaration); container != nil && !ast.IsSourceFile(container) {
+			if name := ast.GetNameOfDeclaration(container); name != nil {
+				item.ContainerName = getTextOfName(name)
+			}
+			item.ContainerKind = getNodeKind(container)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// getNavigateToContainerNames returns the names of the declarations containing declaration, innermost first.
+func getNavigateToContainerNames(declaration *ast.Node) []string {
+	var names []string
+	for container := getContainerNode(declaration); container != nil && !ast.IsSourceFile(container); container = getContainerNode(container) {
+		if name := ast.GetNameOfDeclaration(container); name != nil {
+			names = append(names, getTextOfName(name))
+		}
+	}
+	return names
+}
+
+func compareNavigateToMatches(a, b navigateToMatch) int {
+	if c := comparePatternMatches(a.match, b.match); c != 0 {
+		return c
+	}
+	if c := stringutil.CompareStringsCaseInsensitive(a.name, b.name); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.name, b.name); c != 0 {
+		return c
+	}
+	fileA := ast.GetSourceFileOfNode(a.declaration)
+	fileB := ast.GetSourceFileOfNode(b.declaration)
+	if fileA != fileB {
+		return strings.Compare(string(fileA.Path()), string(fileB.Path()))
+	}
+	return a.declaration.Pos() - b.declaration.Pos()
+}
+
+// getNodeKind returns the kind of a declaration from its syntax alone.
+func getNodeKind(node *ast.Node) ScriptElementKind {
+	switch node.Kind {
+	case ast.KindSourceFile:
+		if ast.IsExternalModule(node.AsSourceFile()) {
+			return ScriptElementKindModuleElement
+		}
+		return ScriptElementKindScriptElement
+	case ast.KindModuleDeclaration:
+		return ScriptElementKindModuleElement
+	case ast.KindClassDeclaration, ast.KindClassExpression:
+		return ScriptElementKindClassElement
+	case ast.KindInterfaceDeclaration:
+		return ScriptElementKindInterfaceElement
+	case ast.KindTypeAliasDeclaration, ast.KindJSDocCallbackTag, ast.KindJSDocTypedefTag:
+		return ScriptElementKindTypeElement
+	case ast.KindEnumDeclaration:
+		return ScriptElementKindEnumElement
+	case ast.KindVariableDeclaration:
+		return getKindOfVariableDeclaration(node)
+	case ast.KindBindingElement:
+		return getKindOfVariableDeclaration(ast.GetRootDeclaration(node))
+	case ast.KindArrowFunction, ast.KindFunctionDeclaration, ast.KindFunctionExpression:
+		return ScriptElementKindFunctionElement
+	case ast.KindGetAccessor:
+		return ScriptElementKindMemberGetAccessorElement
+	case ast.KindSetAccessor:
+		return ScriptElementKindMemberSetAccessorElement
+	case ast.KindMethodDeclaration, ast.KindMethodSignature:
+		return ScriptElementKindMemberFunctionElement
+	case ast.KindPropertyAssignment:
+		if ast.IsFunctionLike(node.Initializer()) {
+			return ScriptElementKindMemberFunctionElement
+		}
+		return ScriptElementKindMemberVariableElement
+	case ast.KindPropertyDeclaration, ast.KindPropertySignature, ast.KindShorthandPropertyAssignment, ast.KindSpreadAssignment:
+		return ScriptElementKindMemberVariableElement
+	case ast.KindIndexSignature:
+		return ScriptElementKindIndexSignatureElement
+	case ast.KindConstructSignature:
+		return ScriptElementKindConstructSignatureElement
+	case ast.KindCallSignature:
+		return ScriptElementKindCallSignatureElement
+	case ast.KindConstructor, ast.KindClassStaticBlockDeclaration:
+		return ScriptElementKindConstructorImplementationElement
+	case ast.KindTypeParameter:
+		return ScriptElementKindTypeParameterElement
+	case ast.KindEnumMember:
+		return ScriptElementKindEnumMemberElement
+	case ast.KindParameter:
+		if ast.HasSyntacticModifier(node, ast.ModifierFlagsParameterPropertyModifier) {
+			return ScriptElementKindMemberVariableElement
+		}
+		return ScriptElementKindParameterElement
+	case ast.KindImportEqualsDeclaration, ast.KindImportSpecifier, ast.KindExportSpecifier, ast.KindNamespaceImport, ast.KindNamespaceExport, ast.KindImportClause:
+		return ScriptElementKindAlias
+	}
+	return ScriptElementKindUnknown
+}
+
+func getKindOfVariableDeclaration(declaration *ast.Node) ScriptElementKind {
+	switch {
+	case ast.IsVarConst(declaration):
+		return ScriptElementKindConstElement
+	case ast.IsLet(declaration):
+		return ScriptElementKindLetElement
+	}
+	return ScriptElementKindVariableElement
+}
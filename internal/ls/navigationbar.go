@@ -0,0 +1,275 @@
+package ls
+
+import (
+	"context"
+	"slices"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/printer"
+	"github.com/microsoft/typescript-go/internal/scanner"
+	"github.com/microsoft/typescript-go/internal/stringutil"
+	"github.com/microsoft/typescript-go/internal/tspath"
+)
+
+type NavigationBarItem struct {
+	Text string            `json:"text"`
+	Kind ScriptElementKind `json:"kind"`
+	// The ranges of the declarations of the item. Merged declarations, such as the overloads of a
+	// function or the blocks of a namespace, contribute a span each.
+	Spans []TextRange `json:"spans"`
+	// The range of the name of the first declaration of the item, if it has one.
+	NameSpan *TextRange `json:"nameSpan,omitempty"`
+	// The items directly inside this one. Child items have no child items of their own: an item
+	// with children is also listed at the top level, one indent deeper than its parent.
+	ChildItems []NavigationBarItem `json:"childItems"`
+	// The depth of a top level item, starting from 0 for the file itself.
+	Indent int `json:"indent"`
+}
+
+// GetNavigationBarItems returns the structure of a file as the navigation bar of tsserver does:
+// a list of the file and of the declarations that contain other declarations, such as classes,
+// interfaces, enums and namespaces, each with its indent and its direct children. Same-named
+// sibling declarations of the same kind are merged into a single item with a span per declaration,
+// so the overloads of a function, the blocks of a namespace or the declarations of an interface
+// appear once, with their children combined. Class members only merge with members of the same
+// staticness. Unlike document symbols, items are sorted by name.
+func (l *LanguageService) GetNavigationBarItems(ctx context.Context, fileName string) ([]NavigationBarItem, error) {
+	file, err := l.GetBoundSourceFile(ctx, fileName)
+	if err != nil {
+		return nil, err
+	}
+	root := &navigationBarNode{node: file.AsNode(), name: getNavigationBarNodeName(file.AsNode())}
+	builder := &navigationBarBuilder{parent: root}
+	file.AsNode().ForEachChild(builder.visit)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	mergeNavigationBarNodeChildren(root)
+
+	var items []NavigationBarItem
+	var addPrimaryItems func(node *navigationBarNode, indent int)
+	addPrimaryItems = func(node *navigationBarNode, indent int) {
+		if node != root && !isPrimaryNavigationBarNode(node) {
+			return
+		}
+		item := l.newNavigationBarItem(file, node, indent)
+		for _, child := range node.children {
+			item.ChildItems = append(item.ChildItems, l.newNavigationBarItem(file, child, 0))
+		}
+		items = append(items, item)
+		for _, child := range node.children {
+			addPrimaryItems(child, indent+1)
+		}
+	}
+	addPrimaryItems(root, 0)
+	return items, nil
+}
+
+type navigationBarNode struct {
+	node *ast.Node
+	name string
+	// The declarations merged into node.
+	additionalNodes []*ast.Node
+	children        []*navigationBarNode
+}
+
+type navigationBarBuilder struct {
+	parent *navigationBarNode
+}
+
+// addNode adds an item for node to the current parent, and calls visitChildren, if any, with the
+// new item as the parent.
+func (b *navigationBarBuilder) addNode(node *ast.Node, visitChildren func()) *navigationBarNode {
+	if node.Flags&ast.NodeFlagsReparsed != 0 {
+		if visitChildren != nil {
+			visitChildren()
+		}
+		return nil
+	}
+	item := &navigationBarNode{node: node, name: getNavigationBarNodeName(node)}
+	b.parent.children = append(b.parent.children, item)
+	if visitChildren != nil {
+		saveParent := b.parent
+		b.parent = item
+		visitChildren()
+		b.parent = saveParent
+	}
+	return item
+}
+
+func (b *navigationBarBuilder) visitChildren(node *ast.Node) func() {
+	if node == nil {
+		return nil
+	}
+	return func() { node.ForEachChild(b.visit) }
+}
+
+func (b *navigationBarBuilder) visit(node *ast.Node) bool {
+	switch node.Kind {
+	case ast.KindClassDeclaration, ast.KindClassExpression:
+		b.addNode(node, func() { b.visitClassMembers(node) })
+	case ast.KindInterfaceDeclaration, ast.KindEnumDeclaration:
+		b.addNode(node, b.visitChildren(node))
+	case ast.KindModuleDeclaration:
+		b.addNode(node, b.visitChildren(getInteriorModule(node).Body()))
+	case ast.KindFunctionDeclaration, ast.KindMethodDeclaration, ast.KindGetAccessor, ast.KindSetAccessor, ast.KindConstructor:
+		b.addNode(node, b.visitChildren(node.Body()))
+	case ast.KindFunctionExpression, ast.KindArrowFunction:
+		// Functions that are not the value of a declaration only appear when they declare something.
+		if item := b.addNode(node, b.visitChildren(node.Body())); item != nil && len(item.children) == 0 {
+			b.parent.children = b.parent.children[:len(b.parent.children)-1]
+		}
+	case ast.KindVariableDeclaration, ast.KindBindingElement, ast.KindPropertyDeclaration, ast.KindPropertyAssignment:
+		if name := node.Name(); name != nil && ast.IsBindingPattern(name) {
+			name.ForEachChild(b.visit)
+		} else if name != nil {
+			b.addNode(node, b.visitInitializer(node.Initializer()))
+		}
+	case ast.KindPropertySignature, ast.KindMethodSignature, ast.KindCallSignature, ast.KindConstructSignature, ast.KindIndexSignature,
+		ast.KindEnumMember, ast.KindShorthandPropertyAssignment, ast.KindTypeAliasDeclaration, ast.KindImportEqualsDeclaration,
+		ast.KindNamespaceImport, ast.KindImportSpecifier:
+		b.addNode(node, nil)
+	case ast.KindImportClause:
+		if node.Name() != nil {
+			b.addNode(node, nil)
+		}
+		if namedBindings := node.AsImportClause().NamedBindings; namedBindings != nil {
+			b.visit(namedBindings)
+		}
+	case ast.KindExportAssignment:
+		b.addNode(node, b.visitInitializer(node.Expression()))
+	default:
+		node.ForEachChild(b.visit)
+	}
+	return false
+}
+
+// visitInitializer returns a function visiting the declarations in the value of a declaration.
+// The declarations of a function or class value are children of the declaration itself.
+func (b *navigationBarBuilder) visitInitializer(initializer *ast.Node) func() {
+	if initializer == nil {
+		return nil
+	}
+	switch initializer.Kind {
+	case ast.KindFunctionExpression, ast.KindArrowFunction:
+		return b.visitChildren(initializer.Body())
+	case ast.KindClassExpression:
+		return func() { b.visitClassMembers(initializer) }
+	}
+	return func() { b.visit(initializer) }
+}
+
+func (b *navigationBarBuilder) visitClassMembers(node *ast.Node) {
+	for _, member := range node.Members() {
+		if ast.IsConstructorDeclaration(member) {
+			for _, parameter := range member.Parameters() {
+				if ast.HasSyntacticModifier(parameter, ast.ModifierFlagsParameterPropertyModifier) && ast.IsIdentifier(parameter.Name()) {
+					b.addNode(parameter, nil)
+				}
+			}
+		}
+		b.visit(member)
+	}
+}
+
+// mergeNavigationBarNodeChildren merges the same-named children of node that declare the same
+// thing, sorts them, and does the same for their children.
+func mergeNavigationBarNodeChildren(node *navigationBarNode) {
+	var children []*navigationBarNode
+	byName := map[string][]*navigationBarNode{}
+	for _, child := range node.children {
+		if ast.GetNameOfDeclaration(child.node) != nil {
+			if target := core.Find(byName[child.name], func(candidate *navigationBarNode) bool {
+				return shouldMergeNavigationBarNodes(candidate, child)
+			}); target != nil {
+				target.additionalNodes = append(target.additionalNodes, child.node)
+				target.additionalNodes = append(target.additionalNodes, child.additionalNodes...)
+				target.children = append(target.children, child.children...)
+				continue
+			}
+			byName[child.name] = append(byName[child.name], child)
+		}
+		children = append(children, child)
+	}
+	slices.SortStableFunc(children, func(a, b *navigationBarNode) int {
+		return stringutil.CompareStringsCaseInsensitiveThenSensitive(a.name, b.name)
+	})
+	node.children = children
+	for _, child := range children {
+		mergeNavigationBarNodeChildren(child)
+	}
+}
+
+func shouldMergeNavigationBarNodes(a *navigationBarNode, b *navigationBarNode) bool {
+	if a.node.Kind != b.node.Kind {
+		return false
+	}
+	switch a.node.Kind {
+	case ast.KindPropertyDeclaration, ast.KindMethodDeclaration, ast.KindGetAccessor, ast.KindSetAccessor:
+		return ast.IsStatic(a.node) == ast.IsStatic(b.node)
+	}
+	return true
+}
+
+// isPrimaryNavigationBarNode reports whether node is listed at the top level of the navigation bar,
+// rather than only as the child of another item.
+func isPrimaryNavigationBarNode(node *navigationBarNode) bool {
+	if len(node.children) != 0 {
+		return true
+	}
+	switch node.node.Kind {
+	case ast.KindClassDeclaration, ast.KindClassExpression, ast.KindEnumDeclaration, ast.KindInterfaceDeclaration,
+		ast.KindModuleDeclaration, ast.KindTypeAliasDeclaration:
+		return true
+	}
+	return false
+}
+
+func getNavigationBarNodeName(node *ast.Node) string {
+	switch node.Kind {
+	case ast.KindSourceFile:
+		file := node.AsSourceFile()
+		if ast.IsExternalModule(file) {
+			return "\"" + printer.EscapeString(tspath.GetBaseFileName(tspath.RemoveFileExtension(file.FileName())), '"') + "\""
+		}
+		return "<global>"
+	case ast.KindModuleDeclaration:
+		if !ast.IsAmbientModule(node) {
+			return getModuleName(node)
+		}
+	case ast.KindExportAssignment:
+		if node.AsExportAssignment().IsExportEquals {
+			return "export="
+		}
+		return "default"
+	}
+	if name := ast.GetNameOfDeclaration(node); name != nil {
+		return getTextOfName(name)
+	}
+	if ast.HasSyntacticModifier(node, ast.ModifierFlagsDefault) {
+		return "default"
+	}
+	return getUnnamedNodeLabel(node)
+}
+
+func (l *LanguageService) newNavigationBarItem(file *ast.SourceFile, node *navigationBarNode, indent int) NavigationBarItem {
+	item := NavigationBarItem{
+		Text:       node.name,
+		Kind:       getNodeKind(node.node),
+		ChildItems: []NavigationBarItem{},
+		Indent:     indent,
+	}
+	for _, declaration := range append([]*ast.Node{node.node}, node.additionalNodes...) {
+		start := 0
+		if !ast.IsSourceFile(declaration) {
+			start = scanner.GetTokenPosOfNode(declaration, file, false /*includeJSDoc*/)
+		}
+		item.Spans = append(item.Spans, l.newTextRange(file, core.NewTextRange(start, declaration.End())))
+	}
+	if name := ast.GetNameOfDeclaration(node.node); name != nil && !ast.IsSourceFile(node.node) {
+		nameSpan := l.newTextRange(file, core.NewTextRange(scanner.GetTokenPosOfNode(name, file, false /*includeJSDoc*/), name.End()))
+		item.NameSpan = &nameSpan
+	}
+	return item
+}
@@ -2,19 +2,66 @@ package ls
 
 import (
 	"cmp"
+	"slices"
 	"strings"
 
 	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/checker"
 	"github.com/microsoft/typescript-go/internal/compiler"
 	"github.com/microsoft/typescript-go/internal/core"
 	"github.com/microsoft/typescript-go/internal/modulespecifiers"
+	"github.com/microsoft/typescript-go/internal/stringutil"
 	"github.com/microsoft/typescript-go/internal/tspath"
 )
 
 // statement = anyImportOrRequireStatement
 func getImportDeclarationInsertIndex(sortedImports []*ast.Statement, newImport *ast.Statement, comparer func(a, b *ast.Statement) int) int {
-	// !!!
-	return len(sortedImports)
+	index, _ := slices.BinarySearchFunc(sortedImports, newImport, comparer)
+	return index
+}
+
+// getModuleSpecifierStringComparerWithDetection returns the comparer that the module specifiers of
+// the given import or require statements are sorted with, ordinal or case-insensitive, and whether
+// they are sorted at all. Statements without string module specifiers sort last.
+func getModuleSpecifierStringComparerWithDetection(statements []*ast.Statement) (comparer func(a, b string) int, isSorted bool) {
+	comparers := []func(a, b string) int{
+		stringutil.CompareStringsCaseInsensitive,
+		stringutil.CompareStringsCaseSensitive,
+	}
+	for _, comparer := range comparers {
+		if slices.IsSortedFunc(statements, func(a, b *ast.Statement) int {
+			return compareImportsOrRequireStatements(a, b, comparer)
+		}) {
+			return comparer, true
+		}
+	}
+	return comparers[0], false
+}
+
+func compareImportsOrRequireStatements(s1 *ast.Statement, s2 *ast.Statement, comparer func(a, b string) int) int {
+	name1 := getImportOrRequireModuleName(s1)
+	name2 := getImportOrRequireModuleName(s2)
+	if comparison := compareBooleans(name1 == "", name2 == ""); comparison != 0 {
+		return -comparison
+	}
+	if comparison := compareBooleans(tspath.IsExternalModuleNameRelative(name1), tspath.IsExternalModuleNameRelative(name2)); comparison != 0 {
+		return -comparison
+	}
+	return comparer(name1, name2)
+}
+
+func getImportOrRequireModuleName(statement *ast.Statement) string {
+	node := statement
+	if ast.IsVariableStatement(statement) {
+		node = core.FirstOrNil(statement.AsVariableStatement().DeclarationList.AsVariableDeclarationList().Declarations.Nodes)
+		if node == nil {
+			return ""
+		}
+	}
+	if specifier := checker.TryGetModuleSpecifierFromDeclaration(node); specifier != nil {
+		return specifier.Text()
+	}
+	return ""
 }
 
 // returns `-1` if `a` is better than `b`
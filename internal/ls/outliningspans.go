@@ -0,0 +1,125 @@
+package ls
+
+import (
+	"context"
+	"slices"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/scanner"
+)
+
+type OutliningSpanKind string
+
+const (
+	OutliningSpanKindComment OutliningSpanKind = "comment"
+	OutliningSpanKindCode    OutliningSpanKind = "code"
+)
+
+type OutliningSpan struct {
+	// The range collapsed by the span.
+	TextSpan TextRange `json:"textSpan"`
+	// The range shown when the span is collapsed, which is its first line.
+	HintSpan TextRange `json:"hintSpan"`
+	// The text replacing the span when it is collapsed.
+	BannerText string `json:"bannerText"`
+	// Whether the span should be collapsed when the file is opened, which is the case of a license
+	// header at the top of the file.
+	AutoCollapse bool              `json:"autoCollapse"`
+	Kind         OutliningSpanKind `json:"kind"`
+}
+
+// GetOutliningSpans returns the collapsible spans of a file that are not structural: block
+// comments and runs of consecutive single-line comments, and string and template literals, that
+// span several lines. A block comment before any code, such as a license header, is marked to be
+// collapsed automatically. Spans are ordered by position.
+func (l *LanguageService) GetOutliningSpans(ctx context.Context, fileName string) ([]OutliningSpan, error) {
+	file, err := l.GetBoundSourceFile(ctx, fileName)
+	if err != nil {
+		return nil, err
+	}
+	var ranges []outliningRange
+	seen := map[int]bool{}
+	var visit func(node *ast.Node) bool
+	visit = func(node *ast.Node) bool {
+		if !seen[node.Pos()] {
+			seen[node.Pos()] = true
+			ranges = append(ranges, getCommentOutliningRanges(file, node)...)
+		}
+		switch node.Kind {
+		case ast.KindStringLiteral, ast.KindNoSubstitutionTemplateLiteral, ast.KindTemplateExpression:
+			ranges = append(ranges, outliningRange{
+				TextRange: core.NewTextRange(scanner.GetTokenPosOfNode(node, file, false /*includeJSDoc*/), node.End()),
+				kind:      OutliningSpanKindCode,
+			})
+			// Literals nested in template expressions belong to the outer span.
+			return false
+		}
+		return node.ForEachChild(visit)
+	}
+	visit(file.AsNode())
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(ranges, func(a, b outliningRange) int {
+		return a.Pos() - b.Pos()
+	})
+	spans := []OutliningSpan{}
+	for _, r := range ranges {
+		startLine, _ := scanner.GetECMALineAndCharacterOfPosition(file, r.Pos())
+		endLine, _ := scanner.GetECMALineAndCharacterOfPosition(file, r.End())
+		if startLine == endLine {
+			continue
+		}
+		spans = append(spans, OutliningSpan{
+			TextSpan:     l.newTextRange(file, r.TextRange),
+			HintSpan:     l.newTextRange(file, core.NewTextRange(r.Pos(), scanner.GetECMAEndLinePosition(file, startLine))),
+			BannerText:   "...",
+			AutoCollapse: r.isLicenseHeader,
+			Kind:         r.kind,
+		})
+	}
+	return spans, nil
+}
+
+type outliningRange struct {
+	core.TextRange
+	kind            OutliningSpanKind
+	isLicenseHeader bool
+}
+
+// getCommentOutliningRanges returns the ranges of the comments before node, where consecutive
+// single-line comments form a single range.
+func getCommentOutliningRanges(file *ast.SourceFile, node *ast.Node) []outliningRange {
+	var ranges []outliningRange
+	var singleLineComments core.TextRange
+	singleLineCommentCount := 0
+	flushSingleLineComments := func() {
+		if singleLineCommentCount > 1 {
+			ranges = append(ranges, outliningRange{TextRange: singleLineComments, kind: OutliningSpanKindComment})
+		}
+		singleLineCommentCount = 0
+	}
+	for comment := range getLeadingCommentRangesOfNode(node, file) {
+		switch comment.Kind {
+		case ast.KindSingleLineCommentTrivia:
+			if singleLineCommentCount == 0 {
+				singleLineComments = comment.TextRange
+			} else {
+				singleLineComments = singleLineComments.WithEnd(comment.End())
+			}
+			singleLineCommentCount++
+		case ast.KindMultiLineCommentTrivia:
+			flushSingleLineComments()
+			ranges = append(ranges, outliningRange{
+				TextRange: comment.TextRange,
+				kind:      OutliningSpanKindComment,
+				// A block comment before the first token of the file is a header, such as a license.
+				isLicenseHeader: node.Pos() == 0,
+			})
+		}
+	}
+	flushSingleLineComments()
+	return ranges
+}
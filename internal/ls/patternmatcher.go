@@ -0,0 +1,358 @@
+package ls
+
+import (
+	"strings"
+	"unicode"
+)
+
+// patternMatchKind orders the ways a pattern can match a name, from best to worst.
+type patternMatchKind int
+
+const (
+	patternMatchKindExact patternMatchKind = iota
+	patternMatchKindPrefix
+	patternMatchKindSubstring
+	patternMatchKindCamelCase
+)
+
+func (k patternMatchKind) String() string {
+	switch k {
+	case patternMatchKindExact:
+		return "exact"
+	case patternMatchKindPrefix:
+		return "prefix"
+	case patternMatchKindSubstring:
+		return "substring"
+	default:
+		return "camelCase"
+	}
+}
+
+type patternMatch struct {
+	kind            patternMatchKind
+	isCaseSensitive bool
+}
+
+func comparePatternMatches(a, b patternMatch) int {
+	if a.kind != b.kind {
+		return int(a.kind - b.kind)
+	}
+	if a.isCaseSensitive != b.isCaseSensitive {
+		if a.isCaseSensitive {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
+
+func betterMatch(a *patternMatch, b *patternMatch) *patternMatch {
+	if a == nil || b != nil && comparePatternMatches(*b, *a) < 0 {
+		return b
+	}
+	return a
+}
+
+type textSpan struct {
+	start  int
+	length int
+}
+
+type textChunk struct {
+	text      []rune
+	lowerText []rune
+	// Whether the chunk has no upper case letters, in which case it also matches case insensitively
+	// in the middle of a name.
+	isLowerCase bool
+	// The spans of the chunk that start with an upper case letter, a digit or after punctuation,
+	// used for camel case matching. "gDS" has the spans "g", "D" and "S".
+	characterSpans []textSpan
+}
+
+func newTextChunk(text string) textChunk {
+	runes := []rune(text)
+	lowerText := make([]rune, len(runes))
+	isLowerCase := true
+	for i, ch := range runes {
+		lowerText[i] = unicode.ToLower(ch)
+		if unicode.IsUpper(ch) {
+			isLowerCase = false
+		}
+	}
+	return textChunk{
+		text:           runes,
+		lowerText:      lowerText,
+		isLowerCase:    isLowerCase,
+		characterSpans: breakIntoSpans(runes, false /*word*/),
+	}
+}
+
+// patternSegment is the part of a pattern between dots.
+type patternSegment struct {
+	total textChunk
+	// The words of the segment, split on anything that cannot be part of an identifier.
+	subWords []textChunk
+}
+
+// patternMatcher matches names against a pattern in the way of the TypeScript language service.
+// A pattern matches a name exactly, as a prefix, as a substring starting at a word boundary of the
+// name, or by camel case, where each upper case letter or digit of the pattern starts a word of the
+// name: "gDS" and "getDS" match "getDocumentSymbols", and "v2S" matches "v2Server". Words of a name
+// are separated by case changes, digits and punctuation such as `_`. Dots separate the patterns of
+// a name's containers, so "Foo.bar" matches `bar` declared in `Foo`.
+type patternMatcher struct {
+	segments  []patternSegment
+	wordSpans map[string][]textSpan
+}
+
+// newPatternMatcher returns a matcher for pattern, or nil if the pattern has no words to match.
+func newPatternMatcher(pattern string) *patternMatcher {
+	parts := strings.Split(pattern, ".")
+	segments := make([]patternSegment, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		segment := patternSegment{total: newTextChunk(part)}
+		for word := range strings.FieldsFuncSeq(part, func(ch rune) bool { return !isPatternWordChar(ch) }) {
+			segment.subWords = append(segment.subWords, newTextChunk(word))
+		}
+		if len(segment.subWords) == 0 {
+			return nil
+		}
+		segments = append(segments, segment)
+	}
+	return &patternMatcher{segments: segments, wordSpans: make(map[string][]textSpan)}
+}
+
+func isPatternWordChar(ch rune) bool {
+	return unicode.IsLetter(ch) || unicode.IsDigit(ch) || ch == '_' || ch == '$'
+}
+
+// getFullMatch matches candidate against the last segment of the pattern and its containers,
+// innermost first, against the preceding segments.
+func (m *patternMatcher) getFullMatch(containers []string, candidate string) *patternMatch {
+	match := m.matchSegment(candidate, m.segments[len(m.segments)-1])
+	if match == nil || len(m.segments)-1 > len(containers) {
+		return nil
+	}
+	for i, j := len(m.segments)-2, 0; i >= 0; i, j = i-1, j+1 {
+		if m.matchSegment(containers[j], m.segments[i]) == nil {
+			return nil
+		}
+	}
+	return match
+}
+
+func (m *patternMatcher) matchSegment(candidate string, segment patternSegment) *patternMatch {
+	// A segment with no spaces or asterisks may match as a whole, which also covers segments with
+	// characters that are dropped when splitting into words, such as "@int".
+	if !strings.ContainsAny(string(segment.total.text), " *") {
+		if match := m.matchTextChunk(candidate, segment.total); match != nil {
+			return match
+		}
+	}
+	// Otherwise every word of the segment must match.
+	var best *patternMatch
+	for _, word := range segment.subWords {
+		match := m.matchTextChunk(candidate, word)
+		if match == nil {
+			return nil
+		}
+		best = betterMatch(best, match)
+	}
+	return best
+}
+
+func (m *patternMatcher) matchTextChunk(candidateText string, chunk textChunk) *patternMatch {
+	candidate := []rune(candidateText)
+	index := indexOfIgnoringCase(candidate, chunk.lowerText)
+	if index == 0 {
+		kind := patternMatchKindPrefix
+		if len(chunk.text) == len(candidate) {
+			kind = patternMatchKindExact
+		}
+		return &patternMatch{kind: kind, isCaseSensitive: hasPrefix(candidate, chunk.text)}
+	}
+
+	if chunk.isLowerCase {
+		if index == -1 {
+			return nil
+		}
+		// A lower case chunk matches as a substring only at the start of a word of the candidate,
+		// so "a" matches "FooAttribute" but not "Class".
+		for _, span := range m.getWordSpans(candidateText, candidate) {
+			if partStartsWith(candidate, span, chunk.text, true /*ignoreCase*/, textSpan{0, len(chunk.text)}) {
+				return &patternMatch{
+					kind:            patternMatchKindSubstring,
+					isCaseSensitive: partStartsWith(candidate, span, chunk.text, false /*ignoreCase*/, textSpan{0, len(chunk.text)}),
+				}
+			}
+		}
+		// Rather than checking every occurrence, accept the first one if it starts on an upper case
+		// letter, as for "fogbar" in "quuxfogbarFogBar".
+		if len(chunk.text) < len(candidate) && unicode.IsUpper(candidate[index]) {
+			return &patternMatch{kind: patternMatchKindSubstring}
+		}
+		return nil
+	}
+
+	// A chunk with upper case letters matches case sensitively anywhere in the candidate.
+	if indexOf(candidate, chunk.text) > 0 {
+		return &patternMatch{kind: patternMatchKindSubstring, isCaseSensitive: true}
+	}
+	if len(chunk.characterSpans) > 0 {
+		words := m.getWordSpans(candidateText, candidate)
+		if tryCamelCaseMatch(candidate, words, chunk, false /*ignoreCase*/) {
+			return &patternMatch{kind: patternMatchKindCamelCase, isCaseSensitive: true}
+		}
+		if tryCamelCaseMatch(candidate, words, chunk, true /*ignoreCase*/) {
+			return &patternMatch{kind: patternMatchKindCamelCase}
+		}
+	}
+	return nil
+}
+
+func (m *patternMatcher) getWordSpans(candidateText string, candidate []rune) []textSpan {
+	spans, ok := m.wordSpans[candidateText]
+	if !ok {
+		spans = breakIntoSpans(candidate, true /*word*/)
+		m.wordSpans[candidateText] = spans
+	}
+	return spans
+}
+
+// tryCamelCaseMatch reports whether each character span of the chunk starts a word of the candidate,
+// in order. Several spans may match the same word when they are all upper case, so "SiUI" matches
+// the words "Simple" and "UI" of "SimpleUIElement".
+func tryCamelCaseMatch(candidate []rune, candidateWords []textSpan, chunk textChunk, ignoreCase bool) bool {
+	chunkSpans := chunk.characterSpans
+	currentChunkSpan := 0
+	for _, word := range candidateWords {
+		if currentChunkSpan == len(chunkSpans) {
+			break
+		}
+		gotOneMatchThisWord := false
+		for ; currentChunkSpan < len(chunkSpans); currentChunkSpan++ {
+			chunkSpan := chunkSpans[currentChunkSpan]
+			// After a match in this word, only keep consuming spans while they are upper case.
+			if gotOneMatchThisWord && (!unicode.IsUpper(chunk.text[chunkSpans[currentChunkSpan-1].start]) || !unicode.IsUpper(chunk.text[chunkSpan.start])) {
+				break
+			}
+			if !partStartsWith(candidate, word, chunk.text, ignoreCase, chunkSpan) {
+				break
+			}
+			gotOneMatchThisWord = true
+			word = textSpan{word.start + chunkSpan.length, word.length - chunkSpan.length}
+		}
+	}
+	return currentChunkSpan == len(chunkSpans)
+}
+
+// breakIntoSpans splits an identifier into words, as "get", "Document" and "Symbols" for
+// "getDocumentSymbols", or, if word is false, into character spans that each start with an upper
+// case letter, as "g", "D" and "S" for "gDS". Digits and punctuation always start a new span, and
+// punctuation other than `_` is dropped.
+func breakIntoSpans(identifier []rune, word bool) []textSpan {
+	var result []textSpan
+	wordStart := 0
+	for i := 1; i < len(identifier); i++ {
+		lastIsDigit := unicode.IsDigit(identifier[i-1])
+		currentIsDigit := unicode.IsDigit(identifier[i])
+		if isPatternPunctuation(identifier[i-1]) ||
+			isPatternPunctuation(identifier[i]) ||
+			lastIsDigit != currentIsDigit ||
+			transitionFromLowerToUpper(identifier, word, i) ||
+			word && transitionFromUpperToLower(identifier, i, wordStart) {
+			if !isAllPunctuation(identifier, wordStart, i) {
+				result = append(result, textSpan{wordStart, i - wordStart})
+			}
+			wordStart = i
+		}
+	}
+	if !isAllPunctuation(identifier, wordStart, len(identifier)) {
+		result = append(result, textSpan{wordStart, len(identifier) - wordStart})
+	}
+	return result
+}
+
+func isPatternPunctuation(ch rune) bool {
+	switch ch {
+	case '!', '"', '#', '%', '&', '\'', '(', ')', '*', ',', '-', '.', '/', ':', ';', '?', '@', '[', '\\', ']', '_', '{', '}':
+		return true
+	}
+	return false
+}
+
+func isAllPunctuation(identifier []rune, start int, end int) bool {
+	for _, ch := range identifier[start:end] {
+		// `_` is kept as a word of its own.
+		if !isPatternPunctuation(ch) || ch == '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// transitionFromUpperToLower reports whether the upper case letter at index starts a new word
+// after a run of upper case letters, as "Element" in "UIElement" or "Disposable" in "IDisposable".
+func transitionFromUpperToLower(identifier []rune, index int, wordStart int) bool {
+	if index == wordStart || index+1 >= len(identifier) || !unicode.IsUpper(identifier[index]) || !unicode.IsLower(identifier[index+1]) {
+		return false
+	}
+	for _, ch := range identifier[wordStart:index] {
+		if !unicode.IsUpper(ch) {
+			return false
+		}
+	}
+	return true
+}
+
+// transitionFromLowerToUpper reports whether the character at index starts a new span because of
+// its case. Words start at an upper case letter that does not follow another, so "AM" is one word,
+// while character spans start at every upper case letter.
+func transitionFromLowerToUpper(identifier []rune, word bool, index int) bool {
+	currentIsUpper := unicode.IsUpper(identifier[index])
+	if word {
+		return currentIsUpper && !unicode.IsUpper(identifier[index-1])
+	}
+	return currentIsUpper
+}
+
+// partStartsWith reports whether the patternSpan of pattern is a prefix of the candidateSpan of candidate.
+func partStartsWith(candidate []rune, candidateSpan textSpan, pattern []rune, ignoreCase bool, patternSpan textSpan) bool {
+	if patternSpan.length > candidateSpan.length {
+		return false
+	}
+	for i := range patternSpan.length {
+		ch1 := candidate[candidateSpan.start+i]
+		ch2 := pattern[patternSpan.start+i]
+		if ignoreCase {
+			ch1, ch2 = unicode.ToLower(ch1), unicode.ToLower(ch2)
+		}
+		if ch1 != ch2 {
+			return false
+		}
+	}
+	return true
+}
+
+func hasPrefix(s []rune, prefix []rune) bool {
+	return partStartsWith(s, textSpan{0, len(s)}, prefix, false /*ignoreCase*/, textSpan{0, len(prefix)})
+}
+
+func indexOf(s []rune, value []rune) int {
+	for i := 0; i+len(value) <= len(s); i++ {
+		if partStartsWith(s, textSpan{i, len(s) - i}, value, false /*ignoreCase*/, textSpan{0, len(value)}) {
+			return i
+		}
+	}
+	return -1
+}
+
+func indexOfIgnoringCase(s []rune, lowerValue []rune) int {
+	for i := 0; i+len(lowerValue) <= len(s); i++ {
+		if partStartsWith(s, textSpan{i, len(s) - i}, lowerValue, true /*ignoreCase*/, textSpan{0, len(lowerValue)}) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,51 @@
+package ls_test
+
+import (
+	"testing"
+
+	"github.com/microsoft/typescript-go/internal/ls"
+	"gotest.tools/v3/assert"
+)
+
+func TestMatchPattern(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern         string
+		candidate       string
+		kind            string
+		isCaseSensitive bool
+	}{
+		{"getDocumentSymbols", "getDocumentSymbols", "exact", true},
+		{"getdocumentsymbols", "getDocumentSymbols", "exact", false},
+		{"getDoc", "getDocumentSymbols", "prefix", true},
+		{"GETDOC", "getDocumentSymbols", "prefix", false},
+		{"Symbols", "getDocumentSymbols", "substring", true},
+		{"symbols", "getDocumentSymbols", "substring", false},
+		{"gDS", "getDocumentSymbols", "camelCase", true},
+		{"GDS", "getDocumentSymbols", "camelCase", false},
+		{"DocSym", "getDocumentSymbols", "camelCase", true},
+		{"bar", "foo_bar", "substring", true},
+		{"fB", "foo_bar", "camelCase", false},
+		{"v2", "parseV2Config", "substring", false},
+		{"pVC", "parseV2Config", "camelCase", true},
+		{"PVC", "parseV2Config", "camelCase", false},
+	}
+
+	for _, test := range tests {
+		kind, isCaseSensitive, ok := ls.MatchPattern(test.pattern, test.candidate)
+		assert.Assert(t, ok, "%q should match %q", test.pattern, test.candidate)
+		assert.Equal(t, kind, test.kind, "%q matching %q", test.pattern, test.candidate)
+		assert.Equal(t, isCaseSensitive, test.isCaseSensitive, "%q matching %q", test.pattern, test.candidate)
+	}
+
+	for _, test := range []struct{ pattern, candidate string }{
+		{"gSD", "getDocumentSymbols"},
+		{"ocument", "getdocumentsymbols"},
+		{"xyz", "getDocumentSymbols"},
+		{"...", "getDocumentSymbols"},
+	} {
+		_, _, ok := ls.MatchPattern(test.pattern, test.candidate)
+		assert.Assert(t, !ok, "%q should not match %q", test.pattern, test.candidate)
+	}
+}
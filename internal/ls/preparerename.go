@@ -0,0 +1,124 @@
+package ls
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+	"github.com/microsoft/typescript-go/internal/checker"
+	"github.com/microsoft/typescript-go/internal/compiler"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/diagnostics"
+	"github.com/microsoft/typescript-go/internal/modulespecifiers"
+	"github.com/microsoft/typescript-go/internal/scanner"
+	"github.com/microsoft/typescript-go/internal/tspath"
+)
+
+type PrepareRenameResult struct {
+	// Range is the range of the name that would be renamed, without the quotes of a string literal.
+	Range TextRange `json:"range"`
+	// Placeholder is the text of the name, offered as the default new name.
+	Placeholder string `json:"placeholder"`
+}
+
+// PrepareRename checks that the name at the given position can be renamed, and returns its range
+// and current text. It returns nil if there is no renameable name at the position, such as on a
+// keyword or on a string literal that refers to nothing, and an error wrapping ErrCannotRename if
+// the name refers to something that cannot be renamed, such as a declaration of a default library
+// or of a file in node_modules.
+func (l *LanguageService) PrepareRename(ctx context.Context, fileName string, position int) (*PrepareRenameResult, error) {
+	program, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	ch, done := program.GetTypeChecker(ctx)
+	defer done()
+	node, message := getRenameNode(program, ch, file, position)
+	if message != nil {
+		return nil, fmt.Errorf("%w: %s", ErrCannotRename, message.Message())
+	}
+	if node == nil {
+		return nil, nil
+	}
+	start := scanner.GetTokenPosOfNode(node, file, false /*includeJSDoc*/)
+	end := node.End()
+	if ast.IsStringLiteralLike(node) {
+		start++
+		end--
+	}
+	return &PrepareRenameResult{
+		Range:       l.newTextRange(file, core.NewTextRange(start, end)),
+		Placeholder: file.Text()[start:end],
+	}, nil
+}
+
+// getRenameNode returns the name at position that a rename would rename. If there is none it
+// returns nil, and if the name cannot be renamed it returns the message explaining why.
+func getRenameNode(program *compiler.Program, ch *checker.Checker, file *ast.SourceFile, position int) (*ast.Node, *diagnostics.Message) {
+	node := astnav.GetTouchingPropertyName(file, position)
+	if !isNodeEligibleForRename(node) {
+		return nil, nil
+	}
+	symbol := ch.GetSymbolAtLocation(node)
+	if symbol == nil {
+		if ast.IsLabelName(node) {
+			return node, nil
+		}
+		return nil, nil
+	}
+	if len(symbol.Declarations) == 0 {
+		return nil, nil
+	}
+	for _, declaration := range symbol.Declarations {
+		declarationFile := ast.GetSourceFileOfNode(declaration)
+		if program.IsSourceFileDefaultLibrary(declarationFile.Path()) && tspath.FileExtensionIs(declarationFile.FileName(), tspath.ExtensionDts) {
+			return nil, diagnostics.You_cannot_rename_elements_that_are_defined_in_the_standard_TypeScript_library
+		}
+	}
+	// `default` in `import { default as x }` or `export { default } from "./a"` names the default
+	// export, which has no name of its own to rename.
+	if ast.IsIdentifier(node) && node.Text() == "default" && symbol.Parent != nil && symbol.Parent.Flags&ast.SymbolFlagsModule != 0 {
+		return nil, diagnostics.You_cannot_rename_this_element
+	}
+	if ast.IsStringLiteralLike(node) && tryGetImportFromModuleSpecifier(node) != nil {
+		return nil, diagnostics.You_cannot_rename_this_element
+	}
+	if isDefinedInOtherNodeModules(ch, file, symbol) {
+		return nil, diagnostics.You_cannot_rename_elements_that_are_defined_in_a_node_modules_folder
+	}
+	return node, nil
+}
+
+func isNodeEligibleForRename(node *ast.Node) bool {
+	switch node.Kind {
+	case ast.KindIdentifier, ast.KindPrivateIdentifier, ast.KindStringLiteral, ast.KindNoSubstitutionTemplateLiteral:
+		return true
+	case ast.KindNumericLiteral:
+		return isLiteralNameOfPropertyDeclarationOrIndexAccess(node)
+	}
+	return false
+}
+
+// isDefinedInOtherNodeModules reports whether renaming symbol from file, which is not itself in
+// node_modules, would edit a declaration in node_modules.
+func isDefinedInOtherNodeModules(ch *checker.Checker, file *ast.SourceFile, symbol *ast.Symbol) bool {
+	if modulespecifiers.ContainsNodeModules(string(file.Path())) {
+		return false
+	}
+	// Renaming `x` in `import { x } from "pkg"` renames the export of the package too.
+	if symbol.Flags&ast.SymbolFlagsAlias != 0 {
+		for _, declaration := range symbol.Declarations {
+			if ast.IsImportSpecifier(declaration) && declaration.PropertyName() == nil {
+				symbol = ch.GetAliasedSymbol(symbol)
+				break
+			}
+		}
+	}
+	for _, declaration := range symbol.Declarations {
+		if modulespecifiers.ContainsNodeModules(string(ast.GetSourceFileOfNode(declaration).Path())) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package ls
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/microsoft/typescript-go/internal/compiler"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/tspath"
+)
+
+type ProgramFileInfo struct {
+	FileName string `json:"fileName"`
+	Path     string `json:"path"`
+	// Whether the file is a default library file, such as lib.es2020.d.ts.
+	IsDefaultLibrary bool `json:"isDefaultLibrary"`
+	// The reason through which the file was first reached while loading the program.
+	Reason *FileIncludeReason `json:"reason,omitempty"`
+}
+
+// FileIncludeReason is a reason a file is part of the program, as explained by --explainFiles.
+type FileIncludeReason struct {
+	// One of "RootFile", "Import", "ReferenceDirective", "LibFile", "AutomaticTypeDirective" or
+	// "ProjectReference".
+	Kind compiler.IncludeReasonKind `json:"kind"`
+	// For imports and reference directives, the file containing the reference.
+	ReferencingFile string `json:"referencingFile,omitempty"`
+	// The module specifier, referenced file name or type library name.
+	Specifier string `json:"specifier,omitempty"`
+	// The range of the specifier or directive in the referencing file, if it appears in its text.
+	Range   *TextRange `json:"range,omitempty"`
+	Message string     `json:"message"`
+}
+
+// GetProgramFiles returns every source file of the program, in program order, with the primary
+// reason it is included. It is the data of `tsc --listFiles` with part of `--explainFiles`.
+func (l *LanguageService) GetProgramFiles(ctx context.Context) ([]ProgramFileInfo, error) {
+	program := l.GetProgram()
+	files := program.GetSourceFiles()
+	result := make([]ProgramFileInfo, 0, len(files))
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		info := ProgramFileInfo{
+			FileName:         file.FileName(),
+			Path:             string(file.Path()),
+			IsDefaultLibrary: program.IsSourceFileDefaultLibrary(file.Path()),
+		}
+		if reasons := program.GetFileIncludeReasons(file.Path()); len(reasons) > 0 {
+			info.Reason = l.newFileIncludeReason(reasons[0])
+		}
+		result = append(result, info)
+	}
+	return result, nil
+}
+
+func (l *LanguageService) newFileIncludeReason(reason *compiler.IncludeReason) *FileIncludeReason {
+	result := &FileIncludeReason{
+		Kind:      reason.Kind,
+		Specifier: reason.Specifier,
+		Message:   reason.Message,
+	}
+	if reason.ReferencingFile != nil {
+		result.ReferencingFile = reason.ReferencingFile.FileName()
+		if reason.Range.Pos() >= 0 {
+			textRange := l.newTextRange(reason.ReferencingFile, reason.Range)
+			result.Range = &textRange
+		}
+	}
+	return result
+}
+
+// GetFileIncludeReasons returns the chain of reasons explaining why a file is part of the program,
+// starting from the root file, library or type library that led to it. Each reason after the
+// first is a reference from the file reached by the reason before it, so for a root file A that
+// imports B, which references C, the reasons for C are A being a root file, A importing B and B
+// referencing C. Only the primary reason of each file is followed.
+func (l *LanguageService) GetFileIncludeReasons(ctx context.Context, fileName string) ([]FileIncludeReason, error) {
+	program, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	var chain []FileIncludeReason
+	seen := map[tspath.Path]bool{}
+	for file != nil && !seen[file.Path()] {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		seen[file.Path()] = true
+		reasons := program.GetFileIncludeReasons(file.Path())
+		if len(reasons) == 0 {
+			break
+		}
+		chain = append(chain, *l.newFileIncludeReason(reasons[0]))
+		file = reasons[0].ReferencingFile
+	}
+	slices.Reverse(chain)
+	return chain, nil
+}
+
+// ModuleResolutionResult explains how a module specifier resolves from a file.
+type ModuleResolutionResult struct {
+	// The resolved file, or empty if the specifier does not resolve.
+	ResolvedFileName string `json:"resolvedFileName,omitempty"`
+	// The resolution mode of the importing file, "import" or "require", or empty if the module
+	// resolution kind does not distinguish them.
+	ResolutionMode string `json:"resolutionMode,omitempty"`
+	// Whether the module was found in a node_modules directory.
+	IsExternalLibraryImport bool `json:"isExternalLibraryImport"`
+	// The directory of the nearest package.json of the importing file, if any.
+	PackageJsonScope string `json:"packageJsonScope,omitempty"`
+	// The paths looked up, in order, ending with the resolved file if any.
+	Candidates []string `json:"candidates"`
+	// The messages printed by --traceResolution for the resolution.
+	Trace []string `json:"trace"`
+}
+
+// ResolveModule resolves a module specifier as if imported by a file of the program, and explains
+// the result. The resolution uses the resolver of the host, with the default resolution mode of the
+// file, and does not change the program.
+func (l *LanguageService) ResolveModule(ctx context.Context, fromFile string, specifier string) (*ModuleResolutionResult, error) {
+	program, file := l.tryGetProgramAndFile(fromFile)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fromFile)
+	}
+	mode := program.GetDefaultResolutionModeForFile(file)
+	resolved, trace := program.ResolveModuleNameWithTrace(specifier, file, mode)
+	result := &ModuleResolutionResult{
+		PackageJsonScope: program.GetNearestAncestorDirectoryWithPackageJson(tspath.GetDirectoryPath(file.FileName())),
+		Candidates:       []string{},
+		Trace:            append([]string{}, trace...),
+	}
+	switch mode {
+	case core.ResolutionModeESM:
+		result.ResolutionMode = "import"
+	case core.ResolutionModeCommonJS:
+		result.ResolutionMode = "require"
+	}
+	if resolved != nil {
+		result.Candidates = append(result.Candidates, resolved.FailedLookupLocations...)
+		if resolved.IsResolved() {
+			result.ResolvedFileName = resolved.ResolvedFileName
+			result.IsExternalLibraryImport = resolved.IsExternalLibraryImport
+			result.Candidates = append(result.Candidates, resolved.ResolvedFileName)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,22 @@
+package ls
+
+import "context"
+
+// ProgressReporter is notified of the progress of a long operation, with the percentage of the
+// operation done, from 0 to 100, and a message describing the step completed or started.
+type ProgressReporter func(percentage int, message string)
+
+type progressReporterKey struct{}
+
+// WithProgressReporter returns a context that makes the long operations of the language service
+// report their progress to reporter.
+func WithProgressReporter(ctx context.Context, reporter ProgressReporter) context.Context {
+	return context.WithValue(ctx, progressReporterKey{}, reporter)
+}
+
+// ReportProgress reports the progress of the current operation to the reporter of ctx, if any.
+func ReportProgress(ctx context.Context, percentage int, message string) {
+	if reporter, ok := ctx.Value(progressReporterKey{}).(ProgressReporter); ok && reporter != nil {
+		reporter(percentage, message)
+	}
+}
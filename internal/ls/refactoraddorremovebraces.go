@@ -0,0 +1,158 @@
+package ls
+
+import (
+	"strings"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/scanner"
+)
+
+const (
+	refactorNameAddOrRemoveBraces = "Add or remove braces in an arrow function"
+
+	refactorActionAddBraces    = "Add braces to arrow function"
+	refactorActionRemoveBraces = "Remove braces from arrow function"
+)
+
+var addOrRemoveBracesRefactorProvider = &refactorProvider{
+	name:                refactorNameAddOrRemoveBraces,
+	description:         "Add or remove braces in an arrow function",
+	getAvailableActions: getAddOrRemoveBracesActions,
+	getEditsForAction:   getAddOrRemoveBracesEdits,
+}
+
+func getAddOrRemoveBracesActions(c *refactorContext) []*RefactorAction {
+	arrowFunction := getConvertibleArrowFunction(c)
+	if arrowFunction == nil {
+		return nil
+	}
+	if ast.IsBlock(arrowFunction.Body()) {
+		return []*RefactorAction{{
+			Name:        refactorActionRemoveBraces,
+			Description: refactorActionRemoveBraces,
+			Kind:        "refactor.rewrite.arrow.braces.remove",
+		}}
+	}
+	return []*RefactorAction{{
+		Name:        refactorActionAddBraces,
+		Description: refactorActionAddBraces,
+		Kind:        "refactor.rewrite.arrow.braces.add",
+	}}
+}
+
+func getAddOrRemoveBracesEdits(c *refactorContext, actionName string) *lsproto.WorkspaceEdit {
+	arrowFunction := getConvertibleArrowFunction(c)
+	if arrowFunction == nil {
+		return nil
+	}
+	ct := c.ls.newChangeTracker(c.ctx)
+	switch body := arrowFunction.Body(); {
+	case actionName == refactorActionAddBraces && !ast.IsBlock(body):
+		ct.addBracesToArrowFunction(c.sourceFile, arrowFunction)
+	case actionName == refactorActionRemoveBraces && ast.IsBlock(body):
+		ct.removeBracesFromArrowFunction(c.sourceFile, arrowFunction)
+	default:
+		return nil
+	}
+	return ct.getWorkspaceEdit()
+}
+
+// addBracesToArrowFunction replaces the expression body of an arrow function with a block
+// returning it, removing the parentheses the expression needed as a body:
+//
+//	x => ({ a: x }) -> x => { return { a: x }; }
+func (ct *changeTracker) addBracesToArrowFunction(file *ast.SourceFile, arrowFunction *ast.Node) {
+	body := arrowFunction.Body()
+	bodyStart := scanner.GetTokenPosOfNode(body, file, false /*includeJSDoc*/)
+	ct.replaceRangeWithText(file, *ct.ls.createLspRangeFromBounds(bodyStart, body.End(), file), ct.getBlockReturningArrowBody(file, arrowFunction))
+}
+
+// getBlockReturningArrowBody returns the text of a block returning the expression body of an arrow
+// function, indented as the statement containing the arrow function.
+func (ct *changeTracker) getBlockReturningArrowBody(file *ast.SourceFile, arrowFunction *ast.Node) string {
+	text := file.Text()
+	body := arrowFunction.Body()
+	bodyStart := scanner.GetTokenPosOfNode(body, file, false /*includeJSDoc*/)
+	expressionText := text[bodyStart:body.End()]
+	if ast.IsParenthesizedExpression(body) && needsParenthesesForArrowBody(body.Expression()) {
+		expression := body.Expression()
+		expressionText = text[scanner.SkipTriviaEx(text, expression.Pos(), &scanner.SkipTriviaOptions{StopAtComments: true}):expression.End()]
+	}
+	indentation := getLineIndentation(file, scanner.GetTokenPosOfNode(arrowFunction, file, false /*includeJSDoc*/))
+	// Lines after the first keep their position relative to the start of the statement.
+	expressionText = strings.ReplaceAll(expressionText, "\n", "\n"+ct.indentationUnit())
+	return "{" + ct.newLine +
+		indentation + ct.indentationUnit() + "return " + expressionText + ";" + ct.newLine +
+		indentation + "}"
+}
+
+// removeBracesFromArrowFunction replaces the block body of an arrow function with the expression
+// of its return statement, parenthesized if needed. Comments around the return statement are kept
+// as block comments around the expression:
+//
+//	x => { return { a: x }; } -> x => ({ a: x })
+func (ct *changeTracker) removeBracesFromArrowFunction(file *ast.SourceFile, arrowFunction *ast.Node) {
+	text := file.Text()
+	body := arrowFunction.Body()
+	returnStatement := body.Statements()[0]
+	expression := returnStatement.Expression()
+	// Comments between the return keyword and the expression are part of it.
+	returnKeywordEnd := scanner.GetTokenPosOfNode(returnStatement, file, false /*includeJSDoc*/) + len("return")
+	expressionText := text[scanner.SkipTriviaEx(text, returnKeywordEnd, &scanner.SkipTriviaOptions{StopAtComments: true}):expression.End()]
+	expressionText = strings.ReplaceAll(expressionText, ct.newLine+ct.indentationUnit(), ct.newLine)
+	if needsParenthesesForArrowBody(expression) {
+		expressionText = "(" + expressionText + ")"
+	}
+	var b strings.Builder
+	for comment := range scanner.GetLeadingCommentRanges(ct.NodeFactory, text, returnStatement.Pos()) {
+		b.WriteString(toBlockComment(text, comment))
+		b.WriteString(" ")
+	}
+	b.WriteString(expressionText)
+	for comment := range scanner.GetLeadingCommentRanges(ct.NodeFactory, text, returnStatement.End()) {
+		b.WriteString(" ")
+		b.WriteString(toBlockComment(text, comment))
+	}
+	bodyStart := scanner.GetTokenPosOfNode(body, file, false /*includeJSDoc*/)
+	ct.replaceRangeWithText(file, *ct.ls.createLspRangeFromBounds(bodyStart, body.End(), file), b.String())
+}
+
+// toBlockComment returns the text of a comment, converted to a block comment if it is a line
+// comment.
+func toBlockComment(text string, comment ast.CommentRange) string {
+	if comment.Kind == ast.KindSingleLineCommentTrivia {
+		return "/*" + strings.TrimRight(text[comment.Pos()+2:comment.End()], " \t") + " */"
+	}
+	return text[comment.Pos():comment.End()]
+}
+
+// getConvertibleArrowFunction returns the innermost function containing the span if it is an arrow
+// function whose body is either an expression or a block with a single return statement with an
+// expression.
+func getConvertibleArrowFunction(c *refactorContext) *ast.Node {
+	arrowFunction := c.findContainingNode(ast.IsFunctionLike)
+	if arrowFunction == nil || !ast.IsArrowFunction(arrowFunction) {
+		return nil
+	}
+	if body := arrowFunction.Body(); ast.IsBlock(body) {
+		statements := body.Statements()
+		if len(statements) != 1 || !ast.IsReturnStatement(statements[0]) || statements[0].Expression() == nil {
+			return nil
+		}
+	}
+	return arrowFunction
+}
+
+// needsParenthesesForArrowBody reports whether an expression must be parenthesized to be the
+// body of an arrow function: an object literal, which would otherwise be parsed as a block, or a
+// comma expression, which would otherwise end the body.
+func needsParenthesesForArrowBody(expression *ast.Node) bool {
+	switch {
+	case ast.IsBinaryExpression(expression):
+		return expression.AsBinaryExpression().OperatorToken.Kind == ast.KindCommaToken
+	case expression.Kind == ast.KindAsExpression || ast.IsSatisfiesExpression(expression):
+		return ast.IsObjectLiteralExpression(expression.Expression())
+	}
+	return ast.IsObjectLiteralExpression(expression)
+}
@@ -0,0 +1,329 @@
+package ls
+
+import (
+	"strings"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/checker"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/scanner"
+)
+
+const (
+	refactorNameConvertArrowFunctionOrFunctionExpression = "Convert arrow function or function expression"
+
+	refactorActionConvertToAnonymousFunction = "Convert to anonymous function"
+	refactorActionConvertToNamedFunction     = "Convert to named function"
+	refactorActionConvertToArrowFunction     = "Convert to arrow function"
+)
+
+var convertArrowFunctionOrFunctionExpressionRefactorProvider = &refactorProvider{
+	name:                refactorNameConvertArrowFunctionOrFunctionExpression,
+	description:         "Convert arrow function or function expression",
+	getAvailableActions: getConvertArrowFunctionOrFunctionExpressionActions,
+	getEditsForAction:   getConvertArrowFunctionOrFunctionExpressionEdits,
+}
+
+func getConvertArrowFunctionOrFunctionExpressionActions(c *refactorContext) []*RefactorAction {
+	function, declaration := getConvertibleFunction(c)
+	if function == nil {
+		return nil
+	}
+	var actions []*RefactorAction
+	if ast.IsArrowFunction(function) {
+		actions = append(actions, &RefactorAction{
+			Name:                refactorActionConvertToAnonymousFunction,
+			Description:         refactorActionConvertToAnonymousFunction,
+			Kind:                "refactor.rewrite.function.anonymous",
+			NotApplicableReason: getConvertToFunctionExpressionReason(c.checker, function),
+		})
+	} else {
+		actions = append(actions, &RefactorAction{
+			Name:                refactorActionConvertToArrowFunction,
+			Description:         refactorActionConvertToArrowFunction,
+			Kind:                "refactor.rewrite.function.arrow",
+			NotApplicableReason: getConvertToArrowFunctionReason(c.checker, function),
+		})
+	}
+	if declaration != nil {
+		actions = append(actions, &RefactorAction{
+			Name:                refactorActionConvertToNamedFunction,
+			Description:         refactorActionConvertToNamedFunction,
+			Kind:                "refactor.rewrite.function.named",
+			NotApplicableReason: getConvertToNamedFunctionReason(c.checker, function, declaration),
+		})
+	}
+	return actions
+}
+
+func getConvertArrowFunctionOrFunctionExpressionEdits(c *refactorContext, actionName string) *lsproto.WorkspaceEdit {
+	function, declaration := getConvertibleFunction(c)
+	if function == nil {
+		return nil
+	}
+	ct := c.ls.newChangeTracker(c.ctx)
+	switch {
+	case actionName == refactorActionConvertToAnonymousFunction && ast.IsArrowFunction(function):
+		if getConvertToFunctionExpressionReason(c.checker, function) != "" {
+			return nil
+		}
+		ct.convertArrowFunctionToFunctionExpression(c.sourceFile, function)
+	case actionName == refactorActionConvertToArrowFunction && ast.IsFunctionExpression(function):
+		if getConvertToArrowFunctionReason(c.checker, function) != "" {
+			return nil
+		}
+		ct.convertFunctionExpressionToArrowFunction(c.sourceFile, function)
+	case actionName == refactorActionConvertToNamedFunction && declaration != nil:
+		if getConvertToNamedFunctionReason(c.checker, function, declaration) != "" {
+			return nil
+		}
+		ct.convertToFunctionDeclaration(c.sourceFile, function, declaration)
+	default:
+		return nil
+	}
+	return ct.getWorkspaceEdit()
+}
+
+// getConvertibleFunction returns the innermost arrow function or function expression whose
+// signature contains the span, or whose variable declaration does, and that declaration if the
+// function is the initializer of the only variable of a `const` statement. Positions in the body of
+// a function are not part of its signature.
+func getConvertibleFunction(c *refactorContext) (*ast.Node, *ast.Node) {
+	token := c.startToken()
+	node := c.findContainingNode(func(node *ast.Node) bool {
+		return ast.IsFunctionLike(node) || ast.IsVariableDeclaration(node)
+	})
+	var function *ast.Node
+	switch {
+	case node == nil:
+		return nil, nil
+	case ast.IsArrowFunction(node) || ast.IsFunctionExpression(node):
+		function = node
+	case ast.IsVariableDeclaration(node) && node.Initializer() != nil:
+		function = ast.SkipParentheses(node.Initializer())
+		if !ast.IsArrowFunction(function) && !ast.IsFunctionExpression(function) {
+			return nil, nil
+		}
+	default:
+		return nil, nil
+	}
+	if containsNodeRange(function.Body(), token) {
+		return nil, nil
+	}
+
+	declaration := ast.WalkUpParenthesizedExpressions(function.Parent)
+	if !ast.IsVariableDeclaration(declaration) || !ast.IsIdentifier(declaration.Name()) || !ast.IsVarConst(declaration) ||
+		!ast.IsVariableStatement(declaration.Parent.Parent) || len(declaration.Parent.AsVariableDeclarationList().Declarations.Nodes) != 1 {
+		declaration = nil
+	}
+	return function, declaration
+}
+
+// getConvertToFunctionExpressionReason returns why an arrow function cannot be converted to a
+// function expression, which binds `this` and `arguments` of its own.
+func getConvertToFunctionExpressionReason(ch *checker.Checker, arrowFunction *ast.Node) string {
+	if usesBoundFunctionContext(ch, arrowFunction) {
+		return "Cannot convert an arrow function that uses 'this', 'super', 'arguments' or 'new.target' of its enclosing function."
+	}
+	return ""
+}
+
+// getConvertToArrowFunctionReason returns why a function expression cannot be converted to an arrow
+// function, which can be neither a generator nor named and uses `this` and `arguments` of the
+// enclosing function.
+func getConvertToArrowFunctionReason(ch *checker.Checker, functionExpression *ast.Node) string {
+	switch {
+	case functionExpression.AsFunctionExpression().AsteriskToken != nil:
+		return "Cannot convert a generator to an arrow function."
+	case core.Some(functionExpression.Parameters(), ast.IsThisParameter):
+		return "Cannot convert a function with a 'this' parameter to an arrow function."
+	case usesBoundFunctionContext(ch, functionExpression):
+		return "Cannot convert a function that uses 'this', 'super', 'arguments' or 'new.target' to an arrow function."
+	case refersToOwnName(ch, functionExpression):
+		return "Cannot convert a function expression that refers to its own name to an arrow function."
+	}
+	return ""
+}
+
+// getConvertToNamedFunctionReason returns why a function cannot replace the variable declaration
+// it initializes as a function declaration of the same name.
+func getConvertToNamedFunctionReason(ch *checker.Checker, function *ast.Node, declaration *ast.Node) string {
+	switch {
+	case declaration.Type() != nil:
+		return "Cannot convert a variable with a type annotation to a named function."
+	case ast.IsArrowFunction(function):
+		return getConvertToFunctionExpressionReason(ch, function)
+	case function.Name() != nil && function.Name().Text() != declaration.Name().Text() && refersToOwnName(ch, function):
+		return "Cannot convert a function expression that refers to its own name to a function of another name."
+	}
+	return ""
+}
+
+// usesBoundFunctionContext returns whether the `this`, `super`, `arguments` or `new.target` used in
+// a function expression are its own, or those used in an arrow function are of the function
+// enclosing it. They would be bound by another function once it is converted.
+func usesBoundFunctionContext(ch *checker.Checker, function *ast.Node) bool {
+	isBoundByFunctionOrEnclosing := func(node *ast.Node) bool {
+		return containsNodeRange(ast.GetThisContainer(node, false /*includeArrowFunctions*/, false /*includeClassComputedPropertyName*/), function)
+	}
+	var visit func(node *ast.Node) bool
+	visit = func(node *ast.Node) bool {
+		switch node.Kind {
+		case ast.KindThisKeyword, ast.KindSuperKeyword:
+			return isBoundByFunctionOrEnclosing(node)
+		case ast.KindMetaProperty:
+			return node.AsMetaProperty().KeywordToken == ast.KindNewKeyword && isBoundByFunctionOrEnclosing(node)
+		case ast.KindIdentifier:
+			// The arguments object has no declarations, unlike a variable named `arguments`.
+			if node.Text() != "arguments" || isRightSideOfPropertyAccess(node) || ast.IsDeclarationName(node) {
+				return false
+			}
+			symbol := ch.GetSymbolAtLocation(node)
+			return symbol != nil && len(symbol.Declarations) == 0 && isBoundByFunctionOrEnclosing(node)
+		}
+		return node.ForEachChild(visit)
+	}
+	return function.ForEachChild(visit)
+}
+
+// refersToOwnName returns whether a named function expression refers to itself by its name.
+func refersToOwnName(ch *checker.Checker, functionExpression *ast.Node) bool {
+	if functionExpression.Name() == nil {
+		return false
+	}
+	found := false
+	forEachReferenceIdentifier(functionExpression, func(identifier *ast.Node) {
+		found = found || getSymbolOfReferenceIdentifier(ch, identifier) == functionExpression.Symbol()
+	})
+	return found
+}
+
+// convertArrowFunctionToFunctionExpression replaces an arrow function with a function expression,
+// parenthesized if it would start an expression statement:
+//
+//	async x => x -> async function (x) { return x; }
+func (ct *changeTracker) convertArrowFunctionToFunctionExpression(file *ast.SourceFile, arrowFunction *ast.Node) {
+	text := "function " + getFunctionSignatureText(file, arrowFunction) + " " + ct.getFunctionBodyText(file, arrowFunction)
+	if ast.IsExpressionStatement(getOutermostExpressionStartingWith(arrowFunction).Parent) {
+		text = "(" + getFunctionModifiersText(file, arrowFunction) + text + ")"
+	} else {
+		text = getFunctionModifiersText(file, arrowFunction) + text
+	}
+	ct.replaceFunction(file, arrowFunction, text)
+}
+
+// convertFunctionExpressionToArrowFunction replaces a function expression with an arrow function,
+// parenthesized where an arrow function would not bind as tightly:
+//
+//	f || function (x) { return x; } -> f || ((x) => { return x; })
+func (ct *changeTracker) convertFunctionExpressionToArrowFunction(file *ast.SourceFile, functionExpression *ast.Node) {
+	signature := getFunctionSignatureText(file, functionExpression)
+	// `<T>(x) =>` would be parsed as the start of a JSX element.
+	if typeParameters := functionExpression.TypeParameters(); file.LanguageVariant == core.LanguageVariantJSX &&
+		len(typeParameters) == 1 && typeParameters[0].AsTypeParameter().Constraint == nil {
+		offset := typeParameters[0].End() - getFunctionSignatureStart(file, functionExpression)
+		signature = signature[:offset] + "," + signature[offset:]
+	}
+	text := getFunctionModifiersText(file, functionExpression) + signature + " => " + ct.getFunctionBodyText(file, functionExpression)
+	if needsParenthesesForArrowFunction(functionExpression) {
+		text = "(" + text + ")"
+	}
+	ct.replaceFunction(file, functionExpression, text)
+}
+
+// convertToFunctionDeclaration replaces the variable statement declaring a function with a
+// function declaration of the same name, with the modifiers of both:
+//
+//	export const f = async (x: number) => x; -> export async function f(x: number) { return x; }
+func (ct *changeTracker) convertToFunctionDeclaration(file *ast.SourceFile, function *ast.Node, declaration *ast.Node) {
+	statement := declaration.Parent.Parent
+	start := scanner.GetTokenPosOfNode(statement, file, false /*includeJSDoc*/)
+	keyword := "function "
+	if ast.IsFunctionExpression(function) && function.AsFunctionExpression().AsteriskToken != nil {
+		keyword = "function* "
+	}
+	text := file.Text()[start:scanner.GetTokenPosOfNode(declaration.Parent, file, false /*includeJSDoc*/)] +
+		getFunctionModifiersText(file, function) + keyword + declaration.Name().Text() +
+		getFunctionSignatureText(file, function) + " " + ct.getFunctionBodyText(file, function)
+	ct.replaceRangeWithText(file, *ct.ls.createLspRangeFromBounds(start, statement.End(), file), text)
+}
+
+func (ct *changeTracker) replaceFunction(file *ast.SourceFile, function *ast.Node, text string) {
+	start := scanner.GetTokenPosOfNode(function, file, false /*includeJSDoc*/)
+	ct.replaceRangeWithText(file, *ct.ls.createLspRangeFromBounds(start, function.End(), file), text)
+}
+
+// getFunctionModifiersText returns the text of the modifiers of a function, such as `async `.
+func getFunctionModifiersText(file *ast.SourceFile, function *ast.Node) string {
+	start := scanner.GetTokenPosOfNode(function, file, false /*includeJSDoc*/)
+	if ast.IsFunctionExpression(function) {
+		return file.Text()[start:scanner.GetTokenPosOfNode(findChildOfKind(function, ast.KindFunctionKeyword, file), file, false /*includeJSDoc*/)]
+	}
+	return file.Text()[start:getFunctionSignatureStart(file, function)]
+}
+
+// getFunctionSignatureStart returns the start of the type parameters or parameters of a function
+// expression or arrow function, after its modifiers, keyword and name.
+func getFunctionSignatureStart(file *ast.SourceFile, function *ast.Node) int {
+	text := file.Text()
+	if ast.IsArrowFunction(function) {
+		if modifiers := function.Modifiers(); modifiers != nil {
+			return scanner.SkipTrivia(text, modifiers.End())
+		}
+		return scanner.GetTokenPosOfNode(function, file, false /*includeJSDoc*/)
+	}
+	end := findChildOfKind(function, ast.KindFunctionKeyword, file).End()
+	if asterisk := function.AsFunctionExpression().AsteriskToken; asterisk != nil {
+		end = asterisk.End()
+	}
+	if name := function.Name(); name != nil {
+		end = name.End()
+	}
+	return scanner.SkipTrivia(text, end)
+}
+
+// getFunctionSignatureText returns the text of the type parameters, parameters and return type of
+// a function expression or arrow function, with the parameter of an arrow function parenthesized.
+func getFunctionSignatureText(file *ast.SourceFile, function *ast.Node) string {
+	end := function.Body().Pos()
+	if ast.IsArrowFunction(function) {
+		end = function.AsArrowFunction().EqualsGreaterThanToken.Pos()
+	}
+	signature := strings.TrimRight(file.Text()[getFunctionSignatureStart(file, function):end], " \t\r\n")
+	if ast.IsArrowFunction(function) && findChildOfKind(function, ast.KindOpenParenToken, file) == nil {
+		signature = "(" + signature + ")"
+	}
+	return signature
+}
+
+// getFunctionBodyText returns the text of the body of a function as a block.
+func (ct *changeTracker) getFunctionBodyText(file *ast.SourceFile, function *ast.Node) string {
+	body := function.Body()
+	if !ast.IsBlock(body) {
+		return ct.getBlockReturningArrowBody(file, function)
+	}
+	return file.Text()[scanner.GetTokenPosOfNode(body, file, false /*includeJSDoc*/):body.End()]
+}
+
+// needsParenthesesForArrowFunction returns whether an arrow function must be parenthesized to
+// replace node, since an arrow function can only be used where any assignment expression can.
+func needsParenthesesForArrowFunction(node *ast.Node) bool {
+	parent := node.Parent
+	switch parent.Kind {
+	case ast.KindBinaryExpression:
+		operator := parent.AsBinaryExpression().OperatorToken.Kind
+		return !(ast.IsAssignmentOperator(operator) && parent.AsBinaryExpression().Right == node) && operator != ast.KindCommaToken
+	case ast.KindConditionalExpression:
+		return parent.AsConditionalExpression().Condition == node
+	case ast.KindCallExpression, ast.KindNewExpression, ast.KindElementAccessExpression:
+		return parent.Expression() == node
+	case ast.KindTaggedTemplateExpression:
+		return parent.AsTaggedTemplateExpression().Tag == node
+	case ast.KindPropertyAccessExpression, ast.KindNonNullExpression, ast.KindAsExpression, ast.KindSatisfiesExpression,
+		ast.KindPrefixUnaryExpression, ast.KindPostfixUnaryExpression, ast.KindTypeOfExpression, ast.KindVoidExpression,
+		ast.KindDeleteExpression, ast.KindAwaitExpression, ast.KindTypeAssertionExpression, ast.KindExpressionWithTypeArguments:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,334 @@
+package ls
+
+import (
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+	"github.com/microsoft/typescript-go/internal/collections"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/scanner"
+)
+
+const (
+	refactorNameConvertExport = "Convert export"
+
+	refactorActionConvertDefaultToNamedExport = "Convert default export to named export"
+	refactorActionConvertNamedToDefaultExport = "Convert named export to default export"
+)
+
+var convertExportRefactorProvider = &refactorProvider{
+	name:                refactorNameConvertExport,
+	description:         "Convert between default and named exports",
+	getAvailableActions: getConvertExportActions,
+	getEditsForAction:   getConvertExportEdits,
+}
+
+type convertExportInfo struct {
+	// The statement declaring the export.
+	exportNode *ast.Node
+	// The name of the export once it is named, generated from the file name for an anonymous default
+	// export.
+	exportName string
+	// Whether the export has no name of its own and must be given exportName.
+	anonymous  bool
+	wasDefault bool
+	// The symbol of the export in the exports of the module.
+	exportSymbol          *ast.Symbol
+	exportingModuleSymbol *ast.Symbol
+}
+
+func getConvertExportActions(c *refactorContext) []*RefactorAction {
+	info := getConvertExportInfo(c)
+	if info == nil {
+		return nil
+	}
+	if info.wasDefault {
+		return []*RefactorAction{{
+			Name:        refactorActionConvertDefaultToNamedExport,
+			Description: refactorActionConvertDefaultToNamedExport,
+			Kind:        "refactor.rewrite.export.named",
+		}}
+	}
+	return []*RefactorAction{{
+		Name:        refactorActionConvertNamedToDefaultExport,
+		Description: refactorActionConvertNamedToDefaultExport,
+		Kind:        "refactor.rewrite.export.default",
+	}}
+}
+
+func getConvertExportEdits(c *refactorContext, actionName string) *lsproto.WorkspaceEdit {
+	info := getConvertExportInfo(c)
+	if info == nil || info.wasDefault != (actionName == refactorActionConvertDefaultToNamedExport) ||
+		!info.wasDefault && actionName != refactorActionConvertNamedToDefaultExport {
+		return nil
+	}
+	ct := c.ls.newChangeTracker(c.ctx)
+	ct.changeExport(c, info)
+	ct.changeExportImports(c, info)
+	return ct.getWorkspaceEdit()
+}
+
+// getConvertExportInfo returns the export declared by the top-level statement at the span, if it
+// can be converted between a default and a named export.
+func getConvertExportInfo(c *refactorContext) *convertExportInfo {
+	statement := c.topLevelStatement()
+	moduleSymbol := c.sourceFile.Symbol
+	if statement == nil || moduleSymbol == nil || moduleSymbol.Exports == nil {
+		return nil
+	}
+	flags := statement.ModifierFlags()
+	if ast.IsExportAssignment(statement) && !statement.AsExportAssignment().IsExportEquals {
+		flags = ast.ModifierFlagsExportDefault
+	}
+	if flags&ast.ModifierFlagsExport == 0 {
+		return nil
+	}
+	info := &convertExportInfo{
+		exportNode:            statement,
+		wasDefault:            flags&ast.ModifierFlagsDefault != 0,
+		exportingModuleSymbol: moduleSymbol,
+	}
+	switch statement.Kind {
+	case ast.KindFunctionDeclaration, ast.KindClassDeclaration, ast.KindInterfaceDeclaration, ast.KindEnumDeclaration,
+		ast.KindTypeAliasDeclaration, ast.KindModuleDeclaration:
+		if name := statement.Name(); name != nil {
+			if !ast.IsIdentifier(name) {
+				return nil
+			}
+			info.exportName = name.Text()
+		} else {
+			info.anonymous = true
+		}
+	case ast.KindVariableStatement:
+		// Only `export const x = ...` can become `export default ...`.
+		declarationList := statement.AsVariableStatement().DeclarationList
+		declarations := declarationList.AsVariableDeclarationList().Declarations.Nodes
+		if declarationList.Flags&ast.NodeFlagsConst == 0 || len(declarations) != 1 || declarations[0].Initializer() == nil || !ast.IsIdentifier(declarations[0].Name()) {
+			return nil
+		}
+		info.exportName = declarations[0].Name().Text()
+	case ast.KindExportAssignment:
+		if statement.AsExportAssignment().IsExportEquals {
+			return nil
+		}
+		if expression := statement.Expression(); ast.IsIdentifier(expression) {
+			info.exportName = expression.Text()
+		} else {
+			info.anonymous = true
+		}
+	default:
+		return nil
+	}
+	if info.anonymous {
+		info.exportName = getUniqueExtractName(c.sourceFile, moduleSpecifierToValidIdentifier(c.sourceFile.FileName(), c.program.Options().GetEmitScriptTarget(), ast.IsClassDeclaration(statement)))
+	}
+	if info.wasDefault {
+		// The named export must not clash with another export of the module.
+		if moduleSymbol.Exports.Get(info.exportName) != nil {
+			return nil
+		}
+		info.exportSymbol = moduleSymbol.Exports.Get(ast.InternalSymbolNameDefault)
+	} else {
+		// The module must not have a default export already.
+		if moduleSymbol.Exports.Get(ast.InternalSymbolNameDefault) != nil {
+			return nil
+		}
+		info.exportSymbol = moduleSymbol.Exports.Get(info.exportName)
+	}
+	if info.exportSymbol == nil {
+		return nil
+	}
+	return info
+}
+
+// changeExport converts the declaration of the export.
+func (ct *changeTracker) changeExport(c *refactorContext, info *convertExportInfo) {
+	sourceFile := c.sourceFile
+	exportNode := info.exportNode
+	if info.wasDefault {
+		switch {
+		case ast.IsExportAssignment(exportNode) && info.anonymous:
+			// export default 1 + 1 -> export const name = 1 + 1
+			ct.replaceNodeStartWithText(sourceFile, exportNode, exportNode.Expression(), "export const "+info.exportName+" = ")
+		case ast.IsExportAssignment(exportNode):
+			// export default x -> export { x }
+			ct.replaceRangeWithText(sourceFile, ct.getAdjustedRange(sourceFile, exportNode, exportNode, leadingTriviaOptionExclude, trailingTriviaOptionExclude), "export { "+info.exportName+" };")
+		default:
+			// export default function f() {} -> export function f() {}
+			defaultKeyword := findModifier(exportNode, ast.KindDefaultKeyword)
+			ct.deleteRange(sourceFile, core.NewTextRange(astnav.GetStartOfNode(defaultKeyword, sourceFile, false /*includeJSDoc*/), scanner.SkipTrivia(sourceFile.Text(), defaultKeyword.End())))
+			if info.anonymous {
+				// export default function () {} -> export function name() {}
+				namePos := getAnonymousDeclarationNamePosition(sourceFile, exportNode)
+				ct.replaceRangeWithText(sourceFile, *ct.ls.createLspRangeFromBounds(namePos, scanner.SkipTrivia(sourceFile.Text(), namePos), sourceFile), " "+info.exportName+core.IfElse(ast.IsClassDeclaration(exportNode), " ", ""))
+			}
+		}
+		return
+	}
+
+	exportKeyword := findModifier(exportNode, ast.KindExportKeyword)
+	switch exportNode.Kind {
+	case ast.KindFunctionDeclaration, ast.KindClassDeclaration, ast.KindInterfaceDeclaration:
+		// export function f() {} -> export default function f() {}
+		ct.insertText(sourceFile, ct.ls.createLspPosition(exportKeyword.End(), sourceFile), " default")
+		return
+	case ast.KindVariableStatement:
+		// export const x = 0 -> export default 0, when nothing else in the file refers to x.
+		declaration := exportNode.AsVariableStatement().DeclarationList.AsVariableDeclarationList().Declarations.Nodes[0]
+		if declaration.Type() == nil && !isDeclarationReferencedInFile(c, declaration) {
+			ct.replaceNodeStartWithText(sourceFile, exportNode, declaration.Initializer(), "export default ")
+			return
+		}
+	}
+	// export type T = number -> type T = number; export default T;
+	ct.deleteRange(sourceFile, core.NewTextRange(astnav.GetStartOfNode(exportKeyword, sourceFile, false /*includeJSDoc*/), scanner.SkipTrivia(sourceFile.Text(), exportKeyword.End())))
+	ct.insertText(sourceFile, ct.ls.createLspPosition(exportNode.End(), sourceFile), ct.newLine+ct.newLine+"export default "+info.exportName+";")
+}
+
+// getAnonymousDeclarationNamePosition returns the position at which the name of an anonymous
+// function or class declaration goes: after the function or class keyword, or the asterisk of a
+// generator.
+func getAnonymousDeclarationNamePosition(sourceFile *ast.SourceFile, declaration *ast.Node) int {
+	if ast.IsFunctionDeclaration(declaration) {
+		if asteriskToken := declaration.AsFunctionDeclaration().AsteriskToken; asteriskToken != nil {
+			return asteriskToken.End()
+		}
+	}
+	keywordStart := scanner.SkipTrivia(sourceFile.Text(), declaration.Modifiers().End())
+	return scanner.GetScannerForSourceFile(sourceFile, keywordStart).TokenEnd()
+}
+
+// isDeclarationReferencedInFile reports whether the name of declaration is referenced in its file
+// other than by the declaration itself.
+func isDeclarationReferencedInFile(c *refactorContext, declaration *ast.Node) bool {
+	name := declaration.Name()
+	symbol := c.checker.GetSymbolAtLocation(name)
+	return core.Some(getPossibleSymbolReferenceNodes(c.sourceFile, name.Text(), c.sourceFile.AsNode()), func(node *ast.Node) bool {
+		return node != name && ast.IsIdentifier(node) && c.checker.GetSymbolAtLocation(node) == symbol
+	})
+}
+
+// changeExportImports updates the imports and re-exports of the export in the files of the program.
+func (ct *changeTracker) changeExportImports(c *refactorContext, info *convertExportInfo) {
+	sourceFiles := c.program.GetSourceFiles()
+	sourceFilesSet := collections.NewSetWithSizeHint[string](len(sourceFiles))
+	for _, file := range sourceFiles {
+		sourceFilesSet.Add(file.FileName())
+	}
+	exportKind := core.IfElse(info.wasDefault, ExportKindDefault, ExportKindNamed)
+	importTracker := createImportTracker(sourceFiles, sourceFilesSet, c.checker)
+	result := importTracker(info.exportSymbol, &ExportInfo{exportingModuleSymbol: info.exportingModuleSymbol, exportKind: exportKind}, false /*isForRename*/)
+
+	var changed collections.Set[*ast.Node]
+	changeReference := func(ref *ast.Node) {
+		importingSourceFile := ast.GetSourceFileOfNode(ref)
+		if importingSourceFile == c.sourceFile && ref.Parent == info.exportNode || !changed.AddIfAbsent(ref) {
+			return
+		}
+		if info.wasDefault {
+			ct.changeDefaultToNamedImport(importingSourceFile, ref, info.exportName)
+		} else {
+			ct.changeNamedToDefaultImport(importingSourceFile, ref)
+		}
+	}
+	for _, search := range result.importSearches {
+		changeReference(search.importLocation)
+	}
+	for _, ref := range result.singleReferences {
+		if ast.IsIdentifier(ref) && ast.IsImportTypeNode(ref.Parent) {
+			changeReference(ref)
+		}
+	}
+	searchName := core.IfElse(info.wasDefault, ast.InternalSymbolNameDefault, info.exportName)
+	for _, indirectUser := range result.indirectUsers {
+		for _, node := range getPossibleSymbolReferenceNodes(indirectUser, searchName, indirectUser.AsNode()) {
+			if !ast.IsIdentifier(node) || ast.IsImportOrExportSpecifier(node.Parent) {
+				// Import and export specifiers are handled by the searches of the direct imports.
+				continue
+			}
+			symbol := c.checker.GetSymbolAtLocation(node)
+			if symbol == info.exportSymbol || symbol != nil && core.Some(symbol.Declarations, ast.IsExportAssignment) {
+				changeReference(node)
+			}
+		}
+	}
+}
+
+func (ct *changeTracker) changeDefaultToNamedImport(importingSourceFile *ast.SourceFile, ref *ast.Node, exportName string) {
+	parent := ref.Parent
+	switch parent.Kind {
+	case ast.KindPropertyAccessExpression:
+		// a.default -> a.name
+		ct.replaceNode(importingSourceFile, ref, ct.NodeFactory.NewIdentifier(exportName), nil)
+	case ast.KindImportSpecifier, ast.KindExportSpecifier:
+		// { default as foo } -> { name as foo }, { default as name } -> { name }
+		ct.replaceNode(importingSourceFile, parent, ct.newSpecifier(parent.Kind, exportName, parent.Name().Text()), nil)
+	case ast.KindImportClause:
+		specifier := ct.newSpecifier(ast.KindImportSpecifier, exportName, ref.Text())
+		namedBindings := parent.AsImportClause().NamedBindings
+		switch {
+		case namedBindings == nil:
+			// import foo from "./a" -> import { name as foo } from "./a"
+			ct.replaceNode(importingSourceFile, ref, ct.NodeFactory.NewNamedImports(ct.NodeFactory.NewNodeList([]*ast.Node{specifier})), nil)
+		case ast.IsNamespaceImport(namedBindings):
+			// import foo, * as a from "./a" -> import * as a from "./a"; import { name as foo } from "./a";
+			importDeclaration := parent.Parent
+			ct.deleteRange(importingSourceFile, core.NewTextRange(astnav.GetStartOfNode(ref, importingSourceFile, false /*includeJSDoc*/), astnav.GetStartOfNode(namedBindings, importingSourceFile, false /*includeJSDoc*/)))
+			moduleSpecifier := importDeclaration.ModuleSpecifier()
+			ct.insertText(importingSourceFile, ct.ls.createLspPosition(importDeclaration.End(), importingSourceFile),
+				ct.newLine+"import { "+getSpecifierText(exportName, ref.Text())+" } from "+importingSourceFile.Text()[astnav.GetStartOfNode(moduleSpecifier, importingSourceFile, false /*includeJSDoc*/):moduleSpecifier.End()]+";")
+		default:
+			// import foo, { bar } from "./a" -> import { bar, name as foo } from "./a"
+			ct.deleteRange(importingSourceFile, core.NewTextRange(astnav.GetStartOfNode(ref, importingSourceFile, false /*includeJSDoc*/), astnav.GetStartOfNode(namedBindings, importingSourceFile, false /*includeJSDoc*/)))
+			elements := namedBindings.Elements()
+			ct.insertNodeInListAfter(importingSourceFile, elements[len(elements)-1], specifier, elements)
+		}
+	case ast.KindImportType:
+		// import("./a").default -> import("./a").name
+		ct.replaceNode(importingSourceFile, ref, ct.NodeFactory.NewIdentifier(exportName), nil)
+	}
+}
+
+func (ct *changeTracker) changeNamedToDefaultImport(importingSourceFile *ast.SourceFile, ref *ast.Node) {
+	parent := ref.Parent
+	switch parent.Kind {
+	case ast.KindPropertyAccessExpression, ast.KindImportType:
+		// a.foo -> a.default
+		ct.replaceNode(importingSourceFile, ref, ct.NodeFactory.NewIdentifier(ast.InternalSymbolNameDefault), nil)
+	case ast.KindImportSpecifier:
+		// import { foo } from "./a" -> import foo from "./a"
+		// import { foo as bar } from "./a" -> import bar from "./a"
+		name := parent.Name().Text()
+		namedImports := parent.Parent
+		if len(namedImports.Elements()) == 1 {
+			ct.replaceNode(importingSourceFile, namedImports, ct.NodeFactory.NewIdentifier(name), nil)
+		} else {
+			ct.deleteNodeInList(importingSourceFile, parent)
+			ct.insertText(importingSourceFile, ct.ls.createLspPosition(astnav.GetStartOfNode(namedImports, importingSourceFile, false /*includeJSDoc*/), importingSourceFile), name+", ")
+		}
+	case ast.KindExportSpecifier:
+		// export { foo } from "./a" -> export { default as foo } from "./a"
+		// export { foo as default } from "./a" -> export { default } from "./a"
+		ct.replaceNode(importingSourceFile, parent, ct.newSpecifier(ast.KindExportSpecifier, ast.InternalSymbolNameDefault, parent.Name().Text()), nil)
+	}
+}
+
+// newSpecifier creates an import or export specifier for propertyName as name, omitting
+// propertyName when both are the same.
+func (ct *changeTracker) newSpecifier(kind ast.Kind, propertyName string, name string) *ast.Node {
+	var propertyNameNode *ast.Node
+	if propertyName != name {
+		propertyNameNode = ct.NodeFactory.NewIdentifier(propertyName)
+	}
+	if kind == ast.KindExportSpecifier {
+		return ct.NodeFactory.NewExportSpecifier(false /*isTypeOnly*/, propertyNameNode, ct.NodeFactory.NewIdentifier(name))
+	}
+	return ct.NodeFactory.NewImportSpecifier(false /*isTypeOnly*/, propertyNameNode, ct.NodeFactory.NewIdentifier(name))
+}
+
+func getSpecifierText(propertyName string, name string) string {
+	if propertyName == name {
+		return name
+	}
+	return propertyName + " as " + name
+}
@@ -0,0 +1,381 @@
+package ls
+
+import (
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+)
+
+const (
+	refactorNameConvertModuleSyntax = "Convert module syntax"
+
+	refactorActionConvertToESModule = "Convert to ES module syntax"
+	refactorActionConvertToCommonJS = "Convert to CommonJS syntax"
+)
+
+var convertModuleSyntaxRefactorProvider = &refactorProvider{
+	name:                refactorNameConvertModuleSyntax,
+	description:         "Convert between CommonJS require and ES import",
+	getAvailableActions: getConvertModuleSyntaxActions,
+	getEditsForAction:   getConvertModuleSyntaxEdits,
+}
+
+func getConvertModuleSyntaxActions(c *refactorContext) []*RefactorAction {
+	statement := c.topLevelStatement()
+	if statement == nil {
+		return nil
+	}
+	if canConvertToESModule(statement) {
+		return []*RefactorAction{{
+			Name:        refactorActionConvertToESModule,
+			Description: "Convert require to import",
+			Kind:        "refactor.rewrite.module.esModule",
+		}}
+	}
+	if canConvertToCommonJS(statement) {
+		return []*RefactorAction{{
+			Name:        refactorActionConvertToCommonJS,
+			Description: "Convert import to require",
+			Kind:        "refactor.rewrite.module.commonJS",
+		}}
+	}
+	return nil
+}
+
+func getConvertModuleSyntaxEdits(c *refactorContext, actionName string) *lsproto.WorkspaceEdit {
+	statement := c.topLevelStatement()
+	if statement == nil {
+		return nil
+	}
+	ct := c.ls.newChangeTracker(c.ctx)
+	switch {
+	case actionName == refactorActionConvertToESModule && canConvertToESModule(statement):
+		ct.convertToESModule(c.sourceFile, statement, c.program.Options().GetAllowSyntheticDefaultImports())
+	case actionName == refactorActionConvertToCommonJS && canConvertToCommonJS(statement):
+		ct.convertToCommonJS(c.sourceFile, statement)
+	default:
+		return nil
+	}
+	return ct.getWorkspaceEdit()
+}
+
+// canConvertToESModule reports whether statement is a variable statement initialized only with
+// `require` calls, `module.exports = ...`, or an assignment to a property of `exports`.
+func canConvertToESModule(statement *ast.Node) bool {
+	switch statement.Kind {
+	case ast.KindVariableStatement:
+		if statement.ModifierFlags()&ast.ModifierFlagsExport != 0 {
+			return false
+		}
+		declarations := statement.AsVariableStatement().DeclarationList.AsVariableDeclarationList().Declarations.Nodes
+		for _, declaration := range declarations {
+			if declaration.Type() != nil || getRequiredModuleSpecifier(declaration.Initializer()) == nil {
+				return false
+			}
+			name := declaration.Name()
+			if ast.IsIdentifier(name) {
+				continue
+			}
+			if !ast.IsObjectBindingPattern(name) || ast.IsPropertyAccessExpression(declaration.Initializer()) {
+				return false
+			}
+			for _, element := range name.AsBindingPattern().Elements.Nodes {
+				if !isImportableBindingElement(element) {
+					return false
+				}
+			}
+		}
+		return len(declarations) > 0
+	case ast.KindExpressionStatement:
+		return getExportsAssignment(statement) != nil
+	}
+	return false
+}
+
+// getRequiredModuleSpecifier returns the module specifier of `require("m")` or `require("m").name`.
+func getRequiredModuleSpecifier(initializer *ast.Node) *ast.Node {
+	if initializer == nil {
+		return nil
+	}
+	if ast.IsPropertyAccessExpression(initializer) && ast.IsIdentifier(initializer.Name()) {
+		initializer = initializer.Expression()
+	}
+	if !ast.IsRequireCall(initializer, true /*requireStringLiteralLikeArgument*/) {
+		return nil
+	}
+	return initializer.Arguments()[0]
+}
+
+// isImportableBindingElement reports whether element can become an import specifier, as the `b: c`
+// in `const { a, b: c } = require("m")`.
+func isImportableBindingElement(element *ast.Node) bool {
+	binding := element.AsBindingElement()
+	if binding.DotDotDotToken != nil || binding.Initializer != nil || !ast.IsIdentifier(binding.Name()) {
+		return false
+	}
+	return binding.PropertyName == nil || ast.IsIdentifier(binding.PropertyName) || ast.IsStringLiteral(binding.PropertyName)
+}
+
+// getExportsAssignment returns the assignment of `module.exports = ...`, `exports.name = ...` or
+// `module.exports.name = ...` in statement.
+func getExportsAssignment(statement *ast.Node) *ast.BinaryExpression {
+	expression := statement.Expression()
+	if !ast.IsBinaryExpression(expression) {
+		return nil
+	}
+	binary := expression.AsBinaryExpression()
+	if binary.OperatorToken.Kind != ast.KindEqualsToken || !ast.IsPropertyAccessExpression(binary.Left) {
+		return nil
+	}
+	if ast.IsModuleExportsAccessExpression(binary.Left) {
+		return binary
+	}
+	target := binary.Left.Expression()
+	if (ast.IsExportsIdentifier(target) || ast.IsModuleExportsAccessExpression(target)) && ast.IsIdentifier(binary.Left.Name()) {
+		return binary
+	}
+	return nil
+}
+
+func (ct *changeTracker) convertToESModule(sourceFile *ast.SourceFile, statement *ast.Node, allowSyntheticDefaultImports bool) {
+	if ast.IsExpressionStatement(statement) {
+		ct.convertExportsAssignment(sourceFile, statement, getExportsAssignment(statement))
+		return
+	}
+	var imports []*ast.Node
+	for _, declaration := range statement.AsVariableStatement().DeclarationList.AsVariableDeclarationList().Declarations.Nodes {
+		initializer := declaration.Initializer()
+		moduleSpecifier := ct.NodeFactory.NewStringLiteral(getRequiredModuleSpecifier(initializer).Text())
+		name := declaration.Name()
+		switch {
+		case ast.IsPropertyAccessExpression(initializer):
+			// const b = require("m").a -> import { a as b } from "m"
+			imports = append(imports, ct.makeImport(nil, []*ast.Node{ct.newImportSpecifier(initializer.Name(), name)}, moduleSpecifier, false /*isTypeOnly*/))
+		case ast.IsObjectBindingPattern(name):
+			// const { a, b: c } = require("m") -> import { a, b as c } from "m"
+			var specifiers []*ast.Node
+			for _, element := range name.AsBindingPattern().Elements.Nodes {
+				specifiers = append(specifiers, ct.newImportSpecifier(element.PropertyName(), element.Name()))
+			}
+			imports = append(imports, ct.makeImport(nil, specifiers, moduleSpecifier, false /*isTypeOnly*/))
+		case allowSyntheticDefaultImports:
+			// const a = require("m") -> import a from "m"
+			imports = append(imports, ct.makeImport(ct.NodeFactory.NewIdentifier(name.Text()), nil, moduleSpecifier, false /*isTypeOnly*/))
+		default:
+			// const a = require("m") -> import * as a from "m"
+			imports = append(imports, ct.NodeFactory.NewImportDeclaration(
+				/*modifiers*/ nil,
+				ct.NodeFactory.NewImportClause(false /*isTypeOnly*/, nil /*name*/, ct.NodeFactory.NewNamespaceImport(ct.NodeFactory.NewIdentifier(name.Text()))),
+				moduleSpecifier,
+				nil, /*attributes*/
+			))
+		}
+	}
+	ct.replaceNodeWithNodes(sourceFile, statement, imports)
+}
+
+// newImportSpecifier creates the specifier importing propertyName as name, omitting propertyName
+// when both are the same.
+func (ct *changeTracker) newImportSpecifier(propertyName *ast.Node, name *ast.Node) *ast.Node {
+	var propertyNameNode *ast.Node
+	if propertyName != nil && propertyName.Text() != name.Text() {
+		if ast.IsStringLiteral(propertyName) {
+			propertyNameNode = ct.NodeFactory.NewStringLiteral(propertyName.Text())
+		} else {
+			propertyNameNode = ct.NodeFactory.NewIdentifier(propertyName.Text())
+		}
+	}
+	return ct.NodeFactory.NewImportSpecifier(false /*isTypeOnly*/, propertyNameNode, ct.NodeFactory.NewIdentifier(name.Text()))
+}
+
+// convertExportsAssignment rewrites the target of an assignment to `module.exports` or one of its
+// properties, keeping the assigned expression as written.
+func (ct *changeTracker) convertExportsAssignment(sourceFile *ast.SourceFile, statement *ast.Node, assignment *ast.BinaryExpression) {
+	if !ast.IsModuleExportsAccessExpression(assignment.Left) {
+		// exports.a = 1 -> export const a = 1
+		ct.replaceNodeStartWithText(sourceFile, statement, assignment.Right, "export const "+assignment.Left.Name().Text()+" = ")
+		return
+	}
+	if names := getExportedLocalNames(assignment.Right); names != nil {
+		// module.exports = { a, b: c } -> export { a, c as b }
+		var specifiers []*ast.Node
+		for _, property := range names {
+			var propertyName *ast.Node
+			if property.local != property.exported {
+				propertyName = ct.NodeFactory.NewIdentifier(property.local)
+			}
+			specifiers = append(specifiers, ct.NodeFactory.NewExportSpecifier(false /*isTypeOnly*/, propertyName, ct.NodeFactory.NewIdentifier(property.exported)))
+		}
+		exportDeclaration := ct.NodeFactory.NewExportDeclaration(nil /*modifiers*/, false /*isTypeOnly*/, ct.NodeFactory.NewNamedExports(ct.NodeFactory.NewNodeList(specifiers)), nil /*moduleSpecifier*/, nil /*attributes*/)
+		ct.replaceNodeWithNodes(sourceFile, statement, []*ast.Node{exportDeclaration})
+		return
+	}
+	// module.exports = f -> export default f
+	ct.replaceNodeStartWithText(sourceFile, statement, assignment.Right, "export default ")
+}
+
+type exportedLocalName struct {
+	exported string
+	local    string
+}
+
+// getExportedLocalNames returns the names of an object literal whose properties all refer to local
+// variables, as in `{ a, b: c }`, or nil for any other expression.
+func getExportedLocalNames(expression *ast.Node) []exportedLocalName {
+	if !ast.IsObjectLiteralExpression(expression) {
+		return nil
+	}
+	properties := expression.AsObjectLiteralExpression().Properties.Nodes
+	names := make([]exportedLocalName, 0, len(properties))
+	for _, property := range properties {
+		switch {
+		case ast.IsShorthandPropertyAssignment(property) && property.AsShorthandPropertyAssignment().ObjectAssignmentInitializer == nil:
+			names = append(names, exportedLocalName{exported: property.Name().Text(), local: property.Name().Text()})
+		case ast.IsPropertyAssignment(property) && ast.IsIdentifier(property.Name()) && ast.IsIdentifier(property.Initializer()):
+			names = append(names, exportedLocalName{exported: property.Name().Text(), local: property.Initializer().Text()})
+		default:
+			return nil
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	return names
+}
+
+// canConvertToCommonJS reports whether statement is an import declaration without type-only parts,
+// `export default` of an expression, or a local `export { ... }`.
+func canConvertToCommonJS(statement *ast.Node) bool {
+	switch statement.Kind {
+	case ast.KindImportDeclaration:
+		importDeclaration := statement.AsImportDeclaration()
+		if !ast.IsStringLiteral(importDeclaration.ModuleSpecifier) || importDeclaration.Attributes != nil {
+			return false
+		}
+		importClause := importDeclaration.ImportClause
+		if importClause == nil {
+			return true
+		}
+		if importClause.IsTypeOnly() {
+			return false
+		}
+		namedBindings := importClause.AsImportClause().NamedBindings
+		if namedBindings == nil || ast.IsNamespaceImport(namedBindings) {
+			// import a, * as b from "m" has no single equivalent require.
+			return namedBindings == nil || importClause.Name() == nil
+		}
+		for _, element := range namedBindings.Elements() {
+			if element.IsTypeOnly() || element.PropertyName() != nil && !ast.IsIdentifier(element.PropertyName()) {
+				return false
+			}
+		}
+		return true
+	case ast.KindExportAssignment:
+		return !statement.AsExportAssignment().IsExportEquals
+	case ast.KindExportDeclaration:
+		exportDeclaration := statement.AsExportDeclaration()
+		if exportDeclaration.IsTypeOnly || exportDeclaration.ModuleSpecifier != nil || exportDeclaration.ExportClause == nil || !ast.IsNamedExports(exportDeclaration.ExportClause) {
+			return false
+		}
+		for _, element := range exportDeclaration.ExportClause.Elements() {
+			if element.IsTypeOnly() || !ast.IsIdentifier(element.Name()) || element.PropertyName() != nil && !ast.IsIdentifier(element.PropertyName()) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func (ct *changeTracker) convertToCommonJS(sourceFile *ast.SourceFile, statement *ast.Node) {
+	switch statement.Kind {
+	case ast.KindImportDeclaration:
+		ct.replaceNodeWithNodes(sourceFile, statement, ct.makeRequireStatements(statement.AsImportDeclaration()))
+	case ast.KindExportAssignment:
+		// export default f -> module.exports = f
+		ct.replaceNodeStartWithText(sourceFile, statement, statement.Expression(), "module.exports = ")
+	case ast.KindExportDeclaration:
+		// export { a, c as b } -> module.exports = { a, b: c }
+		var properties []*ast.Node
+		for _, element := range statement.AsExportDeclaration().ExportClause.Elements() {
+			exported := element.Name().Text()
+			local := exported
+			if element.PropertyName() != nil {
+				local = element.PropertyName().Text()
+			}
+			if local == exported {
+				properties = append(properties, ct.NodeFactory.NewShorthandPropertyAssignment(nil /*modifiers*/, ct.NodeFactory.NewIdentifier(exported), nil /*postfixToken*/, nil /*typeNode*/, nil /*equalsToken*/, nil /*objectAssignmentInitializer*/))
+			} else {
+				properties = append(properties, ct.NodeFactory.NewPropertyAssignment(nil /*modifiers*/, ct.NodeFactory.NewIdentifier(exported), nil /*postfixToken*/, nil /*typeNode*/, ct.NodeFactory.NewIdentifier(local)))
+			}
+		}
+		assignment := ct.NodeFactory.NewBinaryExpression(
+			nil, /*modifiers*/
+			ct.newModuleExportsExpression(),
+			nil, /*typeNode*/
+			ct.NodeFactory.NewToken(ast.KindEqualsToken),
+			ct.NodeFactory.NewObjectLiteralExpression(ct.NodeFactory.NewNodeList(properties), false /*multiLine*/),
+		)
+		ct.replaceNodeWithNodes(sourceFile, statement, []*ast.Node{ct.NodeFactory.NewExpressionStatement(assignment)})
+	}
+}
+
+func (ct *changeTracker) makeRequireStatements(importDeclaration *ast.ImportDeclaration) []*ast.Node {
+	require := ct.newRequireCall(importDeclaration.ModuleSpecifier.Text())
+	importClause := importDeclaration.ImportClause
+	if importClause == nil {
+		// import "m" -> require("m")
+		return []*ast.Node{ct.NodeFactory.NewExpressionStatement(require)}
+	}
+	var statements []*ast.Node
+	namedBindings := importClause.AsImportClause().NamedBindings
+	if name := importClause.Name(); name != nil {
+		// import a from "m" -> const a = require("m")
+		statements = append(statements, ct.newConstStatement(ct.NodeFactory.NewIdentifier(name.Text()), require))
+		// import a, { b } from "m" -> const a = require("m"); const { b } = a
+		require = ct.NodeFactory.NewIdentifier(name.Text())
+	}
+	switch {
+	case namedBindings == nil:
+	case ast.IsNamespaceImport(namedBindings):
+		// import * as a from "m" -> const a = require("m")
+		statements = append(statements, ct.newConstStatement(ct.NodeFactory.NewIdentifier(namedBindings.Name().Text()), require))
+	default:
+		// import { a, b as c } from "m" -> const { a, b: c } = require("m")
+		var elements []*ast.Node
+		for _, specifier := range namedBindings.Elements() {
+			var propertyName *ast.Node
+			if specifier.PropertyName() != nil && specifier.PropertyName().Text() != specifier.Name().Text() {
+				propertyName = ct.NodeFactory.NewIdentifier(specifier.PropertyName().Text())
+			}
+			elements = append(elements, ct.NodeFactory.NewBindingElement(nil /*dotDotDotToken*/, propertyName, ct.NodeFactory.NewIdentifier(specifier.Name().Text()), nil /*initializer*/))
+		}
+		statements = append(statements, ct.newConstStatement(ct.NodeFactory.NewBindingPattern(ast.KindObjectBindingPattern, ct.NodeFactory.NewNodeList(elements)), require))
+	}
+	return statements
+}
+
+func (ct *changeTracker) newRequireCall(moduleSpecifier string) *ast.Node {
+	return ct.NodeFactory.NewCallExpression(
+		ct.NodeFactory.NewIdentifier("require"),
+		nil, /*questionDotToken*/
+		nil, /*typeArguments*/
+		ct.NodeFactory.NewNodeList([]*ast.Node{ct.NodeFactory.NewStringLiteral(moduleSpecifier)}),
+		ast.NodeFlagsNone,
+	)
+}
+
+func (ct *changeTracker) newModuleExportsExpression() *ast.Node {
+	return ct.NodeFactory.NewPropertyAccessExpression(ct.NodeFactory.NewIdentifier("module"), nil /*questionDotToken*/, ct.NodeFactory.NewIdentifier("exports"), ast.NodeFlagsNone)
+}
+
+func (ct *changeTracker) newConstStatement(name *ast.Node, initializer *ast.Node) *ast.Node {
+	declaration := ct.NodeFactory.NewVariableDeclaration(name, nil /*exclamationToken*/, nil /*typeNode*/, initializer)
+	return ct.NodeFactory.NewVariableStatement(nil /*modifiers*/, ct.NodeFactory.NewVariableDeclarationList(ast.NodeFlagsConst, ct.NodeFactory.NewNodeList([]*ast.Node{declaration})))
+}
+
+// replaceNodeStartWithText replaces the text of node that precedes rest, leaving rest as written.
+func (ct *changeTracker) replaceNodeStartWithText(sourceFile *ast.SourceFile, node *ast.Node, rest *ast.Node, text string) {
+	start := astnav.GetStartOfNode(node, sourceFile, false /*includeJSDoc*/)
+	ct.replaceRangeWithText(sourceFile, *ct.ls.createLspRangeFromBounds(start, astnav.GetStartOfNode(rest, sourceFile, false /*includeJSDoc*/), sourceFile), text)
+}
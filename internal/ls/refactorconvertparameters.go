@@ -0,0 +1,283 @@
+package ls
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/checker"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/scanner"
+)
+
+const refactorNameConvertParametersToDestructuredObject = "Convert parameters to destructured object"
+
+var convertParametersToDestructuredObjectRefactorProvider = &refactorProvider{
+	name:                refactorNameConvertParametersToDestructuredObject,
+	description:         "Convert parameters to destructured object",
+	getAvailableActions: getConvertParametersToDestructuredObjectActions,
+	getEditsForAction:   getConvertParametersToDestructuredObjectEdits,
+}
+
+func getConvertParametersToDestructuredObjectActions(c *refactorContext) []*RefactorAction {
+	declaration := getConvertParametersDeclaration(c)
+	if declaration == nil {
+		return nil
+	}
+	var reason string
+	if _, ok := getConvertParametersCalls(c, declaration); !ok {
+		reason = "Cannot convert a function that is referenced other than by calls whose arguments can be converted."
+	}
+	return []*RefactorAction{{
+		Name:                refactorNameConvertParametersToDestructuredObject,
+		Description:         "Convert parameters to destructured object",
+		Kind:                "refactor.rewrite.parameters.toDestructured",
+		NotApplicableReason: reason,
+	}}
+}
+
+// getConvertParametersToDestructuredObjectEdits rewrites the parameters of the function and the
+// arguments of its calls. It returns nil if a reference to the function is not a call whose
+// arguments can be converted, such as a call with spread arguments or the function passed as a
+// value, since the function could then no longer be called with the arguments it is passed.
+func getConvertParametersToDestructuredObjectEdits(c *refactorContext, actionName string) *lsproto.WorkspaceEdit {
+	declaration := getConvertParametersDeclaration(c)
+	if actionName != refactorNameConvertParametersToDestructuredObject || declaration == nil {
+		return nil
+	}
+	calls, ok := getConvertParametersCalls(c, declaration)
+	if !ok {
+		return nil
+	}
+	ct := c.ls.newChangeTracker(c.ctx)
+	parameters := getRefactorableParameters(declaration)
+	for _, call := range calls {
+		ct.convertArgumentsToObject(ast.GetSourceFileOfNode(call), call, parameters)
+	}
+	ct.convertParametersToDestructuredObject(c.sourceFile, c.checker, declaration, parameters)
+	return ct.getWorkspaceEdit()
+}
+
+// getConvertParametersDeclaration returns the function whose signature contains the start of the
+// span, if its parameters can be converted, or nil otherwise. The function must be a function
+// declaration, a class method or constructor, or a function assigned to a const variable, so
+// that its calls can be found, and must not be overloaded.
+func getConvertParametersDeclaration(c *refactorContext) *ast.Node {
+	if ast.IsInJSFile(c.sourceFile.AsNode()) {
+		return nil
+	}
+	for node := c.startToken(); node != nil; node = node.Parent {
+		if ast.IsBlock(node) {
+			return nil
+		}
+		if parent := node.Parent; parent != nil && ast.IsArrowFunction(parent) &&
+			(node.Kind == ast.KindEqualsGreaterThanToken || parent.Body() == node) {
+			return nil
+		}
+		switch node.Kind {
+		case ast.KindFunctionDeclaration, ast.KindFunctionExpression, ast.KindArrowFunction, ast.KindMethodDeclaration, ast.KindConstructor:
+			if !isConvertibleParametersFunction(node) {
+				return nil
+			}
+			return node
+		}
+	}
+	return nil
+}
+
+func isConvertibleParametersFunction(declaration *ast.Node) bool {
+	if declaration.Body() == nil || getConvertParametersFunctionName(declaration) == nil {
+		return false
+	}
+	parameters := getRefactorableParameters(declaration)
+	if len(parameters) == 0 {
+		return false
+	}
+	for _, parameter := range parameters {
+		if len(parameter.ModifierNodes()) != 0 || !ast.IsIdentifier(parameter.Name()) {
+			return false
+		}
+	}
+	if symbol := declaration.Symbol(); symbol != nil {
+		for _, d := range symbol.Declarations {
+			if d != declaration && d.Kind == declaration.Kind {
+				// Overloads would need each of their signatures converted.
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// getConvertParametersFunctionName returns the name through which a function is called: the name
+// of its class for a constructor, or of the const variable it is assigned to for a function
+// expression.
+func getConvertParametersFunctionName(declaration *ast.Node) *ast.Node {
+	var name *ast.Node
+	switch declaration.Kind {
+	case ast.KindFunctionDeclaration:
+		name = declaration.Name()
+	case ast.KindMethodDeclaration:
+		if ast.IsClassLike(declaration.Parent) {
+			name = declaration.Name()
+		}
+	case ast.KindConstructor:
+		if ast.IsClassDeclaration(declaration.Parent) {
+			name = declaration.Parent.Name()
+		}
+	case ast.KindFunctionExpression, ast.KindArrowFunction:
+		if parent := declaration.Parent; ast.IsVariableDeclaration(parent) && parent.Initializer() == declaration &&
+			parent.Type() == nil && ast.IsVarConst(parent) {
+			name = parent.Name()
+		}
+	}
+	if name == nil || !ast.IsIdentifier(name) && !ast.IsPrivateIdentifier(name) {
+		return nil
+	}
+	return name
+}
+
+// getRefactorableParameters returns the parameters of a function other than a `this` parameter.
+func getRefactorableParameters(declaration *ast.Node) []*ast.Node {
+	parameters := declaration.Parameters()
+	if len(parameters) > 0 && ast.IsThisParameter(parameters[0]) {
+		return parameters[1:]
+	}
+	return parameters
+}
+
+// getConvertParametersCalls returns the calls of a function in the program, or false if one of its
+// references cannot be converted. Imports and exports of the function are kept as they are, as are
+// references to the class of a constructor in types.
+func getConvertParametersCalls(c *refactorContext, declaration *ast.Node) ([]*ast.Node, bool) {
+	name := getConvertParametersFunctionName(declaration)
+	isConstructor := ast.IsConstructorDeclaration(declaration)
+	parameters := getRefactorableParameters(declaration)
+	hasRestParameter := isRestParameterDeclaration(parameters[len(parameters)-1])
+	position := scanner.GetTokenPosOfNode(name, c.sourceFile, false /*includeJSDoc*/)
+	options := refOptions{use: referenceUseReferences}
+	symbolsAndEntries := c.ls.getReferencedSymbolsForNode(c.ctx, position, name, c.program, c.program.GetSourceFiles(), options, nil)
+	if c.ctx.Err() != nil {
+		return nil, false
+	}
+	var calls []*ast.Node
+	for _, symbolAndEntries := range symbolsAndEntries {
+		for _, entry := range symbolAndEntries.references {
+			node := entry.node
+			if node == nil {
+				return nil, false
+			}
+			if node == name || ast.IsImportOrExportSpecifier(node.Parent) || ast.IsImportClause(node.Parent) ||
+				ast.IsExportAssignment(node.Parent) || isConstructor && ast.IsPartOfTypeNode(node) {
+				continue
+			}
+			callee := node
+			if ast.IsPropertyAccessExpression(node.Parent) && node.Parent.Name() == node {
+				callee = node.Parent
+			}
+			call := callee.Parent
+			if isConstructor && !ast.IsNewExpression(call) || !isConstructor && !ast.IsCallExpression(call) ||
+				call.Expression() != callee || call.ArgumentList() == nil {
+				return nil, false
+			}
+			arguments := call.Arguments()
+			if slices.ContainsFunc(arguments, ast.IsSpreadElement) || len(arguments) > len(parameters) && !hasRestParameter {
+				return nil, false
+			}
+			if !slices.Contains(calls, call) {
+				calls = append(calls, call)
+			}
+		}
+	}
+	return calls, true
+}
+
+func isRestParameterDeclaration(parameter *ast.Node) bool {
+	return parameter.AsParameterDeclaration().DotDotDotToken != nil
+}
+
+func isOptionalParameterDeclaration(parameter *ast.Node) bool {
+	return parameter.QuestionToken() != nil || parameter.Initializer() != nil || isRestParameterDeclaration(parameter)
+}
+
+// convertParametersToDestructuredObject replaces the parameters of a function with an object
+// binding pattern:
+//
+//	(a: number, b = 1, ...c: string[]) -> ({ a, b = 1, c = [] }: { a: number; b?: number; c?: string[]; })
+//
+// The object parameter defaults to an empty object when every parameter is optional.
+func (ct *changeTracker) convertParametersToDestructuredObject(file *ast.SourceFile, c *checker.Checker, declaration *ast.Node, parameters []*ast.Node) {
+	var elements, members []string
+	allOptional := true
+	for _, parameter := range parameters {
+		name := parameter.Name().Text()
+		element := name
+		if initializer := parameter.Initializer(); initializer != nil {
+			element += " = " + scanner.GetTextOfNode(initializer)
+		} else if isRestParameterDeclaration(parameter) {
+			element += " = []"
+		}
+		elements = append(elements, element)
+
+		var typeText string
+		if typeNode := parameter.Type(); typeNode != nil {
+			typeText = scanner.GetTextOfNode(typeNode)
+		} else {
+			typeText = c.TypeToStringEx(c.GetTypeAtLocation(parameter), declaration, checker.TypeFormatFlagsNoTruncation)
+		}
+		member := name
+		if isOptionalParameterDeclaration(parameter) {
+			member += "?"
+		} else {
+			allOptional = false
+		}
+		members = append(members, member+": "+typeText+";")
+	}
+	text := "{ " + strings.Join(elements, ", ") + " }: { " + strings.Join(members, " ") + " }"
+	if allOptional {
+		text += " = {}"
+	}
+
+	first, last := parameters[0], parameters[len(parameters)-1]
+	start := scanner.GetTokenPosOfNode(first, file, false /*includeJSDoc*/)
+	if findChildOfKind(declaration, ast.KindCloseParenToken, file) == nil {
+		// An arrow function with a single unparenthesized parameter.
+		text = "(" + text + ")"
+	}
+	ct.replaceRangeWithText(file, *ct.ls.createLspRangeFromBounds(start, last.End(), file), text)
+}
+
+// convertArgumentsToObject replaces the arguments of a call with an object literal passing them as
+// the properties named after the parameters. The arguments of a rest parameter are passed as an
+// array.
+func (ct *changeTracker) convertArgumentsToObject(file *ast.SourceFile, call *ast.Node, parameters []*ast.Node) {
+	arguments := call.Arguments()
+	hasRestParameter := isRestParameterDeclaration(parameters[len(parameters)-1])
+	var properties []string
+	for i, argument := range arguments {
+		if hasRestParameter && i == len(parameters)-1 {
+			break
+		}
+		name := parameters[i].Name().Text()
+		if ast.IsIdentifier(argument) && argument.Text() == name {
+			properties = append(properties, name)
+		} else {
+			properties = append(properties, name+": "+scanner.GetTextOfNode(argument))
+		}
+	}
+	if hasRestParameter && len(arguments) >= len(parameters) {
+		restArguments := core.Map(arguments[len(parameters)-1:], scanner.GetTextOfNode)
+		properties = append(properties, parameters[len(parameters)-1].Name().Text()+": ["+strings.Join(restArguments, ", ")+"]")
+	}
+	text := "{}"
+	if len(properties) > 0 {
+		text = "{ " + strings.Join(properties, ", ") + " }"
+	}
+	if len(arguments) == 0 {
+		ct.insertText(file, ct.ls.createLspPosition(call.ArgumentList().Pos(), file), text)
+		return
+	}
+	start := scanner.GetTokenPosOfNode(arguments[0], file, false /*includeJSDoc*/)
+	ct.replaceRangeWithText(file, *ct.ls.createLspRangeFromBounds(start, arguments[len(arguments)-1].End(), file), text)
+}
@@ -0,0 +1,280 @@
+package ls
+
+import (
+	"strings"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/checker"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/scanner"
+)
+
+const (
+	refactorNameConvertStringOrTemplateLiteral = "Convert string or template literal"
+	refactorActionConvertToTemplateString      = "Convert to template string"
+	refactorActionConvertToStringConcatenation = "Convert to string concatenation"
+)
+
+var convertStringOrTemplateLiteralRefactorProvider = &refactorProvider{
+	name:                refactorNameConvertStringOrTemplateLiteral,
+	description:         "Convert string or template literal",
+	getAvailableActions: getConvertStringOrTemplateLiteralActions,
+	getEditsForAction:   getConvertStringOrTemplateLiteralEdits,
+}
+
+func getConvertStringOrTemplateLiteralActions(c *refactorContext) []*RefactorAction {
+	var actions []*RefactorAction
+	if getConvertToTemplateStringNode(c) != nil {
+		actions = append(actions, &RefactorAction{
+			Name:        refactorActionConvertToTemplateString,
+			Description: "Convert to template string",
+			Kind:        "refactor.rewrite.string",
+		})
+	}
+	if getConvertToStringConcatenationNode(c) != nil {
+		actions = append(actions, &RefactorAction{
+			Name:        refactorActionConvertToStringConcatenation,
+			Description: "Convert to string concatenation",
+			Kind:        "refactor.rewrite.string",
+		})
+	}
+	return actions
+}
+
+func getConvertStringOrTemplateLiteralEdits(c *refactorContext, actionName string) *lsproto.WorkspaceEdit {
+	var node *ast.Node
+	var text string
+	switch actionName {
+	case refactorActionConvertToTemplateString:
+		if node = getConvertToTemplateStringNode(c); node != nil {
+			text = getTemplateStringOfConcatenation(c.checker, node)
+		}
+	case refactorActionConvertToStringConcatenation:
+		if node = getConvertToStringConcatenationNode(c); node != nil {
+			quote := byte('"')
+			if getQuotePreference(c.sourceFile, c.ls.getUserPreferences()) == quotePreferenceSingle {
+				quote = '\''
+			}
+			text = getConcatenationOfTemplate(c.sourceFile, c.checker, node, quote)
+		}
+	}
+	if node == nil {
+		return nil
+	}
+	ct := c.ls.newChangeTracker(c.ctx)
+	start := scanner.GetTokenPosOfNode(node, c.sourceFile, false /*includeJSDoc*/)
+	ct.replaceRangeWithText(c.sourceFile, *ct.ls.createLspRangeFromBounds(start, node.End(), c.sourceFile), text)
+	return ct.getWorkspaceEdit()
+}
+
+// isStringConcatenation returns whether node is a `+` expression that the checker types as a
+// string, as opposed to a numeric addition.
+func isStringConcatenation(c *checker.Checker, node *ast.Node) bool {
+	return ast.IsBinaryExpression(node) && node.AsBinaryExpression().OperatorToken.Kind == ast.KindPlusToken &&
+		c.GetTypeAtLocation(node).Flags()&checker.TypeFlagsStringLike != 0
+}
+
+// getConvertToTemplateStringNode returns the outermost string concatenation containing the span,
+// looking through parentheses, or the string literal at the span if it is not concatenated.
+func getConvertToTemplateStringNode(c *refactorContext) *ast.Node {
+	token := c.startToken()
+	var concatenation *ast.Node
+	for node := token; node != nil; node = node.Parent {
+		if isStringConcatenation(c.checker, node) {
+			concatenation = node
+			continue
+		}
+		if concatenation != nil && !ast.IsParenthesizedExpression(node) || node != token && !ast.IsExpressionNode(node) {
+			break
+		}
+	}
+	if concatenation == nil && ast.IsStringLiteral(token) && ast.IsExpressionNode(token) {
+		concatenation = token
+	}
+	if concatenation == nil || concatenation.End() < c.span.End() {
+		return nil
+	}
+	return concatenation
+}
+
+// getConvertToStringConcatenationNode returns the innermost untagged template literal containing
+// the span.
+func getConvertToStringConcatenationNode(c *refactorContext) *ast.Node {
+	return c.findContainingNode(func(node *ast.Node) bool {
+		switch node.Kind {
+		case ast.KindTemplateExpression:
+			return true
+		case ast.KindNoSubstitutionTemplateLiteral:
+			return !ast.IsTaggedTemplateExpression(node.Parent) && ast.IsExpressionNode(node)
+		}
+		return false
+	})
+}
+
+// getTemplateStringOfConcatenation returns a template literal with the value of a string
+// concatenation:
+//
+//	"Hello " + name + "!" -> `Hello ${name}!`
+//
+// The literal operands are inlined into the template, the other operands are substituted. An operand
+// that is not a string concatenation itself, such as the numeric addition in `1 + 2 + "px"`, is
+// substituted as a whole.
+func getTemplateStringOfConcatenation(c *checker.Checker, node *ast.Node) string {
+	var body string
+	var visit func(node *ast.Node)
+	visit = func(node *ast.Node) {
+		switch {
+		case isStringConcatenation(c, node):
+			visit(node.AsBinaryExpression().Left)
+			visit(node.AsBinaryExpression().Right)
+		case ast.IsParenthesizedExpression(node) && isStringConcatenation(c, ast.SkipParentheses(node)):
+			visit(ast.SkipParentheses(node))
+		case ast.IsStringLiteral(node):
+			body = appendTemplateText(body, getTemplateTextOfStringLiteral(scanner.GetTextOfNode(node)))
+		case node.Kind == ast.KindNoSubstitutionTemplateLiteral || ast.IsTemplateExpression(node):
+			text := scanner.GetTextOfNode(node)
+			body = appendTemplateText(body, text[1:len(text)-1])
+		default:
+			body += "${" + scanner.GetTextOfNode(ast.SkipParentheses(node)) + "}"
+		}
+	}
+	visit(node)
+	return "`" + body + "`"
+}
+
+// appendTemplateText appends text to the body of a template literal, escaping a `$` ending the body
+// when text starts with `{` so that they do not form a substitution.
+func appendTemplateText(body string, text string) string {
+	if strings.HasPrefix(text, "{") && strings.HasSuffix(body, "$") {
+		rest := body[:len(body)-1]
+		if (len(rest)-len(strings.TrimRight(rest, `\`)))%2 == 0 {
+			body = rest + `\$`
+		}
+	}
+	return body + text
+}
+
+// getTemplateTextOfStringLiteral returns the text of a string literal, including its quotes, as the
+// text of a template literal. Backticks and `${` are escaped, and escaped quotes no longer need to be.
+func getTemplateTextOfStringLiteral(literal string) string {
+	raw := literal[1 : len(literal)-1]
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		switch ch := raw[i]; {
+		case ch == '\\' && i+1 < len(raw):
+			i++
+			if raw[i] != '\'' && raw[i] != '"' {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(raw[i])
+		case ch == '`', ch == '$' && i+1 < len(raw) && raw[i+1] == '{':
+			b.WriteByte('\\')
+			b.WriteByte(ch)
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return b.String()
+}
+
+// getConcatenationOfTemplate returns a string concatenation with the value of a template literal:
+//
+//	`Hello ${name}!` -> "Hello " + name + "!"
+//
+// An empty head is dropped when the first substitution is a string, so that the concatenation does
+// not start with "". Substitutions binding less tightly than `+` are parenthesized.
+func getConcatenationOfTemplate(file *ast.SourceFile, c *checker.Checker, node *ast.Node, quote byte) string {
+	if node.Kind == ast.KindNoSubstitutionTemplateLiteral {
+		return getStringLiteralOfTemplateText(getRawTemplateText(file, node), quote)
+	}
+	template := node.AsTemplateExpression()
+	spans := template.TemplateSpans.Nodes
+	var parts []string
+	if head := getRawTemplateText(file, template.Head); head != "" ||
+		c.GetTypeAtLocation(spans[0].AsTemplateSpan().Expression).Flags()&checker.TypeFlagsStringLike == 0 {
+		parts = append(parts, getStringLiteralOfTemplateText(head, quote))
+	}
+	for _, span := range spans {
+		expression := span.AsTemplateSpan().Expression
+		text := scanner.GetTextOfNode(expression)
+		if ast.GetExpressionPrecedence(expression) <= ast.OperatorPrecedenceAdditive {
+			text = "(" + text + ")"
+		}
+		parts = append(parts, text)
+		if literal := getRawTemplateText(file, span.AsTemplateSpan().Literal); literal != "" {
+			parts = append(parts, getStringLiteralOfTemplateText(literal, quote))
+		}
+	}
+	text := strings.Join(parts, " + ")
+	if len(parts) > 1 && needsParenthesesAsConcatenation(node) {
+		text = "(" + text + ")"
+	}
+	return text
+}
+
+// getRawTemplateText returns the source text of a template literal part, without its backticks and
+// the delimiters of its substitutions.
+func getRawTemplateText(file *ast.SourceFile, node *ast.Node) string {
+	text := file.Text()[scanner.GetTokenPosOfNode(node, file, false /*includeJSDoc*/):node.End()]
+	switch node.Kind {
+	case ast.KindTemplateHead, ast.KindTemplateMiddle:
+		text = strings.TrimSuffix(text[1:], "${")
+	default:
+		text = strings.TrimSuffix(text[1:], "`")
+	}
+	return text
+}
+
+// getStringLiteralOfTemplateText returns a string literal with the value of the raw text of a
+// template literal part. Escaped backticks and dollar signs are unescaped, quotes are escaped, and
+// line breaks become escape sequences.
+func getStringLiteralOfTemplateText(raw string, quote byte) string {
+	var b strings.Builder
+	b.WriteByte(quote)
+	for i := 0; i < len(raw); i++ {
+		switch ch := raw[i]; {
+		case ch == '\\' && i+1 < len(raw):
+			i++
+			if raw[i] != '`' && raw[i] != '$' {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(raw[i])
+			if raw[i] == '\r' && i+1 < len(raw) && raw[i+1] == '\n' {
+				// A line continuation, which string literals allow as well.
+				i++
+				b.WriteByte('\n')
+			}
+		case ch == quote:
+			b.WriteByte('\\')
+			b.WriteByte(ch)
+		case ch == '\r' || ch == '\n':
+			// Template literals normalize their line breaks to \n.
+			if ch == '\r' && i+1 < len(raw) && raw[i+1] == '\n' {
+				i++
+			}
+			b.WriteString(`\n`)
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	b.WriteByte(quote)
+	return b.String()
+}
+
+// needsParenthesesAsConcatenation returns whether an expression replaced by a string concatenation
+// would bind to its parent more tightly than the `+` operators of the concatenation.
+func needsParenthesesAsConcatenation(node *ast.Node) bool {
+	parent := node.Parent
+	switch parent.Kind {
+	case ast.KindPropertyAccessExpression, ast.KindElementAccessExpression, ast.KindCallExpression, ast.KindNewExpression,
+		ast.KindTaggedTemplateExpression:
+		return parent.Expression() == node
+	case ast.KindPrefixUnaryExpression, ast.KindPostfixUnaryExpression, ast.KindTypeOfExpression, ast.KindVoidExpression,
+		ast.KindDeleteExpression, ast.KindAwaitExpression, ast.KindNonNullExpression, ast.KindAsExpression,
+		ast.KindSatisfiesExpression, ast.KindTypeAssertionExpression:
+		return true
+	case ast.KindBinaryExpression:
+		return ast.GetExpressionPrecedence(parent) >= ast.OperatorPrecedenceAdditive
+	}
+	return false
+}
@@ -0,0 +1,278 @@
+package ls
+
+import (
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+)
+
+const (
+	refactorNameConvertTypeOnlyImport = "Convert type-only import"
+
+	refactorActionConvertToTypeOnlyImport = "Convert to type-only import"
+	refactorActionConvertToRegularImport  = "Convert to regular import"
+)
+
+var convertTypeOnlyImportRefactorProvider = &refactorProvider{
+	name:                refactorNameConvertTypeOnlyImport,
+	description:         "Convert between type-only and regular imports",
+	getAvailableActions: getConvertTypeOnlyImportActions,
+	getEditsForAction:   getConvertTypeOnlyImportEdits,
+}
+
+// typeOnlyImportBinding is a name declared by an import clause: its default import, its namespace
+// import or one of its import specifiers.
+type typeOnlyImportBinding struct {
+	// The import clause for the default import, else the namespace import or import specifier.
+	declaration *ast.Node
+	name        *ast.Node
+	// Whether the binding is imported type-only, by the import clause or by a type modifier of its own.
+	isTypeOnly bool
+	isUsed     bool
+	// Whether the binding is used where a type-only import is an error, such as in an expression.
+	hasValueUsage bool
+}
+
+type convertTypeOnlyImportInfo struct {
+	importDeclaration *ast.Node
+	importClause      *ast.Node
+	defaultImport     *typeOnlyImportBinding
+	namespaceImport   *typeOnlyImportBinding
+	namedImports      []*typeOnlyImportBinding
+}
+
+func (info *convertTypeOnlyImportInfo) bindings() []*typeOnlyImportBinding {
+	var bindings []*typeOnlyImportBinding
+	if info.defaultImport != nil {
+		bindings = append(bindings, info.defaultImport)
+	}
+	if info.namespaceImport != nil {
+		bindings = append(bindings, info.namespaceImport)
+	}
+	return append(bindings, info.namedImports...)
+}
+
+// canConvertToTypeOnly returns whether some binding imported as a value is used, but only as a type.
+// Imports with attributes are only converted in place, since the attributes are not copied to the
+// imports they would be split into.
+func (info *convertTypeOnlyImportInfo) canConvertToTypeOnly() bool {
+	return core.Some(info.bindings(), func(binding *typeOnlyImportBinding) bool {
+		return !binding.isTypeOnly && binding.isUsed && !binding.hasValueUsage
+	}) && (info.importDeclaration.AsImportDeclaration().Attributes == nil || info.canConvertToTypeOnlyInPlace())
+}
+
+// canConvertToTypeOnlyInPlace returns whether every binding is only used as a type and can be
+// imported by a single type-only import, which cannot have both a default import and named bindings.
+func (info *convertTypeOnlyImportInfo) canConvertToTypeOnlyInPlace() bool {
+	return !core.Some(info.bindings(), func(binding *typeOnlyImportBinding) bool {
+		return binding.hasValueUsage
+	}) && (info.defaultImport == nil || info.importClause.AsImportClause().NamedBindings == nil)
+}
+
+// canConvertToRegular returns whether some binding imported type-only is used as a value.
+func (info *convertTypeOnlyImportInfo) canConvertToRegular() bool {
+	return core.Some(info.bindings(), func(binding *typeOnlyImportBinding) bool {
+		return binding.isTypeOnly && binding.hasValueUsage
+	})
+}
+
+func getConvertTypeOnlyImportActions(c *refactorContext) []*RefactorAction {
+	info := getConvertTypeOnlyImportInfo(c)
+	if info == nil {
+		return nil
+	}
+	var actions []*RefactorAction
+	if info.canConvertToTypeOnly() {
+		actions = append(actions, &RefactorAction{
+			Name:        refactorActionConvertToTypeOnlyImport,
+			Description: refactorActionConvertToTypeOnlyImport,
+			Kind:        "refactor.rewrite.import.typeOnly",
+		})
+	}
+	if info.canConvertToRegular() {
+		actions = append(actions, &RefactorAction{
+			Name:        refactorActionConvertToRegularImport,
+			Description: refactorActionConvertToRegularImport,
+			Kind:        "refactor.rewrite.import.regular",
+		})
+	}
+	return actions
+}
+
+func getConvertTypeOnlyImportEdits(c *refactorContext, actionName string) *lsproto.WorkspaceEdit {
+	info := getConvertTypeOnlyImportInfo(c)
+	if info == nil {
+		return nil
+	}
+	ct := c.ls.newChangeTracker(c.ctx)
+	switch {
+	case actionName == refactorActionConvertToTypeOnlyImport && info.canConvertToTypeOnly():
+		ct.convertToTypeOnlyImport(c.sourceFile, info)
+	case actionName == refactorActionConvertToRegularImport && info.canConvertToRegular():
+		ct.convertToRegularImport(c.sourceFile, info)
+	default:
+		return nil
+	}
+	return ct.getWorkspaceEdit()
+}
+
+// getConvertTypeOnlyImportInfo returns the bindings of the import declaration at the span, with
+// whether each of them is used as a value in the file.
+func getConvertTypeOnlyImportInfo(c *refactorContext) *convertTypeOnlyImportInfo {
+	statement := c.topLevelStatement()
+	if statement == nil || !ast.IsImportDeclaration(statement) || ast.IsSourceFileJS(c.sourceFile) {
+		return nil
+	}
+	importClause := statement.AsImportDeclaration().ImportClause
+	if importClause == nil {
+		return nil
+	}
+	clauseIsTypeOnly := importClause.IsTypeOnly()
+	info := &convertTypeOnlyImportInfo{importDeclaration: statement, importClause: importClause}
+	bindingsBySymbol := make(map[*ast.Symbol]*typeOnlyImportBinding)
+	addBinding := func(declaration *ast.Node, name *ast.Node, isTypeOnly bool) *typeOnlyImportBinding {
+		binding := &typeOnlyImportBinding{declaration: declaration, name: name, isTypeOnly: isTypeOnly}
+		if symbol := declaration.Symbol(); symbol != nil {
+			bindingsBySymbol[symbol] = binding
+		}
+		return binding
+	}
+	if name := importClause.Name(); name != nil {
+		info.defaultImport = addBinding(importClause, name, clauseIsTypeOnly)
+	}
+	if namedBindings := importClause.AsImportClause().NamedBindings; namedBindings != nil {
+		if ast.IsNamespaceImport(namedBindings) {
+			info.namespaceImport = addBinding(namedBindings, namedBindings.Name(), clauseIsTypeOnly)
+		} else {
+			for _, specifier := range namedBindings.Elements() {
+				info.namedImports = append(info.namedImports, addBinding(specifier, specifier.Name(), clauseIsTypeOnly || specifier.IsTypeOnly()))
+			}
+		}
+	}
+	if len(bindingsBySymbol) == 0 {
+		return nil
+	}
+
+	var visit func(node *ast.Node) bool
+	visit = func(node *ast.Node) bool {
+		if node == statement {
+			return false
+		}
+		if ast.IsIdentifier(node) {
+			var symbol *ast.Symbol
+			switch {
+			case ast.IsShorthandPropertyAssignment(node.Parent) && node.Parent.Name() == node:
+				symbol = c.checker.GetShorthandAssignmentValueSymbol(node.Parent)
+			case ast.IsExportSpecifier(node.Parent) && node.Parent.PropertyNameOrName() == node:
+				symbol = c.checker.GetExportSpecifierLocalTargetSymbol(node.Parent)
+			default:
+				symbol = c.checker.GetSymbolAtLocation(node)
+			}
+			if binding := bindingsBySymbol[symbol]; binding != nil {
+				binding.isUsed = true
+				binding.hasValueUsage = binding.hasValueUsage || isTypeOnlyImportValueUsage(node)
+			}
+			return false
+		}
+		return node.ForEachChild(visit)
+	}
+	c.sourceFile.AsNode().ForEachChild(visit)
+	return info
+}
+
+// isTypeOnlyImportValueUsage returns whether a reference to an import must not be type-only. Exports
+// of the import other than type-only ones are counted, since they export its value where there is one.
+func isTypeOnlyImportValueUsage(reference *ast.Node) bool {
+	if ast.IsExportSpecifier(reference.Parent) {
+		return !reference.Parent.IsTypeOnly() && !reference.Parent.Parent.Parent.IsTypeOnly()
+	}
+	return !ast.IsValidTypeOnlyAliasUseSite(reference)
+}
+
+// convertToTypeOnlyImport makes the bindings only used as types type-only. The import keeps its
+// text if all of them can be imported by a single type-only import, and is otherwise split into
+// type-only imports followed by an import of the bindings used as values.
+func (ct *changeTracker) convertToTypeOnlyImport(sourceFile *ast.SourceFile, info *convertTypeOnlyImportInfo) {
+	isType := func(binding *typeOnlyImportBinding) bool {
+		return binding != nil && !binding.hasValueUsage
+	}
+	if info.canConvertToTypeOnlyInPlace() {
+		ct.insertText(sourceFile, ct.ls.createLspPosition(astnav.GetStartOfNode(info.importClause, sourceFile, false), sourceFile), "type ")
+		for _, binding := range info.namedImports {
+			if binding.declaration.IsTypeOnly() {
+				ct.deleteTypeKeyword(sourceFile, binding.declaration, binding.declaration.PropertyNameOrName())
+			}
+		}
+		return
+	}
+
+	moduleSpecifier := info.importDeclaration.AsImportDeclaration().ModuleSpecifier
+	newModuleSpecifier := func() *ast.Node {
+		return ct.NodeFactory.NewStringLiteral(moduleSpecifier.Text())
+	}
+	newSpecifiers := func(bindings []*typeOnlyImportBinding) []*ast.Node {
+		specifiers := make([]*ast.Node, 0, len(bindings))
+		for _, binding := range bindings {
+			specifiers = append(specifiers, ct.newImportSpecifier(binding.declaration.PropertyName(), binding.name))
+		}
+		return specifiers
+	}
+	newNamespaceImport := func(isTypeOnly bool, defaultImport *ast.Node) *ast.Node {
+		return ct.NodeFactory.NewImportDeclaration(
+			/*modifiers*/ nil,
+			ct.NodeFactory.NewImportClause(isTypeOnly, defaultImport, ct.NodeFactory.NewNamespaceImport(ct.NodeFactory.NewIdentifier(info.namespaceImport.name.Text()))),
+			newModuleSpecifier(),
+			nil, /*attributes*/
+		)
+	}
+
+	var imports []*ast.Node
+	if isType(info.defaultImport) {
+		imports = append(imports, ct.makeImport(ct.NodeFactory.NewIdentifier(info.defaultImport.name.Text()), nil, newModuleSpecifier(), true /*isTypeOnly*/))
+	}
+	if isType(info.namespaceImport) {
+		imports = append(imports, newNamespaceImport(true /*isTypeOnly*/, nil))
+	}
+	if typeNamedImports := core.Filter(info.namedImports, isType); len(typeNamedImports) > 0 {
+		imports = append(imports, ct.makeImport(nil, newSpecifiers(typeNamedImports), newModuleSpecifier(), true /*isTypeOnly*/))
+	}
+
+	valueNamedImports := core.Filter(info.namedImports, func(binding *typeOnlyImportBinding) bool { return !isType(binding) })
+	var defaultImport *ast.Node
+	if info.defaultImport != nil && !isType(info.defaultImport) {
+		defaultImport = ct.NodeFactory.NewIdentifier(info.defaultImport.name.Text())
+	}
+	switch {
+	case info.namespaceImport != nil && !isType(info.namespaceImport):
+		imports = append(imports, newNamespaceImport(false /*isTypeOnly*/, defaultImport))
+	case defaultImport != nil || len(valueNamedImports) > 0:
+		imports = append(imports, ct.makeImport(defaultImport, newSpecifiers(valueNamedImports), newModuleSpecifier(), false /*isTypeOnly*/))
+	}
+	ct.replaceNodeWithNodes(sourceFile, info.importDeclaration, imports)
+}
+
+// convertToRegularImport removes the type keywords making the bindings used as values type-only.
+// When the type keyword of the import clause is removed, the named imports only used as types stay
+// type-only with a type modifier of their own.
+func (ct *changeTracker) convertToRegularImport(sourceFile *ast.SourceFile, info *convertTypeOnlyImportInfo) {
+	importClause := info.importClause
+	if importClause.IsTypeOnly() {
+		next := importClause.Name()
+		if next == nil {
+			next = importClause.AsImportClause().NamedBindings
+		}
+		ct.deleteTypeKeyword(sourceFile, importClause, next)
+		for _, binding := range info.namedImports {
+			if !binding.hasValueUsage {
+				ct.insertText(sourceFile, ct.ls.createLspPosition(astnav.GetStartOfNode(binding.declaration, sourceFile, false), sourceFile), "type ")
+			}
+		}
+		return
+	}
+	for _, binding := range info.namedImports {
+		if binding.isTypeOnly && binding.hasValueUsage {
+			ct.deleteTypeKeyword(sourceFile, binding.declaration, binding.declaration.PropertyNameOrName())
+		}
+	}
+}
@@ -0,0 +1,543 @@
+package ls
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+	"github.com/microsoft/typescript-go/internal/checker"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/nodebuilder"
+	"github.com/microsoft/typescript-go/internal/printer"
+	"github.com/microsoft/typescript-go/internal/scanner"
+	"github.com/microsoft/typescript-go/internal/stringutil"
+)
+
+const (
+	refactorNameExtractSymbol = "Extract Symbol"
+
+	refactorActionExtractFunction = "Extract to function"
+	refactorActionExtractConstant = "Extract to constant"
+)
+
+var extractSymbolRefactorProvider = &refactorProvider{
+	name:                refactorNameExtractSymbol,
+	description:         "Extract function or constant",
+	getAvailableActions: getExtractSymbolActions,
+	getEditsForAction:   getExtractSymbolEdits,
+}
+
+func getExtractSymbolActions(c *refactorContext) []*RefactorAction {
+	r := getExtractRange(c)
+	if r == nil {
+		return nil
+	}
+	_, reason := analyzeExtractFunction(c, r)
+	actions := []*RefactorAction{{
+		Name:                refactorActionExtractFunction,
+		Description:         "Extract to function in module scope",
+		Kind:                "refactor.extract.function",
+		NotApplicableReason: reason,
+	}}
+	if r.expression != nil {
+		_, reason := analyzeExtractConstant(c, r)
+		actions = append(actions, &RefactorAction{
+			Name:                refactorActionExtractConstant,
+			Description:         "Extract to constant in enclosing scope",
+			Kind:                "refactor.extract.constant",
+			NotApplicableReason: reason,
+		})
+	}
+	return actions
+}
+
+func getExtractSymbolEdits(c *refactorContext, actionName string) *lsproto.WorkspaceEdit {
+	r := getExtractRange(c)
+	if r == nil {
+		return nil
+	}
+	ct := c.ls.newChangeTracker(c.ctx)
+	switch actionName {
+	case refactorActionExtractFunction:
+		info, reason := analyzeExtractFunction(c, r)
+		if reason != "" {
+			return nil
+		}
+		ct.extractFunction(c, r, info)
+	case refactorActionExtractConstant:
+		statement, reason := analyzeExtractConstant(c, r)
+		if r.expression == nil || reason != "" {
+			return nil
+		}
+		ct.extractConstant(c.sourceFile, r, statement)
+	default:
+		return nil
+	}
+	return ct.getWorkspaceEdit()
+}
+
+// extractRange is the selection of an extract refactor: a single expression, or consecutive
+// statements of the same block.
+type extractRange struct {
+	core.TextRange
+	// The selected expression, or nil if statements are selected.
+	expression *ast.Node
+	statements []*ast.Node
+}
+
+// containsNode reports whether node is part of the selection.
+func (r *extractRange) containsNode(node *ast.Node) bool {
+	return node != nil && r.Pos() < node.End() && node.End() <= r.End()
+}
+
+// nodes returns the selected expression or statements.
+func (r *extractRange) nodes() []*ast.Node {
+	if r.expression != nil {
+		return []*ast.Node{r.expression}
+	}
+	return r.statements
+}
+
+// getExtractRange returns the expression or statements spanned by the refactor span, ignoring
+// surrounding whitespace and comments, or nil if the span does not match them exactly.
+func getExtractRange(c *refactorContext) *extractRange {
+	file := c.sourceFile
+	text := file.Text()
+	start := scanner.SkipTrivia(text, c.span.Pos())
+	end := c.span.End()
+	for end > start && stringutil.IsWhiteSpaceLike(rune(text[end-1])) {
+		end--
+	}
+	if start >= end {
+		return nil
+	}
+	for node := astnav.GetTokenAtPosition(file, start); node != nil && !ast.IsSourceFile(node); node = node.Parent {
+		if astnav.GetStartOfNode(node, file, false /*includeJSDoc*/) != start || node.End() > end {
+			return nil
+		}
+		if node.End() == end && isExtractableExpression(node) {
+			return &extractRange{TextRange: core.NewTextRange(start, end), expression: node}
+		}
+		if statements := getStatementListOfNode(node.Parent); statements != nil {
+			first := slices.Index(statements, node)
+			if first < 0 {
+				// The span starts at a token of the list's parent, such as the brace of a block.
+				return nil
+			}
+			for last := first; last < len(statements) && statements[last].End() <= end; last++ {
+				if statements[last].End() == end {
+					return &extractRange{TextRange: core.NewTextRange(start, end), statements: statements[first : last+1]}
+				}
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// getStatementListOfNode returns the statements of a node holding a list of statements, or nil for
+// other nodes.
+func getStatementListOfNode(node *ast.Node) []*ast.Node {
+	switch node.Kind {
+	case ast.KindSourceFile, ast.KindBlock, ast.KindModuleBlock:
+		return node.Statements()
+	case ast.KindCaseClause, ast.KindDefaultClause:
+		return node.AsCaseOrDefaultClause().Statements.Nodes
+	}
+	return nil
+}
+
+func isExtractableExpression(node *ast.Node) bool {
+	if !ast.IsExpressionNode(node) || ast.IsAssignmentTarget(node) || ast.IsPartOfTypeNode(node) {
+		return false
+	}
+	switch node.Kind {
+	case ast.KindSuperKeyword, ast.KindOmittedExpression, ast.KindSpreadElement:
+		return false
+	}
+	parent := node.Parent
+	switch {
+	case ast.IsPropertyAccessExpression(parent) && parent.Name() == node, ast.IsMetaProperty(parent):
+		return false
+	case (ast.IsJsxOpeningLikeElement(parent) || ast.IsJsxClosingElement(parent)) && parent.TagName() == node:
+		return false
+	case ast.IsCallExpression(parent) && parent.Expression() == node && ast.IsAccessExpression(node):
+		// Calling an extracted method would lose its `this`.
+		return false
+	}
+	return true
+}
+
+// extractFunctionInfo describes the function a selection is extracted to.
+type extractFunctionInfo struct {
+	// The locals declared outside the selection and referenced in it, in order of first reference,
+	// which are passed to the function.
+	parameters []extractParameter
+	// The function is async when the selection awaits.
+	isAsync bool
+	// The statement of the source file after which the function is declared.
+	insertAfter *ast.Node
+}
+
+type extractParameter struct {
+	symbol *ast.Symbol
+	// The type of the parameter, or "" in JavaScript files.
+	typeText string
+}
+
+// analyzeExtractFunction determines the parameters of a function declared at the top level of the
+// file with the selection as its body, or returns the reason the selection cannot be extracted.
+// Locals of the enclosing scopes become parameters, so code that assigns them, uses `this`,
+// `arguments` or local types, or jumps out of the selection, is not extracted.
+func analyzeExtractFunction(c *refactorContext, r *extractRange) (*extractFunctionInfo, string) {
+	file := c.sourceFile
+	info := &extractFunctionInfo{}
+	// Locals are the declarations in the top-level statement containing the selection that are
+	// not at the top level of the file.
+	var scope *ast.Node
+	if r.statements != nil && ast.IsSourceFile(r.statements[0].Parent) {
+		info.insertAfter = r.statements[len(r.statements)-1]
+	} else {
+		scope = ast.FindAncestor(r.nodes()[0], func(node *ast.Node) bool {
+			return node.Parent != nil && ast.IsSourceFile(node.Parent)
+		})
+		info.insertAfter = scope
+	}
+	isLocal := func(declaration *ast.Node) bool {
+		return scope != nil && ast.GetSourceFileOfNode(declaration) == file && containsNodeRange(scope, declaration) &&
+			(ast.IsTypeParameterDeclaration(declaration) || ast.GetEnclosingBlockScopeContainer(declaration) != file.AsNode())
+	}
+
+	var reason string
+	fail := func(message string, args ...any) {
+		if reason == "" {
+			reason = fmt.Sprintf(message, args...)
+		}
+	}
+	var references []*ast.Node
+	var visit func(node *ast.Node) bool
+	visit = func(node *ast.Node) bool {
+		switch node.Kind {
+		case ast.KindIdentifier:
+			symbol := getExtractReferencedSymbol(c.checker, node)
+			if symbol == nil {
+				break
+			}
+			if c.checker.IsArgumentsSymbol(symbol) && !r.containsNode(getContainingFunctionOfNode(node)) {
+				fail("Cannot extract code that uses 'arguments' to a function.")
+			}
+			declaration := core.Find(symbol.Declarations, isLocal)
+			if declaration == nil || r.containsNode(declaration) {
+				break
+			}
+			switch {
+			case symbol.Flags&ast.SymbolFlagsValue == 0 || ast.IsPartOfTypeNode(node) && ast.FindAncestor(node, ast.IsTypeQueryNode) == nil:
+				fail("Cannot extract code that references the local type '%s' to a function.", symbol.Name)
+			case ast.IsAssignmentTarget(node):
+				fail("Cannot extract code that assigns to '%s', which is declared outside the selection.", symbol.Name)
+			case !slices.ContainsFunc(info.parameters, func(p extractParameter) bool { return p.symbol == symbol }):
+				info.parameters = append(info.parameters, extractParameter{symbol: symbol})
+				references = append(references, node)
+			}
+		case ast.KindThisKeyword, ast.KindSuperKeyword:
+			if !r.containsNode(ast.GetThisContainer(node, false /*includeArrowFunctions*/, false /*includeClassComputedPropertyName*/)) {
+				fail("Cannot extract code that uses '%s' to a function.", scanner.TokenToString(node.Kind))
+			}
+		case ast.KindReturnStatement:
+			if !r.containsNode(getContainingFunctionOfNode(node)) {
+				fail("Cannot extract a range containing a return statement.")
+			}
+		case ast.KindBreakStatement, ast.KindContinueStatement:
+			if !r.containsNode(getJumpTarget(node)) {
+				fail("Cannot extract a range containing a break or continue statement that jumps out of it.")
+			}
+		case ast.KindYieldExpression:
+			if !r.containsNode(getContainingFunctionOfNode(node)) {
+				fail("Cannot extract a range containing 'yield'.")
+			}
+		case ast.KindAwaitExpression:
+			if !r.containsNode(getContainingFunctionOfNode(node)) {
+				info.isAsync = true
+			}
+		case ast.KindForOfStatement:
+			if node.AsForInOrOfStatement().AwaitModifier != nil && !r.containsNode(getContainingFunctionOfNode(node)) {
+				info.isAsync = true
+			}
+		}
+		return node.ForEachChild(visit)
+	}
+	for _, node := range r.nodes() {
+		visit(node)
+	}
+	if reason != "" {
+		return nil, reason
+	}
+
+	// Declarations of the selected statements must not be used outside of them.
+	if r.statements != nil {
+		container := core.OrElse(getContainingFunctionOfNode(r.statements[0]), file.AsNode())
+		var visitOutside func(node *ast.Node) bool
+		visitOutside = func(node *ast.Node) bool {
+			if r.containsNode(node) {
+				return false
+			}
+			if ast.IsIdentifier(node) {
+				if symbol := getExtractReferencedSymbol(c.checker, node); symbol != nil && slices.ContainsFunc(symbol.Declarations, r.containsNode) {
+					fail("Cannot extract the declaration of '%s', which is used outside the selection.", symbol.Name)
+					return true
+				}
+			}
+			return node.ForEachChild(visitOutside)
+		}
+		container.ForEachChild(visitOutside)
+		if reason != "" {
+			return nil, reason
+		}
+	}
+
+	if !ast.IsInJSFile(file.AsNode()) {
+		localTypeNames := getLocalTypeNames(scope, isLocal)
+		for i := range info.parameters {
+			parameter := &info.parameters[i]
+			t := c.checker.GetBaseTypeOfLiteralType(c.checker.GetTypeOfSymbolAtLocation(parameter.symbol, references[i]))
+			if referencesName(c.checker.TypeToTypeNode(t, file.AsNode(), nodebuilder.FlagsNone), localTypeNames) {
+				return nil, fmt.Sprintf("Cannot extract code that uses '%s' to a function, because its type is declared in the enclosing scope.", parameter.symbol.Name)
+			}
+			parameter.typeText = c.checker.TypeToStringEx(t, file.AsNode(), checker.TypeFormatFlagsNoTruncation)
+		}
+	}
+	return info, ""
+}
+
+// analyzeExtractConstant returns the statement the constant of the selected expression is declared
+// before, or the reason the expression cannot be moved there: it references a local of that
+// statement, or it uses `this`, `arguments`, `await` or `yield` of a function declared in it.
+func analyzeExtractConstant(c *refactorContext, r *extractRange) (*ast.Node, string) {
+	statement := ast.FindAncestor(r.expression, func(node *ast.Node) bool {
+		return node.Parent != nil && getStatementListOfNode(node.Parent) != nil
+	})
+	if statement == nil {
+		return nil, "Cannot find a statement to declare the constant before."
+	}
+	// crosses reports whether container is between the statement and the selection.
+	crosses := func(container *ast.Node) bool {
+		return container != nil && containsNodeRange(statement, container) && !r.containsNode(container)
+	}
+	var reason string
+	fail := func(message string, args ...any) {
+		if reason == "" {
+			reason = fmt.Sprintf(message, args...)
+		}
+	}
+	var visit func(node *ast.Node) bool
+	visit = func(node *ast.Node) bool {
+		switch node.Kind {
+		case ast.KindIdentifier:
+			symbol := getExtractReferencedSymbol(c.checker, node)
+			if symbol == nil {
+				break
+			}
+			if c.checker.IsArgumentsSymbol(symbol) && crosses(getContainingFunctionOfNode(node)) {
+				fail("Cannot extract code that uses 'arguments' out of its function.")
+			}
+			if slices.ContainsFunc(symbol.Declarations, func(declaration *ast.Node) bool {
+				return ast.GetSourceFileOfNode(declaration) == c.sourceFile && crosses(declaration)
+			}) {
+				fail("Cannot extract code that references '%s', which is declared in the same statement.", symbol.Name)
+			}
+		case ast.KindThisKeyword, ast.KindSuperKeyword:
+			if crosses(ast.GetThisContainer(node, false /*includeArrowFunctions*/, false /*includeClassComputedPropertyName*/)) {
+				fail("Cannot extract code that uses '%s' out of its function.", scanner.TokenToString(node.Kind))
+			}
+		case ast.KindAwaitExpression, ast.KindYieldExpression:
+			if crosses(getContainingFunctionOfNode(node)) {
+				fail("Cannot extract code that uses '%s' out of its function.", scanner.TokenToString(node.Kind))
+			}
+		}
+		return node.ForEachChild(visit)
+	}
+	visit(r.expression)
+	if reason != "" {
+		return nil, reason
+	}
+	return statement, ""
+}
+
+func (ct *changeTracker) extractFunction(c *refactorContext, r *extractRange, info *extractFunctionInfo) {
+	file := c.sourceFile
+	text := file.Text()
+	name := getUniqueExtractName(file, "newFunction")
+	indentation := getLineIndentation(file, astnav.GetStartOfNode(info.insertAfter, file, false /*includeJSDoc*/))
+	bodyIndentation := indentation + ct.indentationUnit()
+
+	parameters := make([]string, len(info.parameters))
+	arguments := make([]string, len(info.parameters))
+	for i, parameter := range info.parameters {
+		arguments[i] = parameter.symbol.Name
+		parameters[i] = parameter.symbol.Name
+		if parameter.typeText != "" {
+			parameters[i] += ": " + parameter.typeText
+		}
+	}
+
+	body := reindentText(text[r.Pos():r.End()], getLineIndentation(file, r.Pos()), bodyIndentation)
+	if r.expression != nil {
+		body = "return " + body + ";"
+	}
+	var declaration strings.Builder
+	declaration.WriteString(ct.newLine + ct.newLine + indentation)
+	if info.isAsync {
+		declaration.WriteString("async ")
+	}
+	declaration.WriteString("function " + name + "(" + strings.Join(parameters, ", ") + ") {" + ct.newLine)
+	declaration.WriteString(bodyIndentation + body + ct.newLine)
+	declaration.WriteString(indentation + "}")
+
+	call := name + "(" + strings.Join(arguments, ", ") + ")"
+	if info.isAsync {
+		call = "await " + call
+		if r.expression != nil && needsParenthesesForAwait(r.expression) {
+			call = "(" + call + ")"
+		}
+	}
+	if r.statements != nil {
+		call += ";"
+	}
+	if info.insertAfter.End() == r.End() {
+		// The selection is the last top-level statement, so the declaration directly follows the call.
+		ct.replaceRangeWithText(file, *ct.ls.createLspRangeFromBounds(r.Pos(), r.End(), file), call+declaration.String())
+		return
+	}
+	ct.replaceRangeWithText(file, *ct.ls.createLspRangeFromBounds(r.Pos(), r.End(), file), call)
+	ct.insertText(file, ct.ls.createLspPosition(info.insertAfter.End(), file), declaration.String())
+}
+
+func (ct *changeTracker) extractConstant(file *ast.SourceFile, r *extractRange, statement *ast.Node) {
+	name := getUniqueExtractName(file, "newLocal")
+	start := astnav.GetStartOfNode(statement, file, true /*includeJSDoc*/)
+	declaration := "const " + name + " = " + file.Text()[r.Pos():r.End()] + ";" + ct.newLine + getLineIndentation(file, start)
+	if start == r.Pos() {
+		ct.replaceRangeWithText(file, *ct.ls.createLspRangeFromBounds(r.Pos(), r.End(), file), declaration+name)
+		return
+	}
+	ct.insertText(file, ct.ls.createLspPosition(start, file), declaration)
+	ct.replaceRangeWithText(file, *ct.ls.createLspRangeFromBounds(r.Pos(), r.End(), file), name)
+}
+
+// getExtractReferencedSymbol returns the local symbol an identifier refers to, or nil if it does
+// not refer to a variable, function, class, enum, type or type parameter.
+func getExtractReferencedSymbol(c *checker.Checker, identifier *ast.Node) *ast.Symbol {
+	var symbol *ast.Symbol
+	if ast.IsShorthandPropertyAssignment(identifier.Parent) && identifier.Parent.Name() == identifier {
+		symbol = c.GetShorthandAssignmentValueSymbol(identifier.Parent)
+	} else {
+		symbol = c.GetSymbolAtLocation(identifier)
+	}
+	if symbol == nil || symbol.Flags&(ast.SymbolFlagsVariable|ast.SymbolFlagsFunction|ast.SymbolFlagsClass|ast.SymbolFlagsEnum|ast.SymbolFlagsTypeAlias|ast.SymbolFlagsInterface|ast.SymbolFlagsTypeParameter) == 0 {
+		return nil
+	}
+	return symbol
+}
+
+func getContainingFunctionOfNode(node *ast.Node) *ast.Node {
+	return ast.FindAncestor(node.Parent, ast.IsFunctionLikeOrClassStaticBlockDeclaration)
+}
+
+// getJumpTarget returns the statement a break or continue statement jumps out of, or nil if there
+// is none in its function.
+func getJumpTarget(node *ast.Node) *ast.Node {
+	label := node.Label()
+	for current := node.Parent; current != nil && !ast.IsFunctionLikeOrClassStaticBlockDeclaration(current); current = current.Parent {
+		switch {
+		case label != nil:
+			if ast.IsLabeledStatement(current) && current.Label().Text() == label.Text() {
+				return current
+			}
+		case ast.IsIterationStatement(current, false /*lookInLabeledStatements*/),
+			node.Kind == ast.KindBreakStatement && ast.IsSwitchStatement(current):
+			return current
+		}
+	}
+	return nil
+}
+
+// getLocalTypeNames returns the names of the types and type parameters declared in scope for which
+// isLocal returns true.
+func getLocalTypeNames(scope *ast.Node, isLocal func(declaration *ast.Node) bool) map[string]bool {
+	names := map[string]bool{}
+	if scope == nil {
+		return names
+	}
+	var visit func(node *ast.Node) bool
+	visit = func(node *ast.Node) bool {
+		switch node.Kind {
+		case ast.KindTypeParameter, ast.KindInterfaceDeclaration, ast.KindTypeAliasDeclaration,
+			ast.KindClassDeclaration, ast.KindClassExpression, ast.KindEnumDeclaration:
+			if name := node.Name(); name != nil && ast.IsIdentifier(name) && isLocal(node) {
+				names[name.Text()] = true
+			}
+		}
+		return node.ForEachChild(visit)
+	}
+	visit(scope)
+	return names
+}
+
+// referencesName reports whether a type node contains an identifier with one of names.
+func referencesName(typeNode *ast.Node, names map[string]bool) bool {
+	if typeNode == nil || len(names) == 0 {
+		return false
+	}
+	var visit func(node *ast.Node) bool
+	visit = func(node *ast.Node) bool {
+		if ast.IsIdentifier(node) && names[node.Text()] {
+			return true
+		}
+		return node.ForEachChild(visit)
+	}
+	return visit(typeNode)
+}
+
+// containsNodeRange reports whether node is within container.
+func containsNodeRange(container *ast.Node, node *ast.Node) bool {
+	return container.Pos() <= node.Pos() && node.End() <= container.End()
+}
+
+// needsParenthesesForAwait reports whether an `await` expression replacing expression must be
+// parenthesized to keep binding to its parent as expression did.
+func needsParenthesesForAwait(expression *ast.Node) bool {
+	parent := expression.Parent
+	switch parent.Kind {
+	case ast.KindPropertyAccessExpression, ast.KindElementAccessExpression, ast.KindCallExpression, ast.KindNewExpression:
+		return parent.Expression() == expression
+	case ast.KindTaggedTemplateExpression, ast.KindNonNullExpression, ast.KindPostfixUnaryExpression:
+		return true
+	case ast.KindBinaryExpression:
+		return parent.AsBinaryExpression().OperatorToken.Kind == ast.KindAsteriskAsteriskToken && parent.AsBinaryExpression().Left == expression
+	}
+	return false
+}
+
+// getUniqueExtractName returns base, with a numeric suffix if the file already uses it.
+func getUniqueExtractName(file *ast.SourceFile, base string) string {
+	name := base
+	for i := 1; !printer.IsFileLevelUniqueName(file, name, nil /*hasGlobalName*/); i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+	return name
+}
+
+// reindentText replaces the indentation from at the start of the lines of text after the first
+// with to.
+func reindentText(text string, from string, to string) string {
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if rest, ok := strings.CutPrefix(lines[i], from); ok && strings.TrimSpace(rest) != "" {
+			lines[i] = to + rest
+		}
+	}
+	return strings.Join(lines, "\n")
+}
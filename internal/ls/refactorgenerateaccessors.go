@@ -0,0 +1,202 @@
+package ls
+
+import (
+	"strings"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+	"github.com/microsoft/typescript-go/internal/checker"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/scanner"
+)
+
+const refactorNameGenerateAccessors = "Generate 'get' and 'set' accessors"
+
+var generateAccessorsRefactorProvider = &refactorProvider{
+	name:                refactorNameGenerateAccessors,
+	description:         "Generate 'get' and 'set' accessors",
+	getAvailableActions: getGenerateAccessorsActions,
+	getEditsForAction:   getGenerateAccessorsEdits,
+}
+
+func getGenerateAccessorsActions(c *refactorContext) []*RefactorAction {
+	declaration := getGenerateAccessorsDeclaration(c)
+	if declaration == nil {
+		return nil
+	}
+	return []*RefactorAction{{
+		Name:                refactorNameGenerateAccessors,
+		Description:         "Generate 'get' and 'set' accessors",
+		Kind:                "refactor.rewrite.property.generateAccessors",
+		NotApplicableReason: getGenerateAccessorsNotApplicableReason(declaration),
+	}}
+}
+
+func getGenerateAccessorsEdits(c *refactorContext, actionName string) *lsproto.WorkspaceEdit {
+	declaration := getGenerateAccessorsDeclaration(c)
+	if actionName != refactorNameGenerateAccessors || declaration == nil || getGenerateAccessorsNotApplicableReason(declaration) != "" {
+		return nil
+	}
+	ct := c.ls.newChangeTracker(c.ctx)
+	ct.generateAccessors(c.sourceFile, c.checker, declaration)
+	return ct.getWorkspaceEdit()
+}
+
+// getGenerateAccessorsDeclaration returns the class property or accessor whose modifiers or name
+// contain the span, or nil if there is none.
+func getGenerateAccessorsDeclaration(c *refactorContext) *ast.Node {
+	declaration := c.findContainingNode(func(node *ast.Node) bool {
+		switch node.Kind {
+		case ast.KindPropertyDeclaration, ast.KindGetAccessor, ast.KindSetAccessor:
+			return ast.IsClassLike(node.Parent)
+		}
+		return false
+	})
+	if declaration == nil || c.span.End() > declaration.Name().End() {
+		return nil
+	}
+	switch name := declaration.Name(); name.Kind {
+	case ast.KindIdentifier, ast.KindPrivateIdentifier:
+		return declaration
+	}
+	return nil
+}
+
+func getGenerateAccessorsNotApplicableReason(declaration *ast.Node) string {
+	switch {
+	case !ast.IsPropertyDeclaration(declaration) || ast.IsAutoAccessorPropertyDeclaration(declaration):
+		return "The member is already an accessor."
+	case ast.HasSyntacticModifier(declaration, ast.ModifierFlagsAbstract):
+		return "Cannot generate accessors for an abstract property."
+	case ast.HasSyntacticModifier(declaration, ast.ModifierFlagsAmbient):
+		return "Cannot generate accessors for a declared property."
+	}
+	return ""
+}
+
+// generateAccessors turns a property into a private backing field with public accessors to it:
+//
+//	x: number = 1; -> private _x: number = 1;
+//	                  public get x(): number {
+//	                      return this._x;
+//	                  }
+//	                  public set x(value: number) {
+//	                      this._x = value;
+//	                  }
+//
+// The field keeps the name of a property starting with an underscore, and the accessors are named
+// without it. A protected property gets protected accessors, and a readonly property only gets a
+// get accessor, its assignments in the constructor being renamed to the field.
+func (ct *changeTracker) generateAccessors(file *ast.SourceFile, c *checker.Checker, declaration *ast.Node) {
+	name := declaration.Name()
+	fieldName, accessorName := name.Text(), name.Text()
+	switch {
+	case ast.IsPrivateIdentifier(name):
+		accessorName = strings.TrimPrefix(fieldName, "#")
+	case strings.HasPrefix(fieldName, "_") && len(fieldName) > 1 && scanner.IsIdentifierText(fieldName[1:], file.LanguageVariant):
+		accessorName = fieldName[1:]
+	default:
+		fieldName = "_" + fieldName
+	}
+	isJS := ast.IsInJSFile(declaration)
+	isStatic := ast.HasSyntacticModifier(declaration, ast.ModifierFlagsStatic)
+	isReadonly := ast.HasSyntacticModifier(declaration, ast.ModifierFlagsReadonly)
+
+	// The field keeps the decorators and the static modifier of the property, and becomes private.
+	var fieldModifiers, accessorModifiers []string
+	if !isJS {
+		accessorModifiers = append(accessorModifiers, "public")
+		switch {
+		case ast.IsPrivateIdentifier(name):
+		case ast.HasSyntacticModifier(declaration, ast.ModifierFlagsProtected):
+			fieldModifiers = append(fieldModifiers, "protected")
+			accessorModifiers[0] = "protected"
+		default:
+			fieldModifiers = append(fieldModifiers, "private")
+		}
+	}
+	if isStatic {
+		fieldModifiers = append(fieldModifiers, "static")
+		accessorModifiers = append(accessorModifiers, "static")
+	}
+	if ast.HasSyntacticModifier(declaration, ast.ModifierFlagsOverride) {
+		accessorModifiers = append(accessorModifiers, "override")
+	}
+	fieldStart := scanner.GetTokenPosOfNode(name, file, false /*includeJSDoc*/)
+	for _, modifier := range declaration.ModifierNodes() {
+		if !ast.IsDecorator(modifier) {
+			fieldStart = min(fieldStart, scanner.GetTokenPosOfNode(modifier, file, false /*includeJSDoc*/))
+		}
+	}
+	ct.replaceRangeWithText(file, *ct.ls.createLspRangeFromBounds(fieldStart, name.End(), file), strings.Join(append(fieldModifiers, fieldName), " "))
+
+	var typeAnnotation string
+	if !isJS {
+		if typeNode := declaration.Type(); typeNode != nil {
+			typeAnnotation = ": " + scanner.GetTextOfNode(typeNode)
+		} else {
+			typeAnnotation = ": " + c.TypeToStringEx(c.GetTypeAtLocation(name), declaration.Parent, checker.TypeFormatFlagsNoTruncation)
+		}
+	}
+	receiver := "this"
+	if isStatic {
+		receiver = core.OrElse(getClassName(declaration.Parent), "this")
+	}
+	field := receiver + "." + fieldName
+	prefix := strings.Join(accessorModifiers, " ")
+	if prefix != "" {
+		prefix += " "
+	}
+
+	indentation := getLineIndentation(file, astnav.GetStartOfNode(declaration, file, false /*includeJSDoc*/))
+	bodyIndentation := indentation + ct.indentationUnit()
+	var text strings.Builder
+	text.WriteString(ct.newLine + indentation + prefix + "get " + accessorName + "()" + typeAnnotation + " {" + ct.newLine)
+	text.WriteString(bodyIndentation + "return " + field + ";" + ct.newLine)
+	text.WriteString(indentation + "}")
+	if !isReadonly {
+		text.WriteString(ct.newLine + indentation + prefix + "set " + accessorName + "(value" + typeAnnotation + ") {" + ct.newLine)
+		text.WriteString(bodyIndentation + field + " = value;" + ct.newLine)
+		text.WriteString(indentation + "}")
+	}
+	ct.insertText(file, ct.ls.createLspPosition(declaration.End(), file), text.String())
+
+	if isReadonly && !isStatic && fieldName != name.Text() {
+		ct.renameConstructorAssignments(file, declaration.Parent, name.Text(), fieldName)
+	}
+}
+
+// renameConstructorAssignments renames the property in the assignments `this.name = ...` of the
+// constructor of a class, which can no longer assign the get accessor replacing it.
+func (ct *changeTracker) renameConstructorAssignments(file *ast.SourceFile, classNode *ast.Node, name string, newName string) {
+	constructor := core.Find(classNode.Members(), func(member *ast.Node) bool {
+		return ast.IsConstructorDeclaration(member) && member.Body() != nil
+	})
+	if constructor == nil {
+		return
+	}
+	var visit func(node *ast.Node) bool
+	visit = func(node *ast.Node) bool {
+		// Functions other than arrow functions have their own `this`.
+		if ast.IsFunctionLike(node) && !ast.IsArrowFunction(node) || ast.IsClassLike(node) {
+			return false
+		}
+		if ast.IsAssignmentExpression(node, false /*excludeCompoundAssignment*/) {
+			if left := node.AsBinaryExpression().Left; ast.IsPropertyAccessExpression(left) &&
+				left.Expression().Kind == ast.KindThisKeyword && left.Name().Text() == name {
+				nameNode := left.Name()
+				ct.replaceRangeWithText(file, *ct.ls.createLspRangeFromBounds(scanner.GetTokenPosOfNode(nameNode, file, false /*includeJSDoc*/), nameNode.End(), file), newName)
+			}
+		}
+		return node.ForEachChild(visit)
+	}
+	constructor.Body().ForEachChild(visit)
+}
+
+func getClassName(classNode *ast.Node) string {
+	if name := classNode.Name(); name != nil {
+		return name.Text()
+	}
+	return ""
+}
@@ -0,0 +1,460 @@
+package ls
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+	"github.com/microsoft/typescript-go/internal/checker"
+	"github.com/microsoft/typescript-go/internal/collections"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/scanner"
+)
+
+const (
+	refactorNameGenerateConstructor             = "Generate constructor"
+	refactorActionGenerateConstructor           = "Generate constructor"
+	refactorActionInitializeFieldsInConstructor = "Initialize fields in constructor"
+	refactorActionConvertToParameterProperties  = "Convert to parameter properties"
+)
+
+var generateConstructorRefactorProvider = &refactorProvider{
+	name:                refactorNameGenerateConstructor,
+	description:         "Generate a constructor initializing the fields of a class",
+	getAvailableActions: getGenerateConstructorActions,
+	getEditsForAction:   getGenerateConstructorEdits,
+}
+
+func getGenerateConstructorActions(c *refactorContext) []*RefactorAction {
+	classNode := getGenerateConstructorClass(c)
+	if classNode == nil {
+		return nil
+	}
+	constructor := getConstructorWithBody(classNode)
+	if constructor == nil {
+		if hasConstructorDeclaration(classNode) {
+			return nil
+		}
+		_, _, reason := getGeneratedConstructorInfo(c.checker, classNode)
+		return []*RefactorAction{{
+			Name:                refactorActionGenerateConstructor,
+			Description:         "Generate constructor",
+			Kind:                "refactor.rewrite.class.generateConstructor",
+			NotApplicableReason: reason,
+		}}
+	}
+	actions := []*RefactorAction{{
+		Name:                refactorActionInitializeFieldsInConstructor,
+		Description:         "Initialize fields in constructor",
+		Kind:                "refactor.rewrite.class.initializeFields",
+		NotApplicableReason: getInitializeFieldsNotApplicableReason(classNode, constructor),
+	}}
+	if !ast.IsInJSFile(classNode) {
+		var reason string
+		if len(getParameterPropertyCandidates(classNode, constructor)) == 0 {
+			reason = "The constructor has no parameters assigned to fields."
+		}
+		actions = append(actions, &RefactorAction{
+			Name:                refactorActionConvertToParameterProperties,
+			Description:         "Convert to parameter properties",
+			Kind:                "refactor.rewrite.class.parameterProperties",
+			NotApplicableReason: reason,
+		})
+	}
+	return actions
+}
+
+func getGenerateConstructorEdits(c *refactorContext, actionName string) *lsproto.WorkspaceEdit {
+	classNode := getGenerateConstructorClass(c)
+	if classNode == nil {
+		return nil
+	}
+	constructor := getConstructorWithBody(classNode)
+	ct := c.ls.newChangeTracker(c.ctx)
+	switch actionName {
+	case refactorActionGenerateConstructor:
+		if constructor != nil || hasConstructorDeclaration(classNode) {
+			return nil
+		}
+		fields, baseSignature, reason := getGeneratedConstructorInfo(c.checker, classNode)
+		if reason != "" {
+			return nil
+		}
+		ct.generateConstructor(c.sourceFile, c.checker, classNode, fields, baseSignature)
+	case refactorActionInitializeFieldsInConstructor:
+		if constructor == nil || getInitializeFieldsNotApplicableReason(classNode, constructor) != "" {
+			return nil
+		}
+		ct.initializeFieldsInConstructor(c.sourceFile, c.checker, constructor, getUninitializedFields(classNode, constructor))
+	case refactorActionConvertToParameterProperties:
+		if constructor == nil || ast.IsInJSFile(classNode) {
+			return nil
+		}
+		candidates := getParameterPropertyCandidates(classNode, constructor)
+		if len(candidates) == 0 {
+			return nil
+		}
+		ct.convertToParameterProperties(c.sourceFile, candidates)
+	default:
+		return nil
+	}
+	return ct.getWorkspaceEdit()
+}
+
+// getGenerateConstructorClass returns the class whose heading, property declarations or constructor
+// parameters contain the span, or nil if there is none. Ambient classes have no constructor body
+// to generate.
+func getGenerateConstructorClass(c *refactorContext) *ast.Node {
+	classNode := c.findContainingNode(ast.IsClassLike)
+	if classNode == nil || classNode.Flags&ast.NodeFlagsAmbient != 0 {
+		return nil
+	}
+	member := c.startToken()
+	for member != nil && member.Parent != classNode {
+		member = member.Parent
+	}
+	if member != nil && ast.IsClassElement(member) {
+		switch {
+		case ast.IsPropertyDeclaration(member):
+		case ast.IsConstructorDeclaration(member):
+			if body := member.Body(); body != nil && c.span.End() > scanner.GetTokenPosOfNode(body, c.sourceFile, false /*includeJSDoc*/) {
+				return nil
+			}
+		default:
+			return nil
+		}
+	}
+	return classNode
+}
+
+func getConstructorWithBody(classNode *ast.Node) *ast.Node {
+	return core.Find(classNode.Members(), func(member *ast.Node) bool {
+		return ast.IsConstructorDeclaration(member) && member.Body() != nil
+	})
+}
+
+func hasConstructorDeclaration(classNode *ast.Node) bool {
+	return core.Some(classNode.Members(), ast.IsConstructorDeclaration)
+}
+
+// getUninitializedFields returns the instance properties of a class, in declaration order, that
+// have no initializer and are not assigned by the constructor. Optional properties and properties
+// with a definite assignment assertion are left alone, as are properties whose name cannot be the
+// name of a parameter.
+func getUninitializedFields(classNode *ast.Node, constructor *ast.Node) []*ast.Node {
+	var assigned collections.Set[string]
+	if constructor != nil {
+		forEachThisPropertyAssignment(constructor.Body(), func(name *ast.Node) {
+			assigned.Add(name.Text())
+		})
+	}
+	return core.Filter(classNode.Members(), func(member *ast.Node) bool {
+		if !ast.IsPropertyDeclaration(member) || member.Initializer() != nil || member.PostfixToken() != nil ||
+			ast.HasSyntacticModifier(member, ast.ModifierFlagsStatic|ast.ModifierFlagsAbstract|ast.ModifierFlagsAmbient) {
+			return false
+		}
+		switch name := member.Name(); name.Kind {
+		case ast.KindIdentifier, ast.KindPrivateIdentifier:
+			return !assigned.Has(name.Text())
+		}
+		return false
+	})
+}
+
+// forEachThisPropertyAssignment calls cb with the name of each property assigned as `this.name = ...`
+// in body, outside of nested functions and classes with their own `this`.
+func forEachThisPropertyAssignment(body *ast.Node, cb func(name *ast.Node)) {
+	var visit func(node *ast.Node) bool
+	visit = func(node *ast.Node) bool {
+		if ast.IsFunctionLike(node) && !ast.IsArrowFunction(node) || ast.IsClassLike(node) {
+			return false
+		}
+		if ast.IsAssignmentExpression(node, false /*excludeCompoundAssignment*/) {
+			if left := node.AsBinaryExpression().Left; ast.IsPropertyAccessExpression(left) && left.Expression().Kind == ast.KindThisKeyword {
+				cb(left.Name())
+			}
+		}
+		return node.ForEachChild(visit)
+	}
+	body.ForEachChild(visit)
+}
+
+// getGeneratedConstructorInfo returns the fields a generated constructor initializes and, for a
+// derived class, the signature of the base constructor it forwards parameters to, or the reason no
+// constructor can be generated.
+func getGeneratedConstructorInfo(ch *checker.Checker, classNode *ast.Node) ([]*ast.Node, *checker.Signature, string) {
+	fields := getUninitializedFields(classNode, nil /*constructor*/)
+	if len(fields) == 0 {
+		return nil, nil, "The class has no uninitialized fields."
+	}
+	baseTypeNode := ast.GetClassExtendsHeritageElement(classNode)
+	if baseTypeNode == nil {
+		return fields, nil, ""
+	}
+	baseConstructorType := ch.GetBaseConstructorTypeOfClass(ch.GetDeclaredTypeOfSymbol(classNode.Symbol()))
+	signatures := ch.GetInstantiatedConstructorsForTypeArguments(baseConstructorType, baseTypeNode.TypeArguments(), baseTypeNode)
+	switch len(signatures) {
+	case 0:
+		return nil, nil, "Cannot resolve the constructor of the base class."
+	case 1:
+		return fields, signatures[0], ""
+	}
+	return nil, nil, "The base class has several constructors."
+}
+
+func getInitializeFieldsNotApplicableReason(classNode *ast.Node, constructor *ast.Node) string {
+	parameters := constructor.Parameters()
+	switch {
+	case len(getUninitializedFields(classNode, constructor)) == 0:
+		return "The class has no uninitialized fields."
+	case len(parameters) != 0 && parameters[len(parameters)-1].AsParameterDeclaration().DotDotDotToken != nil:
+		return "The constructor has a rest parameter."
+	case core.Some(parameters, func(parameter *ast.Node) bool { return parameter.QuestionToken() != nil }):
+		return "The constructor has optional parameters."
+	}
+	return ""
+}
+
+// generateConstructor adds a constructor after the last property declaration of a class, with a
+// parameter for each of fields assigned to it:
+//
+//	class A extends B {          class A extends B {
+//	    x: number;                   x: number;
+//	    y: string;          ->       y: string;
+//	}                                constructor(x: number, y: string, b: boolean) {
+//	                                     super(b);
+//	                                     this.x = x;
+//	                                     this.y = y;
+//	                                 }
+//	                             }
+//
+// The parameters of the base constructor a derived class calls come last, keeping their optionality.
+func (ct *changeTracker) generateConstructor(file *ast.SourceFile, ch *checker.Checker, classNode *ast.Node, fields []*ast.Node, baseSignature *checker.Signature) {
+	var usedNames collections.Set[string]
+	var parameters, assignments []string
+	for _, field := range fields {
+		name := getUniqueParameterName(field.Name(), &usedNames)
+		parameters = append(parameters, name+getFieldParameterTypeAnnotation(ch, field))
+		assignments = append(assignments, "this."+field.Name().Text()+" = "+name+";")
+	}
+	var superCall string
+	if baseSignature != nil {
+		isJS := ast.IsInJSFile(classNode)
+		minArgumentCount := ch.GetMinArgumentCount(baseSignature)
+		var arguments []string
+		for i, parameter := range baseSignature.Parameters() {
+			name := parameter.Name
+			if !scanner.IsIdentifierText(name, file.LanguageVariant) {
+				name = "arg"
+			}
+			name = getUniqueName(name, &usedNames)
+			isRest := baseSignature.HasRestParameter() && i == len(baseSignature.Parameters())-1
+			text := name
+			argument := name
+			if isRest {
+				text = "..." + name
+				argument = "..." + name
+			} else if i >= minArgumentCount && !isJS {
+				text += "?"
+			}
+			if !isJS {
+				text += ": " + ch.TypeToStringEx(ch.GetTypeOfSymbol(parameter), classNode, checker.TypeFormatFlagsNoTruncation)
+			}
+			parameters = append(parameters, text)
+			arguments = append(arguments, argument)
+		}
+		superCall = "super(" + strings.Join(arguments, ", ") + ");"
+	}
+
+	anchor := core.FindLast(classNode.Members(), ast.IsPropertyDeclaration)
+	indentation := getLineIndentation(file, astnav.GetStartOfNode(anchor, file, false /*includeJSDoc*/))
+	bodyIndentation := indentation + ct.indentationUnit()
+	var text strings.Builder
+	text.WriteString(ct.newLine + indentation + "constructor(" + strings.Join(parameters, ", ") + ") {" + ct.newLine)
+	if superCall != "" {
+		text.WriteString(bodyIndentation + superCall + ct.newLine)
+	}
+	for _, assignment := range assignments {
+		text.WriteString(bodyIndentation + assignment + ct.newLine)
+	}
+	text.WriteString(indentation + "}")
+	ct.insertText(file, ct.ls.createLspPosition(anchor.End(), file), text.String())
+}
+
+// initializeFieldsInConstructor adds a parameter to an existing constructor for each of fields,
+// after its own parameters, and assigns it to the field after the `super` call of the constructor,
+// or at the start of its body if it has none.
+func (ct *changeTracker) initializeFieldsInConstructor(file *ast.SourceFile, ch *checker.Checker, constructor *ast.Node, fields []*ast.Node) {
+	var usedNames collections.Set[string]
+	for _, parameter := range constructor.Parameters() {
+		forEachParameterName(parameter.Name(), func(name *ast.Node) {
+			usedNames.Add(name.Text())
+		})
+	}
+	var parameters, assignments []string
+	for _, field := range fields {
+		name := getUniqueParameterName(field.Name(), &usedNames)
+		parameters = append(parameters, name+getFieldParameterTypeAnnotation(ch, field))
+		assignments = append(assignments, "this."+field.Name().Text()+" = "+name+";")
+	}
+	if existing := constructor.Parameters(); len(existing) != 0 {
+		ct.insertText(file, ct.ls.createLspPosition(existing[len(existing)-1].End(), file), ", "+strings.Join(parameters, ", "))
+	} else {
+		ct.insertText(file, ct.ls.createLspPosition(constructor.ParameterList().Pos(), file), strings.Join(parameters, ", "))
+	}
+
+	body := constructor.Body()
+	indentation := getLineIndentation(file, astnav.GetStartOfNode(constructor, file, false /*includeJSDoc*/))
+	statements := body.Statements()
+	if len(statements) == 0 {
+		bodyIndentation := indentation + ct.indentationUnit()
+		var text strings.Builder
+		text.WriteString("{" + ct.newLine)
+		for _, assignment := range assignments {
+			text.WriteString(bodyIndentation + assignment + ct.newLine)
+		}
+		text.WriteString(indentation + "}")
+		ct.replaceRangeWithText(file, *ct.ls.createLspRangeFromBounds(scanner.GetTokenPosOfNode(body, file, false /*includeJSDoc*/), body.End(), file), text.String())
+		return
+	}
+	bodyIndentation := getLineIndentation(file, astnav.GetStartOfNode(statements[0], file, false /*includeJSDoc*/))
+	var text strings.Builder
+	for _, assignment := range assignments {
+		text.WriteString(ct.newLine + bodyIndentation + assignment)
+	}
+	position := body.AsBlock().Statements.Pos()
+	if superCall := core.Find(statements, func(statement *ast.Node) bool {
+		return ast.IsExpressionStatement(statement) && ast.IsSuperCall(statement.Expression())
+	}); superCall != nil {
+		position = superCall.End()
+	}
+	ct.insertText(file, ct.ls.createLspPosition(position, file), text.String())
+}
+
+// parameterPropertyCandidate is a constructor parameter only assigned to a field of the same name
+// by a statement `this.name = name;` of the constructor body.
+type parameterPropertyCandidate struct {
+	parameter  *ast.Node
+	field      *ast.Node
+	assignment *ast.Node
+}
+
+func getParameterPropertyCandidates(classNode *ast.Node, constructor *ast.Node) []parameterPropertyCandidate {
+	var candidates []parameterPropertyCandidate
+	for _, parameter := range constructor.Parameters() {
+		name := parameter.Name()
+		if !ast.IsIdentifier(name) || parameter.AsParameterDeclaration().DotDotDotToken != nil ||
+			ast.IsParameterPropertyDeclaration(parameter, constructor) || ast.HasDecorators(parameter) {
+			continue
+		}
+		field := core.Find(classNode.Members(), func(member *ast.Node) bool {
+			return ast.IsPropertyDeclaration(member) && ast.IsIdentifier(member.Name()) && member.Name().Text() == name.Text()
+		})
+		if field == nil || field.Initializer() != nil || field.PostfixToken() != nil || ast.HasDecorators(field) ||
+			ast.IsAutoAccessorPropertyDeclaration(field) ||
+			ast.HasSyntacticModifier(field, ast.ModifierFlagsStatic|ast.ModifierFlagsAbstract|ast.ModifierFlagsAmbient) {
+			continue
+		}
+		assignment := core.Find(constructor.Body().Statements(), func(statement *ast.Node) bool {
+			if !ast.IsExpressionStatement(statement) || !ast.IsAssignmentExpression(statement.Expression(), true /*excludeCompoundAssignment*/) {
+				return false
+			}
+			binary := statement.Expression().AsBinaryExpression()
+			return ast.IsPropertyAccessExpression(binary.Left) && binary.Left.Expression().Kind == ast.KindThisKeyword &&
+				binary.Left.Name().Text() == name.Text() && ast.IsIdentifier(binary.Right) && binary.Right.Text() == name.Text()
+		})
+		if assignment != nil {
+			candidates = append(candidates, parameterPropertyCandidate{parameter: parameter, field: field, assignment: assignment})
+		}
+	}
+	return candidates
+}
+
+// convertToParameterProperties turns constructor parameters assigned to fields into parameter
+// properties, removing the fields and the assignments:
+//
+//	class A {                                     class A {
+//	    private readonly x: number;        ->         constructor(private readonly x: number) {
+//	    constructor(x: number) {                      }
+//	        this.x = x;                           }
+//	    }
+//	}
+//
+// A parameter property gets the accessibility and the readonly and override modifiers of its field,
+// being public if the field has no accessibility modifier, and the type of the field if the
+// parameter has no type annotation.
+func (ct *changeTracker) convertToParameterProperties(file *ast.SourceFile, candidates []parameterPropertyCandidate) {
+	for _, candidate := range candidates {
+		field, parameter := candidate.field, candidate.parameter
+		var modifiers []string
+		switch {
+		case ast.HasSyntacticModifier(field, ast.ModifierFlagsPrivate):
+			modifiers = append(modifiers, "private")
+		case ast.HasSyntacticModifier(field, ast.ModifierFlagsProtected):
+			modifiers = append(modifiers, "protected")
+		default:
+			modifiers = append(modifiers, "public")
+		}
+		if ast.HasSyntacticModifier(field, ast.ModifierFlagsOverride) {
+			modifiers = append(modifiers, "override")
+		}
+		if ast.HasSyntacticModifier(field, ast.ModifierFlagsReadonly) {
+			modifiers = append(modifiers, "readonly")
+		}
+		ct.insertText(file, ct.ls.createLspPosition(scanner.GetTokenPosOfNode(parameter, file, false /*includeJSDoc*/), file), strings.Join(modifiers, " ")+" ")
+		if parameter.Type() == nil && field.Type() != nil {
+			end := parameter.Name().End()
+			if questionToken := parameter.QuestionToken(); questionToken != nil {
+				end = questionToken.End()
+			}
+			ct.insertText(file, ct.ls.createLspPosition(end, file), ": "+scanner.GetTextOfNode(field.Type()))
+		}
+		ct.deleteStatement(file, field)
+		ct.deleteStatement(file, candidate.assignment)
+	}
+}
+
+// getFieldParameterTypeAnnotation returns the type annotation of the parameter initializing field,
+// or an empty string in a JavaScript file.
+func getFieldParameterTypeAnnotation(ch *checker.Checker, field *ast.Node) string {
+	if ast.IsInJSFile(field) {
+		return ""
+	}
+	if typeNode := field.Type(); typeNode != nil {
+		return ": " + scanner.GetTextOfNode(typeNode)
+	}
+	return ": " + ch.TypeToStringEx(ch.GetTypeAtLocation(field.Name()), field.Parent, checker.TypeFormatFlagsNoTruncation)
+}
+
+// getUniqueParameterName returns the name of the parameter initializing the field named name,
+// without the `#` of a private name, made unique among usedNames.
+func getUniqueParameterName(name *ast.Node, usedNames *collections.Set[string]) string {
+	return getUniqueName(strings.TrimPrefix(name.Text(), "#"), usedNames)
+}
+
+// getUniqueName returns name, followed by the smallest number making it unique if it is already in
+// usedNames, and adds it to usedNames.
+func getUniqueName(name string, usedNames *collections.Set[string]) string {
+	unique := name
+	for i := 1; usedNames.Has(unique); i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	usedNames.Add(unique)
+	return unique
+}
+
+// forEachParameterName calls cb with each identifier a parameter name, possibly a binding pattern,
+// declares.
+func forEachParameterName(name *ast.Node, cb func(name *ast.Node)) {
+	if ast.IsIdentifier(name) {
+		cb(name)
+		return
+	}
+	for _, element := range name.Elements() {
+		if ast.IsBindingElement(element) {
+			forEachParameterName(element.Name(), cb)
+		}
+	}
+}
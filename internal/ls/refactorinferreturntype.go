@@ -0,0 +1,134 @@
+package ls
+
+import (
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+	"github.com/microsoft/typescript-go/internal/checker"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/nodebuilder"
+)
+
+const refactorNameInferReturnType = "Infer function return type"
+
+var inferReturnTypeRefactorProvider = &refactorProvider{
+	name:                refactorNameInferReturnType,
+	description:         "Infer function return type",
+	getAvailableActions: getInferReturnTypeActions,
+	getEditsForAction:   getInferReturnTypeEdits,
+}
+
+func getInferReturnTypeActions(c *refactorContext) []*RefactorAction {
+	declaration := getInferReturnTypeDeclaration(c)
+	if declaration == nil {
+		return nil
+	}
+	_, reason := getInferredReturnTypeText(c, declaration)
+	return []*RefactorAction{{
+		Name:                refactorNameInferReturnType,
+		Description:         "Infer function return type",
+		Kind:                "refactor.rewrite.function.returnType",
+		NotApplicableReason: reason,
+	}}
+}
+
+func getInferReturnTypeEdits(c *refactorContext, actionName string) *lsproto.WorkspaceEdit {
+	declaration := getInferReturnTypeDeclaration(c)
+	if actionName != refactorNameInferReturnType || declaration == nil {
+		return nil
+	}
+	typeText, reason := getInferredReturnTypeText(c, declaration)
+	if reason != "" {
+		return nil
+	}
+	file := c.sourceFile
+	ct := c.ls.newChangeTracker(c.ctx)
+	closeParen := findChildOfKind(declaration, ast.KindCloseParenToken, file)
+	if closeParen == nil {
+		// An arrow function with a single unparenthesized parameter: x => x -> (x): T => x
+		parameter := declaration.Parameters()[0]
+		start := c.ls.createLspPosition(astnav.GetStartOfNode(parameter, file, false /*includeJSDoc*/), file)
+		ct.insertText(file, start, "(")
+		ct.insertText(file, c.ls.createLspPosition(parameter.End(), file), "): "+typeText)
+	} else {
+		ct.insertText(file, c.ls.createLspPosition(closeParen.End(), file), ": "+typeText)
+	}
+	return ct.getWorkspaceEdit()
+}
+
+// getInferReturnTypeDeclaration returns the function, method or arrow function with a body and no
+// return type annotation containing the start of the span, or nil if there is none. Positions in a
+// function body, or after the arrow of an arrow function, are not part of the function's signature.
+func getInferReturnTypeDeclaration(c *refactorContext) *ast.Node {
+	if ast.IsInJSFile(c.sourceFile.AsNode()) {
+		return nil
+	}
+	for node := c.startToken(); node != nil; node = node.Parent {
+		if ast.IsBlock(node) {
+			return nil
+		}
+		if parent := node.Parent; parent != nil && ast.IsArrowFunction(parent) &&
+			(node.Kind == ast.KindEqualsGreaterThanToken || parent.Body() == node) {
+			return nil
+		}
+		switch node.Kind {
+		case ast.KindFunctionDeclaration, ast.KindFunctionExpression, ast.KindArrowFunction, ast.KindMethodDeclaration:
+			if node.Body() == nil || node.Type() != nil {
+				return nil
+			}
+			return node
+		}
+	}
+	return nil
+}
+
+// getInferredReturnTypeText returns the source text of the return type of a declaration, or the
+// reason it cannot be written: the type is declared inside the function or cannot be named. The
+// return type of the implementation of overloads is the union of the overloads' return types.
+func getInferredReturnTypeText(c *refactorContext, declaration *ast.Node) (string, string) {
+	const notNameable = "Could not determine function return type."
+	var returnType *checker.Type
+	if signatures := getOverloadSignatures(c.checker, declaration); len(signatures) > 0 {
+		types := make([]*checker.Type, 0, len(signatures))
+		for _, signature := range signatures {
+			types = append(types, c.checker.GetReturnTypeOfSignature(signature))
+		}
+		returnType = c.checker.GetUnionType(types)
+	} else {
+		signature := c.checker.GetSignatureFromDeclaration(declaration)
+		if signature == nil {
+			return "", notNameable
+		}
+		if predicate := c.checker.GetTypePredicateOfSignature(signature); predicate != nil {
+			return c.checker.TypePredicateToStringEx(predicate, declaration, checker.TypeFormatFlagsNoTruncation), ""
+		}
+		returnType = c.checker.GetReturnTypeOfSignature(signature)
+	}
+	if returnType == nil {
+		return "", notNameable
+	}
+	typeNode := c.checker.TypeToTypeNode(returnType, declaration, nodebuilder.FlagsNone)
+	if typeNode == nil {
+		return "", notNameable
+	}
+	localTypeNames := getLocalTypeNames(declaration.Body(), func(*ast.Node) bool { return true })
+	if referencesName(typeNode, localTypeNames) {
+		return "", "Cannot infer a return type that is declared inside the function."
+	}
+	return c.checker.TypeToStringEx(returnType, declaration, checker.TypeFormatFlagsNoTruncation), ""
+}
+
+// getOverloadSignatures returns the signatures of the overloads of a declaration if it is the
+// implementation of an overloaded function or method, or nil otherwise.
+func getOverloadSignatures(c *checker.Checker, declaration *ast.Node) []*checker.Signature {
+	symbol := declaration.Symbol()
+	if symbol == nil || !ast.IsFunctionDeclaration(declaration) && !ast.IsMethodDeclaration(declaration) {
+		return nil
+	}
+	var signatures []*checker.Signature
+	for _, d := range symbol.Declarations {
+		if d != declaration && d.Kind == declaration.Kind && d.Body() == nil {
+			signatures = append(signatures, c.GetSignatureFromDeclaration(d))
+		}
+	}
+	return signatures
+}
@@ -0,0 +1,611 @@
+package ls
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/checker"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/scanner"
+)
+
+const (
+	refactorNameInline = "Inline"
+
+	refactorActionInlineVariable = "Inline variable"
+	refactorActionInlineFunction = "Inline function"
+)
+
+var inlineRefactorProvider = &refactorProvider{
+	name:                refactorNameInline,
+	description:         "Inline variable or function",
+	getAvailableActions: getInlineActions,
+	getEditsForAction:   getInlineEdits,
+}
+
+func getInlineActions(c *refactorContext) []*RefactorAction {
+	var actions []*RefactorAction
+	if declaration := getInlineVariableDeclaration(c); declaration != nil {
+		_, reason := getInlineVariableReferences(c, declaration)
+		actions = append(actions, &RefactorAction{
+			Name:                refactorActionInlineVariable,
+			Description:         refactorActionInlineVariable,
+			Kind:                "refactor.inline.variable",
+			NotApplicableReason: reason,
+		})
+	}
+	if declaration := getInlineFunctionDeclaration(c); declaration != nil {
+		_, reason := getInlineFunctionInfo(c, declaration)
+		actions = append(actions, &RefactorAction{
+			Name:                refactorActionInlineFunction,
+			Description:         refactorActionInlineFunction,
+			Kind:                "refactor.inline.function",
+			NotApplicableReason: reason,
+		})
+	}
+	return actions
+}
+
+func getInlineEdits(c *refactorContext, actionName string) *lsproto.WorkspaceEdit {
+	ct := c.ls.newChangeTracker(c.ctx)
+	switch actionName {
+	case refactorActionInlineVariable:
+		declaration := getInlineVariableDeclaration(c)
+		if declaration == nil {
+			return nil
+		}
+		references, reason := getInlineVariableReferences(c, declaration)
+		if references == nil || reason != "" {
+			return nil
+		}
+		ct.inlineVariable(c.sourceFile, declaration, references)
+	case refactorActionInlineFunction:
+		declaration := getInlineFunctionDeclaration(c)
+		if declaration == nil {
+			return nil
+		}
+		info, reason := getInlineFunctionInfo(c, declaration)
+		if info == nil || reason != "" {
+			return nil
+		}
+		ct.inlineFunction(c.sourceFile, declaration, info)
+	default:
+		return nil
+	}
+	return ct.getWorkspaceEdit()
+}
+
+// getInlineVariableDeclaration returns the declaration of the variable named at the start of the
+// span, by its declaration or by a reference to it, if it is the only declaration of a `const` or
+// `let` statement of this file, has an initializer and is not exported. A `var` could be used
+// before its declaration.
+func getInlineVariableDeclaration(c *refactorContext) *ast.Node {
+	token := c.startToken()
+	if !ast.IsIdentifier(token) {
+		return nil
+	}
+	declaration := token.Parent
+	if !ast.IsVariableDeclaration(declaration) || declaration.Name() != token {
+		symbol := c.checker.GetSymbolAtLocation(token)
+		if symbol == nil || len(symbol.Declarations) != 1 {
+			return nil
+		}
+		declaration = symbol.Declarations[0]
+		if !ast.IsVariableDeclaration(declaration) || ast.GetSourceFileOfNode(declaration) != c.sourceFile {
+			return nil
+		}
+	}
+	if !ast.IsIdentifier(declaration.Name()) || declaration.Initializer() == nil || !ast.IsVarConst(declaration) && !ast.IsVarLet(declaration) {
+		return nil
+	}
+	list := declaration.Parent
+	if !ast.IsVariableStatement(list.Parent) || len(list.AsVariableDeclarationList().Declarations.Nodes) != 1 ||
+		ast.HasSyntacticModifier(list.Parent, ast.ModifierFlagsExport|ast.ModifierFlagsAmbient) {
+		return nil
+	}
+	return declaration
+}
+
+// getInlineVariableReferences returns the references to a variable its initializer would replace,
+// with the reason it cannot be inlined if it is assigned to, used where only a name can be written,
+// or never used. An initializer that could have side effects or evaluate to a different object
+// each time can only replace a single reference, evaluated once where the declaration was.
+func getInlineVariableReferences(c *refactorContext, declaration *ast.Node) ([]*ast.Node, string) {
+	name := declaration.Name()
+	initializer := declaration.Initializer()
+	position := scanner.GetTokenPosOfNode(name, c.sourceFile, false /*includeJSDoc*/)
+	options := refOptions{use: referenceUseReferences}
+	symbolsAndEntries := c.ls.getReferencedSymbolsForNode(c.ctx, position, name, c.program, c.program.GetSourceFiles(), options, nil)
+	if c.ctx.Err() != nil {
+		return nil, ""
+	}
+	var references []*ast.Node
+	for _, symbolAndEntries := range symbolsAndEntries {
+		for _, entry := range symbolAndEntries.references {
+			node := entry.node
+			if node == name {
+				continue
+			}
+			switch {
+			case node == nil || !ast.IsIdentifier(node):
+				return nil, "Cannot find the references to the variable."
+			case ast.IsWriteAccess(node):
+				return nil, "Cannot inline a variable that is assigned to."
+			case ast.IsImportOrExportSpecifier(node.Parent) || ast.IsExportAssignment(node.Parent):
+				return nil, "Cannot inline an exported variable."
+			case ast.IsPartOfTypeQuery(node) || ast.IsJsxTagName(node):
+				return nil, "Cannot inline a variable used where only a name can be written."
+			case containsNodeRange(declaration, node):
+				return nil, "Cannot inline a variable that refers to itself."
+			}
+			references = append(references, node)
+		}
+	}
+	if len(references) == 0 {
+		return nil, "Cannot inline a variable that is never used."
+	}
+	if !isDuplicableExpression(initializer) {
+		if len(references) > 1 {
+			return nil, "Cannot inline an expression with side effects that is used more than once."
+		}
+		if !isEvaluatedOnceIn(references[0], declaration) {
+			return nil, "Cannot inline an expression with side effects into a function or loop."
+		}
+	}
+	for _, reference := range references {
+		if !resolvesSameAt(c.checker, initializer, reference, nil) {
+			return nil, "Cannot inline an expression whose names refer to other declarations at a reference."
+		}
+	}
+	return references, ""
+}
+
+// inlineVariable replaces the references to a variable with its initializer and deletes its
+// declaration.
+func (ct *changeTracker) inlineVariable(file *ast.SourceFile, declaration *ast.Node, references []*ast.Node) {
+	initializer := declaration.Initializer()
+	start := scanner.GetTokenPosOfNode(initializer, file, false /*includeJSDoc*/)
+	text := file.Text()[start:initializer.End()]
+	indentation := getLineIndentation(file, start)
+	for _, reference := range references {
+		referenceFile := ast.GetSourceFileOfNode(reference)
+		referenceStart := scanner.GetTokenPosOfNode(reference, referenceFile, false /*includeJSDoc*/)
+		replacement := getReplacementText(reference, initializer, reindentText(text, indentation, getLineIndentation(referenceFile, referenceStart)))
+		ct.replaceRangeWithText(referenceFile, *ct.ls.createLspRangeFromBounds(referenceStart, reference.End(), referenceFile), replacement)
+	}
+	ct.deleteVariableDeclaration(file, declaration)
+}
+
+// getInlineFunctionDeclaration returns the declaration of the function named at the start of the
+// span, by its declaration or by a reference to it, if it is the only declaration of a function
+// of this file with a body that is not exported.
+func getInlineFunctionDeclaration(c *refactorContext) *ast.Node {
+	token := c.startToken()
+	if !ast.IsIdentifier(token) {
+		return nil
+	}
+	declaration := token.Parent
+	if !ast.IsFunctionDeclaration(declaration) || declaration.Name() != token {
+		symbol := c.checker.GetSymbolAtLocation(token)
+		if symbol == nil || symbol.Flags&ast.SymbolFlagsFunction == 0 || len(symbol.Declarations) != 1 {
+			return nil
+		}
+		declaration = symbol.Declarations[0]
+		if !ast.IsFunctionDeclaration(declaration) || ast.GetSourceFileOfNode(declaration) != c.sourceFile {
+			return nil
+		}
+	}
+	if declaration.Body() == nil || declaration.Symbol() == nil || len(declaration.Symbol().Declarations) != 1 ||
+		ast.HasSyntacticModifier(declaration, ast.ModifierFlagsExport|ast.ModifierFlagsDefault|ast.ModifierFlagsAmbient) {
+		return nil
+	}
+	return declaration
+}
+
+type inlineFunctionInfo struct {
+	// expression is the expression the function returns.
+	expression *ast.Node
+	// uses are the references to each parameter in expression.
+	uses  [][]*ast.Node
+	calls []*ast.Node
+}
+
+// getInlineFunctionInfo returns what is needed to replace the calls of a function with the
+// expression it returns, with the reason it cannot be if its body is more than a return statement,
+// its parameters are not plain names, or it is referenced other than by calls. An argument that
+// could have side effects must replace a single use of its parameter, evaluated once, and such
+// arguments must be used in the order they are evaluated in.
+func getInlineFunctionInfo(c *refactorContext, declaration *ast.Node) (*inlineFunctionInfo, string) {
+	if ast.HasSyntacticModifier(declaration, ast.ModifierFlagsAsync) || declaration.AsFunctionDeclaration().AsteriskToken != nil {
+		return nil, "Cannot inline an async function or a generator."
+	}
+	if declaration.TypeParameters() != nil {
+		return nil, "Cannot inline a generic function."
+	}
+	statements := declaration.Body().Statements()
+	if len(statements) != 1 || !ast.IsReturnStatement(statements[0]) || statements[0].Expression() == nil {
+		return nil, "Only a function whose body is a single return statement can be inlined."
+	}
+	expression := statements[0].Expression()
+	parameters := declaration.Parameters()
+	for _, parameter := range parameters {
+		p := parameter.AsParameterDeclaration()
+		if !ast.IsIdentifier(p.Name()) || p.Name().Text() == "this" || p.Initializer != nil || p.DotDotDotToken != nil || p.QuestionToken != nil {
+			return nil, "Cannot inline a function with rest, optional or destructured parameters."
+		}
+	}
+	if usesFunctionContext(expression) {
+		return nil, "Cannot inline a function that uses 'this', 'super' or 'arguments'."
+	}
+
+	uses := make([][]*ast.Node, len(parameters))
+	writesParameter := false
+	forEachReferenceIdentifier(expression, func(identifier *ast.Node) {
+		symbol := getSymbolOfReferenceIdentifier(c.checker, identifier)
+		for i, parameter := range parameters {
+			if symbol != nil && symbol == parameter.Symbol() {
+				uses[i] = append(uses[i], identifier)
+				writesParameter = writesParameter || ast.IsWriteAccess(identifier)
+			}
+		}
+	})
+	if writesParameter {
+		return nil, "Cannot inline a function that assigns to its parameters."
+	}
+	isParameterUse := func(identifier *ast.Node) bool {
+		return slices.ContainsFunc(uses, func(u []*ast.Node) bool { return slices.Contains(u, identifier) })
+	}
+
+	name := declaration.Name()
+	position := scanner.GetTokenPosOfNode(name, c.sourceFile, false /*includeJSDoc*/)
+	options := refOptions{use: referenceUseReferences}
+	symbolsAndEntries := c.ls.getReferencedSymbolsForNode(c.ctx, position, name, c.program, c.program.GetSourceFiles(), options, nil)
+	if c.ctx.Err() != nil {
+		return nil, ""
+	}
+	var calls []*ast.Node
+	for _, symbolAndEntries := range symbolsAndEntries {
+		for _, entry := range symbolAndEntries.references {
+			node := entry.node
+			if node == name {
+				continue
+			}
+			if node == nil {
+				return nil, "Cannot find the references to the function."
+			}
+			if containsNodeRange(declaration, node) {
+				return nil, "Cannot inline a recursive function."
+			}
+			call := node.Parent
+			if !ast.IsCallExpression(call) || call.Expression() != node || ast.IsOptionalChain(call) {
+				return nil, "Cannot inline a function that is used other than by calling it."
+			}
+			arguments := call.Arguments()
+			if len(arguments) > len(parameters) || slices.ContainsFunc(arguments, ast.IsSpreadElement) {
+				return nil, "Cannot inline a call with spread or extra arguments."
+			}
+			calls = append(calls, call)
+		}
+	}
+	if len(calls) == 0 {
+		return nil, "Cannot inline a function that is never called."
+	}
+	for _, call := range calls {
+		if slices.ContainsFunc(calls, func(other *ast.Node) bool { return other != call && containsNodeRange(other, call) }) {
+			return nil, "Cannot inline a call in the arguments of another."
+		}
+		lastUse := -1
+		for i, argument := range call.Arguments() {
+			if !isDuplicableExpression(argument) {
+				if len(uses[i]) != 1 || !isEvaluatedOnceIn(uses[i][0], expression) {
+					return nil, "Cannot inline a call with an argument with side effects that is not used exactly once."
+				}
+				if uses[i][0].Pos() < lastUse {
+					return nil, "Cannot inline a call with arguments with side effects that are used in a different order."
+				}
+				lastUse = uses[i][0].Pos()
+			}
+			for _, use := range uses[i] {
+				if isCapturedAt(c.checker, argument, use, expression) {
+					return nil, "Cannot inline a call with an argument whose names refer to other declarations in the function."
+				}
+			}
+		}
+		if !resolvesSameAt(c.checker, expression, call, isParameterUse) {
+			return nil, "Cannot inline a function whose names refer to other declarations at a call."
+		}
+	}
+	return &inlineFunctionInfo{expression: expression, uses: uses, calls: calls}, ""
+}
+
+// inlineFunction replaces the calls of a function with the expression it returns, written with the
+// arguments of each call in place of its parameters, and deletes the function.
+func (ct *changeTracker) inlineFunction(file *ast.SourceFile, declaration *ast.Node, info *inlineFunctionInfo) {
+	start := scanner.GetTokenPosOfNode(info.expression, file, false /*includeJSDoc*/)
+	indentation := getLineIndentation(file, start)
+	for _, call := range info.calls {
+		callFile := ast.GetSourceFileOfNode(call)
+		arguments := call.Arguments()
+		type replacement struct {
+			use  *ast.Node
+			text string
+		}
+		var replacements []replacement
+		// The replacement has the precedence of the argument if the function returns a parameter.
+		replaced := info.expression
+		for i, uses := range info.uses {
+			var argument *ast.Node
+			argumentText := "undefined"
+			if i < len(arguments) {
+				argument = arguments[i]
+				argumentText = callFile.Text()[scanner.GetTokenPosOfNode(argument, callFile, false /*includeJSDoc*/):argument.End()]
+			}
+			for _, use := range uses {
+				if use == info.expression {
+					replaced = argument
+				}
+				replacements = append(replacements, replacement{use, getReplacementText(use, argument, argumentText)})
+			}
+		}
+		slices.SortFunc(replacements, func(a, b replacement) int { return a.use.Pos() - b.use.Pos() })
+
+		var b strings.Builder
+		pos := start
+		for _, r := range replacements {
+			b.WriteString(file.Text()[pos:scanner.GetTokenPosOfNode(r.use, file, false /*includeJSDoc*/)])
+			b.WriteString(r.text)
+			pos = r.use.End()
+		}
+		b.WriteString(file.Text()[pos:info.expression.End()])
+
+		callStart := scanner.GetTokenPosOfNode(call, callFile, false /*includeJSDoc*/)
+		text := reindentText(b.String(), indentation, getLineIndentation(callFile, callStart))
+		if replaced != nil && needsParenthesesForReplacement(call, replaced) {
+			text = "(" + text + ")"
+		}
+		ct.replaceRangeWithText(callFile, *ct.ls.createLspRangeFromBounds(callStart, call.End(), callFile), text)
+	}
+	ct.deleteStatement(file, declaration)
+}
+
+// getReplacementText returns the text of expression, or of `undefined` if expression is nil, to be
+// written in place of reference: parenthesized if needed, and after the name of a shorthand
+// property assignment.
+func getReplacementText(reference *ast.Node, expression *ast.Node, text string) string {
+	if expression != nil && needsParenthesesForReplacement(reference, expression) {
+		text = "(" + text + ")"
+	}
+	if ast.IsShorthandPropertyAssignment(reference.Parent) {
+		text = reference.Text() + ": " + text
+	}
+	return text
+}
+
+// isDuplicableExpression returns whether evaluating expression has no side effects and gives the
+// same value each time, so that it can be written in place of several references. Object and
+// array literals, functions and regular expressions create a new object each time.
+func isDuplicableExpression(expression *ast.Node) bool {
+	switch expression.Kind {
+	case ast.KindIdentifier, ast.KindThisKeyword, ast.KindStringLiteral, ast.KindNumericLiteral, ast.KindBigIntLiteral,
+		ast.KindNoSubstitutionTemplateLiteral, ast.KindTrueKeyword, ast.KindFalseKeyword, ast.KindNullKeyword:
+		return true
+	case ast.KindPropertyAccessExpression, ast.KindParenthesizedExpression, ast.KindNonNullExpression, ast.KindAsExpression,
+		ast.KindSatisfiesExpression, ast.KindTypeAssertionExpression, ast.KindTypeOfExpression, ast.KindVoidExpression:
+		return isDuplicableExpression(expression.Expression())
+	case ast.KindElementAccessExpression:
+		return isDuplicableExpression(expression.Expression()) && isDuplicableExpression(expression.AsElementAccessExpression().ArgumentExpression)
+	case ast.KindPrefixUnaryExpression:
+		operator := expression.AsPrefixUnaryExpression().Operator
+		return operator != ast.KindPlusPlusToken && operator != ast.KindMinusMinusToken && isDuplicableExpression(expression.AsPrefixUnaryExpression().Operand)
+	case ast.KindBinaryExpression:
+		binary := expression.AsBinaryExpression()
+		return !ast.IsAssignmentOperator(binary.OperatorToken.Kind) && isDuplicableExpression(binary.Left) && isDuplicableExpression(binary.Right)
+	case ast.KindConditionalExpression:
+		conditional := expression.AsConditionalExpression()
+		return isDuplicableExpression(conditional.Condition) && isDuplicableExpression(conditional.WhenTrue) && isDuplicableExpression(conditional.WhenFalse)
+	case ast.KindTemplateExpression:
+		return core.Every(expression.AsTemplateExpression().TemplateSpans.Nodes, func(span *ast.Node) bool {
+			return isDuplicableExpression(span.Expression())
+		})
+	}
+	return false
+}
+
+// isEvaluatedOnceIn returns whether reference is evaluated once each time scope is, rather than
+// never or several times: it is not in a function or a loop of its own inside of scope.
+func isEvaluatedOnceIn(reference *ast.Node, scope *ast.Node) bool {
+	for node := reference.Parent; node != nil; node = node.Parent {
+		if ast.IsFunctionLike(node) || ast.IsClassLike(node) || ast.IsIterationStatement(node, false /*lookInLabeledStatements*/) {
+			return false
+		}
+		if containsNodeRange(node, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// needsParenthesesForReplacement returns whether expression must be parenthesized to be written in
+// place of reference, so that it is parsed as a whole and the code around it keeps its meaning.
+func needsParenthesesForReplacement(reference *ast.Node, expression *ast.Node) bool {
+	precedence := ast.GetExpressionPrecedence(expression)
+	parent := reference.Parent
+	if precedence == ast.OperatorPrecedenceComma {
+		return !ast.IsExpressionStatement(parent) && !ast.IsParenthesizedExpression(parent) && !ast.IsForStatement(parent) &&
+			!(ast.IsBinaryExpression(parent) && parent.AsBinaryExpression().OperatorToken.Kind == ast.KindCommaToken)
+	}
+	if startsStatementAmbiguously(reference, expression) {
+		return true
+	}
+	switch parent.Kind {
+	case ast.KindPropertyAccessExpression, ast.KindElementAccessExpression, ast.KindCallExpression, ast.KindNonNullExpression,
+		ast.KindExpressionWithTypeArguments, ast.KindTaggedTemplateExpression:
+		if ast.IsTaggedTemplateExpression(parent) && parent.AsTaggedTemplateExpression().Tag != reference ||
+			!ast.IsTaggedTemplateExpression(parent) && parent.Expression() != reference {
+			return false
+		}
+		// `a?.b` would short-circuit the rest of the chain, `new A` would take the arguments of a
+		// call, and the dot of `1.toString()` would be part of the number.
+		if ast.IsOptionalChain(expression) || ast.IsNewExpression(expression) && expression.AsNewExpression().Arguments == nil ||
+			ast.IsNumericLiteral(expression) && ast.IsPropertyAccessExpression(parent) {
+			return true
+		}
+		return !ast.IsLeftHandSideExpression(expression)
+	case ast.KindNewExpression:
+		if parent.Expression() != reference {
+			return false
+		}
+		// A call in the callee would take the arguments of the `new` expression.
+		return !ast.IsLeftHandSideExpression(expression) || ast.IsOptionalChain(expression) ||
+			ast.IsCallExpression(ast.GetLeftmostExpression(expression, true /*stopAtCallExpressions*/))
+	case ast.KindDecorator:
+		return !ast.IsIdentifier(expression)
+	case ast.KindPrefixUnaryExpression, ast.KindTypeOfExpression, ast.KindVoidExpression, ast.KindDeleteExpression,
+		ast.KindAwaitExpression, ast.KindTypeAssertionExpression:
+		if precedence < ast.OperatorPrecedenceUnary {
+			return true
+		}
+		// `-(-a)` must not become `--a`.
+		if ast.IsPrefixUnaryExpression(parent) && ast.IsPrefixUnaryExpression(expression) {
+			outer, inner := parent.AsPrefixUnaryExpression().Operator, expression.AsPrefixUnaryExpression().Operator
+			return outer == ast.KindMinusToken && (inner == ast.KindMinusToken || inner == ast.KindMinusMinusToken) ||
+				outer == ast.KindPlusToken && (inner == ast.KindPlusToken || inner == ast.KindPlusPlusToken)
+		}
+		return false
+	case ast.KindPostfixUnaryExpression:
+		return precedence < ast.OperatorPrecedenceLeftHandSide
+	case ast.KindBinaryExpression:
+		return needsParenthesesForBinaryOperand(parent, reference, expression, precedence)
+	case ast.KindConditionalExpression:
+		return parent.AsConditionalExpression().Condition == reference && precedence <= ast.OperatorPrecedenceConditional
+	case ast.KindAsExpression, ast.KindSatisfiesExpression:
+		return precedence < ast.OperatorPrecedenceRelational
+	}
+	return false
+}
+
+// needsParenthesesForBinaryOperand returns whether expression must be parenthesized to replace
+// reference as an operand of binary.
+func needsParenthesesForBinaryOperand(binary *ast.Node, reference *ast.Node, expression *ast.Node, precedence ast.OperatorPrecedence) bool {
+	operator := binary.AsBinaryExpression().OperatorToken.Kind
+	if ast.IsAssignmentOperator(operator) {
+		return false
+	}
+	isLeft := binary.AsBinaryExpression().Left == reference
+	binaryPrecedence := ast.GetExpressionPrecedence(binary)
+	switch {
+	case precedence < binaryPrecedence:
+		return true
+	case precedence > binaryPrecedence:
+		// `??` cannot be mixed with `||` or `&&`, and the left operand of `**` cannot be a unary
+		// expression.
+		if operator == ast.KindQuestionQuestionToken && ast.IsBinaryExpression(expression) {
+			inner := expression.AsBinaryExpression().OperatorToken.Kind
+			return inner == ast.KindBarBarToken || inner == ast.KindAmpersandAmpersandToken
+		}
+		return operator == ast.KindAsteriskAsteriskToken && isLeft && precedence == ast.OperatorPrecedenceUnary
+	}
+	// Operators of the same precedence associate to the left, except `**`.
+	if operator == ast.KindAsteriskAsteriskToken {
+		return isLeft
+	}
+	return !isLeft
+}
+
+// startsStatementAmbiguously returns whether expression written in place of reference would start
+// an expression statement with `{`, `function` or `class`, or the body of an arrow function with
+// `{`, which would be parsed as a block or a declaration.
+func startsStatementAmbiguously(reference *ast.Node, expression *ast.Node) bool {
+	leftmost := ast.GetLeftmostExpression(expression, false /*stopAtCallExpressions*/)
+	if !ast.IsObjectLiteralExpression(leftmost) && !ast.IsFunctionExpression(leftmost) && !ast.IsClassExpression(leftmost) {
+		return false
+	}
+	node := getOutermostExpressionStartingWith(reference)
+	return ast.IsExpressionStatement(node.Parent) ||
+		ast.IsArrowFunction(node.Parent) && node.Parent.Body() == node && ast.IsObjectLiteralExpression(leftmost)
+}
+
+// getOutermostExpressionStartingWith returns the outermost expression whose leftmost expression is
+// node, such as `a.b + c` for `a` in `a.b + c;`, or node itself.
+func getOutermostExpressionStartingWith(node *ast.Node) *ast.Node {
+	outermost := node
+	for outermost.Parent != nil && ast.GetLeftmostExpression(outermost.Parent, false /*stopAtCallExpressions*/) == node {
+		outermost = outermost.Parent
+	}
+	return outermost
+}
+
+// usesFunctionContext returns whether expression uses `this`, `super`, `arguments` or `new.target`,
+// which would refer to those of another function once it is inlined.
+func usesFunctionContext(expression *ast.Node) bool {
+	var visit func(node *ast.Node) bool
+	visit = func(node *ast.Node) bool {
+		switch node.Kind {
+		case ast.KindThisKeyword, ast.KindSuperKeyword:
+			return true
+		case ast.KindIdentifier:
+			return node.Text() == "arguments" && !isRightSideOfPropertyAccess(node)
+		case ast.KindMetaProperty:
+			return node.AsMetaProperty().KeywordToken == ast.KindNewKeyword
+		}
+		return node.ForEachChild(visit)
+	}
+	return visit(expression)
+}
+
+// forEachReferenceIdentifier calls cb with each identifier in node that refers to a value by its
+// name: not a property name, a declaration name or part of a type.
+func forEachReferenceIdentifier(node *ast.Node, cb func(identifier *ast.Node)) {
+	var visit func(node *ast.Node) bool
+	visit = func(node *ast.Node) bool {
+		if ast.IsIdentifier(node) {
+			if ast.IsShorthandPropertyAssignment(node.Parent) ||
+				!isRightSideOfPropertyAccess(node) && !ast.IsDeclarationName(node) && !ast.IsPartOfTypeNode(node) && !ast.IsJsxAttribute(node.Parent) {
+				cb(node)
+			}
+			return false
+		}
+		node.ForEachChild(visit)
+		return false
+	}
+	visit(node)
+}
+
+func getSymbolOfReferenceIdentifier(ch *checker.Checker, identifier *ast.Node) *ast.Symbol {
+	if ast.IsShorthandPropertyAssignment(identifier.Parent) {
+		return ch.GetShorthandAssignmentValueSymbol(identifier.Parent)
+	}
+	return ch.GetSymbolAtLocation(identifier)
+}
+
+// resolvesSameAt returns whether the names in expression, but those skip returns true for and
+// those declared in expression itself, would refer to the same declarations written at location.
+func resolvesSameAt(ch *checker.Checker, expression *ast.Node, location *ast.Node, skip func(identifier *ast.Node) bool) bool {
+	same := true
+	forEachReferenceIdentifier(expression, func(identifier *ast.Node) {
+		if !same || skip != nil && skip(identifier) {
+			return
+		}
+		symbol := getSymbolOfReferenceIdentifier(ch, identifier)
+		if symbol == nil || core.Some(symbol.Declarations, func(d *ast.Node) bool { return containsNodeRange(expression, d) }) {
+			return
+		}
+		resolved := ch.ResolveName(identifier.Text(), location, ast.SymbolFlagsValue, false /*excludeGlobals*/)
+		same = resolved != nil && ch.GetExportSymbolOfSymbol(resolved) == ch.GetExportSymbolOfSymbol(symbol)
+	})
+	return same
+}
+
+// isCapturedAt returns whether a name in argument would refer to a declaration in body, such as a
+// parameter of a function in it, if argument were written in place of use.
+func isCapturedAt(ch *checker.Checker, argument *ast.Node, use *ast.Node, body *ast.Node) bool {
+	captured := false
+	forEachReferenceIdentifier(argument, func(identifier *ast.Node) {
+		if resolved := ch.ResolveName(identifier.Text(), use, ast.SymbolFlagsValue, false /*excludeGlobals*/); resolved != nil {
+			captured = captured || core.Some(resolved.Declarations, func(d *ast.Node) bool { return containsNodeRange(body, d) })
+		}
+	})
+	return captured
+}
@@ -0,0 +1,512 @@
+package ls
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/checker"
+	"github.com/microsoft/typescript-go/internal/collections"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/modulespecifiers"
+	"github.com/microsoft/typescript-go/internal/scanner"
+	"github.com/microsoft/typescript-go/internal/tspath"
+)
+
+const (
+	refactorNameMoveToNewFile   = "Move to a new file"
+	refactorActionMoveToNewFile = "Move to a new file"
+)
+
+var moveToNewFileRefactorProvider = &refactorProvider{
+	name:                refactorNameMoveToNewFile,
+	description:         "Move to a new file",
+	getAvailableActions: getMoveToNewFileActions,
+	getEditsForAction:   getMoveToNewFileEdits,
+}
+
+func getMoveToNewFileActions(c *refactorContext) []*RefactorAction {
+	// Moving is only offered for a selection, as it applies to any declaration.
+	if c.span.Len() == 0 || getStatementsToMove(c) == nil {
+		return nil
+	}
+	return []*RefactorAction{{
+		Name:        refactorActionMoveToNewFile,
+		Description: "Move to a new file",
+		Kind:        "refactor.move.newFile",
+	}}
+}
+
+func getMoveToNewFileEdits(c *refactorContext, actionName string) *lsproto.WorkspaceEdit {
+	statements := getStatementsToMove(c)
+	if actionName != refactorActionMoveToNewFile || statements == nil {
+		return nil
+	}
+	return c.getMoveToFileEdits(statements, c.getNewFileNameForMove(statements))
+}
+
+// GetMoveToFileEdits returns the edits that move the top-level declarations overlapping textRange
+// to a new file named targetFileName. The new file gets the moved declarations, with the imports
+// of the source file they use and an import of the declarations of the source file they reference,
+// which are exported if they were not. The moved declarations are removed from the source file,
+// which imports those it still uses from the new file, and named imports of moved declarations in
+// other files are changed to import them from the new file. The edit for the new file inserts its
+// whole content at its start; the file has to be created by the client. It fails with
+// ErrRefactorNotApplicable if the range overlaps statements other than declarations, such as
+// imports, and with ErrFileAlreadyExists if the target file exists.
+func (l *LanguageService) GetMoveToFileEdits(ctx context.Context, fileName string, textRange core.TextRange, targetFileName string) (*lsproto.WorkspaceEdit, error) {
+	c, done, err := l.newRefactorContext(ctx, fileName, textRange)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	statements := getStatementsToMove(c)
+	if statements == nil {
+		return nil, fmt.Errorf("%w: %s/%s", ErrRefactorNotApplicable, refactorNameMoveToNewFile, refactorActionMoveToNewFile)
+	}
+	if l.fileExists(targetFileName) {
+		return nil, fmt.Errorf("%w: %s", ErrFileAlreadyExists, targetFileName)
+	}
+	return c.getMoveToFileEdits(statements, targetFileName), nil
+}
+
+func (l *LanguageService) fileExists(fileName string) bool {
+	if l.GetProgram().GetSourceFile(fileName) != nil {
+		return true
+	}
+	_, ok := l.ReadFile(fileName)
+	return ok
+}
+
+// getStatementsToMove returns the top-level statements overlapping the span, or nil if one of them
+// cannot be moved to another file.
+func getStatementsToMove(c *refactorContext) []*ast.Node {
+	var statements []*ast.Node
+	for _, statement := range c.sourceFile.Statements.Nodes {
+		start := scanner.GetTokenPosOfNode(statement, c.sourceFile, false /*includeJSDoc*/)
+		if c.span.Len() == 0 && (c.span.Pos() < start || c.span.Pos() > statement.End()) ||
+			c.span.Len() != 0 && (c.span.Pos() >= statement.End() || c.span.End() <= start) {
+			continue
+		}
+		if !isMovableStatement(statement) {
+			return nil
+		}
+		statements = append(statements, statement)
+	}
+	return statements
+}
+
+// isMovableStatement reports whether statement is a declaration that can be moved to another module
+// without changing the way it is imported.
+func isMovableStatement(statement *ast.Node) bool {
+	switch statement.Kind {
+	case ast.KindFunctionDeclaration, ast.KindClassDeclaration:
+		return statement.Name() != nil && !ast.HasSyntacticModifier(statement, ast.ModifierFlagsDefault)
+	case ast.KindInterfaceDeclaration, ast.KindTypeAliasDeclaration, ast.KindEnumDeclaration, ast.KindVariableStatement:
+		return true
+	case ast.KindModuleDeclaration:
+		return !ast.IsAmbientModule(statement)
+	}
+	return false
+}
+
+// getTopLevelStatementOfDeclaration returns the statement of its file that declares declaration, if
+// declaration is one of the top-level declarations of its file.
+func getTopLevelStatementOfDeclaration(declaration *ast.Node) *ast.Node {
+	switch declaration.Kind {
+	case ast.KindBindingElement, ast.KindVariableDeclaration:
+		declaration = ast.GetRootDeclaration(declaration)
+		if !ast.IsVariableDeclaration(declaration) || !ast.IsVariableDeclarationList(declaration.Parent) {
+			return nil
+		}
+		declaration = declaration.Parent.Parent
+	}
+	if declaration.Parent != nil && ast.IsSourceFile(declaration.Parent) {
+		return declaration
+	}
+	return nil
+}
+
+// getMoveReferencedSymbol returns the symbol an identifier refers to as a value or type.
+func getMoveReferencedSymbol(c *checker.Checker, identifier *ast.Node) *ast.Symbol {
+	if ast.IsShorthandPropertyAssignment(identifier.Parent) && identifier.Parent.Name() == identifier {
+		return c.GetShorthandAssignmentValueSymbol(identifier.Parent)
+	}
+	return c.GetSymbolAtLocation(identifier)
+}
+
+func forEachIdentifier(node *ast.Node, fn func(identifier *ast.Node)) {
+	var visit func(node *ast.Node) bool
+	visit = func(node *ast.Node) bool {
+		if ast.IsIdentifier(node) {
+			fn(node)
+		}
+		return node.ForEachChild(visit)
+	}
+	visit(node)
+}
+
+// getNewFileNameForMove returns the name of a file that does not exist yet next to the source file,
+// named after the first declaration moved.
+func (c *refactorContext) getNewFileNameForMove(statements []*ast.Node) string {
+	name := "newFile"
+	for _, statement := range statements {
+		if ast.IsVariableStatement(statement) {
+			declaration := statement.AsVariableStatement().DeclarationList.AsVariableDeclarationList().Declarations.Nodes[0]
+			if ast.IsIdentifier(declaration.Name()) {
+				name = declaration.Name().Text()
+				break
+			}
+		} else if ast.IsIdentifier(statement.Name()) {
+			name = statement.Name().Text()
+			break
+		}
+	}
+	fileName := c.sourceFile.FileName()
+	extension := tspath.TryGetExtensionFromPath(fileName)
+	directory := tspath.GetDirectoryPath(fileName)
+	newFileName := tspath.CombinePaths(directory, name+extension)
+	for i := 1; c.ls.fileExists(newFileName); i++ {
+		newFileName = tspath.CombinePaths(directory, fmt.Sprintf("%s.%d%s", name, i, extension))
+	}
+	return newFileName
+}
+
+func (c *refactorContext) getMoveToFileEdits(statements []*ast.Node, newFileName string) *lsproto.WorkspaceEdit {
+	file := c.sourceFile
+	moved := collections.NewSetFromItems(statements...)
+	isMoved := func(symbol *ast.Symbol) bool {
+		return symbol != nil && core.Some(symbol.Declarations, func(declaration *ast.Node) bool {
+			return moved.Has(getTopLevelStatementOfDeclaration(declaration))
+		})
+	}
+	// getSourceStatement returns the statement of the source file that is not moved and declares symbol.
+	getSourceStatement := func(symbol *ast.Symbol) *ast.Node {
+		if symbol == nil {
+			return nil
+		}
+		for _, declaration := range symbol.Declarations {
+			if statement := getTopLevelStatementOfDeclaration(declaration); statement != nil && statement.Parent == file.AsNode() && !moved.Has(statement) {
+				return statement
+			}
+		}
+		return nil
+	}
+	isExternalModule := ast.IsExternalModule(file)
+	semicolon := ""
+	if probablyUsesSemicolons(file) {
+		semicolon = ";"
+	}
+	preferences := c.ls.getUserPreferences()
+
+	// The bindings of the imports of the source file used by the moved code, and the declarations of
+	// the source file it references.
+	usedImportBindings := map[*ast.Node]*collections.OrderedSet[*ast.Node]{}
+	var referencedStatements collections.OrderedSet[*ast.Node]
+	referencedNames := map[*ast.Node][]*ast.Symbol{}
+	for _, statement := range statements {
+		forEachIdentifier(statement, func(identifier *ast.Node) {
+			symbol := getMoveReferencedSymbol(c.checker, identifier)
+			if symbol == nil || len(symbol.Declarations) == 0 || isMoved(symbol) {
+				return
+			}
+			declaration := symbol.Declarations[0]
+			if symbol.Flags&ast.SymbolFlagsAlias != 0 && ast.GetSourceFileOfNode(declaration) == file {
+				if importDeclaration := ast.FindAncestor(declaration, func(node *ast.Node) bool {
+					return ast.IsImportDeclaration(node) || ast.IsImportEqualsDeclaration(node)
+				}); importDeclaration != nil {
+					if usedImportBindings[importDeclaration] == nil {
+						usedImportBindings[importDeclaration] = &collections.OrderedSet[*ast.Node]{}
+					}
+					usedImportBindings[importDeclaration].Add(declaration)
+				}
+				return
+			}
+			if sourceStatement := getSourceStatement(symbol); sourceStatement != nil && isExternalModule {
+				referencedStatements.Add(sourceStatement)
+				if !slices.Contains(referencedNames[sourceStatement], symbol) {
+					referencedNames[sourceStatement] = append(referencedNames[sourceStatement], symbol)
+				}
+			}
+		})
+	}
+
+	// The moved declarations the source file still uses.
+	var usedMovedSymbols collections.OrderedSet[*ast.Symbol]
+	if isExternalModule {
+		for _, statement := range file.Statements.Nodes {
+			if moved.Has(statement) || ast.IsImportDeclaration(statement) || ast.IsImportEqualsDeclaration(statement) {
+				continue
+			}
+			forEachIdentifier(statement, func(identifier *ast.Node) {
+				if symbol := getMoveReferencedSymbol(c.checker, identifier); isMoved(symbol) {
+					usedMovedSymbols.Add(symbol)
+				}
+			})
+		}
+	}
+
+	ct := c.ls.newChangeTracker(c.ctx)
+	var newFileText strings.Builder
+	if isExternalModule {
+		for _, statement := range file.Statements.Nodes {
+			if bindings := usedImportBindings[statement]; bindings != nil {
+				newFileText.WriteString(c.getImportTextForMovedCode(statement, bindings, newFileName, semicolon))
+				newFileText.WriteString(ct.newLine)
+			}
+		}
+		if referencedStatements.Size() != 0 {
+			var defaultName string
+			var names []string
+			for statement := range referencedStatements.Values() {
+				for _, symbol := range referencedNames[statement] {
+					switch {
+					case ast.HasSyntacticModifier(statement, ast.ModifierFlagsDefault):
+						defaultName = symbol.Name
+					case symbol.Flags&ast.SymbolFlagsValue == 0:
+						names = append(names, "type "+symbol.Name)
+					default:
+						names = append(names, symbol.Name)
+					}
+				}
+				if !ast.HasSyntacticModifier(statement, ast.ModifierFlagsExport) {
+					ct.insertText(file, ct.ls.createLspPosition(scanner.GetTokenPosOfNode(statement, file, false /*includeJSDoc*/), file), "export ")
+				}
+			}
+			specifier := c.getModuleSpecifierForMove(newFileName, file.FileName())
+			newFileText.WriteString(getImportText(defaultName, names, quote(file, preferences, specifier), semicolon))
+			newFileText.WriteString(ct.newLine)
+		}
+		if newFileText.Len() != 0 {
+			newFileText.WriteString(ct.newLine)
+		}
+	}
+
+	// The moved code, with the declarations the source file still uses exported.
+	first, last := statements[0], statements[len(statements)-1]
+	start := ct.getAdjustedStartPosition(file, first, leadingTriviaOptionIncludeAll, false /*hasTrailingComment*/)
+	end := ct.getAdjustedEndPosition(file, last, trailingTriviaOptionInclude)
+	text := file.Text()[start:last.End()]
+	var exportPositions []int
+	for _, statement := range statements {
+		if ast.HasSyntacticModifier(statement, ast.ModifierFlagsExport) {
+			continue
+		}
+		for symbol := range usedMovedSymbols.Values() {
+			if core.Some(symbol.Declarations, func(declaration *ast.Node) bool { return getTopLevelStatementOfDeclaration(declaration) == statement }) {
+				exportPositions = append(exportPositions, scanner.GetTokenPosOfNode(statement, file, false /*includeJSDoc*/)-start)
+				break
+			}
+		}
+	}
+	for _, position := range slices.Backward(exportPositions) {
+		text = text[:position] + "export " + text[position:]
+	}
+	newFileText.WriteString(text)
+	newFileText.WriteString(ct.newLine)
+
+	// The source file imports the moved declarations it still uses, in place of the last import or
+	// at its top.
+	var importText string
+	if usedMovedSymbols.Size() != 0 {
+		var names []string
+		for symbol := range usedMovedSymbols.Values() {
+			if symbol.Flags&ast.SymbolFlagsValue == 0 {
+				names = append(names, "type "+symbol.Name)
+			} else {
+				names = append(names, symbol.Name)
+			}
+		}
+		specifier := c.getModuleSpecifierForMove(file.FileName(), newFileName)
+		importText = getImportText("", names, quote(file, preferences, specifier), semicolon)
+	}
+	insertPosition := ct.getInsertionPositionAtSourceFileTop(file)
+	lastImport := core.FindLast(file.Statements.Nodes, func(statement *ast.Node) bool {
+		return ast.IsImportDeclaration(statement) || ast.IsImportEqualsDeclaration(statement)
+	})
+	if lastImport != nil {
+		insertPosition = lastImport.End()
+	}
+	switch {
+	case importText == "":
+		ct.deleteRange(file, core.NewTextRange(start, end))
+	case start <= insertPosition && insertPosition <= end:
+		ct.replaceRangeWithText(file, *ct.ls.createLspRangeFromBounds(start, end, file), importText+ct.newLine)
+	case lastImport != nil:
+		ct.insertText(file, ct.ls.createLspPosition(insertPosition, file), ct.newLine+importText)
+		ct.deleteRange(file, core.NewTextRange(start, end))
+	default:
+		ct.insertText(file, ct.ls.createLspPosition(insertPosition, file), importText+ct.newLine)
+		ct.deleteRange(file, core.NewTextRange(start, end))
+	}
+
+	if isExternalModule {
+		c.updateImportsOfMovedDeclarations(ct, isMoved, newFileName)
+	}
+
+	edit := ct.getWorkspaceEdit()
+	(*edit.Changes)[FileNameToDocumentURI(newFileName)] = []*lsproto.TextEdit{{
+		Range:   lsproto.Range{},
+		NewText: newFileText.String(),
+	}}
+	return edit
+}
+
+// getImportTextForMovedCode returns an import of the bindings of an import of the source file, from
+// the new file.
+func (c *refactorContext) getImportTextForMovedCode(statement *ast.Node, bindings *collections.OrderedSet[*ast.Node], newFileName string, semicolon string) string {
+	preferences := c.ls.getUserPreferences()
+	specifierNode := statement.ModuleSpecifier()
+	if ast.IsImportEqualsDeclaration(statement) {
+		specifierNode = nil
+		if ast.IsExternalModuleImportEqualsDeclaration(statement) {
+			specifierNode = ast.GetExternalModuleImportEqualsDeclarationExpression(statement)
+		}
+	}
+	if specifierNode == nil || !ast.IsStringLiteralLike(specifierNode) {
+		return scanner.GetTextOfNode(statement)
+	}
+	specifier := specifierNode.Text()
+	if updated := c.ls.getUpdatedModuleSpecifier(c.program, c.sourceFile, newFileName, specifierNode, "", newFileName, true /*isMovedFile*/); updated != "" {
+		specifier = updated
+	}
+	if ast.IsImportEqualsDeclaration(statement) {
+		return fmt.Sprintf("import %s = require(%s)%s", statement.Name().Text(), quote(c.sourceFile, preferences, specifier), semicolon)
+	}
+
+	importClause := statement.ImportClause()
+	var defaultName string
+	var names []string
+	var namespaceName string
+	for binding := range bindings.Values() {
+		switch binding.Kind {
+		case ast.KindImportClause:
+			defaultName = binding.Name().Text()
+		case ast.KindNamespaceImport:
+			namespaceName = binding.Name().Text()
+		case ast.KindImportSpecifier:
+			names = append(names, scanner.GetTextOfNode(binding))
+		}
+	}
+	var text strings.Builder
+	text.WriteString("import ")
+	if importClause.IsTypeOnly() {
+		text.WriteString("type ")
+	}
+	if namespaceName != "" {
+		if defaultName != "" {
+			text.WriteString(defaultName + ", ")
+		}
+		text.WriteString("* as " + namespaceName + " from " + quote(c.sourceFile, preferences, specifier))
+	} else {
+		// Only the specifier is kept from the text of the import.
+		text.WriteString(strings.TrimPrefix(getImportText(defaultName, names, quote(c.sourceFile, preferences, specifier), ""), "import "))
+	}
+	if attributes := statement.AsImportDeclaration().Attributes; attributes != nil {
+		text.WriteString(" " + scanner.GetTextOfNode(attributes))
+	}
+	text.WriteString(semicolon)
+	return text.String()
+}
+
+// getImportText returns an import declaration of a default import and named imports.
+func getImportText(defaultName string, names []string, quotedSpecifier string, semicolon string) string {
+	var bindings []string
+	if defaultName != "" {
+		bindings = append(bindings, defaultName)
+	}
+	if len(names) != 0 {
+		bindings = append(bindings, "{ "+strings.Join(names, ", ")+" }")
+	}
+	return "import " + strings.Join(bindings, ", ") + " from " + quotedSpecifier + semicolon
+}
+
+// getModuleSpecifierForMove returns the specifier importing toFileName from importingFileName.
+func (c *refactorContext) getModuleSpecifierForMove(importingFileName string, toFileName string) string {
+	return modulespecifiers.GetModuleSpecifier(
+		c.program.Options(),
+		c.program,
+		c.sourceFile,
+		importingFileName,
+		"",
+		toFileName,
+		modulespecifiers.ModuleSpecifierOptions{},
+	)
+}
+
+// updateImportsOfMovedDeclarations changes the named imports and re-exports of moved declarations
+// in the other files of the program to import them from the new file.
+func (c *refactorContext) updateImportsOfMovedDeclarations(ct *changeTracker, isMoved func(symbol *ast.Symbol) bool, newFileName string) {
+	preferences := c.ls.getUserPreferences()
+	for _, importingFile := range c.program.GetSourceFiles() {
+		if importingFile == c.sourceFile || c.program.IsSourceFileDefaultLibrary(importingFile.Path()) || c.program.IsSourceFileFromExternalLibrary(importingFile) {
+			continue
+		}
+		for _, specifier := range importingFile.Imports() {
+			declaration := specifier.Parent
+			if !ast.IsImportDeclaration(declaration) && !ast.IsExportDeclaration(declaration) {
+				continue
+			}
+			resolved := c.program.GetResolvedModuleFromModuleSpecifier(importingFile, specifier)
+			if resolved == nil || !resolved.IsResolved() || toPath(c.program, resolved.ResolvedFileName) != c.sourceFile.Path() {
+				continue
+			}
+			var namedBindings *ast.Node
+			hasOtherBindings := false
+			if ast.IsImportDeclaration(declaration) {
+				if importClause := declaration.ImportClause(); importClause != nil {
+					namedBindings = importClause.AsImportClause().NamedBindings
+					hasOtherBindings = importClause.Name() != nil
+				}
+			} else {
+				namedBindings = declaration.AsExportDeclaration().ExportClause
+			}
+			if namedBindings == nil || !ast.IsNamedImports(namedBindings) && !ast.IsNamedExports(namedBindings) {
+				continue
+			}
+			elements := namedBindings.Elements()
+			movedElements := core.Filter(elements, func(element *ast.Node) bool {
+				return element.Symbol() != nil && isMoved(c.checker.GetImmediateAliasedSymbol(element.Symbol()))
+			})
+			if len(movedElements) == 0 {
+				continue
+			}
+			newSpecifier := modulespecifiers.GetModuleSpecifier(
+				c.program.Options(),
+				c.program,
+				importingFile,
+				importingFile.FileName(),
+				specifier.Text(),
+				newFileName,
+				modulespecifiers.ModuleSpecifierOptions{},
+			)
+			if len(movedElements) == len(elements) && !hasOtherBindings {
+				// Replace the contents of the string literal, keeping its quotes.
+				specifierStart := scanner.GetTokenPosOfNode(specifier, importingFile, false /*includeJSDoc*/) + 1
+				ct.replaceRangeWithText(importingFile, *ct.ls.createLspRangeFromBounds(specifierStart, specifier.End()-1, importingFile), newSpecifier)
+				continue
+			}
+			names := core.Map(movedElements, scanner.GetTextOfNode)
+			for _, element := range movedElements {
+				ct.deleteNodeInList(importingFile, element)
+			}
+			keyword := "import "
+			if ast.IsExportDeclaration(declaration) {
+				keyword = "export "
+			}
+			if ast.IsExportDeclaration(declaration) && declaration.IsTypeOnly() || ast.IsImportDeclaration(declaration) && declaration.ImportClause().IsTypeOnly() {
+				keyword += "type "
+			}
+			semicolon := ""
+			if probablyUsesSemicolons(importingFile) {
+				semicolon = ";"
+			}
+			text := keyword + "{ " + strings.Join(names, ", ") + " } from " + quote(importingFile, preferences, newSpecifier) + semicolon
+			ct.insertText(importingFile, ct.ls.createLspPosition(declaration.End(), importingFile), ct.newLine+text)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package ls
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+	"github.com/microsoft/typescript-go/internal/checker"
+	"github.com/microsoft/typescript-go/internal/compiler"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+)
+
+// ApplicableRefactor is a refactoring available for a range, with the actions it offers.
+type ApplicableRefactor struct {
+	// Name identifying the refactor, passed back to GetRefactorEdits.
+	Name string `json:"name"`
+	// Human-readable description of the refactor.
+	Description string            `json:"description"`
+	Actions     []*RefactorAction `json:"actions"`
+}
+
+type RefactorAction struct {
+	// Name identifying the action within its refactor, passed back to GetRefactorEdits.
+	Name string `json:"name"`
+	// Human-readable description of the action.
+	Description string `json:"description"`
+	// The LSP code action kind of the action, e.g. "refactor.rewrite.import".
+	Kind string `json:"kind"`
+	// If not empty, the reason the action cannot be applied to the range. GetRefactorEdits fails
+	// for such actions.
+	NotApplicableReason string `json:"notApplicableReason,omitempty"`
+}
+
+type RefactorEditInfo struct {
+	// Text edits to apply, keyed by document, each in reverse document order.
+	Edits *lsproto.WorkspaceEdit `json:"edits"`
+	// If set, where to place the cursor once the edits are applied, at a placeholder the user is
+	// expected to fill in.
+	CursorPosition *lsproto.Position `json:"cursorPosition,omitempty"`
+}
+
+type refactorContext struct {
+	ctx        context.Context
+	ls         *LanguageService
+	program    *compiler.Program
+	checker    *checker.Checker
+	sourceFile *ast.SourceFile
+	span       core.TextRange
+	// cursorPosition is set by getEditsForAction for edits leaving a placeholder to fill in.
+	cursorPosition *lsproto.Position
+}
+
+// startToken returns the token at the start of the span.
+func (c *refactorContext) startToken() *ast.Node {
+	return astnav.GetTokenAtPosition(c.sourceFile, c.span.Pos())
+}
+
+// findContainingNode returns the innermost node for which predicate returns true that contains the
+// token at the start of the span and extends to the end of the span, or nil if there is none.
+func (c *refactorContext) findContainingNode(predicate func(node *ast.Node) bool) *ast.Node {
+	for node := c.startToken(); node != nil; node = node.Parent {
+		if node.End() >= c.span.End() && predicate(node) {
+			return node
+		}
+	}
+	return nil
+}
+
+// topLevelStatement returns the statement of the source file containing the span, or nil if the span
+// extends over several statements.
+func (c *refactorContext) topLevelStatement() *ast.Node {
+	return c.findContainingNode(func(node *ast.Node) bool {
+		return node.Parent != nil && ast.IsSourceFile(node.Parent)
+	})
+}
+
+type refactorProvider struct {
+	name        string
+	description string
+	// getAvailableActions returns the actions of the refactor that apply to the span.
+	getAvailableActions func(c *refactorContext) []*RefactorAction
+	// getEditsForAction returns the edits of the action, or nil if the action does not apply to the span.
+	getEditsForAction func(c *refactorContext, actionName string) *lsproto.WorkspaceEdit
+}
+
+var refactorProviders = []*refactorProvider{
+	addOrRemoveBracesRefactorProvider,
+	convertArrowFunctionOrFunctionExpressionRefactorProvider,
+	convertExportRefactorProvider,
+	convertModuleSyntaxRefactorProvider,
+	convertParametersToDestructuredObjectRefactorProvider,
+	convertStringOrTemplateLiteralRefactorProvider,
+	convertTypeOnlyImportRefactorProvider,
+	extractSymbolRefactorProvider,
+	generateAccessorsRefactorProvider,
+	generateConstructorRefactorProvider,
+	inferReturnTypeRefactorProvider,
+	inlineRefactorProvider,
+	moveToNewFileRefactorProvider,
+	splitOrMergeVariableDeclarationsRefactorProvider,
+	surroundWithStatementRefactorProvider,
+}
+
+// GetRefactors returns the refactors that can be applied to the given range, each with the
+// actions available for it.
+func (l *LanguageService) GetRefactors(ctx context.Context, fileName string, textRange core.TextRange) ([]*ApplicableRefactor, error) {
+	c, done, err := l.newRefactorContext(ctx, fileName, textRange)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	var refactors []*ApplicableRefactor
+	for _, provider := range refactorProviders {
+		if actions := provider.getAvailableActions(c); len(actions) > 0 {
+			refactors = append(refactors, &ApplicableRefactor{
+				Name:        provider.name,
+				Description: provider.description,
+				Actions:     actions,
+			})
+		}
+	}
+	return refactors, nil
+}
+
+// GetRefactorEdits returns the edits of an action returned by GetRefactors for the same range.
+func (l *LanguageService) GetRefactorEdits(ctx context.Context, fileName string, textRange core.TextRange, refactorName string, actionName string) (*RefactorEditInfo, error) {
+	c, done, err := l.newRefactorContext(ctx, fileName, textRange)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	for _, provider := range refactorProviders {
+		if provider.name != refactorName {
+			continue
+		}
+		edits := provider.getEditsForAction(c, actionName)
+		if edits == nil {
+			return nil, fmt.Errorf("%w: %s/%s", ErrRefactorNotApplicable, refactorName, actionName)
+		}
+		return &RefactorEditInfo{Edits: edits, CursorPosition: c.cursorPosition}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownRefactor, refactorName)
+}
+
+func (l *LanguageService) newRefactorContext(ctx context.Context, fileName string, textRange core.TextRange) (*refactorContext, func(), error) {
+	program, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	checker, done := program.GetTypeCheckerForFile(ctx, file)
+	return &refactorContext{
+		ctx:        ctx,
+		ls:         l,
+		program:    program,
+		checker:    checker,
+		sourceFile: file,
+		span:       textRange,
+	}, done, nil
+}
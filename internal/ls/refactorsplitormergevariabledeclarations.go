@@ -0,0 +1,255 @@
+package ls
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/scanner"
+)
+
+const (
+	refactorNameSplitOrMergeVariableDeclarations = "Split or merge variable declarations"
+
+	refactorActionSplitVariableDeclarations = "Split into separate declarations"
+	refactorActionMergeVariableDeclarations = "Merge into one declaration"
+)
+
+var splitOrMergeVariableDeclarationsRefactorProvider = &refactorProvider{
+	name:                refactorNameSplitOrMergeVariableDeclarations,
+	description:         "Split or merge variable declarations",
+	getAvailableActions: getSplitOrMergeVariableDeclarationsActions,
+	getEditsForAction:   getSplitOrMergeVariableDeclarationsEdits,
+}
+
+func getSplitOrMergeVariableDeclarationsActions(c *refactorContext) []*RefactorAction {
+	var actions []*RefactorAction
+	if list, reason := getSplittableVariableDeclarationList(c); list != nil {
+		actions = append(actions, &RefactorAction{
+			Name:                refactorActionSplitVariableDeclarations,
+			Description:         refactorActionSplitVariableDeclarations,
+			Kind:                "refactor.rewrite.variable.split",
+			NotApplicableReason: reason,
+		})
+	}
+	if statements := getMergeableVariableStatements(c); statements != nil {
+		actions = append(actions, &RefactorAction{
+			Name:        refactorActionMergeVariableDeclarations,
+			Description: refactorActionMergeVariableDeclarations,
+			Kind:        "refactor.rewrite.variable.merge",
+		})
+	}
+	return actions
+}
+
+func getSplitOrMergeVariableDeclarationsEdits(c *refactorContext, actionName string) *lsproto.WorkspaceEdit {
+	ct := c.ls.newChangeTracker(c.ctx)
+	switch actionName {
+	case refactorActionSplitVariableDeclarations:
+		list, reason := getSplittableVariableDeclarationList(c)
+		if list == nil || reason != "" {
+			return nil
+		}
+		ct.splitVariableStatement(c.sourceFile, list.Parent)
+	case refactorActionMergeVariableDeclarations:
+		statements := getMergeableVariableStatements(c)
+		if statements == nil {
+			return nil
+		}
+		ct.mergeVariableStatements(c.sourceFile, statements)
+	default:
+		return nil
+	}
+	return ct.getWorkspaceEdit()
+}
+
+// getSplittableVariableDeclarationList returns the innermost declaration list containing the span if
+// it declares several variables, with the reason it cannot be split if it is not a statement of a
+// statement list: the declarations of a for loop initializer are scoped to the loop, and a
+// statement that is the body of another could not be replaced by several.
+func getSplittableVariableDeclarationList(c *refactorContext) (*ast.Node, string) {
+	list := c.findContainingNode(func(node *ast.Node) bool {
+		return ast.IsVariableDeclarationList(node) || ast.IsFunctionLike(node) || ast.IsClassLike(node)
+	})
+	if list == nil || !ast.IsVariableDeclarationList(list) || len(list.AsVariableDeclarationList().Declarations.Nodes) < 2 {
+		return nil, ""
+	}
+	switch {
+	case ast.IsForStatement(list.Parent):
+		return list, "Cannot split the declarations of a for loop initializer."
+	case ast.IsVariableStatement(list.Parent) && getSiblingStatements(list.Parent) != nil:
+		return list, ""
+	}
+	return nil, ""
+}
+
+// getMergeableVariableStatements returns the variable statements the span extends over, or the
+// variable statement containing the span and the one following it, if they are adjacent
+// statements of the same kind with the same modifiers. It returns nil otherwise.
+func getMergeableVariableStatements(c *refactorContext) []*ast.Node {
+	var first *ast.Node
+	for node := c.startToken(); node != nil && first == nil; node = node.Parent {
+		switch {
+		case ast.IsVariableStatement(node):
+			first = node
+		case ast.IsFunctionLike(node) || ast.IsClassLike(node):
+			return nil
+		}
+	}
+	if first == nil {
+		return nil
+	}
+	siblings := getSiblingStatements(first)
+	if siblings == nil {
+		return nil
+	}
+	statements := []*ast.Node{first}
+	for _, statement := range siblings[slices.Index(siblings, first)+1:] {
+		if len(statements) > 1 && scanner.GetTokenPosOfNode(statement, c.sourceFile, false /*includeJSDoc*/) >= c.span.End() {
+			break
+		}
+		if !isMergeableVariableStatement(first, statement) {
+			return nil
+		}
+		statements = append(statements, statement)
+	}
+	if len(statements) < 2 {
+		return nil
+	}
+	return statements
+}
+
+// isMergeableVariableStatement returns whether statement declares variables the same way as first,
+// with the same keyword and modifiers.
+func isMergeableVariableStatement(first *ast.Node, statement *ast.Node) bool {
+	return ast.IsVariableStatement(statement) &&
+		first.AsVariableStatement().DeclarationList.Flags&ast.NodeFlagsBlockScoped == statement.AsVariableStatement().DeclarationList.Flags&ast.NodeFlagsBlockScoped &&
+		first.ModifierFlags() == statement.ModifierFlags()
+}
+
+// getSiblingStatements returns the statements of the statement list containing statement, or nil
+// if statement is not part of a statement list.
+func getSiblingStatements(statement *ast.Node) []*ast.Node {
+	switch parent := statement.Parent; parent.Kind {
+	case ast.KindSourceFile, ast.KindBlock, ast.KindModuleBlock:
+		return parent.Statements()
+	case ast.KindCaseClause, ast.KindDefaultClause:
+		return parent.AsCaseOrDefaultClause().Statements.Nodes
+	}
+	return nil
+}
+
+// splitVariableStatement replaces a variable statement declaring several variables with a statement
+// for each of them. Comments before a declarator move with it; comments on the line of the comma
+// preceding a declarator stay at the end of the line of the statement before it:
+//
+//	const a = 1, // one
+//	    b = 2; -> const a = 1; // one
+//	              const b = 2;
+func (ct *changeTracker) splitVariableStatement(file *ast.SourceFile, statement *ast.Node) {
+	text := file.Text()
+	declarations := statement.AsVariableStatement().DeclarationList.AsVariableDeclarationList().Declarations.Nodes
+	start := scanner.GetTokenPosOfNode(statement, file, false /*includeJSDoc*/)
+	indentation := getLineIndentation(file, start)
+	// The modifiers and keyword, such as `export const`.
+	keyword := text[start:declarations[0].Pos()]
+	terminator := ""
+	if strings.HasSuffix(text[:statement.End()], ";") {
+		terminator = ";"
+	}
+
+	var b strings.Builder
+	for i, declaration := range declarations {
+		declarationStart := scanner.GetTokenPosOfNode(declaration, file, false /*includeJSDoc*/)
+		leading := text[declaration.Pos():declarationStart]
+		if i > 0 {
+			// Comments after the comma on its line are written after the previous statement, and
+			// comments on lines of their own before the new statement.
+			line, rest, ok := strings.Cut(leading, "\n")
+			if ok {
+				if line = strings.TrimSpace(line); line != "" {
+					b.WriteString(" ")
+					b.WriteString(line)
+				}
+				leading = ""
+			}
+			b.WriteString(ct.newLine)
+			b.WriteString(indentation)
+			if rest = strings.TrimSpace(rest); ok && rest != "" {
+				b.WriteString(reindentText(rest, getLineIndentation(file, declarationStart), indentation))
+				b.WriteString(ct.newLine)
+				b.WriteString(indentation)
+			}
+		}
+		b.WriteString(keyword)
+		b.WriteString(" ")
+		if leading = strings.TrimSpace(leading); leading != "" {
+			b.WriteString(leading)
+			b.WriteString(" ")
+		}
+		b.WriteString(text[declarationStart:declaration.End()])
+		if i == len(declarations)-1 {
+			b.WriteString(text[declaration.End():statement.End()])
+			break
+		}
+		b.WriteString(terminator)
+		// Comments between the declarator and its comma.
+		if comments := strings.TrimSpace(text[declaration.End() : declarations[i+1].Pos()-1]); comments != "" {
+			b.WriteString(" ")
+			b.WriteString(comments)
+		}
+	}
+	ct.replaceRangeWithText(file, *ct.ls.createLspRangeFromBounds(start, statement.End(), file), b.String())
+}
+
+// mergeVariableStatements replaces adjacent variable statements of the same kind with a single
+// statement declaring all of their variables. Comments between the statements are kept before the
+// declarators that follow them, on lines of their own if they were:
+//
+//	const a = 1; // one
+//	const b = 2; -> const a = 1, // one
+//	                    b = 2;
+func (ct *changeTracker) mergeVariableStatements(file *ast.SourceFile, statements []*ast.Node) {
+	text := file.Text()
+	start := scanner.GetTokenPosOfNode(statements[0], file, false /*includeJSDoc*/)
+	declarationIndentation := getLineIndentation(file, start) + ct.indentationUnit()
+	var b strings.Builder
+	for i, statement := range statements {
+		declarations := statement.AsVariableStatement().DeclarationList.AsVariableDeclarationList().Declarations.Nodes
+		last := declarations[len(declarations)-1]
+		if i == 0 {
+			b.WriteString(text[start:last.End()])
+		} else {
+			b.WriteString(",")
+			statementStart := scanner.GetTokenPosOfNode(statement, file, false /*includeJSDoc*/)
+			between := text[statements[i-1].End():statementStart]
+			separator := " "
+			if line, rest, ok := strings.Cut(between, "\n"); ok {
+				if line = strings.TrimSpace(line); line != "" {
+					b.WriteString(" ")
+					b.WriteString(line)
+					separator = ct.newLine + declarationIndentation
+				}
+				if rest = strings.TrimSpace(rest); rest != "" {
+					b.WriteString(ct.newLine)
+					b.WriteString(declarationIndentation)
+					b.WriteString(reindentText(rest, getLineIndentation(file, statementStart), declarationIndentation))
+					separator = ct.newLine + declarationIndentation
+				}
+			} else if comments := strings.TrimSpace(between); comments != "" {
+				separator = " " + comments + " "
+			}
+			b.WriteString(separator)
+			b.WriteString(strings.TrimLeft(text[declarations[0].Pos():last.End()], " \t"))
+		}
+		if i == len(statements)-1 {
+			b.WriteString(text[last.End():statement.End()])
+		} else if comments := strings.TrimSpace(strings.TrimSuffix(text[last.End():statement.End()], ";")); comments != "" {
+			// Comments between the last declarator and the semicolon.
+			b.WriteString(" ")
+			b.WriteString(comments)
+		}
+	}
+	ct.replaceRangeWithText(file, *ct.ls.createLspRangeFromBounds(start, statements[len(statements)-1].End(), file), b.String())
+}
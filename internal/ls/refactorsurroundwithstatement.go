@@ -0,0 +1,142 @@
+package ls
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/format"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+)
+
+const (
+	refactorNameSurroundWithStatement = "Surround with statement"
+	refactorActionSurroundWithTry     = "Surround with try/catch"
+	refactorActionSurroundWithIf      = "Surround with if statement"
+	refactorActionSurroundWithFor     = "Surround with for loop"
+)
+
+var surroundWithStatementRefactorProvider = &refactorProvider{
+	name:                refactorNameSurroundWithStatement,
+	description:         "Surround with statement",
+	getAvailableActions: getSurroundWithStatementActions,
+	getEditsForAction:   getSurroundWithStatementEdits,
+}
+
+var surroundWithStatementActionNames = []string{
+	refactorActionSurroundWithTry,
+	refactorActionSurroundWithIf,
+	refactorActionSurroundWithFor,
+}
+
+func getSurroundWithStatementActions(c *refactorContext) []*RefactorAction {
+	r := getExtractRange(c)
+	if r == nil || r.statements == nil {
+		return nil
+	}
+	reason := getSurroundWithStatementNotApplicableReason(c, r)
+	actions := make([]*RefactorAction, len(surroundWithStatementActionNames))
+	for i, name := range surroundWithStatementActionNames {
+		actions[i] = &RefactorAction{
+			Name:                name,
+			Description:         name,
+			Kind:                "refactor.rewrite.surround",
+			NotApplicableReason: reason,
+		}
+	}
+	return actions
+}
+
+func getSurroundWithStatementEdits(c *refactorContext, actionName string) *lsproto.WorkspaceEdit {
+	r := getExtractRange(c)
+	if r == nil || r.statements == nil || !slices.Contains(surroundWithStatementActionNames, actionName) ||
+		getSurroundWithStatementNotApplicableReason(c, r) != "" {
+		return nil
+	}
+	file := c.sourceFile
+	text := file.Text()
+	ct := c.ls.newChangeTracker(c.ctx)
+
+	// The statement is indented as the formatter would indent the first selected statement, and
+	// replaces the indentation of its line if nothing precedes it there.
+	indentation := format.GetIndentationString(format.GetIndentationForNode(r.statements[0], nil /*ignoreActualIndentationRange*/, file, ct.formatSettings), ct.formatSettings)
+	bodyIndentation := indentation + ct.indentationUnit()
+	start := r.Pos()
+	lineStart := format.GetLineStartPositionForPosition(start, file)
+	prefix := ""
+	if strings.TrimSpace(text[lineStart:start]) == "" {
+		start = lineStart
+		prefix = indentation
+	}
+	body := bodyIndentation + reindentText(text[r.Pos():r.End()], getLineIndentation(file, r.Pos()), bodyIndentation)
+
+	// The cursor is placed where the statement needs to be completed, in its header or catch clause.
+	var newText string
+	var cursor int
+	switch actionName {
+	case refactorActionSurroundWithTry:
+		newText = prefix + "try {" + ct.newLine + body + ct.newLine + indentation + "} catch (error) {"
+		cursor = len(newText)
+		newText += ct.newLine + indentation + "}"
+	case refactorActionSurroundWithIf:
+		newText = prefix + "if () {" + ct.newLine + body + ct.newLine + indentation + "}"
+		cursor = len(prefix + "if (")
+	case refactorActionSurroundWithFor:
+		header := fmt.Sprintf("for (let %[1]s = 0; %[1]s < ; %[1]s++) {", getUniqueExtractName(file, "i"))
+		newText = prefix + header + ct.newLine + body + ct.newLine + indentation + "}"
+		cursor = len(prefix) + strings.Index(header, " ;") + 1
+	}
+	ct.replaceRangeWithText(file, *ct.ls.createLspRangeFromBounds(start, r.End(), file), newText)
+
+	position := ct.ls.createLspPosition(start, file)
+	if cursorLineStart := strings.LastIndex(newText[:cursor], "\n") + 1; cursorLineStart > 0 {
+		position.Line += uint32(strings.Count(newText[:cursor], "\n"))
+		position.Character = uint32(cursor - cursorLineStart)
+	} else {
+		position.Character += uint32(cursor)
+	}
+	c.cursorPosition = &position
+	return ct.getWorkspaceEdit()
+}
+
+// getSurroundWithStatementNotApplicableReason returns why the selected statements cannot be moved
+// into a block, or "" if they can.
+func getSurroundWithStatementNotApplicableReason(c *refactorContext, r *extractRange) string {
+	for _, statement := range r.statements {
+		switch {
+		case statement.Flags&ast.NodeFlagsAmbient != 0:
+			return "Cannot surround statements in an ambient context."
+		case ast.IsImportDeclaration(statement), ast.IsImportEqualsDeclaration(statement), ast.IsExportDeclaration(statement),
+			ast.IsExportAssignment(statement), ast.HasSyntacticModifier(statement, ast.ModifierFlagsExport):
+			return "Cannot surround import or export declarations."
+		case ast.IsModuleDeclaration(statement):
+			return "Cannot surround namespace declarations."
+		}
+	}
+
+	// Declarations of the selected statements, other than var declarations, would no longer be in
+	// scope after them.
+	var reason string
+	container := core.OrElse(getContainingFunctionOfNode(r.statements[0]), c.sourceFile.AsNode())
+	isScopedToSelection := func(declaration *ast.Node) bool {
+		isVar := ast.IsVariableDeclaration(ast.GetRootDeclaration(declaration)) && !ast.IsBlockOrCatchScoped(declaration)
+		return r.containsNode(declaration) && !isVar
+	}
+	var visit func(node *ast.Node) bool
+	visit = func(node *ast.Node) bool {
+		if r.containsNode(node) {
+			return false
+		}
+		if ast.IsIdentifier(node) {
+			if symbol := getExtractReferencedSymbol(c.checker, node); symbol != nil && slices.ContainsFunc(symbol.Declarations, isScopedToSelection) {
+				reason = fmt.Sprintf("Cannot surround the declaration of '%s', which is used outside the selection.", symbol.Name)
+				return true
+			}
+		}
+		return node.ForEachChild(visit)
+	}
+	container.ForEachChild(visit)
+	return reason
+}
@@ -0,0 +1,98 @@
+package ls
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/tspath"
+)
+
+type ReferenceScopeKind string
+
+const (
+	// ReferenceScopeProject searches every file of the program.
+	ReferenceScopeProject ReferenceScopeKind = "project"
+	// ReferenceScopeFile searches only the file containing the position.
+	ReferenceScopeFile ReferenceScopeKind = "file"
+	// ReferenceScopeDirectory searches the files in a directory and its subdirectories.
+	ReferenceScopeDirectory ReferenceScopeKind = "directory"
+)
+
+// ReferenceScope limits the files searched by FindReferencesInScope.
+type ReferenceScope struct {
+	Kind ReferenceScopeKind `json:"kind"`
+	// For directory scopes, the directory to search. Relative paths are resolved against the
+	// current directory of the program.
+	Directory string `json:"directory,omitempty"`
+}
+
+// FindReferencesInScope returns the references to the symbol at the given position, like find all
+// references, but only in the files of the scope. Files outside the scope, other than the one
+// containing the position, are excluded before any file is searched, so a small scope saves
+// checking the rest of the program. References that
+// are only found through imports in files outside the scope, such as references to a symbol
+// re-exported by a file outside a directory scope, are not returned. With the
+// PreferGoToSourceDefinition preference, references in the output declaration files of project
+// references are mapped to their source files.
+func (l *LanguageService) FindReferencesInScope(ctx context.Context, fileName string, position int, scope ReferenceScope) ([]DefinitionLocation, error) {
+	program, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	var inScope func(sourceFile *ast.SourceFile) bool
+	switch scope.Kind {
+	case ReferenceScopeProject, "":
+		inScope = func(sourceFile *ast.SourceFile) bool { return true }
+	case ReferenceScopeFile:
+		inScope = func(sourceFile *ast.SourceFile) bool { return sourceFile == file }
+	case ReferenceScopeDirectory:
+		options := tspath.ComparePathsOptions{
+			UseCaseSensitiveFileNames: program.UseCaseSensitiveFileNames(),
+			CurrentDirectory:          program.GetCurrentDirectory(),
+		}
+		inScope = func(sourceFile *ast.SourceFile) bool {
+			return tspath.ContainsPath(scope.Directory, sourceFile.FileName(), options)
+		}
+	default:
+		return nil, fmt.Errorf("unknown reference scope %q", scope.Kind)
+	}
+	// The file containing the position is always searched, since the imports of a symbol declared
+	// there are only found from its export. Its references are dropped if it is out of scope.
+	sourceFiles := core.Filter(program.GetSourceFiles(), func(sourceFile *ast.SourceFile) bool {
+		return sourceFile == file || inScope(sourceFile)
+	})
+
+	node := astnav.GetTouchingPropertyName(file, position)
+	options := refOptions{use: referenceUseReferences}
+	symbolsAndEntries := l.getReferencedSymbolsForNode(ctx, position, node, program, sourceFiles, options, nil)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	result := []DefinitionLocation{}
+	for _, symbolAndEntries := range symbolsAndEntries {
+		for _, entry := range symbolAndEntries.references {
+			location := l.newReferenceLocation(entry)
+			if inScope(program.GetSourceFile(location.FileName)) {
+				result = append(result, location)
+			}
+		}
+	}
+	return l.mapDefinitionLocations(result), nil
+}
+
+func (l *LanguageService) newReferenceLocation(entry *referenceEntry) DefinitionLocation {
+	entry = l.resolveEntry(entry)
+	file := l.GetProgram().GetSourceFile(entry.fileName)
+	start := int(l.converters.LineAndCharacterToPosition(file, entry.textRange.Start))
+	end := int(l.converters.LineAndCharacterToPosition(file, entry.textRange.End))
+	return DefinitionLocation{
+		FileName: entry.fileName,
+		Start:    getPosition(file, start, l),
+		End:      getPosition(file, end, l),
+		StartPos: start,
+		EndPos:   end,
+	}
+}
@@ -0,0 +1,48 @@
+package ls
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/scanner"
+)
+
+// GetSelectionRanges returns the ranges that expanding the selection at the given position steps
+// through, from the innermost node to the whole file, with each range containing the previous one.
+// Nodes that only group their children, such as the attribute list of a JSX element, are skipped,
+// so expanding the selection from the name of a JSX attribute steps through the attribute, the
+// opening element and then the element.
+func (l *LanguageService) GetSelectionRanges(ctx context.Context, fileName string, position int) ([]TextRange, error) {
+	_, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	var ranges []TextRange
+	for node := astnav.GetTouchingPropertyName(file, position); node != nil; node = node.Parent {
+		if isSelectionGroupingNode(node) || node.Flags&ast.NodeFlagsReparsed != 0 {
+			continue
+		}
+		start := 0
+		if !ast.IsSourceFile(node) {
+			start = scanner.GetTokenPosOfNode(node, file, false /*includeJSDoc*/)
+		}
+		if len(ranges) != 0 && ranges[len(ranges)-1].StartPos == start && ranges[len(ranges)-1].EndPos == node.End() {
+			continue
+		}
+		ranges = append(ranges, l.newTextRange(file, core.NewTextRange(start, node.End())))
+	}
+	return ranges, nil
+}
+
+// isSelectionGroupingNode reports whether node only groups its children, without syntax of its
+// own, so that selecting it would not select anything meaningful.
+func isSelectionGroupingNode(node *ast.Node) bool {
+	switch node.Kind {
+	case ast.KindJsxAttributes, ast.KindVariableDeclarationList:
+		return true
+	}
+	return false
+}
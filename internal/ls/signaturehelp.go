@@ -591,7 +591,8 @@ func isSyntacticOwner(startingToken *ast.Node, node *ast.CallLikeExpression, sou
 	case ast.KindOpenParenToken, ast.KindCommaToken:
 		return containsNode(invocationChildren, startingToken)
 	case ast.KindLessThanToken:
-		return containsPrecedingToken(startingToken, sourceFile, node.AsCallExpression().Expression)
+		// The type argument list of a call or new expression follows its expression.
+		return containsPrecedingToken(startingToken, sourceFile, node.Expression())
 	default:
 		return false
 	}
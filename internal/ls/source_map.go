@@ -79,3 +79,42 @@ func (l *LanguageService) tryGetSourcePositionWorker(
 	}
 	return documentPos
 }
+
+// mapToProjectReferenceSource returns the location in a source file of a project reference that
+// corresponds to a location in one of the reference's output declaration files, found through the
+// declaration map emitted with it. Locations in other files, or in declaration files without a
+// map, are returned unchanged.
+func (l *LanguageService) mapToProjectReferenceSource(location DefinitionLocation) DefinitionLocation {
+	program := l.GetProgram()
+	path := tspath.ToPath(location.FileName, program.GetCurrentDirectory(), program.UseCaseSensitiveFileNames())
+	if !tspath.IsDeclarationFileName(location.FileName) || program.GetProjectReferenceFromOutputDts(path) == nil {
+		return location
+	}
+	start := l.tryGetSourcePosition(location.FileName, core.TextPos(location.StartPos))
+	end := l.tryGetSourcePosition(location.FileName, core.TextPos(location.EndPos))
+	if start == nil || end == nil || start.FileName != end.FileName {
+		return location
+	}
+	script := l.getScript(start.FileName)
+	startPosition := l.converters.PositionToLineAndCharacter(script, core.TextPos(start.Pos))
+	endPosition := l.converters.PositionToLineAndCharacter(script, core.TextPos(end.Pos))
+	return DefinitionLocation{
+		FileName: start.FileName,
+		Start:    Position{Line: int64(startPosition.Line), Character: int64(startPosition.Character)},
+		End:      Position{Line: int64(endPosition.Line), Character: int64(endPosition.Character)},
+		StartPos: start.Pos,
+		EndPos:   end.Pos,
+	}
+}
+
+// mapDefinitionLocations maps locations to project reference sources when the
+// PreferGoToSourceDefinition preference is set.
+func (l *LanguageService) mapDefinitionLocations(locations []DefinitionLocation) []DefinitionLocation {
+	if !l.getUserPreferences().PreferGoToSourceDefinition {
+		return locations
+	}
+	for i, location := range locations {
+		locations[i] = l.mapToProjectReferenceSource(location)
+	}
+	return locations
+}
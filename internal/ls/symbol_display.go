@@ -131,36 +131,10 @@ func getSymbolKind(typeChecker *checker.Checker, symbol *ast.Symbol, location *a
 	}
 
 	flags := checker.GetCombinedLocalAndExportSymbolFlags(symbol)
-	if flags&ast.SymbolFlagsClass != 0 {
-		decl := ast.GetDeclarationOfKind(symbol, ast.KindClassExpression)
-		if decl != nil {
-			return ScriptElementKindLocalClassElement
-		}
-		return ScriptElementKindClassElement
-	}
-	if flags&ast.SymbolFlagsEnum != 0 {
-		return ScriptElementKindEnumElement
-	}
-	if flags&ast.SymbolFlagsTypeAlias != 0 {
-		return ScriptElementKindTypeElement
-	}
-	if flags&ast.SymbolFlagsInterface != 0 {
-		return ScriptElementKindInterfaceElement
-	}
-	if flags&ast.SymbolFlagsTypeParameter != 0 {
-		return ScriptElementKindTypeParameterElement
+	if flags&ast.SymbolFlagsClass != 0 && ast.GetDeclarationOfKind(symbol, ast.KindClassExpression) != nil {
+		return ScriptElementKindLocalClassElement
 	}
-	if flags&ast.SymbolFlagsEnumMember != 0 {
-		return ScriptElementKindEnumMemberElement
-	}
-	if flags&ast.SymbolFlagsAlias != 0 {
-		return ScriptElementKindAlias
-	}
-	if flags&ast.SymbolFlagsModule != 0 {
-		return ScriptElementKindModuleElement
-	}
-
-	return ScriptElementKindUnknown
+	return getTypeOrNamespaceKindFromFlags(flags)
 }
 
 func getSymbolKindOfConstructorPropertyMethodAccessorFunctionOrVar(typeChecker *checker.Checker, symbol *ast.Symbol, location *ast.Node) ScriptElementKind {
@@ -0,0 +1,113 @@
+package ls
+
+import (
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+)
+
+// SymbolKindFromFlags returns the LSP symbol kind of a symbol with the given flags. A symbol with
+// several meanings gets the kind of its first meaning in the order of getSymbolKind: values, then
+// members, then classes, enums, type aliases, interfaces, type parameters, enum members, aliases
+// and namespaces, so a class merged with an interface or a namespace is a class. decl, if not
+// nil, is the declaration the kind is for. It distinguishes parameters, constants and signatures,
+// and gives the kind on its own when flags is zero.
+func SymbolKindFromFlags(flags ast.SymbolFlags, decl *ast.Node) lsproto.SymbolKind {
+	switch scriptElementKindFromFlags(flags, decl) {
+	case ScriptElementKindModuleElement:
+		return lsproto.SymbolKindNamespace
+	case ScriptElementKindClassElement, ScriptElementKindLocalClassElement, ScriptElementKindTypeElement:
+		return lsproto.SymbolKindClass
+	case ScriptElementKindMemberFunctionElement:
+		return lsproto.SymbolKindMethod
+	case ScriptElementKindMemberVariableElement, ScriptElementKindMemberGetAccessorElement, ScriptElementKindMemberSetAccessorElement:
+		return lsproto.SymbolKindProperty
+	case ScriptElementKindConstructorImplementationElement, ScriptElementKindConstructSignatureElement:
+		return lsproto.SymbolKindConstructor
+	case ScriptElementKindEnumElement:
+		return lsproto.SymbolKindEnum
+	case ScriptElementKindInterfaceElement:
+		return lsproto.SymbolKindInterface
+	case ScriptElementKindFunctionElement, ScriptElementKindLocalFunctionElement:
+		return lsproto.SymbolKindFunction
+	case ScriptElementKindEnumMemberElement:
+		return lsproto.SymbolKindEnumMember
+	case ScriptElementKindTypeParameterElement:
+		return lsproto.SymbolKindTypeParameter
+	}
+	return lsproto.SymbolKindVariable
+}
+
+// CompletionItemKindFromFlags returns the LSP completion item kind of a symbol with the given
+// flags, resolving symbols with several meanings as SymbolKindFromFlags does.
+func CompletionItemKindFromFlags(flags ast.SymbolFlags, decl *ast.Node) lsproto.CompletionItemKind {
+	return getCompletionsSymbolKind(scriptElementKindFromFlags(flags, decl))
+}
+
+// scriptElementKindFromFlags is the part of getSymbolKind that needs no checker.
+func scriptElementKindFromFlags(flags ast.SymbolFlags, decl *ast.Node) ScriptElementKind {
+	switch {
+	case flags&ast.SymbolFlagsVariable != 0:
+		switch {
+		case decl == nil:
+			return ScriptElementKindVariableElement
+		case ast.IsParameter(decl):
+			return ScriptElementKindParameterElement
+		}
+		return getKindOfVariableDeclaration(ast.GetRootDeclaration(decl))
+	case flags&ast.SymbolFlagsFunction != 0:
+		return ScriptElementKindFunctionElement
+	case flags&ast.SymbolFlagsGetAccessor != 0:
+		return ScriptElementKindMemberGetAccessorElement
+	case flags&ast.SymbolFlagsSetAccessor != 0:
+		return ScriptElementKindMemberSetAccessorElement
+	case flags&ast.SymbolFlagsMethod != 0:
+		return ScriptElementKindMemberFunctionElement
+	case flags&ast.SymbolFlagsConstructor != 0:
+		return ScriptElementKindConstructorImplementationElement
+	case flags&ast.SymbolFlagsSignature != 0:
+		if decl != nil {
+			switch decl.Kind {
+			case ast.KindConstructSignature, ast.KindConstructorType:
+				return ScriptElementKindConstructSignatureElement
+			case ast.KindCallSignature, ast.KindFunctionType:
+				return ScriptElementKindCallSignatureElement
+			}
+		}
+		return ScriptElementKindIndexSignatureElement
+	case flags&ast.SymbolFlagsProperty != 0:
+		return ScriptElementKindMemberVariableElement
+	case flags&ast.SymbolFlagsClass != 0 && decl != nil && ast.IsClassExpression(decl):
+		return ScriptElementKindLocalClassElement
+	}
+	if kind := getTypeOrNamespaceKindFromFlags(flags); kind != ScriptElementKindUnknown || decl == nil {
+		return kind
+	}
+	if flags == 0 {
+		return getNodeKind(decl)
+	}
+	return ScriptElementKindUnknown
+}
+
+// getTypeOrNamespaceKindFromFlags returns the kind of a symbol that is not a variable, function or
+// member, or ScriptElementKindUnknown if it has none of the meanings below.
+func getTypeOrNamespaceKindFromFlags(flags ast.SymbolFlags) ScriptElementKind {
+	switch {
+	case flags&ast.SymbolFlagsClass != 0:
+		return ScriptElementKindClassElement
+	case flags&ast.SymbolFlagsEnum != 0:
+		return ScriptElementKindEnumElement
+	case flags&ast.SymbolFlagsTypeAlias != 0:
+		return ScriptElementKindTypeElement
+	case flags&ast.SymbolFlagsInterface != 0:
+		return ScriptElementKindInterfaceElement
+	case flags&ast.SymbolFlagsTypeParameter != 0:
+		return ScriptElementKindTypeParameterElement
+	case flags&ast.SymbolFlagsEnumMember != 0:
+		return ScriptElementKindEnumMemberElement
+	case flags&ast.SymbolFlagsAlias != 0:
+		return ScriptElementKindAlias
+	case flags&ast.SymbolFlagsModule != 0:
+		return ScriptElementKindModuleElement
+	}
+	return ScriptElementKindUnknown
+}
@@ -0,0 +1,41 @@
+package ls_test
+
+import (
+	"testing"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/ls"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"gotest.tools/v3/assert"
+)
+
+func TestSymbolKindFromFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		flags          ast.SymbolFlags
+		kind           lsproto.SymbolKind
+		completionKind lsproto.CompletionItemKind
+	}{
+		{"class", ast.SymbolFlagsClass, lsproto.SymbolKindClass, lsproto.CompletionItemKindClass},
+		{"class and interface", ast.SymbolFlagsClass | ast.SymbolFlagsInterface, lsproto.SymbolKindClass, lsproto.CompletionItemKindClass},
+		{"class and namespace", ast.SymbolFlagsClass | ast.SymbolFlagsNamespaceModule, lsproto.SymbolKindClass, lsproto.CompletionItemKindClass},
+		{"function and namespace", ast.SymbolFlagsFunction | ast.SymbolFlagsValueModule, lsproto.SymbolKindFunction, lsproto.CompletionItemKindFunction},
+		{"variable and interface", ast.SymbolFlagsBlockScopedVariable | ast.SymbolFlagsInterface, lsproto.SymbolKindVariable, lsproto.CompletionItemKindVariable},
+		{"enum and namespace", ast.SymbolFlagsRegularEnum | ast.SymbolFlagsValueModule, lsproto.SymbolKindEnum, lsproto.CompletionItemKindEnum},
+		{"interface", ast.SymbolFlagsInterface, lsproto.SymbolKindInterface, lsproto.CompletionItemKindInterface},
+		{"type alias", ast.SymbolFlagsTypeAlias, lsproto.SymbolKindClass, lsproto.CompletionItemKindClass},
+		{"method", ast.SymbolFlagsMethod, lsproto.SymbolKindMethod, lsproto.CompletionItemKindMethod},
+		{"property", ast.SymbolFlagsProperty, lsproto.SymbolKindProperty, lsproto.CompletionItemKindField},
+		{"accessors", ast.SymbolFlagsGetAccessor | ast.SymbolFlagsSetAccessor, lsproto.SymbolKindProperty, lsproto.CompletionItemKindField},
+		{"enum member", ast.SymbolFlagsEnumMember, lsproto.SymbolKindEnumMember, lsproto.CompletionItemKindEnumMember},
+		{"type parameter", ast.SymbolFlagsTypeParameter, lsproto.SymbolKindTypeParameter, lsproto.CompletionItemKindProperty},
+		{"namespace", ast.SymbolFlagsNamespaceModule, lsproto.SymbolKindNamespace, lsproto.CompletionItemKindModule},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, ls.SymbolKindFromFlags(test.flags, nil), test.kind, test.name)
+		assert.Equal(t, ls.CompletionItemKindFromFlags(test.flags, nil), test.completionKind, test.name)
+	}
+}
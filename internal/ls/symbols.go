@@ -18,7 +18,10 @@ import (
 )
 
 func (l *LanguageService) ProvideDocumentSymbols(ctx context.Context, documentURI lsproto.DocumentUri) (lsproto.DocumentSymbolResponse, error) {
-	_, file := l.getProgramAndFile(documentURI)
+	file, err := l.GetBoundSourceFile(ctx, documentURI.FileName())
+	if err != nil {
+		return lsproto.SymbolInformationsOrDocumentSymbolsOrNull{}, err
+	}
 	symbols := l.getDocumentSymbolsForChildren(ctx, file.AsNode())
 	return lsproto.SymbolInformationsOrDocumentSymbolsOrNull{DocumentSymbols: &symbols}, nil
 }
@@ -283,27 +286,9 @@ func compareDeclarationInfos(d1, d2 DeclarationInfo) int {
 }
 
 func getSymbolKindFromNode(node *ast.Node) lsproto.SymbolKind {
-	switch node.Kind {
-	case ast.KindModuleDeclaration:
-		return lsproto.SymbolKindNamespace
-	case ast.KindClassDeclaration, ast.KindClassExpression, ast.KindTypeAliasDeclaration:
-		return lsproto.SymbolKindClass
-	case ast.KindMethodDeclaration, ast.KindMethodSignature:
-		return lsproto.SymbolKindMethod
-	case ast.KindPropertyDeclaration, ast.KindPropertySignature, ast.KindGetAccessor, ast.KindSetAccessor:
-		return lsproto.SymbolKindProperty
-	case ast.KindConstructor, ast.KindConstructSignature:
-		return lsproto.SymbolKindConstructor
-	case ast.KindEnumDeclaration:
-		return lsproto.SymbolKindEnum
-	case ast.KindInterfaceDeclaration:
-		return lsproto.SymbolKindInterface
-	case ast.KindFunctionDeclaration, ast.KindFunctionExpression:
-		return lsproto.SymbolKindFunction
-	case ast.KindEnumMember:
-		return lsproto.SymbolKindEnumMember
-	case ast.KindTypeParameter:
-		return lsproto.SymbolKindTypeParameter
+	var flags ast.SymbolFlags
+	if symbol := node.Symbol(); symbol != nil {
+		flags = symbol.Flags
 	}
-	return lsproto.SymbolKindVariable
+	return SymbolKindFromFlags(flags, node)
 }
@@ -0,0 +1,52 @@
+package ls
+
+import (
+	"slices"
+
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+)
+
+// newWorkspaceEdit returns the edits of each document of changes in the shape every API producing
+// edits returns them, as normalized by normalizeTextEdits.
+func newWorkspaceEdit(changes map[lsproto.DocumentUri][]*lsproto.TextEdit) *lsproto.WorkspaceEdit {
+	for uri, edits := range changes {
+		changes[uri] = normalizeTextEdits(edits)
+	}
+	return &lsproto.WorkspaceEdit{Changes: &changes}
+}
+
+// normalizeTextEdits merges the edits of a document that touch or overlap into single edits and
+// sorts them in reverse document order, so that a client can apply them one after the other
+// without adjusting the positions of those left. The text of a merged edit is the text of each
+// edit in document order, insertions at the same position keeping the order they were made in,
+// and it replaces every range of them. Identical edits are applied once.
+func normalizeTextEdits(edits []*lsproto.TextEdit) []*lsproto.TextEdit {
+	sorted := slices.Clone(edits)
+	slices.SortStableFunc(sorted, func(a, b *lsproto.TextEdit) int {
+		return CompareRanges(&a.Range, &b.Range)
+	})
+	var result []*lsproto.TextEdit
+	for _, edit := range sorted {
+		if len(result) == 0 {
+			result = append(result, edit)
+			continue
+		}
+		last := result[len(result)-1]
+		switch {
+		case last.Range == edit.Range && last.NewText == edit.NewText && !isEmptyRange(edit.Range):
+		case ComparePositions(edit.Range.Start, last.Range.End) <= 0:
+			end := last.Range.End
+			if ComparePositions(edit.Range.End, end) > 0 {
+				end = edit.Range.End
+			}
+			result[len(result)-1] = &lsproto.TextEdit{
+				Range:   lsproto.Range{Start: last.Range.Start, End: end},
+				NewText: last.NewText + edit.NewText,
+			}
+		default:
+			result = append(result, edit)
+		}
+	}
+	slices.Reverse(result)
+	return result
+}
@@ -0,0 +1,48 @@
+package ls_test
+
+import (
+	"testing"
+
+	"github.com/microsoft/typescript-go/internal/ls"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"gotest.tools/v3/assert"
+)
+
+func TestNormalizeTextEdits(t *testing.T) {
+	t.Parallel()
+
+	// Edits on the first line of "0123456789", written as start, end and new text.
+	type edit struct {
+		start, end uint32
+		newText    string
+	}
+	tests := []struct {
+		name     string
+		edits    []edit
+		expected []edit
+	}{
+		{"disjoint edits are in reverse order", []edit{{1, 2, ""}, {5, 6, "x"}}, []edit{{5, 6, "x"}, {1, 2, ""}}},
+		{"adjacent edits are merged", []edit{{2, 3, "b"}, {1, 2, "a"}}, []edit{{1, 3, "ab"}}},
+		{"insertions at one position are merged in order", []edit{{2, 2, "a"}, {2, 2, "b"}}, []edit{{2, 2, "ab"}}},
+		{"insertion before a replacement is merged", []edit{{2, 4, "x"}, {2, 2, "a"}}, []edit{{2, 4, "ax"}}},
+		{"overlapping edits are merged", []edit{{3, 6, "y"}, {1, 4, "x"}}, []edit{{1, 6, "xy"}}},
+		{"contained edit is merged", []edit{{1, 6, ""}, {2, 3, "y"}}, []edit{{1, 6, "y"}}},
+		{"identical edits are applied once", []edit{{1, 2, "x"}, {1, 2, "x"}}, []edit{{1, 2, "x"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			toTextEdits := func(edits []edit) []*lsproto.TextEdit {
+				result := make([]*lsproto.TextEdit, len(edits))
+				for i, e := range edits {
+					result[i] = &lsproto.TextEdit{
+						Range:   lsproto.Range{Start: lsproto.Position{Character: e.start}, End: lsproto.Position{Character: e.end}},
+						NewText: e.newText,
+					}
+				}
+				return result
+			}
+			assert.DeepEqual(t, ls.NormalizeTextEdits(toTextEdits(tt.edits)), toTextEdits(tt.expected))
+		})
+	}
+}
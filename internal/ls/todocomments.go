@@ -0,0 +1,101 @@
+package ls
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+	"github.com/microsoft/typescript-go/internal/core"
+)
+
+// TodoCommentToken is a marker, such as "TODO" or "FIXME", that GetTodoComments looks for.
+type TodoCommentToken struct {
+	Text     string `json:"text"`
+	Priority int    `json:"priority"`
+}
+
+// TodoComment is a comment starting with a marker.
+type TodoComment struct {
+	// Token is the marker that the comment starts with.
+	Token TodoCommentToken `json:"token"`
+	// Message is the text of the comment from the marker to the end of its line or of the comment.
+	Message string `json:"message"`
+	// Range is the range of the message.
+	Range TextRange `json:"range"`
+}
+
+// GetTodoComments returns the comments of a file that start with one of the given markers, which are
+// matched case-insensitively, and only as whole words: a "TODO" marker does not match "TODOLIST".
+// A marker starts a comment if it is preceded on its line only by the delimiter of the comment,
+// whitespace, or the asterisks decorating the lines of a block comment. Markers in strings, template
+// literals and regular expressions are not matched.
+func (l *LanguageService) GetTodoComments(ctx context.Context, fileName string, tokens []TodoCommentToken) ([]TodoComment, error) {
+	_, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	text := file.Text()
+	var comments []TodoComment
+	for pos := 0; pos < len(text); pos++ {
+		token, ok := matchTodoCommentToken(text, pos, tokens)
+		if !ok || !isAtStartOfCommentLine(text, pos) {
+			continue
+		}
+		comment := isInComment(file, pos, astnav.GetTokenAtPosition(file, pos))
+		if comment == nil {
+			continue
+		}
+		end := comment.End()
+		if comment.Kind == ast.KindMultiLineCommentTrivia && strings.HasSuffix(text[:end], "*/") {
+			end -= 2
+		}
+		if lineEnd := strings.IndexAny(text[pos:end], "\r\n"); lineEnd >= 0 {
+			end = pos + lineEnd
+		}
+		message := strings.TrimRight(text[pos:end], " \t")
+		comments = append(comments, TodoComment{
+			Token:   token,
+			Message: message,
+			Range:   l.newTextRange(file, core.NewTextRange(pos, pos+len(message))),
+		})
+		pos = end - 1
+	}
+	return comments, nil
+}
+
+// matchTodoCommentToken returns the first of tokens that occurs at pos as a whole word.
+func matchTodoCommentToken(text string, pos int, tokens []TodoCommentToken) (TodoCommentToken, bool) {
+	for _, token := range tokens {
+		end := pos + len(token.Text)
+		if token.Text == "" || end > len(text) || !strings.EqualFold(text[pos:end], token.Text) {
+			continue
+		}
+		if end < len(text) && isTodoCommentWordCharacter(text[end]) {
+			continue
+		}
+		return token, true
+	}
+	return TodoCommentToken{}, false
+}
+
+func isTodoCommentWordCharacter(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || ch == '_'
+}
+
+// isAtStartOfCommentLine returns whether pos is preceded on its line only by whitespace and
+// asterisks, after the start of the line or the `//` or `/*` of a comment.
+func isAtStartOfCommentLine(text string, pos int) bool {
+	start := pos
+	for start > 0 && (text[start-1] == ' ' || text[start-1] == '\t' || text[start-1] == '*') {
+		start--
+	}
+	if start == 0 || text[start-1] == '\n' || text[start-1] == '\r' {
+		return true
+	}
+	if text[start-1] != '/' {
+		return false
+	}
+	return text[start] == '*' || start >= 2 && text[start-2] == '/'
+}
@@ -0,0 +1,169 @@
+package ls
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/astnav"
+	"github.com/microsoft/typescript-go/internal/checker"
+	"github.com/microsoft/typescript-go/internal/collections"
+	"github.com/microsoft/typescript-go/internal/tspath"
+)
+
+// TypeHierarchyItem is a class or interface declaration in a type hierarchy.
+type TypeHierarchyItem struct {
+	Name string            `json:"name"`
+	Kind ScriptElementKind `json:"kind"`
+	// Detail is the name of the namespace or class containing the declaration, or the file name for top-level declarations.
+	Detail string `json:"detail"`
+	// Range spans the whole declaration, and SelectionRange its name.
+	Range          DefinitionLocation `json:"range"`
+	SelectionRange DefinitionLocation `json:"selectionRange"`
+}
+
+// PrepareTypeHierarchy returns the declarations of the class or interface at the given position,
+// which may be its declaration or a reference to it. The result is empty for other symbols.
+func (l *LanguageService) PrepareTypeHierarchy(ctx context.Context, fileName string, position int) ([]*TypeHierarchyItem, error) {
+	return l.getTypeHierarchyItems(ctx, fileName, position, func(c *checker.Checker, symbol *ast.Symbol) []*ast.Node {
+		return symbol.Declarations
+	})
+}
+
+// GetSupertypes returns the declarations of the classes and interfaces named in the heritage clauses of the
+// class or interface at the given position. Generic bases resolve to their declarations rather than to an
+// instantiation, and the bases of all declarations of a merged interface are included.
+func (l *LanguageService) GetSupertypes(ctx context.Context, fileName string, position int) ([]*TypeHierarchyItem, error) {
+	return l.getTypeHierarchyItems(ctx, fileName, position, func(c *checker.Checker, symbol *ast.Symbol) []*ast.Node {
+		var declarations []*ast.Node
+		for _, declaration := range symbol.Declarations {
+			for _, base := range getHeritageSymbols(c, declaration) {
+				declarations = append(declarations, base.Declarations...)
+			}
+		}
+		return declarations
+	})
+}
+
+// GetSubtypes returns the classes and interfaces in the program's non-library files whose heritage
+// clauses directly name the class or interface at the given position.
+func (l *LanguageService) GetSubtypes(ctx context.Context, fileName string, position int) ([]*TypeHierarchyItem, error) {
+	return l.getTypeHierarchyItems(ctx, fileName, position, func(c *checker.Checker, symbol *ast.Symbol) []*ast.Node {
+		return l.findDirectSubtypes(ctx, c, symbol)
+	})
+}
+
+func (l *LanguageService) getTypeHierarchyItems(
+	ctx context.Context,
+	fileName string,
+	position int,
+	getDeclarations func(c *checker.Checker, symbol *ast.Symbol) []*ast.Node,
+) ([]*TypeHierarchyItem, error) {
+	program, file := l.tryGetProgramAndFile(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSourceFile, fileName)
+	}
+	result := []*TypeHierarchyItem{}
+	node := astnav.GetTouchingPropertyName(file, position)
+	if node.Kind == ast.KindSourceFile {
+		return result, nil
+	}
+
+	c, done := program.GetTypeChecker(ctx)
+	defer done()
+	symbol := c.GetSymbolAtLocation(getDeclarationNameForKeyword(node))
+	if symbol == nil {
+		return result, nil
+	}
+	symbol = c.SkipAlias(symbol)
+	if symbol.Flags&(ast.SymbolFlagsClass|ast.SymbolFlagsInterface) == 0 {
+		return result, nil
+	}
+
+	declarations := getDeclarations(c, symbol)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	var seen collections.Set[*ast.Node]
+	for _, declaration := range declarations {
+		if isTypeHierarchyDeclaration(declaration) && seen.AddIfAbsent(declaration) {
+			result = append(result, l.newTypeHierarchyItem(declaration))
+		}
+	}
+	return result, nil
+}
+
+func isTypeHierarchyDeclaration(node *ast.Node) bool {
+	return ast.IsClassLike(node) || ast.IsInterfaceDeclaration(node)
+}
+
+func (l *LanguageService) newTypeHierarchyItem(declaration *ast.Node) *TypeHierarchyItem {
+	kind := ScriptElementKindClassElement
+	switch {
+	case ast.IsInterfaceDeclaration(declaration):
+		kind = ScriptElementKindInterfaceElement
+	case ast.IsClassExpression(declaration):
+		kind = ScriptElementKindLocalClassElement
+	}
+	name := "default"
+	if nameNode := ast.GetNameOfDeclaration(declaration); nameNode != nil {
+		name = nameNode.Text()
+	}
+	return &TypeHierarchyItem{
+		Name:           name,
+		Kind:           kind,
+		Detail:         getTypeHierarchyDetail(declaration),
+		Range:          l.newNodeLocation(declaration),
+		SelectionRange: l.newDefinitionLocation(declaration),
+	}
+}
+
+// getTypeHierarchyDetail returns the dotted name of the namespaces and classes containing a declaration,
+// or the base name of its file if it is not nested in any.
+func getTypeHierarchyDetail(declaration *ast.Node) string {
+	var names []string
+	for parent := declaration.Parent; parent != nil; parent = parent.Parent {
+		if ast.IsModuleDeclaration(parent) || ast.IsClassLike(parent) {
+			if name := ast.GetNameOfDeclaration(parent); name != nil {
+				names = append(names, name.Text())
+			}
+		}
+	}
+	if len(names) == 0 {
+		return tspath.GetBaseFileName(ast.GetSourceFileOfNode(declaration).FileName())
+	}
+	slices.Reverse(names)
+	return strings.Join(names, ".")
+}
+
+// findDirectSubtypes scans the program's non-library files for classes and interfaces whose heritage
+// clauses reference the target symbol.
+func (l *LanguageService) findDirectSubtypes(ctx context.Context, c *checker.Checker, target *ast.Symbol) []*ast.Node {
+	program := l.GetProgram()
+	var subtypes []*ast.Node
+	var visit ast.Visitor
+	visit = func(node *ast.Node) bool {
+		if isTypeHierarchyDeclaration(node) {
+			for _, base := range getHeritageSymbols(c, node) {
+				if base == target {
+					subtypes = append(subtypes, node)
+					break
+				}
+			}
+		}
+		node.ForEachChild(visit)
+		return false
+	}
+	for _, sourceFile := range program.GetSourceFiles() {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if program.IsSourceFileDefaultLibrary(sourceFile.Path()) || program.IsSourceFileFromExternalLibrary(sourceFile) {
+			continue
+		}
+		sourceFile.AsNode().ForEachChild(visit)
+	}
+	return subtypes
+}
@@ -0,0 +1,87 @@
+package ls_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/microsoft/typescript-go/internal/bundled"
+	"github.com/microsoft/typescript-go/internal/ls"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/testutil/projecttestutil"
+	"gotest.tools/v3/assert"
+)
+
+func TestTypeHierarchy(t *testing.T) {
+	t.Parallel()
+	if !bundled.Embedded {
+		t.Skip("bundled files are not embedded")
+	}
+
+	a := `interface Tagged {}
+export interface Named {
+    name: string;
+}
+export interface Named extends Tagged {}
+export interface Base<T> {
+    value: T;
+}
+export class Animal implements Base<number>, Named {
+    value = 1;
+    name = "";
+}
+export namespace Zoo {
+    export class Cat extends Animal {}
+}
+const x = 1;
+`
+	b := `import { Animal } from "./a";
+export class Dog extends Animal {}
+`
+	files := map[string]any{
+		"/src/tsconfig.json": `{}`,
+		"/src/a.ts":          a,
+		"/src/b.ts":          b,
+	}
+	session, _ := projecttestutil.Setup(files)
+	ctx := projecttestutil.WithRequestID(context.Background())
+	session.DidOpenFile(ctx, "file:///src/a.ts", 1, files["/src/a.ts"].(string), lsproto.LanguageKindTypeScript)
+	languageService, err := session.GetLanguageService(ctx, "file:///src/a.ts")
+	assert.NilError(t, err)
+
+	// describe returns the kind, name and detail of each item, checking that its selection range
+	// is its name within its range.
+	describe := func(items []*ls.TypeHierarchyItem, err error) []string {
+		assert.NilError(t, err)
+		var result []string
+		for _, item := range items {
+			text := files[item.Range.FileName].(string)
+			assert.Equal(t, text[item.SelectionRange.StartPos:item.SelectionRange.EndPos], item.Name)
+			assert.Assert(t, item.Range.StartPos <= item.SelectionRange.StartPos && item.SelectionRange.EndPos <= item.Range.EndPos)
+			result = append(result, fmt.Sprintf("%s %s (%s)", item.Kind, item.Name, item.Detail))
+		}
+		slices.Sort(result)
+		return result
+	}
+	// PrepareTypeHierarchy resolves declarations and references, including imported ones.
+	assert.DeepEqual(t, describe(languageService.PrepareTypeHierarchy(ctx, "/src/a.ts", strings.Index(a, "Animal implements"))), []string{"class Animal (a.ts)"})
+	assert.DeepEqual(t, describe(languageService.PrepareTypeHierarchy(ctx, "/src/b.ts", strings.Index(b, "Animal {}"))), []string{"class Animal (a.ts)"})
+	assert.DeepEqual(t, describe(languageService.PrepareTypeHierarchy(ctx, "/src/a.ts", strings.Index(a, "Named {"))), []string{"interface Named (a.ts)", "interface Named (a.ts)"})
+	assert.DeepEqual(t, describe(languageService.PrepareTypeHierarchy(ctx, "/src/a.ts", strings.Index(a, "x = 1"))), []string(nil))
+
+	// A generic base resolves to its declaration, and the bases of every declaration of a merged
+	// interface are included.
+	assert.DeepEqual(t, describe(languageService.GetSupertypes(ctx, "/src/a.ts", strings.Index(a, "Animal implements"))), []string{"interface Base (a.ts)", "interface Named (a.ts)", "interface Named (a.ts)"})
+	assert.DeepEqual(t, describe(languageService.GetSupertypes(ctx, "/src/a.ts", strings.Index(a, "Named {"))), []string{"interface Tagged (a.ts)"})
+	assert.DeepEqual(t, describe(languageService.GetSupertypes(ctx, "/src/a.ts", strings.Index(a, "Tagged {}"))), []string(nil))
+
+	// Subtypes are found across files, and only direct subtypes are included.
+	assert.DeepEqual(t, describe(languageService.GetSubtypes(ctx, "/src/a.ts", strings.Index(a, "Animal implements"))), []string{"class Cat (Zoo)", "class Dog (b.ts)"})
+	assert.DeepEqual(t, describe(languageService.GetSubtypes(ctx, "/src/a.ts", strings.Index(a, "Tagged {}"))), []string{"interface Named (a.ts)"})
+	assert.DeepEqual(t, describe(languageService.GetSubtypes(ctx, "/src/b.ts", strings.Index(b, "Dog"))), []string(nil))
+
+	_, err = languageService.GetSubtypes(ctx, "/src/missing.ts", 0)
+	assert.ErrorIs(t, err, ls.ErrNoSourceFile)
+}
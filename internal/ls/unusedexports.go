@@ -0,0 +1,141 @@
+package ls
+
+import (
+	"context"
+	"slices"
+
+	"github.com/microsoft/typescript-go/internal/ast"
+	"github.com/microsoft/typescript-go/internal/checker"
+	"github.com/microsoft/typescript-go/internal/collections"
+	"github.com/microsoft/typescript-go/internal/core"
+)
+
+type UnusedExportInfo struct {
+	// The name the symbol is exported as.
+	Name     string             `json:"name"`
+	Kind     ScriptElementKind  `json:"kind"`
+	FileName string             `json:"fileName"`
+	Location DefinitionLocation `json:"location"`
+}
+
+// GetUnusedExports returns the exports of the modules of the program that no file of the program
+// imports. An export is used when a file imports it by name, including through `export { x } from`
+// and `export *` in another module, or imports its whole module with a namespace import,
+// `import x = require()`, an import type or a dynamic import. The exports of entryPoints are used
+// by definition, as are the names they re-export. Declaration files, default library files and
+// packages are not searched for unused exports, and modules with `export =` are skipped.
+// Exports are in the order of their files in the program, and then of their position.
+func (l *LanguageService) GetUnusedExports(ctx context.Context, entryPoints []string) ([]UnusedExportInfo, error) {
+	program := l.GetProgram()
+	c, done := program.GetTypeChecker(ctx)
+	defer done()
+
+	var used collections.Set[*ast.Symbol]
+	var usedModules collections.Set[*ast.Symbol]
+	useModule := func(moduleSymbol *ast.Symbol) {
+		if moduleSymbol == nil || !usedModules.AddIfAbsent(moduleSymbol) {
+			return
+		}
+		for _, symbol := range c.GetExportsOfModule(moduleSymbol) {
+			used.Add(c.GetMergedSymbol(symbol))
+		}
+	}
+	useImport := func(node *ast.Node) {
+		if symbol := c.GetSymbolAtLocation(node); symbol != nil && symbol.Flags&ast.SymbolFlagsAlias != 0 {
+			if target := c.GetImmediateAliasedSymbol(symbol); target != nil {
+				used.Add(c.GetMergedSymbol(target))
+			}
+		}
+	}
+
+	var modules []*ast.SourceFile
+	for _, file := range program.GetSourceFiles() {
+		if program.IsSourceFileDefaultLibrary(file.Path()) || program.IsSourceFileFromExternalLibrary(file) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if file.Symbol != nil && !file.IsDeclarationFile {
+			modules = append(modules, file)
+		}
+		for _, specifier := range file.Imports() {
+			collectModuleSpecifierUses(c, specifier, useImport, useModule)
+		}
+	}
+	for _, entryPoint := range entryPoints {
+		if file := program.GetSourceFile(entryPoint); file != nil && file.Symbol != nil {
+			useModule(c.GetMergedSymbol(file.Symbol))
+		}
+	}
+
+	result := []UnusedExportInfo{}
+	for _, file := range modules {
+		moduleSymbol := c.GetMergedSymbol(file.Symbol)
+		if moduleSymbol.Exports == nil || moduleSymbol.Exports.Get(ast.InternalSymbolNameExportEquals) != nil {
+			continue
+		}
+		var unused []UnusedExportInfo
+		for name, symbol := range moduleSymbol.Exports.Iter() {
+			if name == ast.InternalSymbolNameExportStar || used.Has(c.GetMergedSymbol(symbol)) {
+				continue
+			}
+			declaration := core.Find(symbol.Declarations, func(declaration *ast.Node) bool {
+				return ast.GetSourceFileOfNode(declaration) == file
+			})
+			if declaration == nil {
+				continue
+			}
+			unused = append(unused, UnusedExportInfo{
+				Name:     name,
+				Kind:     getNodeKind(declaration),
+				FileName: file.FileName(),
+				Location: l.newDefinitionLocation(declaration),
+			})
+		}
+		slices.SortFunc(unused, func(a, b UnusedExportInfo) int {
+			return a.Location.StartPos - b.Location.StartPos
+		})
+		result = append(result, unused...)
+	}
+	return result, nil
+}
+
+// collectModuleSpecifierUses calls useImport with each name imported by the import or export
+// declaration of a module specifier, or useModule with the module if all of its exports may be used.
+func collectModuleSpecifierUses(c *checker.Checker, specifier *ast.Node, useImport func(node *ast.Node), useModule func(moduleSymbol *ast.Symbol)) {
+	parent := specifier.Parent
+	switch {
+	case ast.IsImportDeclaration(parent) || ast.IsJSDocImportTag(parent):
+		importClause := parent.ImportClause()
+		if importClause == nil {
+			return
+		}
+		if name := importClause.Name(); name != nil {
+			useImport(name)
+		}
+		switch namedBindings := importClause.AsImportClause().NamedBindings; {
+		case namedBindings == nil:
+		case ast.IsNamespaceImport(namedBindings):
+			useModule(c.ResolveExternalModuleName(specifier))
+		default:
+			for _, element := range namedBindings.Elements() {
+				useImport(element.Name())
+			}
+		}
+	case ast.IsExportDeclaration(parent):
+		switch exportClause := parent.AsExportDeclaration().ExportClause; {
+		case exportClause == nil:
+			// The names re-exported with `export *` are used through the re-exporting module.
+		case ast.IsNamespaceExport(exportClause):
+			useModule(c.ResolveExternalModuleName(specifier))
+		default:
+			for _, element := range exportClause.Elements() {
+				useImport(element.Name())
+			}
+		}
+	default:
+		// `import x = require()`, `import()`, `require()` and import types.
+		useModule(c.ResolveExternalModuleName(specifier))
+	}
+}
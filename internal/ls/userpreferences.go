@@ -104,6 +104,12 @@ type UserPreferences struct {
 
 	AllowTextChangesInNewFiles bool // !!!
 
+	// ------- Definition -------
+
+	// Map locations in the output declaration files of project references to the source files
+	// they were built from, using their declaration maps.
+	PreferGoToSourceDefinition bool
+
 	// ------- Rename -------
 
 	// renamed from `providePrefixAndSuffixTextForRename`
@@ -199,6 +205,19 @@ const (
 	QuotePreferenceSingle  QuotePreference = "single"
 )
 
+// SetUserPreferences sets the preferences used by requests that take none of their own,
+// such as GetCompletions.
+func (l *LanguageService) SetUserPreferences(preferences *UserPreferences) {
+	l.userPreferences = preferences
+}
+
+func (l *LanguageService) getUserPreferences() *UserPreferences {
+	if l.userPreferences == nil {
+		return &UserPreferences{}
+	}
+	return l.userPreferences
+}
+
 func (p *UserPreferences) Parse(config map[string]interface{}) {
 }
 
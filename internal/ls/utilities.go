@@ -182,7 +182,9 @@ func isExportSpecifierAlias(referenceLocation *ast.Identifier, exportSpecifier *
 }
 
 func isInComment(file *ast.SourceFile, position int, tokenAtPosition *ast.Node) *ast.CommentRange {
-	return getRangeOfEnclosingComment(file, position, astnav.FindPrecedingToken(file, position), tokenAtPosition)
+	// Comments are trivia between the tokens of the file, so JSDoc tokens are excluded. A position in a
+	// comment before a JSDoc comment would otherwise have no preceding token.
+	return getRangeOfEnclosingComment(file, position, astnav.FindPrecedingTokenEx(file, position, nil /*startNode*/, true /*excludeJSDoc*/), tokenAtPosition)
 }
 
 func hasChildOfKind(containingNode *ast.Node, kind ast.Kind, sourceFile *ast.SourceFile) bool {
@@ -1706,7 +1708,8 @@ func getContainingObjectLiteralElementWorker(node *ast.Node) *ast.Node {
 		}
 		fallthrough
 	case ast.KindIdentifier:
-		if ast.IsObjectLiteralElement(node.Parent) && (node.Parent.Parent.Kind == ast.KindObjectLiteralExpression || node.Parent.Parent.Kind == ast.KindJsxAttributes) && node.Parent.Name() == node {
+		// JSX attributes are the elements of the object literal of a JSX element's props.
+		if (ast.IsObjectLiteralElement(node.Parent) || ast.IsJsxAttribute(node.Parent)) && (node.Parent.Parent.Kind == ast.KindObjectLiteralExpression || node.Parent.Parent.Kind == ast.KindJsxAttributes) && node.Parent.Name() == node {
 			return node.Parent
 		}
 	}
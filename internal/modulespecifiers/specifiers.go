@@ -482,6 +482,13 @@ func getLocalModuleSpecifier(
 		})), allowedEndings, compilerOptions, host)
 	}
 
+	if preferences.relativePreference == RelativePreferenceRelative {
+		if pathsOnly {
+			return ""
+		}
+		return relativePath
+	}
+
 	root := compilerOptions.GetPathsBasePath(host.GetCurrentDirectory())
 	baseDirectory := tspath.GetNormalizedAbsolutePath(root, host.GetCurrentDirectory())
 	relativeToBaseUrl := getRelativePathIfInSameVolume(moduleFileName, baseDirectory, host.UseCaseSensitiveFileNames())
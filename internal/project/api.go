@@ -2,8 +2,13 @@ package project
 
 import (
 	"context"
+	"maps"
 
 	"github.com/microsoft/typescript-go/internal/collections"
+	"github.com/microsoft/typescript-go/internal/core"
+	"github.com/microsoft/typescript-go/internal/ls"
+	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/tspath"
 )
 
 func (s *Session) OpenProject(ctx context.Context, configFileName string) (*Project, error) {
@@ -27,3 +32,67 @@ func (s *Session) OpenProject(ctx context.Context, configFileName string) (*Proj
 
 	return project, nil
 }
+
+// CloseProject closes a configured project opened with OpenProject, releasing its program unless
+// the project is still needed for a file open in the session. A later OpenProject of the same config
+// file loads the project again from scratch.
+func (s *Session) CloseProject(ctx context.Context, projectPath tspath.Path) {
+	fileChanges, overlays, ataChanges := s.flushChanges(ctx)
+	s.UpdateSnapshot(ctx, overlays, SnapshotChange{
+		fileChanges: fileChanges,
+		ataChanges:  ataChanges,
+		apiRequest: &APISnapshotRequest{
+			CloseProjects: collections.NewSetFromItems(projectPath),
+		},
+	})
+}
+
+// SetCompilerOptionsOverride sets compiler options to merge over the options of every configured
+// project, rebuilding the programs of the loaded ones. Passing nil clears the override.
+func (s *Session) SetCompilerOptionsOverride(ctx context.Context, options *core.CompilerOptions) {
+	fileChanges, overlays, ataChanges := s.flushChanges(ctx)
+	s.UpdateSnapshot(ctx, overlays, SnapshotChange{
+		reason:                     UpdateReasonDidChangeCompilerOptionsOverride,
+		fileChanges:                fileChanges,
+		ataChanges:                 ataChanges,
+		compilerOptionsOverride:    options,
+		setCompilerOptionsOverride: true,
+	})
+}
+
+// SpeculativeSnapshot returns a snapshot in which a file has the given content, with the program
+// of the configured project at projectPath updated accordingly. The session's own snapshot and
+// open files are unchanged, so concurrent requests do not see the content. The returned function
+// releases the snapshot and must be called once it is no longer needed.
+func (s *Session) SpeculativeSnapshot(ctx context.Context, projectPath tspath.Path, fileName string, content string) (*Snapshot, func(), error) {
+	snapshot, release := s.Snapshot()
+	defer release()
+
+	path := s.toPath(fileName)
+	overlays := maps.Clone(snapshot.fs.overlays)
+	version, kind := int32(0), core.GetScriptKindFromFileName(fileName)
+	if existing, ok := overlays[path]; ok {
+		version, kind = existing.version+1, existing.kind
+	}
+	overlays[path] = newOverlay(fileName, content, version, kind)
+
+	var changed collections.Set[lsproto.DocumentUri]
+	changed.Add(ls.FileNameToDocumentURI(fileName))
+	speculative := snapshot.Clone(ctx, SnapshotChange{
+		reason:      UpdateReasonSpeculativeFileContent,
+		fileChanges: FileChangeSummary{Changed: changed},
+		apiRequest: &APISnapshotRequest{
+			UpdateProjects: collections.NewSetFromItems(projectPath),
+		},
+	}, overlays, s)
+	releaseSpeculative := func() {
+		if speculative.Deref() {
+			speculative.dispose(s)
+		}
+	}
+	if speculative.apiError != nil {
+		releaseSpeculative()
+		return nil, nil, speculative.apiError
+	}
+	return speculative, releaseSpeculative, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"iter"
+	"slices"
 	"sync"
 
 	"github.com/microsoft/typescript-go/internal/ast"
@@ -82,8 +83,9 @@ func (p *checkerPool) GetChecker(ctx context.Context) (*checker.Checker, func())
 	return checker, p.createRelease(core.GetRequestID(ctx), index, checker)
 }
 
+// Files returns every file of the program, since GetAllCheckers only ever returns one checker.
 func (p *checkerPool) Files(checker *checker.Checker) iter.Seq[*ast.SourceFile] {
-	panic("unimplemented")
+	return slices.Values(p.program.GetSourceFiles())
 }
 
 func (p *checkerPool) GetAllCheckers(ctx context.Context) ([]*checker.Checker, func()) {
@@ -100,8 +102,8 @@ func (p *checkerPool) GetAllCheckers(ctx context.Context) ([]*checker.Checker, f
 		return []*checker.Checker{c}, release
 	}
 
-	c, release := p.GetChecker(ctx)
-	return []*checker.Checker{c}, release
+	c, index := p.getCheckerLocked(requestID)
+	return []*checker.Checker{c}, p.createRelease(requestID, index, c)
 }
 
 func (p *checkerPool) getCheckerLocked(requestID string) (*checker.Checker, int) {
@@ -107,6 +107,7 @@ func (c *configFileRegistryBuilder) reloadIfNeeded(entry *configFileEntry, fileN
 	case PendingReloadFull:
 		logger.Log("Loading config file: " + fileName)
 		entry.commandLine, _ = tsoptions.GetParsedCommandLineOfConfigFilePath(fileName, path, nil, c, c)
+		c.applySessionDefaults(entry.commandLine)
 		c.updateExtendingConfigs(path, entry.commandLine, entry.commandLine)
 		c.updateRootFilesWatch(fileName, entry)
 		logger.Log("Finished loading config file")
@@ -482,6 +483,18 @@ func (c *configFileRegistryBuilder) getAncestorConfigFileName(fileName string, p
 	return result
 }
 
+// applySessionDefaults sets the compiler options that the session provides defaults for when the
+// config file leaves them unset. Unlike existing options passed to the parser, they do not
+// override the config file.
+func (c *configFileRegistryBuilder) applySessionDefaults(commandLine *tsoptions.ParsedCommandLine) {
+	if commandLine == nil {
+		return
+	}
+	if options := commandLine.CompilerOptions(); options.NewLine == core.NewLineKindNone {
+		options.NewLine = c.sessionOptions.NewLine
+	}
+}
+
 // FS implements tsoptions.ParseConfigHost.
 func (c *configFileRegistryBuilder) FS() vfs.FS {
 	return c.fs.fs
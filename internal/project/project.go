@@ -59,8 +59,12 @@ type Project struct {
 	dirty         bool
 	dirtyFilePath tspath.Path
 
-	host                            ProjectHost
-	CommandLine                     *tsoptions.ParsedCommandLine
+	host        ProjectHost
+	CommandLine *tsoptions.ParsedCommandLine
+	// configCommandLine is the command line parsed from the config file of a configured project,
+	// which CommandLine is with compilerOptionsOverride merged over its compiler options.
+	configCommandLine               *tsoptions.ParsedCommandLine
+	compilerOptionsOverride         *core.CompilerOptions
 	commandLineWithTypingsFiles     *tsoptions.ParsedCommandLine
 	commandLineWithTypingsFilesOnce sync.Once
 	Program                         *compiler.Program
@@ -114,6 +118,7 @@ func NewInferredProject(
 			ESModuleInterop:            core.TSTrue,
 			AllowNonTsExtensions:       core.TSTrue,
 			ResolveJsonModule:          core.TSTrue,
+			NewLine:                    builder.sessionOptions.NewLine,
 		}
 	}
 	p.CommandLine = tsoptions.NewParsedCommandLine(
@@ -216,6 +221,8 @@ func (p *Project) Clone() *Project {
 
 		host:                        p.host,
 		CommandLine:                 p.CommandLine,
+		configCommandLine:           p.configCommandLine,
+		compilerOptionsOverride:     p.compilerOptionsOverride,
 		commandLineWithTypingsFiles: p.commandLineWithTypingsFiles,
 		Program:                     p.Program,
 		ProgramUpdateKind:           ProgramUpdateKindNone,
@@ -36,6 +36,7 @@ type ProjectCollectionBuilder struct {
 	fs                                 *snapshotFSBuilder
 	base                               *ProjectCollection
 	compilerOptionsForInferredProjects *core.CompilerOptions
+	compilerOptionsOverride            *core.CompilerOptions
 	configFileRegistryBuilder          *configFileRegistryBuilder
 
 	newSnapshotID           uint64
@@ -55,6 +56,7 @@ func newProjectCollectionBuilder(
 	oldConfigFileRegistry *ConfigFileRegistry,
 	oldAPIOpenedProjects map[tspath.Path]struct{},
 	compilerOptionsForInferredProjects *core.CompilerOptions,
+	compilerOptionsOverride *core.CompilerOptions,
 	sessionOptions *SessionOptions,
 	parseCache *ParseCache,
 	extendedConfigCache *extendedConfigCache,
@@ -64,6 +66,7 @@ func newProjectCollectionBuilder(
 		ctx:                                ctx,
 		fs:                                 fs,
 		compilerOptionsForInferredProjects: compilerOptionsForInferredProjects,
+		compilerOptionsOverride:            compilerOptionsOverride,
 		sessionOptions:                     sessionOptions,
 		parseCache:                         parseCache,
 		extendedConfigCache:                extendedConfigCache,
@@ -170,6 +173,17 @@ func (b *ProjectCollectionBuilder) HandleAPIRequest(apiRequest *APISnapshotReque
 	return nil
 }
 
+// DidChangeCompilerOptionsOverride updates the programs of the loaded configured projects for a
+// change of the compiler options merged over their config.
+func (b *ProjectCollectionBuilder) DidChangeCompilerOptionsOverride(logger *logging.LogTree) {
+	b.configuredProjects.Range(func(entry *dirty.SyncMapEntry[tspath.Path, *Project]) bool {
+		if entry.Value().Program != nil {
+			b.updateProgram(entry, logger)
+		}
+		return true
+	})
+}
+
 func (b *ProjectCollectionBuilder) DidChangeFiles(summary FileChangeSummary, logger *logging.LogTree) {
 	changedFiles := make([]tspath.Path, 0, len(summary.Closed)+summary.Changed.Len())
 	for uri, hash := range summary.Closed {
@@ -766,7 +780,7 @@ func (b *ProjectCollectionBuilder) updateProgram(entry dirty.Value[*Project], lo
 				entry.Value(),
 				logger.Fork("Acquiring config for project"),
 			)
-			if entry.Value().CommandLine != commandLine {
+			if entry.Value().configCommandLine != commandLine || commandLine != nil && entry.Value().compilerOptionsOverride != b.compilerOptionsOverride {
 				updateProgram = true
 				if commandLine == nil {
 					b.deleteConfiguredProject(entry, logger)
@@ -774,7 +788,9 @@ func (b *ProjectCollectionBuilder) updateProgram(entry dirty.Value[*Project], lo
 					return
 				}
 				entry.Change(func(p *Project) {
-					p.CommandLine = commandLine
+					p.configCommandLine = commandLine
+					p.compilerOptionsOverride = b.compilerOptionsOverride
+					p.CommandLine = commandLine.WithCompilerOptionsOverride(b.compilerOptionsOverride)
 					p.commandLineWithTypingsFiles = nil
 				})
 			}
@@ -785,7 +801,7 @@ func (b *ProjectCollectionBuilder) updateProgram(entry dirty.Value[*Project], lo
 		if updateProgram {
 			entry.Change(func(project *Project) {
 				oldHost := project.host
-				project.host = b.sessionOptions.MakeHost(project.currentDirectory, project, b, logger.Fork("CompilerHost"))
+				project.host = b.makeHost(project.currentDirectory, project, b, logger.Fork("CompilerHost"))
 				result := project.CreateProgram()
 				project.Program = result.Program
 				project.checkerPool = result.CheckerPool
@@ -15,6 +15,7 @@ import (
 	"github.com/microsoft/typescript-go/internal/core"
 	"github.com/microsoft/typescript-go/internal/ls"
 	"github.com/microsoft/typescript-go/internal/lsp/lsproto"
+	"github.com/microsoft/typescript-go/internal/parser"
 	"github.com/microsoft/typescript-go/internal/project/ata"
 	"github.com/microsoft/typescript-go/internal/project/background"
 	"github.com/microsoft/typescript-go/internal/project/logging"
@@ -31,6 +32,8 @@ const (
 	UpdateReasonRequestedLanguageServicePendingChanges
 	UpdateReasonRequestedLanguageServiceProjectNotLoaded
 	UpdateReasonRequestedLanguageServiceProjectDirty
+	UpdateReasonSpeculativeFileContent
+	UpdateReasonDidChangeCompilerOptionsOverride
 )
 
 // SessionOptions are the immutable initialization options for a session.
@@ -40,10 +43,13 @@ type SessionOptions struct {
 	DefaultLibraryPath string
 	TypingsLocation    string
 	PositionEncoding   lsproto.PositionEncodingKind
-	WatchEnabled       bool
-	LoggingEnabled     bool
-	DebounceDelay      time.Duration
-	MakeHost           func(currentDirectory string, project *Project, builder *ProjectCollectionBuilder, logger *logging.LogTree) ProjectHost
+	// NewLine is the line ending of emitted files and of the text of edits in projects whose compiler
+	// options do not set newLine. Defaults to "\n".
+	NewLine        core.NewLineKind
+	WatchEnabled   bool
+	LoggingEnabled bool
+	DebounceDelay  time.Duration
+	MakeHost       func(currentDirectory string, project *Project, builder *ProjectCollectionBuilder, logger *logging.LogTree) ProjectHost
 }
 
 type SessionInit struct {
@@ -154,6 +160,9 @@ func NewSession(init *SessionInit) *Session {
 		pendingATAChanges: make(map[tspath.Path]*ATAStateChange),
 		makeHost:          init.Options.MakeHost,
 	}
+	if session.makeHost == nil {
+		session.makeHost = NewProjectHost
+	}
 
 	if init.Options.TypingsLocation != "" && init.NpmExecutor != nil {
 		session.typingsInstaller = ata.NewTypingsInstaller(&ata.TypingsInstallerOptions{
@@ -374,6 +383,54 @@ func (s *Session) GetLanguageService(ctx context.Context, uri lsproto.DocumentUr
 	return ls.NewLanguageService(project.GetProgram(), snapshot), nil
 }
 
+// GetBindOnlyLanguageService returns a language service for the structural features of a file,
+// which only need the file parsed and bound. Pending file changes are applied, but unlike
+// GetLanguageService, no project is loaded and no program is updated: the file is taken from the
+// program of its default project if that program is up to date, and is parsed on its own otherwise.
+func (s *Session) GetBindOnlyLanguageService(ctx context.Context, uri lsproto.DocumentUri) (*ls.LanguageService, error) {
+	var snapshot *Snapshot
+	fileChanges, overlays, ataChanges := s.flushChanges(ctx)
+	if !fileChanges.IsEmpty() || len(ataChanges) > 0 {
+		snapshot = s.UpdateSnapshot(ctx, overlays, SnapshotChange{
+			reason:      UpdateReasonRequestedLanguageServicePendingChanges,
+			fileChanges: fileChanges,
+			ataChanges:  ataChanges,
+		})
+	} else {
+		s.snapshotMu.RLock()
+		snapshot = s.snapshot
+		s.snapshotMu.RUnlock()
+	}
+
+	fileName := uri.FileName()
+	options := snapshot.compilerOptionsForInferredProjects
+	if project := snapshot.GetDefaultProject(uri); project != nil {
+		if program := project.GetProgram(); program != nil && !project.dirty {
+			if file := program.GetSourceFile(fileName); file != nil {
+				return ls.NewBindOnlyLanguageService(file, snapshot), nil
+			}
+		}
+		if project.CommandLine != nil {
+			options = project.CommandLine.CompilerOptions()
+		}
+	}
+	fh := snapshot.GetFile(fileName)
+	if fh == nil {
+		return nil, fmt.Errorf("file not found: %s", fileName)
+	}
+	if options == nil {
+		options = &core.CompilerOptions{}
+	}
+	file := parser.ParseSourceFile(ast.SourceFileParseOptions{
+		FileName:                       fileName,
+		Path:                           snapshot.toPath(fileName),
+		CompilerOptions:                ast.GetSourceFileAffectingCompilerOptions(fileName, options),
+		ExternalModuleIndicatorOptions: ast.GetExternalModuleIndicatorOptions(fileName, options, ast.SourceFileMetaData{}),
+		JSDocParsingMode:               ast.JSDocParsingModeParseAll,
+	}, fh.Content(), fh.Kind())
+	return ls.NewBindOnlyLanguageService(file, snapshot), nil
+}
+
 func (s *Session) UpdateSnapshot(ctx context.Context, overlays map[tspath.Path]*overlay, change SnapshotChange) *Snapshot {
 	s.snapshotMu.Lock()
 	oldSnapshot := s.snapshot
@@ -3,6 +3,8 @@ package project
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 	"sync/atomic"
 	"time"
 
@@ -33,6 +35,8 @@ type Snapshot struct {
 	ProjectCollection                  *ProjectCollection
 	ConfigFileRegistry                 *ConfigFileRegistry
 	compilerOptionsForInferredProjects *core.CompilerOptions
+	// compilerOptionsOverride is merged over the compiler options of configured projects.
+	compilerOptionsOverride *core.CompilerOptions
 
 	builderLogs *logging.LogTree
 	apiError    error
@@ -126,6 +130,10 @@ type SnapshotChange struct {
 	// It should only be set the value in the next snapshot should be changed. If nil, the
 	// value from the previous snapshot will be copied to the new snapshot.
 	compilerOptionsForInferredProjects *core.CompilerOptions
+	// compilerOptionsOverride replaces the compiler options merged over the options of configured
+	// projects if setCompilerOptionsOverride is true. A nil value clears the override.
+	compilerOptionsOverride    *core.CompilerOptions
+	setCompilerOptionsOverride bool
 	// ataChanges contains ATA-related changes to apply to projects in the new snapshot.
 	ataChanges map[tspath.Path]*ATAStateChange
 	apiRequest *APISnapshotRequest
@@ -163,12 +171,16 @@ func (s *Snapshot) Clone(ctx context.Context, change SnapshotChange, overlays ma
 			logger.Logf("Reason: DidOpenFile - %s", change.fileChanges.Opened)
 		case UpdateReasonDidChangeCompilerOptionsForInferredProjects:
 			logger.Logf("Reason: DidChangeCompilerOptionsForInferredProjects")
+		case UpdateReasonDidChangeCompilerOptionsOverride:
+			logger.Logf("Reason: DidChangeCompilerOptionsOverride")
 		case UpdateReasonRequestedLanguageServicePendingChanges:
 			logger.Logf("Reason: RequestedLanguageService (pending file changes) - %v", change.requestedURIs)
 		case UpdateReasonRequestedLanguageServiceProjectNotLoaded:
 			logger.Logf("Reason: RequestedLanguageService (project not loaded) - %v", change.requestedURIs)
 		case UpdateReasonRequestedLanguageServiceProjectDirty:
 			logger.Logf("Reason: RequestedLanguageService (project dirty) - %v", change.requestedURIs)
+		case UpdateReasonSpeculativeFileContent:
+			logger.Logf("Reason: SpeculativeFileContent - %v", slices.Collect(maps.Keys(change.fileChanges.Changed.Keys())))
 		}
 	}
 
@@ -182,6 +194,11 @@ func (s *Snapshot) Clone(ctx context.Context, change SnapshotChange, overlays ma
 		compilerOptionsForInferredProjects = change.compilerOptionsForInferredProjects
 	}
 
+	compilerOptionsOverride := s.compilerOptionsOverride
+	if change.setCompilerOptionsOverride {
+		compilerOptionsOverride = change.compilerOptionsOverride
+	}
+
 	newSnapshotID := session.snapshotID.Add(1)
 	projectCollectionBuilder := newProjectCollectionBuilder(
 		ctx,
@@ -191,17 +208,13 @@ func (s *Snapshot) Clone(ctx context.Context, change SnapshotChange, overlays ma
 		s.ConfigFileRegistry,
 		s.ProjectCollection.apiOpenedProjects,
 		compilerOptionsForInferredProjects,
+		compilerOptionsOverride,
 		s.sessionOptions,
 		session.parseCache,
 		session.extendedConfigCache,
 		session.makeHost,
 	)
 
-	var apiError error
-	if change.apiRequest != nil {
-		apiError = projectCollectionBuilder.HandleAPIRequest(change.apiRequest, logger.Fork("HandleAPIRequest"))
-	}
-
 	if len(change.ataChanges) != 0 {
 		projectCollectionBuilder.DidUpdateATAState(change.ataChanges, logger.Fork("DidUpdateATAState"))
 	}
@@ -210,6 +223,17 @@ func (s *Snapshot) Clone(ctx context.Context, change SnapshotChange, overlays ma
 		projectCollectionBuilder.DidChangeFiles(change.fileChanges, logger.Fork("DidChangeFiles"))
 	}
 
+	if change.setCompilerOptionsOverride {
+		projectCollectionBuilder.DidChangeCompilerOptionsOverride(logger.Fork("DidChangeCompilerOptionsOverride"))
+	}
+
+	// API requests are handled after file changes, so that the programs of the projects they
+	// open or update include the changes.
+	var apiError error
+	if change.apiRequest != nil {
+		apiError = projectCollectionBuilder.HandleAPIRequest(change.apiRequest, logger.Fork("HandleAPIRequest"))
+	}
+
 	for _, uri := range change.requestedURIs {
 		projectCollectionBuilder.DidRequestFile(uri, logger.Fork("DidRequestFile"))
 	}
@@ -217,16 +241,20 @@ func (s *Snapshot) Clone(ctx context.Context, change SnapshotChange, overlays ma
 	projectCollection, configFileRegistry := projectCollectionBuilder.Finalize(logger)
 
 	// Clean cached disk files not touched by any open project. It's not important that we do this on
-	// file open specifically, but we don't need to do it on every snapshot clone.
-	if len(change.fileChanges.Opened) != 0 {
-		var changedFiles bool
+	// file open specifically, but we don't need to do it on every snapshot clone. Closing a project
+	// through the API also cleans them, to free their memory and so that a later load of the project
+	// reads them again.
+	closedProjects := change.apiRequest != nil && change.apiRequest.CloseProjects != nil
+	if len(change.fileChanges.Opened) != 0 || closedProjects {
+		changedFiles := closedProjects
 		for _, project := range projectCollection.Projects() {
 			if project.ProgramLastUpdate == newSnapshotID && project.ProgramUpdateKind != ProgramUpdateKindCloned {
 				changedFiles = true
 				break
 			}
 		}
-		// The set of seen files can change only if a program was constructed (not cloned) during this snapshot.
+		// The set of seen files can change only if a program was constructed (not cloned) during this
+		// snapshot, or if a project was closed.
 		if changedFiles {
 			cleanFilesStart := time.Now()
 			removedFiles := 0
@@ -258,6 +286,7 @@ func (s *Snapshot) Clone(ctx context.Context, change SnapshotChange, overlays ma
 		s.toPath,
 	)
 	newSnapshot.parentId = s.id
+	newSnapshot.compilerOptionsOverride = compilerOptionsOverride
 	newSnapshot.ProjectCollection = projectCollection
 	newSnapshot.ConfigFileRegistry = configFileRegistry
 	newSnapshot.builderLogs = logger
@@ -49,8 +49,9 @@ func (s *SnapshotFS) GetFile(fileName string) FileHandle {
 		}
 		return nil
 	}))
-	if entry, ok := s.readFiles.LoadOrStore(s.toPath(fileName), newEntry); ok {
-		return entry()
+	entry, _ := s.readFiles.LoadOrStore(s.toPath(fileName), newEntry)
+	if file := entry(); file != nil {
+		return file
 	}
 	return nil
 }
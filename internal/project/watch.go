@@ -434,3 +434,46 @@ func extractLookups[T resolutionWithLookupLocations](
 		}
 	}
 }
+
+// WatchHints returns the files and directory glob patterns the program of the project depends on,
+// for clients that watch the file system themselves. The files are the config file and the configs
+// it extends, the files of the program, and the files affecting module resolution, such as
+// package.json files. The globs match the directories of the include patterns of the config file,
+// and the directories of failed module lookups, where a new file could change how a module
+// resolves. Files that are not on disk, like bundled lib files, are left out. Both lists are
+// sorted, so clients can diff them after each program update to add and remove watchers.
+func (p *Project) WatchHints() (files []string, globs []string) {
+	fileSet := make(map[tspath.Path]string)
+	addFile := func(fileName string) {
+		if !tspath.IsUrl(fileName) {
+			fileSet[p.toPath(fileName)] = fileName
+		}
+	}
+	if p.Kind == KindConfigured {
+		addFile(p.configFileName)
+	}
+	if p.CommandLine != nil {
+		for _, extendedConfig := range p.CommandLine.ExtendedSourceFiles() {
+			addFile(extendedConfig)
+		}
+		for dir, recursive := range p.CommandLine.WildcardDirectories() {
+			globs = append(globs, fmt.Sprintf("%s/%s", tspath.NormalizePath(dir), core.IfElse(recursive, recursiveFileGlobPattern, fileGlobPattern)))
+		}
+	}
+	if p.Program != nil {
+		for _, file := range p.Program.GetSourceFiles() {
+			addFile(file.FileName())
+		}
+		failedLookups := make(map[tspath.Path]string)
+		affectingLocations := make(map[tspath.Path]string)
+		extractLookups(p.toPath, failedLookups, affectingLocations, p.Program.GetResolvedModules())
+		extractLookups(p.toPath, failedLookups, affectingLocations, p.Program.GetResolvedTypeReferenceDirectives())
+		for _, affectingLocation := range affectingLocations {
+			addFile(affectingLocation)
+		}
+		globs = append(globs, createResolutionLookupGlobMapper(p.currentDirectory, p.host.FS().UseCaseSensitiveFileNames())(failedLookups)...)
+	}
+	files = slices.Sorted(maps.Values(fileSet))
+	slices.Sort(globs)
+	return files, slices.Compact(globs)
+}
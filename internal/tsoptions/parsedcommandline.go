@@ -345,6 +345,26 @@ func (p *ParsedCommandLine) GetMatchedIncludeSpec(fileName string) (string, bool
 	return p.ConfigFile.configFileSpecs.getMatchedIncludeSpec(fileName, p.comparePathsOptions), false
 }
 
+// WithCompilerOptionsOverride returns a copy of the command line whose compiler options are the
+// options of p with the options set in override merged over them. It returns p if override is nil.
+func (p *ParsedCommandLine) WithCompilerOptionsOverride(override *core.CompilerOptions) *ParsedCommandLine {
+	if override == nil {
+		return p
+	}
+	parsedConfig := *p.ParsedConfig
+	parsedConfig.CompilerOptions = mergeCompilerOptions(p.CompilerOptions().Clone(), override, nil)
+	return &ParsedCommandLine{
+		ParsedConfig:        &parsedConfig,
+		ConfigFile:          p.ConfigFile,
+		Errors:              p.Errors,
+		Raw:                 p.Raw,
+		CompileOnSave:       p.CompileOnSave,
+		comparePathsOptions: p.comparePathsOptions,
+		extraFileExtensions: p.extraFileExtensions,
+		literalFileNamesLen: p.literalFileNamesLen,
+	}
+}
+
 func (p *ParsedCommandLine) ReloadFileNamesOfParsedCommandLine(fs vfs.FS) *ParsedCommandLine {
 	parsedConfig := *p.ParsedConfig
 	fileNames, literalFileNamesLen := getFileNamesFromConfigSpecs(
@@ -603,8 +603,8 @@ func convertOptionsFromJson[O optionParser](optionsNameMap CommandLineOptionName
 		}
 
 		commandLineOptionEnumMapVal := opt.EnumMap()
-		if commandLineOptionEnumMapVal != nil {
-			val, ok := commandLineOptionEnumMapVal.Get(strings.ToLower(value.(string)))
+		if str, isString := value.(string); isString && commandLineOptionEnumMapVal != nil {
+			val, ok := commandLineOptionEnumMapVal.Get(strings.ToLower(str))
 			if ok {
 				errors = result.ParseOption(key, val)
 			}
@@ -874,6 +874,30 @@ func convertCompilerOptionsFromJsonWorker(jsonOptions any, basePath string, conf
 	return options, errors
 }
 
+// ParseCompilerOptionsFromJsonText parses a JSON object of compiler options, as in the
+// compilerOptions property of a tsconfig.json, to compiler options in which only the options
+// present in the object are set. Relative paths are resolved against basePath.
+func ParseCompilerOptionsFromJsonText(jsonText string, basePath string) (*core.CompilerOptions, []*ast.Diagnostic) {
+	fileName := tspath.CombinePaths(basePath, "compilerOptions.json")
+	jsonSourceFile := parser.ParseSourceFile(ast.SourceFileParseOptions{
+		FileName: fileName,
+		Path:     tspath.Path(fileName),
+	}, jsonText, core.ScriptKindJSON)
+	if len(jsonSourceFile.Diagnostics()) > 0 {
+		return nil, []*ast.Diagnostic{jsonSourceFile.Diagnostics()[0]}
+	}
+	jsonOptions, errors := convertToObject(jsonSourceFile)
+	if len(errors) > 0 {
+		return nil, errors
+	}
+	if _, ok := jsonOptions.(*collections.OrderedMap[string, any]); !ok {
+		return nil, []*ast.Diagnostic{ast.NewCompilerDiagnostic(diagnostics.Compiler_option_0_requires_a_value_of_type_1, "compilerOptions", "object")}
+	}
+	options := &core.CompilerOptions{}
+	_, errors = convertOptionsFromJson(CommandLineCompilerOptionsMap, jsonOptions, basePath, &compilerOptionsParser{options})
+	return options, errors
+}
+
 func convertTypeAcquisitionFromJsonWorker(jsonOptions any, basePath string, configFileName string) (*core.TypeAcquisition, []*ast.Diagnostic) {
 	options := getDefaultTypeAcquisition(configFileName)
 	_, errors := convertOptionsFromJson(typeAcquisitionDeclaration.ElementOptions, jsonOptions, basePath, &typeAcquisitionParser{options})
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"io/fs"
 	"strings"
+	"sync"
 	"unicode/utf16"
 	"unsafe"
 
+	"github.com/microsoft/typescript-go/internal/stringutil"
 	"github.com/microsoft/typescript-go/internal/tspath"
 	"github.com/microsoft/typescript-go/internal/vfs"
 )
@@ -15,6 +17,10 @@ import (
 type Common struct {
 	RootFor  func(root string) fs.FS
 	Realpath func(path string) string
+
+	// utf16Files holds the byte order of the files that were UTF-16 when last read, keyed by path,
+	// so that writing them back with a byte order mark keeps their encoding.
+	utf16Files sync.Map // map[string]binary.ByteOrder
 }
 
 func RootLength(p string) int {
@@ -159,25 +165,43 @@ func (vfs *Common) ReadFile(path string) (contents string, ok bool) {
 
 	s := unsafe.String(&b[0], len(b))
 
-	return decodeBytes(s)
+	contents, order := decodeBytes(s)
+	if order != nil {
+		vfs.utf16Files.Store(path, order)
+	} else if _, ok := vfs.utf16Files.Load(path); ok {
+		vfs.utf16Files.Delete(path)
+	}
+	return contents, true
+}
+
+// AddByteOrderMark returns content with a byte order mark for writing to path. If the file was
+// UTF-16 when last read, content is encoded as UTF-16 with the same byte order; otherwise it is
+// left as UTF-8.
+func (vfs *Common) AddByteOrderMark(path string, content string) string {
+	if order, ok := vfs.utf16Files.Load(path); ok {
+		return encodeUtf16(content, order.(binary.ByteOrder))
+	}
+	return stringutil.AddUTF8ByteOrderMark(content)
 }
 
-func decodeBytes(s string) (contents string, ok bool) {
+// decodeBytes decodes the contents of a file to UTF-8, removing any byte order mark. The byte order
+// is returned if the file is UTF-16, and nil otherwise.
+func decodeBytes(s string) (contents string, order binary.ByteOrder) {
 	var bom [2]byte
 	if len(s) >= 2 {
 		bom = [2]byte{s[0], s[1]}
 		switch bom {
 		case [2]byte{0xFF, 0xFE}:
-			return decodeUtf16(s[2:], binary.LittleEndian), true
+			return decodeUtf16(s[2:], binary.LittleEndian), binary.LittleEndian
 		case [2]byte{0xFE, 0xFF}:
-			return decodeUtf16(s[2:], binary.BigEndian), true
+			return decodeUtf16(s[2:], binary.BigEndian), binary.BigEndian
 		}
 	}
 	if len(s) >= 3 && s[0] == 0xEF && s[1] == 0xBB && s[2] == 0xBF {
 		s = s[3:]
 	}
 
-	return s, true
+	return s, nil
 }
 
 func decodeUtf16(s string, order binary.ByteOrder) string {
@@ -187,3 +211,11 @@ func decodeUtf16(s string, order binary.ByteOrder) string {
 	}
 	return string(utf16.Decode(ints))
 }
+
+func encodeUtf16(s string, order binary.ByteOrder) string {
+	b, err := binary.Append(make([]byte, 0, 2*len(s)+2), order, append([]uint16{0xFEFF}, utf16.Encode([]rune(s))...))
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
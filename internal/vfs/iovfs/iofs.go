@@ -193,6 +193,10 @@ func (vfs *ioFS) Realpath(path string) string {
 
 func (vfs *ioFS) WriteFile(path string, content string, writeByteOrderMark bool) error {
 	_ = internal.RootLength(path) // Assert path is rooted
+	if writeByteOrderMark {
+		// The byte order mark is added here so that files read as UTF-16 are written back as UTF-16.
+		content = vfs.common.AddByteOrderMark(path, content)
+	}
 	if err := vfs.writeFile(path, content, writeByteOrderMark); err == nil {
 		return nil
 	}
@@ -116,11 +116,16 @@ func osFSRealpath(path string) string {
 
 	orig := path
 	path = filepath.FromSlash(path)
-	path, err := realpath(path)
+	resolved, err := realpath(path)
 	if err != nil {
-		return orig
+		// A symlink cycle resolves to the path where it begins rather than failing altogether.
+		cycleStart, ok := symlinkCycleStart(path)
+		if !ok {
+			return orig
+		}
+		resolved = cycleStart
 	}
-	path, err = filepath.Abs(path)
+	path, err = filepath.Abs(resolved)
 	if err != nil {
 		return orig
 	}
@@ -140,9 +145,7 @@ func (vfs *osFS) writeFile(path string, content string, writeByteOrderMark bool)
 	defer file.Close()
 
 	if writeByteOrderMark {
-		if _, err := file.WriteString("\uFEFF"); err != nil {
-			return err
-		}
+		content = vfs.common.AddByteOrderMark(path, content)
 	}
 
 	if _, err := file.WriteString(content); err != nil {
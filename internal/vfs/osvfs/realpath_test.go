@@ -35,6 +35,27 @@ func TestSymlinkRealpath(t *testing.T) {
 	}
 }
 
+func TestSymlinkCycleRealpath(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("file symlinks need elevation or developer mode on Windows")
+	}
+
+	tmp, err := filepath.EvalSymlinks(t.TempDir())
+	assert.NilError(t, err)
+	a := filepath.Join(tmp, "a")
+	b := filepath.Join(tmp, "b")
+	x := filepath.Join(tmp, "x")
+	assert.NilError(t, os.Symlink(b, a))
+	assert.NilError(t, os.Symlink(a, b))
+	assert.NilError(t, os.Symlink(a, x))
+
+	fs := FS()
+	assert.Equal(t, fs.Realpath(tspath.NormalizePath(a)), tspath.NormalizePath(a))
+	assert.Equal(t, fs.Realpath(tspath.NormalizePath(x)), tspath.NormalizePath(a))
+	assert.Equal(t, fs.Realpath(tspath.NormalizePath(filepath.Join(x, "file"))), tspath.NormalizePath(filepath.Join(a, "file")))
+}
+
 func setupSymlinks(tb testing.TB) (targetFile, linkFile string) {
 	tb.Helper()
 
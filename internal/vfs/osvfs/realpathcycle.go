@@ -0,0 +1,61 @@
+package osvfs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxSymlinks is the number of symlinks followed before giving up on a path that keeps growing,
+// matching the limit of filepath.EvalSymlinks.
+const maxSymlinks = 255
+
+// symlinkCycleStart follows the symlinks of path one at a time, and if they lead back to a path
+// already followed, as with a -> b -> a, returns that path: the last path reached before the
+// links start repeating. It reports false if following the links ends anywhere else.
+func symlinkCycleStart(path string) (string, bool) {
+	visited := make(map[string]struct{})
+	for range maxSymlinks {
+		if _, ok := visited[path]; ok {
+			return path, true
+		}
+		visited[path] = struct{}{}
+		next, ok := followFirstSymlink(path)
+		if !ok {
+			return "", false
+		}
+		path = next
+	}
+	return "", false
+}
+
+// followFirstSymlink replaces the first component of path that is a symlink with its target.
+func followFirstSymlink(path string) (string, bool) {
+	volume := filepath.VolumeName(path)
+	rest := path[len(volume):]
+	prefix := volume
+	for rest != "" {
+		var component string
+		component, rest, _ = strings.Cut(strings.TrimLeft(rest, string(filepath.Separator)), string(filepath.Separator))
+		if component == "" {
+			break
+		}
+		prefix += string(filepath.Separator) + component
+		info, err := os.Lstat(prefix)
+		if err != nil {
+			return "", false
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+		target, err := os.Readlink(prefix)
+		if err != nil {
+			return "", false
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(prefix), target)
+		}
+		return filepath.Join(target, rest), true
+	}
+	return "", false
+}
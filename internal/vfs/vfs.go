@@ -46,9 +46,46 @@ type FS interface {
 	Realpath(path string) string
 }
 
+// RangeReader is implemented by file systems that can read part of a file without reading all of it.
+type RangeReader interface {
+	// ReadFileRange reads up to length bytes of the file specified by path, starting at byte offset start.
+	// If the file fails to be read, ok will be false.
+	ReadFileRange(path string, start int, length int) (contents string, ok bool)
+}
+
+// ReadFileRange reads up to length bytes of a file starting at byte offset start, using
+// [RangeReader] if fs implements it, and slicing the result of ReadFile otherwise.
+func ReadFileRange(fs FS, path string, start int, length int) (contents string, ok bool) {
+	if rangeReader, ok := fs.(RangeReader); ok {
+		return rangeReader.ReadFileRange(path, start, length)
+	}
+	contents, ok = fs.ReadFile(path)
+	if !ok {
+		return "", false
+	}
+	return SliceContents(contents, start, length), true
+}
+
+// SliceContents returns up to length bytes of contents starting at byte offset start,
+// clamped to the bounds of contents.
+func SliceContents(contents string, start int, length int) string {
+	start = min(max(start, 0), len(contents))
+	end := min(start+max(length, 0), len(contents))
+	return contents[start:end]
+}
+
 type Entries struct {
 	Files       []string
 	Directories []string
+	// Stats holds the type and modification time of each entry, keyed by entry name, when the
+	// file system reports them along with the listing. It is nil otherwise.
+	Stats map[string]EntryStat
+}
+
+// EntryStat is the stat information of a directory entry, with symlinks already followed.
+type EntryStat struct {
+	IsDir   bool
+	ModTime time.Time
 }
 
 type (
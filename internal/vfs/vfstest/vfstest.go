@@ -240,8 +240,14 @@ func Symlink(target string) *fstest.MapFile {
 	}
 }
 
+// maxSymlinks is the number of symlinks that may be followed while resolving a single path,
+// matching the limit after which Linux (and so Node) fails with ELOOP.
+const maxSymlinks = 40
+
+var errSymlinkLoop = errors.New("too many levels of symbolic links")
+
 func (m *MapFS) getFollowingSymlinks(p canonicalPath) (*fstest.MapFile, canonicalPath, error) {
-	return m.getFollowingSymlinksWorker(p, "", "")
+	return m.getFollowingSymlinksWorker(p, "", "", make(map[canonicalPath]struct{}))
 }
 
 type brokenSymlinkError struct {
@@ -252,19 +258,43 @@ func (e *brokenSymlinkError) Error() string {
 	return fmt.Sprintf("broken symlink %q -> %q", e.from, e.to)
 }
 
-func (m *MapFS) getFollowingSymlinksWorker(p canonicalPath, symlinkFrom, symlinkTo canonicalPath) (*fstest.MapFile, canonicalPath, error) {
+// symlinkCycleError reports that following symlinks led back to start, a path already followed,
+// as with a -> b -> a.
+type symlinkCycleError struct {
+	start canonicalPath
+}
+
+func (e *symlinkCycleError) Error() string {
+	return fmt.Sprintf("%q: %s", e.start, errSymlinkLoop)
+}
+
+func (e *symlinkCycleError) Unwrap() error {
+	return errSymlinkLoop
+}
+
+func (m *MapFS) getFollowingSymlinksWorker(p canonicalPath, symlinkFrom, symlinkTo canonicalPath, visited map[canonicalPath]struct{}) (*fstest.MapFile, canonicalPath, error) {
 	if file, ok := m.m[string(p)]; ok && file.Mode&fs.ModeSymlink == 0 {
 		return file, p, nil
 	}
 
+	if _, ok := visited[p]; ok {
+		return nil, p, &symlinkCycleError{start: p}
+	}
+	if len(visited) >= maxSymlinks {
+		// A chain too long to be worth following, such as a directory linking to itself
+		// followed through ever longer paths.
+		return nil, p, fmt.Errorf("%q: %w", p, errSymlinkLoop)
+	}
+	visited[p] = struct{}{}
+
 	if target, ok := m.symlinks[p]; ok {
-		return m.getFollowingSymlinksWorker(target, p, target)
+		return m.getFollowingSymlinksWorker(target, p, target, visited)
 	}
 
 	// This could be a path underneath a symlinked directory.
 	for other, target := range m.symlinks {
 		if len(other) < len(p) && other == p[:len(other)] && p[len(other)] == '/' {
-			return m.getFollowingSymlinksWorker(target+p[len(other):], other, target)
+			return m.getFollowingSymlinksWorker(target+p[len(other):], other, target, visited)
 		}
 	}
 
@@ -275,6 +305,20 @@ func (m *MapFS) getFollowingSymlinksWorker(p canonicalPath, symlinkFrom, symlink
 	return nil, p, err
 }
 
+// realpathOf returns p with its longest existing prefix replaced by that entry's realpath.
+func (m *MapFS) realpathOf(p canonicalPath) string {
+	for prefix := string(p); ; {
+		if file, ok := m.m[prefix]; ok {
+			return file.Sys.(*sys).realpath + string(p[len(prefix):])
+		}
+		i := strings.LastIndexByte(prefix, '/')
+		if i <= 0 {
+			return string(p)
+		}
+		prefix = prefix[:i]
+	}
+}
+
 func (m *MapFS) set(p canonicalPath, file *fstest.MapFile) {
 	m.m[string(p)] = file
 }
@@ -423,7 +467,10 @@ func (m *MapFS) Open(name string) (fs.File, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	_, cp, _ := m.getFollowingSymlinks(m.getCanonicalPath(name))
+	_, cp, err := m.getFollowingSymlinks(m.getCanonicalPath(name))
+	if errors.Is(err, errSymlinkLoop) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
 	f, err := m.open(cp)
 	if err != nil {
 		return nil, err
@@ -467,6 +514,12 @@ func (m *MapFS) Realpath(name string) (string, error) {
 
 	file, _, err := m.getFollowingSymlinks(m.getCanonicalPath(name))
 	if err != nil {
+		// A symlink cycle resolves to the path where it begins, which is the last path reached
+		// before the links start repeating, rather than failing resolution altogether.
+		var cycle *symlinkCycleError
+		if errors.As(err, &cycle) {
+			return m.realpathOf(cycle.start), nil
+		}
 		return "", err
 	}
 	return file.Sys.(*sys).realpath, nil
@@ -510,6 +563,9 @@ func (m *MapFS) WriteFile(path string, data []byte, perm fs.FileMode) error {
 
 	file, cp, err := m.getFollowingSymlinks(m.getCanonicalPath(path))
 	if err != nil {
+		if errors.Is(err, errSymlinkLoop) {
+			return fmt.Errorf("write %q: %w", path, err)
+		}
 		var brokenSymlinkError *brokenSymlinkError
 		if !errors.Is(err, fs.ErrNotExist) && !errors.As(err, &brokenSymlinkError) {
 			// No other errors are possible.
@@ -6,6 +6,7 @@ import (
 	"math/rand/v2"
 	"runtime"
 	"slices"
+	"strings"
 	"sync"
 	"testing"
 	"testing/fstest"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/microsoft/typescript-go/internal/testutil"
 	"github.com/microsoft/typescript-go/internal/vfs"
+	"github.com/microsoft/typescript-go/internal/vfs/iovfs"
 	"gotest.tools/v3/assert"
 )
 
@@ -527,6 +529,13 @@ func TestBOM(t *testing.T) {
 			content, ok := fs.ReadFile("/foo.ts")
 			assert.Assert(t, ok)
 			assert.Equal(t, content, expected)
+
+			// Writing the file back with a byte order mark keeps its encoding.
+			assert.NilError(t, fs.WriteFile("/foo.ts", content, true))
+			assert.DeepEqual(t, readRawFile(t, fs, "foo.ts"), buf)
+
+			assert.NilError(t, fs.WriteFile("/bar.ts", content, true))
+			assert.Equal(t, string(readRawFile(t, fs, "bar.ts")), "\xEF\xBB\xBF"+expected)
 		})
 	}
 
@@ -543,6 +552,14 @@ func TestBOM(t *testing.T) {
 	})
 }
 
+// readRawFile reads the bytes of a file of a file system created by FromMap, without decoding them.
+func readRawFile(t *testing.T, fsys vfs.FS, name string) []byte {
+	t.Helper()
+	b, err := fs.ReadFile(fsys.(iovfs.FsWithSys).FSys(), name)
+	assert.NilError(t, err)
+	return b
+}
+
 func TestSymlink(t *testing.T) {
 	t.Parallel()
 
@@ -605,6 +622,38 @@ func TestSymlink(t *testing.T) {
 	})
 }
 
+func TestSymlinkLoop(t *testing.T) {
+	t.Parallel()
+
+	fs := FromMap(map[string]any{
+		"/a":          Symlink("/b"),
+		"/b":          Symlink("/a"),
+		"/x":          Symlink("/a"),
+		"/dir/link":   Symlink("/dir"),
+		"/dir/foo.ts": "hello, world",
+	}, false)
+
+	// A path in the cycle resolves to itself.
+	assert.Equal(t, fs.Realpath("/a"), "/a")
+	assert.Equal(t, fs.Realpath("/b"), "/b")
+	assert.Equal(t, fs.Realpath("/a/foo.ts"), "/a/foo.ts")
+	// A path leading into the cycle resolves to where the cycle begins.
+	assert.Equal(t, fs.Realpath("/x"), "/a")
+	assert.Equal(t, fs.Realpath("/x/foo.ts"), "/a/foo.ts")
+	assert.Assert(t, !fs.FileExists("/a"))
+	assert.Assert(t, !fs.DirectoryExists("/a"))
+	_, ok := fs.ReadFile("/a/foo.ts")
+	assert.Assert(t, !ok)
+
+	err := fs.WriteFile("/a/foo.ts", "hello, world", false)
+	assert.ErrorContains(t, err, "too many levels of symbolic links")
+
+	// A directory linking to itself is not a loop until the chain exceeds the limit.
+	assert.Equal(t, fs.Realpath("/dir/link/link/foo.ts"), "/dir/foo.ts")
+	tooDeep := "/dir" + strings.Repeat("/link", 41) + "/foo.ts"
+	assert.Equal(t, fs.Realpath(tooDeep), tooDeep)
+}
+
 func TestWritableFSSymlink(t *testing.T) {
 	t.Parallel()
 